@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+var errPlanChanged = errors.New("plan changed since the state")
+
+// fingerprintCommand returns the `quark fingerprint` command.
+func fingerprintCommand() *cli.Command {
+	return &cli.Command{
+		Name: "fingerprint",
+		Usage: "Print the fingerprint of a plan, or check it against the state of the last run " +
+			"(without executing it)",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "plan",
+				Aliases:  []string{"p"},
+				Usage:    "Path to plan file (Go program, or YAML/JSON plan document)",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "state",
+				Usage: "Fail unless the plan fingerprint matches this plan state (execute --state)",
+			},
+		},
+		Action: fingerprintCommandAction,
+	}
+}
+
+func fingerprintCommandAction(ctx context.Context, cmd *cli.Command) error {
+	current, err := planState(ctx, cmd.String("plan"))
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintln(os.Stdout, current.Fingerprint)
+
+	if cmd.String("state") == "" {
+		return nil
+	}
+
+	previous, err := sdk.ReadState(cmd.String("state"))
+	if err != nil {
+		return err //nolint:wrapcheck // ReadState errors are descriptive
+	}
+
+	if previous.Fingerprint != current.Fingerprint {
+		return fmt.Errorf("%w: %s was %s", errPlanChanged, cmd.String("state"), orNone(previous.Fingerprint))
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/sdk"
+)
+
+// graphCommand returns the `quark graph` command.
+func graphCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "graph",
+		Usage: "Render the operation dependency graph of a plan as Graphviz DOT or Mermaid (without executing it)",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "plan",
+				Aliases:  []string{"p"},
+				Usage:    "Path to plan file (Go program, or YAML/JSON plan document)",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   "Graph format (dot, mermaid)",
+				Value:   "dot",
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Write the graph to this path instead of stdout",
+			},
+		},
+		Action: graphCommandAction,
+	}
+}
+
+func graphCommandAction(ctx context.Context, cmd *cli.Command) error {
+	format, err := sdk.ParseGraphFormat(cmd.String("format"))
+	if err != nil {
+		return err //nolint:wrapcheck // ParseGraphFormat errors are descriptive
+	}
+
+	state, err := planState(ctx, cmd.String("plan"))
+	if err != nil {
+		return err
+	}
+
+	var graph strings.Builder
+	if err := state.Graph(&graph, format); err != nil {
+		return fmt.Errorf("failed to render plan graph: %w", err)
+	}
+
+	output := cmd.String("output")
+	if output == "" {
+		_, _ = fmt.Fprint(os.Stdout, graph.String())
+
+		return nil
+	}
+
+	if err := os.WriteFile(output, []byte(graph.String()), filesystem.FilePermissionsDefault); err != nil {
+		return fmt.Errorf("failed to write graph file: %w", err)
+	}
+
+	return nil
+}
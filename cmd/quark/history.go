@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/farcloser/quark/internal/database"
+	"github.com/farcloser/quark/internal/history"
+)
+
+// defaultRunsLimit is the number of executions `quark history runs` lists by default.
+const defaultRunsLimit = 10
+
+var (
+	errImageArgumentRequired         = errors.New("image argument required")
+	errVulnerabilityArgumentRequired = errors.New("vulnerability ID argument required")
+	errHistorySourceRequired         = errors.New("either --dir or --database is required")
+)
+
+// historyDirFlag is the history directory flag shared by history subcommands.
+func historyDirFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:    "dir",
+		Aliases: []string{"d"},
+		Usage:   "History directory",
+		Sources: cli.EnvVars("QUARK_HISTORY_DIR"),
+	}
+}
+
+// historyDatabaseFlag is the database flag shared by history subcommands.
+func historyDatabaseFlag(required bool) cli.Flag {
+	return &cli.StringFlag{
+		Name:     "database",
+		Usage:    "SQLite database recorded by plans (Plan.Database)",
+		Sources:  cli.EnvVars("QUARK_DATABASE"),
+		Required: required,
+	}
+}
+
+// openHistory opens the history directory, or the database when --dir is not set. The returned function closes it.
+func openHistory(ctx context.Context, cmd *cli.Command) (history.Backend, func(), error) {
+	if dir := cmd.String("dir"); dir != "" {
+		return history.NewStore(dir), func() {}, nil
+	}
+
+	if cmd.String("database") == "" {
+		return nil, nil, errHistorySourceRequired
+	}
+
+	db, err := database.Open(ctx, cmd.String("database"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	return db, func() { _ = db.Close() }, nil
+}
+
+// historyCommand returns the `quark history` command.
+func historyCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "history",
+		Usage: "Inspect scan and version check history recorded by plans (Plan.History)",
+		Commands: []*cli.Command{
+			{
+				Name:      "show",
+				Usage:     "Show the history of an image, with new and fixed vulnerabilities over time",
+				ArgsUsage: "IMAGE",
+				Flags:     []cli.Flag{historyDirFlag(), historyDatabaseFlag(false)},
+				Action:    historyShowCommand,
+			},
+			{
+				Name:      "affected",
+				Usage:     "List images whose latest scan still reports a vulnerability",
+				ArgsUsage: "VULNERABILITY-ID",
+				Flags:     []cli.Flag{historyDirFlag(), historyDatabaseFlag(false)},
+				Action:    historyAffectedCommand,
+			},
+			{
+				Name:      "runs",
+				Usage:     "List the last executions recorded to a database, with the status of their operations",
+				ArgsUsage: "[PLAN]",
+				Flags: []cli.Flag{
+					historyDatabaseFlag(true),
+					&cli.IntFlag{
+						Name:    "limit",
+						Aliases: []string{"n"},
+						Usage:   "Number of executions to list (0 for all)",
+						Value:   defaultRunsLimit,
+					},
+				},
+				Action: historyRunsCommand,
+			},
+		},
+	}
+}
+
+func historyShowCommand(ctx context.Context, cmd *cli.Command) error {
+	image := cmd.Args().First()
+	if image == "" {
+		return errImageArgumentRequired
+	}
+
+	store, closeStore, err := openHistory(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	defer closeStore()
+
+	records, err := store.Records(image)
+	if err != nil {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+
+	writeHistory(os.Stdout, image, records)
+
+	return nil
+}
+
+func historyAffectedCommand(ctx context.Context, cmd *cli.Command) error {
+	vulnerabilityID := cmd.Args().First()
+	if vulnerabilityID == "" {
+		return errVulnerabilityArgumentRequired
+	}
+
+	store, closeStore, err := openHistory(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	defer closeStore()
+
+	affected, err := store.Affected(vulnerabilityID)
+	if err != nil {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+
+	if len(affected) == 0 {
+		_, _ = fmt.Fprintf(os.Stdout, "No recorded image still ships %s\n", vulnerabilityID)
+
+		return nil
+	}
+
+	for _, record := range affected {
+		_, _ = fmt.Fprintf(os.Stdout, "%s  %s  scanned %s\n",
+			record.Image, imageLabel(record.Version, record.Digest), record.Time.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+func historyRunsCommand(ctx context.Context, cmd *cli.Command) error {
+	db, err := database.Open(ctx, cmd.String("database"))
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	runs, err := db.Runs(ctx, cmd.Args().First(), int(cmd.Int("limit")))
+	if err != nil {
+		return fmt.Errorf("failed to read executions: %w", err)
+	}
+
+	writeRuns(os.Stdout, runs)
+
+	return nil
+}
+
+// writeRuns writes executions, newest first, with the status of their operations.
+func writeRuns(out io.Writer, runs []database.Run) {
+	if len(runs) == 0 {
+		_, _ = fmt.Fprintln(out, "No execution recorded")
+
+		return
+	}
+
+	for idx, run := range runs {
+		if idx > 0 {
+			_, _ = fmt.Fprintln(out)
+		}
+
+		status := "succeeded"
+		if run.Error != "" {
+			status = "failed: " + run.Error
+		}
+
+		_, _ = fmt.Fprintf(out, "%s  %s  %s  %s\n", run.Started.Format(time.RFC3339), run.Plan, run.Duration, status)
+
+		for _, op := range run.Operations {
+			line := fmt.Sprintf("    %-10s %s (%s)", op.Status, op.Name, op.Kind)
+			if op.Error != "" {
+				line += ": " + op.Error
+			}
+
+			_, _ = fmt.Fprintln(out, line)
+		}
+	}
+}
+
+// writeHistory writes the scan trend and version check history of an image.
+func writeHistory(out io.Writer, image string, records []history.Record) {
+	if len(records) == 0 {
+		_, _ = fmt.Fprintf(out, "No history recorded for %s\n", image)
+
+		return
+	}
+
+	changes := history.ScanTrend(records)
+	if len(changes) > 0 {
+		_, _ = fmt.Fprintf(out, "Scans of %s:\n", image)
+	}
+
+	for _, change := range changes {
+		_, _ = fmt.Fprintf(out, "%s  %s  %s  +%d new  -%d fixed\n",
+			change.Time.Format(time.RFC3339), imageLabel(change.Version, change.Digest),
+			formatCounts(change.Counts), len(change.New), len(change.Fixed))
+
+		for _, vuln := range change.New {
+			_, _ = fmt.Fprintf(out, "    + %s %s %s\n", vuln.ID, vuln.Severity, vuln.Package)
+		}
+
+		for _, vuln := range change.Fixed {
+			_, _ = fmt.Fprintf(out, "    - %s %s %s\n", vuln.ID, vuln.Severity, vuln.Package)
+		}
+	}
+
+	var checks []history.Record
+
+	for _, record := range records {
+		if record.Kind == history.KindVersionCheck {
+			checks = append(checks, record)
+		}
+	}
+
+	if len(checks) == 0 {
+		return
+	}
+
+	if len(changes) > 0 {
+		_, _ = fmt.Fprintln(out)
+	}
+
+	_, _ = fmt.Fprintf(out, "Version checks of %s:\n", image)
+
+	for _, check := range checks {
+		status := "up to date"
+		if check.UpdateAvailable {
+			status = "update available: " + check.LatestVersion
+		}
+
+		_, _ = fmt.Fprintf(out, "%s  %s  %s\n", check.Time.Format(time.RFC3339), imageLabel(check.Version, check.Digest), status)
+	}
+}
+
+// imageLabel identifies the recorded image by version and short digest.
+func imageLabel(version, digest string) string {
+	const shortDigest = 12
+
+	_, hex, _ := strings.Cut(digest, ":")
+	if len(hex) > shortDigest {
+		hex = hex[:shortDigest]
+	}
+
+	switch {
+	case version != "" && hex != "":
+		return version + "@" + hex
+	case version != "":
+		return version
+	default:
+		return hex
+	}
+}
+
+// formatCounts formats severity counts from most to least severe (e.g., "CRITICAL=1 HIGH=3").
+func formatCounts(counts map[string]int) string {
+	order := []string{"CRITICAL", "HIGH", "MEDIUM", "LOW", "UNKNOWN"}
+
+	parts := make([]string, 0, len(order))
+
+	for _, severity := range order {
+		if counts[severity] > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", severity, counts[severity]))
+		}
+	}
+
+	if len(parts) == 0 {
+		return "no vulnerabilities"
+	}
+
+	return strings.Join(parts, " ")
+}
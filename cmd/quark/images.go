@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/farcloser/quark/internal/inventory"
+)
+
+// imagesCommand returns the `quark images` command.
+func imagesCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "images",
+		Usage: "List the images a plan references, with their pinning status (without executing the plan)",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "plan",
+				Aliases:  []string{"p"},
+				Usage:    "Path to plan file or directory",
+				Required: true,
+			},
+			&cli.BoolFlag{
+				Name:  "unpinned",
+				Usage: "Only list images not pinned by digest",
+			},
+		},
+		Action: imagesListCommand,
+	}
+}
+
+func imagesListCommand(_ context.Context, cmd *cli.Command) error {
+	images, err := inventory.Scan(cmd.String("plan"))
+	if err != nil {
+		return fmt.Errorf("failed to list plan images: %w", err)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0) //nolint:mnd // column padding
+
+	_, _ = fmt.Fprintln(writer, "IMAGE\tPINNED BY\tUSED AS\tLOCATION")
+
+	for _, image := range images {
+		if cmd.Bool("unpinned") && image.Pinning == inventory.PinnedDigest {
+			continue
+		}
+
+		roles := strings.Join(image.Roles, ",")
+		if roles == "" {
+			roles = "-"
+		}
+
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", image.Reference, image.Pinning, roles, image.Location)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write image list: %w", err)
+	}
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v3"
@@ -25,7 +26,7 @@ func main() {
 	cmd := &cli.Command{
 		Name:    "quark",
 		Usage:   "Container image management tool",
-		Version: "0.1.0",
+		Version: sdk.Version,
 		Commands: []*cli.Command{
 			{
 				Name:  "execute",
@@ -34,7 +35,7 @@ func main() {
 					&cli.StringFlag{
 						Name:     "plan",
 						Aliases:  []string{"p"},
-						Usage:    "Path to plan file",
+						Usage:    "Path to plan file (Go program, or YAML/JSON plan document)",
 						Required: true,
 					},
 					&cli.BoolFlag{
@@ -42,9 +43,65 @@ func main() {
 						Usage:   "Simulate execution without making changes",
 						Aliases: []string{"n"},
 					},
+					&cli.BoolFlag{
+						Name:    "yes",
+						Usage:   "Confirm destructive operations without prompting",
+						Aliases: []string{"y"},
+					},
+					&cli.StringFlag{
+						Name:  "profile",
+						Usage: "Execute with this profile of the plan (e.g., staging)",
+					},
+					&cli.StringFlag{
+						Name:  "report",
+						Usage: "Write an execution report to this path",
+					},
+					&cli.StringFlag{
+						Name:  "report-format",
+						Usage: "Execution report format (markdown, html, junit)",
+					},
+					&cli.StringFlag{
+						Name:  "trace",
+						Usage: "Write the execution timeline to this path (Chrome trace event format, open in Perfetto)",
+					},
+					&cli.StringFlag{
+						Name:  "state",
+						Usage: "Write the plan state to this path after a successful execution (see plan-diff)",
+					},
+					&cli.BoolFlag{
+						Name:  "skip-unchanged",
+						Usage: "Skip the operations whose inputs did not change since the execution writing --state",
+					},
+					&cli.StringFlag{
+						Name:  "database",
+						Usage: "Record plan states, history and the execution log to this SQLite database",
+					},
+					&cli.StringFlag{
+						Name:  "provenance",
+						Usage: "Write the in-toto provenance of a successful execution to this path",
+					},
+					&cli.StringFlag{
+						Name:  "log-dir",
+						Usage: "Write the logs of each operation to its own file in this directory",
+					},
+					&cli.BoolFlag{
+						Name:  "echo-commands",
+						Usage: "Log the commands run by the plan, secrets redacted (with LOG_LEVEL=debug)",
+					},
+					&cli.BoolFlag{
+						Name:  "pr-comment",
+						Usage: "Post the execution report on the pull/merge request (GITHUB_TOKEN or GITLAB_TOKEN)",
+					},
 				},
 				Action: executeCommand,
 			},
+			validateCommand(),
+			planDiffCommand(),
+			graphCommand(),
+			fingerprintCommand(),
+			imagesCommand(),
+			historyCommand(),
+			serveCommand(),
 		},
 	}
 
@@ -53,9 +110,21 @@ func main() {
 	}
 }
 
-func executeCommand(_ context.Context, cmd *cli.Command) error {
+func executeCommand(ctx context.Context, cmd *cli.Command) error {
 	planPath := cmd.String("plan")
 	dryRun := cmd.Bool("dry-run")
+	assumeYes := cmd.Bool("yes")
+	profile := cmd.String("profile")
+	reportPath := cmd.String("report")
+	reportFormat := cmd.String("report-format")
+	prComment := cmd.Bool("pr-comment")
+	logDir := cmd.String("log-dir")
+	tracePath := cmd.String("trace")
+	statePath := cmd.String("state")
+	skipUnchanged := cmd.Bool("skip-unchanged")
+	databasePath := cmd.String("database")
+	provenancePath := cmd.String("provenance")
+	echoCommands := cmd.Bool("echo-commands")
 
 	// Determine if planPath is a directory or file
 	stat, err := os.Stat(planPath)
@@ -85,8 +154,123 @@ func executeCommand(_ context.Context, cmd *cli.Command) error {
 		}
 	}
 
+	if assumeYes {
+		if err := os.Setenv("QUARK_YES", "true"); err != nil {
+			return fmt.Errorf("failed to set QUARK_YES env: %w", err)
+		}
+	}
+
+	if profile != "" {
+		if err := os.Setenv("QUARK_PROFILE", profile); err != nil {
+			return fmt.Errorf("failed to set QUARK_PROFILE env: %w", err)
+		}
+	}
+
+	if reportPath != "" {
+		// The plan runs from its own directory
+		reportPath, err = filepath.Abs(reportPath)
+		if err != nil {
+			return fmt.Errorf("invalid report path: %w", err)
+		}
+
+		if err := os.Setenv("QUARK_REPORT", reportPath); err != nil {
+			return fmt.Errorf("failed to set QUARK_REPORT env: %w", err)
+		}
+	}
+
+	if reportFormat != "" {
+		if err := os.Setenv("QUARK_REPORT_FORMAT", reportFormat); err != nil {
+			return fmt.Errorf("failed to set QUARK_REPORT_FORMAT env: %w", err)
+		}
+	}
+
+	if tracePath != "" {
+		// The plan runs from its own directory
+		tracePath, err = filepath.Abs(tracePath)
+		if err != nil {
+			return fmt.Errorf("invalid trace path: %w", err)
+		}
+
+		if err := os.Setenv("QUARK_TRACE", tracePath); err != nil {
+			return fmt.Errorf("failed to set QUARK_TRACE env: %w", err)
+		}
+	}
+
+	if statePath != "" {
+		// The plan runs from its own directory
+		statePath, err = filepath.Abs(statePath)
+		if err != nil {
+			return fmt.Errorf("invalid state path: %w", err)
+		}
+
+		if err := os.Setenv("QUARK_STATE", statePath); err != nil {
+			return fmt.Errorf("failed to set QUARK_STATE env: %w", err)
+		}
+	}
+
+	if skipUnchanged {
+		if err := os.Setenv("QUARK_SKIP_UNCHANGED", "true"); err != nil {
+			return fmt.Errorf("failed to set QUARK_SKIP_UNCHANGED env: %w", err)
+		}
+	}
+
+	if databasePath != "" {
+		// The plan runs from its own directory
+		databasePath, err = filepath.Abs(databasePath)
+		if err != nil {
+			return fmt.Errorf("invalid database path: %w", err)
+		}
+
+		if err := os.Setenv("QUARK_DATABASE", databasePath); err != nil {
+			return fmt.Errorf("failed to set QUARK_DATABASE env: %w", err)
+		}
+	}
+
+	if provenancePath != "" {
+		// The plan runs from its own directory
+		provenancePath, err = filepath.Abs(provenancePath)
+		if err != nil {
+			return fmt.Errorf("invalid provenance path: %w", err)
+		}
+
+		if err := os.Setenv("QUARK_PROVENANCE", provenancePath); err != nil {
+			return fmt.Errorf("failed to set QUARK_PROVENANCE env: %w", err)
+		}
+	}
+
+	if logDir != "" {
+		// The plan runs from its own directory
+		logDir, err = filepath.Abs(logDir)
+		if err != nil {
+			return fmt.Errorf("invalid log directory: %w", err)
+		}
+
+		if err := os.Setenv("QUARK_LOG_DIR", logDir); err != nil {
+			return fmt.Errorf("failed to set QUARK_LOG_DIR env: %w", err)
+		}
+	}
+
+	if echoCommands {
+		if err := os.Setenv("QUARK_ECHO_COMMANDS", "true"); err != nil {
+			return fmt.Errorf("failed to set QUARK_ECHO_COMMANDS env: %w", err)
+		}
+	}
+
+	if prComment {
+		if err := os.Setenv("QUARK_PR_COMMENT", "true"); err != nil {
+			return fmt.Errorf("failed to set QUARK_PR_COMMENT env: %w", err)
+		}
+	}
+
+	// Declarative plan documents run in-process: the settings above reach the plan through the environment
+	if !stat.IsDir() && isPlanDocument(planPath) {
+		return executePlanDocument(ctx, planPath, dryRun)
+	}
+
 	// #nosec G204 -- args constructed from validated plan path, executing go run is intentional
 	execCmd := exec.Command("go", args...)
+	// Stdin is forwarded for destructive operation confirmation prompts
+	execCmd.Stdin = os.Stdin
 	execCmd.Stdout = os.Stdout
 	execCmd.Stderr = os.Stderr
 	execCmd.Env = os.Environ()
@@ -100,3 +284,29 @@ func executeCommand(_ context.Context, cmd *cli.Command) error {
 
 	return nil
 }
+
+// isPlanDocument reports whether path is a declarative plan document (YAML or JSON) rather than a Go program.
+func isPlanDocument(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	default:
+		return false
+	}
+}
+
+// executePlanDocument loads a declarative plan document and executes it.
+func executePlanDocument(ctx context.Context, planPath string, dryRun bool) error {
+	plan, err := sdk.LoadPlan(planPath)
+	if err != nil {
+		return fmt.Errorf("failed to load plan: %w", err)
+	}
+
+	log.Info().Str("plan", planPath).Bool("dry-run", dryRun).Msg("executing plan")
+
+	if err := plan.Execute(ctx); err != nil {
+		return fmt.Errorf("plan execution failed: %w", err)
+	}
+
+	return nil
+}
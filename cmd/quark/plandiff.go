@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+var errPlanDiffReference = errors.New("one of --state or --previous is required")
+
+// planDiffCommand returns the `quark plan-diff` command.
+func planDiffCommand() *cli.Command {
+	return &cli.Command{
+		Name: "plan-diff",
+		Usage: "Show the operations a plan adds, removes or changes since a previous state or plan " +
+			"(without executing it)",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "plan",
+				Aliases:  []string{"p"},
+				Usage:    "Path to plan file (Go program, or YAML/JSON plan document)",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "state",
+				Usage: "Plan state written by a previous execution (execute --state)",
+			},
+			&cli.StringFlag{
+				Name:  "previous",
+				Usage: "Previous version of the plan file (e.g., checked out from the main branch)",
+			},
+		},
+		Action: planDiffCommandAction,
+	}
+}
+
+func planDiffCommandAction(ctx context.Context, cmd *cli.Command) error {
+	var (
+		previous *sdk.PlanState
+		err      error
+	)
+
+	switch {
+	case cmd.String("state") != "":
+		previous, err = sdk.ReadState(cmd.String("state"))
+	case cmd.String("previous") != "":
+		previous, err = planState(ctx, cmd.String("previous"))
+	default:
+		return errPlanDiffReference
+	}
+
+	if err != nil {
+		return err
+	}
+
+	current, err := planState(ctx, cmd.String("plan"))
+	if err != nil {
+		return err
+	}
+
+	changes := sdk.DiffStates(previous, current)
+	writeStateDiff(os.Stdout, changes)
+
+	// Settings match, but the files the operations read (build contexts, Dockerfiles...) may not
+	if len(changes) == 0 && previous.Fingerprint != "" && previous.Fingerprint != current.Fingerprint {
+		_, _ = fmt.Fprintln(os.Stdout, "The local files read by the plan changed (see quark fingerprint).")
+	}
+
+	return nil
+}
+
+// planState returns the state of the plan at planPath, without executing it.
+// Go programs run with QUARK_STATE_ONLY, which makes Execute write the plan state and return.
+func planState(ctx context.Context, planPath string) (*sdk.PlanState, error) {
+	stat, err := os.Stat(planPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errPlanFileNotFound, planPath)
+	}
+
+	if !stat.IsDir() && isPlanDocument(planPath) {
+		plan, err := sdk.LoadPlan(planPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load plan: %w", err)
+		}
+
+		return plan.State(), nil
+	}
+
+	stateFile, err := os.CreateTemp("", "quark-state-*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state file: %w", err)
+	}
+
+	_ = stateFile.Close()
+
+	defer func() {
+		_ = os.Remove(stateFile.Name())
+	}()
+
+	planDir, args := planPath, []string{"run", "."}
+	if !stat.IsDir() {
+		planDir, args = filepath.Dir(planPath), []string{"run", filepath.Base(planPath)}
+	}
+
+	// #nosec G204 -- args constructed from validated plan path, executing go run is intentional
+	execCmd := exec.CommandContext(ctx, "go", args...)
+	execCmd.Stdout = os.Stderr
+	execCmd.Stderr = os.Stderr
+	execCmd.Env = append(os.Environ(), "QUARK_STATE_ONLY=true", "QUARK_STATE="+stateFile.Name())
+	execCmd.Dir = planDir
+
+	if err := execCmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run plan %s: %w", planPath, err)
+	}
+
+	//nolint:wrapcheck // ReadState errors are descriptive
+	return sdk.ReadState(stateFile.Name())
+}
+
+// writeStateDiff writes changes like `terraform plan`: + added, ~ changed (with the changed settings), - removed.
+func writeStateDiff(out io.Writer, changes []sdk.StateChange) {
+	if len(changes) == 0 {
+		_, _ = fmt.Fprintln(out, "No changes: the plan operations match.")
+
+		return
+	}
+
+	counts := map[sdk.StateChangeKind]int{}
+
+	for _, change := range changes {
+		counts[change.Change]++
+
+		switch change.Change {
+		case sdk.StateAdded:
+			_, _ = fmt.Fprintf(out, "+ %s (%s)\n", change.Operation, change.Kind)
+		case sdk.StateRemoved:
+			_, _ = fmt.Fprintf(out, "- %s (%s)\n", change.Operation, change.Kind)
+		case sdk.StateChanged:
+			_, _ = fmt.Fprintf(out, "~ %s (%s)\n", change.Operation, change.Kind)
+
+			for _, setting := range change.Settings {
+				_, _ = fmt.Fprintf(out, "    %s: %s -> %s\n", setting.Setting, orNone(setting.Previous),
+					orNone(setting.Current))
+			}
+		}
+	}
+
+	_, _ = fmt.Fprintf(out, "\n%d to add, %d to change, %d to remove.\n",
+		counts[sdk.StateAdded], counts[sdk.StateChanged], counts[sdk.StateRemoved])
+}
+
+// orNone returns value, or "(none)" when empty.
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+
+	return value
+}
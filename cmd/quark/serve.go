@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// shutdownTimeout bounds how long the status server waits for requests in flight when stopping.
+const shutdownTimeout = 5 * time.Second
+
+var (
+	errServeGoPlan   = errors.New("quark serve executes plan documents, schedule Go plans with sdk.Schedule")
+	errDuplicatePlan = errors.New("plan served twice")
+	errPlanNotServed = errors.New("plan not served")
+
+	errUnauthorized    = errors.New("missing or invalid bearer token")
+	errRunWithoutToken = errors.New("executions on demand require quark serve --token (or QUARK_SERVE_TOKEN)")
+)
+
+// serveCommand returns the `quark serve` command.
+func serveCommand() *cli.Command {
+	return &cli.Command{
+		Name: "serve",
+		Usage: "Execute plan documents on their schedule (schedule field) until interrupted, " +
+			"exposing their status and executions on demand over HTTP",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:     "plan",
+				Aliases:  []string{"p"},
+				Usage:    "Path to a YAML/JSON plan document (repeatable)",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "listen",
+				Usage: "Address of the HTTP status endpoint (GET /status, POST /plans/NAME/run), empty to disable",
+				Value: "127.0.0.1:8080",
+			},
+			&cli.StringFlag{
+				Name: "token",
+				Usage: "Bearer token HTTP requests must carry (Authorization: Bearer TOKEN); " +
+					"executions on demand are refused without one",
+				Sources: cli.EnvVars("QUARK_SERVE_TOKEN"),
+			},
+			&cli.StringFlag{
+				Name:  "database",
+				Usage: "Record the plan states, history and executions of every plan to this SQLite database",
+			},
+		},
+		Action: serveCommandAction,
+	}
+}
+
+func serveCommandAction(ctx context.Context, cmd *cli.Command) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Read by the plans when they execute (see Plan.Database)
+	if database := cmd.String("database"); database != "" {
+		if err := os.Setenv("QUARK_DATABASE", database); err != nil {
+			return fmt.Errorf("failed to set QUARK_DATABASE env: %w", err)
+		}
+	}
+
+	plans := map[string]*sdk.ScheduledPlan{}
+
+	var order []*sdk.ScheduledPlan
+
+	for _, planPath := range cmd.StringSlice("plan") {
+		if !isPlanDocument(planPath) {
+			return fmt.Errorf("%w: %s", errServeGoPlan, planPath)
+		}
+
+		plan, err := sdk.LoadPlan(planPath)
+		if err != nil {
+			return fmt.Errorf("failed to load plan %s: %w", planPath, err)
+		}
+
+		scheduled, err := sdk.Schedule(plan, "")
+		if err != nil {
+			return fmt.Errorf("plan %s: %w", planPath, err)
+		}
+
+		name := scheduled.Status().Plan
+		if _, ok := plans[name]; ok {
+			return fmt.Errorf("%w: %q (%s)", errDuplicatePlan, name, planPath)
+		}
+
+		plans[name] = scheduled
+		order = append(order, scheduled)
+	}
+
+	var group sync.WaitGroup
+
+	for _, scheduled := range order {
+		group.Add(1)
+
+		go func() {
+			defer group.Done()
+
+			scheduled.Run(ctx)
+		}()
+	}
+
+	var err error
+
+	if listen := cmd.String("listen"); listen != "" {
+		err = serveStatus(ctx, listen, cmd.String("token"), plans, order)
+	} else {
+		<-ctx.Done()
+	}
+
+	// Executions in progress are canceled with ctx
+	stop()
+	group.Wait()
+
+	return err
+}
+
+// serveStatus serves the status of the plans and their executions on demand on listen, until ctx is canceled.
+// With a token, every request must carry it; without one, executions on demand are refused: local processes and
+// web pages (cross-site requests) reach a localhost endpoint too.
+func serveStatus(ctx context.Context, listen, token string, plans map[string]*sdk.ScheduledPlan,
+	order []*sdk.ScheduledPlan,
+) error {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /status", func(writer http.ResponseWriter, request *http.Request) {
+		if token != "" && !authorized(request, token) {
+			http.Error(writer, errUnauthorized.Error(), http.StatusUnauthorized)
+
+			return
+		}
+
+		statuses := make([]sdk.ScheduleStatus, 0, len(order))
+		for _, scheduled := range order {
+			statuses = append(statuses, scheduled.Status())
+		}
+
+		writeStatus(writer, http.StatusOK, statuses)
+	})
+
+	mux.HandleFunc("POST /plans/{name}/run", func(writer http.ResponseWriter, request *http.Request) {
+		// Browsers cannot send an Authorization header cross-site without a CORS preflight, which is not answered
+		if token == "" {
+			http.Error(writer, errRunWithoutToken.Error(), http.StatusForbidden)
+
+			return
+		}
+
+		if !authorized(request, token) {
+			http.Error(writer, errUnauthorized.Error(), http.StatusUnauthorized)
+
+			return
+		}
+
+		scheduled, ok := plans[request.PathValue("name")]
+		if !ok {
+			http.Error(writer, fmt.Sprintf("%v: %q", errPlanNotServed, request.PathValue("name")), http.StatusNotFound)
+
+			return
+		}
+
+		// The execution outlives the request: it is canceled when the server stops
+		if err := scheduled.Trigger(ctx); err != nil {
+			http.Error(writer, err.Error(), http.StatusConflict)
+
+			return
+		}
+
+		writeStatus(writer, http.StatusAccepted, scheduled.Status())
+	})
+
+	server := &http.Server{
+		Addr:              listen,
+		Handler:           mux,
+		ReadHeaderTimeout: shutdownTimeout,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+		defer cancel()
+
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.Info().Str("listen", listen).Int("plans", len(order)).Msg("serving plans")
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("status server failed: %w", err)
+	}
+
+	return nil
+}
+
+// authorized reports whether request carries token as its bearer token.
+func authorized(request *http.Request, token string) bool {
+	bearer, ok := strings.CutPrefix(request.Header.Get("Authorization"), "Bearer ")
+
+	return ok && subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1
+}
+
+// writeStatus writes value as the JSON response.
+func writeStatus(writer http.ResponseWriter, code int, value any) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(code)
+
+	if err := json.NewEncoder(writer).Encode(value); err != nil {
+		log.Warn().Err(err).Msg("failed to write status response")
+	}
+}
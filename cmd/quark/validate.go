@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// validateCommand returns the `quark validate` command.
+func validateCommand() *cli.Command {
+	return &cli.Command{
+		Name: "validate",
+		Usage: "Check what a plan needs (tools, registry credentials, build nodes, Dockerfiles) " +
+			"without executing it, reporting all problems at once",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "plan",
+				Aliases:  []string{"p"},
+				Usage:    "Path to plan file (Go program, or YAML/JSON plan document)",
+				Required: true,
+			},
+		},
+		Action: validatePlanCommand,
+	}
+}
+
+func validatePlanCommand(ctx context.Context, cmd *cli.Command) error {
+	planPath := cmd.String("plan")
+
+	stat, err := os.Stat(planPath)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errPlanFileNotFound, planPath)
+	}
+
+	if !stat.IsDir() && isPlanDocument(planPath) {
+		plan, err := sdk.LoadPlan(planPath)
+		if err != nil {
+			return fmt.Errorf("failed to load plan: %w", err)
+		}
+
+		if err := plan.Validate(ctx); err != nil {
+			return fmt.Errorf("invalid plan: %w", err)
+		}
+
+		return nil
+	}
+
+	// Go programs validate instead of executing when QUARK_VALIDATE is set
+	planDir, args := planPath, []string{"run", "."}
+	if !stat.IsDir() {
+		planDir, args = filepath.Dir(planPath), []string{"run", filepath.Base(planPath)}
+	}
+
+	// #nosec G204 -- args constructed from validated plan path, executing go run is intentional
+	execCmd := exec.CommandContext(ctx, "go", args...)
+	execCmd.Stdout = os.Stdout
+	execCmd.Stderr = os.Stderr
+	execCmd.Env = append(os.Environ(), "QUARK_VALIDATE=true")
+	execCmd.Dir = planDir
+
+	log.Info().Str("plan", planPath).Msg("validating plan")
+
+	if err := execCmd.Run(); err != nil {
+		return fmt.Errorf("invalid plan: %w", err)
+	}
+
+	return nil
+}
@@ -6,13 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 
 	"github.com/rs/zerolog"
 
 	"github.com/farcloser/godolint/sdk"
 
+	"github.com/farcloser/quark/internal/dockerconfig"
+	"github.com/farcloser/quark/internal/subprocess"
 	"github.com/farcloser/quark/internal/tools"
 )
 
@@ -60,10 +61,17 @@ type Result struct {
 	ImageIssues      int
 	Passed           bool
 	Output           string
+	// Issues lists one line per issue (e.g., "[WARN] CIS-DI-0001 - Create a user for the container").
+	Issues []string
 }
 
 // AuditDockerfile audits a Dockerfile using godolint SDK.
-func (auditor *Auditor) AuditDockerfile(ctx context.Context, dockerfilePath string) (*Result, error) {
+// Rules listed in ignoreRules (e.g., "DL3008") are not checked.
+func (auditor *Auditor) AuditDockerfile(
+	ctx context.Context,
+	dockerfilePath string,
+	ignoreRules ...string,
+) (*Result, error) {
 	auditor.log.Info().
 		Str("dockerfile", dockerfilePath).
 		Msg("auditing Dockerfile with godolint")
@@ -75,8 +83,13 @@ func (auditor *Auditor) AuditDockerfile(ctx context.Context, dockerfilePath stri
 		return nil, fmt.Errorf("failed to read Dockerfile: %w", err)
 	}
 
+	linter := auditor.linter
+	if len(ignoreRules) > 0 {
+		linter = sdk.New(sdk.WithDisabledRules(ignoreRules...))
+	}
+
 	// Lint with godolint SDK
-	lintResult, err := auditor.linter.Lint(ctx, content)
+	lintResult, err := linter.Lint(ctx, content)
 	if err != nil {
 		auditor.log.Error().Err(err).Msg("godolint linting failed")
 
@@ -89,6 +102,11 @@ func (auditor *Auditor) AuditDockerfile(ctx context.Context, dockerfilePath stri
 		Output:           formatGodolintOutput(lintResult.Violations),
 	}
 
+	for _, violation := range lintResult.Violations {
+		result.Issues = append(result.Issues,
+			fmt.Sprintf("[%s] Line %d: %s - %s", violation.Severity, violation.Line, violation.Code, violation.Message))
+	}
+
 	auditor.log.Info().
 		Int("issues", result.DockerfileIssues).
 		Bool("passed", result.Passed).
@@ -113,16 +131,30 @@ func (auditor *Auditor) AuditImage(ctx context.Context, imageRef string, opts Im
 	args := []string{"--format", "json", "--exit-code", "1", imageRef}
 
 	//nolint:gosec // Image ref is from user config
-	cmd := exec.CommandContext(ctx, docklePath, args...)
+	cmd := subprocess.Command(ctx, docklePath, args...)
 
 	// Initialize environment with parent variables
 	cmd.Env = os.Environ()
 
 	// Set credentials via environment variables to avoid exposing in process list
 	// DOCKLE_AUTH_URL scopes credentials to the specific registry
+	// A private docker config scoped to this invocation replaces the runner's docker login state,
+	// so the audit never depends on (or leaks into) credentials stored on the host
 	if opts.Username != "" && opts.Password != "" && opts.RegistryHost != "" {
+		config, err := dockerconfig.Write(opts.RegistryHost, opts.Username, opts.Password)
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if err := config.Scrub(); err != nil {
+				auditor.log.Warn().Err(err).Str("dir", config.Dir()).Msg("failed to scrub temporary docker config")
+			}
+		}()
+
 		authURL := "https://" + opts.RegistryHost
 		cmd.Env = append(cmd.Env,
+			config.Env(),
 			"DOCKLE_AUTH_URL="+authURL,
 			"DOCKLE_USERNAME="+opts.Username,
 			"DOCKLE_PASSWORD="+opts.Password,
@@ -135,22 +167,35 @@ func (auditor *Auditor) AuditImage(ctx context.Context, imageRef string, opts Im
 		cmd.Env = append(cmd.Env, "DOCKLE_IGNORES="+ignores)
 	}
 
-	output, err := cmd.CombinedOutput()
+	// JSON on stdout, stderr kept for errors (dockle exits with 1 when it reports issues)
+	var stdout, stderr strings.Builder
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	subprocess.Echo(ctx, cmd)
+
+	err = cmd.Run()
+	if err != nil && ctx.Err() != nil {
+		return nil, fmt.Errorf("dockle audit interrupted: %w", subprocess.Error(ctx, err, stderr.String()))
+	}
+
+	output := stdout.String()
 
 	// Parse dockle JSON output
 	var dockleResult DockleResult
 	if len(output) > 0 {
-		if parseErr := json.Unmarshal(output, &dockleResult); parseErr != nil {
+		if parseErr := json.Unmarshal([]byte(output), &dockleResult); parseErr != nil {
 			auditor.log.Error().
 				Err(parseErr).
-				Str("output", string(output)).
+				Str("output", output).
+				Str("stderr", stderr.String()).
 				Msg("failed to parse dockle JSON output")
 
-			return nil, fmt.Errorf("failed to parse dockle output: %w", parseErr)
+			return nil, fmt.Errorf("failed to parse dockle output: %w", subprocess.Error(ctx, parseErr, stderr.String()))
 		}
 	} else if err != nil {
 		// Command failed with no output
-		return nil, fmt.Errorf("dockle command failed: %w", err)
+		return nil, fmt.Errorf("dockle command failed: %w", subprocess.Error(ctx, err, stderr.String()))
 	}
 
 	// Count issues by severity level
@@ -194,6 +239,10 @@ func (auditor *Auditor) AuditImage(ctx context.Context, imageRef string, opts Im
 		Output:      formatDockleOutput(&dockleResult),
 	}
 
+	for _, detail := range dockleResult.Details {
+		result.Issues = append(result.Issues, fmt.Sprintf("[%s] %s - %s", detail.Level, detail.Code, detail.Title))
+	}
+
 	auditor.log.Info().
 		Int("issues", result.ImageIssues).
 		Bool("passed", result.Passed).
@@ -0,0 +1,262 @@
+// Package awssig signs HTTP requests with AWS Signature Version 4, for the AWS APIs quark calls without the AWS
+// SDK (S3-compatible object stores, ECR).
+package awssig
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	signingAlgorithm = "AWS4-HMAC-SHA256"
+	amzDateFormat    = "20060102T150405Z"
+	amzDayFormat     = "20060102"
+)
+
+// ErrInvalidDate is returned when the X-Amz-Date header of a request is missing or malformed.
+var ErrInvalidDate = errors.New("invalid X-Amz-Date header")
+
+// Credentials are the request signing credentials.
+type Credentials struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+}
+
+// Signature is the Signature Version 4 of a request, with the values it is derived from.
+type Signature struct {
+	// CanonicalRequest is the canonical form of the request: method, path, query, headers and payload hash.
+	CanonicalRequest string
+	// StringToSign is the algorithm, date, credential scope and canonical request hash.
+	StringToSign string
+	// SignedHeaders is the list of the signed header names (lowercase, ";"-separated).
+	SignedHeaders string
+	// Signature is the hex-encoded signature of StringToSign.
+	Signature string
+	// Authorization is the Authorization header value carrying the signature.
+	Authorization string
+}
+
+// FromEnv returns the credentials of the standard AWS environment variables (AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN).
+func FromEnv() Credentials {
+	return Credentials{
+		AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// Sign adds the Signature Version 4 headers of service (e.g., "s3", "ecr") in region to req, whose payload has
+// the SHA-256 payloadHash (see HashHex).
+func Sign(req *http.Request, payloadHash string, creds Credentials, region, service string) {
+	now := time.Now().UTC()
+
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	req.Header.Set("Authorization", compute(req, payloadHash, creds, region, service, now).Authorization)
+}
+
+// Compute returns the signature of req as it is, without changing it: its headers, including X-Amz-Date, must
+// already be set. The payload has the SHA-256 payloadHash (see HashHex).
+func Compute(req *http.Request, payloadHash string, creds Credentials, region, service string) (Signature, error) {
+	signedAt, err := time.Parse(amzDateFormat, req.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return Signature{}, fmt.Errorf("%w: %w", ErrInvalidDate, err)
+	}
+
+	return compute(req, payloadHash, creds, region, service, signedAt), nil
+}
+
+// compute signs req at signedAt, the time of its X-Amz-Date header.
+func compute(
+	req *http.Request,
+	payloadHash string,
+	creds Credentials,
+	region, service string,
+	signedAt time.Time,
+) Signature {
+	amzDate := signedAt.Format(amzDateFormat)
+	day := signedAt.Format(amzDayFormat)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	headers := map[string]string{"host": host}
+
+	for name, values := range req.Header {
+		// A previous signature is not part of the request being signed
+		if strings.EqualFold(name, "Authorization") {
+			continue
+		}
+
+		trimmed := make([]string, 0, len(values))
+		for _, value := range values {
+			trimmed = append(trimmed, strings.Join(strings.Fields(value), " "))
+		}
+
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL.Path),
+		canonicalQuery(req.URL.RawQuery),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		signingAlgorithm,
+		amzDate,
+		scope,
+		HashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	return Signature{
+		CanonicalRequest: canonicalRequest,
+		StringToSign:     stringToSign,
+		SignedHeaders:    signedHeaders,
+		Signature:        signature,
+		Authorization: fmt.Sprintf(
+			"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+			signingAlgorithm, creds.AccessKey, scope, signedHeaders, signature,
+		),
+	}
+}
+
+// canonicalURI encodes each segment of the (unescaped) path once, as S3 expects: the paths of the other services
+// quark calls need no encoding.
+func canonicalURI(path string) string {
+	if path == "" {
+		return "/"
+	}
+
+	segments := strings.Split(path, "/")
+	for idx, segment := range segments {
+		segments[idx] = uriEncode(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery encodes the parameters of a raw query with uriEncode, sorted by name then value. Unlike
+// url.Values.Encode, spaces are "%20", not "+".
+func canonicalQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	var params [][2]string
+
+	for param := range strings.SplitSeq(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+
+		name, value, _ := strings.Cut(param, "=")
+		params = append(params, [2]string{uriEncode(queryUnescape(name)), uriEncode(queryUnescape(value))})
+	}
+
+	// By encoded name, then value: sorting "name=value" strings would put "a-b=1" before "a=2"
+	sort.Slice(params, func(i, j int) bool {
+		if params[i][0] != params[j][0] {
+			return params[i][0] < params[j][0]
+		}
+
+		return params[i][1] < params[j][1]
+	})
+
+	pairs := make([]string, 0, len(params))
+	for _, param := range params {
+		pairs = append(pairs, param[0]+"="+param[1])
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// queryUnescape decodes a query component ("+" is a space), keeping it as is when malformed: it is then encoded
+// as sent.
+func queryUnescape(component string) string {
+	decoded, err := url.QueryUnescape(component)
+	if err != nil {
+		return component
+	}
+
+	return decoded
+}
+
+// uriEncode percent-encodes every byte of value but the RFC 3986 unreserved characters, with uppercase hex digits.
+func uriEncode(value string) string {
+	const hexDigits = "0123456789ABCDEF"
+
+	var encoded strings.Builder
+
+	for idx := range len(value) {
+		char := value[idx]
+
+		switch {
+		case 'A' <= char && char <= 'Z', 'a' <= char && char <= 'z', '0' <= char && char <= '9',
+			char == '-', char == '.', char == '_', char == '~':
+			encoded.WriteByte(char)
+		default:
+			encoded.WriteByte('%')
+			encoded.WriteByte(hexDigits[char>>4])
+			encoded.WriteByte(hexDigits[char&0x0F])
+		}
+	}
+
+	return encoded.String()
+}
+
+// HashHex returns the hex-encoded SHA-256 of data.
+func HashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
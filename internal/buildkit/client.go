@@ -3,9 +3,16 @@ package buildkit
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/carapace-sh/carapace-shlex"
 	"github.com/rs/zerolog"
@@ -16,14 +23,41 @@ import (
 const (
 	// builderName is the name of the buildx builder instance used for multi-platform builds.
 	builderName = "quark-builder"
+
+	// dfBlockSize is the block size of `df -Pk` output.
+	dfBlockSize = 1024
 )
 
+// ErrInsufficientDiskSpace indicates a build node does not have enough free disk space for a build.
+var ErrInsufficientDiskSpace = errors.New("insufficient disk space on build node")
+
+// DiskEstimate estimates the disk space a build needs on a node:
+// the uploaded context, plus build storage of ContextSize × Factor + ImageSize.
+type DiskEstimate struct {
+	ContextSize int64
+	Factor      float64
+	ImageSize   int64
+}
+
+// buildSize returns the estimated build storage size.
+func (estimate DiskEstimate) buildSize() int64 {
+	return int64(float64(estimate.ContextSize)*estimate.Factor) + estimate.ImageSize
+}
+
 // Client wraps buildkit operations over SSH.
 type Client struct {
-	sshConn ssh.Connection
-	log     zerolog.Logger
+	sshConn  ssh.Connection
+	sshAgent bool
+	log      zerolog.Logger
+	onOutput OutputHandler
+
+	// Reproducible builds (see SourceDateEpoch), nil when disabled
+	sourceDateEpoch *time.Time
 }
 
+// OutputHandler receives the build output, one line at a time, with its stream ("stdout" or "stderr").
+type OutputHandler func(stream, line string)
+
 // NewClient creates a new buildkit client using SSH.
 func NewClient(sshConn ssh.Connection, log zerolog.Logger) *Client {
 	return &Client{
@@ -32,6 +66,25 @@ func NewClient(sshConn ssh.Connection, log zerolog.Logger) *Client {
 	}
 }
 
+// ExposeSSHAgent makes the SSH agent of the remote session available to builds (buildx --ssh default),
+// so Dockerfiles can use RUN --mount=type=ssh (e.g., to clone private git submodules).
+// The SSH connection must forward the local agent (see ssh.Pool.GetClientWithAgentForwarding).
+func (bkclient *Client) ExposeSSHAgent() {
+	bkclient.sshAgent = true
+}
+
+// SourceDateEpoch makes builds reproducible: SOURCE_DATE_EPOCH is set to epoch (build argument), which BuildKit
+// uses as the creation time of the image config and history, and pushed layers get their file timestamps clamped
+// to it (rewrite-timestamp, BuildKit 0.13+).
+func (bkclient *Client) SourceDateEpoch(epoch time.Time) {
+	bkclient.sourceDateEpoch = &epoch
+}
+
+// OnOutput sets a handler receiving every line of build output, in addition to the logger.
+func (bkclient *Client) OnOutput(handler OutputHandler) {
+	bkclient.onOutput = handler
+}
+
 // Build executes a build on the remote buildkit node.
 // Returns the image tag that was built (digest retrieval requires registry operations).
 func (bkclient *Client) Build(
@@ -59,8 +112,10 @@ func (bkclient *Client) Build(
 	// This requires buildkit to be running on the remote host
 
 	buildCmd := fmt.Sprintf(
-		"docker buildx build --platform %s --load -f %s %s",
+		"docker buildx build --platform %s --load%s%s -f %s %s",
 		shlex.Join([]string{platform}),
+		bkclient.sshFlag(),
+		bkclient.epochFlag(),
 		shlex.Join([]string{dockerfilePath}),
 		shlex.Join([]string{contextPath}),
 	)
@@ -133,19 +188,26 @@ func (bkclient *Client) BuildMultiPlatform(
 	}
 
 	buildCmd := fmt.Sprintf(
-		"docker buildx build --builder %s --platform %s --push -t %s -f %s %s",
+		"docker buildx build --builder %s --platform %s%s%s -t %s -f %s %s",
 		shlex.Join([]string{builderName}),
 		shlex.Join([]string{platformsStr}),
+		bkclient.pushFlag(),
+		bkclient.sshFlag(),
 		shlex.Join([]string{tag}),
 		shlex.Join([]string{dockerfilePath}),
 		shlex.Join([]string{contextPath}),
 	)
 
 	// Stream build output to logger
-	stdoutWriter := &logWriter{log: bkclient.log.With().Str("stream", "stdout").Logger()}
-	stderrWriter := &logWriter{log: bkclient.log.With().Str("stream", "stderr").Logger()}
+	stdoutWriter := bkclient.newLogWriter("stdout")
+	stderrWriter := bkclient.newLogWriter("stderr")
 
 	err := bkclient.sshConn.ExecuteStreaming(buildCmd, stdoutWriter, stderrWriter)
+
+	// Output may end without newline
+	stdoutWriter.flush()
+	stderrWriter.flush()
+
 	if err != nil {
 		bkclient.log.Error().
 			Err(err).
@@ -161,6 +223,230 @@ func (bkclient *Client) BuildMultiPlatform(
 	return tag, nil
 }
 
+// sshFlag returns the buildx flag exposing the SSH agent to builds, if enabled.
+func (bkclient *Client) sshFlag() string {
+	if !bkclient.sshAgent {
+		return ""
+	}
+
+	return " --ssh default"
+}
+
+// epochFlag returns the buildx flag setting SOURCE_DATE_EPOCH, if enabled.
+func (bkclient *Client) epochFlag() string {
+	if bkclient.sourceDateEpoch == nil {
+		return ""
+	}
+
+	return fmt.Sprintf(" --build-arg SOURCE_DATE_EPOCH=%d", bkclient.sourceDateEpoch.Unix())
+}
+
+// pushFlag returns the buildx flags pushing the built image, with file timestamps rewritten for reproducible
+// builds.
+func (bkclient *Client) pushFlag() string {
+	if bkclient.sourceDateEpoch == nil {
+		return " --push"
+	}
+
+	return bkclient.epochFlag() + " --output type=image,push=true,rewrite-timestamp=true"
+}
+
+// PruneCache removes build cache records older than olderThan (all records if olderThan is zero)
+// from the default builder and, if it exists, the multi-platform builder.
+// Returns the reclaimed space reported by buildx for each pruned builder (e.g., "1.2GB").
+func (bkclient *Client) PruneCache(ctx context.Context, olderThan time.Duration) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("prune cancelled: %w", err)
+	}
+
+	builders := []string{""}
+
+	// The multi-platform builder only exists once a multi-platform build ran on the node
+	inspectCmd := "docker buildx inspect " + shlex.Join([]string{builderName})
+	if _, _, err := bkclient.sshConn.Execute(inspectCmd); err == nil {
+		builders = append(builders, builderName)
+	}
+
+	reclaimed := make([]string, 0, len(builders))
+
+	for _, builder := range builders {
+		pruneCmd := "docker buildx prune --force"
+		if builder != "" {
+			pruneCmd += " --builder " + shlex.Join([]string{builder})
+		}
+
+		if olderThan > 0 {
+			pruneCmd += " --filter " + shlex.Join([]string{"until=" + olderThan.String()})
+		}
+
+		bkclient.log.Debug().
+			Str("builder", builder).
+			Dur("older_than", olderThan).
+			Msg("pruning build cache")
+
+		stdout, stderr, err := bkclient.sshConn.Execute(pruneCmd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to prune build cache: %w: %s", err, strings.TrimSpace(stderr))
+		}
+
+		reclaimed = append(reclaimed, parseReclaimed(stdout))
+	}
+
+	return reclaimed, nil
+}
+
+// parseReclaimed extracts the reclaimed space from buildx prune output ("Total:\t1.2GB").
+func parseReclaimed(output string) string {
+	for line := range strings.Lines(output) {
+		if total, found := strings.CutPrefix(strings.TrimSpace(line), "Total:"); found {
+			return strings.TrimSpace(total)
+		}
+	}
+
+	return "0B"
+}
+
+// PullImage pulls an image into the Docker image store of the node, so the first build using it
+// as a base image does not start cold.
+func (bkclient *Client) PullImage(ctx context.Context, ref, platform string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("pull cancelled: %w", err)
+	}
+
+	bkclient.log.Debug().
+		Str("image", ref).
+		Str("platform", platform).
+		Msg("pulling image")
+
+	pullCmd := fmt.Sprintf(
+		"docker pull --quiet --platform %s %s",
+		shlex.Join([]string{platform}),
+		shlex.Join([]string{ref}),
+	)
+
+	if _, stderr, err := bkclient.sshConn.Execute(pullCmd); err != nil {
+		return fmt.Errorf("failed to pull %s: %w: %s", ref, err, strings.TrimSpace(stderr))
+	}
+
+	return nil
+}
+
+// CheckDiskSpace fails early with ErrInsufficientDiskSpace if the node cannot hold the uploaded context
+// (under the parent of remotePath) and the build storage (under the Docker root directory).
+// When both live on the same filesystem, their sum must fit.
+func (bkclient *Client) CheckDiskSpace(ctx context.Context, remotePath string, estimate DiskEstimate) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("disk space check cancelled: %w", err)
+	}
+
+	uploadDir := path.Dir(remotePath)
+
+	uploadFS, uploadFree, err := bkclient.freeSpace(uploadDir)
+	if err != nil {
+		return err
+	}
+
+	rootDir, _, err := bkclient.sshConn.Execute("docker info --format '{{.DockerRootDir}}'")
+	if err != nil {
+		return fmt.Errorf("failed to get Docker root directory: %w", err)
+	}
+
+	rootDir = strings.TrimSpace(rootDir)
+
+	rootFS, rootFree, err := bkclient.freeSpace(rootDir)
+	if err != nil {
+		return err
+	}
+
+	bkclient.log.Debug().
+		Str("upload_dir", uploadDir).
+		Int64("upload_free", uploadFree).
+		Str("docker_root", rootDir).
+		Int64("docker_root_free", rootFree).
+		Int64("context_size", estimate.ContextSize).
+		Int64("build_size", estimate.buildSize()).
+		Msg("checking disk space")
+
+	if uploadFS == rootFS {
+		required := estimate.ContextSize + estimate.buildSize()
+		if rootFree < required {
+			return fmt.Errorf(
+				"%w: %s has %d bytes free, %d bytes required (upload %d bytes + context %d bytes × %.1f + image %d bytes)",
+				ErrInsufficientDiskSpace, rootDir, rootFree, required,
+				estimate.ContextSize, estimate.ContextSize, estimate.Factor, estimate.ImageSize,
+			)
+		}
+
+		return nil
+	}
+
+	if uploadFree < estimate.ContextSize {
+		return fmt.Errorf("%w: %s has %d bytes free, %d bytes required for the build context",
+			ErrInsufficientDiskSpace, uploadDir, uploadFree, estimate.ContextSize)
+	}
+
+	if rootFree < estimate.buildSize() {
+		return fmt.Errorf("%w: %s has %d bytes free, %d bytes required (context %d bytes × %.1f + image %d bytes)",
+			ErrInsufficientDiskSpace, rootDir, rootFree, estimate.buildSize(),
+			estimate.ContextSize, estimate.Factor, estimate.ImageSize)
+	}
+
+	return nil
+}
+
+// freeSpace returns the filesystem holding dir and its available space in bytes, using POSIX df output.
+func (bkclient *Client) freeSpace(dir string) (string, int64, error) {
+	stdout, stderr, err := bkclient.sshConn.Execute("df -Pk " + shlex.Join([]string{dir}))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get free space of %s: %w: %s", dir, err, strings.TrimSpace(stderr))
+	}
+
+	// Filesystem 1024-blocks Used Available Capacity Mounted-on
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	fields := strings.Fields(lines[len(lines)-1])
+
+	//nolint:mnd // df -P output has a header line and 6 columns
+	if len(lines) < 2 || len(fields) < 6 {
+		return "", 0, fmt.Errorf("unexpected df output for %s: %q", dir, stdout)
+	}
+
+	available, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("unexpected df output for %s: %w", dir, err)
+	}
+
+	return fields[0], available * dfBlockSize, nil
+}
+
+// ContextSize returns the total size of the regular files under a local build context directory.
+func ContextSize(localPath string) (int64, error) {
+	var size int64
+
+	err := filepath.WalkDir(localPath, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		size += info.Size()
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure build context: %w", err)
+	}
+
+	return size, nil
+}
+
 // UploadContext uploads the build context to the remote host.
 func (bkclient *Client) UploadContext(ctx context.Context, localPath, remotePath string) error {
 	bkclient.log.Debug().
@@ -229,6 +515,36 @@ const maxLogBufferSize = 64 * 1024 // 64KB max buffer to prevent memory exhausti
 type logWriter struct {
 	log    zerolog.Logger
 	buffer []byte
+
+	// Optional line handler, with the stream written to
+	stream   string
+	onOutput OutputHandler
+}
+
+// newLogWriter returns a writer logging output of stream, and passing it to the output handler.
+func (bkclient *Client) newLogWriter(stream string) *logWriter {
+	return &logWriter{
+		log:      bkclient.log.With().Str("stream", stream).Logger(),
+		stream:   stream,
+		onOutput: bkclient.onOutput,
+	}
+}
+
+// emit logs a line and passes it to the output handler.
+func (writer *logWriter) emit(line string) {
+	writer.log.Info().Msg(line)
+
+	if writer.onOutput != nil {
+		writer.onOutput(writer.stream, line)
+	}
+}
+
+// flush emits the buffered partial line, if any.
+func (writer *logWriter) flush() {
+	if len(writer.buffer) > 0 {
+		writer.emit(string(writer.buffer))
+		writer.buffer = writer.buffer[:0]
+	}
 }
 
 func (writer *logWriter) Write(bytes []byte) (int, error) {
@@ -237,7 +553,7 @@ func (writer *logWriter) Write(bytes []byte) (int, error) {
 
 	// Force-flush if buffer exceeds max size (prevents unbounded growth)
 	if len(writer.buffer) > maxLogBufferSize {
-		writer.log.Info().Msg(string(writer.buffer))
+		writer.emit(string(writer.buffer))
 		writer.buffer = writer.buffer[:0]
 
 		return len(bytes), nil
@@ -267,7 +583,7 @@ func (writer *logWriter) Write(bytes []byte) (int, error) {
 
 		// Log line if not empty
 		if line != "" {
-			writer.log.Info().Msg(line)
+			writer.emit(line)
 		}
 	}
 
@@ -2,11 +2,19 @@ package buildkit_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/rs/zerolog"
 
+	"github.com/farcloser/quark/filesystem"
 	"github.com/farcloser/quark/internal/buildkit"
 	"github.com/farcloser/quark/ssh"
 )
@@ -85,15 +93,314 @@ func TestNewClient_WithMockConnection(t *testing.T) {
 	}
 }
 
+// INTENTION: Builds only expose the SSH agent (buildx --ssh default) when explicitly requested.
+func TestClient_BuildMultiPlatform_SSHAgent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		sshAgent bool
+		want     bool
+	}{
+		{name: "agent not exposed by default", sshAgent: false, want: false},
+		{name: "agent exposed on request", sshAgent: true, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			conn := &mockSSHConnection{}
+			client := buildkit.NewClient(conn, zerolog.Nop())
+
+			if tt.sshAgent {
+				client.ExposeSSHAgent()
+			}
+
+			if _, err := client.BuildMultiPlatform(
+				t.Context(), "/tmp/context", "/tmp/context/Dockerfile", []string{"linux/amd64"}, "test:latest",
+			); err != nil {
+				t.Fatalf("BuildMultiPlatform() error = %v", err)
+			}
+
+			if got := strings.Contains(conn.streamed, " --ssh default "); got != tt.want {
+				t.Errorf("build command %q exposes SSH agent = %v, want %v", conn.streamed, got, tt.want)
+			}
+		})
+	}
+}
+
+// INTENTION: Reproducible builds set SOURCE_DATE_EPOCH and push with file timestamps rewritten; other builds
+// push as is.
+func TestClient_BuildMultiPlatform_SourceDateEpoch(t *testing.T) {
+	t.Parallel()
+
+	conn := &mockSSHConnection{}
+	client := buildkit.NewClient(conn, zerolog.Nop())
+
+	if _, err := client.BuildMultiPlatform(
+		t.Context(), "/tmp/context", "/tmp/context/Dockerfile", []string{"linux/amd64"}, "test:latest",
+	); err != nil {
+		t.Fatalf("BuildMultiPlatform() error = %v", err)
+	}
+
+	if !strings.Contains(conn.streamed, " --push ") || strings.Contains(conn.streamed, "SOURCE_DATE_EPOCH") {
+		t.Errorf("build command %q, want a plain push", conn.streamed)
+	}
+
+	client.SourceDateEpoch(time.Unix(1700000000, 0))
+
+	if _, err := client.BuildMultiPlatform(
+		t.Context(), "/tmp/context", "/tmp/context/Dockerfile", []string{"linux/amd64"}, "test:latest",
+	); err != nil {
+		t.Fatalf("BuildMultiPlatform() error = %v", err)
+	}
+
+	for _, flag := range []string{
+		" --build-arg SOURCE_DATE_EPOCH=1700000000 ",
+		" --output type=image,push=true,rewrite-timestamp=true ",
+	} {
+		if !strings.Contains(conn.streamed, flag) {
+			t.Errorf("build command %q, want %q", conn.streamed, flag)
+		}
+	}
+}
+
+// INTENTION: The output handler receives every non-empty line of build output, with its stream,
+// including a last line without newline.
+func TestClient_BuildMultiPlatform_OnOutput(t *testing.T) {
+	t.Parallel()
+
+	conn := &mockSSHConnection{output: "#1 [internal] load build definition\n\n#2 DONE 0.1s"}
+	client := buildkit.NewClient(conn, zerolog.Nop())
+
+	var lines []string
+
+	client.OnOutput(func(stream, line string) {
+		lines = append(lines, stream+": "+line)
+	})
+
+	if _, err := client.BuildMultiPlatform(
+		t.Context(), "/tmp/context", "/tmp/context/Dockerfile", []string{"linux/amd64"}, "test:latest",
+	); err != nil {
+		t.Fatalf("BuildMultiPlatform() error = %v", err)
+	}
+
+	want := []string{"stdout: #1 [internal] load build definition", "stdout: #2 DONE 0.1s"}
+	if !slices.Equal(lines, want) {
+		t.Errorf("output lines = %q, want %q", lines, want)
+	}
+}
+
+// INTENTION: PruneCache prunes the default builder and, only when it exists, the multi-platform builder,
+// applying the age filter and reporting the reclaimed space per builder.
+func TestClient_PruneCache(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		olderThan     time.Duration
+		builderExists bool
+		wantPrunes    []string
+		wantReclaimed []string
+	}{
+		{
+			name:          "default builder only",
+			olderThan:     72 * time.Hour,
+			wantPrunes:    []string{"docker buildx prune --force --filter until=72h0m0s"},
+			wantReclaimed: []string{"1.5GB"},
+		},
+		{
+			name:          "multi-platform builder exists",
+			olderThan:     time.Hour,
+			builderExists: true,
+			wantPrunes: []string{
+				"docker buildx prune --force --filter until=1h0m0s",
+				"docker buildx prune --force --builder quark-builder --filter until=1h0m0s",
+			},
+			wantReclaimed: []string{"1.5GB", "1.5GB"},
+		},
+		{
+			name:          "prune everything",
+			wantPrunes:    []string{"docker buildx prune --force"},
+			wantReclaimed: []string{"1.5GB"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			conn := &mockSSHConnection{
+				respond: func(string) string {
+					return "ID\tRECLAIMABLE\tSIZE\nabc\ttrue\t1.5GB\nTotal:\t1.5GB\n"
+				},
+				fail: func(command string) bool {
+					return strings.HasPrefix(command, "docker buildx inspect") && !tt.builderExists
+				},
+			}
+
+			reclaimed, err := buildkit.NewClient(conn, zerolog.Nop()).PruneCache(t.Context(), tt.olderThan)
+			if err != nil {
+				t.Fatalf("PruneCache() error = %v", err)
+			}
+
+			var prunes []string
+
+			for _, command := range conn.executed {
+				if strings.HasPrefix(command, "docker buildx prune") {
+					prunes = append(prunes, command)
+				}
+			}
+
+			if !slices.Equal(prunes, tt.wantPrunes) {
+				t.Errorf("prune commands = %q, want %q", prunes, tt.wantPrunes)
+			}
+
+			if !slices.Equal(reclaimed, tt.wantReclaimed) {
+				t.Errorf("PruneCache() = %v, want %v", reclaimed, tt.wantReclaimed)
+			}
+		})
+	}
+}
+
+// INTENTION: CheckDiskSpace fails early with ErrInsufficientDiskSpace when the upload directory cannot hold
+// the context or the Docker root cannot hold the build, and requires their sum when they share a filesystem.
+func TestClient_CheckDiskSpace(t *testing.T) {
+	t.Parallel()
+
+	const mib = 1 << 20
+
+	estimate := buildkit.DiskEstimate{ContextSize: 100 * mib, Factor: 3, ImageSize: 200 * mib}
+
+	tests := []struct {
+		name        string
+		tmpFS       string
+		tmpFreeKiB  int64
+		rootFS      string
+		rootFreeKiB int64
+		wantErr     error
+	}{
+		{
+			name:  "enough space on separate filesystems",
+			tmpFS: "tmpfs", tmpFreeKiB: 200 * 1024,
+			rootFS: "/dev/sda1", rootFreeKiB: 600 * 1024,
+		},
+		{
+			name:  "upload directory too small",
+			tmpFS: "tmpfs", tmpFreeKiB: 50 * 1024,
+			rootFS: "/dev/sda1", rootFreeKiB: 10000 * 1024,
+			wantErr: buildkit.ErrInsufficientDiskSpace,
+		},
+		{
+			name:  "docker root too small",
+			tmpFS: "tmpfs", tmpFreeKiB: 10000 * 1024,
+			rootFS: "/dev/sda1", rootFreeKiB: 400 * 1024,
+			wantErr: buildkit.ErrInsufficientDiskSpace,
+		},
+		{
+			name:  "shared filesystem must hold upload and build",
+			tmpFS: "/dev/sda1", tmpFreeKiB: 550 * 1024,
+			rootFS: "/dev/sda1", rootFreeKiB: 550 * 1024,
+			wantErr: buildkit.ErrInsufficientDiskSpace,
+		},
+		{
+			name:  "enough space on shared filesystem",
+			tmpFS: "/dev/sda1", tmpFreeKiB: 600 * 1024,
+			rootFS: "/dev/sda1", rootFreeKiB: 600 * 1024,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			df := func(filesystem string, freeKiB int64, mount string) string {
+				return fmt.Sprintf("Filesystem 1024-blocks Used Available Capacity Mounted on\n"+
+					"%s 100000000 1000 %d 1%% %s\n", filesystem, freeKiB, mount)
+			}
+
+			conn := &mockSSHConnection{
+				respond: func(command string) string {
+					switch {
+					case strings.HasPrefix(command, "docker info"):
+						return "/var/lib/docker\n"
+					case command == "df -Pk /tmp":
+						return df(tt.tmpFS, tt.tmpFreeKiB, "/tmp")
+					case command == "df -Pk /var/lib/docker":
+						return df(tt.rootFS, tt.rootFreeKiB, "/")
+					default:
+						return ""
+					}
+				},
+			}
+
+			err := buildkit.NewClient(conn, zerolog.Nop()).
+				CheckDiskSpace(t.Context(), "/tmp/quark-build-app", estimate)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckDiskSpace() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// INTENTION: ContextSize sums the regular files of a context directory, recursively.
+func TestContextSize(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(dir, "src"), filesystem.DirPermissionsDefault); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	for name, size := range map[string]int{"Dockerfile": 100, "src/main.go": 2048} {
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, size), filesystem.FilePermissionsPrivate); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	size, err := buildkit.ContextSize(dir)
+	if err != nil {
+		t.Fatalf("ContextSize() error = %v", err)
+	}
+
+	if size != 2148 {
+		t.Errorf("ContextSize() = %d, want 2148", size)
+	}
+}
+
 // mockSSHConnection is a minimal mock implementation of ssh.Connection for testing.
-type mockSSHConnection struct{}
+// It records executed and streamed commands, answers with respond, and commands matching fail return an error.
+// Streamed commands write output to stdout.
+type mockSSHConnection struct {
+	executed []string
+	streamed string
+	output   string
+	respond  func(command string) string
+	fail     func(command string) bool
+}
 
-func (*mockSSHConnection) Execute(_ string) (string, string, error) {
-	return "", "", nil
+func (conn *mockSSHConnection) Execute(command string) (string, string, error) {
+	conn.executed = append(conn.executed, command)
+
+	if conn.fail != nil && conn.fail(command) {
+		return "", "", errCommandFailed
+	}
+
+	if conn.respond == nil {
+		return "", "", nil
+	}
+
+	return conn.respond(command), "", nil
 }
 
-func (*mockSSHConnection) ExecuteStreaming(_ string, _, _ io.Writer) error {
-	return nil
+func (conn *mockSSHConnection) ExecuteStreaming(command string, stdout, _ io.Writer) error {
+	conn.streamed = command
+
+	_, err := io.WriteString(stdout, conn.output)
+
+	return err
 }
 
 func (*mockSSHConnection) UploadFile(_, _ string) error {
@@ -104,5 +411,11 @@ func (*mockSSHConnection) UploadData(_ []byte, _ string) error {
 	return nil
 }
 
+func (*mockSSHConnection) UploadStream(_ io.Reader, _ string) error {
+	return nil
+}
+
+var errCommandFailed = errors.New("command failed")
+
 // Ensure mockSSHConnection implements ssh.Connection at compile time.
 var _ ssh.Connection = (*mockSSHConnection)(nil)
@@ -0,0 +1,175 @@
+// Package compose extracts and rewrites the service images of Compose files (docker-compose.yml).
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrInvalidFile indicates a Compose file that cannot be parsed.
+	ErrInvalidFile = errors.New("invalid Compose file")
+
+	// ErrReferenceNotFound indicates a service image reference to rewrite was not found.
+	ErrReferenceNotFound = errors.New("image reference not found")
+)
+
+// ServiceImage is the image of a Compose service.
+type ServiceImage struct {
+	// Service is the service name.
+	Service string
+	// Reference is the image reference as written (e.g., "nginx:1.27").
+	Reference string
+	// Line and Column locate the reference in the file (1-based).
+	Line   int
+	Column int
+	// Built reports whether the service also has a build section: the image is produced locally, not pulled.
+	Built bool
+	// Interpolated reports whether the reference uses variables (e.g., "nginx:${NGINX_VERSION}"),
+	// which are only known when Compose runs.
+	Interpolated bool
+}
+
+// ParseFile extracts the service images of a Compose file.
+func ParseFile(path string) ([]ServiceImage, error) {
+	// #nosec G304 -- Compose file path provided by the plan author
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Compose file: %w", err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	return Parse(file)
+}
+
+// Parse extracts the service images of a Compose file, in file order.
+// Services without an image key are skipped.
+func Parse(reader io.Reader) ([]ServiceImage, error) {
+	var document yaml.Node
+	if err := yaml.NewDecoder(reader).Decode(&document); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%w: top level is not a mapping", ErrInvalidFile)
+	}
+
+	services := lookup(document.Content[0], "services")
+	if services == nil {
+		return nil, nil
+	}
+
+	if services.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%w: services is not a mapping", ErrInvalidFile)
+	}
+
+	var images []ServiceImage
+
+	for idx := 0; idx+1 < len(services.Content); idx += 2 {
+		name, service := services.Content[idx], services.Content[idx+1]
+		if service.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%w: service %q is not a mapping", ErrInvalidFile, name.Value)
+		}
+
+		image := lookup(service, "image")
+		if image == nil {
+			continue
+		}
+
+		if image.Kind != yaml.ScalarNode || image.Value == "" {
+			return nil, fmt.Errorf("%w: service %q image is not a string", ErrInvalidFile, name.Value)
+		}
+
+		images = append(images, ServiceImage{
+			Service:      name.Value,
+			Reference:    image.Value,
+			Line:         image.Line,
+			Column:       image.Column,
+			Built:        lookup(service, "build") != nil,
+			Interpolated: strings.Contains(image.Value, "$"),
+		})
+	}
+
+	sort.SliceStable(images, func(i, j int) bool {
+		return images[i].Line < images[j].Line
+	})
+
+	return images, nil
+}
+
+// lookup returns the value of key in a mapping node, or nil.
+func lookup(mapping *yaml.Node, key string) *yaml.Node {
+	for idx := 0; idx+1 < len(mapping.Content); idx += 2 {
+		if mapping.Content[idx].Value == key {
+			return mapping.Content[idx+1]
+		}
+	}
+
+	return nil
+}
+
+// Replacement rewrites the image reference of a service.
+type Replacement struct {
+	// Line and Column locate the reference (ServiceImage.Line, ServiceImage.Column).
+	Line   int
+	Column int
+	// Old is the reference as written (ServiceImage.Reference).
+	Old string
+	// New is the reference to write instead.
+	New string
+}
+
+// Rewrite applies replacements to the content of a Compose file, leaving everything else untouched
+// (quoting, comments, formatting).
+func Rewrite(content string, replacements []Replacement) (string, error) {
+	lines := strings.Split(content, "\n")
+
+	for _, replacement := range replacements {
+		if !replaceReference(lines, replacement) {
+			return "", fmt.Errorf("%w: %q on line %d", ErrReferenceNotFound, replacement.Old, replacement.Line)
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// replaceReference replaces the reference starting at the replacement position, quoted or not.
+func replaceReference(lines []string, replacement Replacement) bool {
+	if replacement.Line < 1 || replacement.Line > len(lines) || replacement.Column < 1 {
+		return false
+	}
+
+	line := lines[replacement.Line-1]
+
+	// Columns count characters, not bytes
+	runes := []rune(line)
+	if replacement.Column > len(runes) {
+		return false
+	}
+
+	start := len(string(runes[:replacement.Column-1]))
+	if quote := line[start]; quote == '"' || quote == '\'' {
+		start++
+	}
+
+	if !strings.HasPrefix(line[start:], replacement.Old) {
+		return false
+	}
+
+	lines[replacement.Line-1] = line[:start] + replacement.New + line[start+len(replacement.Old):]
+
+	return true
+}
@@ -0,0 +1,122 @@
+package compose_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/farcloser/quark/internal/compose"
+)
+
+const testCompose = `# Edge stack
+services:
+  proxy:
+    image: "caddy:2.8"   # front
+    ports: ["443:443"]
+  app:
+    build: .
+    image: registry.example.com/app:dev
+  cache:
+    image: 'redis:${REDIS_VERSION:-7.2}'
+  db:
+    image: postgres:16@sha256:0000000000000000000000000000000000000000000000000000000000000000
+  sidecar:
+    command: ["sleep", "infinity"]
+`
+
+// INTENTION: every service image is extracted in file order with its position, and images built locally
+// or using variables are flagged; services without an image are skipped.
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	images, err := compose.Parse(strings.NewReader(testCompose))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := []compose.ServiceImage{
+		{Service: "proxy", Reference: "caddy:2.8", Line: 4, Column: 12},
+		{Service: "app", Reference: "registry.example.com/app:dev", Line: 8, Column: 12, Built: true},
+		{Service: "cache", Reference: "redis:${REDIS_VERSION:-7.2}", Line: 10, Column: 12, Interpolated: true},
+		{
+			Service:   "db",
+			Reference: "postgres:16@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+			Line:      12,
+			Column:    12,
+		},
+	}
+
+	if len(images) != len(want) {
+		t.Fatalf("Parse() = %+v, want %d images", images, len(want))
+	}
+
+	for idx := range want {
+		if images[idx] != want[idx] {
+			t.Errorf("image %d = %+v, want %+v", idx, images[idx], want[idx])
+		}
+	}
+}
+
+// INTENTION: files without services have no images; malformed files are rejected.
+func TestParse_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr error
+	}{
+		{name: "empty file"},
+		{name: "no services", content: "volumes:\n  data: {}\n"},
+		{name: "not yaml", content: "services: [", wantErr: compose.ErrInvalidFile},
+		{name: "services list", content: "services:\n  - web\n", wantErr: compose.ErrInvalidFile},
+		{name: "image mapping", content: "services:\n  web:\n    image:\n      name: nginx\n", wantErr: compose.ErrInvalidFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			images, err := compose.Parse(strings.NewReader(tt.content))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(images) != 0 {
+				t.Errorf("Parse() = %+v, want no images", images)
+			}
+		})
+	}
+}
+
+// INTENTION: rewrites only change the reference token, keeping quotes and comments,
+// and fail when the file changed since it was parsed.
+func TestRewrite(t *testing.T) {
+	t.Parallel()
+
+	images, err := compose.Parse(strings.NewReader(testCompose))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	pinned := "caddy:2.9@sha256:1111111111111111111111111111111111111111111111111111111111111111"
+
+	rewritten, err := compose.Rewrite(testCompose, []compose.Replacement{
+		{Line: images[0].Line, Column: images[0].Column, Old: images[0].Reference, New: pinned},
+	})
+	if err != nil {
+		t.Fatalf("Rewrite() error = %v", err)
+	}
+
+	want := strings.Replace(testCompose, `"caddy:2.8"   # front`, `"`+pinned+`"   # front`, 1)
+	if rewritten != want {
+		t.Errorf("Rewrite() =\n%s\nwant\n%s", rewritten, want)
+	}
+
+	_, err = compose.Rewrite(testCompose, []compose.Replacement{
+		{Line: images[0].Line, Column: images[0].Column, Old: "caddy:2.7", New: pinned},
+	})
+	if !errors.Is(err, compose.ErrReferenceNotFound) {
+		t.Errorf("Rewrite() error = %v, want %v", err, compose.ErrReferenceNotFound)
+	}
+}
@@ -0,0 +1,149 @@
+// Package containerd imports image archives into the containerd image store of remote hosts over SSH.
+package containerd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/carapace-sh/carapace-shlex"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/ssh"
+)
+
+// DefaultNamespace is the containerd namespace used by Kubernetes (CRI) to look up images.
+const DefaultNamespace = "k8s.io"
+
+// remoteDir is where archives are uploaded before import.
+const remoteDir = "/tmp"
+
+// ErrUnknownTool indicates an unsupported import tool.
+var ErrUnknownTool = errors.New("unknown containerd import tool")
+
+// Tool is the command line client importing archives on the host.
+type Tool string
+
+const (
+	// ToolCtr imports with `ctr images import`, shipped with containerd.
+	ToolCtr Tool = "ctr"
+	// ToolNerdctl imports with `nerdctl load`.
+	ToolNerdctl Tool = "nerdctl"
+)
+
+// Importer imports image archives into the containerd image store of a host.
+type Importer struct {
+	conn      ssh.Connection
+	namespace string
+	tool      Tool
+	log       zerolog.Logger
+}
+
+// New creates an importer for the host behind conn, using ctr and the Kubernetes namespace.
+func New(conn ssh.Connection, log zerolog.Logger) *Importer {
+	return &Importer{
+		conn:      conn,
+		namespace: DefaultNamespace,
+		tool:      ToolCtr,
+		log:       log,
+	}
+}
+
+// WithNamespace sets the containerd namespace images are imported into.
+func (importer *Importer) WithNamespace(namespace string) *Importer {
+	importer.namespace = namespace
+
+	return importer
+}
+
+// WithTool sets the command line client importing archives.
+func (importer *Importer) WithTool(tool Tool) *Importer {
+	importer.tool = tool
+
+	return importer
+}
+
+// Import uploads the archive at localPath (an OCI or docker image tarball) to the host,
+// imports it into the containerd namespace, and removes the uploaded copy.
+// Images are named after the org.opencontainers.image.ref.name annotations of the archive.
+func (importer *Importer) Import(ctx context.Context, localPath string) error {
+	remote := path.Join(remoteDir, filepath.Base(localPath))
+
+	// Validate the tool before uploading
+	command, err := importer.command(remote)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("import cancelled: %w", err)
+	}
+
+	if err := importer.conn.UploadFile(localPath, remote); err != nil {
+		return fmt.Errorf("failed to upload archive: %w", err)
+	}
+
+	defer func() {
+		if _, stderr, err := importer.conn.Execute("rm -f " + shlex.Join([]string{remote})); err != nil {
+			importer.log.Warn().
+				Str("archive", remote).
+				Str("stderr", strings.TrimSpace(stderr)).
+				Err(err).
+				Msg("failed to remove uploaded archive")
+		}
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("import cancelled: %w", err)
+	}
+
+	sudo, err := importer.sudo()
+	if err != nil {
+		return err
+	}
+
+	importer.log.Debug().Str("command", sudo+command).Msg("importing archive")
+
+	stdout, stderr, err := importer.conn.Execute(sudo + command)
+	if err != nil {
+		importer.log.Error().
+			Str("stdout", stdout).
+			Str("stderr", stderr).
+			Err(err).
+			Msg("import command failed")
+
+		return fmt.Errorf("failed to import archive with %s: %w: %s", importer.tool, err, strings.TrimSpace(stderr))
+	}
+
+	return nil
+}
+
+// command returns the command importing the archive at remote.
+func (importer *Importer) command(remote string) (string, error) {
+	switch importer.tool {
+	case ToolCtr:
+		return shlex.Join([]string{"ctr", "--namespace", importer.namespace, "images", "import", remote}), nil
+	case ToolNerdctl:
+		return shlex.Join([]string{"nerdctl", "--namespace", importer.namespace, "load", "--input", remote}), nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownTool, importer.tool)
+	}
+}
+
+// sudo returns the privilege escalation prefix: none for root, non-interactive sudo otherwise.
+// The containerd socket is only accessible to root on most hosts.
+func (importer *Importer) sudo() (string, error) {
+	stdout, _, err := importer.conn.Execute("id -u")
+	if err != nil {
+		return "", fmt.Errorf("failed to get remote user: %w", err)
+	}
+
+	if strings.TrimSpace(stdout) == "0" {
+		return "", nil
+	}
+
+	return "sudo -n ", nil
+}
@@ -0,0 +1,173 @@
+package containerd_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/containerd"
+)
+
+var errCommandFailed = errors.New("command failed")
+
+// mockConnection is an ssh.Connection answering commands with canned responses.
+// Commands without a response fail; executed commands and uploads are recorded.
+type mockConnection struct {
+	responses map[string]string
+	executed  []string
+	uploaded  []string
+}
+
+func (conn *mockConnection) Execute(command string) (string, string, error) {
+	conn.executed = append(conn.executed, command)
+
+	for prefix, response := range conn.responses {
+		if strings.HasPrefix(command, prefix) {
+			return response, "", nil
+		}
+	}
+
+	return "", "not found", errCommandFailed
+}
+
+func (*mockConnection) ExecuteStreaming(_ string, _, _ io.Writer) error {
+	return nil
+}
+
+func (conn *mockConnection) UploadFile(_, remotePath string) error {
+	conn.uploaded = append(conn.uploaded, remotePath)
+
+	return nil
+}
+
+func (*mockConnection) UploadData(_ []byte, _ string) error {
+	return nil
+}
+
+func (*mockConnection) UploadStream(_ io.Reader, _ string) error {
+	return nil
+}
+
+// INTENTION: archives are uploaded, imported with the selected tool into the selected namespace
+// (with sudo for non-root users), and the uploaded copy is removed even when the import fails.
+func TestImporter_Import(t *testing.T) {
+	t.Parallel()
+
+	archive := filepath.Join(t.TempDir(), "quark-import-123.tar")
+	if err := os.WriteFile(archive, []byte("archive"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		tool        containerd.Tool
+		namespace   string
+		root        bool
+		importFails bool
+		wantCommand string
+		wantErr     error
+	}{
+		{
+			name:        "ctr as root in the kubernetes namespace",
+			tool:        containerd.ToolCtr,
+			root:        true,
+			wantCommand: "ctr --namespace k8s.io images import /tmp/quark-import-123.tar",
+		},
+		{
+			name:        "nerdctl with sudo",
+			tool:        containerd.ToolNerdctl,
+			namespace:   "default",
+			wantCommand: "sudo -n nerdctl --namespace default load --input /tmp/quark-import-123.tar",
+		},
+		{
+			name:        "import failure",
+			tool:        containerd.ToolCtr,
+			root:        true,
+			importFails: true,
+			wantCommand: "ctr --namespace k8s.io images import /tmp/quark-import-123.tar",
+			wantErr:     errCommandFailed,
+		},
+		{
+			name:    "unknown tool",
+			tool:    containerd.Tool("crictl"),
+			wantErr: containerd.ErrUnknownTool,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			uid := "1000"
+			if tt.root {
+				uid = "0"
+			}
+
+			conn := &mockConnection{responses: map[string]string{"id -u": uid, "rm -f": ""}}
+			if !tt.importFails && tt.wantCommand != "" {
+				conn.responses[tt.wantCommand] = ""
+			}
+
+			importer := containerd.New(conn, zerolog.Nop()).WithTool(tt.tool)
+			if tt.namespace != "" {
+				importer.WithNamespace(tt.namespace)
+			}
+
+			err := importer.Import(context.Background(), archive)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Import() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Import() error = %v", err)
+			}
+
+			if tt.wantCommand == "" {
+				if len(conn.uploaded) != 0 {
+					t.Errorf("uploaded %v, want no upload", conn.uploaded)
+				}
+
+				return
+			}
+
+			if len(conn.uploaded) != 1 || conn.uploaded[0] != "/tmp/quark-import-123.tar" {
+				t.Errorf("uploaded %v, want /tmp/quark-import-123.tar", conn.uploaded)
+			}
+
+			if !slices.Contains(conn.executed, tt.wantCommand) {
+				t.Errorf("executed %v, want %q", conn.executed, tt.wantCommand)
+			}
+
+			if last := conn.executed[len(conn.executed)-1]; last != "rm -f /tmp/quark-import-123.tar" {
+				t.Errorf("last command = %q, want the uploaded archive removed", last)
+			}
+		})
+	}
+}
+
+// INTENTION: a cancelled context stops the import before anything is uploaded.
+func TestImporter_ImportCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	conn := &mockConnection{}
+
+	err := containerd.New(conn, zerolog.Nop()).Import(ctx, "/nonexistent/archive.tar")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Import() error = %v, want context.Canceled", err)
+	}
+
+	if len(conn.uploaded) != 0 || len(conn.executed) != 0 {
+		t.Errorf("uploaded %v, executed %v, want nothing", conn.uploaded, conn.executed)
+	}
+}
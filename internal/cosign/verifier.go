@@ -0,0 +1,173 @@
+// Package cosign provides cosign signature verification.
+package cosign
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/dockerconfig"
+	"github.com/farcloser/quark/internal/subprocess"
+	"github.com/farcloser/quark/internal/tools"
+)
+
+var (
+	// ErrVerificationFailed indicates the image has no signature matching the key or identity.
+	ErrVerificationFailed = errors.New("signature verification failed")
+
+	// ErrDigestMismatch indicates a verified signature covers another image than the one requested.
+	ErrDigestMismatch = errors.New("signature does not cover the image digest")
+
+	errDigestRequired = errors.New("image reference must include a digest")
+	errKeyRequired    = errors.New("either a key or a certificate identity and issuer are required")
+)
+
+// Verifier wraps cosign CLI operations.
+type Verifier struct {
+	log       zerolog.Logger
+	installer *tools.Installer
+}
+
+// NewVerifier creates a new cosign verifier.
+func NewVerifier(log zerolog.Logger) *Verifier {
+	return &Verifier{
+		log:       log,
+		installer: tools.NewInstaller(log),
+	}
+}
+
+// VerifyOptions configures signature verification.
+// Signatures are verified either with Key, or keyless with Identity and Issuer (Fulcio certificates).
+type VerifyOptions struct {
+	RegistryHost string // Registry host for authentication (optional)
+	Username     string // Registry username (optional)
+	Password     string // Registry password (optional)
+	Key          string // Public key: file path, URL or KMS URI (e.g., "cosign.pub", "awskms://...")
+	Identity     string // Certificate identity (e.g., a CI workflow URL)
+	Issuer       string // Certificate OIDC issuer (e.g., "https://token.actions.githubusercontent.com")
+	RekorURL     string // Transparency log (optional, cosign default when empty)
+}
+
+// Signature is a verified signature of an image.
+type Signature struct {
+	Digest   string // Image digest covered by the signature
+	Identity string // Certificate identity (keyless signatures only)
+	Issuer   string // Certificate OIDC issuer (keyless signatures only)
+}
+
+// payload is an entry of the cosign verify JSON output (simple signing format).
+type payload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+	Optional map[string]any `json:"optional"`
+}
+
+// Verify verifies the signatures of imageRef, which must be a digest reference, and returns them.
+// Every verified signature must cover the digest of imageRef.
+func (verifier *Verifier) Verify(ctx context.Context, imageRef string, opts VerifyOptions) ([]Signature, error) {
+	_, imageDigest, ok := strings.Cut(imageRef, "@")
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errDigestRequired, imageRef)
+	}
+
+	args := []string{"verify", "--output", "json"}
+
+	switch {
+	case opts.Key != "":
+		args = append(args, "--key", opts.Key)
+	case opts.Identity != "" && opts.Issuer != "":
+		args = append(args, "--certificate-identity", opts.Identity, "--certificate-oidc-issuer", opts.Issuer)
+	default:
+		return nil, errKeyRequired
+	}
+
+	if opts.RekorURL != "" {
+		args = append(args, "--rekor-url", opts.RekorURL)
+	}
+
+	args = append(args, imageRef)
+
+	cosignPath, err := verifier.installer.Ensure(tools.Cosign)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure cosign is installed: %w", err)
+	}
+
+	verifier.log.Info().
+		Str("image", imageRef).
+		Msg("verifying signatures with cosign")
+
+	//nolint:gosec // Image ref and key are from user config
+	cmd := subprocess.Command(ctx, cosignPath, args...)
+	cmd.Env = os.Environ()
+
+	// A private docker config scoped to this invocation replaces the runner's docker login state
+	if opts.Username != "" && opts.Password != "" && opts.RegistryHost != "" {
+		config, err := dockerconfig.Write(opts.RegistryHost, opts.Username, opts.Password)
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if err := config.Scrub(); err != nil {
+				verifier.log.Warn().Err(err).Str("dir", config.Dir()).Msg("failed to scrub temporary docker config")
+			}
+		}()
+
+		cmd.Env = append(cmd.Env, config.Env())
+	}
+
+	// JSON payloads on stdout, verification messages and errors on stderr
+	var stdout, stderr strings.Builder
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	subprocess.Echo(ctx, cmd)
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("cosign verification interrupted: %w", subprocess.Error(ctx, err, stderr.String()))
+		}
+
+		return nil, fmt.Errorf("%w: %s: %w", ErrVerificationFailed, imageRef,
+			subprocess.Error(ctx, err, stderr.String()))
+	}
+
+	return parseSignatures(stdout.String(), imageRef, imageDigest)
+}
+
+// parseSignatures decodes the cosign verify output, and checks every signature covers imageDigest.
+func parseSignatures(output, imageRef, imageDigest string) ([]Signature, error) {
+	var payloads []payload
+	if err := json.Unmarshal([]byte(output), &payloads); err != nil {
+		return nil, fmt.Errorf("failed to parse cosign output: %w", err)
+	}
+
+	if len(payloads) == 0 {
+		return nil, fmt.Errorf("%w: %s: no signature", ErrVerificationFailed, imageRef)
+	}
+
+	signatures := make([]Signature, 0, len(payloads))
+
+	for _, entry := range payloads {
+		signed := entry.Critical.Image.DockerManifestDigest
+		if signed != imageDigest {
+			return nil, fmt.Errorf("%w: %s: signed %s", ErrDigestMismatch, imageRef, signed)
+		}
+
+		// Keyless signatures carry the certificate subject and issuer
+		identity, _ := entry.Optional["Subject"].(string)
+		issuer, _ := entry.Optional["Issuer"].(string)
+
+		signatures = append(signatures, Signature{Digest: signed, Identity: identity, Issuer: issuer})
+	}
+
+	return signatures, nil
+}
@@ -0,0 +1,128 @@
+package cosign_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/cosign"
+)
+
+const (
+	imageDigest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	otherDigest = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+// fakeCosign puts a cosign in PATH printing output and exiting with code, and records its arguments.
+// Returns the path of the arguments file.
+func fakeCosign(t *testing.T, output string, code int) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	outputFile := filepath.Join(dir, "output")
+
+	if err := os.WriteFile(outputFile, []byte(output), 0o600); err != nil {
+		t.Fatalf("failed to write output: %v", err)
+	}
+
+	script := "#!/bin/sh\necho \"$@\" > " + argsFile + "\ncat " + outputFile + "\nexit " + strconv.Itoa(code) + "\n"
+
+	//nolint:gosec // Test binary must be executable
+	if err := os.WriteFile(filepath.Join(dir, "cosign"), []byte(script), 0o700); err != nil {
+		t.Fatalf("failed to write fake cosign: %v", err)
+	}
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	return argsFile
+}
+
+// INTENTION: Keyless verification passes the identity and issuer to cosign, and returns the certificate
+// identities of the signatures.
+func TestVerifier_Verify_Keyless(t *testing.T) {
+	argsFile := fakeCosign(t, `[{"critical":{"image":{"docker-manifest-digest":"`+imageDigest+`"}},`+
+		`"optional":{"Subject":"https://github.com/org/app/.github/workflows/release.yml@refs/heads/main",`+
+		`"Issuer":"https://token.actions.githubusercontent.com"}}]`, 0)
+
+	signatures, err := cosign.NewVerifier(zerolog.Nop()).Verify(t.Context(), "ghcr.io/org/app@"+imageDigest,
+		cosign.VerifyOptions{
+			Identity: "https://github.com/org/app/.github/workflows/release.yml@refs/heads/main",
+			Issuer:   "https://token.actions.githubusercontent.com",
+			RekorURL: "https://rekor.example.com",
+		})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	if len(signatures) != 1 || signatures[0].Issuer != "https://token.actions.githubusercontent.com" ||
+		!strings.HasSuffix(signatures[0].Identity, "release.yml@refs/heads/main") {
+		t.Errorf("Verify() = %+v, want the certificate identity and issuer", signatures)
+	}
+
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("failed to read arguments: %v", err)
+	}
+
+	for _, want := range []string{"--certificate-identity", "--certificate-oidc-issuer", "--rekor-url"} {
+		if !strings.Contains(string(args), want) {
+			t.Errorf("cosign arguments = %q, want %s", args, want)
+		}
+	}
+}
+
+// INTENTION: Verification fails when cosign rejects the signatures, or when a signature covers another image.
+func TestVerifier_Verify_Failures(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		code    int
+		wantErr error
+	}{
+		{
+			name:    "rejected",
+			output:  "",
+			code:    1,
+			wantErr: cosign.ErrVerificationFailed,
+		},
+		{
+			name:    "other digest",
+			output:  `[{"critical":{"image":{"docker-manifest-digest":"` + otherDigest + `"}}}]`,
+			wantErr: cosign.ErrDigestMismatch,
+		},
+		{
+			name:    "no signature",
+			output:  `[]`,
+			wantErr: cosign.ErrVerificationFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeCosign(t, tt.output, tt.code)
+
+			_, err := cosign.NewVerifier(zerolog.Nop()).Verify(t.Context(), "ghcr.io/org/app@"+imageDigest,
+				cosign.VerifyOptions{Key: "cosign.pub"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want error wrapping %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// INTENTION: Only digest references are verified: a tag could be moved to unsigned content after verification.
+func TestVerifier_Verify_RequiresDigest(t *testing.T) {
+	t.Parallel()
+
+	_, err := cosign.NewVerifier(zerolog.Nop()).Verify(t.Context(), "ghcr.io/org/app:1.0",
+		cosign.VerifyOptions{Key: "cosign.pub"})
+	if err == nil {
+		t.Error("Verify() should fail for tag references")
+	}
+}
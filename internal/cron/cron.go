@@ -0,0 +1,207 @@
+// Package cron parses cron expressions and computes the times they match.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidExpression indicates a cron expression that cannot be parsed.
+var ErrInvalidExpression = errors.New("invalid cron expression")
+
+// searchLimit bounds the search of the next matching time: expressions matching no date (e.g., February 30)
+// never match.
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+//nolint:gochecknoglobals // read-only lookup tables
+var (
+	macros = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+
+	monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	dayNames   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// field describes the values of one field of an expression.
+type field struct {
+	name     string
+	min, max int
+	// names of the values from min (e.g., months), matched case-insensitively
+	names []string
+}
+
+//nolint:gochecknoglobals // read-only lookup table
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: monthNames},
+	// 7 is Sunday too
+	{name: "day of week", min: 0, max: 7, names: dayNames},
+}
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	minutes, hours, days, months, weekdays uint64
+
+	// Days of month and of week restricted both: a day matching either matches (as in crontab)
+	eitherDay bool
+}
+
+// Parse parses a standard cron expression: five fields (minute, hour, day of month, month, day of week) of
+// values, ranges (1-5), steps (*/15, 0-30/10), lists (1,15) or names (jan, mon), or a macro (@hourly, @daily,
+// @midnight, @weekly, @monthly, @yearly, @annually).
+func Parse(expression string) (*Schedule, error) {
+	spec := strings.TrimSpace(expression)
+	if macro, ok := macros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("%w: %q: want %d fields, got %d", ErrInvalidExpression, expression, len(fields),
+			len(parts))
+	}
+
+	sets := make([]uint64, len(fields))
+
+	for idx, part := range parts {
+		set, err := fields[idx].parse(part)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %w", ErrInvalidExpression, expression, err)
+		}
+
+		sets[idx] = set
+	}
+
+	// Sunday is 0 and 7
+	weekdays := sets[4]
+	if weekdays&(1<<7) != 0 {
+		weekdays = weekdays&^(1<<7) | 1
+	}
+
+	return &Schedule{
+		minutes:   sets[0],
+		hours:     sets[1],
+		days:      sets[2],
+		months:    sets[3],
+		weekdays:  weekdays,
+		eitherDay: parts[2] != "*" && parts[4] != "*",
+	}, nil
+}
+
+// parse returns the set of values of a field (bit n set for value n).
+func (fld field) parse(spec string) (uint64, error) {
+	var set uint64
+
+	for item := range strings.SplitSeq(spec, ",") {
+		low, high, step := fld.min, fld.max, 1
+
+		rng := item
+		if before, after, found := strings.Cut(item, "/"); found {
+			parsed, err := strconv.Atoi(after)
+			if err != nil || parsed < 1 {
+				return 0, fmt.Errorf("%s: invalid step %q", fld.name, after)
+			}
+
+			rng, step = before, parsed
+		}
+
+		if rng != "*" {
+			var err error
+
+			before, after, isRange := strings.Cut(rng, "-")
+
+			if low, err = fld.value(before); err != nil {
+				return 0, err
+			}
+
+			high = fld.max
+
+			switch {
+			case isRange:
+				if high, err = fld.value(after); err != nil {
+					return 0, err
+				}
+			case step == 1:
+				// A single value, unless stepped (5/15 is 5-max/15)
+				high = low
+			}
+
+			if low > high {
+				return 0, fmt.Errorf("%s: invalid range %q", fld.name, rng)
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			set |= 1 << value
+		}
+	}
+
+	return set, nil
+}
+
+// value parses a value of the field, as a number or a name.
+func (fld field) value(spec string) (int, error) {
+	for idx, name := range fld.names {
+		if strings.EqualFold(spec, name) {
+			return fld.min + idx, nil
+		}
+	}
+
+	value, err := strconv.Atoi(spec)
+	if err != nil || value < fld.min || value > fld.max {
+		return 0, fmt.Errorf("%s: invalid value %q (%d-%d)", fld.name, spec, fld.min, fld.max)
+	}
+
+	return value, nil
+}
+
+// Next returns the first time matching the schedule strictly after after, to the minute, in the location of after.
+// Returns the zero time if the schedule matches no time (e.g., February 30).
+func (schedule *Schedule) Next(after time.Time) time.Time {
+	next := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.Add(searchLimit)
+
+	for next.Before(limit) {
+		year, month, day := next.Date()
+		loc := next.Location()
+
+		switch {
+		case schedule.months&(1<<uint(month)) == 0:
+			next = time.Date(year, month+1, 1, 0, 0, 0, 0, loc)
+		case !schedule.matchDay(next):
+			next = time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+		case schedule.hours&(1<<uint(next.Hour())) == 0:
+			next = time.Date(year, month, day, next.Hour()+1, 0, 0, 0, loc)
+		case schedule.minutes&(1<<uint(next.Minute())) == 0:
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+
+	return time.Time{}
+}
+
+// matchDay reports whether the day of moment matches the days of month and of week of the schedule.
+func (schedule *Schedule) matchDay(moment time.Time) bool {
+	day := schedule.days&(1<<uint(moment.Day())) != 0
+	weekday := schedule.weekdays&(1<<uint(moment.Weekday())) != 0
+
+	if schedule.eitherDay {
+		return day || weekday
+	}
+
+	return day && weekday
+}
@@ -0,0 +1,65 @@
+package cron_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/farcloser/quark/internal/cron"
+)
+
+// INTENTION: The next time of a schedule is the first minute strictly after the given time matching every field,
+// with ranges, steps, lists, names and macros, and crontab semantics when both day fields are restricted.
+func TestSchedule_Next(t *testing.T) {
+	t.Parallel()
+
+	// A Wednesday
+	from := time.Date(2025, time.January, 15, 10, 30, 45, 0, time.UTC)
+
+	tests := []struct {
+		expression string
+		want       time.Time
+	}{
+		{"* * * * *", time.Date(2025, time.January, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, time.January, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2025, time.January, 16, 3, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, time.January, 16, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, time.January, 15, 11, 0, 0, 0, time.UTC)},
+		{"30 9 * * mon-fri", time.Date(2025, time.January, 16, 9, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, time.January, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 mar *", time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1,20 * *", time.Date(2025, time.January, 20, 12, 0, 0, 0, time.UTC)},
+		// Day 1 of the month or any Friday
+		{"0 0 1 * fri", time.Date(2025, time.January, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			t.Parallel()
+
+			schedule, err := cron.Parse(tt.expression)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if got := schedule.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// INTENTION: Expressions with the wrong number of fields, out of range values, inverted ranges or invalid steps
+// are rejected.
+func TestParse_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, expression := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "5-1 * * * *",
+		"*/0 * * * *", "0 0 * foo *", "@reboot"} {
+		if _, err := cron.Parse(expression); !errors.Is(err, cron.ErrInvalidExpression) {
+			t.Errorf("Parse(%q) error = %v, want %v", expression, err, cron.ErrInvalidExpression)
+		}
+	}
+}
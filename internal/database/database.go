@@ -0,0 +1,564 @@
+// Package database stores plan states, scan and version check history, and the execution log of plans in an
+// embedded SQLite database, for long-running processes (quark serve) querying them across plans and runs.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	// Registers the pure Go "sqlite" driver (no cgo)
+	_ "modernc.org/sqlite"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/internal/history"
+)
+
+// busyTimeout is how long a write waits for the writes of other processes (e.g., plans of quark serve)
+// sharing the database.
+const busyTimeout = 10 * time.Second
+
+// ErrNewerSchema indicates a database migrated by a newer version of quark.
+var ErrNewerSchema = errors.New("database schema is newer than this version of quark")
+
+// migrations are the schema changes, applied in order. The schema version (user_version) is the number of
+// migrations applied: append new migrations, never edit released ones.
+//
+//nolint:gochecknoglobals // read-only schema definition
+var migrations = []string{
+	`CREATE TABLE records (
+		id INTEGER PRIMARY KEY,
+		time TEXT NOT NULL,
+		plan TEXT NOT NULL,
+		operation TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		image TEXT NOT NULL,
+		version TEXT NOT NULL,
+		digest TEXT NOT NULL,
+		latest_version TEXT NOT NULL,
+		latest_digest TEXT NOT NULL,
+		update_available INTEGER NOT NULL
+	);
+	CREATE INDEX records_image ON records (image, time);
+	CREATE TABLE vulnerabilities (
+		record INTEGER NOT NULL REFERENCES records (id) ON DELETE CASCADE,
+		id TEXT NOT NULL,
+		severity TEXT NOT NULL,
+		package TEXT NOT NULL,
+		installed_version TEXT NOT NULL,
+		fixed_version TEXT NOT NULL,
+		platforms TEXT NOT NULL
+	);
+	CREATE INDEX vulnerabilities_id ON vulnerabilities (id);
+	CREATE INDEX vulnerabilities_record ON vulnerabilities (record);`,
+
+	`CREATE TABLE states (
+		plan TEXT NOT NULL,
+		written TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		state TEXT NOT NULL
+	);
+	CREATE INDEX states_plan ON states (plan, written);`,
+
+	`CREATE TABLE runs (
+		id INTEGER PRIMARY KEY,
+		plan TEXT NOT NULL,
+		started TEXT NOT NULL,
+		duration_ms INTEGER NOT NULL,
+		error TEXT NOT NULL
+	);
+	CREATE INDEX runs_plan ON runs (plan, started);
+	CREATE TABLE run_operations (
+		run INTEGER NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		status TEXT NOT NULL,
+		started TEXT NOT NULL,
+		duration_ms INTEGER NOT NULL,
+		error TEXT NOT NULL,
+		digest TEXT NOT NULL
+	);
+	CREATE INDEX run_operations_run ON run_operations (run);`,
+}
+
+// DB is a quark database. It is safe for concurrent use, including by several processes.
+type DB struct {
+	sql *sql.DB
+}
+
+// Run is an execution of a plan, in the execution log.
+type Run struct {
+	Plan     string
+	Started  time.Time
+	Duration time.Duration
+	// Error is the failure message (empty unless the execution failed).
+	Error      string
+	Operations []RunOperation
+}
+
+// RunOperation is the outcome of an operation in a run.
+type RunOperation struct {
+	Name   string
+	Kind   string
+	Status string
+	// Started is when the operation started (zero when it did not run).
+	Started  time.Time
+	Duration time.Duration
+	Error    string
+	Digest   string
+}
+
+// Open opens the database at path, creating it (and its directory) if needed, and migrates its schema to the
+// latest version. Fails with ErrNewerSchema for databases migrated by a newer version.
+func Open(ctx context.Context, path string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), filesystem.DirPermissionsDefault); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(%d)&_pragma=journal_mode(wal)&_pragma=foreign_keys(1)",
+		path, busyTimeout.Milliseconds())
+
+	handle, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+
+	db := &DB{sql: handle}
+
+	if err := db.migrate(ctx); err != nil {
+		_ = handle.Close()
+
+		return nil, fmt.Errorf("failed to migrate database %s: %w", path, err)
+	}
+
+	return db, nil
+}
+
+// Close closes the database.
+func (db *DB) Close() error {
+	if err := db.sql.Close(); err != nil {
+		return fmt.Errorf("failed to close database: %w", err)
+	}
+
+	return nil
+}
+
+// migrate applies the migrations the database lacks, each in its own transaction.
+func (db *DB) migrate(ctx context.Context) error {
+	var version int
+	if err := db.sql.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	if version > len(migrations) {
+		return fmt.Errorf("%w: version %d, latest known %d", ErrNewerSchema, version, len(migrations))
+	}
+
+	for ; version < len(migrations); version++ {
+		err := db.transaction(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, migrations[version]); err != nil {
+				return fmt.Errorf("migration %d: %w", version+1, err)
+			}
+
+			// PRAGMA does not take parameters
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", version+1)); err != nil {
+				return fmt.Errorf("migration %d: %w", version+1, err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// transaction runs fn in a transaction, committed if fn succeeds.
+func (db *DB) transaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := db.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Append records a scan or version check result (see history.Store.Append).
+func (db *DB) Append(record history.Record) error {
+	image, err := history.Repository(record.Image)
+	if err != nil {
+		return err //nolint:wrapcheck // Already describes the record
+	}
+
+	ctx := context.Background()
+
+	return db.transaction(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `INSERT INTO records (time, plan, operation, kind, image, version, digest,
+			latest_version, latest_digest, update_available) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			formatTime(record.Time), record.Plan, record.Operation, record.Kind, image, record.Version,
+			record.Digest, record.LatestVersion, record.LatestDigest, record.UpdateAvailable)
+		if err != nil {
+			return fmt.Errorf("failed to write history record: %w", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to write history record: %w", err)
+		}
+
+		for _, vuln := range record.Vulnerabilities {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO vulnerabilities (record, id, severity, package,
+				installed_version, fixed_version, platforms) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+				id, vuln.ID, vuln.Severity, vuln.Package, vuln.InstalledVersion, vuln.FixedVersion,
+				strings.Join(vuln.Platforms, ",")); err != nil {
+				return fmt.Errorf("failed to write history record: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// Records returns the history of an image, oldest first (see history.Store.Records).
+func (db *DB) Records(image string) ([]history.Record, error) {
+	repository, err := history.Repository(image)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Already describes the image
+	}
+
+	return db.records(context.Background(), "WHERE image = ? ORDER BY time, id", repository)
+}
+
+// Images returns the canonical repositories with a recorded history, sorted.
+func (db *DB) Images() ([]string, error) {
+	rows, err := db.sql.QueryContext(context.Background(), "SELECT DISTINCT image FROM records ORDER BY image")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list history: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var images []string
+
+	for rows.Next() {
+		var image string
+		if err := rows.Scan(&image); err != nil {
+			return nil, fmt.Errorf("failed to list history: %w", err)
+		}
+
+		images = append(images, image)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list history: %w", err)
+	}
+
+	return images, nil
+}
+
+// Affected returns, for each image whose latest scan still reports the vulnerability (e.g., "CVE-2024-3094"),
+// that latest scan record, by image (see history.Store.Affected).
+func (db *DB) Affected(vulnerabilityID string) ([]history.Record, error) {
+	return db.records(context.Background(), `WHERE id IN (
+		SELECT latest.id FROM records AS latest
+		WHERE latest.kind = ? AND latest.id = (
+			SELECT newest.id FROM records AS newest
+			WHERE newest.image = latest.image AND newest.kind = latest.kind
+			ORDER BY newest.time DESC, newest.id DESC LIMIT 1
+		)
+		AND EXISTS (SELECT 1 FROM vulnerabilities WHERE record = latest.id AND vulnerabilities.id = ?)
+	) ORDER BY image`, history.KindScan, vulnerabilityID)
+}
+
+// records returns the records matching the query clause, with their vulnerabilities.
+func (db *DB) records(ctx context.Context, clause string, args ...any) ([]history.Record, error) {
+	//nolint:gosec // The clause is a constant of this package, values are parameters
+	rows, err := db.sql.QueryContext(ctx, `SELECT id, time, plan, operation, kind, image, version, digest,
+		latest_version, latest_digest, update_available FROM records `+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var (
+		records []history.Record
+		ids     []int64
+	)
+
+	for rows.Next() {
+		var (
+			record history.Record
+			id     int64
+			when   string
+		)
+
+		if err := rows.Scan(&id, &when, &record.Plan, &record.Operation, &record.Kind, &record.Image,
+			&record.Version, &record.Digest, &record.LatestVersion, &record.LatestDigest,
+			&record.UpdateAvailable); err != nil {
+			_ = rows.Close()
+
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+
+		if record.Time, err = parseTime(when); err != nil {
+			_ = rows.Close()
+
+			return nil, err
+		}
+
+		records = append(records, record)
+		ids = append(ids, id)
+	}
+
+	err = rows.Err()
+	_ = rows.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	for idx, id := range ids {
+		if records[idx].Vulnerabilities, err = db.vulnerabilities(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return records, nil
+}
+
+// vulnerabilities returns the findings of a scan record, sorted by ID and package.
+func (db *DB) vulnerabilities(ctx context.Context, record int64) ([]history.Vulnerability, error) {
+	rows, err := db.sql.QueryContext(ctx, `SELECT id, severity, package, installed_version, fixed_version,
+		platforms FROM vulnerabilities WHERE record = ? ORDER BY id, package`, record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var vulns []history.Vulnerability
+
+	for rows.Next() {
+		var (
+			vuln      history.Vulnerability
+			platforms string
+		)
+
+		if err := rows.Scan(&vuln.ID, &vuln.Severity, &vuln.Package, &vuln.InstalledVersion, &vuln.FixedVersion,
+			&platforms); err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+
+		if platforms != "" {
+			vuln.Platforms = strings.Split(platforms, ",")
+		}
+
+		vulns = append(vulns, vuln)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	return vulns, nil
+}
+
+// WriteState records state, the JSON plan state of an execution of plan with its fingerprint. Earlier states are
+// kept: State returns the latest.
+func (db *DB) WriteState(ctx context.Context, plan, fingerprint string, state json.RawMessage) error {
+	if _, err := db.sql.ExecContext(ctx, `INSERT INTO states (plan, written, fingerprint, state)
+		VALUES (?, ?, ?, ?)`, plan, formatTime(time.Now()), fingerprint, string(state)); err != nil {
+		return fmt.Errorf("failed to write plan state: %w", err)
+	}
+
+	return nil
+}
+
+// State returns the latest JSON plan state recorded for plan, or nil when none was.
+func (db *DB) State(ctx context.Context, plan string) (json.RawMessage, error) {
+	var state string
+
+	err := db.sql.QueryRowContext(ctx, `SELECT state FROM states WHERE plan = ?
+		ORDER BY written DESC, rowid DESC LIMIT 1`, plan).Scan(&state)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan state: %w", err)
+	}
+
+	return json.RawMessage(state), nil
+}
+
+// AppendRun records a run in the execution log.
+func (db *DB) AppendRun(ctx context.Context, run Run) error {
+	return db.transaction(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `INSERT INTO runs (plan, started, duration_ms, error) VALUES (?, ?, ?, ?)`,
+			run.Plan, formatTime(run.Started), run.Duration.Milliseconds(), run.Error)
+		if err != nil {
+			return fmt.Errorf("failed to write run: %w", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to write run: %w", err)
+		}
+
+		for position, op := range run.Operations {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO run_operations (run, position, name, kind, status, started,
+				duration_ms, error, digest) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				id, position, op.Name, op.Kind, op.Status, formatTime(op.Started), op.Duration.Milliseconds(),
+				op.Error, op.Digest); err != nil {
+				return fmt.Errorf("failed to write run: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// Runs returns the last runs of plan (of every plan when empty) in the execution log, newest first, at most
+// limit (all when zero).
+func (db *DB) Runs(ctx context.Context, plan string, limit int) ([]Run, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := db.sql.QueryContext(ctx, `SELECT id, plan, started, duration_ms, error FROM runs
+		WHERE ? = '' OR plan = ? ORDER BY started DESC, id DESC LIMIT ?`, plan, plan, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read runs: %w", err)
+	}
+
+	var (
+		runs []Run
+		ids  []int64
+	)
+
+	for rows.Next() {
+		var (
+			run      Run
+			id       int64
+			started  string
+			duration int64
+		)
+
+		if err := rows.Scan(&id, &run.Plan, &started, &duration, &run.Error); err != nil {
+			_ = rows.Close()
+
+			return nil, fmt.Errorf("failed to read runs: %w", err)
+		}
+
+		if run.Started, err = parseTime(started); err != nil {
+			_ = rows.Close()
+
+			return nil, err
+		}
+
+		run.Duration = time.Duration(duration) * time.Millisecond
+		runs = append(runs, run)
+		ids = append(ids, id)
+	}
+
+	err = rows.Err()
+	_ = rows.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read runs: %w", err)
+	}
+
+	for idx, id := range ids {
+		if runs[idx].Operations, err = db.runOperations(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return runs, nil
+}
+
+// runOperations returns the operations of a run, in plan order.
+func (db *DB) runOperations(ctx context.Context, run int64) ([]RunOperation, error) {
+	rows, err := db.sql.QueryContext(ctx, `SELECT name, kind, status, started, duration_ms, error, digest
+		FROM run_operations WHERE run = ? ORDER BY position`, run)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read runs: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var ops []RunOperation
+
+	for rows.Next() {
+		var (
+			op       RunOperation
+			started  string
+			duration int64
+		)
+
+		if err := rows.Scan(&op.Name, &op.Kind, &op.Status, &started, &duration, &op.Error, &op.Digest); err != nil {
+			return nil, fmt.Errorf("failed to read runs: %w", err)
+		}
+
+		if op.Started, err = parseTime(started); err != nil {
+			return nil, err
+		}
+
+		op.Duration = time.Duration(duration) * time.Millisecond
+		ops = append(ops, op)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read runs: %w", err)
+	}
+
+	return ops, nil
+}
+
+// formatTime formats a time to be stored: UTC RFC 3339 with nanoseconds, which sorts as text (empty for the zero
+// time).
+func formatTime(moment time.Time) string {
+	if moment.IsZero() {
+		return ""
+	}
+
+	return moment.UTC().Format("2006-01-02T15:04:05.000000000Z")
+}
+
+// parseTime parses a stored time.
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	moment, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time in database: %w", err)
+	}
+
+	return moment, nil
+}
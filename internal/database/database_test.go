@@ -0,0 +1,205 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/farcloser/quark/internal/database"
+	"github.com/farcloser/quark/internal/history"
+)
+
+// INTENTION: The database is a history backend answering like the file store: records read back per canonical
+// repository from any reference form, oldest first, with their findings, and cross-image queries only report
+// images whose latest scan still has the vulnerability.
+func TestDB_History(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	db, err := database.Open(ctx, filepath.Join(t.TempDir(), "quark.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var _ history.Backend = db
+
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	openssl := history.Vulnerability{ID: "CVE-2024-0001", Severity: "HIGH", Package: "openssl", Platforms: []string{
+		"linux/amd64", "linux/arm64",
+	}}
+
+	records := []history.Record{
+		{Time: start.Add(time.Hour), Kind: history.KindScan, Image: "alpine:3.20", Digest: "sha256:bbb"},
+		{
+			Time: start, Kind: history.KindScan, Image: "docker.io/library/alpine", Digest: "sha256:aaa",
+			Vulnerabilities: []history.Vulnerability{openssl},
+		},
+		{
+			Time: start, Kind: history.KindScan, Image: "ghcr.io/org/app", Digest: "sha256:ccc",
+			Vulnerabilities: []history.Vulnerability{openssl},
+		},
+		{Time: start.Add(time.Hour), Kind: history.KindVersionCheck, Image: "ghcr.io/org/app", LatestVersion: "2.0"},
+	}
+
+	for _, record := range records {
+		if err := db.Append(record); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	got, err := db.Records("alpine")
+	if err != nil {
+		t.Fatalf("Records() error = %v", err)
+	}
+
+	if len(got) != 2 || got[0].Digest != "sha256:aaa" || got[1].Digest != "sha256:bbb" {
+		t.Fatalf("Records(alpine) = %+v, want alpine records oldest first", got)
+	}
+
+	if got[0].Image != "docker.io/library/alpine" || !got[0].Time.Equal(start) {
+		t.Errorf("Records(alpine)[0] = %+v, want canonical image and run time", got[0])
+	}
+
+	if len(got[0].Vulnerabilities) != 1 || len(got[0].Vulnerabilities[0].Platforms) != 2 {
+		t.Errorf("Records(alpine)[0].Vulnerabilities = %+v, want %+v", got[0].Vulnerabilities, openssl)
+	}
+
+	images, err := db.Images()
+	if err != nil || len(images) != 2 || images[0] != "docker.io/library/alpine" || images[1] != "ghcr.io/org/app" {
+		t.Errorf("Images() = %v, %v, want alpine and app", images, err)
+	}
+
+	// Alpine was fixed by its latest scan; the version check of app is not a scan
+	affected, err := db.Affected(openssl.ID)
+	if err != nil || len(affected) != 1 || affected[0].Image != "ghcr.io/org/app" {
+		t.Errorf("Affected() = %+v, %v, want app only", affected, err)
+	}
+
+	if err := db.Append(history.Record{Image: "INVALID"}); !errors.Is(err, history.ErrInvalidImage) {
+		t.Errorf("Append(invalid image) error = %v, want ErrInvalidImage", err)
+	}
+}
+
+// INTENTION: Plan states and executions are kept per plan: the latest state is returned, and runs are listed
+// newest first with their operations in plan order.
+func TestDB_StatesAndRuns(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	db, err := database.Open(ctx, filepath.Join(t.TempDir(), "quark.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	if state, err := db.State(ctx, "release"); err != nil || state != nil {
+		t.Errorf("State(never written) = %s, %v, want none", state, err)
+	}
+
+	for _, state := range []string{`{"plan":"release","fingerprint":"a"}`, `{"plan":"release","fingerprint":"b"}`} {
+		if err := db.WriteState(ctx, "release", "", []byte(state)); err != nil {
+			t.Fatalf("WriteState() error = %v", err)
+		}
+	}
+
+	if state, err := db.State(ctx, "release"); err != nil || string(state) != `{"plan":"release","fingerprint":"b"}` {
+		t.Errorf("State() = %s, %v, want the latest", state, err)
+	}
+
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	runs := []database.Run{
+		{Plan: "release", Started: start, Duration: time.Minute, Operations: []database.RunOperation{
+			{
+				Name: "sync", Kind: "sync", Status: "succeeded", Started: start, Duration: time.Second,
+				Digest: "sha256:a",
+			},
+			{Name: "scan", Kind: "scan", Status: "skipped"},
+		}},
+		{Plan: "nightly", Started: start.Add(time.Hour), Error: "scan failed"},
+		{Plan: "release", Started: start.Add(2 * time.Hour), Error: "sync failed"},
+	}
+
+	for _, run := range runs {
+		if err := db.AppendRun(ctx, run); err != nil {
+			t.Fatalf("AppendRun() error = %v", err)
+		}
+	}
+
+	got, err := db.Runs(ctx, "release", 0)
+	if err != nil {
+		t.Fatalf("Runs() error = %v", err)
+	}
+
+	if len(got) != 2 || got[0].Error != "sync failed" || got[1].Duration != time.Minute {
+		t.Fatalf("Runs(release) = %+v, want both release runs newest first", got)
+	}
+
+	ops := got[1].Operations
+	if len(ops) != 2 || ops[0].Name != "sync" || ops[0].Digest != "sha256:a" || !ops[1].Started.IsZero() {
+		t.Errorf("Runs(release)[1].Operations = %+v, want sync then scan", ops)
+	}
+
+	if all, err := db.Runs(ctx, "", 1); err != nil || len(all) != 1 || all[0].Plan != "release" {
+		t.Errorf("Runs(all, limit 1) = %+v, %v, want the latest run", all, err)
+	}
+}
+
+// INTENTION: Reopening a database keeps its content and does not migrate it again, and a database migrated by a
+// newer version of quark is refused rather than written with an older schema.
+func TestOpen_Migrations(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "nested", "quark.db")
+
+	db, err := database.Open(ctx, path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	if err := db.WriteState(ctx, "release", "", []byte(`{}`)); err != nil {
+		t.Fatalf("WriteState() error = %v", err)
+	}
+
+	_ = db.Close()
+
+	db, err = database.Open(ctx, path)
+	if err != nil {
+		t.Fatalf("Open(existing) error = %v", err)
+	}
+
+	if state, err := db.State(ctx, "release"); err != nil || state == nil {
+		t.Errorf("State() after reopening = %s, %v, want the state written before", state, err)
+	}
+
+	_ = db.Close()
+
+	raw, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+
+	if _, err := raw.ExecContext(ctx, "PRAGMA user_version = 1000"); err != nil {
+		t.Fatalf("PRAGMA error = %v", err)
+	}
+
+	_ = raw.Close()
+
+	if _, err := database.Open(ctx, path); !errors.Is(err, database.ErrNewerSchema) {
+		t.Errorf("Open(newer schema) error = %v, want ErrNewerSchema", err)
+	}
+}
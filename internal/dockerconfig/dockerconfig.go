@@ -0,0 +1,82 @@
+// Package dockerconfig provides short-lived docker configs holding registry credentials for external tools
+// (trivy, dockle), so they never depend on, or leak into, the docker login state of the host.
+package dockerconfig
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/farcloser/quark/filesystem"
+)
+
+// dockerHubAuthKey is the docker config key used for Docker Hub credentials.
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// Config is a private temporary docker config directory holding credentials for a single registry,
+// suitable for DOCKER_CONFIG.
+type Config struct {
+	dir string
+}
+
+// Write writes a docker config.json holding credentials for registryHost into a new private temporary directory.
+// The caller is responsible for calling Scrub once the tool using it has exited.
+func Write(registryHost, username, password string) (*Config, error) {
+	key := registryHost
+	if registryHost == "docker.io" || registryHost == "index.docker.io" || registryHost == "registry-1.docker.io" {
+		key = dockerHubAuthKey
+	}
+
+	config := map[string]map[string]map[string]string{
+		"auths": {
+			key: {"auth": base64.StdEncoding.EncodeToString([]byte(username + ":" + password))},
+		},
+	}
+
+	content, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode docker config: %w", err)
+	}
+
+	// os.MkdirTemp creates the directory with DirPermissionsPrivate
+	dir, err := os.MkdirTemp("", "quark-docker-config-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker config directory: %w", err)
+	}
+
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, content, filesystem.FilePermissionsPrivate); err != nil {
+		_ = os.RemoveAll(dir)
+
+		return nil, fmt.Errorf("failed to write docker config: %w", err)
+	}
+
+	return &Config{dir: dir}, nil
+}
+
+// Dir returns the docker config directory.
+func (config *Config) Dir() string {
+	return config.dir
+}
+
+// Env returns the DOCKER_CONFIG environment entry pointing tools at the config.
+func (config *Config) Env() string {
+	return "DOCKER_CONFIG=" + config.dir
+}
+
+// Scrub overwrites the credentials with zeros and removes the config directory.
+func (config *Config) Scrub() error {
+	path := filepath.Join(config.dir, "config.json")
+
+	if info, err := os.Stat(path); err == nil {
+		_ = os.WriteFile(path, make([]byte, info.Size()), filesystem.FilePermissionsPrivate)
+	}
+
+	if err := os.RemoveAll(config.dir); err != nil {
+		return fmt.Errorf("failed to remove docker config: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package dockerconfig_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/internal/dockerconfig"
+)
+
+// INTENTION: The generated docker config holds credentials for exactly the audited registry,
+// is private to the current user, and maps Docker Hub aliases to the canonical auth key.
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		host    string
+		wantKey string
+	}{
+		{name: "private registry", host: "ghcr.io", wantKey: "ghcr.io"},
+		{name: "docker hub", host: "docker.io", wantKey: "https://index.docker.io/v1/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := dockerconfig.Write(tt.host, "user", "secret")
+			if err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			t.Cleanup(func() { _ = config.Scrub() })
+
+			if env := config.Env(); env != "DOCKER_CONFIG="+config.Dir() {
+				t.Errorf("Env() = %q, want DOCKER_CONFIG=%s", env, config.Dir())
+			}
+
+			path := filepath.Join(config.Dir(), "config.json")
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatalf("config.json missing: %v", err)
+			}
+
+			if info.Mode().Perm() != filesystem.FilePermissionsPrivate {
+				t.Errorf("config.json mode = %v, want %o", info.Mode().Perm(), filesystem.FilePermissionsPrivate)
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read config.json: %v", err)
+			}
+
+			var parsed struct {
+				Auths map[string]struct {
+					Auth string `json:"auth"`
+				} `json:"auths"`
+			}
+
+			if err := json.Unmarshal(content, &parsed); err != nil {
+				t.Fatalf("invalid config.json: %v", err)
+			}
+
+			if len(parsed.Auths) != 1 {
+				t.Errorf("config has %d auth entries, want 1", len(parsed.Auths))
+			}
+
+			want := base64.StdEncoding.EncodeToString([]byte("user:secret"))
+			if parsed.Auths[tt.wantKey].Auth != want {
+				t.Errorf("auth for %q = %q, want %q", tt.wantKey, parsed.Auths[tt.wantKey].Auth, want)
+			}
+		})
+	}
+}
+
+// INTENTION: Scrub removes the credentials once the tool is done with them.
+func TestConfig_Scrub(t *testing.T) {
+	t.Parallel()
+
+	config, err := dockerconfig.Write("ghcr.io", "user", "secret")
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if err := config.Scrub(); err != nil {
+		t.Fatalf("Scrub() error = %v", err)
+	}
+
+	if _, err := os.Stat(config.Dir()); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("config directory still exists after Scrub(): %v", err)
+	}
+}
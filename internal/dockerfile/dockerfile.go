@@ -0,0 +1,212 @@
+// Package dockerfile extracts base image references from Dockerfiles.
+package dockerfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// scratch is the reserved empty base image.
+const scratch = "scratch"
+
+// variablePattern matches $NAME, ${NAME}, ${NAME:-default} and ${NAME-default}.
+var variablePattern = regexp.MustCompile(`\$(?:\{([A-Za-z_][A-Za-z0-9_]*)(?::?-([^}]*))?\}|([A-Za-z_][A-Za-z0-9_]*))`)
+
+// BaseImage is an image referenced by a FROM instruction.
+type BaseImage struct {
+	// Reference is the image reference with build arguments expanded (e.g., "alpine:3.20").
+	Reference string
+	// Raw is the reference as written (e.g., "alpine:${ALPINE_VERSION}").
+	Raw string
+	// Platform is the --platform flag value, if any.
+	Platform string
+	// Stage is the stage name (FROM ... AS <stage>), if any.
+	Stage string
+	// Line is the line number of the FROM instruction (1-based).
+	Line int
+	// Unresolved lists build arguments used by the reference that have no default value.
+	Unresolved []string
+}
+
+// Pinned reports whether the base image is pinned by digest.
+func (base *BaseImage) Pinned() bool {
+	return strings.Contains(base.Reference, "@sha256:")
+}
+
+// ParseFile extracts the base images of a Dockerfile.
+func ParseFile(path string) ([]BaseImage, error) {
+	// #nosec G304 -- Dockerfile path provided by the plan author
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Dockerfile: %w", err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	return Parse(file)
+}
+
+// Parse extracts the base images of a Dockerfile, in order.
+// Build arguments declared before the first FROM (ARG NAME=default) are expanded with their defaults.
+// FROM instructions referencing an earlier stage, and scratch, are not base images and are skipped.
+func Parse(reader io.Reader) ([]BaseImage, error) {
+	args := make(map[string]string)
+	stages := make(map[string]bool)
+
+	var (
+		bases    []BaseImage
+		seenFrom bool
+	)
+
+	err := forEachInstruction(reader, func(line int, instruction string) {
+		fields := strings.Fields(instruction)
+		if len(fields) < 2 { //nolint:mnd // instruction and at least one argument
+			return
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "ARG":
+			// Only global build arguments (before the first FROM) are in scope for FROM lines
+			if seenFrom {
+				return
+			}
+
+			for _, declaration := range fields[1:] {
+				name, value, _ := strings.Cut(declaration, "=")
+				args[name] = strings.Trim(value, `"'`)
+			}
+		case "FROM":
+			seenFrom = true
+
+			base, ok := parseFrom(line, fields[1:], args, stages)
+			if ok {
+				bases = append(bases, base)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bases, nil
+}
+
+// parseFrom parses the arguments of a FROM instruction, recording its stage name.
+func parseFrom(line int, fields []string, args map[string]string, stages map[string]bool) (BaseImage, bool) {
+	base := BaseImage{Line: line}
+
+	for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+		if value, ok := strings.CutPrefix(fields[0], "--platform="); ok {
+			base.Platform = value
+		}
+
+		fields = fields[1:]
+	}
+
+	if len(fields) == 0 {
+		return base, false
+	}
+
+	base.Raw = fields[0]
+
+	if len(fields) >= 3 && strings.EqualFold(fields[1], "AS") { //nolint:mnd // FROM <image> AS <stage>
+		base.Stage = strings.ToLower(fields[2])
+	}
+
+	base.Reference, base.Unresolved = expand(base.Raw, args)
+
+	// Stage references and scratch are not base images
+	isStage := stages[strings.ToLower(base.Reference)]
+
+	if base.Stage != "" {
+		stages[base.Stage] = true
+	}
+
+	if isStage || base.Reference == scratch {
+		return base, false
+	}
+
+	return base, true
+}
+
+// expand substitutes build arguments, returning the names of arguments without a value.
+func expand(raw string, args map[string]string) (string, []string) {
+	var unresolved []string
+
+	expanded := variablePattern.ReplaceAllStringFunc(raw, func(match string) string {
+		groups := variablePattern.FindStringSubmatch(match)
+
+		name := groups[1]
+		if name == "" {
+			name = groups[3]
+		}
+
+		if value := args[name]; value != "" {
+			return value
+		}
+
+		if groups[2] != "" {
+			return groups[2]
+		}
+
+		unresolved = append(unresolved, name)
+
+		return ""
+	})
+
+	return expanded, unresolved
+}
+
+// forEachInstruction calls fn for every instruction, with continuation lines joined and comments removed.
+func forEachInstruction(reader io.Reader, fn func(line int, instruction string)) error {
+	scanner := bufio.NewScanner(reader)
+
+	var (
+		current   strings.Builder
+		startLine int
+		lineNum   int
+	)
+
+	for scanner.Scan() {
+		lineNum++
+
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if current.Len() == 0 {
+			if text == "" {
+				continue
+			}
+
+			startLine = lineNum
+		}
+
+		if continued, ok := strings.CutSuffix(text, `\`); ok {
+			current.WriteString(continued + " ")
+
+			continue
+		}
+
+		current.WriteString(text)
+		fn(startLine, current.String())
+		current.Reset()
+	}
+
+	if current.Len() > 0 {
+		fn(startLine, current.String())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read Dockerfile: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package dockerfile_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/farcloser/quark/internal/dockerfile"
+)
+
+// INTENTION: Base images are extracted with global build arguments expanded, while stage references,
+// scratch and unresolvable arguments are handled so that only real base images are reported.
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	content := `# syntax=docker/dockerfile:1
+ARG GO_VERSION=1.24
+ARG DEBIAN_SUITE
+FROM --platform=$BUILDPLATFORM golang:${GO_VERSION}-alpine AS build
+ARG GO_VERSION=ignored
+RUN go build \
+    -o /app .
+
+FROM build AS test
+FROM debian:${DEBIAN_SUITE:-bookworm}-slim
+FROM alpine:3.20@sha256:0000000000000000000000000000000000000000000000000000000000000000
+FROM registry.example.com/base:$UNSET
+FROM scratch
+COPY --from=build /app /app
+`
+
+	bases, err := dockerfile.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := []struct {
+		reference  string
+		line       int
+		pinned     bool
+		unresolved []string
+	}{
+		{reference: "golang:1.24-alpine", line: 4},
+		{reference: "debian:bookworm-slim", line: 10},
+		{reference: "alpine:3.20@sha256:0000000000000000000000000000000000000000000000000000000000000000", line: 11, pinned: true},
+		{reference: "registry.example.com/base:", line: 12, unresolved: []string{"UNSET"}},
+	}
+
+	if len(bases) != len(want) {
+		t.Fatalf("Parse() = %+v, want %d base images", bases, len(want))
+	}
+
+	for idx, base := range bases {
+		if base.Reference != want[idx].reference || base.Line != want[idx].line || base.Pinned() != want[idx].pinned ||
+			!slices.Equal(base.Unresolved, want[idx].unresolved) {
+			t.Errorf("base %d = %+v, want %+v", idx, base, want[idx])
+		}
+	}
+
+	if bases[0].Stage != "build" || bases[0].Platform != "$BUILDPLATFORM" {
+		t.Errorf("first base stage = %q, platform = %q, want build and $BUILDPLATFORM", bases[0].Stage, bases[0].Platform)
+	}
+}
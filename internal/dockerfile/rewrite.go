@@ -0,0 +1,168 @@
+package dockerfile
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around changes in unified diffs.
+const diffContext = 3
+
+// ErrReferenceNotFound indicates a FROM instruction reference to rewrite was not found.
+var ErrReferenceNotFound = errors.New("FROM reference not found")
+
+// Replacement rewrites the image reference of a FROM instruction.
+type Replacement struct {
+	// Line is the line number of the FROM instruction (BaseImage.Line).
+	Line int
+	// Old is the reference as written (BaseImage.Raw).
+	Old string
+	// New is the reference to write instead.
+	New string
+}
+
+// PinnedReference returns raw pinned to digest as name:tag@digest, replacing any existing digest.
+// Untagged references are tagged "latest" explicitly, so the tag the digest was resolved from stays visible.
+func PinnedReference(raw, digest string, tagged bool) string {
+	name, _, _ := strings.Cut(raw, "@")
+
+	if !tagged {
+		name += ":latest"
+	}
+
+	return name + "@" + digest
+}
+
+// Rewrite applies replacements to the content of a Dockerfile, leaving everything else untouched.
+// The reference of a FROM instruction continued over several lines is looked up on its continuation lines.
+func Rewrite(content string, replacements []Replacement) (string, error) {
+	lines := strings.Split(content, "\n")
+
+	for _, replacement := range replacements {
+		if !replaceReference(lines, replacement) {
+			return "", fmt.Errorf("%w: %q on line %d", ErrReferenceNotFound, replacement.Old, replacement.Line)
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// replaceReference replaces the first whitespace-delimited occurrence of the old reference,
+// starting on the FROM line and following continuation lines.
+func replaceReference(lines []string, replacement Replacement) bool {
+	for idx := replacement.Line - 1; idx >= 0 && idx < len(lines); idx++ {
+		fields := strings.Fields(lines[idx])
+
+		for _, field := range fields {
+			if field != replacement.Old {
+				continue
+			}
+
+			start := fieldIndex(lines[idx], field)
+			lines[idx] = lines[idx][:start] + replacement.New + lines[idx][start+len(field):]
+
+			return true
+		}
+
+		if !strings.HasSuffix(strings.TrimSpace(lines[idx]), `\`) {
+			return false
+		}
+	}
+
+	return false
+}
+
+// fieldIndex returns the index of the first whitespace-delimited occurrence of field in line.
+func fieldIndex(line, field string) int {
+	offset := 0
+
+	for {
+		idx := strings.Index(line[offset:], field)
+		if idx < 0 {
+			return -1
+		}
+
+		start := offset + idx
+		end := start + len(field)
+
+		if (start == 0 || isSpace(line[start-1])) && (end == len(line) || isSpace(line[end])) {
+			return start
+		}
+
+		offset = end
+	}
+}
+
+func isSpace(char byte) bool {
+	return char == ' ' || char == '\t'
+}
+
+// Diff returns a unified diff (as produced by diff -u) between two versions of the file at path
+// with the same number of lines, such as a Dockerfile and its rewrite. Returns "" if they are identical.
+func Diff(path, before, after string) string {
+	oldLines := strings.Split(strings.TrimSuffix(before, "\n"), "\n")
+	newLines := strings.Split(strings.TrimSuffix(after, "\n"), "\n")
+
+	if len(oldLines) != len(newLines) {
+		return ""
+	}
+
+	var changed []int
+
+	for idx := range oldLines {
+		if oldLines[idx] != newLines[idx] {
+			changed = append(changed, idx)
+		}
+	}
+
+	if len(changed) == 0 {
+		return ""
+	}
+
+	var diff strings.Builder
+
+	fmt.Fprintf(&diff, "--- a/%s\n+++ b/%s\n", path, path)
+
+	for len(changed) > 0 {
+		// Group changes whose contexts overlap into one hunk
+		last := 1
+		for last < len(changed) && changed[last]-changed[last-1] <= 2*diffContext {
+			last++
+		}
+
+		start := max(changed[0]-diffContext, 0)
+		end := min(changed[last-1]+diffContext+1, len(oldLines))
+
+		fmt.Fprintf(&diff, "@@ -%d,%d +%d,%d @@\n", start+1, end-start, start+1, end-start)
+
+		for idx := start; idx < end; {
+			if oldLines[idx] == newLines[idx] {
+				diff.WriteString(" " + oldLines[idx] + "\n")
+				idx++
+
+				continue
+			}
+
+			// Consecutive changed lines are removed, then added, as one block
+			run := idx
+			for run < end && oldLines[run] != newLines[run] {
+				run++
+			}
+
+			for _, line := range oldLines[idx:run] {
+				diff.WriteString("-" + line + "\n")
+			}
+
+			for _, line := range newLines[idx:run] {
+				diff.WriteString("+" + line + "\n")
+			}
+
+			idx = run
+		}
+
+		changed = changed[last:]
+	}
+
+	return diff.String()
+}
@@ -0,0 +1,75 @@
+package dockerfile_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/farcloser/quark/internal/dockerfile"
+)
+
+const digest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+
+// INTENTION: Rewriting only touches the FROM references being pinned (including references on continuation
+// lines and written with build arguments), and the diff of the rewrite is a unified diff git apply accepts.
+func TestRewrite(t *testing.T) {
+	t.Parallel()
+
+	content := `ARG GO_VERSION=1.24
+FROM golang:${GO_VERSION} AS build
+RUN echo golang:${GO_VERSION}
+FROM --platform=linux/amd64 \
+    alpine AS runtime
+COPY --from=build /app /app
+`
+
+	rewritten, err := dockerfile.Rewrite(content, []dockerfile.Replacement{
+		{Line: 2, Old: "golang:${GO_VERSION}", New: dockerfile.PinnedReference("golang:${GO_VERSION}", digest, true)},
+		{Line: 4, Old: "alpine", New: dockerfile.PinnedReference("alpine", digest, false)},
+	})
+	if err != nil {
+		t.Fatalf("Rewrite() error = %v", err)
+	}
+
+	want := `ARG GO_VERSION=1.24
+FROM golang:${GO_VERSION}@` + digest + ` AS build
+RUN echo golang:${GO_VERSION}
+FROM --platform=linux/amd64 \
+    alpine:latest@` + digest + ` AS runtime
+COPY --from=build /app /app
+`
+	if rewritten != want {
+		t.Errorf("Rewrite() =\n%s\nwant\n%s", rewritten, want)
+	}
+
+	wantDiff := `--- a/Dockerfile
++++ b/Dockerfile
+@@ -1,6 +1,6 @@
+ ARG GO_VERSION=1.24
+-FROM golang:${GO_VERSION} AS build
++FROM golang:${GO_VERSION}@` + digest + ` AS build
+ RUN echo golang:${GO_VERSION}
+ FROM --platform=linux/amd64 \
+-    alpine AS runtime
++    alpine:latest@` + digest + ` AS runtime
+ COPY --from=build /app /app
+`
+	if diff := dockerfile.Diff("Dockerfile", content, rewritten); diff != wantDiff {
+		t.Errorf("Diff() =\n%s\nwant\n%s", diff, wantDiff)
+	}
+
+	if _, err := dockerfile.Rewrite(content, []dockerfile.Replacement{{Line: 6, Old: "alpine", New: "x"}}); !errors.Is(
+		err,
+		dockerfile.ErrReferenceNotFound,
+	) {
+		t.Errorf("Rewrite() of a missing reference error = %v, want %v", err, dockerfile.ErrReferenceNotFound)
+	}
+}
+
+// INTENTION: Pinning replaces an existing (stale) digest rather than appending a second one.
+func TestPinnedReference(t *testing.T) {
+	t.Parallel()
+
+	if got := dockerfile.PinnedReference("alpine:3.20@sha256:old", digest, true); got != "alpine:3.20@"+digest {
+		t.Errorf("PinnedReference() = %q, want %q", got, "alpine:3.20@"+digest)
+	}
+}
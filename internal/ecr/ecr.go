@@ -0,0 +1,218 @@
+// Package ecr creates Amazon ECR repositories through the ECR API, signed with AWS Signature Version 4, so
+// pushes to repositories that do not exist yet do not fail.
+package ecr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/farcloser/quark/internal/awssig"
+)
+
+const (
+	// targetPrefix is the X-Amz-Target prefix of the ECR API actions.
+	targetPrefix = "AmazonEC2ContainerRegistry_V20150921."
+	// service is the signing name of the ECR API.
+	service = "ecr"
+)
+
+var (
+	// ErrNotECR indicates a registry host is not an ECR private registry.
+	ErrNotECR = errors.New("not an Amazon ECR registry host")
+	// ErrCredentialsRequired indicates no AWS credentials are available to sign requests.
+	ErrCredentialsRequired = errors.New("AWS credentials are required")
+	// ErrRequestFailed indicates the ECR API rejected a request.
+	ErrRequestFailed = errors.New("ECR API request failed")
+)
+
+// hostPattern matches private registry hosts: <account>.dkr.ecr[-fips].<region>.amazonaws.com[.cn].
+var hostPattern = regexp.MustCompile(`^(\d{12})\.dkr\.ecr(-fips)?\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+
+// ParseHost returns the account and region of an ECR private registry host
+// (e.g., "123456789012.dkr.ecr.eu-west-1.amazonaws.com"), or ErrNotECR.
+func ParseHost(host string) (string, string, error) {
+	match := hostPattern.FindStringSubmatch(host)
+	if match == nil {
+		return "", "", fmt.Errorf("%w: %s", ErrNotECR, host)
+	}
+
+	return match[1], match[3], nil
+}
+
+// RepositoryConfig configures the repositories a client creates.
+type RepositoryConfig struct {
+	// Tags are the resource tags of created repositories.
+	Tags map[string]string
+	// LifecyclePolicy is the lifecycle policy (JSON document) of created repositories, none when empty.
+	LifecyclePolicy string
+}
+
+// Client creates the repositories of an ECR private registry.
+type Client struct {
+	endpoint string
+	account  string
+	region   string
+	creds    awssig.Credentials
+
+	// HTTP client sending the requests (http.DefaultClient when nil)
+	client *http.Client
+}
+
+// NewClient creates a client for the ECR private registry at host, signing requests with creds.
+func NewClient(host string, creds awssig.Credentials) (*Client, error) {
+	account, region, err := ParseHost(host)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := "https://api.ecr." + region + ".amazonaws.com"
+	if strings.HasSuffix(host, ".cn") {
+		endpoint += ".cn"
+	}
+
+	return &Client{endpoint: endpoint, account: account, region: region, creds: creds}, nil
+}
+
+// WithEndpoint sets the ECR API endpoint (e.g., a VPC endpoint).
+func (client *Client) WithEndpoint(endpoint string) *Client {
+	client.endpoint = strings.TrimSuffix(endpoint, "/")
+
+	return client
+}
+
+// WithHTTPClient sets the HTTP client sending the requests.
+func (client *Client) WithHTTPClient(httpClient *http.Client) *Client {
+	client.client = httpClient
+
+	return client
+}
+
+// EnsureRepository creates the repository named name (e.g., "team/app") with config if it does not exist.
+// Existing repositories are left unchanged. Returns whether the repository was created.
+func (client *Client) EnsureRepository(ctx context.Context, name string, config RepositoryConfig) (bool, error) {
+	err := client.call(ctx, "DescribeRepositories", map[string]any{
+		"registryId":      client.account,
+		"repositoryNames": []string{name},
+	})
+	if err == nil {
+		return false, nil
+	}
+
+	if !isException(err, "RepositoryNotFoundException") {
+		return false, err
+	}
+
+	tags := make([]map[string]string, 0, len(config.Tags))
+	for key, value := range config.Tags {
+		tags = append(tags, map[string]string{"Key": key, "Value": value})
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i]["Key"] < tags[j]["Key"]
+	})
+
+	err = client.call(ctx, "CreateRepository", map[string]any{
+		"registryId":     client.account,
+		"repositoryName": name,
+		"tags":           tags,
+	})
+
+	// Created meanwhile (e.g., by a concurrent push)
+	if isException(err, "RepositoryAlreadyExistsException") {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	if config.LifecyclePolicy != "" {
+		if err := client.call(ctx, "PutLifecyclePolicy", map[string]any{
+			"registryId":          client.account,
+			"repositoryName":      name,
+			"lifecyclePolicyText": config.LifecyclePolicy,
+		}); err != nil {
+			return true, err
+		}
+	}
+
+	return true, nil
+}
+
+// apiError is an ECR API error response.
+type apiError struct {
+	Type    string `json:"__type"` //nolint:tagliatelle // ECR API
+	Message string `json:"message"`
+}
+
+func (err *apiError) Error() string {
+	return err.Type + ": " + err.Message
+}
+
+// isException reports whether err is the ECR API exception named exception.
+func isException(err error, exception string) bool {
+	var failure *apiError
+	if !errors.As(err, &failure) {
+		return false
+	}
+
+	// Types may be qualified (e.g., "com.amazonaws.ecr#RepositoryNotFoundException")
+	return failure.Type == exception || strings.HasSuffix(failure.Type, "#"+exception)
+}
+
+// call sends the ECR API action with payload, discarding the response.
+func (client *Client) call(ctx context.Context, action string, payload any) error {
+	if client.creds.AccessKey == "" || client.creds.SecretKey == "" {
+		return ErrCredentialsRequired
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, client.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", targetPrefix+action)
+	awssig.Sign(req, awssig.HashHex(body), client.creds, client.region, service)
+
+	httpClient := client.client
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	//nolint:mnd // Error bodies are short JSON documents
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	failure := &apiError{}
+	if json.Unmarshal(detail, failure) != nil || failure.Type == "" {
+		failure = &apiError{Type: resp.Status, Message: strings.TrimSpace(string(detail))}
+	}
+
+	return fmt.Errorf("%w: %s: %w", ErrRequestFailed, action, failure)
+}
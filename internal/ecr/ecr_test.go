@@ -0,0 +1,155 @@
+package ecr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/farcloser/quark/internal/awssig"
+	"github.com/farcloser/quark/internal/ecr"
+)
+
+const testHost = "123456789012.dkr.ecr.eu-west-1.amazonaws.com"
+
+// fakeECR is a minimal ECR API checking signed requests, recording the actions it receives.
+type fakeECR struct {
+	mu           sync.Mutex
+	repositories map[string]map[string]any
+	actions      []string
+}
+
+func (fake *fakeECR) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	if !strings.Contains(req.Header.Get("Authorization"), "/eu-west-1/ecr/aws4_request") {
+		http.Error(writer, `{"__type":"UnrecognizedClientException","message":"unsigned"}`, http.StatusForbidden)
+
+		return
+	}
+
+	var payload map[string]any
+	_ = json.NewDecoder(req.Body).Decode(&payload)
+
+	action := strings.TrimPrefix(req.Header.Get("X-Amz-Target"), "AmazonEC2ContainerRegistry_V20150921.")
+	fake.actions = append(fake.actions, action)
+
+	fail := func(exception string) {
+		writer.WriteHeader(http.StatusBadRequest)
+		_, _ = writer.Write([]byte(`{"__type":"com.amazonaws.ecr#` + exception + `","message":"` + exception + `"}`))
+	}
+
+	switch action {
+	case "DescribeRepositories":
+		names, _ := payload["repositoryNames"].([]any)
+		name, _ := names[0].(string)
+
+		if _, ok := fake.repositories[name]; !ok {
+			fail("RepositoryNotFoundException")
+
+			return
+		}
+	case "CreateRepository":
+		name, _ := payload["repositoryName"].(string)
+		fake.repositories[name] = payload
+	case "PutLifecyclePolicy":
+		name, _ := payload["repositoryName"].(string)
+		fake.repositories[name]["lifecyclePolicyText"] = payload["lifecyclePolicyText"]
+	default:
+		fail("InvalidAction")
+
+		return
+	}
+
+	_, _ = writer.Write([]byte(`{}`))
+}
+
+func newFakeECR(t *testing.T) (*fakeECR, string) {
+	t.Helper()
+
+	fake := &fakeECR{repositories: map[string]map[string]any{}}
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	return fake, server.URL
+}
+
+// INTENTION: Missing repositories are created once, with their tags and lifecycle policy; existing ones are left
+// unchanged.
+func TestClient_EnsureRepository(t *testing.T) {
+	t.Parallel()
+
+	fake, endpoint := newFakeECR(t)
+
+	client, err := ecr.NewClient(testHost, awssig.Credentials{AccessKey: "AKID", SecretKey: "secret"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	client.WithEndpoint(endpoint)
+
+	config := ecr.RepositoryConfig{
+		Tags:            map[string]string{"team": "platform"},
+		LifecyclePolicy: `{"rules":[]}`,
+	}
+
+	for run := range 2 {
+		created, err := client.EnsureRepository(t.Context(), "team/app", config)
+		if err != nil {
+			t.Fatalf("EnsureRepository() error = %v", err)
+		}
+
+		if created != (run == 0) {
+			t.Errorf("EnsureRepository() created = %v on run %d", created, run)
+		}
+	}
+
+	want := []string{"DescribeRepositories", "CreateRepository", "PutLifecyclePolicy", "DescribeRepositories"}
+	if strings.Join(fake.actions, ",") != strings.Join(want, ",") {
+		t.Errorf("actions = %v, want %v", fake.actions, want)
+	}
+
+	repository := fake.repositories["team/app"]
+	if repository["registryId"] != "123456789012" || repository["lifecyclePolicyText"] != config.LifecyclePolicy {
+		t.Errorf("repository = %v, want registry 123456789012 with the lifecycle policy", repository)
+	}
+
+	tags, _ := repository["tags"].([]any)
+	if len(tags) != 1 {
+		t.Errorf("tags = %v, want team=platform", repository["tags"])
+	}
+}
+
+// INTENTION: Only ECR private registry hosts are accepted, and requests need credentials.
+func TestClient_Errors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ecr.NewClient("ghcr.io", awssig.Credentials{}); !errors.Is(err, ecr.ErrNotECR) {
+		t.Errorf("NewClient(ghcr.io) error = %v, want %v", err, ecr.ErrNotECR)
+	}
+
+	account, region, err := ecr.ParseHost("123456789012.dkr.ecr-fips.us-gov-west-1.amazonaws.com")
+	if err != nil || account != "123456789012" || region != "us-gov-west-1" {
+		t.Errorf("ParseHost() = %q, %q, %v", account, region, err)
+	}
+
+	_, endpoint := newFakeECR(t)
+
+	client, err := ecr.NewClient(testHost, awssig.Credentials{})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	client.WithEndpoint(endpoint)
+
+	if _, err := client.EnsureRepository(t.Context(), "app", ecr.RepositoryConfig{}); !errors.Is(
+		err, ecr.ErrCredentialsRequired,
+	) {
+		t.Errorf("EnsureRepository() error = %v, want %v", err, ecr.ErrCredentialsRequired)
+	}
+}
@@ -0,0 +1,207 @@
+// Package exceptions reads waiver files: findings (vulnerabilities, audit checks) accepted for a while,
+// with a reason and an owner, shared by scans and audits.
+package exceptions
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/farcloser/quark/internal/reference"
+)
+
+// DefaultFile is the conventional name of the waiver file.
+const DefaultFile = "quark-exceptions.yaml"
+
+// dateLayout is the format of expiry dates.
+const dateLayout = "2006-01-02"
+
+// ErrInvalid indicates a waiver file that cannot be read or fails validation.
+var ErrInvalid = errors.New("invalid exceptions file")
+
+// Exception waives a finding until it expires.
+type Exception struct {
+	// ID is the waived vulnerability (e.g., "CVE-2024-1234") or audit check (e.g., "DKL-DI-0005", "DL3008").
+	ID string
+	// Images restricts the exception to the images matching one of these patterns (path.Match syntax,
+	// e.g., "ghcr.io/org/*"), against their canonical ("docker.io/library/alpine") or familiar ("alpine")
+	// name. Exceptions without images apply to every image and Dockerfile.
+	Images []string
+	Reason string
+	Owner  string
+	// Expires is the last day the exception applies (UTC).
+	Expires time.Time
+}
+
+// Set is the content of a waiver file.
+type Set struct {
+	Exceptions []Exception
+}
+
+// document is the YAML format of a waiver file.
+type document struct {
+	Exceptions []struct {
+		ID      string   `yaml:"id"`
+		Images  []string `yaml:"images"`
+		Reason  string   `yaml:"reason"`
+		Owner   string   `yaml:"owner"`
+		Expires string   `yaml:"expires"`
+	} `yaml:"exceptions"`
+}
+
+// Load reads and validates the waiver file at filePath.
+func Load(filePath string) (*Set, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+
+	set, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+
+	return set, nil
+}
+
+// Parse validates a waiver file. Every exception needs an ID, a reason, an owner and an expiry date
+// (YYYY-MM-DD); all problems are reported at once. Unknown fields are rejected, to report typos.
+// Expired exceptions are valid: they no longer apply, and are reported by Expired.
+func Parse(data []byte) (*Set, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var doc document
+	if err := decoder.Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+
+	set := &Set{}
+
+	var problems []string
+
+	for idx, entry := range doc.Exceptions {
+		name := fmt.Sprintf("exception %d", idx+1)
+		if entry.ID != "" {
+			name += " (" + entry.ID + ")"
+		}
+
+		var missing []string
+
+		for field, value := range map[string]string{"id": entry.ID, "reason": entry.Reason, "owner": entry.Owner} {
+			if strings.TrimSpace(value) == "" {
+				missing = append(missing, field)
+			}
+		}
+
+		if len(missing) > 0 {
+			slices.Sort(missing)
+			problems = append(problems, fmt.Sprintf("%s: missing %s", name, strings.Join(missing, ", ")))
+		}
+
+		expires, err := time.Parse(dateLayout, entry.Expires)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: expires %q is not a YYYY-MM-DD date", name, entry.Expires))
+		}
+
+		for _, pattern := range entry.Images {
+			if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+				problems = append(problems, fmt.Sprintf("%s: invalid image pattern %q", name, pattern))
+			}
+		}
+
+		set.Exceptions = append(set.Exceptions, Exception{
+			ID:      strings.TrimSpace(entry.ID),
+			Images:  entry.Images,
+			Reason:  strings.TrimSpace(entry.Reason),
+			Owner:   strings.TrimSpace(entry.Owner),
+			Expires: expires,
+		})
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalid, strings.Join(problems, "; "))
+	}
+
+	return set, nil
+}
+
+// Active reports whether the exception still applies at now (through the end of its expiry day).
+func (exception Exception) Active(now time.Time) bool {
+	return now.Before(exception.Expires.AddDate(0, 0, 1))
+}
+
+// Covers reports whether the exception applies to image (any reference form; empty for Dockerfiles):
+// exceptions without images cover everything, the others only the images they match.
+func (exception Exception) Covers(image string) bool {
+	if len(exception.Images) == 0 {
+		return true
+	}
+
+	if image == "" {
+		return false
+	}
+
+	names := []string{image}
+
+	if ref, err := reference.Parse(image); err == nil {
+		names = append(names, ref.Name(), ref.FamiliarName())
+	}
+
+	for _, pattern := range exception.Images {
+		for _, name := range names {
+			if matched, _ := path.Match(pattern, name); matched {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Applicable returns the exceptions active at now that cover image.
+func (set *Set) Applicable(image string, now time.Time) []Exception {
+	var applicable []Exception
+
+	for _, exception := range set.Exceptions {
+		if exception.Active(now) && exception.Covers(image) {
+			applicable = append(applicable, exception)
+		}
+	}
+
+	return applicable
+}
+
+// Expired returns the exceptions that no longer apply at now.
+func (set *Set) Expired(now time.Time) []Exception {
+	var expired []Exception
+
+	for _, exception := range set.Exceptions {
+		if !exception.Active(now) {
+			expired = append(expired, exception)
+		}
+	}
+
+	return expired
+}
+
+// Expiring returns the exceptions active at now that expire within the given duration.
+func (set *Set) Expiring(now time.Time, within time.Duration) []Exception {
+	var expiring []Exception
+
+	for _, exception := range set.Exceptions {
+		if exception.Active(now) && !exception.Active(now.Add(within)) {
+			expiring = append(expiring, exception)
+		}
+	}
+
+	return expiring
+}
@@ -0,0 +1,119 @@
+package exceptions_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/farcloser/quark/internal/exceptions"
+)
+
+const testFile = `
+exceptions:
+  - id: CVE-2024-0001
+    images: ["ghcr.io/org/*", "alpine"]
+    reason: Not reachable, the vulnerable function is never called
+    owner: team-security
+    expires: 2026-10-31
+  - id: DKL-DI-0005
+    reason: apk cache cleared in a later layer
+    owner: team-platform
+    expires: 2026-01-01
+`
+
+// INTENTION: Exceptions apply to the images their patterns match (canonical or familiar names), until the end
+// of their expiry day; unscoped exceptions cover every image and Dockerfiles.
+func TestSet_Applicable(t *testing.T) {
+	t.Parallel()
+
+	set, err := exceptions.Parse([]byte(testFile))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	now := time.Date(2025, 12, 31, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		image string
+		now   time.Time
+		want  []string
+	}{
+		{image: "ghcr.io/org/app:1.0", now: now, want: []string{"CVE-2024-0001", "DKL-DI-0005"}},
+		{image: "docker.io/library/alpine@sha256:" + strings.Repeat("a", 64), now: now,
+			want: []string{"CVE-2024-0001", "DKL-DI-0005"}},
+		{image: "ghcr.io/other/app", now: now, want: []string{"DKL-DI-0005"}},
+		{image: "", now: now, want: []string{"DKL-DI-0005"}},
+		{image: "", now: time.Date(2026, 1, 1, 23, 59, 0, 0, time.UTC), want: []string{"DKL-DI-0005"}},
+		{image: "ghcr.io/org/app", now: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), want: []string{"CVE-2024-0001"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, exception := range set.Applicable(tt.image, tt.now) {
+			got = append(got, exception.ID)
+		}
+
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Applicable(%q, %s) = %v, want %v", tt.image, tt.now, got, tt.want)
+		}
+	}
+
+	later := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+
+	if expired := set.Expired(later); len(expired) != 1 || expired[0].ID != "DKL-DI-0005" {
+		t.Errorf("Expired() = %+v, want DKL-DI-0005", expired)
+	}
+
+	if expiring := set.Expiring(later, 14*24*time.Hour); len(expiring) != 1 || expiring[0].ID != "CVE-2024-0001" {
+		t.Errorf("Expiring() = %+v, want CVE-2024-0001", expiring)
+	}
+}
+
+// INTENTION: Invalid files report every problem at once: missing fields, bad dates, bad patterns, unknown fields.
+func TestParse_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "fields",
+			content: `
+exceptions:
+  - id: CVE-2024-0001
+    expires: soon
+  - reason: why
+    owner: me
+    expires: 2026-01-01
+    images: ["[invalid"]
+`,
+			want: []string{
+				"exception 1 (CVE-2024-0001): missing owner, reason",
+				`exception 1 (CVE-2024-0001): expires "soon" is not a YYYY-MM-DD date`,
+				"exception 2: missing id",
+				`exception 2: invalid image pattern "[invalid"`,
+			},
+		},
+		{
+			name:    "unknown field",
+			content: "exceptions:\n  - id: CVE-2024-0001\n    expiry: 2026-01-01\n",
+			want:    []string{"expiry"},
+		},
+	}
+
+	for _, tt := range tests {
+		_, err := exceptions.Parse([]byte(tt.content))
+		if !errors.Is(err, exceptions.ErrInvalid) {
+			t.Fatalf("%s: Parse() error = %v, want %v", tt.name, err, exceptions.ErrInvalid)
+		}
+
+		for _, want := range tt.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: Parse() error = %v, want it to contain %q", tt.name, err, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Package ghcr reads GitHub Container Registry packages through the GitHub REST API (visibility, linked
+// repository), and names the OCI annotations GHCR reads package metadata from.
+package ghcr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultAPIURL is the root of the GitHub REST API.
+const DefaultAPIURL = "https://api.github.com"
+
+// Annotations GHCR reads package metadata from, on the pushed manifest or index.
+const (
+	// AnnotationSource links the package to the repository at its URL (e.g., "https://github.com/org/app").
+	AnnotationSource = "org.opencontainers.image.source"
+	// AnnotationDescription is the package description.
+	AnnotationDescription = "org.opencontainers.image.description"
+	// AnnotationLicenses is the SPDX license expression shown on the package page.
+	AnnotationLicenses = "org.opencontainers.image.licenses"
+)
+
+var (
+	// ErrRequestFailed indicates the GitHub API rejected a request.
+	ErrRequestFailed = errors.New("GitHub API request failed")
+	// ErrPackageNotFound indicates the package does not exist, or the token cannot read it.
+	ErrPackageNotFound = errors.New("GitHub package not found")
+
+	errNotFound = errors.New("not found")
+)
+
+// Package is a container package.
+type Package struct {
+	Name       string `json:"name"`
+	Visibility string `json:"visibility"`
+	HTMLURL    string `json:"html_url"` //nolint:tagliatelle // GitHub API
+	Owner      struct {
+		Login string `json:"login"`
+		Type  string `json:"type"`
+	} `json:"owner"`
+	Repository *struct {
+		FullName string `json:"full_name"` //nolint:tagliatelle // GitHub API
+	} `json:"repository"`
+}
+
+// LinkedRepository returns the repository the package is linked to ("owner/name"), empty if none.
+func (pkg *Package) LinkedRepository() string {
+	if pkg.Repository == nil {
+		return ""
+	}
+
+	return pkg.Repository.FullName
+}
+
+// SettingsURL returns the page where the package visibility is changed.
+func (pkg *Package) SettingsURL() string {
+	return pkg.HTMLURL + "/settings"
+}
+
+// Client reads container packages through the GitHub REST API.
+type Client struct {
+	apiURL string
+	token  string
+
+	// HTTP client sending the requests (http.DefaultClient when nil)
+	client *http.Client
+}
+
+// NewClient creates a client authenticating with token (read:packages scope, or a GITHUB_TOKEN with packages
+// read permission).
+func NewClient(token string) *Client {
+	return &Client{apiURL: DefaultAPIURL, token: token}
+}
+
+// WithAPIURL sets the API root (e.g., "https://github.example.com/api/v3" for GitHub Enterprise Server).
+func (client *Client) WithAPIURL(apiURL string) *Client {
+	client.apiURL = strings.TrimSuffix(apiURL, "/")
+
+	return client
+}
+
+// WithHTTPClient sets the HTTP client sending the requests.
+func (client *Client) WithHTTPClient(httpClient *http.Client) *Client {
+	client.client = httpClient
+
+	return client
+}
+
+// Package returns the container package name (e.g., "team/app") of owner, an organization or a user,
+// or ErrPackageNotFound.
+func (client *Client) Package(ctx context.Context, owner, name string) (*Package, error) {
+	// Package names are a single path segment: slashes are escaped
+	path := "/packages/container/" + url.PathEscape(name)
+
+	for _, scope := range []string{"/orgs/", "/users/"} {
+		var pkg Package
+
+		err := client.get(ctx, scope+url.PathEscape(owner)+path, &pkg)
+		if err == nil {
+			return &pkg, nil
+		}
+
+		if !errors.Is(err, errNotFound) {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s/%s", ErrPackageNotFound, owner, name)
+}
+
+// get sends a GET request to path (relative to the API root), and decodes the JSON response into out.
+func (client *Client) get(ctx context.Context, path string, out any) error {
+	target := client.apiURL + path
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-Github-Api-Version", "2022-11-28")
+
+	if client.token != "" {
+		req.Header.Set("Authorization", "Bearer "+client.token)
+	}
+
+	httpClient := client.client
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("GET %s: %w", target, err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %w: GET %s", ErrRequestFailed, errNotFound, target)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		//nolint:mnd // Error bodies are short JSON documents
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		return fmt.Errorf("%w: GET %s: %s %s", ErrRequestFailed, target, resp.Status,
+			strings.TrimSpace(string(detail)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response of GET %s: %w", target, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package ghcr_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/farcloser/quark/internal/ghcr"
+)
+
+// INTENTION: Packages are found in the organization or the user scope, with slashes in their name escaped,
+// and report their visibility and linked repository.
+func TestClient_Package(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer token" {
+			writer.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		if req.URL.EscapedPath() != "/users/octo/packages/container/team%2Fapp" {
+			writer.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = writer.Write([]byte(`{"name":"team/app","visibility":"private",` +
+			`"html_url":"https://github.com/users/octo/packages/container/package/team%2Fapp",` +
+			`"repository":{"full_name":"octo/app"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := ghcr.NewClient("token").WithAPIURL(server.URL)
+
+	pkg, err := client.Package(t.Context(), "octo", "team/app")
+	if err != nil {
+		t.Fatalf("Package() error = %v", err)
+	}
+
+	if pkg.Visibility != "private" || pkg.LinkedRepository() != "octo/app" {
+		t.Errorf("Package() = %+v, want private package linked to octo/app", pkg)
+	}
+
+	if _, err := client.Package(t.Context(), "octo", "missing"); !errors.Is(err, ghcr.ErrPackageNotFound) {
+		t.Errorf("Package(missing) error = %v, want %v", err, ghcr.ErrPackageNotFound)
+	}
+
+	if _, err := ghcr.NewClient("wrong").WithAPIURL(server.URL).Package(t.Context(), "octo", "team/app"); !errors.Is(
+		err, ghcr.ErrRequestFailed,
+	) || errors.Is(err, ghcr.ErrPackageNotFound) {
+		t.Errorf("Package() with a rejected token error = %v, want %v", err, ghcr.ErrRequestFailed)
+	}
+}
@@ -0,0 +1,397 @@
+// Package harbor manages Harbor projects through the Harbor API (v2.0): projects, tag retention and immutability
+// rules, and robot accounts, so registries quark pushes to can be bootstrapped by a plan.
+package harbor
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// apiPath is the root of the Harbor API.
+	apiPath = "/api/v2.0"
+	// secretLength is the length of generated robot secrets.
+	secretLength = 32
+	// pageSize is the number of robots listed per page when looking for an existing robot.
+	pageSize = 100
+	// maxPages bounds the robot pages searched.
+	maxPages = 20
+)
+
+var (
+	// ErrRequestFailed indicates the Harbor API rejected a request.
+	ErrRequestFailed = errors.New("harbor API request failed")
+	// ErrProjectNotFound indicates the project does not exist.
+	ErrProjectNotFound = errors.New("harbor project not found")
+
+	errNotFound = errors.New("not found")
+)
+
+// Client manages the projects of a Harbor instance.
+type Client struct {
+	url      string
+	username string
+	password string
+
+	// HTTP client sending the requests (http.DefaultClient when nil)
+	client *http.Client
+}
+
+// NewClient creates a client for the Harbor instance at rawURL (e.g., "https://harbor.example.com"),
+// authenticating with username and password (a system or project administrator).
+func NewClient(rawURL, username, password string) *Client {
+	return &Client{url: strings.TrimSuffix(rawURL, "/"), username: username, password: password}
+}
+
+// WithHTTPClient sets the HTTP client sending the requests.
+func (client *Client) WithHTTPClient(httpClient *http.Client) *Client {
+	client.client = httpClient
+
+	return client
+}
+
+// Project is a Harbor project.
+type Project struct {
+	ID       int64             `json:"project_id"` //nolint:tagliatelle // Harbor API
+	Name     string            `json:"name"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// RetentionRule keeps the artifacts of the project matching Tags (doublestar pattern, all tags when empty):
+// the KeepLatest most recently pushed, or those pushed in the last KeepDays days.
+type RetentionRule struct {
+	Tags       string
+	KeepLatest int
+	KeepDays   int
+}
+
+// Robot is a robot account, with the secret it authenticates with.
+type Robot struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Secret string `json:"secret"`
+}
+
+// CheckAuth checks that Harbor accepts the credentials of the client.
+func (client *Client) CheckAuth(ctx context.Context) error {
+	return client.do(ctx, http.MethodGet, "/users/current", nil, nil)
+}
+
+// Project returns the project named name, or ErrProjectNotFound.
+func (client *Client) Project(ctx context.Context, name string) (*Project, error) {
+	var project Project
+
+	err := client.do(ctx, http.MethodGet, "/projects/"+url.PathEscape(name), nil, &project)
+	if errors.Is(err, errNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrProjectNotFound, name)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+// EnsureProject creates the project named name, or updates its visibility if it exists.
+// Returns whether the project was created.
+func (client *Client) EnsureProject(ctx context.Context, name string, public bool) (bool, error) {
+	metadata := map[string]string{"public": strconv.FormatBool(public)}
+
+	_, err := client.Project(ctx, name)
+	if err == nil {
+		return false, client.do(ctx, http.MethodPut, "/projects/"+url.PathEscape(name),
+			map[string]any{"metadata": metadata}, nil)
+	}
+
+	if !errors.Is(err, ErrProjectNotFound) {
+		return false, err
+	}
+
+	if err := client.do(ctx, http.MethodPost, "/projects",
+		map[string]any{"project_name": name, "metadata": metadata}, nil); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// SetRetention replaces the tag retention policy of the project with rules, run on schedule (cron expression
+// with seconds, e.g., "0 0 0 * * *"; manually only when empty). Artifacts matching any rule are retained.
+func (client *Client) SetRetention(
+	ctx context.Context,
+	projectName string,
+	rules []RetentionRule,
+	schedule string,
+) error {
+	project, err := client.Project(ctx, projectName)
+	if err != nil {
+		return err
+	}
+
+	policyRules := make([]map[string]any, 0, len(rules))
+
+	for _, rule := range rules {
+		template, value := "latestPushedK", rule.KeepLatest
+		if rule.KeepDays > 0 {
+			template, value = "nDaysSinceLastPush", rule.KeepDays
+		}
+
+		tags := rule.Tags
+		if tags == "" {
+			tags = "**"
+		}
+
+		policyRules = append(policyRules, map[string]any{
+			"action":          "retain",
+			"template":        template,
+			"params":          map[string]int{template: value},
+			"tag_selectors":   []selector{{Kind: "doublestar", Decoration: "matches", Pattern: tags}},
+			"scope_selectors": allRepositories(),
+		})
+	}
+
+	policy := map[string]any{
+		"algorithm": "or",
+		"rules":     policyRules,
+		"trigger":   map[string]any{"kind": "Schedule", "settings": map[string]string{"cron": schedule}},
+		"scope":     map[string]any{"level": "project", "ref": project.ID},
+	}
+
+	// Projects have at most one policy, referenced by their metadata
+	if id := project.Metadata["retention_id"]; id != "" {
+		return client.do(ctx, http.MethodPut, "/retentions/"+url.PathEscape(id), policy, nil)
+	}
+
+	return client.do(ctx, http.MethodPost, "/retentions", policy, nil)
+}
+
+// EnsureImmutableTags adds an immutability rule for each tag pattern (doublestar, e.g., "v*") the project does
+// not protect yet, in all its repositories. Returns the number of rules added.
+func (client *Client) EnsureImmutableTags(ctx context.Context, projectName string, patterns []string) (int, error) {
+	path := "/projects/" + url.PathEscape(projectName) + "/immutabletagrules"
+
+	var existing []struct {
+		TagSelectors []selector `json:"tag_selectors"` //nolint:tagliatelle // Harbor API
+	}
+
+	if err := client.do(ctx, http.MethodGet, path, nil, &existing); err != nil {
+		return 0, err
+	}
+
+	protected := make(map[string]bool)
+
+	for _, rule := range existing {
+		for _, tagSelector := range rule.TagSelectors {
+			protected[tagSelector.Pattern] = true
+		}
+	}
+
+	added := 0
+
+	for _, pattern := range patterns {
+		if protected[pattern] {
+			continue
+		}
+
+		if err := client.do(ctx, http.MethodPost, path, map[string]any{
+			"tag_selectors":   []selector{{Kind: "doublestar", Decoration: "matches", Pattern: pattern}},
+			"scope_selectors": allRepositories(),
+		}, nil); err != nil {
+			return added, err
+		}
+
+		protected[pattern] = true
+		added++
+	}
+
+	return added, nil
+}
+
+// EnsureRobot provisions the project robot account named name, with pull and push access to the repositories
+// of the project, and returns it with a new secret. Harbor only returns secrets on creation: the secret of an
+// existing robot is replaced.
+func (client *Client) EnsureRobot(ctx context.Context, projectName, name string) (*Robot, error) {
+	project, err := client.Project(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := client.findRobot(ctx, project, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		secret, err := generateSecret()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := client.do(ctx, http.MethodPatch, "/robots/"+strconv.FormatInt(existing.ID, 10),
+			map[string]string{"secret": secret}, nil); err != nil {
+			return nil, err
+		}
+
+		existing.Secret = secret
+
+		return existing, nil
+	}
+
+	var robot Robot
+
+	if err := client.do(ctx, http.MethodPost, "/robots", map[string]any{
+		"name":        name,
+		"description": "Managed by quark",
+		"duration":    -1,
+		"level":       "project",
+		"permissions": []map[string]any{{
+			"kind":      "project",
+			"namespace": projectName,
+			"access": []map[string]string{
+				{"resource": "repository", "action": "pull"},
+				{"resource": "repository", "action": "push"},
+			},
+		}},
+	}, &robot); err != nil {
+		return nil, err
+	}
+
+	return &robot, nil
+}
+
+// findRobot returns the project robot named name, or nil if none.
+// Harbor lists project robots with their full name (e.g., "robot$project+name").
+func (client *Client) findRobot(ctx context.Context, project *Project, name string) (*Robot, error) {
+	suffix := project.Name + "+" + name
+	query := url.QueryEscape("Level=project,ProjectID=" + strconv.FormatInt(project.ID, 10))
+
+	for page := 1; page <= maxPages; page++ {
+		var robots []Robot
+
+		path := fmt.Sprintf("/robots?q=%s&page_size=%d&page=%d", query, pageSize, page)
+		if err := client.do(ctx, http.MethodGet, path, nil, &robots); err != nil {
+			return nil, err
+		}
+
+		for _, robot := range robots {
+			if robot.Name == suffix || strings.HasSuffix(robot.Name, "$"+suffix) {
+				return &robot, nil
+			}
+		}
+
+		if len(robots) < pageSize {
+			break
+		}
+	}
+
+	return nil, nil
+}
+
+// selector selects repositories or tags by pattern.
+type selector struct {
+	Kind       string `json:"kind"`
+	Decoration string `json:"decoration"`
+	Pattern    string `json:"pattern"`
+}
+
+// allRepositories is the scope selector of every repository of a project.
+func allRepositories() map[string][]selector {
+	return map[string][]selector{"repository": {{Kind: "doublestar", Decoration: "repoMatches", Pattern: "**"}}}
+}
+
+// generateSecret returns a random robot secret, with the upper case letter, lower case letter and digit Harbor
+// requires.
+func generateSecret() (string, error) {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+	secret := []byte("Aa0")
+
+	for len(secret) < secretLength {
+		idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate robot secret: %w", err)
+		}
+
+		secret = append(secret, alphabet[idx.Int64()])
+	}
+
+	return string(secret), nil
+}
+
+// do sends a request to path (relative to the API root) with an optional JSON payload, and decodes the JSON
+// response into out if not nil.
+func (client *Client) do(ctx context.Context, method, path string, payload, out any) error {
+	var body io.Reader
+
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+
+		body = bytes.NewReader(encoded)
+	}
+
+	target := client.url + apiPath + path
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.SetBasicAuth(client.username, client.password)
+	req.Header.Set("Accept", "application/json")
+	// Project paths are names, even when they look like IDs
+	req.Header.Set("X-Is-Resource-Name", "true")
+
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := client.client
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, target, err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %w: %s %s", ErrRequestFailed, errNotFound, method, target)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		//nolint:mnd // Error bodies are short JSON documents
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		return fmt.Errorf("%w: %s %s: %s %s", ErrRequestFailed, method, target, resp.Status,
+			strings.TrimSpace(string(detail)))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response of %s %s: %w", method, target, err)
+	}
+
+	return nil
+}
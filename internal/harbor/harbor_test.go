@@ -0,0 +1,95 @@
+package harbor_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/farcloser/quark/internal/harbor"
+	"github.com/farcloser/quark/testutil"
+)
+
+// INTENTION: Bootstrapping a project converges: a second run creates nothing, replaces the retention policy in
+// place, adds no duplicate immutability rule, and rotates the secret of the existing robot.
+func TestClient_Bootstrap(t *testing.T) {
+	t.Parallel()
+
+	fake := testutil.NewFakeHarbor(t, "admin", "Harbor12345")
+	client := harbor.NewClient(fake.URL, "admin", "Harbor12345")
+
+	var secrets []string
+
+	for run := range 2 {
+		created, err := client.EnsureProject(t.Context(), "mirror", false)
+		if err != nil {
+			t.Fatalf("EnsureProject() error = %v", err)
+		}
+
+		if created != (run == 0) {
+			t.Errorf("EnsureProject() created = %v on run %d", created, run)
+		}
+
+		rules := []harbor.RetentionRule{{KeepLatest: 10}, {Tags: "v*", KeepDays: 90}}
+		if err := client.SetRetention(t.Context(), "mirror", rules, "0 0 0 * * *"); err != nil {
+			t.Fatalf("SetRetention() error = %v", err)
+		}
+
+		added, err := client.EnsureImmutableTags(t.Context(), "mirror", []string{"v*"})
+		if err != nil {
+			t.Fatalf("EnsureImmutableTags() error = %v", err)
+		}
+
+		if added != 1-run {
+			t.Errorf("EnsureImmutableTags() added = %d on run %d", added, run)
+		}
+
+		robot, err := client.EnsureRobot(t.Context(), "mirror", "sync")
+		if err != nil {
+			t.Fatalf("EnsureRobot() error = %v", err)
+		}
+
+		if robot.Name != "robot$mirror+sync" || robot.Secret == "" {
+			t.Errorf("EnsureRobot() = %+v, want robot$mirror+sync with a secret", robot)
+		}
+
+		secrets = append(secrets, robot.Secret)
+	}
+
+	if secrets[0] == secrets[1] {
+		t.Error("EnsureRobot() kept the secret of the existing robot, want a new one")
+	}
+
+	fake.Snapshot(func(state *testutil.FakeHarbor) {
+		if state.Projects["mirror"]["public"] != "false" || len(state.Retentions) != 1 ||
+			len(state.ImmutableTags["mirror"]) != 1 || len(state.Robots) != 1 {
+			t.Errorf("state = %v %v %v %v, want one project, policy, rule and robot",
+				state.Projects, state.Retentions, state.ImmutableTags, state.Robots)
+		}
+
+		if state.Robots["robot$mirror+sync"] != secrets[1] {
+			t.Errorf("robot secret = %q, want the returned %q", state.Robots["robot$mirror+sync"], secrets[1])
+		}
+	})
+}
+
+// INTENTION: Rejected credentials and missing projects are reported with distinct errors.
+func TestClient_Errors(t *testing.T) {
+	t.Parallel()
+
+	fake := testutil.NewFakeHarbor(t, "admin", "Harbor12345")
+
+	if err := harbor.NewClient(fake.URL, "admin", "wrong").CheckAuth(t.Context()); !errors.Is(
+		err, harbor.ErrRequestFailed,
+	) {
+		t.Errorf("CheckAuth() error = %v, want %v", err, harbor.ErrRequestFailed)
+	}
+
+	client := harbor.NewClient(fake.URL, "admin", "Harbor12345")
+
+	if err := client.CheckAuth(t.Context()); err != nil {
+		t.Errorf("CheckAuth() error = %v", err)
+	}
+
+	if _, err := client.EnsureRobot(t.Context(), "missing", "sync"); !errors.Is(err, harbor.ErrProjectNotFound) {
+		t.Errorf("EnsureRobot() error = %v, want %v", err, harbor.ErrProjectNotFound)
+	}
+}
@@ -0,0 +1,297 @@
+// Package history persists scan and version check results per image across plan runs,
+// so findings can be compared over time (new and fixed vulnerabilities, version drift).
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/internal/reference"
+)
+
+// Record kinds.
+const (
+	// KindScan is a vulnerability scan record.
+	KindScan = "scan"
+	// KindVersionCheck is a version check record.
+	KindVersionCheck = "version-check"
+)
+
+// recordExtension is the extension of per-image record files (one JSON record per line).
+const recordExtension = ".jsonl"
+
+// maxRecordSize bounds a single record line (large images may carry thousands of findings).
+const maxRecordSize = 64 << 20
+
+// ErrInvalidImage indicates an image reference that cannot be used as a history key.
+var ErrInvalidImage = errors.New("invalid image reference for history")
+
+// Vulnerability is a finding stored with a scan record.
+type Vulnerability struct {
+	ID               string   `json:"id"`
+	Severity         string   `json:"severity"`
+	Package          string   `json:"package,omitempty"`
+	InstalledVersion string   `json:"installedVersion,omitempty"`
+	FixedVersion     string   `json:"fixedVersion,omitempty"`
+	Platforms        []string `json:"platforms,omitempty"`
+}
+
+// key identifies a finding across runs (the same CVE may affect several packages).
+func (vuln Vulnerability) key() string {
+	return vuln.ID + "\x00" + vuln.Package
+}
+
+// Record is the result of one operation on one image, in one plan run.
+type Record struct {
+	// Time is the start time of the plan run (shared by all records of a run).
+	Time time.Time `json:"time"`
+	// Plan and Operation name the plan and operation that produced the record.
+	Plan      string `json:"plan"`
+	Operation string `json:"operation"`
+	// Kind is KindScan or KindVersionCheck.
+	Kind string `json:"kind"`
+	// Image is the canonical repository (e.g., "docker.io/library/alpine").
+	Image string `json:"image"`
+	// Version and Digest identify the image the operation ran against.
+	Version string `json:"version,omitempty"`
+	Digest  string `json:"digest,omitempty"`
+
+	// Scan results
+	Vulnerabilities []Vulnerability `json:"vulnerabilities,omitempty"`
+
+	// Version check results
+	LatestVersion   string `json:"latestVersion,omitempty"`
+	LatestDigest    string `json:"latestDigest,omitempty"`
+	UpdateAvailable bool   `json:"updateAvailable,omitempty"`
+}
+
+// Backend stores history records: a Store, or a database (see internal/database).
+type Backend interface {
+	// Append appends a record to the history of its image.
+	Append(record Record) error
+	// Records returns the history of an image (any reference form), oldest first.
+	Records(image string) ([]Record, error)
+	// Images returns the canonical repositories with a recorded history, sorted.
+	Images() ([]string, error)
+	// Affected returns the latest scan record of each image whose latest scan still reports the vulnerability.
+	Affected(vulnerabilityID string) ([]Record, error)
+}
+
+// Store is a history store keeping one append-only record file per image under a directory
+// (e.g., "<dir>/docker.io/library/alpine.jsonl").
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewStore creates a store under dir. The directory is created on first write.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the store directory.
+func (store *Store) Dir() string {
+	return store.dir
+}
+
+// Append appends a record to the history of its image.
+func (store *Store) Append(record Record) error {
+	target, err := store.path(record.Image)
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode history record: %w", err)
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(target), filesystem.DirPermissionsDefault); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	// #nosec G304 -- path derived from a validated image reference under the store directory
+	file, err := os.OpenFile(target, os.O_APPEND|os.O_CREATE|os.O_WRONLY, filesystem.FilePermissionsDefault)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+
+		return fmt.Errorf("failed to write history record: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write history record: %w", err)
+	}
+
+	return nil
+}
+
+// Records returns the history of an image (any reference form, e.g., "alpine" or "ghcr.io/org/app:1.0"),
+// oldest first. Returns an empty history for images that were never recorded.
+func (store *Store) Records(image string) ([]Record, error) {
+	target, err := store.path(image)
+	if err != nil {
+		return nil, err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	// #nosec G304 -- path derived from a validated image reference under the store directory
+	file, err := os.Open(target)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var records []Record
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxRecordSize)
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("failed to decode history record in %s: %w", target, err)
+		}
+
+		records = append(records, record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+
+	return records, nil
+}
+
+// path returns the record file of an image.
+func (store *Store) path(image string) (string, error) {
+	repository, err := Repository(image)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(store.dir, filepath.FromSlash(repository)+recordExtension), nil
+}
+
+// Repository returns the canonical repository an image reference is recorded under
+// (e.g., "alpine:3.20" -> "docker.io/library/alpine").
+func Repository(image string) (string, error) {
+	ref, err := reference.Parse(image)
+	if err != nil || ref.Path == "" || ref.Domain == "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidImage, image)
+	}
+
+	return ref.Name(), nil
+}
+
+// Images returns the canonical repositories with a recorded history, sorted.
+func (store *Store) Images() ([]string, error) {
+	var images []string
+
+	err := filepath.WalkDir(store.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), recordExtension) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(store.dir, path)
+		if err != nil {
+			return err
+		}
+
+		images = append(images, filepath.ToSlash(strings.TrimSuffix(rel, recordExtension)))
+
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list history: %w", err)
+	}
+
+	sort.Strings(images)
+
+	return images, nil
+}
+
+// Affected returns, for each image whose latest scan still reports the vulnerability (e.g., "CVE-2024-3094"),
+// that latest scan record. Images fixed since an earlier scan are not reported.
+func (store *Store) Affected(vulnerabilityID string) ([]Record, error) {
+	images, err := store.Images()
+	if err != nil {
+		return nil, err
+	}
+
+	var affected []Record
+
+	for _, image := range images {
+		records, err := store.Records(image)
+		if err != nil {
+			return nil, err
+		}
+
+		latest, found := latestScan(records)
+		if !found {
+			continue
+		}
+
+		for _, vuln := range latest.Vulnerabilities {
+			if vuln.ID == vulnerabilityID {
+				affected = append(affected, latest)
+
+				break
+			}
+		}
+	}
+
+	return affected, nil
+}
+
+// latestScan returns the most recent scan record of a history (as returned by Records).
+func latestScan(records []Record) (Record, bool) {
+	for idx := len(records) - 1; idx >= 0; idx-- {
+		if records[idx].Kind == KindScan {
+			return records[idx], true
+		}
+	}
+
+	return Record{}, false
+}
@@ -0,0 +1,134 @@
+package history_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/farcloser/quark/internal/history"
+)
+
+// INTENTION: Records are stored per canonical repository, so any reference form of an image
+// (familiar name, tag, digest) reads back the same history, oldest first.
+func TestStore_AppendRecords(t *testing.T) {
+	t.Parallel()
+
+	store := history.NewStore(t.TempDir())
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	records := []history.Record{
+		{Time: start.Add(time.Hour), Kind: history.KindScan, Image: "docker.io/library/alpine", Digest: "sha256:bbb"},
+		{Time: start, Kind: history.KindScan, Image: "docker.io/library/alpine", Digest: "sha256:aaa"},
+		{Time: start, Kind: history.KindScan, Image: "ghcr.io/org/app", Digest: "sha256:ccc"},
+	}
+
+	for _, record := range records {
+		if err := store.Append(record); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	for _, image := range []string{"alpine", "alpine:3.20", "docker.io/library/alpine"} {
+		got, err := store.Records(image)
+		if err != nil {
+			t.Fatalf("Records(%q) error = %v", image, err)
+		}
+
+		if len(got) != 2 || got[0].Digest != "sha256:aaa" || got[1].Digest != "sha256:bbb" {
+			t.Errorf("Records(%q) = %+v, want alpine records oldest first", image, got)
+		}
+	}
+
+	got, err := store.Records("busybox")
+	if err != nil || len(got) != 0 {
+		t.Errorf("Records(unknown) = %+v, %v, want empty history", got, err)
+	}
+
+	if err := store.Append(history.Record{Image: "INVALID"}); !errors.Is(err, history.ErrInvalidImage) {
+		t.Errorf("Append(invalid image) error = %v, want ErrInvalidImage", err)
+	}
+}
+
+// INTENTION: The scan trend reports, for each scan, the findings that appeared and disappeared since the
+// previous scan, keyed by vulnerability and package, ignoring version check records.
+func TestScanTrend(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	openssl := history.Vulnerability{ID: "CVE-2024-0001", Severity: "HIGH", Package: "openssl"}
+	libssl := history.Vulnerability{ID: "CVE-2024-0001", Severity: "HIGH", Package: "libssl3"}
+	zlib := history.Vulnerability{ID: "CVE-2024-0002", Severity: "CRITICAL", Package: "zlib"}
+
+	changes := history.ScanTrend([]history.Record{
+		{Time: start, Kind: history.KindScan, Vulnerabilities: []history.Vulnerability{openssl, zlib}},
+		{Time: start.Add(time.Hour), Kind: history.KindVersionCheck, LatestVersion: "3.21"},
+		{Time: start.Add(2 * time.Hour), Kind: history.KindScan, Vulnerabilities: []history.Vulnerability{openssl, libssl}},
+	})
+
+	if len(changes) != 2 {
+		t.Fatalf("ScanTrend() = %d changes, want 2", len(changes))
+	}
+
+	if len(changes[0].New) != 2 || len(changes[0].Fixed) != 0 {
+		t.Errorf("first scan: new = %v, fixed = %v, want all findings new", changes[0].New, changes[0].Fixed)
+	}
+
+	second := changes[1]
+	if len(second.New) != 1 || second.New[0].Package != libssl.Package {
+		t.Errorf("second scan: new = %v, want [%v]", second.New, libssl)
+	}
+
+	if len(second.Fixed) != 1 || second.Fixed[0].ID != zlib.ID {
+		t.Errorf("second scan: fixed = %v, want [%v]", second.Fixed, zlib)
+	}
+
+	if second.Counts["HIGH"] != 2 || second.Counts["CRITICAL"] != 0 {
+		t.Errorf("second scan: counts = %v, want HIGH=2", second.Counts)
+	}
+}
+
+// INTENTION: Affected lists the images whose latest scan still ships a vulnerability,
+// ignoring images that fixed it since an earlier scan.
+func TestStore_Affected(t *testing.T) {
+	t.Parallel()
+
+	store := history.NewStore(t.TempDir())
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	xz := history.Vulnerability{ID: "CVE-2024-3094", Severity: "CRITICAL", Package: "xz-utils"}
+
+	records := []history.Record{
+		// Fixed since the first scan
+		{Time: start, Kind: history.KindScan, Image: "docker.io/library/debian", Vulnerabilities: []history.Vulnerability{xz}},
+		{Time: start.Add(time.Hour), Kind: history.KindScan, Image: "docker.io/library/debian"},
+		// Still affected, followed by a version check
+		{Time: start, Kind: history.KindScan, Image: "ghcr.io/org/app", Vulnerabilities: []history.Vulnerability{xz}},
+		{Time: start.Add(time.Hour), Kind: history.KindVersionCheck, Image: "ghcr.io/org/app"},
+		// Never affected
+		{Time: start, Kind: history.KindScan, Image: "ghcr.io/org/other"},
+	}
+
+	for _, record := range records {
+		if err := store.Append(record); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	images, err := store.Images()
+	if err != nil || len(images) != 3 {
+		t.Fatalf("Images() = %v, %v, want 3 images", images, err)
+	}
+
+	affected, err := store.Affected(xz.ID)
+	if err != nil {
+		t.Fatalf("Affected() error = %v", err)
+	}
+
+	if len(affected) != 1 || affected[0].Image != "ghcr.io/org/app" {
+		t.Errorf("Affected() = %+v, want ghcr.io/org/app only", affected)
+	}
+
+	images, err = history.NewStore(t.TempDir() + "/missing").Images()
+	if err != nil || len(images) != 0 {
+		t.Errorf("Images(missing directory) = %v, %v, want empty", images, err)
+	}
+}
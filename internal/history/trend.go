@@ -0,0 +1,76 @@
+package history
+
+import (
+	"sort"
+	"time"
+)
+
+// ScanChange compares a scan record with the previous scan of the same image.
+type ScanChange struct {
+	Time    time.Time
+	Version string
+	Digest  string
+	// Counts is the number of vulnerabilities per severity (e.g., "HIGH": 3).
+	Counts map[string]int
+	// New lists vulnerabilities absent from the previous scan (all of them for the first scan).
+	New []Vulnerability
+	// Fixed lists vulnerabilities of the previous scan that are no longer found.
+	Fixed []Vulnerability
+}
+
+// ScanTrend returns the scan-over-scan changes of an image history (as returned by Store.Records),
+// oldest first. Non-scan records are ignored.
+func ScanTrend(records []Record) []ScanChange {
+	var (
+		changes  []ScanChange
+		previous map[string]Vulnerability
+	)
+
+	for _, record := range records {
+		if record.Kind != KindScan {
+			continue
+		}
+
+		current := make(map[string]Vulnerability, len(record.Vulnerabilities))
+		change := ScanChange{
+			Time:    record.Time,
+			Version: record.Version,
+			Digest:  record.Digest,
+			Counts:  make(map[string]int),
+		}
+
+		for _, vuln := range record.Vulnerabilities {
+			current[vuln.key()] = vuln
+			change.Counts[vuln.Severity]++
+
+			if _, known := previous[vuln.key()]; !known {
+				change.New = append(change.New, vuln)
+			}
+		}
+
+		for key, vuln := range previous {
+			if _, still := current[key]; !still {
+				change.Fixed = append(change.Fixed, vuln)
+			}
+		}
+
+		sortVulnerabilities(change.New)
+		sortVulnerabilities(change.Fixed)
+
+		changes = append(changes, change)
+		previous = current
+	}
+
+	return changes
+}
+
+// sortVulnerabilities sorts findings by ID, then package, for stable output.
+func sortVulnerabilities(vulns []Vulnerability) {
+	sort.Slice(vulns, func(i, j int) bool {
+		if vulns[i].ID != vulns[j].ID {
+			return vulns[i].ID < vulns[j].ID
+		}
+
+		return vulns[i].Package < vulns[j].Package
+	})
+}
@@ -0,0 +1,213 @@
+package inventory
+
+import (
+	"go/ast"
+	"go/token"
+	"path"
+)
+
+// call is one method call of a builder chain.
+type call struct {
+	method string
+	args   []ast.Expr
+}
+
+// chain is a builder call chain rooted at a package function or method call
+// (e.g., sdk.NewImage("alpine").Version("3.20").Build() or plan.Build("app").Dockerfile("Dockerfile")).
+type chain struct {
+	// root is the name of the first call (e.g., "NewImage", "Build").
+	root string
+	// calls lists all calls in order, the root included.
+	calls []call
+	// assignedTo is the variable the chain result is assigned to, if any.
+	assignedTo string
+	pos        token.Pos
+}
+
+// callChains returns the call chains of a file.
+func callChains(file *ast.File) []chain {
+	var (
+		chains []chain
+		stack  []ast.Node
+	)
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		if node == nil {
+			stack = stack[:len(stack)-1]
+
+			return true
+		}
+
+		stack = append(stack, node)
+
+		root, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		selector, ok := root.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		// Only the innermost call of a chain is a root
+		if _, inner := selector.X.(*ast.CallExpr); inner {
+			return true
+		}
+
+		found := chain{
+			root:  selector.Sel.Name,
+			calls: []call{{method: selector.Sel.Name, args: root.Args}},
+			pos:   root.Pos(),
+		}
+
+		// Walk up: <expr>.Method(args) wraps the current expression in a selector, then a call
+		var current ast.Expr = root
+
+		idx := len(stack) - 1
+		for idx >= 2 {
+			sel, ok := stack[idx-1].(*ast.SelectorExpr)
+			if !ok || sel.X != current {
+				break
+			}
+
+			outer, ok := stack[idx-2].(*ast.CallExpr)
+			if !ok || outer.Fun != sel {
+				break
+			}
+
+			found.calls = append(found.calls, call{method: sel.Sel.Name, args: outer.Args})
+			current = outer
+			idx -= 2
+		}
+
+		if idx >= 1 {
+			found.assignedTo = assignedVariable(stack[idx-1], current)
+		}
+
+		chains = append(chains, found)
+
+		return true
+	})
+
+	return chains
+}
+
+// assignedVariable returns the name of the variable expr is assigned to by parent, if any.
+func assignedVariable(parent ast.Node, expr ast.Expr) string {
+	switch typed := parent.(type) {
+	case *ast.AssignStmt:
+		for idx, rhs := range typed.Rhs {
+			if rhs != expr {
+				continue
+			}
+
+			// Builders return (value, error): the value is the first variable
+			if len(typed.Rhs) == 1 {
+				idx = 0
+			}
+
+			if ident, ok := typed.Lhs[idx].(*ast.Ident); ok && ident.Name != "_" {
+				return ident.Name
+			}
+		}
+	case *ast.ValueSpec:
+		if len(typed.Names) > 0 && typed.Names[0].Name != "_" {
+			return typed.Names[0].Name
+		}
+	}
+
+	return ""
+}
+
+// argument returns the value of the first argument of the last call to method,
+// Dynamic if it is not a constant string, or "" if method is not called.
+func (found *chain) argument(method string, constants map[string]string) string {
+	value := ""
+
+	for _, call := range found.calls {
+		if call.method != method || len(call.args) == 0 {
+			continue
+		}
+
+		value = Dynamic
+
+		switch arg := call.args[0].(type) {
+		case *ast.BasicLit:
+			if literal, ok := stringLiteral(arg); ok {
+				value = literal
+			}
+		case *ast.Ident:
+			if constant, ok := constants[arg.Name]; ok {
+				value = constant
+			}
+		}
+	}
+
+	return value
+}
+
+// reference formats the image reference of a NewImage chain.
+func (found *chain) reference(constants map[string]string) string {
+	ref := found.argument("NewImage", constants)
+
+	if domain := found.argument("Domain", constants); domain != "" {
+		ref = domain + "/" + ref
+	}
+
+	if version := found.argument("Version", constants); version != "" {
+		ref += ":" + version
+	}
+
+	if digest := found.argument("Digest", constants); digest != "" {
+		ref += "@" + digest
+	}
+
+	return ref
+}
+
+// pinning returns the pinning of a NewImage chain.
+func (found *chain) pinning(constants map[string]string) Pinning {
+	switch {
+	case found.argument("Digest", constants) != "":
+		return PinnedDigest
+	case found.argument("Version", constants) != "":
+		return PinnedTag
+	default:
+		return Unpinned
+	}
+}
+
+// dockerfile returns the Dockerfile path of a Build (relative to its context) or Audit chain.
+func (found *chain) dockerfile(constants map[string]string) string {
+	file := found.argument("Dockerfile", constants)
+
+	if found.root != "Build" {
+		return file
+	}
+
+	// Build chains configure a context: other "Build" roots (e.g., builder.Build()) are not builds
+	buildContext := found.argument("Context", constants)
+	if buildContext == "" {
+		return ""
+	}
+
+	if file == "" {
+		file = defaultDockerfile
+	}
+
+	if buildContext == Dynamic || file == Dynamic {
+		return Dynamic
+	}
+
+	return path.Join(buildContext, file)
+}
+
+// buildTag returns the image tag produced by a Build chain.
+func (found *chain) buildTag(constants map[string]string) string {
+	if found.root != "Build" || found.argument("Context", constants) == "" {
+		return ""
+	}
+
+	return found.argument("Tag", constants)
+}
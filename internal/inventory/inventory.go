@@ -0,0 +1,292 @@
+// Package inventory statically lists the images a plan references, by reading its Go source
+// (without executing it) and the Dockerfiles it builds or audits.
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/farcloser/quark/internal/dockerfile"
+)
+
+// Dynamic marks an image field computed at runtime, which cannot be known statically.
+const Dynamic = "<dynamic>"
+
+// defaultDockerfile is the Dockerfile name builds use when unset.
+const defaultDockerfile = "Dockerfile"
+
+// ErrNoPlanSource indicates the plan path has no Go source files.
+var ErrNoPlanSource = errors.New("no plan source files found")
+
+// Pinning is how strictly an image reference identifies its content.
+type Pinning string
+
+const (
+	// PinnedDigest images are identified by digest (immutable).
+	PinnedDigest Pinning = "digest"
+	// PinnedTag images are identified by tag only (mutable).
+	PinnedTag Pinning = "tag"
+	// Unpinned images have neither tag nor digest (implicitly "latest").
+	Unpinned Pinning = "none"
+)
+
+// Image is an image referenced by a plan.
+type Image struct {
+	// Reference is the image reference as the plan declares it (e.g., "ghcr.io/org/app:1.0@sha256:...").
+	Reference string
+	Pinning   Pinning
+	// Roles lists how the plan uses the image: the builder methods it is passed to (e.g., "Source",
+	// "Destination"), "Build" for build tags, or "FROM" for Dockerfile base images.
+	Roles []string
+	// Location is where the image is declared ("file:line").
+	Location string
+}
+
+// imageDecl is an image declared with sdk.NewImage, before its roles are known.
+type imageDecl struct {
+	image    Image
+	variable string
+}
+
+// Scan lists the images referenced by the plan at path (a Go file, or a directory of Go files).
+// Images declared with sdk.NewImage are reported with the roles their variables are passed to,
+// build tags with the "Build" role, and base images of Dockerfiles referenced by Build and Audit
+// with the "FROM" role.
+// Values computed at runtime (e.g., read from the environment) are reported as Dynamic.
+func Scan(path string) ([]Image, error) {
+	files, dir, err := sourceFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+
+	var parsed []*ast.File
+
+	for _, file := range files {
+		astFile, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+
+		parsed = append(parsed, astFile)
+	}
+
+	constants := stringConstants(parsed)
+
+	var (
+		decls       []*imageDecl
+		roles       = make(map[string][]string)
+		dockerfiles = make(map[string]bool)
+	)
+
+	for _, astFile := range parsed {
+		for _, chain := range callChains(astFile) {
+			switch chain.root {
+			case "NewImage":
+				decl := &imageDecl{
+					image: Image{
+						Reference: chain.reference(constants),
+						Location:  location(fset, chain.pos, dir),
+					},
+					variable: chain.assignedTo,
+				}
+
+				decl.image.Pinning = chain.pinning(constants)
+				decls = append(decls, decl)
+			case "Build", "Audit":
+				if file := chain.dockerfile(constants); file != "" && file != Dynamic {
+					dockerfiles[file] = true
+				}
+
+				// Images produced by builds
+				if tag := chain.buildTag(constants); tag != "" {
+					decls = append(decls, &imageDecl{image: Image{
+						Reference: tag,
+						Pinning:   referencePinning(tag),
+						Roles:     []string{"Build"},
+						Location:  location(fset, chain.pos, dir),
+					}})
+				}
+			}
+
+			for _, call := range chain.calls {
+				for _, arg := range call.args {
+					if ident, ok := arg.(*ast.Ident); ok {
+						roles[ident.Name] = appendUnique(roles[ident.Name], call.method)
+					}
+				}
+			}
+		}
+	}
+
+	images := make([]Image, 0, len(decls))
+
+	for _, decl := range decls {
+		if decl.variable != "" {
+			decl.image.Roles = roles[decl.variable]
+		}
+
+		images = append(images, decl.image)
+	}
+
+	bases, err := baseImages(dir, dockerfiles)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(images, bases...), nil
+}
+
+// sourceFiles returns the non-test Go files of the plan and the directory paths are relative to.
+func sourceFiles(path string) ([]string, string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read plan: %w", err)
+	}
+
+	if !info.IsDir() {
+		return []string{path}, filepath.Dir(path), nil
+	}
+
+	matches, err := filepath.Glob(filepath.Join(path, "*.go"))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list plan sources: %w", err)
+	}
+
+	var files []string
+
+	for _, match := range matches {
+		if !strings.HasSuffix(match, "_test.go") {
+			files = append(files, match)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, "", fmt.Errorf("%w in %s", ErrNoPlanSource, path)
+	}
+
+	return files, path, nil
+}
+
+// baseImages lists the base images of the given Dockerfiles (paths relative to dir).
+func baseImages(dir string, dockerfiles map[string]bool) ([]Image, error) {
+	paths := make([]string, 0, len(dockerfiles))
+	for path := range dockerfiles {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	var images []Image
+
+	for _, path := range paths {
+		bases, err := dockerfile.ParseFile(filepath.Join(dir, path))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, base := range bases {
+			pinning := referencePinning(base.Reference)
+			if len(base.Unresolved) > 0 && pinning != PinnedDigest {
+				pinning = Unpinned
+			}
+
+			images = append(images, Image{
+				Reference: base.Reference,
+				Pinning:   pinning,
+				Roles:     []string{"FROM"},
+				Location:  fmt.Sprintf("%s:%d", path, base.Line),
+			})
+		}
+	}
+
+	return images, nil
+}
+
+// referencePinning returns the pinning of an image reference string.
+func referencePinning(reference string) Pinning {
+	name, digest, _ := strings.Cut(reference, "@")
+
+	switch {
+	case digest != "":
+		return PinnedDigest
+	// A colon after the last slash is a tag (before it, a registry port)
+	case strings.Contains(name[strings.LastIndex(name, "/")+1:], ":"):
+		return PinnedTag
+	default:
+		return Unpinned
+	}
+}
+
+// location formats a source position relative to dir.
+func location(fset *token.FileSet, pos token.Pos, dir string) string {
+	position := fset.Position(pos)
+
+	file, err := filepath.Rel(dir, position.Filename)
+	if err != nil {
+		file = position.Filename
+	}
+
+	return fmt.Sprintf("%s:%d", file, position.Line)
+}
+
+// stringConstants collects constants and variables initialized with string literals (e.g., versions).
+func stringConstants(files []*ast.File) map[string]string {
+	constants := make(map[string]string)
+
+	for _, file := range files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			spec, ok := node.(*ast.ValueSpec)
+			if !ok {
+				return true
+			}
+
+			for idx, name := range spec.Names {
+				if idx < len(spec.Values) {
+					if value, ok := stringLiteral(spec.Values[idx]); ok {
+						constants[name.Name] = value
+					}
+				}
+			}
+
+			return true
+		})
+	}
+
+	return constants
+}
+
+// stringLiteral returns the value of a string literal expression.
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+
+	value, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+
+	return value, true
+}
+
+// appendUnique appends value unless already present.
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+
+	return append(values, value)
+}
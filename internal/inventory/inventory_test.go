@@ -0,0 +1,100 @@
+package inventory_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/internal/inventory"
+)
+
+const testPlan = `package main
+
+import (
+	"os"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+const alpineVersion = "3.20"
+
+func main() {
+	plan := sdk.NewPlan("test")
+
+	src, _ := sdk.NewImage("library/alpine").Version(alpineVersion).
+		Digest("sha256:0000000000000000000000000000000000000000000000000000000000000000").Build()
+	dst, _ := sdk.NewImage("org/alpine").Domain("ghcr.io").Version(os.Getenv("TAG")).Build()
+	app, _ := sdk.NewImage("org/app").Domain("ghcr.io").Build()
+
+	_, _ = plan.Sync("mirror").Source(src).Destination(dst).Build()
+	_, _ = plan.Build("app").Context("./app").Tag("ghcr.io/org/app:1.0").Build()
+	_, _ = plan.Scan("scan").Source(dst).Build()
+	_, _ = plan.Rollback("rollback").Image(app).Build()
+}
+`
+
+const testDockerfile = `ARG GO_VERSION=1.24
+FROM golang:${GO_VERSION} AS build
+FROM alpine
+`
+
+// INTENTION: Images declared in the plan source are listed with their pinning and the builder methods they
+// are passed to, values computed at runtime are flagged, and Dockerfile base images of builds are included.
+func TestScan(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(dir, "app"), filesystem.DirPermissionsDefault); err != nil {
+		t.Fatalf("failed to create context: %v", err)
+	}
+
+	files := map[string]string{
+		"main.go":        testPlan,
+		"main_test.go":   "package main\n\nvar ignored = sdk.NewImage(\"ignored\")\n",
+		"app/Dockerfile": testDockerfile,
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), filesystem.FilePermissionsDefault); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	images, err := inventory.Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	want := []inventory.Image{
+		{
+			Reference: "library/alpine:3.20@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+			Pinning:   inventory.PinnedDigest,
+			Roles:     []string{"Source"},
+			Location:  "main.go:14",
+		},
+		{
+			Reference: "ghcr.io/org/alpine:" + inventory.Dynamic,
+			Pinning:   inventory.PinnedTag,
+			Roles:     []string{"Destination", "Source"},
+			Location:  "main.go:16",
+		},
+		{Reference: "ghcr.io/org/app", Pinning: inventory.Unpinned, Roles: []string{"Image"}, Location: "main.go:17"},
+		{Reference: "ghcr.io/org/app:1.0", Pinning: inventory.PinnedTag, Roles: []string{"Build"}, Location: "main.go:20"},
+		{Reference: "golang:1.24", Pinning: inventory.PinnedTag, Roles: []string{"FROM"}, Location: "app/Dockerfile:2"},
+		{Reference: "alpine", Pinning: inventory.Unpinned, Roles: []string{"FROM"}, Location: "app/Dockerfile:3"},
+	}
+
+	if len(images) != len(want) {
+		t.Fatalf("Scan() = %+v, want %d images", images, len(want))
+	}
+
+	for idx, image := range images {
+		if image.Reference != want[idx].Reference || image.Pinning != want[idx].Pinning ||
+			!slices.Equal(image.Roles, want[idx].Roles) || image.Location != want[idx].Location {
+			t.Errorf("image %d = %+v, want %+v", idx, image, want[idx])
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Package kubernetes extracts container images from Kubernetes manifests (plain YAML or Helm-rendered output).
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidManifest indicates a manifest document that cannot be parsed.
+var ErrInvalidManifest = errors.New("invalid Kubernetes manifest")
+
+// Workload is a Kubernetes object running containers.
+type Workload struct {
+	// Kind is the object kind (e.g., "Deployment").
+	Kind string
+	// Namespace is the object namespace (empty when not set in the manifest).
+	Namespace string
+	// Name is the object name.
+	Name string
+	// Containers lists the containers of the pod template, init containers first.
+	Containers []Container
+}
+
+// String returns the workload as kind/name, prefixed with the namespace when set (e.g., "web/Deployment/api").
+func (workload Workload) String() string {
+	ret := workload.Kind + "/" + workload.Name
+	if workload.Namespace != "" {
+		ret = workload.Namespace + "/" + ret
+	}
+
+	return ret
+}
+
+// Container is a container of a workload.
+type Container struct {
+	// Name is the container name.
+	Name string
+	// Image is the image reference as written (e.g., "nginx:1.27").
+	Image string
+	// Init reports whether the container is an init container.
+	Init bool
+}
+
+// podSpec is the part of a pod specification listing containers.
+type podSpec struct {
+	InitContainers      []container `yaml:"initContainers"`
+	Containers          []container `yaml:"containers"`
+	EphemeralContainers []container `yaml:"ephemeralContainers"`
+}
+
+type container struct {
+	Name  string `yaml:"name"`
+	Image string `yaml:"image"`
+}
+
+type podTemplate struct {
+	Spec podSpec `yaml:"spec"`
+}
+
+// object is the part of a Kubernetes object locating pod specifications:
+// in spec (Pod), spec.template (Deployment, StatefulSet, DaemonSet, ReplicaSet, Job, ...),
+// or spec.jobTemplate.spec.template (CronJob). Lists carry objects in items.
+type object struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+	Spec struct {
+		podSpec `yaml:",inline"`
+
+		Template    podTemplate `yaml:"template"`
+		JobTemplate struct {
+			Spec struct {
+				Template podTemplate `yaml:"template"`
+			} `yaml:"spec"`
+		} `yaml:"jobTemplate"`
+	} `yaml:"spec"`
+	Items []object `yaml:"items"`
+}
+
+// ParseFile extracts the workloads of a manifest file.
+func ParseFile(path string) ([]Workload, error) {
+	// #nosec G304 -- Manifest path provided by the plan author
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	workloads, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return workloads, nil
+}
+
+// Parse extracts the workloads of a stream of YAML documents, in order.
+// Objects without containers (Services, ConfigMaps, ...) and empty documents are skipped.
+func Parse(reader io.Reader) ([]Workload, error) {
+	decoder := yaml.NewDecoder(reader)
+
+	var workloads []Workload
+
+	for {
+		var obj object
+
+		err := decoder.Decode(&obj)
+		if errors.Is(err, io.EOF) {
+			return workloads, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidManifest, err)
+		}
+
+		workloads = appendWorkloads(workloads, obj)
+	}
+}
+
+// appendWorkloads appends the workload described by obj, or by the items of a list.
+func appendWorkloads(workloads []Workload, obj object) []Workload {
+	for _, item := range obj.Items {
+		workloads = appendWorkloads(workloads, item)
+	}
+
+	var spec podSpec
+
+	switch obj.Kind {
+	case "Pod":
+		spec = obj.Spec.podSpec
+	case "CronJob":
+		spec = obj.Spec.JobTemplate.Spec.Template.Spec
+	default:
+		spec = obj.Spec.Template.Spec
+	}
+
+	workload := Workload{Kind: obj.Kind, Namespace: obj.Metadata.Namespace, Name: obj.Metadata.Name}
+
+	for _, init := range spec.InitContainers {
+		workload.Containers = append(workload.Containers, Container{Name: init.Name, Image: init.Image, Init: true})
+	}
+
+	for _, regular := range slices.Concat(spec.Containers, spec.EphemeralContainers) {
+		workload.Containers = append(workload.Containers, Container{Name: regular.Name, Image: regular.Image})
+	}
+
+	// Containers without image are only valid in pod templates completed at admission
+	workload.Containers = slices.DeleteFunc(workload.Containers, func(ctr Container) bool {
+		return ctr.Image == ""
+	})
+
+	if len(workload.Containers) == 0 {
+		return workloads
+	}
+
+	return append(workloads, workload)
+}
+
+// Images returns the distinct images of workloads, in order of first use.
+func Images(workloads []Workload) []string {
+	seen := make(map[string]bool)
+
+	var images []string
+
+	for _, workload := range workloads {
+		for _, ctr := range workload.Containers {
+			if !seen[ctr.Image] {
+				seen[ctr.Image] = true
+				images = append(images, ctr.Image)
+			}
+		}
+	}
+
+	return images
+}
@@ -0,0 +1,122 @@
+package kubernetes_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/farcloser/quark/internal/kubernetes"
+)
+
+const testManifests = `# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: web
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          image: registry.example.com/api:1.4
+      containers:
+        - name: api
+          image: registry.example.com/api:1.4
+        - name: proxy
+          image: envoyproxy/envoy:v1.31.0
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  ports:
+    - port: 80
+---
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: backup
+              image: postgres:16
+---
+apiVersion: v1
+kind: List
+items:
+  - apiVersion: v1
+    kind: Pod
+    metadata:
+      name: debug
+    spec:
+      containers:
+        - name: shell
+          image: busybox
+`
+
+// INTENTION: workloads are extracted from multi-document streams (Helm output) with their containers,
+// whatever their kind (pod templates, cron jobs, bare pods, lists), and objects without containers are skipped.
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	workloads, err := kubernetes.Parse(strings.NewReader(testManifests))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := []kubernetes.Workload{
+		{
+			Kind:      "Deployment",
+			Namespace: "web",
+			Name:      "api",
+			Containers: []kubernetes.Container{
+				{Name: "migrate", Image: "registry.example.com/api:1.4", Init: true},
+				{Name: "api", Image: "registry.example.com/api:1.4"},
+				{Name: "proxy", Image: "envoyproxy/envoy:v1.31.0"},
+			},
+		},
+		{
+			Kind:       "CronJob",
+			Name:       "backup",
+			Containers: []kubernetes.Container{{Name: "backup", Image: "postgres:16"}},
+		},
+		{
+			Kind:       "Pod",
+			Name:       "debug",
+			Containers: []kubernetes.Container{{Name: "shell", Image: "busybox"}},
+		},
+	}
+
+	if !reflect.DeepEqual(workloads, want) {
+		t.Errorf("Parse() =\n%+v\nwant\n%+v", workloads, want)
+	}
+
+	if got := workloads[0].String(); got != "web/Deployment/api" {
+		t.Errorf("String() = %q, want %q", got, "web/Deployment/api")
+	}
+
+	images := kubernetes.Images(workloads)
+	wantImages := []string{"registry.example.com/api:1.4", "envoyproxy/envoy:v1.31.0", "postgres:16", "busybox"}
+
+	if !reflect.DeepEqual(images, wantImages) {
+		t.Errorf("Images() = %v, want %v", images, wantImages)
+	}
+}
+
+// INTENTION: malformed documents are rejected rather than silently skipped.
+func TestParse_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := kubernetes.Parse(strings.NewReader("kind: Deployment\nspec: [\n"))
+	if !errors.Is(err, kubernetes.ErrInvalidManifest) {
+		t.Fatalf("Parse() error = %v, want %v", err, kubernetes.ErrInvalidManifest)
+	}
+}
@@ -0,0 +1,115 @@
+// Package logfmt converts zerolog JSON events to logfmt lines (key=value pairs).
+package logfmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidEvent indicates an event that is not a JSON object.
+var ErrInvalidEvent = errors.New("invalid log event")
+
+// Writer writes the JSON events written to it as logfmt lines to its output.
+// Field order is preserved. Nested objects and arrays are written as quoted compact JSON.
+type Writer struct {
+	mutex sync.Mutex
+	out   io.Writer
+}
+
+// New returns a writer converting events to logfmt on out.
+func New(out io.Writer) *Writer {
+	return &Writer{out: out}
+}
+
+// Write converts one JSON event (zerolog writes one event per call) to a logfmt line.
+func (writer *Writer) Write(event []byte) (int, error) {
+	line, err := Format(event)
+	if err != nil {
+		return 0, err
+	}
+
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	if _, err := writer.out.Write(line); err != nil {
+		return 0, err
+	}
+
+	return len(event), nil
+}
+
+// Format converts a JSON event to a logfmt line, terminated by a newline.
+func Format(event []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(event))
+	decoder.UseNumber()
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, ErrInvalidEvent
+	}
+
+	var line bytes.Buffer
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidEvent, err)
+		}
+
+		key, ok := token.(string)
+		if !ok {
+			return nil, ErrInvalidEvent
+		}
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidEvent, err)
+		}
+
+		if line.Len() > 0 {
+			line.WriteByte(' ')
+		}
+
+		line.WriteString(key)
+		line.WriteByte('=')
+		line.WriteString(formatValue(value))
+	}
+
+	line.WriteByte('\n')
+
+	return line.Bytes(), nil
+}
+
+// formatValue returns a JSON value in logfmt: strings quoted when needed, other scalars as is,
+// objects and arrays as quoted compact JSON.
+func formatValue(value json.RawMessage) string {
+	var str string
+	if len(value) == 0 || value[0] != '"' || json.Unmarshal(value, &str) != nil {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, value); err != nil {
+			return quote(string(value))
+		}
+
+		if first := compact.Bytes()[0]; first == '{' || first == '[' {
+			return quote(compact.String())
+		}
+
+		return compact.String()
+	}
+
+	if str == "" || strings.ContainsAny(str, " =\"\\\t\r\n") {
+		return quote(str)
+	}
+
+	return str
+}
+
+func quote(str string) string {
+	quoted, _ := json.Marshal(str)
+
+	return string(quoted)
+}
@@ -0,0 +1,73 @@
+package logfmt_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/farcloser/quark/internal/logfmt"
+)
+
+// INTENTION: events keep their field order, strings are only quoted when they need to be,
+// and structured values stay readable as compact JSON.
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		event string
+		want  string
+	}{
+		{
+			name:  "scalars",
+			event: `{"level":"info","plan":"mirror","count":3,"dry_run":false,"time":"2025-01-02T03:04:05Z"}`,
+			want:  "level=info plan=mirror count=3 dry_run=false time=2025-01-02T03:04:05Z\n",
+		},
+		{
+			name:  "quoted strings",
+			event: `{"message":"sync complete","empty":"","path":"a=b","quote":"say \"hi\""}`,
+			want:  `message="sync complete" empty="" path="a=b" quote="say \"hi\""` + "\n",
+		},
+		{
+			name:  "structured values",
+			event: `{"run_only_on":["ci", "local"],"details":{"a": 1},"error":null}`,
+			want:  `run_only_on="[\"ci\",\"local\"]" details="{\"a\":1}" error=null` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := logfmt.Format([]byte(tt.event))
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// INTENTION: the writer emits one line per event, and rejects events that are not JSON objects.
+func TestWriter(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+
+	writer := logfmt.New(&out)
+
+	if _, err := writer.Write([]byte(`{"level":"warn","message":"slow"}` + "\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if got := out.String(); got != "level=warn message=slow\n" {
+		t.Errorf("output = %q, want %q", got, "level=warn message=slow\n")
+	}
+
+	if _, err := writer.Write([]byte("not json")); !errors.Is(err, logfmt.ErrInvalidEvent) {
+		t.Errorf("Write() error = %v, want %v", err, logfmt.ErrInvalidEvent)
+	}
+}
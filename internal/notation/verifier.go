@@ -0,0 +1,166 @@
+// Package notation provides Notary Project (notation) signature verification.
+package notation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/subprocess"
+	"github.com/farcloser/quark/internal/tools"
+)
+
+var (
+	// ErrVerificationFailed indicates the image has no signature trusted by the trust policy.
+	ErrVerificationFailed = errors.New("signature verification failed")
+
+	// ErrDigestMismatch indicates notation verified another image than the one requested.
+	ErrDigestMismatch = errors.New("signature does not cover the image digest")
+
+	errDigestRequired      = errors.New("image reference must include a digest")
+	errTrustPolicyRequired = errors.New("a trust policy directory is required")
+)
+
+// verifiedPrefix starts the notation verify success message, followed by the verified reference.
+const verifiedPrefix = "Successfully verified signature for "
+
+// Verifier wraps notation CLI operations.
+type Verifier struct {
+	log       zerolog.Logger
+	installer *tools.Installer
+}
+
+// NewVerifier creates a new notation verifier.
+func NewVerifier(log zerolog.Logger) *Verifier {
+	return &Verifier{
+		log:       log,
+		installer: tools.NewInstaller(log),
+	}
+}
+
+// VerifyOptions configures signature verification.
+type VerifyOptions struct {
+	Username string // Registry username (optional)
+	Password string // Registry password (optional)
+	// TrustPolicy is a directory laid out like the notation configuration directory: trustpolicy.json (or
+	// trustpolicy.oci.json) and the truststore/ holding the trusted certificates.
+	TrustPolicy string
+}
+
+// Verify verifies the signatures of imageRef, which must be a digest reference, against the trust policy.
+// Returns the verified digest.
+func (verifier *Verifier) Verify(ctx context.Context, imageRef string, opts VerifyOptions) (string, error) {
+	_, imageDigest, ok := strings.Cut(imageRef, "@")
+	if !ok {
+		return "", fmt.Errorf("%w: %s", errDigestRequired, imageRef)
+	}
+
+	if opts.TrustPolicy == "" {
+		return "", errTrustPolicyRequired
+	}
+
+	notationPath, err := verifier.installer.Ensure(tools.Notation)
+	if err != nil {
+		return "", fmt.Errorf("failed to ensure notation is installed: %w", err)
+	}
+
+	configEnv, cleanup, err := configDirectory(opts.TrustPolicy)
+	if err != nil {
+		return "", err
+	}
+
+	defer cleanup()
+
+	verifier.log.Info().
+		Str("image", imageRef).
+		Str("trust_policy", opts.TrustPolicy).
+		Msg("verifying signatures with notation")
+
+	//nolint:gosec // Image ref is from user config
+	cmd := subprocess.Command(ctx, notationPath, "verify", imageRef)
+	cmd.Env = append(os.Environ(), configEnv)
+
+	// Credentials go through the environment rather than the command line
+	if opts.Username != "" && opts.Password != "" {
+		cmd.Env = append(cmd.Env, "NOTATION_USERNAME="+opts.Username, "NOTATION_PASSWORD="+opts.Password)
+	}
+
+	var stdout, stderr strings.Builder
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	subprocess.Echo(ctx, cmd)
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("notation verification interrupted: %w", subprocess.Error(ctx, err, stderr.String()))
+		}
+
+		return "", fmt.Errorf("%w: %s: %w", ErrVerificationFailed, imageRef,
+			subprocess.Error(ctx, err, stderr.String()))
+	}
+
+	return verifiedDigest(stdout.String(), imageRef, imageDigest)
+}
+
+// verifiedDigest checks the reference notation reports as verified is imageDigest.
+func verifiedDigest(output, imageRef, imageDigest string) (string, error) {
+	for line := range strings.Lines(output) {
+		verified, ok := strings.CutPrefix(strings.TrimSpace(line), verifiedPrefix)
+		if !ok {
+			continue
+		}
+
+		if _, digest, _ := strings.Cut(verified, "@"); digest != imageDigest {
+			return "", fmt.Errorf("%w: %s: verified %s", ErrDigestMismatch, imageRef, verified)
+		}
+
+		return imageDigest, nil
+	}
+
+	return "", fmt.Errorf("%w: %s: %s", ErrVerificationFailed, imageRef, strings.TrimSpace(output))
+}
+
+// configDirectory makes notation read its trust policy and trust store from trustPolicy, instead of the user
+// configuration directory: returns the environment variable pointing notation to it, and its cleanup.
+func configDirectory(trustPolicy string) (string, func(), error) {
+	trustPolicy, err := filepath.Abs(trustPolicy)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid trust policy directory: %w", err)
+	}
+
+	root, err := os.MkdirTemp("", "quark-notation-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create notation config directory: %w", err)
+	}
+
+	cleanup := func() {
+		_ = os.RemoveAll(root)
+	}
+
+	// notation reads <user config dir>/notation: $XDG_CONFIG_HOME, or $HOME/Library/Application Support on macOS
+	configHome, env := root, "XDG_CONFIG_HOME="+root
+	if runtime.GOOS == "darwin" {
+		configHome, env = filepath.Join(root, "Library", "Application Support"), "HOME="+root
+	}
+
+	if err := os.MkdirAll(configHome, 0o700); err != nil {
+		cleanup()
+
+		return "", nil, fmt.Errorf("failed to create notation config directory: %w", err)
+	}
+
+	if err := os.Symlink(trustPolicy, filepath.Join(configHome, "notation")); err != nil {
+		cleanup()
+
+		return "", nil, fmt.Errorf("failed to link trust policy directory: %w", err)
+	}
+
+	return env, cleanup, nil
+}
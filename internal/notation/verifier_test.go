@@ -0,0 +1,136 @@
+package notation_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/notation"
+)
+
+const (
+	imageDigest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	otherDigest = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+// fakeNotation puts a notation in PATH printing output and exiting with code, after copying the trust policy
+// it reads from its configuration directory. Returns the path of the trust policy copy.
+func fakeNotation(t *testing.T, output string, code int) string {
+	t.Helper()
+
+	if runtime.GOOS == "darwin" {
+		t.Skip("the fake notation reads its configuration from XDG_CONFIG_HOME")
+	}
+
+	dir := t.TempDir()
+	policyFile := filepath.Join(dir, "policy")
+	outputFile := filepath.Join(dir, "output")
+
+	if err := os.WriteFile(outputFile, []byte(output), 0o600); err != nil {
+		t.Fatalf("failed to write output: %v", err)
+	}
+
+	script := "#!/bin/sh\ncp \"$XDG_CONFIG_HOME/notation/trustpolicy.json\" " + policyFile + "\ncat " + outputFile +
+		"\nexit " + strconv.Itoa(code) + "\n"
+
+	//nolint:gosec // Test binary must be executable
+	if err := os.WriteFile(filepath.Join(dir, "notation"), []byte(script), 0o700); err != nil {
+		t.Fatalf("failed to write fake notation: %v", err)
+	}
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	return policyFile
+}
+
+// trustPolicy writes a trust policy directory and returns it.
+func trustPolicy(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "trustpolicy.json"), []byte(`{"version":"1.0"}`), 0o600); err != nil {
+		t.Fatalf("failed to write trust policy: %v", err)
+	}
+
+	return dir
+}
+
+// INTENTION: notation verifies against the trust policy of the operation, not the one of the user, and the
+// verified digest is returned.
+func TestVerifier_Verify(t *testing.T) {
+	policyFile := fakeNotation(t, "Successfully verified signature for ghcr.io/org/app@"+imageDigest+"\n", 0)
+
+	digest, err := notation.NewVerifier(zerolog.Nop()).Verify(t.Context(), "ghcr.io/org/app@"+imageDigest,
+		notation.VerifyOptions{TrustPolicy: trustPolicy(t)})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	if digest != imageDigest {
+		t.Errorf("Verify() = %s, want %s", digest, imageDigest)
+	}
+
+	policy, err := os.ReadFile(policyFile)
+	if err != nil {
+		t.Fatalf("failed to read the trust policy read by notation: %v", err)
+	}
+
+	if string(policy) != `{"version":"1.0"}` {
+		t.Errorf("notation read trust policy %q, want the one of the operation", policy)
+	}
+}
+
+// INTENTION: Verification fails when notation rejects the signatures, or reports another image as verified.
+func TestVerifier_Verify_Failures(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		code    int
+		wantErr error
+	}{
+		{
+			name:    "rejected",
+			output:  "",
+			code:    1,
+			wantErr: notation.ErrVerificationFailed,
+		},
+		{
+			name:    "other digest",
+			output:  "Successfully verified signature for ghcr.io/org/app@" + otherDigest + "\n",
+			wantErr: notation.ErrDigestMismatch,
+		},
+		{
+			name:    "no confirmation",
+			output:  "Warning: nothing verified\n",
+			wantErr: notation.ErrVerificationFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeNotation(t, tt.output, tt.code)
+
+			_, err := notation.NewVerifier(zerolog.Nop()).Verify(t.Context(), "ghcr.io/org/app@"+imageDigest,
+				notation.VerifyOptions{TrustPolicy: trustPolicy(t)})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want error wrapping %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// INTENTION: Only digest references are verified: a tag could be moved to unsigned content after verification.
+func TestVerifier_Verify_RequiresDigest(t *testing.T) {
+	t.Parallel()
+
+	_, err := notation.NewVerifier(zerolog.Nop()).Verify(t.Context(), "ghcr.io/org/app:1.0",
+		notation.VerifyOptions{TrustPolicy: "trust"})
+	if err == nil {
+		t.Error("Verify() should fail for tag references")
+	}
+}
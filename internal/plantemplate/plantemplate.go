@@ -0,0 +1,112 @@
+// Package plantemplate renders declarative plan documents as Go text/templates, so a single document can
+// describe a matrix of operations (ranges over image lists), read the environment and reference secrets.
+package plantemplate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var (
+	// ErrUndefinedEnv indicates a strict template reads an environment variable that is not set.
+	ErrUndefinedEnv = errors.New("environment variable not set")
+
+	// ErrNoSecretResolver indicates a template references a secret but no resolver is configured.
+	ErrNoSecretResolver = errors.New("secret references are not supported")
+
+	// ErrRender indicates a template failed to parse or execute.
+	ErrRender = errors.New("failed to render plan template")
+)
+
+// SecretResolver returns a field of a secret item (e.g., "op://vault/item", "password").
+type SecretResolver func(reference, field string) (string, error)
+
+// Options configure rendering.
+type Options struct {
+	// Strict fails rendering on undefined variables and unset environment variables,
+	// instead of rendering them as empty values.
+	Strict bool
+	// Vars is the template data (e.g., {"images": [...]} for {{ range .images }}).
+	Vars map[string]any
+	// LookupEnv reads environment variables (default: os.LookupEnv).
+	LookupEnv func(key string) (string, bool)
+	// Secret resolves secret references; templates using secret fail when nil.
+	Secret SecretResolver
+}
+
+// Render executes content as a template named name (used in error messages, e.g. the plan path).
+//
+// Besides the text/template builtins, templates can use:
+//
+//	env "NAME"                     environment variable (unset: error in strict mode, empty otherwise)
+//	envOr "NAME" "default"         environment variable with a default
+//	secret "op://vault/item" "f"   field of a secret item
+//	split "," "a,b"                split a string into a list (e.g., to range over an environment variable)
+//	quote "value"                  double-quoted string, safe as a YAML or JSON scalar
+func Render(name string, content []byte, opts Options) ([]byte, error) {
+	lookupEnv := opts.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+
+	funcs := template.FuncMap{
+		"env": func(key string) (string, error) {
+			value, ok := lookupEnv(key)
+			if !ok && opts.Strict {
+				return "", fmt.Errorf("%w: %q", ErrUndefinedEnv, key)
+			}
+
+			return value, nil
+		},
+		"envOr": func(key, fallback string) string {
+			if value, ok := lookupEnv(key); ok {
+				return value
+			}
+
+			return fallback
+		},
+		"secret": func(reference, field string) (string, error) {
+			if opts.Secret == nil {
+				return "", fmt.Errorf("%w: %q", ErrNoSecretResolver, reference)
+			}
+
+			return opts.Secret(reference, field)
+		},
+		"split": func(separator, value string) []string {
+			if value == "" {
+				return nil
+			}
+
+			return strings.Split(value, separator)
+		},
+		"quote": strconv.Quote,
+	}
+
+	tmpl := template.New(name).Funcs(funcs)
+
+	if opts.Strict {
+		tmpl = tmpl.Option("missingkey=error")
+	}
+
+	tmpl, err := tmpl.Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRender, err)
+	}
+
+	vars := opts.Vars
+	if vars == nil {
+		vars = map[string]any{}
+	}
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, vars); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRender, err)
+	}
+
+	return rendered.Bytes(), nil
+}
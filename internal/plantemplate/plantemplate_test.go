@@ -0,0 +1,101 @@
+package plantemplate_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/farcloser/quark/internal/plantemplate"
+)
+
+func lookupEnv(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+
+		return value, ok
+	}
+}
+
+// INTENTION: A single document expands into a matrix of operations from variables, the environment and
+// secrets, and strict mode turns every undefined value into an error instead of an empty string.
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	content := `registries:
+  - domain: ghcr.io
+    password: {{ secret "op://build/ghcr" "password" | quote }}
+scans:
+{{- range .images }}
+  - name: scan-{{ .name }}
+    image: {{ .ref }}:{{ envOr "TAG" "latest" }}
+{{- end }}
+{{- range split "," (env "MIRRORS") }}
+  - name: scan-{{ . }}
+{{- end }}
+`
+
+	opts := plantemplate.Options{
+		Strict: true,
+		Vars: map[string]any{
+			"images": []map[string]string{
+				{"name": "app", "ref": "ghcr.io/org/app"},
+				{"name": "worker", "ref": "ghcr.io/org/worker"},
+			},
+		},
+		LookupEnv: lookupEnv(map[string]string{"MIRRORS": "alpine,debian"}),
+		Secret: func(reference, field string) (string, error) {
+			return reference + "#" + field, nil
+		},
+	}
+
+	rendered, err := plantemplate.Render("plan.yaml", []byte(content), opts)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	want := `registries:
+  - domain: ghcr.io
+    password: "op://build/ghcr#password"
+scans:
+  - name: scan-app
+    image: ghcr.io/org/app:latest
+  - name: scan-worker
+    image: ghcr.io/org/worker:latest
+  - name: scan-alpine
+  - name: scan-debian
+`
+	if string(rendered) != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", rendered, want)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		strict  bool
+		want    string
+		wantErr error
+	}{
+		{name: "strict undefined variable", content: "{{ .missing }}", strict: true, wantErr: plantemplate.ErrRender},
+		{name: "strict unset env", content: `{{ env "UNSET" }}`, strict: true, wantErr: plantemplate.ErrUndefinedEnv},
+		{name: "lenient unset env", content: `[{{ env "UNSET" }}]`, want: "[]"},
+		{name: "secret without resolver", content: `{{ secret "op://v/i" "f" }}`, wantErr: plantemplate.ErrNoSecretResolver},
+		{name: "parse error", content: "{{ range }}", wantErr: plantemplate.ErrRender},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rendered, err := plantemplate.Render("plan.yaml", []byte(test.content), plantemplate.Options{
+				Strict:    test.strict,
+				LookupEnv: lookupEnv(nil),
+			})
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("Render() error = %v, want %v", err, test.wantErr)
+			}
+
+			if err == nil && string(rendered) != test.want {
+				t.Errorf("Render() = %q, want %q", rendered, test.want)
+			}
+		})
+	}
+}
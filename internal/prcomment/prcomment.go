@@ -0,0 +1,303 @@
+// Package prcomment posts summary comments on the pull request (GitHub) or merge request (GitLab)
+// a CI job runs for, updating its previous comment in place instead of adding a new one on every push.
+package prcomment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// pageSize is the number of comments fetched per page when looking for a previous comment.
+const pageSize = 100
+
+// maxPages bounds the comment pages searched (pull requests with thousands of comments).
+const maxPages = 50
+
+var (
+	// ErrRequestFailed indicates the code hosting API rejected a request.
+	ErrRequestFailed = errors.New("code hosting API request failed")
+	// ErrInvalidEvent indicates the CI event payload could not be read.
+	ErrInvalidEvent = errors.New("invalid CI event payload")
+)
+
+// Commenter posts a comment on a pull or merge request.
+type Commenter interface {
+	// Upsert creates the comment, or replaces the body of the existing comment containing marker.
+	// The marker (typically an HTML comment, invisible once rendered) must be part of body.
+	Upsert(ctx context.Context, marker, body string) error
+	// Target describes the pull or merge request (e.g., "octo/app#42").
+	Target() string
+}
+
+// FromEnv detects the pull or merge request of the current CI job from getenv (typically os.Getenv).
+// Returns nil when the job does not run for a pull or merge request, or when no API token is provided:
+//   - GitHub Actions: GITHUB_TOKEN, on pull_request events
+//   - GitLab CI: GITLAB_TOKEN (a token with api scope; CI_JOB_TOKEN cannot comment), on merge request pipelines
+func FromEnv(getenv func(string) string) (Commenter, error) {
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		return gitHubFromEnv(getenv)
+	case getenv("GITLAB_CI") == "true":
+		return gitLabFromEnv(getenv), nil
+	default:
+		return nil, nil
+	}
+}
+
+// GitHub comments on a GitHub pull request (issue comments API).
+type GitHub struct {
+	// APIURL is the API root (e.g., "https://api.github.com").
+	APIURL string
+	// Repository is "owner/name".
+	Repository string
+	Number     int
+	Token      string
+	Client     *http.Client
+}
+
+func gitHubFromEnv(getenv func(string) string) (Commenter, error) {
+	token := getenv("GITHUB_TOKEN")
+	repository := getenv("GITHUB_REPOSITORY")
+
+	if token == "" || repository == "" {
+		return nil, nil
+	}
+
+	number, err := gitHubPullRequestNumber(getenv)
+	if err != nil || number == 0 {
+		return nil, err
+	}
+
+	apiURL := getenv("GITHUB_API_URL")
+	if apiURL == "" {
+		apiURL = "https://api.github.com"
+	}
+
+	return &GitHub{APIURL: apiURL, Repository: repository, Number: number, Token: token}, nil
+}
+
+// gitHubPullRequestNumber returns the pull request number of the current workflow run, or 0 if none.
+func gitHubPullRequestNumber(getenv func(string) string) (int, error) {
+	// refs/pull/<number>/merge on pull_request events
+	if rest, ok := strings.CutPrefix(getenv("GITHUB_REF"), "refs/pull/"); ok {
+		if number, err := strconv.Atoi(strings.TrimSuffix(rest, "/merge")); err == nil {
+			return number, nil
+		}
+	}
+
+	// pull_request_target (and other) events carry the pull request in the event payload
+	eventPath := getenv("GITHUB_EVENT_PATH")
+	if eventPath == "" {
+		return 0, nil
+	}
+
+	// #nosec G304 -- path provided by the CI runner
+	content, err := os.ReadFile(eventPath)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidEvent, err)
+	}
+
+	var event struct {
+		PullRequest *struct {
+			Number int `json:"number"`
+		} `json:"pull_request"` //nolint:tagliatelle // GitHub event payload
+	}
+
+	if err := json.Unmarshal(content, &event); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidEvent, err)
+	}
+
+	if event.PullRequest == nil {
+		return 0, nil
+	}
+
+	return event.PullRequest.Number, nil
+}
+
+// Target implements Commenter.
+func (gh *GitHub) Target() string {
+	return fmt.Sprintf("%s#%d", gh.Repository, gh.Number)
+}
+
+// Upsert implements Commenter.
+func (gh *GitHub) Upsert(ctx context.Context, marker, body string) error {
+	api := &apiClient{client: gh.Client, header: http.Header{
+		"Authorization":        {"Bearer " + gh.Token},
+		"Accept":               {"application/vnd.github+json"},
+		"X-Github-Api-Version": {"2022-11-28"},
+	}}
+
+	root := strings.TrimSuffix(gh.APIURL, "/") + "/repos/" + gh.Repository
+	list := fmt.Sprintf("%s/issues/%d/comments", root, gh.Number)
+
+	id, err := api.find(ctx, list, marker)
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]string{"body": body}
+
+	if id == 0 {
+		return api.do(ctx, http.MethodPost, list, payload, nil)
+	}
+
+	return api.do(ctx, http.MethodPatch, fmt.Sprintf("%s/issues/comments/%d", root, id), payload, nil)
+}
+
+// GitLab comments on a GitLab merge request (notes API).
+type GitLab struct {
+	// APIURL is the API v4 root (e.g., "https://gitlab.com/api/v4").
+	APIURL string
+	// ProjectID is the numeric project ID or URL-encoded path.
+	ProjectID string
+	IID       int
+	Token     string
+	Client    *http.Client
+}
+
+func gitLabFromEnv(getenv func(string) string) Commenter {
+	token := getenv("GITLAB_TOKEN")
+	projectID := getenv("CI_PROJECT_ID")
+
+	iid, err := strconv.Atoi(getenv("CI_MERGE_REQUEST_IID"))
+	if token == "" || projectID == "" || err != nil {
+		return nil
+	}
+
+	apiURL := getenv("CI_API_V4_URL")
+	if apiURL == "" {
+		apiURL = "https://gitlab.com/api/v4"
+	}
+
+	return &GitLab{APIURL: apiURL, ProjectID: projectID, IID: iid, Token: token}
+}
+
+// Target implements Commenter.
+func (gl *GitLab) Target() string {
+	return fmt.Sprintf("project %s!%d", gl.ProjectID, gl.IID)
+}
+
+// Upsert implements Commenter.
+func (gl *GitLab) Upsert(ctx context.Context, marker, body string) error {
+	api := &apiClient{client: gl.Client, header: http.Header{"Private-Token": {gl.Token}}}
+
+	list := fmt.Sprintf("%s/projects/%s/merge_requests/%d/notes",
+		strings.TrimSuffix(gl.APIURL, "/"), url.PathEscape(gl.ProjectID), gl.IID)
+
+	id, err := api.find(ctx, list, marker)
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]string{"body": body}
+
+	if id == 0 {
+		return api.do(ctx, http.MethodPost, list, payload, nil)
+	}
+
+	return api.do(ctx, http.MethodPut, fmt.Sprintf("%s/%d", list, id), payload, nil)
+}
+
+// apiClient sends JSON requests to a code hosting API.
+type apiClient struct {
+	client *http.Client
+	header http.Header
+}
+
+// comment is the subset of GitHub issue comments and GitLab notes used to find a previous comment.
+type comment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// find returns the ID of the first comment listed under listURL containing marker, or 0 if none.
+func (api *apiClient) find(ctx context.Context, listURL, marker string) (int64, error) {
+	for page := 1; page <= maxPages; page++ {
+		var comments []comment
+
+		pageURL := fmt.Sprintf("%s?per_page=%d&page=%d", listURL, pageSize, page)
+		if err := api.do(ctx, http.MethodGet, pageURL, nil, &comments); err != nil {
+			return 0, err
+		}
+
+		for _, existing := range comments {
+			if strings.Contains(existing.Body, marker) {
+				return existing.ID, nil
+			}
+		}
+
+		if len(comments) < pageSize {
+			break
+		}
+	}
+
+	return 0, nil
+}
+
+// do sends a request with an optional JSON payload, and decodes the JSON response into out if not nil.
+func (api *apiClient) do(ctx context.Context, method, target string, payload, out any) error {
+	var body io.Reader
+
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+
+		body = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for key, values := range api.header {
+		req.Header[key] = values
+	}
+
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := api.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, target, err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		//nolint:mnd // Error bodies are short JSON documents
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		return fmt.Errorf("%w: %s %s: %s %s", ErrRequestFailed, method, target, resp.Status,
+			strings.TrimSpace(string(detail)))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response of %s %s: %w", method, target, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,215 @@
+package prcomment_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/internal/prcomment"
+)
+
+const marker = "<!-- quark:test -->"
+
+// fakeAPI is an in-memory comment store answering GitHub issue comment and GitLab note requests.
+type fakeAPI struct {
+	mu       sync.Mutex
+	comments map[int64]string
+	nextID   int64
+	header   http.Header
+}
+
+func newFakeAPI(existing ...string) *fakeAPI {
+	api := &fakeAPI{comments: map[int64]string{}, nextID: 1}
+	for _, body := range existing {
+		api.comments[api.nextID] = body
+		api.nextID++
+	}
+
+	return api
+}
+
+func (api *fakeAPI) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	api.header = req.Header.Clone()
+
+	var payload struct {
+		Body string `json:"body"`
+	}
+
+	if req.Body != nil {
+		_ = json.NewDecoder(req.Body).Decode(&payload)
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		type comment struct {
+			ID   int64  `json:"id"`
+			Body string `json:"body"`
+		}
+
+		list := []comment{}
+		if req.URL.Query().Get("page") == "1" {
+			for id := int64(1); id < api.nextID; id++ {
+				if body, ok := api.comments[id]; ok {
+					list = append(list, comment{ID: id, Body: body})
+				}
+			}
+		}
+
+		_ = json.NewEncoder(writer).Encode(list)
+	case http.MethodPost:
+		api.comments[api.nextID] = payload.Body
+		api.nextID++
+
+		writer.WriteHeader(http.StatusCreated)
+		_, _ = writer.Write([]byte("{}"))
+	case http.MethodPatch, http.MethodPut:
+		var id int64
+
+		segments := strings.Split(req.URL.Path, "/")
+		_ = json.Unmarshal([]byte(segments[len(segments)-1]), &id)
+
+		if _, ok := api.comments[id]; !ok {
+			http.NotFound(writer, req)
+
+			return
+		}
+
+		api.comments[id] = payload.Body
+		_, _ = writer.Write([]byte("{}"))
+	}
+}
+
+// INTENTION: Upsert creates a comment the first time, then updates that comment in place,
+// leaving unrelated comments untouched, on both GitHub and GitLab.
+func TestUpsert(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		commenter  func(serverURL string) prcomment.Commenter
+		authHeader string
+	}{
+		{
+			name: "github",
+			commenter: func(serverURL string) prcomment.Commenter {
+				return &prcomment.GitHub{APIURL: serverURL, Repository: "octo/app", Number: 42, Token: "gh-token"}
+			},
+			authHeader: "Authorization",
+		},
+		{
+			name: "gitlab",
+			commenter: func(serverURL string) prcomment.Commenter {
+				return &prcomment.GitLab{APIURL: serverURL, ProjectID: "7", IID: 3, Token: "gl-token"}
+			},
+			authHeader: "Private-Token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			api := newFakeAPI("unrelated review comment")
+			server := httptest.NewServer(api)
+			t.Cleanup(server.Close)
+
+			commenter := tt.commenter(server.URL)
+
+			for _, body := range []string{marker + "\nfirst run", marker + "\nsecond run"} {
+				if err := commenter.Upsert(t.Context(), marker, body); err != nil {
+					t.Fatalf("Upsert() error = %v", err)
+				}
+			}
+
+			if len(api.comments) != 2 {
+				t.Fatalf("comments = %v, want the unrelated comment and one quark comment", api.comments)
+			}
+
+			if api.comments[1] != "unrelated review comment" || api.comments[2] != marker+"\nsecond run" {
+				t.Errorf("comments = %v, want quark comment updated in place", api.comments)
+			}
+
+			if api.header.Get(tt.authHeader) == "" {
+				t.Errorf("request missing %s header", tt.authHeader)
+			}
+		})
+	}
+}
+
+// INTENTION: Commenting is enabled only for pull/merge request jobs with an API token.
+func TestFromEnv(t *testing.T) {
+	t.Parallel()
+
+	eventPath := filepath.Join(t.TempDir(), "event.json")
+	if err := os.WriteFile(eventPath, []byte(`{"pull_request":{"number":17}}`), filesystem.FilePermissionsDefault); err != nil {
+		t.Fatalf("failed to write event: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		env        map[string]string
+		wantTarget string
+	}{
+		{name: "not in CI", env: map[string]string{}},
+		{
+			name: "github pull request",
+			env: map[string]string{
+				"GITHUB_ACTIONS": "true", "GITHUB_TOKEN": "token", "GITHUB_REPOSITORY": "octo/app",
+				"GITHUB_REF": "refs/pull/42/merge",
+			},
+			wantTarget: "octo/app#42",
+		},
+		{
+			name: "github pull request target event",
+			env: map[string]string{
+				"GITHUB_ACTIONS": "true", "GITHUB_TOKEN": "token", "GITHUB_REPOSITORY": "octo/app",
+				"GITHUB_REF": "refs/heads/main", "GITHUB_EVENT_PATH": eventPath,
+			},
+			wantTarget: "octo/app#17",
+		},
+		{
+			name: "github without token",
+			env: map[string]string{
+				"GITHUB_ACTIONS": "true", "GITHUB_REPOSITORY": "octo/app", "GITHUB_REF": "refs/pull/42/merge",
+			},
+		},
+		{
+			name:       "gitlab merge request",
+			env:        map[string]string{"GITLAB_CI": "true", "GITLAB_TOKEN": "token", "CI_PROJECT_ID": "7", "CI_MERGE_REQUEST_IID": "3"},
+			wantTarget: "project 7!3",
+		},
+		{
+			name: "gitlab branch pipeline",
+			env:  map[string]string{"GITLAB_CI": "true", "GITLAB_TOKEN": "token", "CI_PROJECT_ID": "7"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			commenter, err := prcomment.FromEnv(func(key string) string { return tt.env[key] })
+			if err != nil {
+				t.Fatalf("FromEnv() error = %v", err)
+			}
+
+			var target string
+			if commenter != nil {
+				target = commenter.Target()
+			}
+
+			if target != tt.wantTarget {
+				t.Errorf("FromEnv() target = %q, want %q", target, tt.wantTarget)
+			}
+		})
+	}
+}
@@ -0,0 +1,390 @@
+// Package provision installs build tooling (Docker, buildx) on fresh build nodes over SSH.
+package provision
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/carapace-sh/carapace-shlex"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/ssh"
+)
+
+const (
+	// BuildxReleaseURL is the default download location of buildx release assets.
+	BuildxReleaseURL = "https://github.com/docker/buildx/releases/download"
+
+	// buildxPluginPath is the system-wide docker CLI plugin location of buildx.
+	buildxPluginPath = "/usr/local/lib/docker/cli-plugins/docker-buildx"
+
+	// buildxDownloadTimeout bounds the buildx release download.
+	buildxDownloadTimeout = 5 * time.Minute
+)
+
+var (
+	// ErrUnsupportedDistro indicates Docker cannot be installed on the node distribution.
+	ErrUnsupportedDistro = errors.New("unsupported distribution for Docker installation")
+	// ErrUnsupportedArch indicates buildx cannot be installed for the node architecture.
+	ErrUnsupportedArch = errors.New("unsupported architecture for buildx installation")
+	// ErrDownloadFailed indicates the buildx release could not be downloaded.
+	ErrDownloadFailed = errors.New("buildx download failed")
+	// ErrChecksumMismatch indicates the downloaded buildx binary does not match its checksum.
+	ErrChecksumMismatch = errors.New("buildx checksum mismatch")
+	// ErrChecksumNotFound indicates the buildx release does not publish a checksum for the asset.
+	ErrChecksumNotFound = errors.New("buildx checksum not found")
+)
+
+// Distro identifies the Linux distribution of a node, from /etc/os-release.
+type Distro struct {
+	ID      string   // e.g., "ubuntu"
+	Like    []string // ID_LIKE entries, e.g., ["debian"]
+	Version string   // VERSION_ID, e.g., "24.04"
+}
+
+// is reports whether the distribution is, or derives from, one of ids.
+func (distro Distro) is(ids ...string) bool {
+	if slices.Contains(ids, distro.ID) {
+		return true
+	}
+
+	for _, like := range distro.Like {
+		if slices.Contains(ids, like) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Provisioner installs build tooling on a node.
+type Provisioner struct {
+	conn       ssh.Connection
+	releaseURL string
+	httpClient *http.Client
+	log        zerolog.Logger
+}
+
+// New creates a provisioner for the node behind conn.
+func New(conn ssh.Connection, log zerolog.Logger) *Provisioner {
+	return &Provisioner{
+		conn:       conn,
+		releaseURL: BuildxReleaseURL,
+		httpClient: &http.Client{Timeout: buildxDownloadTimeout},
+		log:        log,
+	}
+}
+
+// WithReleaseURL overrides the buildx release download location (e.g., an internal mirror).
+// Assets are fetched from <url>/<version>/<asset>.
+func (prov *Provisioner) WithReleaseURL(url string) *Provisioner {
+	prov.releaseURL = strings.TrimSuffix(url, "/")
+
+	return prov
+}
+
+// DetectDistro reads the node distribution from /etc/os-release.
+func (prov *Provisioner) DetectDistro() (Distro, error) {
+	stdout, stderr, err := prov.conn.Execute("cat /etc/os-release")
+	if err != nil {
+		return Distro{}, fmt.Errorf("failed to read /etc/os-release: %w: %s", err, strings.TrimSpace(stderr))
+	}
+
+	var distro Distro
+
+	for line := range strings.Lines(stdout) {
+		key, value, found := strings.Cut(strings.TrimSpace(line), "=")
+		if !found {
+			continue
+		}
+
+		value = strings.Trim(value, `"'`)
+
+		switch key {
+		case "ID":
+			distro.ID = value
+		case "ID_LIKE":
+			distro.Like = strings.Fields(value)
+		case "VERSION_ID":
+			distro.Version = value
+		}
+	}
+
+	return distro, nil
+}
+
+// EnsureDocker installs Docker with the distribution package manager if it is missing,
+// and makes sure the daemon is running.
+// Returns whether Docker was installed.
+func (prov *Provisioner) EnsureDocker(ctx context.Context) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, fmt.Errorf("provisioning cancelled: %w", err)
+	}
+
+	if _, _, err := prov.conn.Execute("docker version --format '{{.Server.Version}}'"); err == nil {
+		prov.log.Debug().Msg("docker already installed and running")
+
+		return false, nil
+	}
+
+	sudo, err := prov.sudo()
+	if err != nil {
+		return false, err
+	}
+
+	installed := false
+
+	if _, _, err := prov.conn.Execute("command -v docker"); err != nil {
+		distro, err := prov.DetectDistro()
+		if err != nil {
+			return false, err
+		}
+
+		install, err := dockerInstallCommand(distro, sudo)
+		if err != nil {
+			return false, err
+		}
+
+		prov.log.Info().
+			Str("distro", distro.ID).
+			Str("version", distro.Version).
+			Msg("installing docker")
+
+		if err := prov.run(ctx, install); err != nil {
+			return false, fmt.Errorf("failed to install docker: %w", err)
+		}
+
+		installed = true
+	}
+
+	start := "if command -v systemctl >/dev/null; then " + sudo + "systemctl enable --now docker; " +
+		"else " + sudo + "rc-update add docker default && " + sudo + "service docker start; fi"
+	if err := prov.run(ctx, start); err != nil {
+		return installed, fmt.Errorf("failed to start docker: %w", err)
+	}
+
+	if sudo != "" {
+		// Builds run docker as the SSH user; group membership applies to new SSH connections only
+		if err := prov.run(ctx, sudo+"usermod -aG docker \"$(id -un)\""); err != nil {
+			return installed, fmt.Errorf("failed to add user to docker group: %w", err)
+		}
+
+		prov.log.Warn().Msg("user added to the docker group: it takes effect on the next SSH connection")
+	}
+
+	return installed, nil
+}
+
+// dockerInstallCommand returns the command installing Docker on distro.
+func dockerInstallCommand(distro Distro, sudo string) (string, error) {
+	switch {
+	case distro.is("debian", "ubuntu"):
+		return sudo + "env DEBIAN_FRONTEND=noninteractive apt-get update -q && " +
+			sudo + "env DEBIAN_FRONTEND=noninteractive apt-get install -y -q docker.io", nil
+	case distro.is("fedora") && !distro.is("rhel", "centos"):
+		return sudo + "dnf install -y moby-engine", nil
+	case distro.is("rhel", "centos"):
+		// RHEL derivatives ship podman: Docker comes from the upstream repository
+		return sudo + "dnf install -y dnf-plugins-core && " +
+			sudo + "dnf config-manager --add-repo https://download.docker.com/linux/centos/docker-ce.repo && " +
+			sudo + "dnf install -y docker-ce docker-ce-cli containerd.io", nil
+	case distro.is("alpine"):
+		return sudo + "apk add --no-cache docker", nil
+	case distro.is("arch"):
+		return sudo + "pacman -Sy --noconfirm docker", nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedDistro, distro.ID)
+	}
+}
+
+// EnsureBuildx installs the given buildx release (e.g., "v0.19.3") as a system-wide docker CLI plugin,
+// unless that version is already installed. arch is the node architecture ("amd64", "arm64").
+// The binary is downloaded locally, verified against sha256 (hex) when set, or against the checksums
+// published with the release otherwise, and uploaded to the node over SFTP: the node needs no
+// download tooling or internet access.
+// Returns whether buildx was installed.
+func (prov *Provisioner) EnsureBuildx(ctx context.Context, version, arch, sha256Hex string) (bool, error) {
+	version = "v" + strings.TrimPrefix(version, "v")
+
+	if stdout, _, err := prov.conn.Execute("docker buildx version"); err == nil {
+		// github.com/docker/buildx v0.19.3 48d6a39
+		if fields := strings.Fields(stdout); len(fields) > 1 && fields[1] == version {
+			prov.log.Debug().Str("version", version).Msg("buildx already installed")
+
+			return false, nil
+		}
+	}
+
+	if arch != "amd64" && arch != "arm64" {
+		return false, fmt.Errorf("%w: %q", ErrUnsupportedArch, arch)
+	}
+
+	asset := fmt.Sprintf("buildx-%s.linux-%s", version, arch)
+
+	if sha256Hex == "" {
+		published, err := prov.publishedChecksum(ctx, version, asset)
+		if err != nil {
+			return false, err
+		}
+
+		sha256Hex = published
+	}
+
+	prov.log.Info().
+		Str("version", version).
+		Str("arch", arch).
+		Msg("installing buildx")
+
+	local, err := prov.download(ctx, version, asset, sha256Hex)
+	if err != nil {
+		return false, err
+	}
+
+	defer func() { _ = os.Remove(local) }()
+
+	remote := "/tmp/quark-" + asset
+	if err := prov.conn.UploadFile(local, remote); err != nil {
+		return false, fmt.Errorf("failed to upload buildx: %w", err)
+	}
+
+	sudo, err := prov.sudo()
+	if err != nil {
+		return false, err
+	}
+
+	install := fmt.Sprintf("%sinstall -D -m 0755 %s %s && rm -f %s",
+		sudo, shlex.Join([]string{remote}), buildxPluginPath, shlex.Join([]string{remote}))
+	if err := prov.run(ctx, install); err != nil {
+		return false, fmt.Errorf("failed to install buildx: %w", err)
+	}
+
+	return true, nil
+}
+
+// publishedChecksum returns the checksum of asset from the release checksums.txt.
+func (prov *Provisioner) publishedChecksum(ctx context.Context, version, asset string) (string, error) {
+	body, err := prov.get(ctx, version, "checksums.txt")
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		// <sha256>  *<asset>
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return fields[0], nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read buildx checksums: %w", err)
+	}
+
+	return "", fmt.Errorf("%w: %s %s", ErrChecksumNotFound, version, asset)
+}
+
+// download fetches a release asset to a local temporary file and verifies its checksum.
+func (prov *Provisioner) download(ctx context.Context, version, asset, sha256Hex string) (string, error) {
+	body, err := prov.get(ctx, version, asset)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	file, err := os.CreateTemp("", "quark-buildx-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	hasher := sha256.New()
+
+	_, err = io.Copy(io.MultiWriter(file, hasher), body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(file.Name())
+
+		return "", fmt.Errorf("%w: %w", ErrDownloadFailed, err)
+	}
+
+	if actual := hex.EncodeToString(hasher.Sum(nil)); !strings.EqualFold(actual, sha256Hex) {
+		_ = os.Remove(file.Name())
+
+		return "", fmt.Errorf("%w: %s: expected %s, got %s", ErrChecksumMismatch, asset, sha256Hex, actual)
+	}
+
+	return file.Name(), nil
+}
+
+// get opens a release asset.
+func (prov *Provisioner) get(ctx context.Context, version, asset string) (io.ReadCloser, error) {
+	url := prov.releaseURL + "/" + version + "/" + asset
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := prov.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDownloadFailed, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+
+		return nil, fmt.Errorf("%w: %s: %s", ErrDownloadFailed, url, resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+// sudo returns the privilege escalation prefix: none for root, non-interactive sudo otherwise.
+func (prov *Provisioner) sudo() (string, error) {
+	stdout, _, err := prov.conn.Execute("id -u")
+	if err != nil {
+		return "", fmt.Errorf("failed to get remote user: %w", err)
+	}
+
+	if strings.TrimSpace(stdout) == "0" {
+		return "", nil
+	}
+
+	return "sudo -n ", nil
+}
+
+// run executes a provisioning command, logging its output.
+func (prov *Provisioner) run(ctx context.Context, command string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("provisioning cancelled: %w", err)
+	}
+
+	prov.log.Debug().Str("command", command).Msg("provisioning")
+
+	stdout, stderr, err := prov.conn.Execute(command)
+	if err != nil {
+		prov.log.Error().
+			Str("stdout", stdout).
+			Str("stderr", stderr).
+			Err(err).
+			Msg("provisioning command failed")
+
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+	}
+
+	return nil
+}
@@ -0,0 +1,279 @@
+package provision_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/provision"
+)
+
+const (
+	testBuildxVersion = "v0.19.3"
+	testBuildxAsset   = "buildx-v0.19.3.linux-amd64"
+)
+
+// mockConnection is an ssh.Connection answering commands with canned responses.
+// Commands without a response fail; executed commands and uploads are recorded.
+type mockConnection struct {
+	responses map[string]string
+	executed  []string
+	uploaded  map[string][]byte
+}
+
+func (conn *mockConnection) Execute(command string) (string, string, error) {
+	conn.executed = append(conn.executed, command)
+
+	for prefix, response := range conn.responses {
+		if strings.HasPrefix(command, prefix) {
+			return response, "", nil
+		}
+	}
+
+	return "", "not found", errCommandFailed
+}
+
+func (*mockConnection) ExecuteStreaming(_ string, _, _ io.Writer) error {
+	return nil
+}
+
+func (conn *mockConnection) UploadFile(localPath, remotePath string) error {
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return err
+	}
+
+	if conn.uploaded == nil {
+		conn.uploaded = map[string][]byte{}
+	}
+
+	conn.uploaded[remotePath] = data
+
+	return nil
+}
+
+func (*mockConnection) UploadData(_ []byte, _ string) error {
+	return nil
+}
+
+func (*mockConnection) UploadStream(_ io.Reader, _ string) error {
+	return nil
+}
+
+var errCommandFailed = errors.New("command failed")
+
+// ran reports whether a command starting with prefix was executed.
+func (conn *mockConnection) ran(prefix string) bool {
+	for _, command := range conn.executed {
+		if strings.HasPrefix(command, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// INTENTION: Docker is installed with the package manager of the detected distribution (or a derivative),
+// with sudo for non-root users; nodes with a running Docker are left alone.
+func TestProvisioner_EnsureDocker(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		osRelease     string
+		root          bool
+		running       bool
+		wantInstalled bool
+		wantCommand   string
+		wantErr       error
+	}{
+		{
+			name:    "docker already running",
+			running: true,
+		},
+		{
+			name:          "ubuntu as root",
+			osRelease:     "NAME=\"Ubuntu\"\nID=ubuntu\nID_LIKE=debian\nVERSION_ID=\"24.04\"\n",
+			root:          true,
+			wantInstalled: true,
+			wantCommand:   "env DEBIAN_FRONTEND=noninteractive apt-get update",
+		},
+		{
+			name:          "debian derivative with sudo",
+			osRelease:     "ID=raspbian\nID_LIKE=debian\n",
+			wantInstalled: true,
+			wantCommand:   "sudo -n env DEBIAN_FRONTEND=noninteractive apt-get update",
+		},
+		{
+			name:          "rocky uses the docker-ce repository",
+			osRelease:     "ID=\"rocky\"\nID_LIKE=\"rhel centos fedora\"\n",
+			root:          true,
+			wantInstalled: true,
+			wantCommand:   "dnf install -y dnf-plugins-core",
+		},
+		{
+			name:          "fedora",
+			osRelease:     "ID=fedora\n",
+			root:          true,
+			wantInstalled: true,
+			wantCommand:   "dnf install -y moby-engine",
+		},
+		{
+			name:          "alpine",
+			osRelease:     "ID=alpine\n",
+			root:          true,
+			wantInstalled: true,
+			wantCommand:   "apk add --no-cache docker",
+		},
+		{
+			name:      "unsupported distribution",
+			osRelease: "ID=gentoo\n",
+			root:      true,
+			wantErr:   provision.ErrUnsupportedDistro,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			conn := &mockConnection{responses: map[string]string{
+				"cat /etc/os-release": tt.osRelease,
+				"id -u":               "1000\n",
+				// Installation, service and group commands succeed
+				"env ":     "",
+				"sudo -n ": "",
+				"dnf ":     "",
+				"apk ":     "",
+				"if ":      "",
+			}}
+
+			if tt.root {
+				conn.responses["id -u"] = "0\n"
+			}
+
+			if tt.running {
+				conn.responses["docker version"] = "27.3.1\n"
+			}
+
+			installed, err := provision.New(conn, zerolog.Nop()).EnsureDocker(t.Context())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("EnsureDocker() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if installed != tt.wantInstalled {
+				t.Errorf("EnsureDocker() installed = %v, want %v", installed, tt.wantInstalled)
+			}
+
+			if tt.wantCommand != "" && !conn.ran(tt.wantCommand) {
+				t.Errorf("expected a command starting with %q, executed: %q", tt.wantCommand, conn.executed)
+			}
+
+			if tt.running && conn.ran("cat /etc/os-release") {
+				t.Error("EnsureDocker() inspected the node although docker is running")
+			}
+		})
+	}
+}
+
+// INTENTION: buildx is downloaded locally, verified against the published or pinned checksum,
+// and uploaded to the node; tampered downloads are refused, and an installed version is kept.
+func TestProvisioner_EnsureBuildx(t *testing.T) {
+	t.Parallel()
+
+	binary := []byte("buildx binary")
+	sum := sha256.Sum256(binary)
+	checksum := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name          string
+		installed     string
+		published     string
+		pinned        string
+		arch          string
+		wantInstalled bool
+		wantErr       error
+	}{
+		{
+			name:          "verified against published checksums",
+			published:     checksum,
+			arch:          "amd64",
+			wantInstalled: true,
+		},
+		{
+			name:          "verified against pinned checksum",
+			published:     strings.Repeat("0", 64),
+			pinned:        checksum,
+			arch:          "amd64",
+			wantInstalled: true,
+		},
+		{
+			name:      "tampered download",
+			published: strings.Repeat("0", 64),
+			arch:      "amd64",
+			wantErr:   provision.ErrChecksumMismatch,
+		},
+		{
+			name:      "same version already installed",
+			installed: "github.com/docker/buildx v0.19.3 48d6a39\n",
+			arch:      "amd64",
+		},
+		{
+			name:    "unsupported architecture",
+			arch:    "riscv64",
+			wantErr: provision.ErrUnsupportedArch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+				switch req.URL.Path {
+				case "/" + testBuildxVersion + "/checksums.txt":
+					fmt.Fprintf(writer, "%s *%s\n", tt.published, testBuildxAsset)
+				case "/" + testBuildxVersion + "/" + testBuildxAsset:
+					_, _ = writer.Write(binary)
+				default:
+					http.NotFound(writer, req)
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			conn := &mockConnection{responses: map[string]string{"id -u": "0\n", "install ": ""}}
+			if tt.installed != "" {
+				conn.responses["docker buildx version"] = tt.installed
+			}
+
+			installed, err := provision.New(conn, zerolog.Nop()).
+				WithReleaseURL(server.URL).
+				EnsureBuildx(t.Context(), strings.TrimPrefix(testBuildxVersion, "v"), tt.arch, tt.pinned)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("EnsureBuildx() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if installed != tt.wantInstalled {
+				t.Errorf("EnsureBuildx() installed = %v, want %v", installed, tt.wantInstalled)
+			}
+
+			uploaded := conn.uploaded["/tmp/quark-"+testBuildxAsset]
+			if tt.wantInstalled && string(uploaded) != string(binary) {
+				t.Errorf("uploaded buildx = %q, want %q", uploaded, binary)
+			}
+
+			if !tt.wantInstalled && len(conn.uploaded) > 0 {
+				t.Errorf("EnsureBuildx() uploaded %d files, want none", len(conn.uploaded))
+			}
+		})
+	}
+}
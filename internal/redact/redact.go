@@ -0,0 +1,140 @@
+// Package redact masks registered secret values (passwords, tokens) in log output and error messages.
+package redact
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Mask replaces secret values.
+const Mask = "<redacted>"
+
+// MinLength is the length under which values are not registered: masking them would garble unrelated text.
+const MinLength = 4
+
+// Secrets is a set of secret values to mask. The zero value is an empty set, safe for concurrent use.
+type Secrets struct {
+	mutex    sync.RWMutex
+	values   map[string]struct{}
+	replacer *strings.Replacer
+}
+
+// Add registers values, as is and JSON-escaped (as they appear in zerolog events). Values shorter than
+// MinLength are ignored.
+func (secrets *Secrets) Add(values ...string) {
+	secrets.mutex.Lock()
+	defer secrets.mutex.Unlock()
+
+	if secrets.values == nil {
+		secrets.values = map[string]struct{}{}
+	}
+
+	added := false
+
+	for _, value := range values {
+		if len(value) < MinLength {
+			continue
+		}
+
+		for _, form := range []string{value, jsonEscaped(value)} {
+			if _, known := secrets.values[form]; !known {
+				secrets.values[form] = struct{}{}
+				added = true
+			}
+		}
+	}
+
+	if !added {
+		return
+	}
+
+	// Longest first: a secret containing another one is masked whole
+	sorted := slices.SortedFunc(maps.Keys(secrets.values), func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(b), len(a)), strings.Compare(a, b))
+	})
+
+	pairs := make([]string, 0, 2*len(sorted))
+	for _, value := range sorted {
+		pairs = append(pairs, value, Mask)
+	}
+
+	secrets.replacer = strings.NewReplacer(pairs...)
+}
+
+// Redact returns text with the registered values masked.
+func (secrets *Secrets) Redact(text string) string {
+	secrets.mutex.RLock()
+	replacer := secrets.replacer
+	secrets.mutex.RUnlock()
+
+	if replacer == nil {
+		return text
+	}
+
+	return replacer.Replace(text)
+}
+
+// Writer returns a writer masking the registered values in what it writes to out. zerolog writes each event in
+// a single call, so values are never split across writes.
+func (secrets *Secrets) Writer(out io.Writer) io.Writer {
+	return &writer{secrets: secrets, out: out}
+}
+
+// Error returns err with the registered values masked from its message. The original error stays in the chain,
+// for errors.Is and errors.As. Errors without registered values (and nil) are returned as they are.
+func (secrets *Secrets) Error(err error) error {
+	if err == nil || secrets.Redact(err.Error()) == err.Error() {
+		return err
+	}
+
+	return &redactedError{secrets: secrets, err: err}
+}
+
+type writer struct {
+	secrets *Secrets
+	out     io.Writer
+}
+
+// Write writes p to the output with the registered values masked.
+func (writer *writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(writer.out, writer.secrets.Redact(string(p))); err != nil {
+		return 0, err //nolint:wrapcheck // Errors of the wrapped writer are passed through
+	}
+
+	return len(p), nil
+}
+
+type redactedError struct {
+	secrets *Secrets
+	err     error
+}
+
+// Error returns the message of the error with the registered values masked (including values registered
+// later).
+func (err *redactedError) Error() string {
+	return err.secrets.Redact(err.err.Error())
+}
+
+// Unwrap returns the original error.
+func (err *redactedError) Unwrap() error {
+	return err.err
+}
+
+// jsonEscaped returns value escaped as in a JSON string, without quotes.
+func jsonEscaped(value string) string {
+	var escaped bytes.Buffer
+
+	encoder := json.NewEncoder(&escaped)
+	encoder.SetEscapeHTML(false)
+
+	// Encoding a string cannot fail
+	_ = encoder.Encode(value)
+
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(escaped.String()), `"`), `"`)
+}
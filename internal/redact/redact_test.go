@@ -0,0 +1,61 @@
+package redact_test
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/redact"
+)
+
+// INTENTION: Registered values are masked in text, in zerolog output (including JSON-escaped values) and in
+// error messages, keeping the error chain; short values are not registered.
+func TestSecrets(t *testing.T) {
+	t.Parallel()
+
+	var secrets redact.Secrets
+
+	if got := secrets.Redact("nothing registered"); got != "nothing registered" {
+		t.Errorf("Redact() = %q, want the text unchanged", got)
+	}
+
+	secrets.Add("s3cr3t-token", `pa"ss\word`, "abc", "s3cr3t-token-long")
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"token s3cr3t-token used", "token " + redact.Mask + " used"},
+		{"token s3cr3t-token-long used", "token " + redact.Mask + " used"},
+		{`password pa"ss\word`, "password " + redact.Mask},
+		{"short abc kept", "short abc kept"},
+	}
+
+	for _, tt := range tests {
+		if got := secrets.Redact(tt.text); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	var out strings.Builder
+
+	logger := zerolog.New(secrets.Writer(&out))
+	logger.Info().Str("password", `pa"ss\word`).Err(errors.New("denied for s3cr3t-token")).Msg("login")
+
+	if strings.Contains(out.String(), "s3cr3t") || strings.Contains(out.String(), `ss\\word`) ||
+		strings.Count(out.String(), redact.Mask) != 2 {
+		t.Errorf("log output = %s, want both secrets masked", out.String())
+	}
+
+	err := secrets.Error(&fs.PathError{Op: "open", Path: "/s3cr3t-token", Err: fs.ErrNotExist})
+	if err.Error() != "open /"+redact.Mask+": file does not exist" || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Error() = %q, want the secret masked and the error chain kept", err.Error())
+	}
+
+	if plain := fs.ErrNotExist; secrets.Error(plain) != plain || secrets.Error(nil) != nil {
+		t.Error("Error() wrapped an error without secrets")
+	}
+}
@@ -7,4 +7,6 @@ var (
 	ErrInvalidImageReference = errors.New("invalid image reference")
 	// ErrInvalidPattern indicates that the pattern used to parse the image reference is invalid.
 	ErrInvalidPattern = errors.New("invalid pattern")
+	// ErrMissingLocation indicates that a protocol reference has no archive path or image reference.
+	ErrMissingLocation = errors.New("missing archive path or image reference after protocol")
 )
@@ -0,0 +1,171 @@
+package reference
+
+import (
+	"errors"
+	"path"
+	"strings"
+)
+
+// Supported protocols. References without a protocol point to images in a registry.
+const (
+	// ProtocolDockerArchive references an image saved with `docker save`:
+	// "docker-archive:path[:reference]" (the reference selects an image in multi-image archives).
+	ProtocolDockerArchive Protocol = "docker-archive"
+	// ProtocolOCIArchive references an image in a tarball of an OCI image layout:
+	// "oci-archive:path[:name]" (the name matches the org.opencontainers.image.ref.name annotation).
+	ProtocolOCIArchive Protocol = "oci-archive"
+	// ProtocolContainerd references an image in the local containerd image store: "containerd://reference".
+	ProtocolContainerd Protocol = "containerd"
+)
+
+// protocolPrefixes maps each protocol to the prefix introducing it in a raw reference.
+//
+//nolint:gochecknoglobals // Lookup table
+var protocolPrefixes = []struct {
+	protocol Protocol
+	prefix   string
+}{
+	{ProtocolDockerArchive, "docker-archive:"},
+	{ProtocolOCIArchive, "oci-archive:"},
+	{ProtocolContainerd, "containerd://"},
+}
+
+// HasProtocol reports whether rawRef starts with a supported protocol prefix.
+func HasProtocol(rawRef string) bool {
+	for _, known := range protocolPrefixes {
+		if strings.HasPrefix(rawRef, known.prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsArchive reports whether the protocol references an image stored in a local file.
+func (protocol Protocol) IsArchive() bool {
+	return protocol == ProtocolDockerArchive || protocol == ProtocolOCIArchive
+}
+
+// prefix returns the prefix introducing the protocol in a raw reference.
+func (protocol Protocol) prefix() string {
+	for _, known := range protocolPrefixes {
+		if known.protocol == protocol {
+			return known.prefix
+		}
+	}
+
+	return ""
+}
+
+// parseProtocol parses a protocol-prefixed reference.
+// Returns false if rawRef has no known protocol prefix.
+func parseProtocol(rawRef string) (*ImageReference, bool, error) {
+	for _, known := range protocolPrefixes {
+		rest, found := strings.CutPrefix(rawRef, known.prefix)
+		if !found {
+			continue
+		}
+
+		imageRef, err := parseWithProtocol(known.protocol, rest)
+
+		return imageRef, true, err
+	}
+
+	return nil, false, nil
+}
+
+// parseWithProtocol parses the part of a reference following the protocol prefix.
+func parseWithProtocol(protocol Protocol, rest string) (*ImageReference, error) {
+	if rest == "" {
+		return &ImageReference{}, errors.Join(ErrInvalidImageReference, ErrMissingLocation)
+	}
+
+	if protocol == ProtocolContainerd {
+		imageRef, err := parseNamed(rest)
+		if err != nil {
+			return imageRef, err
+		}
+
+		imageRef.Protocol = protocol
+
+		return imageRef, nil
+	}
+
+	// Like skopeo, the archive path ends at the first colon
+	archive, name, hasName := strings.Cut(rest, ":")
+	if archive == "" || (hasName && name == "") {
+		return &ImageReference{}, errors.Join(ErrInvalidImageReference, ErrMissingLocation)
+	}
+
+	imageRef := &ImageReference{}
+
+	if hasName {
+		if protocol == ProtocolDockerArchive {
+			var err error
+
+			imageRef, err = parseNamed(name)
+			if err != nil {
+				return imageRef, err
+			}
+		} else {
+			// OCI layouts name images with a free-form annotation, usually a tag
+			imageRef.Tag = name
+			imageRef.ExplicitTag = name
+		}
+	}
+
+	imageRef.Protocol = protocol
+	imageRef.Archive = archive
+
+	return imageRef, nil
+}
+
+// parseNamed parses a named registry reference nested in a protocol reference.
+func parseNamed(rawRef string) (*ImageReference, error) {
+	if HasProtocol(rawRef) {
+		return &ImageReference{}, ErrInvalidImageReference
+	}
+
+	imageRef, err := Parse(rawRef)
+	if err != nil {
+		return imageRef, err
+	}
+
+	if imageRef.nn == nil {
+		// Digest-only references do not name an image
+		return imageRef, ErrInvalidImageReference
+	}
+
+	return imageRef, nil
+}
+
+// protocolString returns the string representation of a protocol reference.
+func (ir *ImageReference) protocolString() string {
+	ret := ir.Protocol.prefix()
+
+	switch ir.Protocol {
+	case ProtocolContainerd:
+		if ir.nn != nil {
+			ret += ir.nn.String()
+		}
+	case ProtocolOCIArchive:
+		ret += ir.Archive
+		if ir.ExplicitTag != "" {
+			ret += ":" + ir.ExplicitTag
+		}
+	default:
+		ret += ir.Archive
+		if ir.nn != nil {
+			ret += ":" + ir.nn.String()
+		}
+	}
+
+	return ret
+}
+
+// archiveName returns the base name of the archive, without extension.
+func (ir *ImageReference) archiveName() string {
+	name := path.Base(ir.Archive)
+
+	return strings.TrimSuffix(name, path.Ext(name))
+}
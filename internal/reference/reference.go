@@ -11,11 +11,12 @@ import (
 // Protocol represents the protocol used for the image reference.
 type Protocol string
 
-const shortIDLength = 5
-
 // ImageReference represents a reference to an image, which may include a protocol, domain, path, tag, and digest.
+// For archive protocols, Archive is the path of the archive, and the other fields describe the optional
+// image name selecting an image inside it.
 type ImageReference struct {
 	Protocol    Protocol
+	Archive     string
 	Digest      digest.Digest
 	Tag         string
 	ExplicitTag string
@@ -39,8 +40,8 @@ func (ir *ImageReference) Name() string {
 
 // FamiliarName returns a familiar (eg: shortened) name for the image reference.
 func (ir *ImageReference) FamiliarName() string {
-	if ir.Protocol != "" && ir.Domain == "" {
-		return ir.Path
+	if ir.Protocol.IsArchive() && ir.nn == nil {
+		return ir.archiveName()
 	}
 
 	if ir.nn != nil {
@@ -68,8 +69,8 @@ func (ir *ImageReference) FamiliarMatch(pattern string) (bool, error) {
 
 // String returns the string representation of the image reference.
 func (ir *ImageReference) String() string {
-	if ir.Protocol != "" && ir.Domain == "" {
-		return ir.Path
+	if ir.Protocol != "" {
+		return ir.protocolString()
 	}
 
 	if ir.Path == "" && ir.Digest != "" {
@@ -86,8 +87,8 @@ func (ir *ImageReference) String() string {
 // SuggestContainerName generates a suggested container name based on the image reference.
 func (ir *ImageReference) SuggestContainerName(suffix string) string {
 	name := "untitled"
-	if ir.Protocol != "" && ir.Domain == "" {
-		name = string(ir.Protocol) + "-" + ir.String()[:shortIDLength]
+	if ir.Protocol.IsArchive() && ir.nn == nil {
+		name = ir.archiveName()
 	} else if ir.Path != "" {
 		name = path.Base(ir.Path)
 	}
@@ -96,7 +97,12 @@ func (ir *ImageReference) SuggestContainerName(suffix string) string {
 }
 
 // Parse parses a raw image reference string and returns an ImageReference object.
+// Scheme-prefixed references (docker-archive:, oci-archive:, containerd://) set Protocol.
 func Parse(rawRef string) (*ImageReference, error) {
+	if imageRef, ok, err := parseProtocol(rawRef); ok {
+		return imageRef, err
+	}
+
 	imageRef := &ImageReference{}
 
 	if dgst, err := digest.Parse(rawRef); err == nil {
@@ -23,6 +23,7 @@ func TestReference(t *testing.T) {
 		FamiliarName  string
 		FamiliarMatch map[string]bool
 		Protocol      reference.Protocol
+		Archive       string
 		Digest        digest.Digest
 		Path          string
 		Domain        string
@@ -212,6 +213,62 @@ func TestReference(t *testing.T) {
 			Tag:          "latest",
 			ExplicitTag:  "",
 		},
+		"docker-archive:/tmp/app.tar": {
+			Error:        nil,
+			String:       "docker-archive:/tmp/app.tar",
+			Suggested:    "app-abcde",
+			FamiliarName: "app",
+			Protocol:     reference.ProtocolDockerArchive,
+			Archive:      "/tmp/app.tar",
+		},
+		"docker-archive:/tmp/app.tar:ghcr.io/org/app:1.0": {
+			Error:        nil,
+			String:       "docker-archive:/tmp/app.tar:ghcr.io/org/app:1.0",
+			Suggested:    "app-abcde",
+			FamiliarName: "ghcr.io/org/app",
+			Protocol:     reference.ProtocolDockerArchive,
+			Archive:      "/tmp/app.tar",
+			Path:         "org/app",
+			Domain:       "ghcr.io",
+			Tag:          "1.0",
+			ExplicitTag:  "1.0",
+		},
+		"oci-archive:build/app.oci.tar:1.0": {
+			Error:        nil,
+			String:       "oci-archive:build/app.oci.tar:1.0",
+			Suggested:    "app.oci-abcde",
+			FamiliarName: "app.oci",
+			Protocol:     reference.ProtocolOCIArchive,
+			Archive:      "build/app.oci.tar",
+			Tag:          "1.0",
+			ExplicitTag:  "1.0",
+		},
+		"containerd://alpine:3.19": {
+			Error:        nil,
+			String:       "containerd://docker.io/library/alpine:3.19",
+			Suggested:    "alpine-abcde",
+			FamiliarName: "alpine",
+			Protocol:     reference.ProtocolContainerd,
+			Path:         "library/alpine",
+			Domain:       "docker.io",
+			Tag:          "3.19",
+			ExplicitTag:  "3.19",
+		},
+		"docker-archive:": {
+			Error: reference.ErrMissingLocation,
+		},
+		"oci-archive:/tmp/app.tar:": {
+			Error: reference.ErrMissingLocation,
+		},
+		"containerd://": {
+			Error: reference.ErrMissingLocation,
+		},
+		"containerd://docker-archive:/tmp/app.tar": {
+			Error: reference.ErrInvalidImageReference,
+		},
+		"docker-archive:/tmp/app.tar:sha256:4b826db5f1f14d1db0b560304f189d4b17798ddce2278b7822c9d32313fe3f50": {
+			Error: reference.ErrInvalidImageReference,
+		},
 	}
 
 	for index, test := range needles {
@@ -234,10 +291,18 @@ func TestReference(t *testing.T) {
 		}
 
 		assert.Equal(t, parsed.Protocol, test.Protocol, index)
+		assert.Equal(t, parsed.Archive, test.Archive, index)
 		assert.Equal(t, parsed.Digest, test.Digest, index)
 		assert.Equal(t, parsed.Path, test.Path, index)
 		assert.Equal(t, parsed.Domain, test.Domain, index)
 		assert.Equal(t, parsed.Tag, test.Tag, index)
 		assert.Equal(t, parsed.ExplicitTag, test.ExplicitTag, index)
+
+		// Protocol references round-trip
+		if test.Protocol != "" {
+			reparsed, err := reference.Parse(parsed.String())
+			assert.NilError(t, err, index)
+			assert.Equal(t, reparsed.String(), parsed.String(), index)
+		}
 	}
 }
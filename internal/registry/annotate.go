@@ -0,0 +1,63 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// ErrAnnotationsUnsupported indicates annotations on a Docker (schema 2) manifest or manifest list, which have no
+// annotations field.
+var ErrAnnotationsUnsupported = errors.New("docker manifests do not support annotations")
+
+// Annotate adds annotations to the OCI image manifest or index imageRef (a tag) points to, and pushes the annotated
+// manifest under the same tag. Platform manifests, configs and layers are unchanged: only the digest of the
+// top-level manifest changes. Manifests already carrying the annotations are not pushed again.
+// Returns the digest the tag points to.
+func (client *Client) Annotate(ctx context.Context, imageRef string, annotations map[string]string) (string, error) {
+	desc, err := client.GetImage(ctx, imageRef)
+	if err != nil {
+		return "", err
+	}
+
+	if desc.MediaType != types.OCIImageIndex && desc.MediaType != types.OCIManifestSchema1 {
+		return "", fmt.Errorf("%w: %s is %s", ErrAnnotationsUnsupported, imageRef, desc.MediaType)
+	}
+
+	current, err := client.GetAnnotations(ctx, imageRef)
+	if err != nil {
+		return "", err
+	}
+
+	merged := maps.Clone(current)
+	maps.Copy(merged, annotations)
+
+	if maps.Equal(merged, current) {
+		return desc.Digest.String(), nil
+	}
+
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrGetImageIndex, err)
+		}
+
+		annotated, _ := mutate.Annotations(idx, annotations).(v1.ImageIndex)
+
+		return client.PushIndex(ctx, imageRef, annotated)
+	}
+
+	img, err := desc.Image()
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrGetImage, err)
+	}
+
+	annotated, _ := mutate.Annotations(img, annotations).(v1.Image)
+
+	return client.PushImage(ctx, imageRef, annotated)
+}
@@ -0,0 +1,85 @@
+package registry_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// INTENTION: Annotating an OCI index moves the tag to an annotated index with the same platform manifests,
+// once; Docker manifests, which have no annotations, are refused.
+func TestClient_Annotate(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatalf("Failed to create image: %v", err)
+	}
+
+	oci := mutate.MediaType(mutate.ConfigMediaType(img, types.OCIConfigJSON), types.OCIManifestSchema1)
+	idx := mutate.IndexMediaType(mutate.AppendManifests(empty.Index, mutate.IndexAddendum{
+		Add:        oci,
+		Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}},
+	}), types.OCIImageIndex)
+
+	ref := host + "/test/app:1.0.0"
+
+	original, err := client.PushIndex(t.Context(), ref, idx)
+	if err != nil {
+		t.Fatalf("PushIndex() error = %v", err)
+	}
+
+	annotations := map[string]string{"org.opencontainers.image.description": "The app"}
+
+	annotated, err := client.Annotate(t.Context(), ref, annotations)
+	if err != nil {
+		t.Fatalf("Annotate() error = %v", err)
+	}
+
+	if annotated == original {
+		t.Error("Annotate() kept the index digest, want the annotated index")
+	}
+
+	got, err := client.GetAnnotations(t.Context(), ref)
+	if err != nil || got["org.opencontainers.image.description"] != "The app" {
+		t.Errorf("GetAnnotations() = %v, %v, want the description", got, err)
+	}
+
+	platforms, err := client.GetPlatformDigests(t.Context(), ref)
+	if err != nil || len(platforms) != 1 {
+		t.Errorf("GetPlatformDigests() = %v, %v, want the original platform manifest", platforms, err)
+	}
+
+	if again, err := client.Annotate(t.Context(), ref, annotations); err != nil || again != annotated {
+		t.Errorf("Annotate() again = %q, %v, want unchanged %q", again, err, annotated)
+	}
+
+	if _, err := client.PushImage(t.Context(), host+"/test/docker:1.0.0", img); err != nil {
+		t.Fatalf("PushImage() error = %v", err)
+	}
+
+	if _, err := client.Annotate(t.Context(), host+"/test/docker:1.0.0", annotations); !errors.Is(
+		err, registry.ErrAnnotationsUnsupported,
+	) {
+		t.Errorf("Annotate(docker manifest) error = %v, want %v", err, registry.ErrAnnotationsUnsupported)
+	}
+}
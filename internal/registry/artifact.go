@@ -0,0 +1,339 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+const (
+	// EmptyConfigMediaType is the OCI 1.1 media type of the empty artifact config.
+	EmptyConfigMediaType = "application/vnd.oci.empty.v1+json"
+
+	// TitleAnnotation carries the file name of an artifact blob (as used by ORAS).
+	TitleAnnotation = "org.opencontainers.image.title"
+)
+
+var (
+	// ErrArtifactTypeRequired indicates an artifact push without an artifact type.
+	ErrArtifactTypeRequired = errors.New("artifact type is required")
+	// ErrArtifactBlobsRequired indicates an artifact push without blobs.
+	ErrArtifactBlobsRequired = errors.New("artifact requires at least one blob")
+	// ErrNotAnArtifact indicates the pulled manifest is not an OCI image manifest.
+	ErrNotAnArtifact = errors.New("manifest is not an OCI artifact manifest")
+)
+
+// ArtifactBlob is a single blob (layer) of an OCI artifact.
+// Pushed blobs take their content from Open when set (streamed, for large files), from Data otherwise.
+// Pulled blobs have their content in Data.
+type ArtifactBlob struct {
+	MediaType string // Blob media type (e.g., "application/spdx+json")
+	Title     string // File name, recorded as org.opencontainers.image.title (optional)
+	Data      []byte
+	Open      BlobOpener
+}
+
+// Artifact is a non-image OCI artifact (SBOM, policy bundle, report, ...).
+type Artifact struct {
+	Digest       string
+	ArtifactType string
+	Annotations  map[string]string
+	Blobs        []ArtifactBlob
+}
+
+// artifactManifest is an OCI image manifest carrying the OCI 1.1 artifactType field
+// (v1.Manifest does not expose it).
+type artifactManifest struct {
+	SchemaVersion int64             `json:"schemaVersion"`
+	MediaType     types.MediaType   `json:"mediaType,omitempty"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        v1.Descriptor     `json:"config"`
+	Layers        []v1.Descriptor   `json:"layers"`
+	Subject       *v1.Descriptor    `json:"subject,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// rawManifest is a pre-serialized manifest pushed with remote.Put.
+type rawManifest struct {
+	body      []byte
+	mediaType types.MediaType
+}
+
+// RawManifest implements remote.Taggable.
+func (manifest *rawManifest) RawManifest() ([]byte, error) {
+	return manifest.body, nil
+}
+
+// MediaType implements the media type lookup used by remote.Put.
+func (manifest *rawManifest) MediaType() (types.MediaType, error) {
+	return manifest.mediaType, nil
+}
+
+// PushArtifact publishes a non-image artifact to an OCI registry.
+// The manifest follows the OCI 1.1 artifact layout (as produced by ORAS): an OCI image manifest with
+// artifactType set, the empty config descriptor, and one layer per blob.
+// Returns the manifest digest.
+func (client *Client) PushArtifact(
+	ctx context.Context,
+	artifactRef, artifactType string,
+	blobs []ArtifactBlob,
+	annotations map[string]string,
+) (string, error) {
+	ref, err := name.ParseReference(artifactRef)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrParseDestinationReference, err)
+	}
+
+	return client.pushArtifact(ctx, ref, artifactType, blobs, annotations, nil)
+}
+
+// AttachArtifact publishes an artifact referring to subjectRef (OCI referrer, e.g., an attestation of an image)
+// in its repository, and returns the artifact digest. The artifact is found from the subject with the referrers
+// API, or the referrers tag on registries without it.
+func (client *Client) AttachArtifact(
+	ctx context.Context,
+	subjectRef, artifactType string,
+	blobs []ArtifactBlob,
+	annotations map[string]string,
+) (string, error) {
+	ref, err := name.ParseReference(subjectRef)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrParseDestinationReference, err)
+	}
+
+	subject, err := client.GetManifest(ctx, subjectRef)
+	if err != nil {
+		return "", fmt.Errorf("failed to get subject: %w", err)
+	}
+
+	return client.pushArtifact(ctx, ref, artifactType, blobs, annotations, &v1.Descriptor{
+		MediaType: subject.MediaType,
+		Digest:    subject.Digest,
+		Size:      int64(len(subject.Raw)),
+	})
+}
+
+// pushArtifact pushes an artifact to ref, or by digest to the repository of ref when it refers to subject.
+func (client *Client) pushArtifact(
+	ctx context.Context,
+	ref name.Reference,
+	artifactType string,
+	blobs []ArtifactBlob,
+	annotations map[string]string,
+	subject *v1.Descriptor,
+) (string, error) {
+	if artifactType == "" {
+		return "", ErrArtifactTypeRequired
+	}
+
+	if len(blobs) == 0 {
+		return "", ErrArtifactBlobsRequired
+	}
+
+	config := static.NewLayer([]byte("{}"), EmptyConfigMediaType)
+	if subject != nil {
+		// Registries without referrers API list referrers by config media type (referrers tag schema)
+		config = static.NewLayer([]byte("{}"), types.MediaType(artifactType))
+	}
+
+	// The blobs and the manifest are pushed with the authentication method accepted for the config
+	var opts []remote.Option
+
+	configDesc, err := authenticated(ctx, client, func(attempt []remote.Option) (v1.Descriptor, error) {
+		opts = attempt
+
+		return client.writeArtifactBlob(ref.Context(), config, nil, attempt)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	layers := make([]v1.Descriptor, 0, len(blobs))
+
+	for _, blob := range blobs {
+		var blobAnnotations map[string]string
+		if blob.Title != "" {
+			blobAnnotations = map[string]string{TitleAnnotation: blob.Title}
+		}
+
+		layer, err := artifactLayer(blob)
+		if err != nil {
+			return "", err
+		}
+
+		desc, err := client.writeArtifactBlob(ref.Context(), layer, blobAnnotations, opts)
+		if err != nil {
+			return "", err
+		}
+
+		layers = append(layers, desc)
+	}
+
+	manifest := artifactManifest{
+		SchemaVersion: 2, //nolint:mnd // OCI manifest schema version
+		MediaType:     types.OCIManifestSchema1,
+		ArtifactType:  artifactType,
+		Config:        configDesc,
+		Layers:        layers,
+		Subject:       subject,
+		Annotations:   annotations,
+	}
+
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode artifact manifest: %w", err)
+	}
+
+	digest, _, err := v1.SHA256(bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to compute artifact digest: %w", err)
+	}
+
+	if subject != nil {
+		ref = ref.Context().Digest(digest.String())
+	}
+
+	client.log.Debug().
+		Str("ref", ref.String()).
+		Str("artifact_type", artifactType).
+		Int("blobs", len(blobs)).
+		Str("digest", digest.String()).
+		Msg("pushing artifact manifest")
+
+	invalidateCache(ctx, ref)
+
+	if err := remote.Put(ref, &rawManifest{body: body, mediaType: types.OCIManifestSchema1}, opts...); err != nil {
+		return "", fmt.Errorf("failed to push artifact manifest: %w", Classify(err))
+	}
+
+	return digest.String(), nil
+}
+
+// PullArtifact fetches an OCI artifact and all its blobs.
+// Artifacts pushed with the older ORAS layout (artifact type carried as config media type) are supported.
+func (client *Client) PullArtifact(ctx context.Context, artifactRef string) (*Artifact, error) {
+	ref, err := name.ParseReference(artifactRef)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParseSourceReference, err)
+	}
+
+	// The blobs are pulled with the authentication method accepted for the manifest
+	var opts []remote.Option
+
+	desc, err := authenticated(ctx, client, func(attempt []remote.Option) (*remote.Descriptor, error) {
+		opts = attempt
+
+		return remote.Get(ref, attempt...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrGetImage, Classify(err))
+	}
+
+	if desc.MediaType != types.OCIManifestSchema1 {
+		return nil, fmt.Errorf("%w: %s", ErrNotAnArtifact, desc.MediaType)
+	}
+
+	var manifest artifactManifest
+	if err := json.Unmarshal(desc.Manifest, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode artifact manifest: %w", err)
+	}
+
+	artifact := &Artifact{
+		Digest:       desc.Digest.String(),
+		ArtifactType: manifest.ArtifactType,
+		Annotations:  manifest.Annotations,
+		Blobs:        make([]ArtifactBlob, 0, len(manifest.Layers)),
+	}
+
+	if artifact.ArtifactType == "" {
+		artifact.ArtifactType = string(manifest.Config.MediaType)
+	}
+
+	for _, layerDesc := range manifest.Layers {
+		data, err := client.readArtifactBlob(ref.Context().Digest(layerDesc.Digest.String()), layerDesc.Size, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		artifact.Blobs = append(artifact.Blobs, ArtifactBlob{
+			MediaType: string(layerDesc.MediaType),
+			Title:     layerDesc.Annotations[TitleAnnotation],
+			Data:      data,
+		})
+	}
+
+	return artifact, nil
+}
+
+// artifactLayer returns the layer of a pushed blob, streamed from its opener when it has one.
+func artifactLayer(blob ArtifactBlob) (v1.Layer, error) {
+	if blob.Open != nil {
+		return StreamedLayer(blob.Open, types.MediaType(blob.MediaType))
+	}
+
+	return static.NewLayer(blob.Data, types.MediaType(blob.MediaType)), nil
+}
+
+// writeArtifactBlob uploads a blob and returns its descriptor.
+func (*Client) writeArtifactBlob(
+	repo name.Repository,
+	layer v1.Layer,
+	annotations map[string]string,
+	opts []remote.Option,
+) (v1.Descriptor, error) {
+	digest, err := layer.Digest()
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("failed to compute blob digest: %w", err)
+	}
+
+	size, err := layer.Size()
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("failed to compute blob size: %w", err)
+	}
+
+	mediaType, err := layer.MediaType()
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("failed to get blob media type: %w", err)
+	}
+
+	if err := remote.WriteLayer(repo, layer, opts...); err != nil {
+		return v1.Descriptor{}, fmt.Errorf("failed to upload blob %s: %w", digest, Classify(err))
+	}
+
+	return v1.Descriptor{
+		MediaType:   mediaType,
+		Size:        size,
+		Digest:      digest,
+		Annotations: annotations,
+	}, nil
+}
+
+// readArtifactBlob downloads a blob of declared size into memory, within the memory limit of the client
+// (the registry client verifies its digest while reading).
+func (client *Client) readArtifactBlob(ref name.Digest, size int64, opts []remote.Option) ([]byte, error) {
+	layer, err := remote.Layer(ref, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob %s: %w", ref.DigestStr(), Classify(err))
+	}
+
+	reader, err := layer.Compressed()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", ref.DigestStr(), Classify(err))
+	}
+	defer reader.Close()
+
+	data, err := client.readLimited(reader, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", ref.DigestStr(), Classify(err))
+	}
+
+	return data, nil
+}
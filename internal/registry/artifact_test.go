@@ -0,0 +1,174 @@
+package registry_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// INTENTION: Artifacts round-trip through a registry with their type, annotations, blob media types and titles.
+func TestClient_PushPullArtifact(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+	ref := host + "/test/app:sbom"
+
+	blobs := []registry.ArtifactBlob{
+		{MediaType: "application/spdx+json", Title: "sbom.spdx.json", Data: []byte(`{"spdxVersion":"SPDX-2.3"}`)},
+		{MediaType: "text/plain", Data: []byte("notes")},
+	}
+
+	digest, err := client.PushArtifact(
+		t.Context(), ref, "application/vnd.example.sbom", blobs, map[string]string{"org.example.build": "42"},
+	)
+	if err != nil {
+		t.Fatalf("PushArtifact() failed: %v", err)
+	}
+
+	artifact, err := client.PullArtifact(t.Context(), ref)
+	if err != nil {
+		t.Fatalf("PullArtifact() failed: %v", err)
+	}
+
+	if artifact.Digest != digest {
+		t.Errorf("Digest = %q, want pushed digest %q", artifact.Digest, digest)
+	}
+
+	if artifact.ArtifactType != "application/vnd.example.sbom" {
+		t.Errorf("ArtifactType = %q, want application/vnd.example.sbom", artifact.ArtifactType)
+	}
+
+	if artifact.Annotations["org.example.build"] != "42" {
+		t.Errorf("Annotations = %v, want org.example.build=42", artifact.Annotations)
+	}
+
+	if len(artifact.Blobs) != len(blobs) {
+		t.Fatalf("got %d blobs, want %d", len(artifact.Blobs), len(blobs))
+	}
+
+	for i, blob := range artifact.Blobs {
+		if blob.MediaType != blobs[i].MediaType || blob.Title != blobs[i].Title || string(blob.Data) != string(blobs[i].Data) {
+			t.Errorf("blob %d = %+v, want %+v", i, blob, blobs[i])
+		}
+	}
+}
+
+// INTENTION: Pushing requires an artifact type and at least one blob; pulling rejects non-artifacts.
+func TestClient_ArtifactErrors(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+	blobs := []registry.ArtifactBlob{{MediaType: "text/plain", Data: []byte("x")}}
+
+	if _, err := client.PushArtifact(t.Context(), host+"/test/a:1", "", blobs, nil); !errors.Is(
+		err, registry.ErrArtifactTypeRequired,
+	) {
+		t.Errorf("PushArtifact() without type error = %v, want %v", err, registry.ErrArtifactTypeRequired)
+	}
+
+	if _, err := client.PushArtifact(t.Context(), host+"/test/a:1", "application/x", nil, nil); !errors.Is(
+		err, registry.ErrArtifactBlobsRequired,
+	) {
+		t.Errorf("PushArtifact() without blobs error = %v, want %v", err, registry.ErrArtifactBlobsRequired)
+	}
+
+	// Images pushed by docker use the Docker manifest media type
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+
+	if _, err := client.PushImage(t.Context(), host+"/test/image:1", img); err != nil {
+		t.Fatalf("PushImage() failed: %v", err)
+	}
+
+	if _, err := client.PullArtifact(t.Context(), host+"/test/image:1"); !errors.Is(err, registry.ErrNotAnArtifact) {
+		t.Errorf("PullArtifact() on image error = %v, want %v", err, registry.ErrNotAnArtifact)
+	}
+}
+
+// INTENTION: Attached artifacts are found from their subject by artifact type, with or without the referrers API
+// (referrers tag schema), and keep their blobs.
+func TestClient_AttachArtifact(t *testing.T) {
+	t.Parallel()
+
+	for _, referrersAPI := range []bool{true, false} {
+		options := []ggcrregistry.Option{ggcrregistry.Logger(log.New(io.Discard, "", 0))}
+		if referrersAPI {
+			options = append(options, ggcrregistry.WithReferrersSupport(true))
+		}
+
+		server := httptest.NewServer(ggcrregistry.New(options...))
+		t.Cleanup(server.Close)
+
+		host := strings.TrimPrefix(server.URL, "http://")
+		client := registry.NewClient(host, "", "", zerolog.Nop())
+
+		img, err := random.Image(1024, 1)
+		if err != nil {
+			t.Fatalf("failed to create random image: %v", err)
+		}
+
+		subjectDigest, err := client.PushImage(t.Context(), host+"/test/app:1", img)
+		if err != nil {
+			t.Fatalf("PushImage() failed: %v", err)
+		}
+
+		subjectRef := host + "/test/app@" + subjectDigest
+		blobs := []registry.ArtifactBlob{
+			{MediaType: "application/vnd.in-toto+json", Title: "provenance.intoto.json", Data: []byte(`{}`)},
+		}
+
+		digest, err := client.AttachArtifact(t.Context(), subjectRef, "application/vnd.in-toto+json", blobs, nil)
+		if err != nil {
+			t.Fatalf("AttachArtifact() failed: %v (referrers API: %v)", err, referrersAPI)
+		}
+
+		subject, err := name.NewDigest(subjectRef)
+		if err != nil {
+			t.Fatalf("Failed to parse subject reference: %v", err)
+		}
+
+		referrers, err := remote.Referrers(subject, remote.WithFilter("artifactType", "application/vnd.in-toto+json"))
+		if err != nil {
+			t.Fatalf("Referrers() error = %v", err)
+		}
+
+		manifest, err := referrers.IndexManifest()
+		if err != nil {
+			t.Fatalf("IndexManifest() error = %v", err)
+		}
+
+		if len(manifest.Manifests) != 1 || manifest.Manifests[0].Digest.String() != digest {
+			t.Errorf("referrers = %v, want [%s] (referrers API: %v)", manifest.Manifests, digest, referrersAPI)
+		}
+
+		artifact, err := client.PullArtifact(t.Context(), host+"/test/app@"+digest)
+		if err != nil {
+			t.Fatalf("PullArtifact() failed: %v", err)
+		}
+
+		if len(artifact.Blobs) != 1 || artifact.Blobs[0].Title != "provenance.intoto.json" {
+			t.Errorf("Blobs = %+v, want the attached blob", artifact.Blobs)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// AuthKind is a way of authenticating with a registry.
+type AuthKind string
+
+const (
+	// AuthAnonymous sends no credentials (public repositories, anonymous pulls).
+	AuthAnonymous AuthKind = "anonymous"
+	// AuthBasic authenticates with a username and a password (or personal access token).
+	AuthBasic AuthKind = "basic"
+	// AuthToken sends a registry bearer token as is.
+	AuthToken AuthKind = "token"
+)
+
+// AuthMethod is one authentication strategy of a registry.
+type AuthMethod struct {
+	Kind     AuthKind
+	Username string // AuthBasic only
+	Password string // AuthBasic only
+	Token    string // AuthToken only
+}
+
+// authenticator returns the go-containerregistry authenticator of the method.
+func (method AuthMethod) authenticator() authn.Authenticator {
+	switch method.Kind {
+	case AuthBasic:
+		return &authn.Basic{Username: method.Username, Password: method.Password}
+	case AuthToken:
+		return &authn.Bearer{Token: method.Token}
+	default:
+		return authn.Anonymous
+	}
+}
+
+// AuthChain is the ordered list of authentication methods of a registry, shared by all its clients
+// (see Client.WithAuth). Requests start with the method that last worked (the first one until a request
+// succeeds), and fall back to the others, in order, when the registry rejects it (HTTP 401 or 403).
+// This serves registries where pulls are anonymous but pushes need a token.
+type AuthChain struct {
+	mutex     sync.Mutex
+	methods   []AuthMethod
+	preferred int
+}
+
+// NewAuthChain returns an authentication chain trying methods in order (anonymous access without methods).
+func NewAuthChain(methods ...AuthMethod) *AuthChain {
+	if len(methods) == 0 {
+		methods = []AuthMethod{{Kind: AuthAnonymous}}
+	}
+
+	return &AuthChain{methods: methods}
+}
+
+// Preferred returns the method requests start with.
+func (chain *AuthChain) Preferred() AuthMethod {
+	chain.mutex.Lock()
+	defer chain.mutex.Unlock()
+
+	return chain.methods[chain.preferred]
+}
+
+// order returns the indexes of the methods, in the order requests try them.
+func (chain *AuthChain) order() []int {
+	chain.mutex.Lock()
+	defer chain.mutex.Unlock()
+
+	order := make([]int, 0, len(chain.methods))
+	order = append(order, chain.preferred)
+
+	for idx := range chain.methods {
+		if idx != chain.preferred {
+			order = append(order, idx)
+		}
+	}
+
+	return order
+}
+
+// succeeded records that the method at idx was accepted by the registry.
+func (chain *AuthChain) succeeded(idx int) {
+	chain.mutex.Lock()
+	defer chain.mutex.Unlock()
+
+	chain.preferred = idx
+}
+
+// rejected reports whether err is the registry refusing the credentials of a request.
+func rejected(err error) bool {
+	err = Classify(err)
+
+	return errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrForbidden)
+}
+
+// authenticated calls call with the remote options of the client, trying each method of its authentication
+// chain in turn while the registry rejects them. Clients without chain make a single call.
+func authenticated[T any](ctx context.Context, client *Client, call func(opts []remote.Option) (T, error)) (T, error) {
+	if client.auth == nil || len(client.auth.methods) < 2 {
+		return call(client.remoteOptionsWithContext(ctx))
+	}
+
+	var (
+		result T
+		err    error
+	)
+
+	for _, idx := range client.auth.order() {
+		method := client.auth.methods[idx]
+
+		result, err = call(client.contextOptions(ctx, client.remoteOptionsWith(method.authenticator())))
+		if err == nil {
+			client.auth.succeeded(idx)
+
+			return result, nil
+		}
+
+		if ctx.Err() != nil || !rejected(err) {
+			return result, err
+		}
+
+		client.log.Debug().Err(err).Str("auth", string(method.Kind)).Msg("registry rejected authentication method")
+	}
+
+	return result, err
+}
+
+// authenticatedDo is authenticated for calls without result.
+func authenticatedDo(ctx context.Context, client *Client, call func(opts []remote.Option) error) error {
+	_, err := authenticated(ctx, client, func(opts []remote.Option) (struct{}, error) {
+		return struct{}{}, call(opts)
+	})
+
+	return err
+}
+
+// CheckAuth verifies that the registry accepts the credentials of the client (any method of its authentication
+// chain), without accessing a repository: the token exchange of bearer registries, then the API version check,
+// must succeed.
+func (client *Client) CheckAuth(ctx context.Context) error {
+	reg, err := name.NewRegistry(client.host)
+	if err != nil {
+		return fmt.Errorf("invalid registry %q: %w", client.host, err)
+	}
+
+	methods := []AuthMethod{{Kind: AuthAnonymous}}
+
+	switch {
+	case client.auth != nil:
+		methods = methods[:0]
+		for _, idx := range client.auth.order() {
+			methods = append(methods, client.auth.methods[idx])
+		}
+	case client.username != "" && client.password != "":
+		methods = []AuthMethod{{Kind: AuthBasic, Username: client.username, Password: client.password}}
+	}
+
+	for _, method := range methods {
+		err = client.checkAuth(ctx, reg, method.authenticator())
+		if err == nil || ctx.Err() != nil || !rejected(err) {
+			return err
+		}
+
+		client.log.Debug().Err(err).Str("auth", string(method.Kind)).Msg("registry rejected authentication method")
+	}
+
+	return err
+}
+
+// checkAuth requests the API version check of reg with auth.
+func (client *Client) checkAuth(ctx context.Context, reg name.Registry, auth authn.Authenticator) error {
+	base := client.transport
+	if base == nil {
+		base = remote.DefaultTransport
+	}
+
+	authenticated, err := transport.NewWithContext(ctx, reg, auth, base, nil)
+	if err != nil {
+		return fmt.Errorf("failed to authenticate with %s: %w", reg.RegistryStr(), Classify(err))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reg.Scheme()+"://"+reg.RegistryStr()+"/v2/", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := authenticated.RoundTrip(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", reg.RegistryStr(), err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if err := transport.CheckError(resp, http.StatusOK); err != nil {
+		return fmt.Errorf("failed to authenticate with %s: %w", reg.RegistryStr(), Classify(err))
+	}
+
+	return nil
+}
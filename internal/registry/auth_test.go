@@ -0,0 +1,89 @@
+package registry_test
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// INTENTION: Requests fall back through the authentication chain while the registry rejects a method,
+// and later requests start with the method that was accepted.
+func TestClient_WithAuth(t *testing.T) {
+	t.Parallel()
+
+	// Reads are anonymous, writes need the basic credentials, and other credentials are refused
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/v2/" {
+			writer.WriteHeader(http.StatusOK)
+
+			return
+		}
+
+		username, password, basic := req.BasicAuth()
+		valid := basic && username == "ci" && password == "secret"
+
+		switch {
+		case req.Header.Get("Authorization") != "" && !valid:
+			writer.WriteHeader(http.StatusForbidden)
+		case req.Method != http.MethodGet && req.Method != http.MethodHead && !valid:
+			writer.WriteHeader(http.StatusUnauthorized)
+		default:
+			handler.ServeHTTP(writer, req)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+
+	chain := registry.NewAuthChain(
+		registry.AuthMethod{Kind: registry.AuthAnonymous},
+		registry.AuthMethod{Kind: registry.AuthBasic, Username: "ci", Password: "secret"},
+	)
+	client := registry.NewClient(host, "", "", zerolog.Nop()).WithAuth(chain)
+
+	if _, err := client.PushImage(t.Context(), host+"/test/app:1.0", img); err != nil {
+		t.Fatalf("PushImage() failed: %v", err)
+	}
+
+	if kind := chain.Preferred().Kind; kind != registry.AuthBasic {
+		t.Errorf("Preferred() after push = %s, want %s", kind, registry.AuthBasic)
+	}
+
+	// A stale token is refused, anonymous reads are accepted
+	chain = registry.NewAuthChain(
+		registry.AuthMethod{Kind: registry.AuthToken, Token: "stale"},
+		registry.AuthMethod{Kind: registry.AuthAnonymous},
+	)
+	client = registry.NewClient(host, "", "", zerolog.Nop()).WithAuth(chain)
+
+	if _, err := client.GetImage(t.Context(), host+"/test/app:1.0"); err != nil {
+		t.Fatalf("GetImage() failed: %v", err)
+	}
+
+	if kind := chain.Preferred().Kind; kind != registry.AuthAnonymous {
+		t.Errorf("Preferred() after pull = %s, want %s", kind, registry.AuthAnonymous)
+	}
+
+	// Without fallback, the rejection is reported
+	client = registry.NewClient(host, "", "", zerolog.Nop()).
+		WithAuth(registry.NewAuthChain(registry.AuthMethod{Kind: registry.AuthAnonymous}))
+
+	if _, err := client.PushImage(t.Context(), host+"/test/app:1.1", img); err == nil {
+		t.Error("PushImage() without credentials should fail")
+	}
+}
@@ -0,0 +1,146 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// ErrRegistryUnhealthy indicates a request was not sent because the registry host failed too many
+// consecutive requests.
+var ErrRegistryUnhealthy = errors.New("registry unhealthy")
+
+// breakerContextKey is the context key under which a Breaker is stored.
+type breakerContextKey struct{}
+
+// Breaker is a per-host circuit breaker: once a registry host has failed threshold consecutive
+// requests (network errors, HTTP 429 and 5xx responses, retries included), every further request
+// to that host fails immediately with ErrRegistryUnhealthy instead of waiting for its own timeouts
+// and retries. A host stays unhealthy for the lifetime of the breaker.
+// It is safe for concurrent use.
+type Breaker struct {
+	threshold int
+	failures  map[string]int
+	mu        sync.Mutex
+}
+
+// NewBreaker creates a circuit breaker opening after threshold consecutive failures (at least 1).
+func NewBreaker(threshold int) *Breaker {
+	threshold = max(threshold, 1)
+
+	return &Breaker{
+		threshold: threshold,
+		failures:  make(map[string]int),
+	}
+}
+
+// WithBreaker returns a context carrying the breaker.
+// Registry clients route all HTTP requests performed with this context through the breaker.
+func WithBreaker(ctx context.Context, breaker *Breaker) context.Context {
+	return context.WithValue(ctx, breakerContextKey{}, breaker)
+}
+
+// breakerFromContext returns the breaker carried by ctx, or nil.
+func breakerFromContext(ctx context.Context) *Breaker {
+	breaker, _ := ctx.Value(breakerContextKey{}).(*Breaker)
+
+	return breaker
+}
+
+// Unhealthy returns the hosts the breaker opened for, sorted.
+func (breaker *Breaker) Unhealthy() []string {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	var hosts []string
+
+	for host, failures := range breaker.failures {
+		if failures >= breaker.threshold {
+			hosts = append(hosts, host)
+		}
+	}
+
+	sort.Strings(hosts)
+
+	return hosts
+}
+
+// allow returns ErrRegistryUnhealthy if the breaker is open for host.
+func (breaker *Breaker) allow(host string) error {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	if failures := breaker.failures[host]; failures >= breaker.threshold {
+		return fmt.Errorf("%w: %s failed %d consecutive requests", ErrRegistryUnhealthy, host, failures)
+	}
+
+	return nil
+}
+
+// record counts a failed request for host, or resets its count on success.
+// Once open, the breaker stays open.
+func (breaker *Breaker) record(host string, failed bool) {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	if breaker.failures[host] >= breaker.threshold {
+		return
+	}
+
+	if failed {
+		breaker.failures[host]++
+	} else {
+		delete(breaker.failures, host)
+	}
+}
+
+// breakerTransport is an http.RoundTripper failing fast for unhealthy registry hosts.
+type breakerTransport struct {
+	base    http.RoundTripper
+	breaker *Breaker
+}
+
+// RoundTrip implements http.RoundTripper.
+func (transport *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := registryHost(req)
+
+	if err := transport.breaker.allow(host); err != nil {
+		return nil, err
+	}
+
+	resp, err := transport.base.RoundTrip(req)
+
+	switch {
+	case err != nil:
+		// Cancellation and HTTPS probes of plain HTTP registries say nothing about the registry health
+		if req.Context().Err() == nil && !isSchemeProbe(req) {
+			transport.breaker.record(host, true)
+		}
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		transport.breaker.record(host, true)
+	case req.URL.Path == "/v2/":
+		// Answering pings does not make a registry healthy: only successful API requests reset the count
+	default:
+		transport.breaker.record(host, false)
+	}
+
+	//nolint:wrapcheck // Transport errors are passed through unchanged
+	return resp, err
+}
+
+// isSchemeProbe reports whether req is an HTTPS ping of a registry reachable over plain HTTP
+// (localhost, *.local): pings try both schemes, one of them is expected to fail.
+func isSchemeProbe(req *http.Request) bool {
+	if req.URL.Path != "/v2/" || req.URL.Scheme != "https" {
+		return false
+	}
+
+	reg, err := name.NewRegistry(req.URL.Host)
+
+	return err == nil && reg.Scheme() == "http"
+}
@@ -0,0 +1,84 @@
+package registry_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// newStatusServer starts a registry answering pings, and every other request with status.
+func newStatusServer(t *testing.T, status int) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/v2/" {
+			writer.WriteHeader(http.StatusOK)
+
+			return
+		}
+
+		writer.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+// INTENTION: Once a registry host failed threshold consecutive requests, further requests to it
+// should fail immediately with ErrRegistryUnhealthy.
+func TestBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	t.Parallel()
+
+	// 501 is not retried, so every operation fails after a single request
+	host := newStatusServer(t, http.StatusNotImplemented)
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+
+	breaker := registry.NewBreaker(2)
+	ctx := registry.WithBreaker(t.Context(), breaker)
+
+	_, err := client.GetDigest(ctx, host+"/team/app:1.0")
+	if err == nil || errors.Is(err, registry.ErrRegistryUnhealthy) {
+		t.Fatalf("first GetDigest() error = %v, want the registry error", err)
+	}
+
+	_, _ = client.GetDigest(ctx, host+"/team/app:1.0")
+
+	_, err = client.GetDigest(ctx, host+"/team/app:1.0")
+	if !errors.Is(err, registry.ErrRegistryUnhealthy) {
+		t.Fatalf("GetDigest() error = %v, want error wrapping %v", err, registry.ErrRegistryUnhealthy)
+	}
+
+	unhealthy := breaker.Unhealthy()
+	if len(unhealthy) != 1 || unhealthy[0] != host {
+		t.Errorf("Unhealthy() = %v, want [%s]", unhealthy, host)
+	}
+}
+
+// INTENTION: Client errors (e.g., missing images) say nothing about registry health and should
+// never open the breaker.
+func TestBreaker_IgnoresClientErrors(t *testing.T) {
+	t.Parallel()
+
+	host := newStatusServer(t, http.StatusNotFound)
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+
+	breaker := registry.NewBreaker(1)
+	ctx := registry.WithBreaker(t.Context(), breaker)
+
+	for range 3 {
+		_, err := client.GetDigest(ctx, host+"/team/app:1.0")
+		if !errors.Is(err, registry.ErrNotFound) {
+			t.Fatalf("GetDigest() error = %v, want error wrapping %v", err, registry.ErrNotFound)
+		}
+	}
+
+	if unhealthy := breaker.Unhealthy(); len(unhealthy) != 0 {
+		t.Errorf("Unhealthy() = %v, want none", unhealthy)
+	}
+}
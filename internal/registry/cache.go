@@ -0,0 +1,186 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// cacheContextKey is the context key under which a ManifestCache is stored.
+type cacheContextKey struct{}
+
+// Manifest is a manifest (or index) as served by the registry.
+type Manifest struct {
+	MediaType types.MediaType
+	Digest    v1.Hash
+	Raw       []byte
+}
+
+// ManifestCache caches manifests by reference for the duration of a plan execution,
+// so operations referencing the same image do not fetch its manifest repeatedly.
+// Digest references are immutable and always safe to serve from cache; tag references are
+// invalidated whenever a client writes to them. It is safe for concurrent use.
+type ManifestCache struct {
+	entries map[string]*Manifest
+	// Digests resolved with HEAD requests, without manifest content
+	digests map[string]v1.Hash
+	hits    int
+	misses  int
+	mu      sync.Mutex
+}
+
+// NewManifestCache creates an empty manifest cache.
+func NewManifestCache() *ManifestCache {
+	return &ManifestCache{
+		entries: make(map[string]*Manifest),
+		digests: make(map[string]v1.Hash),
+	}
+}
+
+// WithManifestCache returns a context carrying the cache.
+// Registry clients serve manifest lookups performed with this context from the cache.
+func WithManifestCache(ctx context.Context, cache *ManifestCache) context.Context {
+	return context.WithValue(ctx, cacheContextKey{}, cache)
+}
+
+// InvalidateManifest drops the cached manifest for imageRef, if any.
+// Operations that move tags outside of the registry client (e.g., remote builds pushing with buildx)
+// call this so subsequent lookups see the new content.
+func InvalidateManifest(ctx context.Context, imageRef string) {
+	cache := cacheFromContext(ctx)
+	if cache == nil {
+		return
+	}
+
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return
+	}
+
+	cache.invalidate(ref)
+}
+
+// Stats returns the number of cache hits and misses.
+func (cache *ManifestCache) Stats() (hits, misses int) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	return cache.hits, cache.misses
+}
+
+// cacheFromContext returns the cache carried by ctx, or nil.
+func cacheFromContext(ctx context.Context) *ManifestCache {
+	cache, _ := ctx.Value(cacheContextKey{}).(*ManifestCache)
+
+	return cache
+}
+
+// get returns the cached manifest for ref.
+func (cache *ManifestCache) get(ref name.Reference) (*Manifest, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	manifest, ok := cache.entries[ref.Name()]
+	if ok {
+		cache.hits++
+	} else {
+		cache.misses++
+	}
+
+	return manifest, ok
+}
+
+// put caches the manifest under ref and under its digest reference.
+func (cache *ManifestCache) put(ref name.Reference, manifest *Manifest) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.entries[ref.Name()] = manifest
+	cache.entries[ref.Context().Digest(manifest.Digest.String()).Name()] = manifest
+}
+
+// getDigest returns the cached digest for ref, from a cached manifest or a HEAD request.
+func (cache *ManifestCache) getDigest(ref name.Reference) (v1.Hash, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if manifest, ok := cache.entries[ref.Name()]; ok {
+		cache.hits++
+
+		return manifest.Digest, true
+	}
+
+	digest, ok := cache.digests[ref.Name()]
+	if ok {
+		cache.hits++
+	} else {
+		cache.misses++
+	}
+
+	return digest, ok
+}
+
+// putDigest caches the digest ref resolves to.
+func (cache *ManifestCache) putDigest(ref name.Reference, digest v1.Hash) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.digests[ref.Name()] = digest
+}
+
+// invalidate drops the cached manifest and digest for ref (digest entries stay valid).
+func (cache *ManifestCache) invalidate(ref name.Reference) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	delete(cache.entries, ref.Name())
+	delete(cache.digests, ref.Name())
+}
+
+// GetManifest fetches the manifest (or index) for imageRef, from the execution cache when available.
+func (client *Client) GetManifest(ctx context.Context, imageRef string) (*Manifest, error) {
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParseImageReference, err)
+	}
+
+	cache := cacheFromContext(ctx)
+	if cache != nil {
+		if manifest, ok := cache.get(ref); ok {
+			client.log.Debug().Str("ref", imageRef).Msg("manifest served from cache")
+
+			return manifest, nil
+		}
+	}
+
+	desc, err := authenticated(ctx, client, func(opts []remote.Option) (*remote.Descriptor, error) {
+		return remote.Get(ref, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrGetImage, Classify(err))
+	}
+
+	manifest := &Manifest{
+		MediaType: desc.MediaType,
+		Digest:    desc.Digest,
+		Raw:       desc.Manifest,
+	}
+
+	if cache != nil {
+		cache.put(ref, manifest)
+	}
+
+	return manifest, nil
+}
+
+// invalidateCache drops the cached manifest for a reference the client wrote to.
+func invalidateCache(ctx context.Context, ref name.Reference) {
+	if cache := cacheFromContext(ctx); cache != nil {
+		cache.invalidate(ref)
+	}
+}
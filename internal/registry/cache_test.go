@@ -0,0 +1,92 @@
+package registry_test
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// INTENTION: Repeated manifest lookups within one execution hit the registry once,
+// lookups by the resolved digest are served from cache, and writes to a tag invalidate it.
+func TestManifestCache(t *testing.T) {
+	t.Parallel()
+
+	var manifestGets atomic.Int64
+
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet && strings.Contains(req.URL.Path, "/manifests/") {
+			manifestGets.Add(1)
+		}
+
+		handler.ServeHTTP(writer, req)
+	}))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+	tag := host + "/test/app:1.0"
+
+	first, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+
+	cache := registry.NewManifestCache()
+	ctx := registry.WithManifestCache(t.Context(), cache)
+
+	if _, err := client.PushImage(ctx, tag, first); err != nil {
+		t.Fatalf("PushImage() failed: %v", err)
+	}
+
+	firstDigest, err := client.GetDigest(ctx, tag)
+	if err != nil {
+		t.Fatalf("GetDigest() failed: %v", err)
+	}
+
+	if _, err := client.CheckExists(ctx, tag); err != nil {
+		t.Fatalf("CheckExists() failed: %v", err)
+	}
+
+	if _, err := client.GetAnnotations(ctx, host+"/test/app@"+firstDigest); err != nil {
+		t.Fatalf("GetAnnotations() failed: %v", err)
+	}
+
+	if got := manifestGets.Load(); got != 1 {
+		t.Errorf("manifest GETs = %d, want 1", got)
+	}
+
+	// Overwriting the tag must not serve the stale manifest
+	second, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+
+	secondDigest, err := client.PushImage(ctx, tag, second)
+	if err != nil {
+		t.Fatalf("PushImage() failed: %v", err)
+	}
+
+	current, err := client.GetDigest(ctx, tag)
+	if err != nil {
+		t.Fatalf("GetDigest() failed: %v", err)
+	}
+
+	if current != secondDigest {
+		t.Errorf("GetDigest() after overwrite = %q, want %q", current, secondDigest)
+	}
+
+	if hits, misses := cache.Stats(); hits != 2 || misses != 2 {
+		t.Errorf("Stats() = %d hits, %d misses, want 2 hits, 2 misses", hits, misses)
+	}
+}
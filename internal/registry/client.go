@@ -3,6 +3,7 @@ package registry
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -16,7 +17,6 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/empty"
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
-	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/rs/zerolog"
 )
@@ -42,6 +42,15 @@ type Client struct {
 	username string
 	password string
 	log      zerolog.Logger
+
+	// Base HTTP transport (go-containerregistry default when nil)
+	transport http.RoundTripper
+
+	// Authentication methods tried in turn (username and password when nil)
+	auth *AuthChain
+
+	// Largest blob read into memory (DefaultMaxBlobMemory when zero)
+	maxBlobMemory int64
 }
 
 // NewClient creates a new registry client.
@@ -54,6 +63,38 @@ func NewClient(host, username, password string, log zerolog.Logger) *Client {
 	}
 }
 
+// WithTransport sets the base HTTP transport of the client (see NewTransport), and returns the client.
+// Context transport wrappers (circuit breaker, metering, request logging) are applied around it.
+func (client *Client) WithTransport(transport http.RoundTripper) *Client {
+	client.transport = transport
+
+	return client
+}
+
+// WithAuth sets the authentication methods of the client (see AuthChain), replacing its username and password,
+// and returns the client. Clients of the same registry share one chain, to share which method works.
+func (client *Client) WithAuth(chain *AuthChain) *Client {
+	client.auth = chain
+
+	return client
+}
+
+// SameRegistry reports whether two image references point to the same registry host.
+// Copies within a registry can use cross-repository blob mounts instead of pull/push.
+func SameRegistry(srcRef, dstRef string) bool {
+	src, err := name.ParseReference(srcRef)
+	if err != nil {
+		return false
+	}
+
+	dst, err := name.ParseReference(dstRef)
+	if err != nil {
+		return false
+	}
+
+	return src.Context().RegistryStr() == dst.Context().RegistryStr()
+}
+
 // GetImage retrieves an image descriptor from the registry.
 func (client *Client) GetImage(ctx context.Context, imageRef string) (remote.Descriptor, error) {
 	ref, err := name.ParseReference(imageRef)
@@ -61,9 +102,11 @@ func (client *Client) GetImage(ctx context.Context, imageRef string) (remote.Des
 		return remote.Descriptor{}, fmt.Errorf("%w: %w", ErrParseImageReference, err)
 	}
 
-	desc, err := remote.Get(ref, client.remoteOptionsWithContext(ctx)...)
+	desc, err := authenticated(ctx, client, func(opts []remote.Option) (*remote.Descriptor, error) {
+		return remote.Get(ref, opts...)
+	})
 	if err != nil {
-		return remote.Descriptor{}, fmt.Errorf("%w: %w", ErrGetImage, err)
+		return remote.Descriptor{}, fmt.Errorf("%w: %w", ErrGetImage, Classify(err))
 	}
 
 	return *desc, nil
@@ -88,14 +131,22 @@ func (client *Client) CopyImage(ctx context.Context, srcRef, dstRef string, dstC
 		Msg("copying image")
 
 	// Get source image (TRUSTED - must be called with digest reference)
-	img, err := remote.Image(srcNameRef, client.remoteOptionsWithContext(ctx)...)
+	img, err := authenticated(ctx, client, func(opts []remote.Option) (v1.Image, error) {
+		return remote.Image(srcNameRef, opts...)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get source image: %w", err)
+		return nil, fmt.Errorf("failed to get source image: %w", Classify(err))
 	}
 
 	// Push to destination
-	if err := remote.Write(dstNameRef, img, dstClient.remoteOptionsWithContext(ctx)...); err != nil {
-		return nil, fmt.Errorf("failed to write destination image: %w", err)
+	// Layers of remote images are mountable: when the destination is on the same registry,
+	// remote.Write mounts blobs from the source repository instead of uploading them.
+	invalidateCache(ctx, dstNameRef)
+
+	if err := authenticatedDo(ctx, dstClient, func(opts []remote.Option) error {
+		return remote.Write(dstNameRef, img, opts...)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to write destination image: %w", Classify(err))
 	}
 
 	// Return the TRUSTED source image (not fetched from destination)
@@ -103,16 +154,17 @@ func (client *Client) CopyImage(ctx context.Context, srcRef, dstRef string, dstC
 	return img, nil
 }
 
-// CopyIndex copies a multi-platform image index from source to destination.
-func (client *Client) CopyIndex(ctx context.Context, srcRef, dstRef string, dstClient *Client) error {
+// CopyIndex copies an image index verbatim from source to destination.
+// Returns the source index object (fetched by digest) for trusted digest computation.
+func (client *Client) CopyIndex(ctx context.Context, srcRef, dstRef string, dstClient *Client) (v1.ImageIndex, error) {
 	srcNameRef, err := name.ParseReference(srcRef)
 	if err != nil {
-		return fmt.Errorf("%w: %w", ErrParseSourceReference, err)
+		return nil, fmt.Errorf("%w: %w", ErrParseSourceReference, err)
 	}
 
 	dstNameRef, err := name.ParseReference(dstRef)
 	if err != nil {
-		return fmt.Errorf("%w: %w", ErrParseDestinationReference, err)
+		return nil, fmt.Errorf("%w: %w", ErrParseDestinationReference, err)
 	}
 
 	client.log.Debug().
@@ -121,20 +173,28 @@ func (client *Client) CopyIndex(ctx context.Context, srcRef, dstRef string, dstC
 		Msg("copying image index")
 
 	// Get source index
-	idx, err := remote.Index(srcNameRef, client.remoteOptionsWithContext(ctx)...)
+	idx, err := authenticated(ctx, client, func(opts []remote.Option) (v1.ImageIndex, error) {
+		return remote.Index(srcNameRef, opts...)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get source index: %w", err)
+		return nil, fmt.Errorf("failed to get source index: %w", Classify(err))
 	}
 
 	// Push to destination
-	if err := remote.WriteIndex(dstNameRef, idx, dstClient.remoteOptionsWithContext(ctx)...); err != nil {
-		return fmt.Errorf("failed to write destination index: %w", err)
+	invalidateCache(ctx, dstNameRef)
+
+	if err := authenticatedDo(ctx, dstClient, func(opts []remote.Option) error {
+		return remote.WriteIndex(dstNameRef, idx, opts...)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to write destination index: %w", Classify(err))
 	}
 
-	return nil
+	return idx, nil
 }
 
-// GetPlatformDigests returns platform-specific digests for a multi-platform image.
+// GetPlatformDigests returns platform-specific digests for a multi-platform image, keyed by platform
+// (os/architecture, with the variant when there is one: "linux/arm/v7"; arm64 is "linux/arm64" with or without
+// the v8 variant).
 func (client *Client) GetPlatformDigests(ctx context.Context, imageRef string) (map[string]string, error) {
 	ref, err := name.ParseReference(imageRef)
 	if err != nil {
@@ -142,9 +202,11 @@ func (client *Client) GetPlatformDigests(ctx context.Context, imageRef string) (
 	}
 
 	// Get the image index
-	idx, err := remote.Index(ref, client.remoteOptionsWithContext(ctx)...)
+	idx, err := authenticated(ctx, client, func(opts []remote.Option) (v1.ImageIndex, error) {
+		return remote.Index(ref, opts...)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrGetImageIndex, err)
+		return nil, fmt.Errorf("%w: %w", ErrGetImageIndex, Classify(err))
 	}
 
 	manifest, err := idx.IndexManifest()
@@ -157,14 +219,23 @@ func (client *Client) GetPlatformDigests(ctx context.Context, imageRef string) (
 
 	for _, desc := range manifest.Manifests {
 		if desc.Platform != nil {
-			platform := fmt.Sprintf("%s/%s", desc.Platform.OS, desc.Platform.Architecture)
-			platformDigests[platform] = desc.Digest.String()
+			platformDigests[platformKey(desc.Platform)] = desc.Digest.String()
 		}
 	}
 
 	return platformDigests, nil
 }
 
+// platformKey returns the key of platform in GetPlatformDigests.
+func platformKey(platform *v1.Platform) string {
+	key := fmt.Sprintf("%s/%s", platform.OS, platform.Architecture)
+	if platform.Variant != "" && (platform.Architecture != "arm64" || platform.Variant != "v8") {
+		key += "/" + platform.Variant
+	}
+
+	return key
+}
+
 // FetchPlatformImage fetches a specific platform image by digest from source.
 // Returns the source image object (fetched by digest) for trusted manifest list creation.
 // The image is NOT pushed - it will be pushed by digest when PushManifestList is called.
@@ -182,9 +253,11 @@ func (client *Client) FetchPlatformImage(ctx context.Context, srcRef, platformDi
 		Msg("fetching platform image")
 
 	// Get source image by digest (TRUSTED - fetched by known digest)
-	img, err := remote.Image(srcNameRef, client.remoteOptionsWithContext(ctx)...)
+	img, err := authenticated(ctx, client, func(opts []remote.Option) (v1.Image, error) {
+		return remote.Image(srcNameRef, opts...)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get source image: %w", err)
+		return nil, fmt.Errorf("failed to get source image: %w", Classify(err))
 	}
 
 	// Return the TRUSTED source image (not fetched from destination)
@@ -193,6 +266,103 @@ func (client *Client) FetchPlatformImage(ctx context.Context, srcRef, platformDi
 	return img, nil
 }
 
+// ImageSize describes the compressed size of a single-platform image.
+type ImageSize struct {
+	Platform string // e.g., "linux/amd64" (empty for single-platform images without platform info)
+	Digest   string // Image manifest digest
+	Size     int64  // Compressed size in bytes (config + layers, as declared by the manifest)
+	Layers   int    // Number of layers
+}
+
+// GetImageSizes returns the compressed size of an image as declared by its manifest.
+// For a multi-platform index, one entry is returned per platform image.
+// Only manifests are fetched - no layer is downloaded.
+func (client *Client) GetImageSizes(ctx context.Context, imageRef string) ([]ImageSize, error) {
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParseImageReference, err)
+	}
+
+	desc, err := authenticated(ctx, client, func(opts []remote.Option) (*remote.Descriptor, error) {
+		return remote.Get(ref, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrGetImage, Classify(err))
+	}
+
+	if !desc.MediaType.IsIndex() {
+		img, err := desc.Image()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrGetImage, err)
+		}
+
+		size, err := imageSize(img)
+		if err != nil {
+			return nil, err
+		}
+
+		return []ImageSize{size}, nil
+	}
+
+	idx, err := desc.ImageIndex()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrGetImageIndex, err)
+	}
+
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index manifest: %w", err)
+	}
+
+	sizes := make([]ImageSize, 0, len(manifest.Manifests))
+
+	for _, child := range manifest.Manifests {
+		// Skip attestation manifests and other non-runnable entries
+		if child.Platform == nil || child.Platform.OS == "unknown" {
+			continue
+		}
+
+		img, err := idx.Image(child.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrGetImage, err)
+		}
+
+		size, err := imageSize(img)
+		if err != nil {
+			return nil, err
+		}
+
+		size.Platform = fmt.Sprintf("%s/%s", child.Platform.OS, child.Platform.Architecture)
+		sizes = append(sizes, size)
+	}
+
+	return sizes, nil
+}
+
+// imageSize computes the compressed size of an image from its manifest.
+func imageSize(img v1.Image) (ImageSize, error) {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return ImageSize{}, fmt.Errorf("failed to get manifest: %w", err)
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		return ImageSize{}, fmt.Errorf("failed to compute image digest: %w", err)
+	}
+
+	total := manifest.Config.Size
+	for _, layer := range manifest.Layers {
+		total += layer.Size
+	}
+
+	return ImageSize{
+		Digest: digest.String(),
+		Size:   total,
+		Layers: len(manifest.Layers),
+	}, nil
+}
+
 // GetDigest returns the digest for an image reference.
 func (client *Client) GetDigest(ctx context.Context, imageRef string) (string, error) {
 	ref, err := name.ParseReference(imageRef)
@@ -200,12 +370,12 @@ func (client *Client) GetDigest(ctx context.Context, imageRef string) (string, e
 		return "", fmt.Errorf("%w: %w", ErrParseImageReference, err)
 	}
 
-	desc, err := remote.Get(ref, client.remoteOptionsWithContext(ctx)...)
+	manifest, err := client.GetManifest(ctx, ref.String())
 	if err != nil {
-		return "", fmt.Errorf("%w: %w", ErrGetImage, err)
+		return "", err
 	}
 
-	return desc.Digest.String(), nil
+	return manifest.Digest.String(), nil
 }
 
 // PushManifestList creates and pushes a manifest list from platform-specific images.
@@ -226,6 +396,31 @@ func (client *Client) PushManifestList(
 		return "", fmt.Errorf("%w: %w", ErrParseManifestReference, err)
 	}
 
+	idx := client.BuildManifestList(platformImages)
+
+	// Push the manifest list
+	invalidateCache(ctx, ref)
+
+	if err := authenticatedDo(ctx, client, func(opts []remote.Option) error {
+		return remote.WriteIndex(ref, idx, opts...)
+	}); err != nil {
+		return "", fmt.Errorf("failed to push manifest list: %w", Classify(err))
+	}
+
+	// Get the digest of the pushed manifest list
+	digest, err := idx.Digest()
+	if err != nil {
+		return "", fmt.Errorf("failed to get manifest list digest: %w", err)
+	}
+
+	client.log.Debug().Str("digest", digest.String()).Msg("manifest list pushed successfully")
+
+	return digest.String(), nil
+}
+
+// BuildManifestList assembles a manifest list from platform-specific images without pushing it.
+// platformImages is a map of platform string (e.g., "linux/amd64") to image.
+func (client *Client) BuildManifestList(platformImages map[string]v1.Image) v1.ImageIndex {
 	// Start with an empty index
 	idx := mutate.IndexMediaType(empty.Index, types.DockerManifestList)
 
@@ -243,38 +438,102 @@ func (client *Client) PushManifestList(
 		img := platformImages[platform]
 		client.log.Debug().Str("platform", platform).Msg("adding platform to manifest list")
 
-		// Extract OS and architecture from platform string (e.g., "linux/amd64")
-		parts := strings.SplitN(platform, "/", 2)
-		osName, archName := parts[0], parts[1]
+		// Extract OS, architecture and variant from platform string (e.g., "linux/amd64", "linux/arm/v7")
+		parts := strings.SplitN(platform, "/", 3)
+		spec := &v1.Platform{OS: parts[0]}
+
+		if len(parts) > 1 {
+			spec.Architecture = parts[1]
+		}
+
+		if len(parts) > 2 {
+			spec.Variant = parts[2]
+		}
 
 		// Add image to index with platform specification
 		idx = mutate.AppendManifests(idx, mutate.IndexAddendum{
 			Add: img,
 			Descriptor: v1.Descriptor{
-				Platform: &v1.Platform{
-					OS:           osName,
-					Architecture: archName,
-				},
+				Platform: spec,
 			},
 		})
 	}
 
-	// Push the manifest list
-	if err := remote.WriteIndex(ref, idx, client.remoteOptionsWithContext(ctx)...); err != nil {
-		return "", fmt.Errorf("failed to push manifest list: %w", err)
+	return idx
+}
+
+// PushIndex pushes an image index to the given reference.
+// Returns the digest of the pushed index (computed locally).
+func (client *Client) PushIndex(ctx context.Context, indexRef string, idx v1.ImageIndex) (string, error) {
+	ref, err := name.ParseReference(indexRef)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrParseManifestReference, err)
+	}
+
+	invalidateCache(ctx, ref)
+
+	if err := authenticatedDo(ctx, client, func(opts []remote.Option) error {
+		return remote.WriteIndex(ref, idx, opts...)
+	}); err != nil {
+		return "", fmt.Errorf("failed to push index: %w", Classify(err))
 	}
 
-	// Get the digest of the pushed manifest list
 	digest, err := idx.Digest()
 	if err != nil {
-		return "", fmt.Errorf("failed to get manifest list digest: %w", err)
+		return "", fmt.Errorf("failed to get index digest: %w", err)
 	}
 
-	client.log.Debug().Str("digest", digest.String()).Msg("manifest list pushed successfully")
+	return digest.String(), nil
+}
+
+// PushImage pushes a single image to the given reference.
+// Returns the digest of the pushed image (computed locally).
+func (client *Client) PushImage(ctx context.Context, imageRef string, img v1.Image) (string, error) {
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrParseDestinationReference, err)
+	}
+
+	invalidateCache(ctx, ref)
+
+	if err := authenticatedDo(ctx, client, func(opts []remote.Option) error {
+		return remote.Write(ref, img, opts...)
+	}); err != nil {
+		return "", fmt.Errorf("failed to write destination image: %w", Classify(err))
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		return "", fmt.Errorf("failed to compute image digest: %w", err)
+	}
 
 	return digest.String(), nil
 }
 
+// GetAnnotations returns the manifest annotations for an image or index reference.
+// Returns an empty map if the manifest carries no annotations.
+func (client *Client) GetAnnotations(ctx context.Context, imageRef string) (map[string]string, error) {
+	raw, err := client.GetManifest(ctx, imageRef)
+	if err != nil {
+		return nil, err
+	}
+
+	// Image manifests and indexes both carry annotations at the top level
+	var manifest struct {
+		Annotations map[string]string `json:"annotations"`
+	}
+
+	if err := json.Unmarshal(raw.Raw, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	if manifest.Annotations == nil {
+		return map[string]string{}, nil
+	}
+
+	return manifest.Annotations, nil
+}
+
 // CheckExists checks if an image exists in the registry.
 // Returns (false, nil) only for 404/not found errors.
 // Returns (false, err) for all other errors (network, auth, etc.).
@@ -284,11 +543,10 @@ func (client *Client) CheckExists(ctx context.Context, imageRef string) (bool, e
 		return false, fmt.Errorf("%w: %w", ErrParseImageReference, err)
 	}
 
-	_, err = remote.Get(ref, client.remoteOptionsWithContext(ctx)...)
+	_, err = client.GetManifest(ctx, ref.String())
 	if err != nil {
 		// Check if this is a 404/not found error
-		var transportErr *transport.Error
-		if errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound {
+		if errors.Is(err, ErrNotFound) {
 			// Image doesn't exist - this is expected
 			return false, nil
 		}
@@ -299,6 +557,42 @@ func (client *Client) CheckExists(ctx context.Context, imageRef string) (bool, e
 	return true, nil
 }
 
+// Tag points tagRef at the manifest identified by digestRef (both within the same registry).
+// The manifest is fetched by digest first, so a missing digest fails before the tag is touched.
+func (client *Client) Tag(ctx context.Context, digestRef, tagRef string) error {
+	srcRef, err := name.ParseReference(digestRef)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrParseSourceReference, err)
+	}
+
+	dstTag, err := name.NewTag(tagRef)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrParseDestinationReference, err)
+	}
+
+	desc, err := authenticated(ctx, client, func(opts []remote.Option) (*remote.Descriptor, error) {
+		return remote.Get(srcRef, opts...)
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrGetImage, Classify(err))
+	}
+
+	client.log.Debug().
+		Str("digest", desc.Digest.String()).
+		Str("tag", tagRef).
+		Msg("tagging manifest")
+
+	invalidateCache(ctx, dstTag)
+
+	if err := authenticatedDo(ctx, client, func(opts []remote.Option) error {
+		return remote.Tag(dstTag, desc, opts...)
+	}); err != nil {
+		return fmt.Errorf("failed to tag manifest: %w", Classify(err))
+	}
+
+	return nil
+}
+
 // GetImageHandle fetches a v1.Image for the given reference.
 // This is needed for creating manifest lists.
 func (client *Client) GetImageHandle(ctx context.Context, imageRef string) (v1.Image, error) {
@@ -307,31 +601,57 @@ func (client *Client) GetImageHandle(ctx context.Context, imageRef string) (v1.I
 		return nil, fmt.Errorf("%w: %w", ErrParseImageReference, err)
 	}
 
-	img, err := remote.Image(ref, client.remoteOptionsWithContext(ctx)...)
+	img, err := authenticated(ctx, client, func(opts []remote.Option) (v1.Image, error) {
+		return remote.Image(ref, opts...)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrGetImage, err)
+		return nil, fmt.Errorf("%w: %w", ErrGetImage, Classify(err))
 	}
 
 	return img, nil
 }
 
-// ListTags returns all tags for a repository.
-func (client *Client) ListTags(ctx context.Context, repository string) ([]string, error) {
-	repo, err := name.NewRepository(repository)
+// GetIndexHandle fetches a v1.ImageIndex for the given reference.
+func (client *Client) GetIndexHandle(ctx context.Context, imageRef string) (v1.ImageIndex, error) {
+	ref, err := name.ParseReference(imageRef)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse repository: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrParseImageReference, err)
 	}
 
-	tags, err := remote.List(repo, client.remoteOptionsWithContext(ctx)...)
+	idx, err := authenticated(ctx, client, func(opts []remote.Option) (v1.ImageIndex, error) {
+		return remote.Index(ref, opts...)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list tags: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrGetImageIndex, Classify(err))
 	}
 
-	return tags, nil
+	return idx, nil
+}
+
+// ListTags returns all tags for a repository.
+// Use FindTags or WalkTags to stop listing once the tags needed are found.
+func (client *Client) ListTags(ctx context.Context, repository string) ([]string, error) {
+	return client.FindTags(ctx, repository, func(string) bool { return true }, 0)
 }
 
 // remoteOptions returns remote options with authentication and retry configuration.
+// Clients with an authentication chain use its preferred method.
 func (client *Client) remoteOptions() []remote.Option {
+	switch {
+	case client.auth != nil:
+		return client.remoteOptionsWith(client.auth.Preferred().authenticator())
+	case client.username != "" && client.password != "":
+		return client.remoteOptionsWith(&authn.Basic{
+			Username: client.username,
+			Password: client.password,
+		})
+	default:
+		return client.remoteOptionsWith(nil)
+	}
+}
+
+// remoteOptionsWith returns remote options with auth (anonymous when nil) and retry configuration.
+func (client *Client) remoteOptionsWith(auth authn.Authenticator) []remote.Option {
 	opts := []remote.Option{
 		// Retry on rate limits and transient server errors
 		remote.WithRetryStatusCodes(
@@ -350,11 +670,7 @@ func (client *Client) remoteOptions() []remote.Option {
 		}),
 	}
 
-	if client.username != "" && client.password != "" {
-		auth := &authn.Basic{
-			Username: client.username,
-			Password: client.password,
-		}
+	if auth != nil {
 		opts = append(opts, remote.WithAuth(auth))
 	}
 
@@ -363,8 +679,13 @@ func (client *Client) remoteOptions() []remote.Option {
 
 // remoteOptionsWithContext returns remote options with context, authentication, and retry configuration.
 func (client *Client) remoteOptionsWithContext(ctx context.Context) []remote.Option {
-	opts := client.remoteOptions()
+	return client.contextOptions(ctx, client.remoteOptions())
+}
+
+// contextOptions adds the context and its transport options to opts.
+func (client *Client) contextOptions(ctx context.Context, opts []remote.Option) []remote.Option {
 	opts = append(opts, remote.WithContext(ctx))
+	opts = append(opts, client.TransportOptions(ctx)...)
 
 	return opts
 }
@@ -135,7 +135,7 @@ func TestClient_CopyIndex_InvalidSourceReference(t *testing.T) {
 	client := registry.NewClient("docker.io", "", "", zerolog.Nop())
 	dstClient := registry.NewClient("ghcr.io", "", "", zerolog.Nop())
 
-	err := client.CopyIndex(t.Context(), "invalid@@@reference", "ghcr.io/valid/image:latest", dstClient)
+	_, err := client.CopyIndex(t.Context(), "invalid@@@reference", "ghcr.io/valid/image:latest", dstClient)
 
 	if err == nil {
 		t.Fatal("CopyIndex() error = nil, want error")
@@ -153,7 +153,7 @@ func TestClient_CopyIndex_InvalidDestinationReference(t *testing.T) {
 	client := registry.NewClient("docker.io", "", "", zerolog.Nop())
 	dstClient := registry.NewClient("ghcr.io", "", "", zerolog.Nop())
 
-	err := client.CopyIndex(t.Context(), "docker.io/library/alpine:latest", "invalid@@@reference", dstClient)
+	_, err := client.CopyIndex(t.Context(), "docker.io/library/alpine:latest", "invalid@@@reference", dstClient)
 
 	if err == nil {
 		t.Fatal("CopyIndex() error = nil, want error")
@@ -252,3 +252,36 @@ func TestClient_GetImageHandle_InvalidReference(t *testing.T) {
 		t.Errorf("GetImageHandle() error = %v, want error wrapping %v", err, registry.ErrParseImageReference)
 	}
 }
+
+// INTENTION: Invalid references passed to Tag should fail before any network access.
+func TestClient_Tag_InvalidReference(t *testing.T) {
+	t.Parallel()
+
+	client := registry.NewClient("ghcr.io", "", "", zerolog.Nop())
+
+	err := client.Tag(t.Context(), "invalid@@@reference", "ghcr.io/valid/image:latest")
+	if !errors.Is(err, registry.ErrParseSourceReference) {
+		t.Errorf("Tag() error = %v, want error wrapping %v", err, registry.ErrParseSourceReference)
+	}
+
+	err = client.Tag(
+		t.Context(),
+		"ghcr.io/valid/image@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+		"ghcr.io/valid/image@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+	)
+	if !errors.Is(err, registry.ErrParseDestinationReference) {
+		t.Errorf("Tag() error = %v, want error wrapping %v", err, registry.ErrParseDestinationReference)
+	}
+}
+
+// INTENTION: Invalid image references should return ErrParseImageReference.
+func TestClient_GetImageSizes_InvalidReference(t *testing.T) {
+	t.Parallel()
+
+	client := registry.NewClient("docker.io", "", "", zerolog.Nop())
+
+	_, err := client.GetImageSizes(t.Context(), "invalid@@@reference")
+	if !errors.Is(err, registry.ErrParseImageReference) {
+		t.Errorf("GetImageSizes() error = %v, want error wrapping %v", err, registry.ErrParseImageReference)
+	}
+}
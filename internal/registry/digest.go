@@ -0,0 +1,136 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// DefaultDigestConcurrency is how many digests GetDigests resolves at once by default.
+const DefaultDigestConcurrency = 8
+
+// ErrManifestRewritten indicates the registry does not serve a pushed manifest under its locally computed
+// digest: it (or a proxy in front of it) rewrote the manifest.
+var ErrManifestRewritten = errors.New("registry rewrote the pushed manifest")
+
+// DigestResult is the outcome of resolving one reference with GetDigests.
+type DigestResult struct {
+	Reference string
+	Digest    string
+	Err       error
+}
+
+// HeadDigest resolves the digest imageRef points to with a HEAD request, which does not transfer the manifest
+// (and is not counted against the Docker Hub pull rate limit).
+// Digests are served from the execution cache when available. Registries answering HEAD requests without
+// a digest, or failing them, are asked with a GET.
+func (client *Client) HeadDigest(ctx context.Context, imageRef string) (string, error) {
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrParseImageReference, err)
+	}
+
+	cache := cacheFromContext(ctx)
+	if cache != nil {
+		if digest, ok := cache.getDigest(ref); ok {
+			client.log.Debug().Str("ref", imageRef).Msg("digest served from cache")
+
+			return digest.String(), nil
+		}
+	}
+
+	desc, err := authenticated(ctx, client, func(opts []remote.Option) (*v1.Descriptor, error) {
+		return remote.Head(ref, opts...)
+	})
+	if err != nil {
+		if ctx.Err() != nil || errors.Is(err, ErrRegistryUnhealthy) {
+			return "", fmt.Errorf("%w: %w", ErrGetImage, Classify(err))
+		}
+
+		client.log.Debug().Err(err).Str("ref", imageRef).Msg("HEAD request failed, fetching manifest")
+
+		return client.GetDigest(ctx, imageRef)
+	}
+
+	if cache != nil {
+		cache.putDigest(ref, desc.Digest)
+	}
+
+	return desc.Digest.String(), nil
+}
+
+// VerifyPushedDigest checks that the registry serves the manifest just pushed to manifestRef under digest,
+// the digest computed locally before the push: the manifest must exist by digest, and the tag must point to it.
+// Both are asked with HEAD requests, bypassing the execution cache.
+func (client *Client) VerifyPushedDigest(ctx context.Context, manifestRef, digest string) error {
+	ref, err := name.ParseReference(manifestRef)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrParseManifestReference, err)
+	}
+
+	byDigest := ref.Context().Digest(digest)
+
+	if _, err := authenticated(ctx, client, func(opts []remote.Option) (*v1.Descriptor, error) {
+		return remote.Head(byDigest, opts...)
+	}); err != nil {
+		if errors.Is(Classify(err), ErrNotFound) {
+			return fmt.Errorf("%w: %s not found by digest", ErrManifestRewritten, byDigest)
+		}
+
+		return fmt.Errorf("failed to verify pushed manifest: %w", Classify(err))
+	}
+
+	invalidateCache(ctx, ref)
+
+	desc, err := authenticated(ctx, client, func(opts []remote.Option) (*v1.Descriptor, error) {
+		return remote.Head(ref, opts...)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to verify pushed manifest: %w", Classify(err))
+	}
+
+	if desc.Digest.String() != digest {
+		return fmt.Errorf("%w: %s points to %s, pushed %s", ErrManifestRewritten, ref, desc.Digest, digest)
+	}
+
+	client.log.Debug().Str("manifest", manifestRef).Str("digest", digest).Msg("pushed manifest digest verified")
+
+	return nil
+}
+
+// GetDigests resolves the digests of imageRefs with HEAD requests (see HeadDigest), at most concurrency
+// at a time (DefaultDigestConcurrency when zero or less). Results are in the order of imageRefs;
+// a reference failing to resolve does not stop the others.
+func (client *Client) GetDigests(ctx context.Context, imageRefs []string, concurrency int) []DigestResult {
+	if concurrency <= 0 {
+		concurrency = DefaultDigestConcurrency
+	}
+
+	results := make([]DigestResult, len(imageRefs))
+	slots := make(chan struct{}, concurrency)
+
+	var group sync.WaitGroup
+
+	for idx, imageRef := range imageRefs {
+		group.Add(1)
+
+		go func() {
+			defer group.Done()
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			digest, err := client.HeadDigest(ctx, imageRef)
+			results[idx] = DigestResult{Reference: imageRef, Digest: digest, Err: err}
+		}()
+	}
+
+	group.Wait()
+
+	return results
+}
@@ -0,0 +1,98 @@
+package registry_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// INTENTION: Batched digest lookups resolve every reference with HEAD requests only, keep the order
+// of the references, report failures per reference, and are served from the execution cache afterwards.
+func TestClient_GetDigests(t *testing.T) {
+	t.Parallel()
+
+	var manifestGets, manifestHeads atomic.Int64
+
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if strings.Contains(req.URL.Path, "/manifests/") {
+			switch req.Method {
+			case http.MethodGet:
+				manifestGets.Add(1)
+			case http.MethodHead:
+				manifestHeads.Add(1)
+			}
+		}
+
+		handler.ServeHTTP(writer, req)
+	}))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+	ctx := registry.WithManifestCache(t.Context(), registry.NewManifestCache())
+
+	var (
+		refs []string
+		want []string
+	)
+
+	for _, tag := range []string{"1.0", "1.1", "1.2"} {
+		img, err := random.Image(256, 1)
+		if err != nil {
+			t.Fatalf("failed to create random image: %v", err)
+		}
+
+		digest, err := client.PushImage(t.Context(), host+"/test/app:"+tag, img)
+		if err != nil {
+			t.Fatalf("PushImage() failed: %v", err)
+		}
+
+		refs = append(refs, host+"/test/app:"+tag)
+		want = append(want, digest)
+	}
+
+	refs = append(refs, host+"/test/app:missing")
+
+	results := client.GetDigests(ctx, refs, 2)
+	if len(results) != len(refs) {
+		t.Fatalf("GetDigests() = %d results, want %d", len(results), len(refs))
+	}
+
+	for idx, digest := range want {
+		if results[idx].Reference != refs[idx] || results[idx].Digest != digest || results[idx].Err != nil {
+			t.Errorf("GetDigests()[%d] = %+v, want %s", idx, results[idx], digest)
+		}
+	}
+
+	if results[3].Err == nil || !errors.Is(results[3].Err, registry.ErrNotFound) {
+		t.Errorf("GetDigests()[3] error = %v, want %v", results[3].Err, registry.ErrNotFound)
+	}
+
+	// The missing tag falls back to a GET, which fails too
+	if gets := manifestGets.Load(); gets != 1 {
+		t.Errorf("manifest GETs = %d, want 1", gets)
+	}
+
+	heads := manifestHeads.Load()
+
+	digest, err := client.HeadDigest(ctx, refs[0])
+	if err != nil || digest != want[0] {
+		t.Errorf("HeadDigest() = %q, %v, want %q", digest, err, want[0])
+	}
+
+	if manifestHeads.Load() != heads {
+		t.Error("HeadDigest() after GetDigests() should be served from cache")
+	}
+}
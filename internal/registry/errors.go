@@ -0,0 +1,108 @@
+package registry
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// Registry failure causes, matched with errors.Is on errors returned by the client.
+// They let callers tell failures apart (e.g., retry rate limits, report bad credentials) without
+// inspecting HTTP status codes.
+var (
+	// ErrUnauthorized indicates the registry rejected the credentials, or required some (HTTP 401).
+	ErrUnauthorized = errors.New("registry authentication required or failed")
+	// ErrForbidden indicates the credentials lack access to the repository (HTTP 403).
+	ErrForbidden = errors.New("registry access denied")
+	// ErrRateLimited indicates the registry throttled requests (HTTP 429).
+	ErrRateLimited = errors.New("registry rate limit exceeded")
+	// ErrNotFound indicates the repository, manifest or blob does not exist (HTTP 404).
+	ErrNotFound = errors.New("not found in registry")
+	// ErrBlobUnknown indicates a blob referenced by a manifest does not exist in the repository.
+	// Blob unknown errors also match ErrNotFound.
+	ErrBlobUnknown = errors.New("blob unknown to registry")
+	// ErrTagImmutable indicates the registry refused to overwrite an existing tag (immutable tags:
+	// TAG_INVALID error code, HTTP 409 or 412).
+	ErrTagImmutable = errors.New("registry refused to overwrite tag")
+)
+
+// classifiedError attaches failure causes to a registry error, keeping its message unchanged.
+type classifiedError struct {
+	err    error
+	causes []error
+}
+
+// Error implements error.
+func (classified *classifiedError) Error() string {
+	return classified.err.Error()
+}
+
+// Unwrap returns the original error followed by its causes, for errors.Is and errors.As.
+func (classified *classifiedError) Unwrap() []error {
+	return append([]error{classified.err}, classified.causes...)
+}
+
+// Classify attaches the matching failure causes (ErrUnauthorized, ErrForbidden, ErrRateLimited,
+// ErrNotFound, ErrBlobUnknown, ErrTagImmutable) to a registry error, based on its HTTP status code and error codes.
+// Errors without a registry response (network, parsing, ...) are returned unchanged.
+// The client classifies the errors it returns; callers using go-containerregistry directly can
+// classify their own.
+func Classify(err error) error {
+	var transportErr *transport.Error
+	if !errors.As(err, &transportErr) {
+		return err
+	}
+
+	var causes []error
+
+	for _, diagnostic := range transportErr.Errors {
+		switch diagnostic.Code {
+		case transport.BlobUnknownErrorCode:
+			causes = appendCause(causes, ErrBlobUnknown, ErrNotFound)
+		case transport.ManifestUnknownErrorCode, transport.NameUnknownErrorCode:
+			causes = appendCause(causes, ErrNotFound)
+		case transport.UnauthorizedErrorCode:
+			causes = appendCause(causes, ErrUnauthorized)
+		case transport.DeniedErrorCode:
+			causes = appendCause(causes, ErrForbidden)
+		case transport.TooManyRequestsErrorCode:
+			causes = appendCause(causes, ErrRateLimited)
+		case transport.TagInvalidErrorCode:
+			causes = appendCause(causes, ErrTagImmutable)
+		default:
+		}
+	}
+
+	switch transportErr.StatusCode {
+	case http.StatusUnauthorized:
+		causes = appendCause(causes, ErrUnauthorized)
+	case http.StatusForbidden:
+		causes = appendCause(causes, ErrForbidden)
+	case http.StatusTooManyRequests:
+		causes = appendCause(causes, ErrRateLimited)
+	case http.StatusNotFound:
+		causes = appendCause(causes, ErrNotFound)
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		causes = appendCause(causes, ErrTagImmutable)
+	default:
+	}
+
+	if len(causes) == 0 {
+		return err
+	}
+
+	return &classifiedError{err: err, causes: causes}
+}
+
+// appendCause appends causes not already present.
+func appendCause(causes []error, add ...error) []error {
+	for _, cause := range add {
+		if !slices.Contains(causes, cause) {
+			causes = append(causes, cause)
+		}
+	}
+
+	return causes
+}
@@ -0,0 +1,177 @@
+package registry_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// INTENTION: Registry errors should match the failure cause of their status code and error codes,
+// so callers can tell bad credentials, missing access, throttling and missing content apart.
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	causes := []error{
+		registry.ErrUnauthorized,
+		registry.ErrForbidden,
+		registry.ErrRateLimited,
+		registry.ErrNotFound,
+		registry.ErrBlobUnknown,
+		registry.ErrTagImmutable,
+	}
+
+	tests := []struct {
+		name string
+		err  *transport.Error
+		want []error
+	}{
+		{
+			name: "401",
+			err:  &transport.Error{StatusCode: http.StatusUnauthorized},
+			want: []error{registry.ErrUnauthorized},
+		},
+		{
+			name: "403",
+			err:  &transport.Error{StatusCode: http.StatusForbidden},
+			want: []error{registry.ErrForbidden},
+		},
+		{
+			name: "429",
+			err:  &transport.Error{StatusCode: http.StatusTooManyRequests},
+			want: []error{registry.ErrRateLimited},
+		},
+		{
+			name: "404",
+			err:  &transport.Error{StatusCode: http.StatusNotFound},
+			want: []error{registry.ErrNotFound},
+		},
+		{
+			name: "blob unknown",
+			err: &transport.Error{
+				StatusCode: http.StatusNotFound,
+				Errors:     []transport.Diagnostic{{Code: transport.BlobUnknownErrorCode}},
+			},
+			want: []error{registry.ErrBlobUnknown, registry.ErrNotFound},
+		},
+		{
+			name: "denied error code on another status",
+			err: &transport.Error{
+				StatusCode: http.StatusBadRequest,
+				Errors:     []transport.Diagnostic{{Code: transport.DeniedErrorCode}},
+			},
+			want: []error{registry.ErrForbidden},
+		},
+		{
+			name: "tag invalid",
+			err: &transport.Error{
+				StatusCode: http.StatusBadRequest,
+				Errors:     []transport.Diagnostic{{Code: transport.TagInvalidErrorCode}},
+			},
+			want: []error{registry.ErrTagImmutable},
+		},
+		{
+			name: "409",
+			err:  &transport.Error{StatusCode: http.StatusConflict},
+			want: []error{registry.ErrTagImmutable},
+		},
+		{
+			name: "server error",
+			err:  &transport.Error{StatusCode: http.StatusInternalServerError},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := registry.Classify(fmt.Errorf("wrapped: %w", tt.err))
+
+			for _, cause := range causes {
+				want := slices.Contains(tt.want, cause)
+
+				if got := errors.Is(err, cause); got != want {
+					t.Errorf("errors.Is(%v, %v) = %v, want %v", err, cause, got, want)
+				}
+			}
+
+			// The original error stays reachable and the message is unchanged
+			var transportErr *transport.Error
+			if !errors.As(err, &transportErr) {
+				t.Error("errors.As(*transport.Error) = false, want true")
+			}
+
+			if err.Error() != "wrapped: "+tt.err.Error() {
+				t.Errorf("Error() = %q, want %q", err.Error(), "wrapped: "+tt.err.Error())
+			}
+		})
+	}
+}
+
+// INTENTION: Errors without a registry response should be returned unchanged.
+func TestClassify_NotRegistryError(t *testing.T) {
+	t.Parallel()
+
+	err := errors.New("connection refused")
+
+	if got := registry.Classify(err); got != err { //nolint:errorlint // Identity is the point
+		t.Errorf("Classify() = %v, want the same error", got)
+	}
+
+	if registry.Classify(nil) != nil {
+		t.Error("Classify(nil) != nil")
+	}
+}
+
+// INTENTION: Errors returned by the client should carry their failure cause.
+func TestClient_ClassifiesErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		status  int
+		wantErr error
+	}{
+		{name: "unauthorized", status: http.StatusUnauthorized, wantErr: registry.ErrUnauthorized},
+		{name: "forbidden", status: http.StatusForbidden, wantErr: registry.ErrForbidden},
+		{name: "not found", status: http.StatusNotFound, wantErr: registry.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+				if req.URL.Path == "/v2/" {
+					writer.WriteHeader(http.StatusOK)
+
+					return
+				}
+
+				writer.WriteHeader(tt.status)
+			}))
+			t.Cleanup(server.Close)
+
+			host := strings.TrimPrefix(server.URL, "http://")
+			client := registry.NewClient(host, "", "", zerolog.Nop())
+
+			_, err := client.GetDigest(t.Context(), host+"/team/app:1.0")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetDigest() error = %v, want error wrapping %v", err, tt.wantErr)
+			}
+
+			if !errors.Is(err, registry.ErrGetImage) {
+				t.Errorf("GetDigest() error = %v, want error wrapping %v", err, registry.ErrGetImage)
+			}
+		})
+	}
+}
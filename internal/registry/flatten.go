@@ -0,0 +1,110 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// Flatten squashes the layers of image into a single layer holding its final filesystem (files deleted by upper
+// layers are gone, not hidden), keeping its config (environment, entrypoint, labels...) and manifest media types,
+// and pushes the flattened image to destRef with the client (the image can live on another registry).
+// Multi-platform images are flattened platform by platform; attestation manifests, which describe the original
+// image, are dropped. Images with a single layer are kept as is, so flattening again changes nothing: when no
+// platform needed flattening and destRef is the image itself, nothing is pushed.
+// The filesystem of each platform is staged in a temporary file. Returns the digest of the flattened image.
+func (client *Client) Flatten(ctx context.Context, image RemoteImage, destRef string) (string, error) {
+	staging, err := os.MkdirTemp("", "quark-flatten-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	defer func() {
+		_ = os.RemoveAll(staging)
+	}()
+
+	return client.transform(ctx, image, destRef, func(img v1.Image, _ *v1.Platform) (v1.Image, bool, error) {
+		return flattenImage(img, staging)
+	})
+}
+
+// flattenImage squashes the layers of img into one, staged in a file of the staging directory, keeping the
+// manifest media types of img. Images with at most one layer are returned unchanged (false).
+func flattenImage(img v1.Image, staging string) (v1.Image, bool, error) {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get image manifest: %w", err)
+	}
+
+	if len(manifest.Layers) <= 1 {
+		return img, false, nil
+	}
+
+	config, err := img.ConfigFile()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get image config: %w", err)
+	}
+
+	filesystem, err := os.CreateTemp(staging, "layer-*.tar")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create staging file: %w", err)
+	}
+
+	extracted := mutate.Extract(img)
+
+	_, err = io.Copy(filesystem, extracted)
+
+	_ = extracted.Close()
+	_ = filesystem.Close()
+
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to extract image filesystem: %w", err)
+	}
+
+	layerType := types.DockerLayer
+	if manifest.MediaType == types.OCIManifestSchema1 {
+		layerType = types.OCILayer
+	}
+
+	layer, err := tarball.LayerFromFile(filesystem.Name(), tarball.WithMediaType(layerType))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create flattened layer: %w", err)
+	}
+
+	// The config of the image, with the single layer and its history entry replacing those of the layers
+	flatConfig := config.DeepCopy()
+	flatConfig.RootFS.DiffIDs = nil
+	flatConfig.History = nil
+
+	flattened, err := mutate.ConfigFile(mutate.MediaType(empty.Image, manifest.MediaType), flatConfig)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to set image config: %w", err)
+	}
+
+	flattened, err = mutate.Append(flattened, mutate.Addendum{
+		Layer: layer,
+		History: v1.History{
+			Created:   config.Created,
+			CreatedBy: "quark flatten",
+			Comment:   fmt.Sprintf("flattened %d layers", len(manifest.Layers)),
+		},
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to append flattened layer: %w", err)
+	}
+
+	flattened = mutate.ConfigMediaType(flattened, manifest.Config.MediaType)
+
+	if len(manifest.Annotations) > 0 {
+		flattened, _ = mutate.Annotations(flattened, manifest.Annotations).(v1.Image)
+	}
+
+	return flattened, true, nil
+}
@@ -0,0 +1,140 @@
+package registry_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"log"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// tarLayer returns a layer holding the given files, by path.
+func tarLayer(t *testing.T, files map[string]string) v1.Layer {
+	t.Helper()
+
+	var content bytes.Buffer
+
+	writer := tar.NewWriter(&content)
+
+	for path, data := range files {
+		if err := writer.WriteHeader(&tar.Header{Name: path, Mode: 0o644, Size: int64(len(data))}); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+
+		if _, err := writer.Write([]byte(data)); err != nil {
+			t.Fatalf("Failed to write tar content: %v", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close tar: %v", err)
+	}
+
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(content.Bytes())), nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to create layer: %v", err)
+	}
+
+	return layer
+}
+
+// INTENTION: Flattening squashes the layers of an image into one holding its final filesystem (files deleted by
+// an upper layer are gone), keeps its config, and pushes it under the destination tag; flattening again is a
+// no-op.
+func TestClient_Flatten(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+
+	img, err := mutate.AppendLayers(empty.Image,
+		tarLayer(t, map[string]string{"app/config": "v1", "app/secret": "token"}),
+		tarLayer(t, map[string]string{"app/config": "v2", "app/.wh.secret": ""}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to append layers: %v", err)
+	}
+
+	config, err := img.ConfigFile()
+	if err != nil {
+		t.Fatalf("Failed to get config: %v", err)
+	}
+
+	config.Config.Env = []string{"APP_ENV=production"}
+	config.Config.Entrypoint = []string{"/app/run"}
+
+	img, err = mutate.ConfigFile(img, config)
+	if err != nil {
+		t.Fatalf("Failed to set config: %v", err)
+	}
+
+	if _, err := client.PushImage(t.Context(), host+"/test/app:1.0.0", img); err != nil {
+		t.Fatalf("PushImage() error = %v", err)
+	}
+
+	app := registry.RemoteImage{Ref: host + "/test/app:1.0.0", Client: client}
+
+	flattened, err := client.Flatten(t.Context(), app, host+"/test/app:1.0.0-flat")
+	if err != nil {
+		t.Fatalf("Flatten() error = %v", err)
+	}
+
+	flat, err := client.GetImageHandle(t.Context(), host+"/test/app@"+flattened)
+	if err != nil {
+		t.Fatalf("GetImageHandle() error = %v", err)
+	}
+
+	layers, err := flat.Layers()
+	if err != nil || len(layers) != 1 {
+		t.Fatalf("Layers() = %d, %v, want a single layer", len(layers), err)
+	}
+
+	flatConfig, err := flat.ConfigFile()
+	if err != nil {
+		t.Fatalf("Failed to get flattened config: %v", err)
+	}
+
+	if !slices.Equal(flatConfig.Config.Env, config.Config.Env) ||
+		!slices.Equal(flatConfig.Config.Entrypoint, config.Config.Entrypoint) {
+		t.Errorf("config = %v, want the config of the image %v", flatConfig.Config, config.Config)
+	}
+
+	files := map[string]string{}
+
+	reader := tar.NewReader(mutate.Extract(flat))
+	for {
+		header, err := reader.Next()
+		if err != nil {
+			break
+		}
+
+		data, _ := io.ReadAll(reader)
+		files[header.Name] = string(data)
+	}
+
+	if len(files) != 1 || files["app/config"] != "v2" {
+		t.Errorf("files = %v, want only app/config from the upper layer", files)
+	}
+
+	again := registry.RemoteImage{Ref: host + "/test/app:1.0.0-flat", Client: client}
+	if digest, err := client.Flatten(t.Context(), again, again.Ref); err != nil || digest != flattened {
+		t.Errorf("Flatten() again = %q, %v, want unchanged %q", digest, err, flattened)
+	}
+}
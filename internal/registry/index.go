@@ -0,0 +1,80 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/go-containerregistry/pkg/v1"
+)
+
+var (
+	// ErrNotSinglePlatform indicates an image combined into a manifest list that is a manifest list itself.
+	ErrNotSinglePlatform = errors.New("image is not a single-platform image")
+
+	// ErrDuplicatePlatform indicates two images combined into a manifest list that are of the same platform.
+	ErrDuplicatePlatform = errors.New("images are of the same platform")
+
+	// ErrPlatformUnknown indicates an image combined into a manifest list whose config has no OS or architecture.
+	ErrPlatformUnknown = errors.New("image config has no platform")
+)
+
+// CombineImages pushes to indexRef, with the client, a manifest list of the given single-platform images (they
+// can live on other registries), each listed under the platform of its config (e.g., linux/arm/v7): to publish
+// images tagged per architecture under one multi-platform tag. Fails with ErrNotSinglePlatform for a manifest
+// list, and with ErrDuplicatePlatform for images of the same platform. Returns the platforms (sorted) and the
+// digest of the manifest list, which only depends on the images.
+func (client *Client) CombineImages(ctx context.Context, images []RemoteImage, indexRef string) (
+	[]string, string, error,
+) {
+	platformImages := make(map[string]v1.Image, len(images))
+	refs := make(map[string]string, len(images))
+
+	for _, image := range images {
+		desc, err := image.Client.GetImage(ctx, image.Ref)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if desc.MediaType.IsIndex() {
+			return nil, "", fmt.Errorf("%w: %s", ErrNotSinglePlatform, image.Ref)
+		}
+
+		img, err := desc.Image()
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %w", ErrGetImage, err)
+		}
+
+		config, err := img.ConfigFile()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get image config of %s: %w", image.Ref, err)
+		}
+
+		if config.Platform() == nil {
+			return nil, "", fmt.Errorf("%w: %s", ErrPlatformUnknown, image.Ref)
+		}
+
+		platform := platformKey(config.Platform())
+		if previous, ok := refs[platform]; ok {
+			return nil, "", fmt.Errorf("%w: %s and %s are %s", ErrDuplicatePlatform, previous, image.Ref, platform)
+		}
+
+		platformImages[platform] = img
+		refs[platform] = image.Ref
+	}
+
+	digest, err := client.PushManifestList(ctx, indexRef, platformImages)
+	if err != nil {
+		return nil, "", err
+	}
+
+	platforms := make([]string, 0, len(platformImages))
+	for platform := range platformImages {
+		platforms = append(platforms, platform)
+	}
+
+	slices.Sort(platforms)
+
+	return platforms, digest, nil
+}
@@ -0,0 +1,89 @@
+package registry_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// INTENTION: Combining per-architecture images pushes a manifest list holding each of them under the platform of
+// its config; manifest lists and images of the same platform are refused.
+func TestClient_CombineImages(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+
+	for _, arch := range []string{"amd64", "arm64"} {
+		img, err := random.Image(64, 1)
+		if err != nil {
+			t.Fatalf("Failed to create image: %v", err)
+		}
+
+		config, err := img.ConfigFile()
+		if err != nil {
+			t.Fatalf("Failed to get config: %v", err)
+		}
+
+		config.OS = "linux"
+		config.Architecture = arch
+
+		img, err = mutate.ConfigFile(img, config)
+		if err != nil {
+			t.Fatalf("Failed to set config: %v", err)
+		}
+
+		if _, err := client.PushImage(t.Context(), host+"/test/app:1.0-"+arch, img); err != nil {
+			t.Fatalf("PushImage() error = %v", err)
+		}
+	}
+
+	amd64 := registry.RemoteImage{Ref: host + "/test/app:1.0-amd64", Client: client}
+	arm64 := registry.RemoteImage{Ref: host + "/test/app:1.0-arm64", Client: client}
+
+	images := []registry.RemoteImage{arm64, amd64}
+
+	platforms, digest, err := client.CombineImages(t.Context(), images, host+"/test/app:1.0")
+	if err != nil {
+		t.Fatalf("CombineImages() error = %v", err)
+	}
+
+	if want := []string{"linux/amd64", "linux/arm64"}; !slices.Equal(platforms, want) {
+		t.Errorf("CombineImages() platforms = %v, want %v", platforms, want)
+	}
+
+	pushed, err := client.GetPlatformDigests(t.Context(), host+"/test/app:1.0")
+	if err != nil || len(pushed) != 2 {
+		t.Fatalf("GetPlatformDigests() = %v, %v, want the two platforms", pushed, err)
+	}
+
+	if again, err := client.GetDigest(t.Context(), host+"/test/app:1.0"); err != nil || again != digest {
+		t.Errorf("GetDigest() = %q, %v, want the manifest list %q", again, err, digest)
+	}
+
+	index := registry.RemoteImage{Ref: host + "/test/app:1.0", Client: client}
+
+	_, _, err = client.CombineImages(t.Context(), []registry.RemoteImage{index}, host+"/test/app:1.0-all")
+	if !errors.Is(err, registry.ErrNotSinglePlatform) {
+		t.Errorf("CombineImages() of a manifest list error = %v, want %v", err, registry.ErrNotSinglePlatform)
+	}
+
+	_, _, err = client.CombineImages(t.Context(), []registry.RemoteImage{amd64, amd64}, host+"/test/app:1.0-twice")
+	if !errors.Is(err, registry.ErrDuplicatePlatform) {
+		t.Errorf("CombineImages() of one platform twice error = %v, want %v", err, registry.ErrDuplicatePlatform)
+	}
+}
@@ -0,0 +1,82 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// limiterContextKey is the context key under which a Limiter is stored.
+type limiterContextKey struct{}
+
+// Limiter bounds the number of requests in flight to each registry host it has a limit for, so that
+// parallel operations do not trip registry rate limits. A request holds its slot until its response
+// headers are received: uploads are limited for their whole transfer, download bodies are not (a sync
+// streaming layers between hosts sharing a limit could otherwise wait for itself).
+// It is safe for concurrent use.
+type Limiter struct {
+	slots map[string]chan struct{}
+}
+
+// NewLimiter creates a limiter from limits per registry host (e.g., {"ghcr.io": 3}).
+// Hosts are matched the way image references name them ("docker.io" limits "index.docker.io").
+// Limits of zero or less are ignored.
+func NewLimiter(limits map[string]int) *Limiter {
+	limiter := &Limiter{slots: make(map[string]chan struct{}, len(limits))}
+
+	for host, limit := range limits {
+		if limit <= 0 {
+			continue
+		}
+
+		if reg, err := name.NewRegistry(host); err == nil {
+			host = reg.RegistryStr()
+		}
+
+		limiter.slots[host] = make(chan struct{}, limit)
+	}
+
+	return limiter
+}
+
+// WithLimiter returns a context carrying the limiter.
+// Registry clients wait for a slot of the limiter before sending requests performed with this context.
+func WithLimiter(ctx context.Context, limiter *Limiter) context.Context {
+	return context.WithValue(ctx, limiterContextKey{}, limiter)
+}
+
+// limiterFromContext returns the limiter carried by ctx, or nil.
+func limiterFromContext(ctx context.Context) *Limiter {
+	limiter, _ := ctx.Value(limiterContextKey{}).(*Limiter)
+
+	return limiter
+}
+
+// limitedTransport is an http.RoundTripper waiting for a slot of its registry host before sending requests.
+type limitedTransport struct {
+	base    http.RoundTripper
+	limiter *Limiter
+}
+
+// RoundTrip implements http.RoundTripper.
+func (transport *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	slots, ok := transport.limiter.slots[registryHost(req)]
+	if !ok {
+		//nolint:wrapcheck // Transport errors are passed through unchanged
+		return transport.base.RoundTrip(req)
+	}
+
+	select {
+	case slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	defer func() {
+		<-slots
+	}()
+
+	//nolint:wrapcheck // Transport errors are passed through unchanged
+	return transport.base.RoundTrip(req)
+}
@@ -0,0 +1,123 @@
+package registry_test
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// INTENTION: With a limiter in the context, a registry host never has more requests in flight than its limit,
+// even when callers ask for more concurrency.
+func TestLimiter_BoundsRequestsInFlight(t *testing.T) {
+	t.Parallel()
+
+	var inFlight, peak atomic.Int64
+
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			previous := peak.Load()
+			if current <= previous || peak.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		handler.ServeHTTP(writer, req)
+	}))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+
+	if _, err := client.PushImage(t.Context(), host+"/test/app:1.0", img); err != nil {
+		t.Fatalf("PushImage() failed: %v", err)
+	}
+
+	refs := make([]string, 8)
+	for idx := range refs {
+		refs[idx] = host + "/test/app:1.0"
+	}
+
+	peak.Store(0)
+
+	ctx := registry.WithLimiter(t.Context(), registry.NewLimiter(map[string]int{host: 2}))
+
+	for _, result := range client.GetDigests(ctx, refs, len(refs)) {
+		if result.Err != nil {
+			t.Fatalf("GetDigests() error = %v", result.Err)
+		}
+	}
+
+	if got := peak.Load(); got > 2 {
+		t.Errorf("peak requests in flight = %d, want at most 2", got)
+	}
+}
+
+// INTENTION: Hosts without a limit are not slowed down by the limiter.
+func TestLimiter_IgnoresOtherHosts(t *testing.T) {
+	t.Parallel()
+
+	var inFlight, peak atomic.Int64
+
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		if current > peak.Load() {
+			peak.Store(current)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		handler.ServeHTTP(writer, req)
+	}))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+
+	if _, err := client.PushImage(t.Context(), host+"/test/app:1.0", img); err != nil {
+		t.Fatalf("PushImage() failed: %v", err)
+	}
+
+	refs := []string{host + "/test/app:1.0", host + "/test/app:1.0", host + "/test/app:1.0"}
+
+	peak.Store(0)
+
+	ctx := registry.WithLimiter(t.Context(), registry.NewLimiter(map[string]int{"ghcr.io": 1}))
+
+	for _, result := range client.GetDigests(ctx, refs, len(refs)) {
+		if result.Err != nil {
+			t.Fatalf("GetDigests() error = %v", result.Err)
+		}
+	}
+
+	if got := peak.Load(); got < 2 {
+		t.Errorf("peak requests in flight = %d, want concurrent requests", got)
+	}
+}
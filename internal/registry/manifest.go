@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// Helm chart OCI media types (see https://helm.sh/docs/topics/registries/).
+const (
+	// HelmChartConfigMediaType is the config media type of Helm charts stored in OCI registries.
+	HelmChartConfigMediaType = "application/vnd.cncf.helm.config.v1+json"
+	// HelmChartContentMediaType is the media type of the packaged chart layer.
+	HelmChartContentMediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+	// HelmChartProvenanceMediaType is the media type of the chart provenance (signature) layer.
+	HelmChartProvenanceMediaType = "application/vnd.cncf.helm.chart.provenance.v1.prov"
+)
+
+// ErrNotRunnableImage indicates an operation requiring a container image was given an artifact.
+var ErrNotRunnableImage = errors.New("reference is an OCI artifact, not a runnable container image")
+
+// ManifestInfo summarizes the media types of a manifest.
+// For an index, ConfigMediaType and LayerMediaTypes are empty.
+type ManifestInfo struct {
+	MediaType       string
+	ArtifactType    string
+	ConfigMediaType string
+	LayerMediaTypes []string
+}
+
+// IsHelmChart reports whether the manifest is a Helm chart.
+func (info ManifestInfo) IsHelmChart() bool {
+	return info.ConfigMediaType == HelmChartConfigMediaType
+}
+
+// IsArtifact reports whether the manifest describes an artifact (Helm chart, WASM module, signature,
+// attestation, SBOM, ...) rather than a runnable container image.
+// Artifacts declare an artifactType, or carry a config that is not a container image config.
+func (info ManifestInfo) IsArtifact() bool {
+	if info.ArtifactType != "" {
+		return true
+	}
+
+	if types.MediaType(info.MediaType).IsIndex() {
+		return false
+	}
+
+	switch types.MediaType(info.ConfigMediaType) {
+	case types.OCIConfigJSON, types.DockerConfigJSON, "":
+		return false
+	default:
+		return true
+	}
+}
+
+// Kind returns the artifact type of an artifact manifest (artifactType, or config media type
+// for artifacts pushed before OCI 1.1), or empty for container images.
+func (info ManifestInfo) Kind() string {
+	if !info.IsArtifact() {
+		return ""
+	}
+
+	if info.ArtifactType != "" {
+		return info.ArtifactType
+	}
+
+	return info.ConfigMediaType
+}
+
+// HasLayer reports whether the manifest has a layer of the given media type.
+func (info ManifestInfo) HasLayer(mediaType string) bool {
+	for _, layer := range info.LayerMediaTypes {
+		if layer == mediaType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseManifestInfo extracts media type information from a raw manifest or index.
+func ParseManifestInfo(rawManifest []byte) (ManifestInfo, error) {
+	var manifest struct {
+		MediaType    string `json:"mediaType"`
+		ArtifactType string `json:"artifactType"`
+		Config       struct {
+			MediaType string `json:"mediaType"`
+		} `json:"config"`
+		Layers []struct {
+			MediaType string `json:"mediaType"`
+		} `json:"layers"`
+	}
+
+	if err := json.Unmarshal(rawManifest, &manifest); err != nil {
+		return ManifestInfo{}, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+
+	info := ManifestInfo{
+		MediaType:       manifest.MediaType,
+		ArtifactType:    manifest.ArtifactType,
+		ConfigMediaType: manifest.Config.MediaType,
+		LayerMediaTypes: make([]string, 0, len(manifest.Layers)),
+	}
+
+	for _, layer := range manifest.Layers {
+		info.LayerMediaTypes = append(info.LayerMediaTypes, layer.MediaType)
+	}
+
+	return info, nil
+}
+
+// CheckRunnable returns ErrNotRunnableImage if the reference points to an artifact
+// (e.g., WASM module, signature, Helm chart) instead of a container image.
+// Operations that run or unpack images (scanning, auditing) call this to fail with a clear error.
+func (client *Client) CheckRunnable(ctx context.Context, imageRef string) error {
+	manifest, err := client.GetManifest(ctx, imageRef)
+	if err != nil {
+		return err
+	}
+
+	info, err := ParseManifestInfo(manifest.Raw)
+	if err != nil {
+		return err
+	}
+
+	// Manifests without media type field (legacy) are treated as images
+	if info.MediaType == "" {
+		info.MediaType = string(manifest.MediaType)
+	}
+
+	if info.IsArtifact() {
+		return fmt.Errorf("%w: %s (%s)", ErrNotRunnableImage, imageRef, info.Kind())
+	}
+
+	return nil
+}
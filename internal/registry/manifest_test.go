@@ -0,0 +1,120 @@
+package registry_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// INTENTION: Container images are told apart from artifacts by artifactType and config media type.
+func TestParseManifestInfo_IsArtifact(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		manifest     string
+		wantArtifact bool
+		wantKind     string
+	}{
+		{
+			name: "oci image",
+			manifest: `{"mediaType":"application/vnd.oci.image.manifest.v1+json",` +
+				`"config":{"mediaType":"application/vnd.oci.image.config.v1+json"}}`,
+			wantArtifact: false,
+		},
+		{
+			name: "docker image",
+			manifest: `{"mediaType":"application/vnd.docker.distribution.manifest.v2+json",` +
+				`"config":{"mediaType":"application/vnd.docker.container.image.v1+json"}}`,
+			wantArtifact: false,
+		},
+		{
+			name:         "image index",
+			manifest:     `{"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[]}`,
+			wantArtifact: false,
+		},
+		{
+			name: "oci 1.1 artifact",
+			manifest: `{"mediaType":"application/vnd.oci.image.manifest.v1+json",` +
+				`"artifactType":"application/vnd.dev.cosign.artifact.sig.v1+json",` +
+				`"config":{"mediaType":"application/vnd.oci.empty.v1+json"}}`,
+			wantArtifact: true,
+			wantKind:     "application/vnd.dev.cosign.artifact.sig.v1+json",
+		},
+		{
+			name: "wasm module (config media type)",
+			manifest: `{"mediaType":"application/vnd.oci.image.manifest.v1+json",` +
+				`"config":{"mediaType":"application/vnd.wasm.config.v0+json"}}`,
+			wantArtifact: true,
+			wantKind:     "application/vnd.wasm.config.v0+json",
+		},
+		{
+			name: "artifact index",
+			manifest: `{"mediaType":"application/vnd.oci.image.index.v1+json",` +
+				`"artifactType":"application/vnd.example.bundle","manifests":[]}`,
+			wantArtifact: true,
+			wantKind:     "application/vnd.example.bundle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			info, err := registry.ParseManifestInfo([]byte(tt.manifest))
+			if err != nil {
+				t.Fatalf("ParseManifestInfo() error = %v", err)
+			}
+
+			if info.IsArtifact() != tt.wantArtifact {
+				t.Errorf("IsArtifact() = %v, want %v", info.IsArtifact(), tt.wantArtifact)
+			}
+
+			if info.Kind() != tt.wantKind {
+				t.Errorf("Kind() = %q, want %q", info.Kind(), tt.wantKind)
+			}
+		})
+	}
+}
+
+// INTENTION: Operations needing a runnable image get a clear error for artifacts, and pass for images.
+func TestClient_CheckRunnable(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+
+	if _, err := client.PushImage(t.Context(), host+"/test/image:1", img); err != nil {
+		t.Fatalf("PushImage() failed: %v", err)
+	}
+
+	if _, err := client.PushArtifact(t.Context(), host+"/test/module:1", "application/vnd.wasm.content.layer.v1+wasm",
+		[]registry.ArtifactBlob{{MediaType: "application/wasm", Data: []byte("\x00asm")}}, nil); err != nil {
+		t.Fatalf("PushArtifact() failed: %v", err)
+	}
+
+	if err := client.CheckRunnable(t.Context(), host+"/test/image:1"); err != nil {
+		t.Errorf("CheckRunnable(image) error = %v, want nil", err)
+	}
+
+	if err := client.CheckRunnable(t.Context(), host+"/test/module:1"); !errors.Is(err, registry.ErrNotRunnableImage) {
+		t.Errorf("CheckRunnable(artifact) error = %v, want %v", err, registry.ErrNotRunnableImage)
+	}
+}
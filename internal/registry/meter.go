@@ -0,0 +1,142 @@
+package registry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// meterContextKey is the context key under which a Meter is stored.
+type meterContextKey struct{}
+
+// Traffic records bytes transferred with a single registry host.
+type Traffic struct {
+	Host       string
+	Downloaded int64 // Response body bytes received
+	Uploaded   int64 // Request body bytes sent
+}
+
+// Meter accumulates registry traffic per host.
+// It is safe for concurrent use.
+type Meter struct {
+	traffic map[string]*Traffic
+	mu      sync.Mutex
+}
+
+// NewMeter creates a new traffic meter.
+func NewMeter() *Meter {
+	return &Meter{
+		traffic: make(map[string]*Traffic),
+	}
+}
+
+// WithMeter returns a context carrying the meter.
+// Registry clients record all HTTP traffic performed with this context into the meter.
+func WithMeter(ctx context.Context, meter *Meter) context.Context {
+	return context.WithValue(ctx, meterContextKey{}, meter)
+}
+
+// meterFromContext returns the meter carried by ctx, or nil.
+func meterFromContext(ctx context.Context) *Meter {
+	meter, _ := ctx.Value(meterContextKey{}).(*Meter)
+
+	return meter
+}
+
+// Traffic returns the recorded traffic, sorted by host.
+func (meter *Meter) Traffic() []Traffic {
+	meter.mu.Lock()
+	defer meter.mu.Unlock()
+
+	result := make([]Traffic, 0, len(meter.traffic))
+	for _, traffic := range meter.traffic {
+		result = append(result, *traffic)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Host < result[j].Host
+	})
+
+	return result
+}
+
+// add records transferred bytes for host.
+func (meter *Meter) add(host string, downloaded, uploaded int64) {
+	meter.mu.Lock()
+	defer meter.mu.Unlock()
+
+	traffic, ok := meter.traffic[host]
+	if !ok {
+		traffic = &Traffic{Host: host}
+		meter.traffic[host] = traffic
+	}
+
+	traffic.Downloaded += downloaded
+	traffic.Uploaded += uploaded
+}
+
+// meteredTransport is an http.RoundTripper recording body bytes into a Meter.
+type meteredTransport struct {
+	base  http.RoundTripper
+	meter *Meter
+}
+
+// RoundTrip implements http.RoundTripper.
+func (transport *meteredTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := registryHost(req)
+
+	if req.Body != nil {
+		req.Body = &countingReadCloser{
+			ReadCloser: req.Body,
+			count: func(n int64) {
+				transport.meter.add(host, 0, n)
+			},
+		}
+	}
+
+	resp, err := transport.base.RoundTrip(req)
+	if err != nil {
+		//nolint:wrapcheck // Transport errors are passed through unchanged
+		return resp, err
+	}
+
+	resp.Body = &countingReadCloser{
+		ReadCloser: resp.Body,
+		count: func(n int64) {
+			transport.meter.add(host, n, 0)
+		},
+	}
+
+	return resp, nil
+}
+
+// registryHost returns the host of the request that started a redirect chain.
+// Registries commonly redirect blob downloads to storage/CDN hosts; that traffic
+// is attributed to the registry the client talked to.
+func registryHost(req *http.Request) string {
+	for req.Response != nil && req.Response.Request != nil {
+		req = req.Response.Request
+	}
+
+	return req.URL.Host
+}
+
+// countingReadCloser reports the number of bytes read through it.
+type countingReadCloser struct {
+	io.ReadCloser
+
+	count func(n int64)
+}
+
+// Read implements io.Reader.
+func (reader *countingReadCloser) Read(buf []byte) (int, error) {
+	n, err := reader.ReadCloser.Read(buf)
+	if n > 0 {
+		reader.count(int64(n))
+	}
+
+	//nolint:wrapcheck // io.EOF must be returned unwrapped
+	return n, err
+}
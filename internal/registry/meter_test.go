@@ -0,0 +1,59 @@
+package registry_test
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// INTENTION: Traffic performed with a metered context is attributed to the registry host.
+func TestMeter_RecordsTrafficPerHost(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+
+	img, err := random.Image(4096, 2)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+
+	meter := registry.NewMeter()
+	ctx := registry.WithMeter(t.Context(), meter)
+
+	if _, err := client.PushImage(ctx, host+"/test/app:1.0", img); err != nil {
+		t.Fatalf("PushImage() failed: %v", err)
+	}
+
+	if _, err := client.GetDigest(ctx, host+"/test/app:1.0"); err != nil {
+		t.Fatalf("GetDigest() failed: %v", err)
+	}
+
+	traffic := meter.Traffic()
+	if len(traffic) != 1 {
+		t.Fatalf("Traffic() = %v, want a single host", traffic)
+	}
+
+	if traffic[0].Host != host {
+		t.Errorf("Host = %q, want %q", traffic[0].Host, host)
+	}
+
+	if traffic[0].Uploaded < 2*4096 {
+		t.Errorf("Uploaded = %d, want at least the layer bytes", traffic[0].Uploaded)
+	}
+
+	if traffic[0].Downloaded == 0 {
+		t.Error("Downloaded = 0, want manifest bytes")
+	}
+}
@@ -0,0 +1,44 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+)
+
+// ConfigChange changes the config of an image: its runtime config (environment, labels, entrypoint, user...),
+// creation time or history. The layers (root filesystem) must be left as they are.
+type ConfigChange func(config *v1.ConfigFile)
+
+// MutateConfig applies change to the config of image, keeping its layers and manifest media types, and pushes the
+// result to destRef with the client (the image can live on another registry). Multi-platform images are changed
+// platform by platform; attestation manifests, which describe the original image, are dropped. Platforms the
+// change leaves as they are are kept, so applying the change again changes nothing: when no platform changed and
+// destRef is the image itself, nothing is pushed. Returns the digest of the mutated image.
+func (client *Client) MutateConfig(ctx context.Context, image RemoteImage, destRef string, change ConfigChange) (
+	string, error,
+) {
+	return client.transform(ctx, image, destRef, func(img v1.Image, _ *v1.Platform) (v1.Image, bool, error) {
+		config, err := img.ConfigFile()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get image config: %w", err)
+		}
+
+		mutated := config.DeepCopy()
+		change(mutated)
+
+		if reflect.DeepEqual(mutated, config) {
+			return img, false, nil
+		}
+
+		result, err := mutate.ConfigFile(img, mutated)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to set image config: %w", err)
+		}
+
+		return result, true, nil
+	})
+}
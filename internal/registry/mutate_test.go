@@ -0,0 +1,86 @@
+package registry_test
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// INTENTION: Mutating the config of a multi-platform image changes the config of every platform, keeping the
+// layers; applying the same change again is a no-op.
+func TestClient_MutateConfig(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+
+	images := map[string]v1.Image{"amd64": platformImage(t, "amd64", 2), "arm64": platformImage(t, "arm64", 2)}
+
+	var idx v1.ImageIndex = empty.Index
+	for arch, img := range images {
+		idx = mutate.AppendManifests(idx, mutate.IndexAddendum{
+			Add:        img,
+			Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: arch}},
+		})
+	}
+
+	if _, err := client.PushIndex(t.Context(), host+"/test/app:1.0.0", idx); err != nil {
+		t.Fatalf("PushIndex() error = %v", err)
+	}
+
+	change := func(config *v1.ConfigFile) {
+		config.Config.User = "nonroot"
+		config.Config.Env = []string{"APP_ENV=production"}
+	}
+
+	app := registry.RemoteImage{Ref: host + "/test/app:1.0.0", Client: client}
+
+	mutated, err := client.MutateConfig(t.Context(), app, app.Ref, change)
+	if err != nil {
+		t.Fatalf("MutateConfig() error = %v", err)
+	}
+
+	platforms, err := client.GetPlatformDigests(t.Context(), host+"/test/app@"+mutated)
+	if err != nil || len(platforms) != 2 {
+		t.Fatalf("GetPlatformDigests() = %v, %v, want two platforms", platforms, err)
+	}
+
+	for platform, digest := range platforms {
+		img, err := client.GetImageHandle(t.Context(), host+"/test/app@"+digest)
+		if err != nil {
+			t.Fatalf("GetImageHandle() error = %v", err)
+		}
+
+		config, err := img.ConfigFile()
+		if err != nil {
+			t.Fatalf("Failed to get config: %v", err)
+		}
+
+		if config.Config.User != "nonroot" || !slices.Equal(config.Config.Env, []string{"APP_ENV=production"}) {
+			t.Errorf("%s config = %+v, want the changed user and environment", platform, config.Config)
+		}
+
+		arch := strings.TrimPrefix(platform, "linux/")
+		if got, want := layerDigests(t, img), layerDigests(t, images[arch]); !slices.Equal(got, want) {
+			t.Errorf("%s layers = %v, want unchanged %v", platform, got, want)
+		}
+	}
+
+	if digest, err := client.MutateConfig(t.Context(), app, app.Ref, change); err != nil || digest != mutated {
+		t.Errorf("MutateConfig() again = %q, %v, want unchanged %q", digest, err, mutated)
+	}
+}
@@ -0,0 +1,114 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// ManifestSummary describes a manifest of a repository for retention.
+type ManifestSummary struct {
+	Digest string
+	// Created is the config creation time of the image, the newest of its platform images for a manifest list
+	// (zero when no config records it).
+	Created time.Time
+	// Children are the digests of the manifests a manifest list references.
+	Children []string
+}
+
+// SummarizeManifest returns the digest of the manifest imageRef points to, the creation time of its image and,
+// for a manifest list, the manifests it references.
+func (client *Client) SummarizeManifest(ctx context.Context, imageRef string) (ManifestSummary, error) {
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return ManifestSummary{}, fmt.Errorf("%w: %w", ErrParseImageReference, err)
+	}
+
+	desc, err := authenticated(ctx, client, func(opts []remote.Option) (*remote.Descriptor, error) {
+		return remote.Get(ref, opts...)
+	})
+	if err != nil {
+		return ManifestSummary{}, fmt.Errorf("%w: %w", ErrGetImage, Classify(err))
+	}
+
+	summary := ManifestSummary{Digest: desc.Digest.String()}
+
+	var images []v1.Image
+
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return ManifestSummary{}, fmt.Errorf("%w: %w", ErrGetImageIndex, err)
+		}
+
+		manifest, err := idx.IndexManifest()
+		if err != nil {
+			return ManifestSummary{}, fmt.Errorf("%w: %w", ErrGetImageIndex, err)
+		}
+
+		for _, child := range manifest.Manifests {
+			summary.Children = append(summary.Children, child.Digest.String())
+
+			// Attestation manifests and nested indexes have no image config
+			if !child.MediaType.IsImage() || child.Platform == nil || child.Platform.OS == "unknown" {
+				continue
+			}
+
+			img, err := idx.Image(child.Digest)
+			if err != nil {
+				return ManifestSummary{}, fmt.Errorf("%w: %w", ErrGetImage, Classify(err))
+			}
+
+			images = append(images, img)
+		}
+	} else {
+		img, err := desc.Image()
+		if err != nil {
+			return ManifestSummary{}, fmt.Errorf("%w: %w", ErrGetImage, err)
+		}
+
+		images = append(images, img)
+	}
+
+	for _, img := range images {
+		config, err := img.ConfigFile()
+		if err != nil {
+			return ManifestSummary{}, fmt.Errorf("failed to read image config: %w", Classify(err))
+		}
+
+		if config.Created.After(summary.Created) {
+			summary.Created = config.Created.Time
+		}
+	}
+
+	return summary, nil
+}
+
+// DeleteManifest deletes the manifest digestRef points to (e.g., "ghcr.io/org/app@sha256:..."), and with it
+// every tag pointing to it, the tags given forgotten by the manifest cache.
+func (client *Client) DeleteManifest(ctx context.Context, digestRef string, tags ...string) error {
+	ref, err := name.NewDigest(digestRef)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrParseImageReference, err)
+	}
+
+	client.log.Debug().Str("digest", digestRef).Strs("tags", tags).Msg("deleting manifest")
+
+	invalidateCache(ctx, ref)
+
+	for _, tag := range tags {
+		invalidateCache(ctx, ref.Context().Tag(tag))
+	}
+
+	if err := authenticatedDo(ctx, client, func(opts []remote.Option) error {
+		return remote.Delete(ref, opts...)
+	}); err != nil {
+		return fmt.Errorf("failed to delete manifest: %w", Classify(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+var (
+	// ErrRebasePlatformMissing indicates a platform of the image the old or new base image does not provide.
+	ErrRebasePlatformMissing = errors.New("base image does not provide the platform")
+
+	// ErrNotBasedOn indicates an image built on neither the old nor the new base image of a rebase.
+	ErrNotBasedOn = errors.New("image is not based on the old base image")
+)
+
+// Rebase replaces the layers of oldBase at the bottom of image with the layers of newBase, keeping the layers,
+// config and history of the image above them, and pushes the rebased image to destRef with the client (the images
+// can live on other registries). The layers of the image are not rebuilt: only the manifests and configs change.
+// Multi-platform images are rebased platform by platform onto the platform manifest of each base image
+// (single-platform bases apply to every platform); attestation manifests, which describe the original image,
+// are dropped. Platforms already based on newBase are kept as is, so rebasing again changes nothing: when no
+// platform needed a rebase and destRef is the image itself, nothing is pushed.
+// Fails with ErrNotBasedOn if the image is based on neither. Returns the digest of the rebased image.
+func (client *Client) Rebase(ctx context.Context, image, oldBase, newBase RemoteImage, destRef string) (
+	string, error,
+) {
+	oldDesc, err := oldBase.Client.GetImage(ctx, oldBase.Ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to get old base image: %w", err)
+	}
+
+	newDesc, err := newBase.Client.GetImage(ctx, newBase.Ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to get new base image: %w", err)
+	}
+
+	return client.transform(ctx, image, destRef, func(img v1.Image, platform *v1.Platform) (v1.Image, bool, error) {
+		return rebaseImage(img, &oldDesc, &newDesc, platform)
+	})
+}
+
+// rebaseImage rebases img (of the given platform, read from its config when nil) from oldBase onto newBase,
+// keeping the manifest media types of img. Images already based on newBase are returned unchanged (false).
+func rebaseImage(img v1.Image, oldBase, newBase *remote.Descriptor, platform *v1.Platform) (v1.Image, bool, error) {
+	if platform == nil {
+		config, err := img.ConfigFile()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get image config: %w", err)
+		}
+
+		platform = config.Platform()
+	}
+
+	oldImg, err := platformImage(oldBase, platform)
+	if err != nil {
+		return nil, false, fmt.Errorf("old base image: %w", err)
+	}
+
+	newImg, err := platformImage(newBase, platform)
+	if err != nil {
+		return nil, false, fmt.Errorf("new base image: %w", err)
+	}
+
+	onOld, err := basedOn(img, oldImg)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !onOld {
+		onNew, err := basedOn(img, newImg)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if !onNew {
+			return nil, false, ErrNotBasedOn
+		}
+
+		return img, false, nil
+	}
+
+	rebased, err := mutate.Rebase(img, oldImg, newImg)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to rebase image: %w", err)
+	}
+
+	// Rebased images start from an empty Docker image: OCI images stay OCI
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get image manifest: %w", err)
+	}
+
+	rebased = mutate.ConfigMediaType(mutate.MediaType(rebased, manifest.MediaType), manifest.Config.MediaType)
+
+	if len(manifest.Annotations) > 0 {
+		rebased, _ = mutate.Annotations(rebased, manifest.Annotations).(v1.Image)
+	}
+
+	return rebased, true, nil
+}
+
+// basedOn reports whether the bottom layers of img are the layers of base.
+func basedOn(img, base v1.Image) (bool, error) {
+	layers, err := img.Manifest()
+	if err != nil {
+		return false, fmt.Errorf("failed to get image manifest: %w", err)
+	}
+
+	baseLayers, err := base.Manifest()
+	if err != nil {
+		return false, fmt.Errorf("failed to get base image manifest: %w", err)
+	}
+
+	if len(baseLayers.Layers) > len(layers.Layers) {
+		return false, nil
+	}
+
+	for idx, layer := range baseLayers.Layers {
+		if layers.Layers[idx].Digest != layer.Digest {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// platformImage returns the image of desc for platform: desc itself when it is a single-platform image, its
+// platform manifest otherwise.
+func platformImage(desc *remote.Descriptor, platform *v1.Platform) (v1.Image, error) {
+	if !desc.MediaType.IsIndex() {
+		img, err := desc.Image()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrGetImage, err)
+		}
+
+		return img, nil
+	}
+
+	idx, err := desc.ImageIndex()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrGetImageIndex, err)
+	}
+
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index manifest: %w", err)
+	}
+
+	for _, child := range manifest.Manifests {
+		if platform != nil && child.Platform != nil && platformKey(child.Platform) == platformKey(platform) {
+			img, err := idx.Image(child.Digest)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrGetImage, err)
+			}
+
+			return img, nil
+		}
+	}
+
+	if platform == nil {
+		return nil, fmt.Errorf("%w: the image has no platform", ErrRebasePlatformMissing)
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrRebasePlatformMissing, platformKey(platform))
+}
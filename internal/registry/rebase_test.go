@@ -0,0 +1,195 @@
+package registry_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// platformImage returns a random image of the given architecture.
+func platformImage(t *testing.T, arch string, layers int64) v1.Image {
+	t.Helper()
+
+	img, err := random.Image(64, layers)
+	if err != nil {
+		t.Fatalf("Failed to create image: %v", err)
+	}
+
+	config, err := img.ConfigFile()
+	if err != nil {
+		t.Fatalf("Failed to get config: %v", err)
+	}
+
+	config.OS, config.Architecture = "linux", arch
+
+	img, err = mutate.ConfigFile(img, config)
+	if err != nil {
+		t.Fatalf("Failed to set config: %v", err)
+	}
+
+	return img
+}
+
+// layerDigests returns the layer digests of img.
+func layerDigests(t *testing.T, img v1.Image) []v1.Hash {
+	t.Helper()
+
+	layers, err := img.Layers()
+	if err != nil {
+		t.Fatalf("Failed to get layers: %v", err)
+	}
+
+	digests := make([]v1.Hash, 0, len(layers))
+
+	for _, layer := range layers {
+		digest, err := layer.Digest()
+		if err != nil {
+			t.Fatalf("Failed to get layer digest: %v", err)
+		}
+
+		digests = append(digests, digest)
+	}
+
+	return digests
+}
+
+// INTENTION: Rebasing a multi-platform image swaps the old base layers of each platform for the layers of the
+// new base of the same platform, keeping the application layers, even when the bases live on another registry.
+// Rebasing again is a no-op; an image built on neither base is refused, as is a platform the new base lacks.
+func TestClient_Rebase(t *testing.T) {
+	t.Parallel()
+
+	quiet := ggcrregistry.Logger(log.New(io.Discard, "", 0))
+
+	server := httptest.NewServer(ggcrregistry.New(quiet))
+	t.Cleanup(server.Close)
+
+	baseServer := httptest.NewServer(ggcrregistry.New(quiet))
+	t.Cleanup(baseServer.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	baseHost := strings.TrimPrefix(baseServer.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+	baseClient := registry.NewClient(baseHost, "", "", zerolog.Nop())
+
+	index := func(images map[string]v1.Image) v1.ImageIndex {
+		var idx v1.ImageIndex = empty.Index
+		for arch, img := range images {
+			idx = mutate.AppendManifests(idx, mutate.IndexAddendum{
+				Add:        img,
+				Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: arch}},
+			})
+		}
+
+		return idx
+	}
+
+	oldBases := map[string]v1.Image{"amd64": platformImage(t, "amd64", 2), "arm64": platformImage(t, "arm64", 2)}
+	newBases := map[string]v1.Image{"amd64": platformImage(t, "amd64", 3), "arm64": platformImage(t, "arm64", 3)}
+	apps := make(map[string]v1.Image)
+	appLayers := make(map[string]v1.Layer)
+
+	for arch, base := range oldBases {
+		layer, err := random.Layer(64, "application/vnd.docker.image.rootfs.diff.tar.gzip")
+		if err != nil {
+			t.Fatalf("Failed to create layer: %v", err)
+		}
+
+		app, err := mutate.AppendLayers(base, layer)
+		if err != nil {
+			t.Fatalf("Failed to append layer: %v", err)
+		}
+
+		apps[arch], appLayers[arch] = app, layer
+	}
+
+	pushes := map[string]v1.ImageIndex{
+		baseHost + "/library/base:1.0": index(oldBases),
+		baseHost + "/library/base:1.1": index(newBases),
+		host + "/test/app:1.0.0":       index(apps),
+	}
+
+	for ref, idx := range pushes {
+		pushClient := client
+		if strings.HasPrefix(ref, baseHost) {
+			pushClient = baseClient
+		}
+
+		if _, err := pushClient.PushIndex(t.Context(), ref, idx); err != nil {
+			t.Fatalf("PushIndex(%s) error = %v", ref, err)
+		}
+	}
+
+	oldBase := registry.RemoteImage{Ref: baseHost + "/library/base:1.0", Client: baseClient}
+	newBase := registry.RemoteImage{Ref: baseHost + "/library/base:1.1", Client: baseClient}
+
+	app := registry.RemoteImage{Ref: host + "/test/app:1.0.0", Client: client}
+
+	rebased, err := client.Rebase(t.Context(), app, oldBase, newBase, host+"/test/app:1.0.1")
+	if err != nil {
+		t.Fatalf("Rebase() error = %v", err)
+	}
+
+	platforms, err := client.GetPlatformDigests(t.Context(), host+"/test/app@"+rebased)
+	if err != nil || len(platforms) != 2 {
+		t.Fatalf("GetPlatformDigests() = %v, %v, want two platforms", platforms, err)
+	}
+
+	for platform, digest := range platforms {
+		arch := strings.TrimPrefix(platform, "linux/")
+
+		img, err := client.GetImageHandle(t.Context(), host+"/test/app@"+digest)
+		if err != nil {
+			t.Fatalf("GetImageHandle() error = %v", err)
+		}
+
+		appLayer, err := appLayers[arch].Digest()
+		if err != nil {
+			t.Fatalf("Failed to get layer digest: %v", err)
+		}
+
+		want := append(layerDigests(t, newBases[arch]), appLayer)
+		if got := layerDigests(t, img); !slices.Equal(got, want) {
+			t.Errorf("%s layers = %v, want %v", platform, got, want)
+		}
+	}
+
+	// Rebasing again the rebased image changes nothing
+	again := registry.RemoteImage{Ref: host + "/test/app:1.0.1", Client: client}
+	if digest, err := client.Rebase(t.Context(), again, oldBase, newBase, again.Ref); err != nil || digest != rebased {
+		t.Errorf("Rebase() again = %q, %v, want unchanged %q", digest, err, rebased)
+	}
+
+	other := registry.RemoteImage{Ref: host + "/test/other:1.0.0", Client: client}
+	if _, err := client.PushIndex(t.Context(), other.Ref, index(newBases)); err != nil {
+		t.Fatalf("PushIndex() error = %v", err)
+	}
+
+	if _, err := client.Rebase(t.Context(), other, app, oldBase, other.Ref); !errors.Is(err, registry.ErrNotBasedOn) {
+		t.Errorf("Rebase() of an unrelated image error = %v, want %v", err, registry.ErrNotBasedOn)
+	}
+
+	if _, err := baseClient.PushIndex(t.Context(), baseHost+"/library/base:amd64",
+		index(map[string]v1.Image{"amd64": newBases["amd64"]})); err != nil {
+		t.Fatalf("PushIndex() error = %v", err)
+	}
+
+	amd64 := registry.RemoteImage{Ref: baseHost + "/library/base:amd64", Client: baseClient}
+	_, err = client.Rebase(t.Context(), app, oldBase, amd64, host+"/test/app:1.0.2")
+	if !errors.Is(err, registry.ErrRebasePlatformMissing) {
+		t.Errorf("Rebase() onto a base without arm64 error = %v, want %v", err, registry.ErrRebasePlatformMissing)
+	}
+}
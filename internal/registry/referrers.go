@@ -0,0 +1,77 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// NotationSignatureArtifactType is the artifact type of Notary Project (notation) signatures, stored as OCI
+// referrers of the manifest they sign.
+const NotationSignatureArtifactType = "application/vnd.cncf.notary.signature"
+
+// CopyReferrers copies the manifests of the given artifactType referring to subjectRef (a digest reference)
+// to the repository of dstRef, on dstClient, so they refer to the same digest there.
+// Registries without the referrers API are supported on both sides (referrers tag schema).
+// Returns the digests of the copied manifests, which are unchanged.
+func (client *Client) CopyReferrers(
+	ctx context.Context,
+	subjectRef, dstRef, artifactType string,
+	dstClient *Client,
+) ([]string, error) {
+	subject, err := name.NewDigest(subjectRef)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParseSourceReference, err)
+	}
+
+	dst, err := name.ParseReference(dstRef)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParseDestinationReference, err)
+	}
+
+	idx, err := authenticated(ctx, client, func(opts []remote.Option) (v1.ImageIndex, error) {
+		return remote.Referrers(subject, append(opts, remote.WithFilter("artifactType", artifactType))...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list referrers: %w", Classify(err))
+	}
+
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read referrers: %w", err)
+	}
+
+	digests := make([]string, 0, len(manifest.Manifests))
+
+	for _, desc := range manifest.Manifests {
+		srcRef := subject.Context().Digest(desc.Digest.String())
+
+		// Fetched by digest: the raw manifest, and its subject, are pushed unchanged
+		referrer, err := authenticated(ctx, client, func(opts []remote.Option) (v1.Image, error) {
+			return remote.Image(srcRef, opts...)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get referrer %s: %w", desc.Digest, Classify(err))
+		}
+
+		// Writing a manifest with a subject also updates the referrers tag of registries without referrers API
+		if err := authenticatedDo(ctx, dstClient, func(opts []remote.Option) error {
+			return remote.Write(dst.Context().Digest(desc.Digest.String()), referrer, opts...)
+		}); err != nil {
+			return nil, fmt.Errorf("failed to write referrer %s: %w", desc.Digest, Classify(err))
+		}
+
+		client.log.Debug().
+			Str("subject", subjectRef).
+			Str("referrer", desc.Digest.String()).
+			Str("artifact_type", artifactType).
+			Msg("referrer copied")
+
+		digests = append(digests, desc.Digest.String())
+	}
+
+	return digests, nil
+}
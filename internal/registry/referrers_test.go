@@ -0,0 +1,139 @@
+package registry_test
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// pushReferrer pushes an artifact of configMediaType referring to subject, and returns its digest.
+func pushReferrer(t *testing.T, repository string, subject v1.Image, configMediaType types.MediaType) string {
+	t.Helper()
+
+	subjectDigest, err := subject.Digest()
+	if err != nil {
+		t.Fatalf("Failed to get subject digest: %v", err)
+	}
+
+	subjectManifest, err := subject.RawManifest()
+	if err != nil {
+		t.Fatalf("Failed to get subject manifest: %v", err)
+	}
+
+	mediaType, err := subject.MediaType()
+	if err != nil {
+		t.Fatalf("Failed to get subject media type: %v", err)
+	}
+
+	artifact, ok := mutate.Subject(
+		mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), configMediaType),
+		v1.Descriptor{MediaType: mediaType, Digest: subjectDigest, Size: int64(len(subjectManifest))},
+	).(v1.Image)
+	if !ok {
+		t.Fatal("referrer is not an image")
+	}
+
+	digest, err := artifact.Digest()
+	if err != nil {
+		t.Fatalf("Failed to get referrer digest: %v", err)
+	}
+
+	ref, err := name.NewDigest(repository + "@" + digest.String())
+	if err != nil {
+		t.Fatalf("Failed to parse referrer reference: %v", err)
+	}
+
+	if err := remote.Write(ref, artifact); err != nil {
+		t.Fatalf("Failed to push referrer: %v", err)
+	}
+
+	return digest.String()
+}
+
+// INTENTION: Referrers of the requested artifact type are copied unchanged, so they still refer to the subject
+// at the destination, including between registries without the referrers API (referrers tag schema).
+func TestClient_CopyReferrers(t *testing.T) {
+	t.Parallel()
+
+	for _, referrersAPI := range []bool{true, false} {
+		options := []ggcrregistry.Option{ggcrregistry.Logger(log.New(io.Discard, "", 0))}
+		if referrersAPI {
+			options = append(options, ggcrregistry.WithReferrersSupport(true))
+		}
+
+		server := httptest.NewServer(ggcrregistry.New(options...))
+		t.Cleanup(server.Close)
+
+		host := strings.TrimPrefix(server.URL, "http://")
+
+		subject, err := random.Image(256, 1)
+		if err != nil {
+			t.Fatalf("Failed to create random image: %v", err)
+		}
+
+		subjectDigest, err := subject.Digest()
+		if err != nil {
+			t.Fatalf("Failed to get subject digest: %v", err)
+		}
+
+		for _, repository := range []string{"/source/app:1.0", "/mirror/app:1.0"} {
+			ref, err := name.ParseReference(host + repository)
+			if err != nil {
+				t.Fatalf("Failed to parse reference: %v", err)
+			}
+
+			if err := remote.Write(ref, subject); err != nil {
+				t.Fatalf("Failed to push subject: %v", err)
+			}
+		}
+
+		signature := pushReferrer(t, host+"/source/app", subject, registry.NotationSignatureArtifactType)
+		pushReferrer(t, host+"/source/app", subject, "application/vnd.example.sbom")
+
+		client := registry.NewClient(host, "", "", zerolog.Nop())
+
+		copied, err := client.CopyReferrers(t.Context(), host+"/source/app@"+subjectDigest.String(),
+			host+"/mirror/app:1.0", registry.NotationSignatureArtifactType, client)
+		if err != nil {
+			t.Fatalf("CopyReferrers() error = %v (referrers API: %v)", err, referrersAPI)
+		}
+
+		if len(copied) != 1 || copied[0] != signature {
+			t.Errorf("CopyReferrers() = %v, want [%s] (referrers API: %v)", copied, signature, referrersAPI)
+		}
+
+		mirrored, err := name.NewDigest(host + "/mirror/app@" + subjectDigest.String())
+		if err != nil {
+			t.Fatalf("Failed to parse reference: %v", err)
+		}
+
+		referrers, err := remote.Referrers(mirrored)
+		if err != nil {
+			t.Fatalf("Referrers() error = %v", err)
+		}
+
+		manifest, err := referrers.IndexManifest()
+		if err != nil {
+			t.Fatalf("IndexManifest() error = %v", err)
+		}
+
+		if len(manifest.Manifests) != 1 || manifest.Manifests[0].Digest.String() != signature {
+			t.Errorf("destination referrers = %+v, want the signature only (referrers API: %v)",
+				manifest.Manifests, referrersAPI)
+		}
+	}
+}
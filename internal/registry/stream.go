@@ -0,0 +1,100 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// DefaultMaxBlobMemory is the largest blob read into memory (artifact blobs, manifests) by default.
+// Image layers are never read into memory: they stream from the source to the destination.
+const DefaultMaxBlobMemory = 64 << 20
+
+// ErrBlobTooLarge indicates a blob exceeds the limit of content read into memory (see WithMaxBlobMemory).
+var ErrBlobTooLarge = errors.New("blob too large to be read into memory")
+
+// BlobOpener opens the content of a blob. It is called once to compute the blob digest and size,
+// and again for every upload attempt, so content is never held in memory.
+type BlobOpener func() (io.ReadCloser, error)
+
+// StreamedLayer returns a layer of mediaType whose content is read from open when needed,
+// instead of being held in memory: large files are uploaded with constant memory.
+func StreamedLayer(open BlobOpener, mediaType types.MediaType) (v1.Layer, error) {
+	reader, err := open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	defer reader.Close()
+
+	digest, size, err := v1.SHA256(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute blob digest: %w", err)
+	}
+
+	//nolint:wrapcheck // Conversion of a complete compressed layer cannot fail
+	return partial.CompressedToLayer(&streamedLayer{open: open, digest: digest, size: size, mediaType: mediaType})
+}
+
+// streamedLayer implements partial.CompressedLayer over a BlobOpener.
+type streamedLayer struct {
+	open      BlobOpener
+	digest    v1.Hash
+	size      int64
+	mediaType types.MediaType
+}
+
+// Digest implements partial.CompressedLayer.
+func (layer *streamedLayer) Digest() (v1.Hash, error) {
+	return layer.digest, nil
+}
+
+// Compressed implements partial.CompressedLayer.
+func (layer *streamedLayer) Compressed() (io.ReadCloser, error) {
+	return layer.open()
+}
+
+// Size implements partial.CompressedLayer.
+func (layer *streamedLayer) Size() (int64, error) {
+	return layer.size, nil
+}
+
+// MediaType implements partial.CompressedLayer.
+func (layer *streamedLayer) MediaType() (types.MediaType, error) {
+	return layer.mediaType, nil
+}
+
+// WithMaxBlobMemory sets the largest blob the client reads into memory (DefaultMaxBlobMemory when zero or
+// less), and returns the client. Larger blobs fail with ErrBlobTooLarge instead of exhausting memory.
+func (client *Client) WithMaxBlobMemory(size int64) *Client {
+	client.maxBlobMemory = size
+
+	return client
+}
+
+// readLimited reads a whole blob of declared size (negative when unknown) from reader,
+// refusing blobs over the memory limit of the client.
+func (client *Client) readLimited(reader io.Reader, size int64) ([]byte, error) {
+	limit := client.maxBlobMemory
+	if limit <= 0 {
+		limit = DefaultMaxBlobMemory
+	}
+
+	if size > limit {
+		return nil, fmt.Errorf("%w: %d bytes (limit %d)", ErrBlobTooLarge, size, limit)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: over %d bytes", ErrBlobTooLarge, limit)
+	}
+
+	return data, nil
+}
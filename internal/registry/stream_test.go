@@ -0,0 +1,77 @@
+package registry_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// INTENTION: Streamed blobs are opened for hashing and for upload instead of being held in memory, and push the
+// same artifact as in-memory data; pulling a blob over the memory limit fails instead of reading it.
+func TestClient_StreamedArtifact(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+	ref := host + "/test/app:report"
+	content := bytes.Repeat([]byte("report "), 1024)
+
+	opened := 0
+
+	blobs := []registry.ArtifactBlob{{
+		MediaType: "text/plain",
+		Title:     "report.txt",
+		Open: func() (io.ReadCloser, error) {
+			opened++
+
+			return io.NopCloser(bytes.NewReader(content)), nil
+		},
+	}}
+
+	streamed, err := client.PushArtifact(t.Context(), ref, "application/vnd.example.report", blobs, nil)
+	if err != nil {
+		t.Fatalf("PushArtifact() failed: %v", err)
+	}
+
+	if opened < 2 {
+		t.Errorf("blob opened %d times, want once for the digest and once per upload", opened)
+	}
+
+	buffered, err := client.PushArtifact(t.Context(), ref, "application/vnd.example.report", []registry.ArtifactBlob{
+		{MediaType: "text/plain", Title: "report.txt", Data: content},
+	}, nil)
+	if err != nil {
+		t.Fatalf("PushArtifact() failed: %v", err)
+	}
+
+	if streamed != buffered {
+		t.Errorf("streamed digest = %s, want %s", streamed, buffered)
+	}
+
+	artifact, err := client.PullArtifact(t.Context(), ref)
+	if err != nil {
+		t.Fatalf("PullArtifact() failed: %v", err)
+	}
+
+	if !bytes.Equal(artifact.Blobs[0].Data, content) {
+		t.Errorf("pulled %d bytes, want %d", len(artifact.Blobs[0].Data), len(content))
+	}
+
+	limited := registry.NewClient(host, "", "", zerolog.Nop()).WithMaxBlobMemory(int64(len(content) - 1))
+
+	if _, err := limited.PullArtifact(t.Context(), ref); !errors.Is(err, registry.ErrBlobTooLarge) {
+		t.Errorf("PullArtifact() over the limit error = %v, want %v", err, registry.ErrBlobTooLarge)
+	}
+}
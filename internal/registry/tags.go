@@ -0,0 +1,87 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// DefaultTagPageSize is how many tags are requested per page by default (the go-containerregistry default).
+const DefaultTagPageSize = 1000
+
+// WalkTags lists the tags of a repository page by page, pageSize tags per request (0 lets the registry choose),
+// and calls visit with each page, in registry order, until it returns false or the last page is read.
+// Repositories with tens of thousands of tags can be searched without listing them all.
+func (client *Client) WalkTags(
+	ctx context.Context,
+	repository string,
+	pageSize int,
+	visit func(tags []string) bool,
+) error {
+	repo, err := name.NewRepository(repository)
+	if err != nil {
+		return fmt.Errorf("failed to parse repository: %w", err)
+	}
+
+	// Next pages are requested with the authentication method accepted for the first one
+	lister, err := authenticated(ctx, client, func(opts []remote.Option) (*remote.Lister, error) {
+		puller, err := remote.NewPuller(append(opts, remote.WithPageSize(pageSize))...)
+		if err != nil {
+			return nil, err
+		}
+
+		return puller.Lister(ctx, repo)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list tags: %w", Classify(err))
+	}
+
+	for pages := 0; lister.HasNext(); pages++ {
+		page, err := lister.Next(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list tags: %w", Classify(err))
+		}
+
+		if !visit(page.Tags) {
+			client.log.Debug().Str("repository", repository).Int("pages", pages+1).Msg("tag listing stopped early")
+
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// FindTags returns the tags of a repository accepted by match, in registry order, and stops listing
+// once limit tags are found (0 for no limit).
+func (client *Client) FindTags(
+	ctx context.Context,
+	repository string,
+	match func(tag string) bool,
+	limit int,
+) ([]string, error) {
+	var found []string
+
+	err := client.WalkTags(ctx, repository, DefaultTagPageSize, func(tags []string) bool {
+		for _, tag := range tags {
+			if !match(tag) {
+				continue
+			}
+
+			found = append(found, tag)
+
+			if limit > 0 && len(found) >= limit {
+				return false
+			}
+		}
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
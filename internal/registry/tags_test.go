@@ -0,0 +1,173 @@
+package registry_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// newPaginatedRegistry starts a test registry answering full tag list pages with a Link header to the next
+// page (the go-containerregistry test registry honors n and last, but does not link pages), and counts
+// tag list requests.
+func newPaginatedRegistry(t *testing.T) (string, *atomic.Int64) {
+	t.Helper()
+
+	var listRequests atomic.Int64
+
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if !strings.HasSuffix(req.URL.Path, "/tags/list") {
+			handler.ServeHTTP(writer, req)
+
+			return
+		}
+
+		listRequests.Add(1)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+
+		if err := json.Unmarshal(recorder.Body.Bytes(), &page); err == nil && len(page.Tags) > 0 {
+			if size, err := strconv.Atoi(req.URL.Query().Get("n")); err == nil && len(page.Tags) == size {
+				writer.Header().Set("Link", fmt.Sprintf(`<%s?n=%d&last=%s>; rel="next"`,
+					req.URL.Path, size, page.Tags[len(page.Tags)-1]))
+			}
+		}
+
+		writer.WriteHeader(recorder.Code)
+		_, _ = writer.Write(recorder.Body.Bytes())
+	}))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+
+	for _, tag := range []string{"1.0", "1.1", "1.2", "1.3", "1.4"} {
+		img, err := random.Image(256, 1)
+		if err != nil {
+			t.Fatalf("failed to create random image: %v", err)
+		}
+
+		if _, err := client.PushImage(t.Context(), host+"/test/app:"+tag, img); err != nil {
+			t.Fatalf("PushImage() failed: %v", err)
+		}
+	}
+
+	listRequests.Store(0)
+
+	return host, &listRequests
+}
+
+// INTENTION: Tags are listed page by page, in registry order, and listing stops as soon as the visitor
+// or the match limit asks for no more pages.
+func TestClient_WalkTags(t *testing.T) {
+	t.Parallel()
+
+	host, listRequests := newPaginatedRegistry(t)
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+	repository := host + "/test/app"
+
+	var pages [][]string
+
+	err := client.WalkTags(t.Context(), repository, 2, func(tags []string) bool {
+		pages = append(pages, tags)
+
+		return true
+	})
+	if err != nil {
+		t.Fatalf("WalkTags() failed: %v", err)
+	}
+
+	want := [][]string{{"1.0", "1.1"}, {"1.2", "1.3"}, {"1.4"}}
+	if !slices.EqualFunc(pages, want, slices.Equal) {
+		t.Errorf("pages = %v, want %v", pages, want)
+	}
+
+	listRequests.Store(0)
+
+	err = client.WalkTags(t.Context(), repository, 2, func([]string) bool { return false })
+	if err != nil {
+		t.Fatalf("WalkTags() failed: %v", err)
+	}
+
+	if got := listRequests.Load(); got != 1 {
+		t.Errorf("WalkTags() stopped after the first page made %d list requests, want 1", got)
+	}
+
+	all, err := client.ListTags(t.Context(), repository)
+	if err != nil {
+		t.Fatalf("ListTags() failed: %v", err)
+	}
+
+	if len(all) != 5 {
+		t.Errorf("ListTags() = %v, want 5 tags", all)
+	}
+}
+
+// INTENTION: FindTags only returns the tags accepted by the filter, and stops at the limit.
+func TestClient_FindTags(t *testing.T) {
+	t.Parallel()
+
+	host, _ := newPaginatedRegistry(t)
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+	repository := host + "/test/app"
+
+	tests := []struct {
+		name  string
+		match func(string) bool
+		limit int
+		want  []string
+	}{
+		{
+			name:  "all matches",
+			match: func(tag string) bool { return tag != "1.2" },
+			want:  []string{"1.0", "1.1", "1.3", "1.4"},
+		},
+		{
+			name:  "limit reached",
+			match: func(tag string) bool { return tag >= "1.1" },
+			limit: 2,
+			want:  []string{"1.1", "1.2"},
+		},
+		{
+			name:  "no match",
+			match: func(string) bool { return false },
+			limit: 1,
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := client.FindTags(t.Context(), repository, tt.match, tt.limit)
+			if err != nil {
+				t.Fatalf("FindTags() failed: %v", err)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FindTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// blobObserverContextKey is the context key under which a BlobObserver is stored.
+type blobObserverContextKey struct{}
+
+// BlobTransfer describes a blob that reached a destination repository.
+type BlobTransfer struct {
+	// Repository is the destination repository, with its registry host (e.g., "ghcr.io/org/app").
+	Repository string
+	Digest     string
+	// Mounted is true when the registry mounted the blob from another repository instead of receiving it.
+	Mounted bool
+}
+
+// BlobObserver is called for every blob uploaded or mounted. It may be called concurrently.
+type BlobObserver func(transfer BlobTransfer)
+
+// WithBlobObserver returns a context carrying the observer.
+// Registry clients report every blob they upload or mount with this context; blobs the destination
+// already had are not transferred, and not reported.
+func WithBlobObserver(ctx context.Context, observer BlobObserver) context.Context {
+	return context.WithValue(ctx, blobObserverContextKey{}, observer)
+}
+
+// blobObserverFromContext returns the blob observer carried by ctx, or nil.
+func blobObserverFromContext(ctx context.Context) BlobObserver {
+	observer, _ := ctx.Value(blobObserverContextKey{}).(BlobObserver)
+
+	return observer
+}
+
+// observedTransport is an http.RoundTripper reporting completed blob uploads to a BlobObserver.
+// An upload completes with a 201 answer to either a PUT carrying the digest (the final request of an
+// upload session) or a POST carrying a mount digest (a cross-repository mount).
+type observedTransport struct {
+	base     http.RoundTripper
+	observer BlobObserver
+}
+
+// RoundTrip implements http.RoundTripper.
+func (transport *observedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := transport.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		//nolint:wrapcheck // Transport errors are passed through unchanged
+		return resp, err
+	}
+
+	repository, ok := uploadRepository(req.URL.Path)
+	if !ok {
+		return resp, nil
+	}
+
+	query := req.URL.Query()
+
+	switch {
+	case req.Method == http.MethodPut && query.Get("digest") != "":
+		transport.observer(BlobTransfer{Repository: req.URL.Host + "/" + repository, Digest: query.Get("digest")})
+	case req.Method == http.MethodPost && query.Get("mount") != "":
+		transport.observer(BlobTransfer{
+			Repository: req.URL.Host + "/" + repository,
+			Digest:     query.Get("mount"),
+			Mounted:    true,
+		})
+	}
+
+	return resp, nil
+}
+
+// uploadRepository returns the repository of a blob upload path ("/v2/<repository>/blobs/uploads/...").
+func uploadRepository(path string) (string, bool) {
+	rest, ok := strings.CutPrefix(path, "/v2/")
+	if !ok {
+		return "", false
+	}
+
+	repository, _, ok := strings.Cut(rest, "/blobs/uploads/")
+	if !ok {
+		// Upload sessions start at "/v2/<repository>/blobs/uploads/" without a session ID
+		repository, ok = strings.CutSuffix(rest, "/blobs/uploads")
+	}
+
+	return repository, ok && repository != ""
+}
@@ -0,0 +1,90 @@
+package registry_test
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// INTENTION: Every blob uploaded with an observed context is reported once, with its destination
+// repository; pushing again transfers nothing, and reports nothing.
+func TestWithBlobObserver_ReportsUploads(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+
+	img, err := random.Image(1024, 2)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+
+	var (
+		mutex     sync.Mutex
+		transfers []registry.BlobTransfer
+	)
+
+	ctx := registry.WithBlobObserver(t.Context(), func(transfer registry.BlobTransfer) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		transfers = append(transfers, transfer)
+	})
+
+	if _, err := client.PushImage(ctx, host+"/test/app:1.0", img); err != nil {
+		t.Fatalf("PushImage() failed: %v", err)
+	}
+
+	// Two layers and the config
+	if len(transfers) != 3 {
+		t.Fatalf("transfers = %v, want 3 blobs", transfers)
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		t.Fatalf("Layers() failed: %v", err)
+	}
+
+	reported := make(map[string]bool)
+
+	for _, transfer := range transfers {
+		if transfer.Repository != host+"/test/app" || transfer.Mounted {
+			t.Errorf("transfer = %+v, want an upload to %s/test/app", transfer, host)
+		}
+
+		reported[transfer.Digest] = true
+	}
+
+	for _, layer := range layers {
+		digest, err := layer.Digest()
+		if err != nil {
+			t.Fatalf("Digest() failed: %v", err)
+		}
+
+		if !reported[digest.String()] {
+			t.Errorf("layer %s not reported", digest)
+		}
+	}
+
+	transfers = nil
+
+	if _, err := client.PushImage(ctx, host+"/test/app:1.1", img); err != nil {
+		t.Fatalf("PushImage() failed: %v", err)
+	}
+
+	if len(transfers) != 0 {
+		t.Errorf("transfers = %v, want none for blobs already in the repository", transfers)
+	}
+}
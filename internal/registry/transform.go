@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+)
+
+// attestationReferenceType is the annotation BuildKit marks the attestation manifests of an index with.
+const attestationReferenceType = "vnd.docker.reference.type"
+
+// RemoteImage is an image read with the client of its registry.
+type RemoteImage struct {
+	Ref    string
+	Client *Client
+}
+
+// transformFunc returns img (of the given platform, nil for single-platform images) transformed, and whether it
+// changed.
+type transformFunc func(img v1.Image, platform *v1.Platform) (v1.Image, bool, error)
+
+// transform applies transformFn to image, platform by platform for multi-platform images, and pushes the result
+// to destRef with the client. Attestation manifests, which describe the original image, are dropped. When no
+// platform changed and destRef is the image itself, nothing is pushed. Returns the digest of the result.
+func (client *Client) transform(ctx context.Context, image RemoteImage, destRef string, transformFn transformFunc) (
+	string, error,
+) {
+	desc, err := image.Client.GetImage(ctx, image.Ref)
+	if err != nil {
+		return "", err
+	}
+
+	if !desc.MediaType.IsIndex() {
+		img, err := desc.Image()
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrGetImage, err)
+		}
+
+		transformed, changed, err := transformFn(img, nil)
+		if err != nil {
+			return "", err
+		}
+
+		if !changed && destRef == image.Ref {
+			return desc.Digest.String(), nil
+		}
+
+		return client.PushImage(ctx, destRef, transformed)
+	}
+
+	idx, err := desc.ImageIndex()
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrGetImageIndex, err)
+	}
+
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return "", fmt.Errorf("failed to get index manifest: %w", err)
+	}
+
+	transformedIdx := mutate.IndexMediaType(empty.Index, desc.MediaType)
+	if len(manifest.Annotations) > 0 {
+		transformedIdx, _ = mutate.Annotations(transformedIdx, manifest.Annotations).(v1.ImageIndex)
+	}
+
+	changed := false
+
+	for _, child := range manifest.Manifests {
+		if child.Annotations[attestationReferenceType] == "attestation-manifest" {
+			client.log.Debug().Str("digest", child.Digest.String()).Msg("dropping attestation manifest")
+
+			continue
+		}
+
+		img, err := idx.Image(child.Digest)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrGetImage, err)
+		}
+
+		transformed, platformChanged, err := transformFn(img, child.Platform)
+		if err != nil {
+			return "", err
+		}
+
+		changed = changed || platformChanged
+
+		transformedIdx = mutate.AppendManifests(transformedIdx, mutate.IndexAddendum{
+			Add: transformed,
+			Descriptor: v1.Descriptor{
+				MediaType:   child.MediaType,
+				Platform:    child.Platform,
+				Annotations: child.Annotations,
+			},
+		})
+	}
+
+	if !changed && destRef == image.Ref {
+		return desc.Digest.String(), nil
+	}
+
+	return client.PushIndex(ctx, destRef, transformedIdx)
+}
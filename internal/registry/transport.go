@@ -0,0 +1,121 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/rs/zerolog"
+)
+
+// userAgentContextKey is the context key under which the user agent is stored.
+type userAgentContextKey struct{}
+
+// requestLogContextKey is the context key under which the request logger is stored.
+type requestLogContextKey struct{}
+
+// WithUserAgent returns a context carrying the User-Agent sent on registry requests (e.g., "quark/0.1.0").
+func WithUserAgent(ctx context.Context, userAgent string) context.Context {
+	return context.WithValue(ctx, userAgentContextKey{}, userAgent)
+}
+
+// WithRequestLogging returns a context enabling the request logger: every registry HTTP request
+// performed with this context is logged at trace level (method, URL, status, duration).
+func WithRequestLogging(ctx context.Context, log zerolog.Logger) context.Context {
+	return context.WithValue(ctx, requestLogContextKey{}, log)
+}
+
+// TransportOptions returns the remote options carried by ctx: user agent, concurrency limits, circuit breaker,
+// traffic metering, blob observation and request logging. Registry clients apply them to every
+// request; other go-containerregistry callers can append them to their own options.
+func TransportOptions(ctx context.Context) []remote.Option {
+	return transportOptions(ctx, nil)
+}
+
+// TransportOptions returns the remote options carried by ctx, applied around the client transport,
+// for go-containerregistry callers sharing the client connections (e.g., the version checker).
+func (client *Client) TransportOptions(ctx context.Context) []remote.Option {
+	return transportOptions(ctx, client.transport)
+}
+
+// transportOptions returns the remote options carried by ctx, with the context transport wrappers
+// chained around base (the default transport when nil).
+func transportOptions(ctx context.Context, base http.RoundTripper) []remote.Option {
+	var opts []remote.Option
+
+	if userAgent, ok := ctx.Value(userAgentContextKey{}).(string); ok && userAgent != "" {
+		opts = append(opts, remote.WithUserAgent(userAgent))
+	}
+
+	// Only one transport can be set: wrappers are chained around the base transport
+	transport := remote.DefaultTransport
+	wrapped := false
+
+	if base != nil {
+		transport = base
+		wrapped = true
+	}
+
+	// Innermost: the breaker fails fast without waiting for a slot, and logged durations include the wait
+	if limiter := limiterFromContext(ctx); limiter != nil {
+		transport = &limitedTransport{base: transport, limiter: limiter}
+		wrapped = true
+	}
+
+	if breaker := breakerFromContext(ctx); breaker != nil {
+		transport = &breakerTransport{base: transport, breaker: breaker}
+		wrapped = true
+	}
+
+	if meter := meterFromContext(ctx); meter != nil {
+		transport = &meteredTransport{base: transport, meter: meter}
+		wrapped = true
+	}
+
+	if observer := blobObserverFromContext(ctx); observer != nil {
+		transport = &observedTransport{base: transport, observer: observer}
+		wrapped = true
+	}
+
+	if log, ok := ctx.Value(requestLogContextKey{}).(zerolog.Logger); ok {
+		transport = &loggingTransport{base: transport, log: log}
+		wrapped = true
+	}
+
+	if wrapped {
+		opts = append(opts, remote.WithTransport(transport))
+	}
+
+	return opts
+}
+
+// loggingTransport is an http.RoundTripper logging every request at trace level.
+type loggingTransport struct {
+	base http.RoundTripper
+	log  zerolog.Logger
+}
+
+// RoundTrip implements http.RoundTripper.
+func (transport *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	resp, err := transport.base.RoundTrip(req)
+
+	// Query strings are dropped: redirects to blob storage carry signed credentials
+	event := transport.log.Trace().
+		Str("method", req.Method).
+		Str("url", req.URL.Scheme+"://"+req.URL.Host+req.URL.Path).
+		Dur("duration", time.Since(start))
+
+	if err != nil {
+		event.Err(err).Msg("registry request failed")
+
+		//nolint:wrapcheck // Transport errors are passed through unchanged
+		return resp, err
+	}
+
+	event.Int("status", resp.StatusCode).Msg("registry request")
+
+	return resp, nil
+}
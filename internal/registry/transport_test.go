@@ -0,0 +1,71 @@
+package registry_test
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// INTENTION: The configured User-Agent is sent on registry requests, and the opt-in request
+// logger records method, URL and status at trace level.
+func TestTransportOptions_UserAgentAndRequestLogging(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu         sync.Mutex
+		userAgents []string
+	)
+
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		userAgents = append(userAgents, req.UserAgent())
+		mu.Unlock()
+
+		handler.ServeHTTP(writer, req)
+	}))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+
+	var logs bytes.Buffer
+
+	ctx := registry.WithUserAgent(t.Context(), "quark/test")
+	ctx = registry.WithRequestLogging(ctx, zerolog.New(&logs).Level(zerolog.TraceLevel))
+
+	// Missing image: requests are still performed and logged
+	if _, err := client.CheckExists(ctx, host+"/test/app:1.0"); err != nil {
+		t.Fatalf("CheckExists() failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(userAgents) == 0 {
+		t.Fatal("no request reached the registry")
+	}
+
+	for _, userAgent := range userAgents {
+		if !strings.HasPrefix(userAgent, "quark/test") {
+			t.Errorf("User-Agent = %q, want prefix quark/test", userAgent)
+		}
+	}
+
+	output := logs.String()
+	for _, want := range []string{`"level":"trace"`, `"method":"`, `"status":`, "/v2/"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("request log %q does not contain %q", output, want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package registry
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// dialTimeout is the connection timeout of tuned transports (same as the default transport).
+const dialTimeout = 30 * time.Second
+
+// TransportConfig tunes the HTTP connections to a registry.
+// Zero values keep the defaults of the go-containerregistry transport (50 idle connections per host,
+// HTTP/2 when the registry supports it, 30s TCP keepalive).
+type TransportConfig struct {
+	// MaxIdleConnsPerHost is the number of idle connections kept open per host, for reuse.
+	MaxIdleConnsPerHost int
+	// DisableHTTP2 forces HTTP/1.1: each concurrent blob transfer gets its own connection
+	// instead of being multiplexed on a single one.
+	DisableHTTP2 bool
+	// KeepAlive is the interval between TCP keepalive probes (negative disables keepalives).
+	KeepAlive time.Duration
+}
+
+// IsZero reports whether the config keeps all defaults.
+func (config TransportConfig) IsZero() bool {
+	return config == TransportConfig{}
+}
+
+// NewTransport returns an HTTP transport tuned with config, based on the go-containerregistry default transport.
+// The transport owns a connection pool: share it between all clients of the same registry
+// (see Client.WithTransport) for connections to be reused.
+func NewTransport(config TransportConfig) http.RoundTripper {
+	base, ok := remote.DefaultTransport.(*http.Transport)
+	if !ok {
+		return remote.DefaultTransport
+	}
+
+	transport := base.Clone()
+
+	if config.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+		transport.MaxIdleConns = max(transport.MaxIdleConns, config.MaxIdleConnsPerHost)
+	}
+
+	if config.DisableHTTP2 {
+		// A non-nil, empty TLSNextProto disables HTTP/2 negotiation
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	if config.KeepAlive != 0 {
+		transport.DialContext = (&net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: config.KeepAlive,
+		}).DialContext
+	}
+
+	return transport
+}
@@ -0,0 +1,91 @@
+package registry_test
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// INTENTION: Tuned transports apply the requested connection settings, and keep the defaults otherwise.
+func TestNewTransport(t *testing.T) {
+	t.Parallel()
+
+	tuned, ok := registry.NewTransport(registry.TransportConfig{
+		MaxIdleConnsPerHost: 200,
+		DisableHTTP2:        true,
+		KeepAlive:           time.Minute,
+	}).(*http.Transport)
+	if !ok {
+		t.Fatal("NewTransport() is not an *http.Transport")
+	}
+
+	if tuned.MaxIdleConnsPerHost != 200 {
+		t.Errorf("MaxIdleConnsPerHost = %d, want 200", tuned.MaxIdleConnsPerHost)
+	}
+
+	if tuned.MaxIdleConns < 200 {
+		t.Errorf("MaxIdleConns = %d, want at least MaxIdleConnsPerHost", tuned.MaxIdleConns)
+	}
+
+	if tuned.ForceAttemptHTTP2 || tuned.TLSNextProto == nil {
+		t.Error("HTTP/2 not disabled")
+	}
+
+	defaults, ok := registry.NewTransport(registry.TransportConfig{}).(*http.Transport)
+	if !ok {
+		t.Fatal("NewTransport() is not an *http.Transport")
+	}
+
+	if defaults.MaxIdleConnsPerHost != 50 || !defaults.ForceAttemptHTTP2 {
+		t.Errorf("default transport = %d idle conns per host, HTTP/2 %v, want 50 and true",
+			defaults.MaxIdleConnsPerHost, defaults.ForceAttemptHTTP2)
+	}
+}
+
+// INTENTION: Clients using a tuned transport still go through the context transport wrappers.
+func TestClient_WithTransport(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	transport := registry.NewTransport(registry.TransportConfig{MaxIdleConnsPerHost: 4, DisableHTTP2: true})
+	client := registry.NewClient(host, "", "", zerolog.Nop()).WithTransport(transport)
+
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+
+	meter := registry.NewMeter()
+	ctx := registry.WithMeter(t.Context(), meter)
+
+	pushed, err := client.PushImage(ctx, host+"/test/app:1.0", img)
+	if err != nil {
+		t.Fatalf("PushImage() failed: %v", err)
+	}
+
+	digest, err := client.GetDigest(ctx, host+"/test/app:1.0")
+	if err != nil {
+		t.Fatalf("GetDigest() failed: %v", err)
+	}
+
+	if digest != pushed {
+		t.Errorf("GetDigest() = %q, want %q", digest, pushed)
+	}
+
+	if traffic := meter.Traffic(); len(traffic) != 1 || traffic[0].Uploaded == 0 {
+		t.Errorf("Traffic() = %v, want traffic recorded for %s", traffic, host)
+	}
+}
@@ -0,0 +1,250 @@
+package relay
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/farcloser/quark/internal/awssig"
+)
+
+const (
+	// GCSEndpoint is the S3-compatible (XML API) endpoint of Google Cloud Storage, used with HMAC keys.
+	GCSEndpoint = "https://storage.googleapis.com"
+)
+
+var (
+	// ErrBucketRequired indicates a bucket name is required.
+	ErrBucketRequired = errors.New("bucket name is required")
+	// ErrBucketRequestFailed indicates the object store rejected a request.
+	ErrBucketRequestFailed = errors.New("bucket request failed")
+)
+
+// BucketConfig configures an S3-compatible bucket (AWS S3, GCS with HMAC keys, MinIO, ...).
+type BucketConfig struct {
+	// Endpoint is the object store URL. Defaults to the AWS S3 regional endpoint.
+	Endpoint string
+	// Region is the signing region. Defaults to "us-east-1" ("auto" for GCS).
+	Region string
+	// Bucket is the bucket name.
+	Bucket string
+	// Prefix is prepended to every object key.
+	Prefix string
+	// AccessKey, SecretKey and SessionToken are the request signing credentials.
+	// Requests are sent unsigned when AccessKey is empty (public buckets).
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+}
+
+// Bucket is a Transport storing objects in an S3-compatible bucket, using path-style requests
+// signed with AWS Signature Version 4.
+type Bucket struct {
+	config   BucketConfig
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewBucket creates a bucket transport.
+func NewBucket(config BucketConfig) (*Bucket, error) {
+	if config.Bucket == "" {
+		return nil, ErrBucketRequired
+	}
+
+	if config.Region == "" {
+		config.Region = "us-east-1"
+		if config.Endpoint == GCSEndpoint {
+			config.Region = "auto"
+		}
+	}
+
+	if config.Endpoint == "" {
+		config.Endpoint = "https://s3." + config.Region + ".amazonaws.com"
+	}
+
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid bucket endpoint %q: %w", config.Endpoint, err)
+	}
+
+	config.Prefix = strings.Trim(config.Prefix, "/")
+
+	return &Bucket{
+		config:   config,
+		endpoint: endpoint,
+		client:   http.DefaultClient,
+	}, nil
+}
+
+// Put implements Transport.
+// Content is spooled to a temporary file first: object stores require the length and
+// the signed payload hash before the upload starts.
+func (bucket *Bucket) Put(ctx context.Context, key string, reader io.Reader) error {
+	spool, err := os.CreateTemp("", "quark-upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create upload spool: %w", err)
+	}
+
+	defer func() {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+	}()
+
+	hasher := sha256.New()
+
+	size, err := io.Copy(io.MultiWriter(spool, hasher), &contextReader{ctx: ctx, reader: reader})
+	if err != nil {
+		return fmt.Errorf("failed to spool %s: %w", key, err)
+	}
+
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind upload spool: %w", err)
+	}
+
+	req, err := bucket.request(ctx, http.MethodPut, key, io.NopCloser(spool), hex.EncodeToString(hasher.Sum(nil)))
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = size
+
+	resp, err := bucket.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return bucket.failure(resp, key)
+	}
+
+	return nil
+}
+
+// Get implements Transport.
+func (bucket *Bucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := bucket.request(ctx, http.MethodGet, key, nil, emptyPayloadHash())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := bucket.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		_ = resp.Body.Close()
+
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	default:
+		defer resp.Body.Close()
+
+		return nil, bucket.failure(resp, key)
+	}
+}
+
+// Exists implements Transport.
+func (bucket *Bucket) Exists(ctx context.Context, key string) (bool, error) {
+	req, err := bucket.request(ctx, http.MethodHead, key, nil, emptyPayloadHash())
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := bucket.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to check %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, bucket.failure(resp, key)
+	}
+}
+
+func (bucket *Bucket) objectKey(key string) string {
+	if bucket.config.Prefix == "" {
+		return key
+	}
+
+	return bucket.config.Prefix + "/" + key
+}
+
+// request builds a signed path-style request for an object key.
+func (bucket *Bucket) request(
+	ctx context.Context,
+	method, key string,
+	body io.ReadCloser,
+	payloadHash string,
+) (*http.Request, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	target := *bucket.endpoint
+	target.Path = strings.TrimSuffix(target.Path, "/") + "/" + bucket.config.Bucket + "/" + bucket.objectKey(cleaned)
+	target.RawPath = escapePath(target.Path)
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if bucket.config.AccessKey != "" {
+		awssig.Sign(req, payloadHash, awssig.Credentials{
+			AccessKey:    bucket.config.AccessKey,
+			SecretKey:    bucket.config.SecretKey,
+			SessionToken: bucket.config.SessionToken,
+		}, bucket.config.Region, "s3")
+	}
+
+	return req, nil
+}
+
+func (bucket *Bucket) failure(resp *http.Response, key string) error {
+	//nolint:mnd // Error bodies are short XML documents
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	return fmt.Errorf("%w: %s %s: %s %s", ErrBucketRequestFailed, resp.Request.Method, key, resp.Status,
+		strings.TrimSpace(string(detail)))
+}
+
+// escapePath URI-encodes every byte of path except unreserved characters and "/",
+// as required by Signature Version 4.
+func escapePath(path string) string {
+	var escaped strings.Builder
+
+	for idx := range len(path) {
+		char := path[idx]
+
+		switch {
+		case 'A' <= char && char <= 'Z', 'a' <= char && char <= 'z', '0' <= char && char <= '9',
+			char == '-', char == '_', char == '.', char == '~', char == '/':
+			escaped.WriteByte(char)
+		default:
+			fmt.Fprintf(&escaped, "%%%02X", char)
+		}
+	}
+
+	return escaped.String()
+}
+
+func emptyPayloadHash() string {
+	return awssig.HashHex(nil)
+}
@@ -0,0 +1,201 @@
+package relay_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	gosync "sync"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/relay"
+)
+
+// fakeBucketServer is a minimal S3-compatible object store checking signed requests.
+type fakeBucketServer struct {
+	mu      gosync.Mutex
+	objects map[string][]byte
+}
+
+func newFakeBucketServer(t *testing.T) (*httptest.Server, *fakeBucketServer) {
+	t.Helper()
+
+	store := &fakeBucketServer{objects: make(map[string][]byte)}
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			http.Error(writer, "missing signature", http.StatusForbidden)
+
+			return
+		}
+
+		store.mu.Lock()
+		defer store.mu.Unlock()
+
+		switch req.Method {
+		case http.MethodPut:
+			data, _ := io.ReadAll(req.Body)
+			sum := sha256.Sum256(data)
+
+			if req.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+				http.Error(writer, "payload hash mismatch", http.StatusBadRequest)
+
+				return
+			}
+
+			store.objects[req.URL.Path] = data
+		case http.MethodGet, http.MethodHead:
+			data, ok := store.objects[req.URL.Path]
+			if !ok {
+				http.NotFound(writer, req)
+
+				return
+			}
+
+			_, _ = writer.Write(data)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, store
+}
+
+// INTENTION: Bucket stores objects under the configured prefix with signed requests,
+// and reports missing objects with ErrNotFound.
+func TestBucket_PutGetExists(t *testing.T) {
+	t.Parallel()
+
+	server, store := newFakeBucketServer(t)
+
+	bucket, err := relay.NewBucket(relay.BucketConfig{
+		Endpoint:  server.URL,
+		Bucket:    "deliveries",
+		Prefix:    "/acme/",
+		AccessKey: "AKID",
+		SecretKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewBucket() failed: %v", err)
+	}
+
+	if err := bucket.Put(t.Context(), "index.json", strings.NewReader("{}")); err != nil {
+		t.Fatalf("Put() failed: %v", err)
+	}
+
+	if _, ok := store.objects["/deliveries/acme/index.json"]; !ok {
+		t.Errorf("object not stored under bucket prefix, have %v", store.objects)
+	}
+
+	exists, err := bucket.Exists(t.Context(), "index.json")
+	if err != nil || !exists {
+		t.Errorf("Exists() = %v, %v, want true, nil", exists, err)
+	}
+
+	reader, err := bucket.Get(t.Context(), "index.json")
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+
+	data, _ := io.ReadAll(reader)
+	_ = reader.Close()
+
+	if string(data) != "{}" {
+		t.Errorf("Get() = %q, want %q", data, "{}")
+	}
+
+	if _, err := bucket.Get(t.Context(), "missing"); !errors.Is(err, relay.ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+
+	exists, err = bucket.Exists(t.Context(), "missing")
+	if err != nil || exists {
+		t.Errorf("Exists(missing) = %v, %v, want false, nil", exists, err)
+	}
+}
+
+// INTENTION: Rejected requests surface as ErrBucketRequestFailed.
+func TestBucket_RequestFailed(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newFakeBucketServer(t)
+
+	// Unsigned requests are refused by the fake server
+	bucket, err := relay.NewBucket(relay.BucketConfig{Endpoint: server.URL, Bucket: "deliveries"})
+	if err != nil {
+		t.Fatalf("NewBucket() failed: %v", err)
+	}
+
+	if err := bucket.Put(t.Context(), "index.json", strings.NewReader("{}")); !errors.Is(err, relay.ErrBucketRequestFailed) {
+		t.Errorf("Put() error = %v, want ErrBucketRequestFailed", err)
+	}
+}
+
+// INTENTION: NewBucket requires a bucket name.
+func TestNewBucket_BucketRequired(t *testing.T) {
+	t.Parallel()
+
+	if _, err := relay.NewBucket(relay.BucketConfig{}); !errors.Is(err, relay.ErrBucketRequired) {
+		t.Errorf("NewBucket() error = %v, want ErrBucketRequired", err)
+	}
+}
+
+// INTENTION: A bundle staged in a directory archives to an OCI layout tarball,
+// byte-identical across runs.
+func TestDirectory_WriteTar(t *testing.T) {
+	t.Parallel()
+
+	img, err := random.Image(128, 1)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+
+	archive := func() []byte {
+		dir := relay.NewDirectory(t.TempDir())
+		if _, err := relay.NewBundle(dir, zerolog.Nop()).WriteImage(t.Context(), "example.com/app:1.0", img); err != nil {
+			t.Fatalf("WriteImage() failed: %v", err)
+		}
+
+		var buf bytes.Buffer
+		if err := dir.WriteTar(t.Context(), &buf); err != nil {
+			t.Fatalf("WriteTar() failed: %v", err)
+		}
+
+		return buf.Bytes()
+	}
+
+	first := archive()
+
+	if !bytes.Equal(first, archive()) {
+		t.Error("WriteTar() archives differ across runs, want identical")
+	}
+
+	names := map[string]bool{}
+	reader := tar.NewReader(bytes.NewReader(first))
+
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("failed to read archive: %v", err)
+		}
+
+		names[header.Name] = true
+	}
+
+	for _, want := range []string{"oci-layout", "index.json", "blobs/sha256/"} {
+		if !names[want] {
+			t.Errorf("archive missing %q, have %v", want, names)
+		}
+	}
+}
@@ -0,0 +1,552 @@
+package relay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// RefNameAnnotation names an entry of the bundle index (OCI image layout convention).
+	RefNameAnnotation = "org.opencontainers.image.ref.name"
+
+	indexKey  = "index.json"
+	layoutKey = "oci-layout"
+	layout    = `{"imageLayoutVersion":"1.0.0"}`
+
+	// maxMetadataSize bounds the blobs read into memory (index, manifests, configs); layers are always streamed.
+	maxMetadataSize = 64 << 20
+)
+
+var (
+	// ErrEntryNotFound indicates the bundle index has no entry for the requested digest.
+	ErrEntryNotFound = errors.New("bundle entry not found")
+	// ErrBlobCorrupted indicates a blob read from the bundle does not match its digest or size.
+	ErrBlobCorrupted = errors.New("bundle blob corrupted")
+	// ErrUnsupportedMediaType indicates a manifest media type that cannot be stored in a bundle.
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	// ErrBlobTooLarge indicates a manifest or config blob too large to be read into memory.
+	ErrBlobTooLarge = errors.New("bundle blob too large to be read into memory")
+)
+
+// Bundle is an OCI image layout stored in a Transport.
+// Images are written with their blobs first and the index last, so a bundle interrupted mid-write
+// never references missing content; blobs already present are not rewritten.
+type Bundle struct {
+	transport Transport
+	log       zerolog.Logger
+}
+
+// NewBundle creates a bundle backed by transport.
+func NewBundle(transport Transport, log zerolog.Logger) *Bundle {
+	return &Bundle{
+		transport: transport,
+		log:       log,
+	}
+}
+
+// WriteImage stores img and all its blobs, and records it in the bundle index under refName.
+// An existing entry with the same refName is replaced.
+// Returns the image manifest digest.
+func (bundle *Bundle) WriteImage(ctx context.Context, refName string, img v1.Image) (string, error) {
+	desc, err := bundle.writeImage(ctx, img)
+	if err != nil {
+		return "", err
+	}
+
+	return bundle.addEntry(ctx, refName, desc)
+}
+
+// WriteIndex stores idx, all its child manifests and their blobs, and records it in the bundle index
+// under refName. An existing entry with the same refName is replaced.
+// Returns the index digest.
+func (bundle *Bundle) WriteIndex(ctx context.Context, refName string, idx v1.ImageIndex) (string, error) {
+	desc, err := bundle.writeIndex(ctx, idx)
+	if err != nil {
+		return "", err
+	}
+
+	return bundle.addEntry(ctx, refName, desc)
+}
+
+// Entries returns the descriptors recorded in the bundle index.
+// Returns an empty list for an empty bundle.
+func (bundle *Bundle) Entries(ctx context.Context) ([]v1.Descriptor, error) {
+	manifest, err := bundle.readIndexManifest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest.Manifests, nil
+}
+
+// Find returns the bundle index entry with the given digest.
+func (bundle *Bundle) Find(ctx context.Context, digest string) (v1.Descriptor, error) {
+	entries, err := bundle.Entries(ctx)
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+
+	for _, entry := range entries {
+		if entry.Digest.String() == digest {
+			return entry, nil
+		}
+	}
+
+	return v1.Descriptor{}, fmt.Errorf("%w: %s", ErrEntryNotFound, digest)
+}
+
+// Image returns the image with the given manifest digest, reading blobs from the bundle on demand.
+// Manifests and blobs are verified against their digests as they are read.
+func (bundle *Bundle) Image(ctx context.Context, digest v1.Hash) (v1.Image, error) {
+	raw, err := bundle.readBlob(ctx, digest, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := v1.ParseManifest(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse image manifest %s: %w", digest, err)
+	}
+
+	img, err := partial.CompressedToImage(&bundleImage{
+		ctx:      ctx,
+		bundle:   bundle,
+		raw:      raw,
+		manifest: manifest,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load image %s: %w", digest, err)
+	}
+
+	return img, nil
+}
+
+// Index returns the index with the given digest, reading child manifests from the bundle on demand.
+func (bundle *Bundle) Index(ctx context.Context, digest v1.Hash) (v1.ImageIndex, error) {
+	raw, err := bundle.readBlob(ctx, digest, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := v1.ParseIndexManifest(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse index manifest %s: %w", digest, err)
+	}
+
+	return &bundleIndex{
+		ctx:      ctx,
+		bundle:   bundle,
+		raw:      raw,
+		digest:   digest,
+		manifest: manifest,
+	}, nil
+}
+
+func (bundle *Bundle) writeImage(ctx context.Context, img v1.Image) (v1.Descriptor, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("failed to get layers: %w", err)
+	}
+
+	for _, layer := range layers {
+		digest, err := layer.Digest()
+		if err != nil {
+			return v1.Descriptor{}, fmt.Errorf("failed to get layer digest: %w", err)
+		}
+
+		if err := bundle.writeBlob(ctx, digest, layer.Compressed); err != nil {
+			return v1.Descriptor{}, err
+		}
+	}
+
+	configDigest, err := img.ConfigName()
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("failed to get config digest: %w", err)
+	}
+
+	config, err := img.RawConfigFile()
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("failed to get config: %w", err)
+	}
+
+	if err := bundle.writeBlob(ctx, configDigest, readerOf(config)); err != nil {
+		return v1.Descriptor{}, err
+	}
+
+	return bundle.writeManifest(ctx, img)
+}
+
+func (bundle *Bundle) writeIndex(ctx context.Context, idx v1.ImageIndex) (v1.Descriptor, error) {
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("failed to get index manifest: %w", err)
+	}
+
+	for _, child := range manifest.Manifests {
+		switch {
+		case child.MediaType.IsIndex():
+			childIdx, err := idx.ImageIndex(child.Digest)
+			if err != nil {
+				return v1.Descriptor{}, fmt.Errorf("failed to get child index %s: %w", child.Digest, err)
+			}
+
+			if _, err := bundle.writeIndex(ctx, childIdx); err != nil {
+				return v1.Descriptor{}, err
+			}
+		case child.MediaType.IsImage():
+			childImg, err := idx.Image(child.Digest)
+			if err != nil {
+				return v1.Descriptor{}, fmt.Errorf("failed to get child image %s: %w", child.Digest, err)
+			}
+
+			if _, err := bundle.writeImage(ctx, childImg); err != nil {
+				return v1.Descriptor{}, err
+			}
+		default:
+			return v1.Descriptor{}, fmt.Errorf("%w: %s (%s)", ErrUnsupportedMediaType, child.MediaType, child.Digest)
+		}
+	}
+
+	return bundle.writeManifest(ctx, idx)
+}
+
+// manifestSource is the subset of v1.Image and v1.ImageIndex needed to store a manifest.
+type manifestSource interface {
+	RawManifest() ([]byte, error)
+	MediaType() (types.MediaType, error)
+	Digest() (v1.Hash, error)
+}
+
+func (bundle *Bundle) writeManifest(ctx context.Context, source manifestSource) (v1.Descriptor, error) {
+	raw, err := source.RawManifest()
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("failed to get manifest: %w", err)
+	}
+
+	mediaType, err := source.MediaType()
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("failed to get manifest media type: %w", err)
+	}
+
+	digest, err := source.Digest()
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("failed to get manifest digest: %w", err)
+	}
+
+	if err := bundle.writeBlob(ctx, digest, readerOf(raw)); err != nil {
+		return v1.Descriptor{}, err
+	}
+
+	return v1.Descriptor{
+		MediaType: mediaType,
+		Size:      int64(len(raw)),
+		Digest:    digest,
+	}, nil
+}
+
+// writeBlob stores a blob unless the bundle already has it.
+func (bundle *Bundle) writeBlob(ctx context.Context, digest v1.Hash, open func() (io.ReadCloser, error)) error {
+	key := blobKey(digest)
+
+	exists, err := bundle.transport.Exists(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to check blob %s: %w", digest, err)
+	}
+
+	if exists {
+		bundle.log.Debug().Str("digest", digest.String()).Msg("blob already in bundle")
+
+		return nil
+	}
+
+	reader, err := open()
+	if err != nil {
+		return fmt.Errorf("failed to read blob %s: %w", digest, err)
+	}
+	defer reader.Close()
+
+	if err := bundle.transport.Put(ctx, key, reader); err != nil {
+		return fmt.Errorf("failed to store blob %s: %w", digest, err)
+	}
+
+	bundle.log.Debug().Str("digest", digest.String()).Msg("blob stored in bundle")
+
+	return nil
+}
+
+// readBlob reads a whole blob (manifests, configs) and verifies it. A negative size skips the size check.
+// Blobs over maxMetadataSize are refused rather than read into memory.
+func (bundle *Bundle) readBlob(ctx context.Context, digest v1.Hash, size int64) ([]byte, error) {
+	if size > maxMetadataSize {
+		return nil, fmt.Errorf("%w: %s is %d bytes", ErrBlobTooLarge, digest, size)
+	}
+
+	reader, err := bundle.openBlob(ctx, digest, size)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxMetadataSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", digest, err)
+	}
+
+	if len(data) > maxMetadataSize {
+		return nil, fmt.Errorf("%w: %s is over %d bytes", ErrBlobTooLarge, digest, maxMetadataSize)
+	}
+
+	return data, nil
+}
+
+// openBlob opens a blob with a reader verifying its digest (and size, if not negative) at EOF.
+func (bundle *Bundle) openBlob(ctx context.Context, digest v1.Hash, size int64) (io.ReadCloser, error) {
+	hasher, err := v1.Hasher(digest.Algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported digest algorithm %q: %w", digest.Algorithm, err)
+	}
+
+	reader, err := bundle.transport.Get(ctx, blobKey(digest))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blob %s: %w", digest, err)
+	}
+
+	return &verifiedReader{ReadCloser: reader, hasher: hasher, digest: digest, size: size}, nil
+}
+
+// addEntry records desc under refName in the bundle index and returns its digest.
+func (bundle *Bundle) addEntry(ctx context.Context, refName string, desc v1.Descriptor) (string, error) {
+	if err := bundle.transport.Put(ctx, layoutKey, strings.NewReader(layout)); err != nil {
+		return "", fmt.Errorf("failed to store layout marker: %w", err)
+	}
+
+	manifest, err := bundle.readIndexManifest(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	desc.Annotations = map[string]string{RefNameAnnotation: refName}
+
+	entries := make([]v1.Descriptor, 0, len(manifest.Manifests)+1)
+
+	for _, entry := range manifest.Manifests {
+		if entry.Annotations[RefNameAnnotation] != refName {
+			entries = append(entries, entry)
+		}
+	}
+
+	manifest.Manifests = append(entries, desc)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode bundle index: %w", err)
+	}
+
+	if err := bundle.transport.Put(ctx, indexKey, bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("failed to store bundle index: %w", err)
+	}
+
+	bundle.log.Debug().
+		Str("ref", refName).
+		Str("digest", desc.Digest.String()).
+		Msg("bundle index updated")
+
+	return desc.Digest.String(), nil
+}
+
+// readIndexManifest reads the bundle index, returning an empty index if the bundle has none yet.
+func (bundle *Bundle) readIndexManifest(ctx context.Context) (*v1.IndexManifest, error) {
+	reader, err := bundle.transport.Get(ctx, indexKey)
+	if errors.Is(err, ErrNotFound) {
+		return &v1.IndexManifest{
+			SchemaVersion: 2, //nolint:mnd // OCI image index schema version
+			MediaType:     types.OCIImageIndex,
+		}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle index: %w", err)
+	}
+	defer reader.Close()
+
+	manifest, err := v1.ParseIndexManifest(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bundle index: %w", err)
+	}
+
+	return manifest, nil
+}
+
+func blobKey(digest v1.Hash) string {
+	return "blobs/" + digest.Algorithm + "/" + digest.Hex
+}
+
+func readerOf(data []byte) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+}
+
+// verifiedReader checks a blob against its digest and size at EOF.
+type verifiedReader struct {
+	io.ReadCloser
+
+	hasher hash.Hash
+	digest v1.Hash
+	size   int64
+	read   int64
+}
+
+// Read implements io.Reader.
+func (reader *verifiedReader) Read(buf []byte) (int, error) {
+	n, err := reader.ReadCloser.Read(buf)
+	if n > 0 {
+		_, _ = reader.hasher.Write(buf[:n])
+		reader.read += int64(n)
+	}
+
+	if errors.Is(err, io.EOF) {
+		if reader.size >= 0 && reader.read != reader.size {
+			return n, fmt.Errorf("%w: %s is %d bytes, expected %d", ErrBlobCorrupted, reader.digest, reader.read, reader.size)
+		}
+
+		actual := v1.Hash{Algorithm: reader.digest.Algorithm, Hex: fmt.Sprintf("%x", reader.hasher.Sum(nil))}
+		if actual != reader.digest {
+			return n, fmt.Errorf("%w: expected %s, got %s", ErrBlobCorrupted, reader.digest, actual)
+		}
+	}
+
+	//nolint:wrapcheck // io.EOF must be returned unwrapped
+	return n, err
+}
+
+// bundleImage implements partial.CompressedImageCore over a bundle.
+type bundleImage struct {
+	//nolint:containedctx // v1.Image methods take no context, reads are bound to the loading context
+	ctx      context.Context
+	bundle   *Bundle
+	raw      []byte
+	manifest *v1.Manifest
+}
+
+// RawConfigFile implements partial.CompressedImageCore.
+func (img *bundleImage) RawConfigFile() ([]byte, error) {
+	return img.bundle.readBlob(img.ctx, img.manifest.Config.Digest, img.manifest.Config.Size)
+}
+
+// MediaType implements partial.CompressedImageCore.
+func (img *bundleImage) MediaType() (types.MediaType, error) {
+	if img.manifest.MediaType != "" {
+		return img.manifest.MediaType, nil
+	}
+
+	return types.OCIManifestSchema1, nil
+}
+
+// RawManifest implements partial.CompressedImageCore.
+func (img *bundleImage) RawManifest() ([]byte, error) {
+	return img.raw, nil
+}
+
+// LayerByDigest implements partial.CompressedImageCore.
+func (img *bundleImage) LayerByDigest(digest v1.Hash) (partial.CompressedLayer, error) {
+	if img.manifest.Config.Digest == digest {
+		return &bundleLayer{ctx: img.ctx, bundle: img.bundle, desc: img.manifest.Config}, nil
+	}
+
+	for _, desc := range img.manifest.Layers {
+		if desc.Digest == digest {
+			return &bundleLayer{ctx: img.ctx, bundle: img.bundle, desc: desc}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: layer %s", ErrEntryNotFound, digest)
+}
+
+// bundleLayer implements partial.CompressedLayer over a bundle blob.
+type bundleLayer struct {
+	//nolint:containedctx // v1.Layer methods take no context, reads are bound to the loading context
+	ctx    context.Context
+	bundle *Bundle
+	desc   v1.Descriptor
+}
+
+// Digest implements partial.CompressedLayer.
+func (layer *bundleLayer) Digest() (v1.Hash, error) {
+	return layer.desc.Digest, nil
+}
+
+// Compressed implements partial.CompressedLayer.
+func (layer *bundleLayer) Compressed() (io.ReadCloser, error) {
+	return layer.bundle.openBlob(layer.ctx, layer.desc.Digest, layer.desc.Size)
+}
+
+// Size implements partial.CompressedLayer.
+func (layer *bundleLayer) Size() (int64, error) {
+	return layer.desc.Size, nil
+}
+
+// MediaType implements partial.CompressedLayer.
+func (layer *bundleLayer) MediaType() (types.MediaType, error) {
+	return layer.desc.MediaType, nil
+}
+
+// bundleIndex implements v1.ImageIndex over a bundle.
+type bundleIndex struct {
+	//nolint:containedctx // v1.ImageIndex methods take no context, reads are bound to the loading context
+	ctx      context.Context
+	bundle   *Bundle
+	raw      []byte
+	digest   v1.Hash
+	manifest *v1.IndexManifest
+}
+
+// MediaType implements v1.ImageIndex.
+func (idx *bundleIndex) MediaType() (types.MediaType, error) {
+	if idx.manifest.MediaType != "" {
+		return idx.manifest.MediaType, nil
+	}
+
+	return types.OCIImageIndex, nil
+}
+
+// Digest implements v1.ImageIndex.
+func (idx *bundleIndex) Digest() (v1.Hash, error) {
+	return idx.digest, nil
+}
+
+// Size implements v1.ImageIndex.
+func (idx *bundleIndex) Size() (int64, error) {
+	return int64(len(idx.raw)), nil
+}
+
+// IndexManifest implements v1.ImageIndex.
+func (idx *bundleIndex) IndexManifest() (*v1.IndexManifest, error) {
+	return idx.manifest.DeepCopy(), nil
+}
+
+// RawManifest implements v1.ImageIndex.
+func (idx *bundleIndex) RawManifest() ([]byte, error) {
+	return idx.raw, nil
+}
+
+// Image implements v1.ImageIndex.
+func (idx *bundleIndex) Image(digest v1.Hash) (v1.Image, error) {
+	return idx.bundle.Image(idx.ctx, digest)
+}
+
+// ImageIndex implements v1.ImageIndex.
+func (idx *bundleIndex) ImageIndex(digest v1.Hash) (v1.ImageIndex, error) {
+	return idx.bundle.Index(idx.ctx, digest)
+}
@@ -0,0 +1,164 @@
+// Package relay provides two-phase image transfers through an intermediate store.
+package relay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/farcloser/quark/filesystem"
+)
+
+var (
+	// ErrNotFound indicates no object is stored under the requested key.
+	ErrNotFound = errors.New("object not found")
+	// ErrInvalidKey indicates an object key that is empty, absolute or escapes the store.
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Transport is an intermediate object store that fetched content is written to and read from,
+// instead of copying directly between registries (e.g., a filesystem bundle carried across an air gap,
+// or an object storage bucket shared by both sides of a security boundary).
+// Keys are slash-separated relative paths (e.g., "blobs/sha256/<hex>", "index.json").
+type Transport interface {
+	// Put stores the content of reader under key, replacing any existing object.
+	Put(ctx context.Context, key string, reader io.Reader) error
+	// Get opens the object stored under key. Returns an error wrapping ErrNotFound if absent.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Exists reports whether an object is stored under key.
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// Directory is a Transport storing objects as files under a root directory.
+type Directory struct {
+	root string
+}
+
+// NewDirectory creates a Transport storing objects under root.
+// The directory is created on first write.
+func NewDirectory(root string) *Directory {
+	return &Directory{root: root}
+}
+
+// Put implements Transport.
+// Objects are written to a temporary file and renamed, so readers never observe partial content.
+func (dir *Directory) Put(ctx context.Context, key string, reader io.Reader) error {
+	target, err := dir.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), filesystem.DirPermissionsDefault); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := io.Copy(tmp, &contextReader{ctx: ctx, reader: reader}); err != nil {
+		_ = tmp.Close()
+
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+
+	if err := os.Chmod(tmp.Name(), filesystem.FilePermissionsDefault); err != nil {
+		return fmt.Errorf("failed to set permissions on %s: %w", key, err)
+	}
+
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// Get implements Transport.
+func (dir *Directory) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	target, err := dir.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint:gosec // Key is validated to stay under the root directory
+	file, err := os.Open(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", key, err)
+	}
+
+	return file, nil
+}
+
+// Exists implements Transport.
+func (dir *Directory) Exists(_ context.Context, key string) (bool, error) {
+	target, err := dir.path(key)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = os.Stat(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %s: %w", key, err)
+	}
+
+	return true, nil
+}
+
+// path maps a key to a file path under the root directory.
+func (dir *Directory) path(key string) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir.root, filepath.FromSlash(cleaned)), nil
+}
+
+// cleanKey normalizes an object key, rejecting keys that are empty, absolute or escape the store.
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean(key)
+	if key == "" || path.IsAbs(key) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+
+	return cleaned, nil
+}
+
+// contextReader stops reading once the context is cancelled.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+// Read implements io.Reader.
+func (reader *contextReader) Read(buf []byte) (int, error) {
+	if err := reader.ctx.Err(); err != nil {
+		return 0, fmt.Errorf("transfer cancelled: %w", err)
+	}
+
+	//nolint:wrapcheck // io.EOF must be returned unwrapped
+	return reader.reader.Read(buf)
+}
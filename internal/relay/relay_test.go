@@ -0,0 +1,210 @@
+package relay_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
+	"github.com/farcloser/quark/internal/relay"
+)
+
+// INTENTION: Directory keys must stay under the root directory.
+func TestDirectory_InvalidKey(t *testing.T) {
+	t.Parallel()
+
+	dir := relay.NewDirectory(t.TempDir())
+
+	for _, key := range []string{"", "/etc/passwd", "..", "../escape", "blobs/../../escape"} {
+		if err := dir.Put(t.Context(), key, strings.NewReader("data")); !errors.Is(err, relay.ErrInvalidKey) {
+			t.Errorf("Put(%q) error = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
+
+// INTENTION: Directory Get reports missing objects with ErrNotFound, and Exists reports them as absent.
+func TestDirectory_Missing(t *testing.T) {
+	t.Parallel()
+
+	dir := relay.NewDirectory(t.TempDir())
+
+	if _, err := dir.Get(t.Context(), "index.json"); !errors.Is(err, relay.ErrNotFound) {
+		t.Errorf("Get() error = %v, want ErrNotFound", err)
+	}
+
+	exists, err := dir.Exists(t.Context(), "index.json")
+	if err != nil || exists {
+		t.Errorf("Exists() = %v, %v, want false, nil", exists, err)
+	}
+}
+
+// INTENTION: An image and a multi-platform index written to a bundle can be pushed from it
+// to a registry with unchanged digests (two-phase transfer).
+func TestBundle_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+
+	img, err := random.Image(256, 2)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+
+	idx, err := random.Index(128, 1, 2)
+	if err != nil {
+		t.Fatalf("failed to create random index: %v", err)
+	}
+
+	root := t.TempDir()
+	bundle := relay.NewBundle(relay.NewDirectory(root), zerolog.Nop())
+
+	imgDigest, err := bundle.WriteImage(t.Context(), "example.com/app:1.0", img)
+	if err != nil {
+		t.Fatalf("WriteImage() failed: %v", err)
+	}
+
+	idxDigest, err := bundle.WriteIndex(t.Context(), "example.com/multi:1.0", idx)
+	if err != nil {
+		t.Fatalf("WriteIndex() failed: %v", err)
+	}
+
+	// Rewriting an entry replaces it instead of duplicating it
+	if _, err := bundle.WriteImage(t.Context(), "example.com/app:1.0", img); err != nil {
+		t.Fatalf("WriteImage() again failed: %v", err)
+	}
+
+	entries, err := bundle.Entries(t.Context())
+	if err != nil {
+		t.Fatalf("Entries() failed: %v", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("Entries() = %d entries, want 2", len(entries))
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "oci-layout")); err != nil {
+		t.Errorf("oci-layout marker missing: %v", err)
+	}
+
+	// Second phase: read back from the bundle and push
+	imgEntry, err := bundle.Find(t.Context(), imgDigest)
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	loaded, err := bundle.Image(t.Context(), imgEntry.Digest)
+	if err != nil {
+		t.Fatalf("Image() failed: %v", err)
+	}
+
+	pushed, err := client.PushImage(t.Context(), host+"/mirror/app:1.0", loaded)
+	if err != nil {
+		t.Fatalf("PushImage() failed: %v", err)
+	}
+
+	if pushed != imgDigest {
+		t.Errorf("pushed image digest = %s, want %s", pushed, imgDigest)
+	}
+
+	idxEntry, err := bundle.Find(t.Context(), idxDigest)
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	loadedIdx, err := bundle.Index(t.Context(), idxEntry.Digest)
+	if err != nil {
+		t.Fatalf("Index() failed: %v", err)
+	}
+
+	pushedIdx, err := client.PushIndex(t.Context(), host+"/mirror/multi:1.0", loadedIdx)
+	if err != nil {
+		t.Fatalf("PushIndex() failed: %v", err)
+	}
+
+	if pushedIdx != idxDigest {
+		t.Errorf("pushed index digest = %s, want %s", pushedIdx, idxDigest)
+	}
+
+	remoteDigest, err := client.GetDigest(t.Context(), host+"/mirror/multi:1.0")
+	if err != nil {
+		t.Fatalf("GetDigest() failed: %v", err)
+	}
+
+	if remoteDigest != idxDigest {
+		t.Errorf("registry index digest = %s, want %s", remoteDigest, idxDigest)
+	}
+}
+
+// INTENTION: Blobs tampered with in the intermediate store are rejected when read back.
+func TestBundle_CorruptedBlob(t *testing.T) {
+	t.Parallel()
+
+	layer, err := random.Layer(256, "application/vnd.oci.image.layer.v1.tar+gzip")
+	if err != nil {
+		t.Fatalf("failed to create random layer: %v", err)
+	}
+
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	if err != nil {
+		t.Fatalf("failed to create image: %v", err)
+	}
+
+	root := t.TempDir()
+	bundle := relay.NewBundle(relay.NewDirectory(root), zerolog.Nop())
+
+	imgDigest, err := bundle.WriteImage(t.Context(), "example.com/app:1.0", img)
+	if err != nil {
+		t.Fatalf("WriteImage() failed: %v", err)
+	}
+
+	layerDigest, err := layer.Digest()
+	if err != nil {
+		t.Fatalf("failed to get layer digest: %v", err)
+	}
+
+	blobPath := filepath.Join(root, "blobs", layerDigest.Algorithm, layerDigest.Hex)
+	if err := os.WriteFile(blobPath, []byte("tampered"), 0o600); err != nil {
+		t.Fatalf("failed to tamper blob: %v", err)
+	}
+
+	hash, err := v1.NewHash(imgDigest)
+	if err != nil {
+		t.Fatalf("failed to parse digest: %v", err)
+	}
+
+	loaded, err := bundle.Image(t.Context(), hash)
+	if err != nil {
+		t.Fatalf("Image() failed: %v", err)
+	}
+
+	loadedLayer, err := loaded.LayerByDigest(layerDigest)
+	if err != nil {
+		t.Fatalf("LayerByDigest() failed: %v", err)
+	}
+
+	reader, err := loadedLayer.Compressed()
+	if err != nil {
+		t.Fatalf("Compressed() failed: %v", err)
+	}
+	defer reader.Close()
+
+	if _, err := io.ReadAll(reader); !errors.Is(err, relay.ErrBlobCorrupted) {
+		t.Errorf("reading tampered blob error = %v, want ErrBlobCorrupted", err)
+	}
+}
@@ -0,0 +1,91 @@
+package relay
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/farcloser/quark/filesystem"
+)
+
+// WriteTar writes the directory content as a tar archive (e.g., an OCI layout tarball).
+// Entries are written in lexical order with fixed ownership, permissions and timestamps,
+// so the same content always produces the same archive.
+func (dir *Directory) WriteTar(ctx context.Context, writer io.Writer) error {
+	archive := tar.NewWriter(writer)
+
+	err := filepath.WalkDir(dir.root, func(current string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("archive cancelled: %w", err)
+		}
+
+		rel, err := filepath.Rel(dir.root, current)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", current, err)
+		}
+
+		// Skip the root itself and in-flight temporary files
+		if rel == "." || strings.HasPrefix(entry.Name(), ".tmp-") {
+			return nil
+		}
+
+		header := &tar.Header{
+			Name:    filepath.ToSlash(rel),
+			ModTime: time.Unix(0, 0),
+			Format:  tar.FormatPAX,
+		}
+
+		if entry.IsDir() {
+			header.Typeflag = tar.TypeDir
+			header.Name += "/"
+			header.Mode = int64(filesystem.DirPermissionsDefault)
+
+			return archive.WriteHeader(header)
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", rel, err)
+		}
+
+		header.Typeflag = tar.TypeReg
+		header.Mode = int64(filesystem.FilePermissionsDefault)
+		header.Size = info.Size()
+
+		if err := archive.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write header for %s: %w", rel, err)
+		}
+
+		//nolint:gosec // Paths come from walking the bundle directory
+		file, err := os.Open(current)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", rel, err)
+		}
+		defer file.Close()
+
+		if _, err := io.Copy(archive, file); err != nil {
+			return fmt.Errorf("failed to archive %s: %w", rel, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", dir.root, err)
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to finalize archive: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,276 @@
+// Package repodocs reads and updates the documentation registries show on repository pages: the short
+// description and README (full description) of Docker Hub repositories, and the description of Quay repositories.
+package repodocs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// DockerHubAPIURL is the root of the Docker Hub API.
+	DockerHubAPIURL = "https://hub.docker.com"
+	// QuayAPIURL is the root of the quay.io API.
+	QuayAPIURL = "https://quay.io/api/v1"
+
+	// MaxShortDescription is the length limit of Docker Hub short descriptions.
+	MaxShortDescription = 100
+	// MaxFullDescription is the length limit of Docker Hub full descriptions.
+	MaxFullDescription = 25000
+)
+
+var (
+	// ErrRequestFailed indicates the registry API rejected a request.
+	ErrRequestFailed = errors.New("repository API request failed")
+	// ErrRepositoryNotFound indicates the repository does not exist, or the credentials cannot read it.
+	ErrRepositoryNotFound = errors.New("repository not found")
+	// ErrCredentialsRequired indicates a client without credentials, which cannot update repositories.
+	ErrCredentialsRequired = errors.New("repository API credentials are required")
+
+	errNotFound = errors.New("not found")
+)
+
+// Docs is the documentation of a repository.
+type Docs struct {
+	// Short is the one-line description (Docker Hub only, shown in search results).
+	Short string
+	// Full is the Markdown README shown on the repository page.
+	Full string
+}
+
+// Client reads and updates repository documentation.
+type Client interface {
+	// Docs returns the documentation of the repository namespace/name, or ErrRepositoryNotFound.
+	Docs(ctx context.Context, namespace, name string) (Docs, error)
+	// Update replaces the documentation of the repository namespace/name.
+	Update(ctx context.Context, namespace, name string, docs Docs) error
+}
+
+// DockerHub manages the documentation of Docker Hub repositories.
+type DockerHub struct {
+	apiURL   string
+	username string
+	password string
+
+	// Token obtained by logging in with username and password, on first use
+	token string
+	// HTTP client sending the requests (http.DefaultClient when nil)
+	client *http.Client
+}
+
+// NewDockerHub creates a Docker Hub client logging in with username and password (a personal access token with
+// read and write scope, or an organization access token).
+func NewDockerHub(username, password string) *DockerHub {
+	return &DockerHub{apiURL: DockerHubAPIURL, username: username, password: password}
+}
+
+// WithAPIURL sets the API root.
+func (hub *DockerHub) WithAPIURL(apiURL string) *DockerHub {
+	hub.apiURL = strings.TrimSuffix(apiURL, "/")
+
+	return hub
+}
+
+// WithHTTPClient sets the HTTP client sending the requests.
+func (hub *DockerHub) WithHTTPClient(httpClient *http.Client) *DockerHub {
+	hub.client = httpClient
+
+	return hub
+}
+
+// hubRepository is a Docker Hub repository.
+type hubRepository struct {
+	Description     string `json:"description"`
+	FullDescription string `json:"full_description"` //nolint:tagliatelle // Docker Hub API
+}
+
+// Docs returns the documentation of the repository namespace/name ("library" for official images).
+func (hub *DockerHub) Docs(ctx context.Context, namespace, name string) (Docs, error) {
+	if err := hub.login(ctx); err != nil {
+		return Docs{}, err
+	}
+
+	var repo hubRepository
+
+	err := request(ctx, hub.client, http.MethodGet, hub.repositoryURL(namespace, name), hub.token, nil, &repo)
+	if errors.Is(err, errNotFound) {
+		return Docs{}, fmt.Errorf("%w: %s/%s", ErrRepositoryNotFound, namespace, name)
+	}
+
+	if err != nil {
+		return Docs{}, err
+	}
+
+	return Docs{Short: repo.Description, Full: repo.FullDescription}, nil
+}
+
+// Update replaces the short and full description of the repository namespace/name.
+func (hub *DockerHub) Update(ctx context.Context, namespace, name string, docs Docs) error {
+	if hub.username == "" || hub.password == "" {
+		return ErrCredentialsRequired
+	}
+
+	if err := hub.login(ctx); err != nil {
+		return err
+	}
+
+	payload := hubRepository{Description: docs.Short, FullDescription: docs.Full}
+
+	return request(ctx, hub.client, http.MethodPatch, hub.repositoryURL(namespace, name), hub.token, payload, nil)
+}
+
+// repositoryURL returns the API URL of the repository namespace/name.
+func (hub *DockerHub) repositoryURL(namespace, name string) string {
+	return hub.apiURL + "/v2/repositories/" + url.PathEscape(namespace) + "/" + url.PathEscape(name) + "/"
+}
+
+// login exchanges the credentials for a token, once. Without credentials, public repositories are read
+// anonymously.
+func (hub *DockerHub) login(ctx context.Context) error {
+	if hub.token != "" || hub.username == "" || hub.password == "" {
+		return nil
+	}
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+
+	payload := map[string]string{"username": hub.username, "password": hub.password}
+
+	if err := request(ctx, hub.client, http.MethodPost, hub.apiURL+"/v2/users/login", "", payload, &resp); err != nil {
+		return fmt.Errorf("failed to log in to Docker Hub: %w", err)
+	}
+
+	hub.token = resp.Token
+
+	return nil
+}
+
+// Quay manages the description of Quay repositories.
+type Quay struct {
+	apiURL string
+	token  string
+
+	// HTTP client sending the requests (http.DefaultClient when nil)
+	client *http.Client
+}
+
+// NewQuay creates a client of the Quay API at apiURL (QuayAPIURL, or "https://<host>/api/v1" for a self-hosted
+// Quay), authenticating with token (an OAuth application token with repository administration scope).
+func NewQuay(apiURL, token string) *Quay {
+	return &Quay{apiURL: strings.TrimSuffix(apiURL, "/"), token: token}
+}
+
+// WithHTTPClient sets the HTTP client sending the requests.
+func (quay *Quay) WithHTTPClient(httpClient *http.Client) *Quay {
+	quay.client = httpClient
+
+	return quay
+}
+
+// Docs returns the description of the repository namespace/name as Full (Quay has no short description).
+func (quay *Quay) Docs(ctx context.Context, namespace, name string) (Docs, error) {
+	var repo struct {
+		Description string `json:"description"`
+	}
+
+	err := request(ctx, quay.client, http.MethodGet, quay.repositoryURL(namespace, name), quay.token, nil, &repo)
+	if errors.Is(err, errNotFound) {
+		return Docs{}, fmt.Errorf("%w: %s/%s", ErrRepositoryNotFound, namespace, name)
+	}
+
+	if err != nil {
+		return Docs{}, err
+	}
+
+	return Docs{Full: repo.Description}, nil
+}
+
+// Update replaces the description of the repository namespace/name with docs.Full (Quay has no short
+// description).
+func (quay *Quay) Update(ctx context.Context, namespace, name string, docs Docs) error {
+	if quay.token == "" {
+		return ErrCredentialsRequired
+	}
+
+	payload := map[string]string{"description": docs.Full}
+
+	return request(ctx, quay.client, http.MethodPut, quay.repositoryURL(namespace, name), quay.token, payload, nil)
+}
+
+// repositoryURL returns the API URL of the repository namespace/name.
+func (quay *Quay) repositoryURL(namespace, name string) string {
+	return quay.apiURL + "/repository/" + url.PathEscape(namespace) + "/" + url.PathEscape(name)
+}
+
+// request sends a JSON request to target with token as bearer (anonymous when empty), and decodes the JSON
+// response into out (when not nil).
+func request(ctx context.Context, httpClient *http.Client, method, target, token string, payload, out any) error {
+	var body io.Reader
+
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+
+		body = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, target, err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %w: %s %s", ErrRequestFailed, errNotFound, method, target)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		//nolint:mnd // Error bodies are short JSON documents
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		return fmt.Errorf("%w: %s %s: %s %s", ErrRequestFailed, method, target, resp.Status,
+			strings.TrimSpace(string(detail)))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response of %s %s: %w", method, target, err)
+	}
+
+	return nil
+}
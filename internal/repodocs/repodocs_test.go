@@ -0,0 +1,117 @@
+package repodocs_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/farcloser/quark/internal/repodocs"
+)
+
+// INTENTION: Docker Hub documentation is read and updated with the token obtained by logging in once.
+func TestDockerHub_Update(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu     sync.Mutex
+		logins int
+		repo   = map[string]string{"description": "old", "full_description": "# Old"}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if req.URL.Path == "/v2/users/login" {
+			logins++
+
+			_, _ = writer.Write([]byte(`{"token":"jwt"}`))
+
+			return
+		}
+
+		if req.Header.Get("Authorization") != "Bearer jwt" || req.URL.Path != "/v2/repositories/my-org/app/" {
+			writer.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		if req.Method == http.MethodPatch {
+			if err := json.NewDecoder(req.Body).Decode(&repo); err != nil {
+				writer.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+		}
+
+		_ = json.NewEncoder(writer).Encode(repo)
+	}))
+	t.Cleanup(server.Close)
+
+	hub := repodocs.NewDockerHub("user", "pat").WithAPIURL(server.URL)
+	docs := repodocs.Docs{Short: "The app", Full: "# App\n"}
+
+	if err := hub.Update(t.Context(), "my-org", "app", docs); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	got, err := hub.Docs(t.Context(), "my-org", "app")
+	if err != nil || got != docs {
+		t.Errorf("Docs() = %+v, %v, want %+v", got, err, docs)
+	}
+
+	if _, err := hub.Docs(t.Context(), "my-org", "missing"); !errors.Is(err, repodocs.ErrRepositoryNotFound) {
+		t.Errorf("Docs(missing) error = %v, want %v", err, repodocs.ErrRepositoryNotFound)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if logins != 1 {
+		t.Errorf("logins = %d, want 1", logins)
+	}
+}
+
+// INTENTION: Quay repositories only have a description, updated from the README, which requires a token.
+func TestQuay_Update(t *testing.T) {
+	t.Parallel()
+
+	var description string
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer oauth" || req.URL.Path != "/api/v1/repository/my-org/app" {
+			writer.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		if req.Method == http.MethodPut {
+			var payload map[string]string
+			_ = json.NewDecoder(req.Body).Decode(&payload)
+			description = payload["description"]
+		}
+
+		_ = json.NewEncoder(writer).Encode(map[string]string{"description": description})
+	}))
+	t.Cleanup(server.Close)
+
+	quay := repodocs.NewQuay(server.URL+"/api/v1", "oauth")
+
+	if err := quay.Update(t.Context(), "my-org", "app", repodocs.Docs{Short: "ignored", Full: "# App"}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if got, err := quay.Docs(t.Context(), "my-org", "app"); err != nil || got.Full != "# App" || got.Short != "" {
+		t.Errorf("Docs() = %+v, %v, want the README as description", got, err)
+	}
+
+	anonymous := repodocs.NewQuay(server.URL+"/api/v1", "")
+	if err := anonymous.Update(t.Context(), "my-org", "app", repodocs.Docs{}); !errors.Is(
+		err, repodocs.ErrCredentialsRequired,
+	) {
+		t.Errorf("Update() without token error = %v, want %v", err, repodocs.ErrCredentialsRequired)
+	}
+}
@@ -0,0 +1,157 @@
+// Package retention decides which manifests of a repository a retention policy keeps, and which a prune deletes.
+package retention
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// versionTag matches version tags: "1.4", "v1.4.2", "1.4.2-alpine" (variant "alpine").
+var versionTag = regexp.MustCompile(`^v?(\d+)\.(\d+)(?:\.(\d+))?(?:-(.+))?$`)
+
+// Policy is a retention policy. Manifests referenced by a kept tag are kept, with all their tags; the others
+// lose every tag to the policy and are deleted, once older than UntaggedAge. A tag is lost to the policy only when
+// it is a version tag beyond KeepPerMinor or matches DropTags: other tags (e.g., "latest") keep their manifests.
+type Policy struct {
+	// KeepPerMinor keeps the newest version tags (by version, e.g., 1.4.2 before 1.4.1) of each minor version
+	// (1.4) and variant (e.g., "-alpine"), up to this many per minor version.
+	KeepPerMinor int
+	// KeepTags keeps the manifests referenced by tags matching these patterns (path.Match, e.g., "stable",
+	// "*-lts"), including under their other tags.
+	KeepTags []string
+	// DropTags gives up the tags matching these patterns (path.Match, e.g., "pr-*"), which are not versions.
+	DropTags []string
+	// UntaggedAge is how old manifests without a kept tag must be to be deleted (any age when zero). Manifests
+	// whose creation time is unknown, or the reproducible epoch (1970-01-01), are never deleted.
+	UntaggedAge time.Duration
+}
+
+// Manifest is a manifest of a repository, with its tags.
+type Manifest struct {
+	Digest string
+	Tags   []string
+	// Created is the creation time of the image (zero when unknown).
+	Created time.Time
+	// Children are the digests of the manifests an index references, kept with it.
+	Children []string
+}
+
+// version is a parsed version tag.
+type version struct {
+	digest string
+	// minor groups versions of the same minor version and variant (e.g., "1.4-alpine")
+	minor string
+	patch int
+}
+
+// Evaluate returns the manifests the policy keeps and those it deletes, at time now, in the order of manifests.
+func (policy Policy) Evaluate(manifests []Manifest, now time.Time) (keep, drop []Manifest) {
+	kept := map[string]bool{}
+
+	// The newest versions of each minor version
+	versions := map[string][]version{}
+
+	for _, manifest := range manifests {
+		for _, tag := range manifest.Tags {
+			if matches(policy.KeepTags, tag) {
+				kept[manifest.Digest] = true
+			}
+
+			if parsed, ok := parseVersion(tag, manifest.Digest); ok {
+				versions[parsed.minor] = append(versions[parsed.minor], parsed)
+			} else if !matches(policy.DropTags, tag) {
+				// Tags other than versions are only given up when the policy drops them
+				kept[manifest.Digest] = true
+			}
+		}
+	}
+
+	for _, minor := range versions {
+		sort.SliceStable(minor, func(i, j int) bool {
+			return minor[i].patch > minor[j].patch
+		})
+
+		for idx := 0; idx < len(minor) && idx < policy.KeepPerMinor; idx++ {
+			kept[minor[idx].digest] = true
+		}
+	}
+
+	for _, manifest := range manifests {
+		if !kept[manifest.Digest] && !policy.expired(manifest, now) {
+			kept[manifest.Digest] = true
+		}
+	}
+
+	// Deleting the platform manifests of a kept index (e.g., under per-platform tags) would break it
+	for changed := true; changed; {
+		changed = false
+
+		for _, manifest := range manifests {
+			if !kept[manifest.Digest] {
+				continue
+			}
+
+			for _, child := range manifest.Children {
+				if !kept[child] {
+					kept[child] = true
+					changed = true
+				}
+			}
+		}
+	}
+
+	for _, manifest := range manifests {
+		if kept[manifest.Digest] {
+			keep = append(keep, manifest)
+		} else {
+			drop = append(drop, manifest)
+		}
+	}
+
+	return keep, drop
+}
+
+// matches reports whether tag matches one of patterns.
+func matches(patterns []string, tag string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, tag); err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expired reports whether a manifest without kept tags is old enough to be deleted.
+func (policy Policy) expired(manifest Manifest, now time.Time) bool {
+	// Reproducible builds date their images at the epoch: their age is unknown
+	if manifest.Created.IsZero() || !manifest.Created.After(time.Unix(0, 0)) {
+		return false
+	}
+
+	return now.Sub(manifest.Created) >= policy.UntaggedAge
+}
+
+// parseVersion parses a version tag of the manifest digest.
+func parseVersion(tag, digest string) (version, bool) {
+	match := versionTag.FindStringSubmatch(tag)
+	if match == nil {
+		return version{}, false
+	}
+
+	minor := match[1] + "." + match[2]
+	if match[4] != "" {
+		minor += "-" + match[4]
+	}
+
+	// "1.4" is the oldest of the 1.4 versions
+	patch := -1
+	if match[3] != "" {
+		patch, _ = strconv.Atoi(match[3])
+	}
+
+	return version{digest: digest, minor: minor, patch: patch}, true
+}
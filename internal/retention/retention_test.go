@@ -0,0 +1,129 @@
+package retention_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/farcloser/quark/internal/retention"
+)
+
+// digests returns the digests of manifests, in order.
+func digests(manifests []retention.Manifest) []string {
+	var out []string
+	for _, manifest := range manifests {
+		out = append(out, manifest.Digest)
+	}
+
+	return out
+}
+
+// INTENTION: A policy keeps the newest versions of each minor version (variants apart) and anything a protected
+// tag references, under all its tags; the other manifests (older versions, dropped tags) are deleted once old
+// enough, never when their age is unknown.
+func TestPolicy_Evaluate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := now.Add(-60 * 24 * time.Hour)
+	recent := now.Add(-24 * time.Hour)
+
+	manifests := []retention.Manifest{
+		{Digest: "sha256:140", Tags: []string{"1.4.0"}, Created: old},
+		{Digest: "sha256:141", Tags: []string{"1.4.1"}, Created: old},
+		{Digest: "sha256:142", Tags: []string{"1.4.2", "1.4"}, Created: old},
+		{Digest: "sha256:1410", Tags: []string{"v1.4.10"}, Created: old},
+		{Digest: "sha256:141a", Tags: []string{"1.4.1-alpine"}, Created: old},
+		{Digest: "sha256:130", Tags: []string{"1.3.0", "stable"}, Created: old},
+		{Digest: "sha256:120", Tags: []string{"1.2.0", "1.2.0-lts"}, Created: old},
+		{Digest: "sha256:110", Tags: []string{"1.1.0"}, Created: old},
+		{Digest: "sha256:pr", Tags: []string{"pr-42"}, Created: recent},
+		{Digest: "sha256:main", Tags: []string{"main"}, Created: old},
+		{Digest: "sha256:unknown", Tags: []string{"nightly"}},
+	}
+
+	policy := retention.Policy{
+		KeepPerMinor: 2,
+		KeepTags:     []string{"stable", "*-lts"},
+		DropTags:     []string{"pr-*", "main"},
+		UntaggedAge:  30 * 24 * time.Hour,
+	}
+
+	keep, drop := policy.Evaluate(manifests, now)
+
+	wantKeep := []string{
+		"sha256:142", "sha256:1410", "sha256:141a", "sha256:130", "sha256:120", "sha256:110", "sha256:pr",
+		"sha256:unknown",
+	}
+	if got := digests(keep); !slices.Equal(got, wantKeep) {
+		t.Errorf("keep = %v, want %v", got, wantKeep)
+	}
+
+	wantDrop := []string{"sha256:140", "sha256:141", "sha256:main"}
+	if got := digests(drop); !slices.Equal(got, wantDrop) {
+		t.Errorf("drop = %v, want %v", got, wantDrop)
+	}
+
+	// Without an age, anything not kept goes
+	policy.UntaggedAge = 0
+
+	if _, drop := policy.Evaluate(manifests, now); !slices.Contains(digests(drop), "sha256:pr") {
+		t.Errorf("drop without age = %v, want recent manifests too", digests(drop))
+	}
+}
+
+// INTENTION: Deleting a manifest only follows from the policy: tags it does not drop (e.g., "latest") keep their
+// manifest, the platform manifests of a kept index stay even when their own version tags are outdated, and images
+// dated at the reproducible epoch are never too old.
+func TestPolicy_Evaluate_Keeps(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := now.Add(-60 * 24 * time.Hour)
+	policy := retention.Policy{KeepPerMinor: 1, UntaggedAge: 30 * 24 * time.Hour}
+
+	tests := []struct {
+		name      string
+		manifests []retention.Manifest
+		wantDrop  []string
+	}{
+		{
+			name: "tags other than versions",
+			manifests: []retention.Manifest{
+				{Digest: "sha256:latest", Tags: []string{"1.0.0", "latest"}, Created: old},
+				{Digest: "sha256:main", Tags: []string{"main"}, Created: old},
+				{Digest: "sha256:101", Tags: []string{"1.0.1"}, Created: old},
+			},
+		},
+		{
+			name: "children of a kept index",
+			manifests: []retention.Manifest{
+				{Digest: "sha256:amd64", Tags: []string{"1.0.0-amd64"}, Created: old},
+				{Digest: "sha256:index", Tags: []string{"1.0.0"}, Created: old, Children: []string{"sha256:amd64"}},
+				{Digest: "sha256:rebuilt-amd64", Tags: []string{"1.0.1-amd64"}, Created: old},
+				{Digest: "sha256:090", Tags: []string{"0.9.0"}, Created: old},
+				{Digest: "sha256:091", Tags: []string{"0.9.1"}, Created: old},
+			},
+			wantDrop: []string{"sha256:090"},
+		},
+		{
+			name: "reproducible epoch",
+			manifests: []retention.Manifest{
+				{Digest: "sha256:100", Tags: []string{"1.0.0"}, Created: time.Unix(0, 0)},
+				{Digest: "sha256:101", Tags: []string{"1.0.1"}, Created: old},
+				{Digest: "sha256:102", Tags: []string{"1.0.2"}, Created: old},
+			},
+			wantDrop: []string{"sha256:101"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, drop := policy.Evaluate(tt.manifests, now); !slices.Equal(digests(drop), tt.wantDrop) {
+				t.Errorf("drop = %v, want %v", digests(drop), tt.wantDrop)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Package subprocess runs external tools (trivy, dockle, op) bound to a context, with graceful termination
+// and stderr reported in errors.
+package subprocess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DefaultGrace is how long a cancelled tool has to exit after SIGTERM before it is killed.
+const DefaultGrace = 10 * time.Second
+
+// maxStderr is the maximum length of the stderr tail included in errors.
+const maxStderr = 2048
+
+// graceContextKey is the context key under which the termination grace period is stored.
+type graceContextKey struct{}
+
+// WithGrace returns a context carrying the termination grace period of tools started with it.
+func WithGrace(ctx context.Context, grace time.Duration) context.Context {
+	return context.WithValue(ctx, graceContextKey{}, grace)
+}
+
+// Grace returns the termination grace period carried by ctx (DefaultGrace if unset).
+func Grace(ctx context.Context) time.Duration {
+	if grace, ok := ctx.Value(graceContextKey{}).(time.Duration); ok && grace >= 0 {
+		return grace
+	}
+
+	return DefaultGrace
+}
+
+// Command returns a command bound to ctx: when ctx is done (cancelled or past its deadline), the process
+// receives SIGTERM, then SIGKILL if it is still running after the grace period carried by ctx.
+// The grace period also bounds how long Wait waits for output pipes after the process exits.
+func Command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+
+	cmd.WaitDelay = Grace(ctx)
+
+	return cmd
+}
+
+// Error wraps the error of a failed command with the reason it was stopped (context cancellation
+// or deadline) and the tail of its stderr, for post-mortem.
+// When stderr is empty, the stderr captured by Output (exec.ExitError.Stderr) is used.
+func Error(ctx context.Context, err error, stderr string) error {
+	if err == nil {
+		return nil
+	}
+
+	if stderr == "" {
+		if exitErr := (*exec.ExitError)(nil); errors.As(err, &exitErr) {
+			stderr = string(exitErr.Stderr)
+		}
+	}
+
+	if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+		err = fmt.Errorf("%w: %w", ctxErr, err)
+	}
+
+	if tail := Tail(stderr); tail != "" {
+		return fmt.Errorf("%w (stderr: %s)", err, tail)
+	}
+
+	return err
+}
+
+// Tail returns the end of a tool output, trimmed and bounded in length.
+func Tail(output string) string {
+	output = strings.TrimSpace(output)
+
+	if len(output) > maxStderr {
+		output = "..." + output[len(output)-maxStderr:]
+	}
+
+	return output
+}
@@ -0,0 +1,118 @@
+package subprocess_test
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/farcloser/quark/internal/subprocess"
+)
+
+// INTENTION: A cancelled command gets SIGTERM and the grace period to exit cleanly (its TERM handler runs),
+// and a command ignoring SIGTERM is killed once the grace period expires.
+func TestCommand_Termination(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{
+			name:   "graceful",
+			script: `trap 'echo terminated; exit 0' TERM; echo ready; while :; do sleep 0.05; done`,
+			want:   "terminated",
+		},
+		{
+			name:   "killed",
+			script: `trap '' TERM; echo ready; while :; do sleep 0.05; done`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(subprocess.WithGrace(context.Background(), 200*time.Millisecond))
+			defer cancel()
+
+			cmd := subprocess.Command(ctx, "sh", "-c", test.script)
+
+			stdout, err := cmd.StdoutPipe()
+			if err != nil {
+				t.Fatalf("StdoutPipe() error = %v", err)
+			}
+
+			if err := cmd.Start(); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			ready := make([]byte, len("ready\n"))
+			if _, err := stdout.Read(ready); err != nil {
+				t.Fatalf("failed to wait for the command: %v", err)
+			}
+
+			cancel()
+
+			started := time.Now()
+
+			var output strings.Builder
+
+			buffer := make([]byte, 64)
+			for {
+				count, err := stdout.Read(buffer)
+				output.Write(buffer[:count])
+
+				if err != nil {
+					break
+				}
+			}
+
+			_ = cmd.Wait()
+
+			if elapsed := time.Since(started); elapsed > 5*time.Second {
+				t.Fatalf("command took %s to stop", elapsed)
+			}
+
+			if !strings.Contains(output.String(), test.want) {
+				t.Errorf("output = %q, want %q", output.String(), test.want)
+			}
+		})
+	}
+}
+
+// INTENTION: Errors of failed commands carry the cancellation reason and the stderr of the command.
+func TestError(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	ctx := context.Background()
+
+	_, err := subprocess.Command(ctx, "sh", "-c", "echo 'database not found' >&2; exit 3").Output()
+
+	wrapped := subprocess.Error(ctx, err, "")
+	if !strings.Contains(wrapped.Error(), "database not found") {
+		t.Errorf("Error() = %v, want stderr included", wrapped)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if err := subprocess.Error(cancelled, errors.New("signal: terminated"), ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("Error() = %v, want %v", err, context.Canceled)
+	}
+
+	if subprocess.Error(ctx, nil, "stderr") != nil {
+		t.Error("Error(nil) != nil")
+	}
+}
@@ -0,0 +1,39 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// ErrSignatureSubjectChanged indicates signatures cannot be copied because the destination manifest differs from
+// the signed source manifest (platforms filtered, previous digest recorded).
+var ErrSignatureSubjectChanged = errors.New("destination digest differs from the signed source digest")
+
+// copySignatures copies the notation signatures of the source manifest (sourceDigest) to the destination, which
+// must have the same digest: signatures are bound to the manifest they sign.
+func (syncer *Syncer) copySignatures(
+	ctx context.Context,
+	srcImage, dstImage, sourceDigest string,
+	result *Result,
+) error {
+	if result.Digest != sourceDigest {
+		return fmt.Errorf("%w: %s, destination %s", ErrSignatureSubjectChanged, sourceDigest, result.Digest)
+	}
+
+	signatures, err := syncer.srcClient.CopyReferrers(ctx, stripTag(srcImage)+"@"+sourceDigest, dstImage,
+		registry.NotationSignatureArtifactType, syncer.dstClient)
+	if err != nil {
+		return fmt.Errorf("failed to copy signatures: %w", err)
+	}
+
+	result.Signatures = signatures
+
+	syncer.log.Info().
+		Int("signatures", len(signatures)).
+		Msg("notation signatures copied")
+
+	return nil
+}
@@ -4,14 +4,67 @@ package sync
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/rs/zerolog"
 
 	"github.com/farcloser/quark/internal/registry"
 )
 
+// PreviousDigestAnnotation is the manifest annotation recording the digest a tag pointed to
+// before it was overwritten by a sync. It provides a lightweight rollback pointer.
+const PreviousDigestAnnotation = "quark.dev/previous-digest"
+
+// DefaultPlatforms are the platforms of multi-platform images synced when Options.Platforms is empty.
+//
+//nolint:gochecknoglobals // Immutable default
+var DefaultPlatforms = []string{"linux/amd64", "linux/arm64"}
+
+// Options configures a sync.
+type Options struct {
+	// Platforms are the platforms of multi-platform images to sync (e.g., "linux/arm64"); DefaultPlatforms
+	// when empty. Other platforms of the source index are left out of the destination manifest list.
+	Platforms []string
+	// RecordPreviousDigest annotates the pushed manifest with the digest the destination tag
+	// pointed to before the sync (see PreviousDigestAnnotation).
+	RecordPreviousDigest bool
+	// VerifyBlobs hashes every layer blob as it streams from source to destination and fails fast
+	// when its digest or size does not match the source manifest.
+	// Blobs mounted within a registry or already present at the destination never transit the client
+	// and are not verified.
+	VerifyBlobs bool
+	// VerifyPushedDigest asks the destination, once a manifest list is pushed, for the digest its tag points to,
+	// and fails with registry.ErrManifestRewritten when it differs from the digest computed locally
+	// (registries or proxies rewriting manifests).
+	VerifyPushedDigest bool
+	// CopySignatures copies the notation signatures of the source manifest (OCI referrers) to the destination.
+	// The destination must keep the source digest: multi-platform images are copied verbatim (Platforms is
+	// ignored), and syncs recording the previous digest fail with ErrSignatureSubjectChanged.
+	CopySignatures bool
+}
+
+// Result describes the outcome of a sync.
+type Result struct {
+	// Digest is the destination digest (computed locally, not from registry).
+	Digest string
+	// PreviousDigest is the digest recorded in PreviousDigestAnnotation, if any.
+	PreviousDigest string
+	// Mounted reports whether blobs were cross-repository mounted (source and destination on the same registry).
+	Mounted bool
+	// Chart reports whether the synced artifact is a Helm chart.
+	Chart bool
+	// ArtifactType is the artifact type when a non-image artifact (WASM module, signature, chart, ...)
+	// was copied verbatim; empty for container images.
+	ArtifactType string
+	// VerifiedBlobs lists the layer digests verified while copying (sorted), when Options.VerifyBlobs is set.
+	VerifiedBlobs []string
+	// Signatures lists the digests of the notation signatures copied, when Options.CopySignatures is set.
+	Signatures []string
+}
+
 // Syncer handles image synchronization between registries.
 type Syncer struct {
 	srcClient *registry.Client
@@ -33,27 +86,203 @@ func NewSyncer(srcClient, dstClient *registry.Client, log zerolog.Logger) *Synce
 // This matches the approach used by black/scripts/sync-images.sh.
 // Returns the destination image digest (computed locally, not from registry for security).
 func (syncer *Syncer) SyncImage(ctx context.Context, srcImage, dstImage string) (string, error) {
+	result, err := syncer.SyncImageWithOptions(ctx, srcImage, dstImage, Options{})
+	if err != nil {
+		return "", err
+	}
+
+	return result.Digest, nil
+}
+
+// SyncImageWithOptions synchronizes an image from source to destination using the given options.
+func (syncer *Syncer) SyncImageWithOptions(
+	ctx context.Context,
+	srcImage, dstImage string,
+	opts Options,
+) (*Result, error) {
 	syncer.log.Debug().
 		Str("source", srcImage).
 		Str("destination", dstImage).
 		Msg("starting image sync")
 
-	// Check if source exists and get descriptor
-	desc, err := syncer.srcClient.GetImage(ctx, srcImage)
+	// Check if source exists and get its manifest
+	desc, err := syncer.srcClient.GetManifest(ctx, srcImage)
 	if err != nil {
-		return "", fmt.Errorf("failed to get source image: %w", err)
+		return nil, fmt.Errorf("failed to get source image: %w", err)
+	}
+
+	// Same-registry promotion: blobs are never downloaded, the destination mounts them
+	// from the source repository (layers fetched by remote are mountable).
+	// Credentials are shared since both references resolve to the same registry.
+	mounted := registry.SameRegistry(srcImage, dstImage)
+	if mounted {
+		syncer.log.Info().Msg("source and destination share a registry, using cross-repository blob mounts")
+	}
+
+	// Mounted blobs never transit the client, there is nothing to verify
+	var verifier *blobVerifier
+	if opts.VerifyBlobs && !mounted {
+		verifier = newBlobVerifier()
+	} else if opts.VerifyBlobs {
+		syncer.log.Info().Msg("blobs are mounted within the registry, skipping blob verification")
 	}
 
-	// Determine if this is an index (multi-platform) or single image
-	if desc.MediaType.IsIndex() {
+	info, err := registry.ParseManifestInfo(desc.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect source manifest: %w", err)
+	}
+
+	if info.MediaType == "" {
+		info.MediaType = string(desc.MediaType)
+	}
+
+	var result *Result
+
+	// Determine if this is an artifact, an index (multi-platform) or single image
+	switch {
+	case desc.MediaType.IsIndex() && info.IsArtifact():
+		syncer.log.Info().Str("artifact_type", info.Kind()).Msg("detected artifact index")
+
+		// Artifact indexes (e.g., WASM multi-target, signature bundles) are copied verbatim,
+		// without platform resolution
+		result, err = syncer.syncIndexVerbatim(ctx, srcImage, dstImage, verifier)
+	case desc.MediaType.IsIndex() && opts.CopySignatures:
+		syncer.log.Debug().Msg("detected multi-platform image index, copied verbatim to keep its signatures valid")
+
+		result, err = syncer.syncIndexVerbatim(ctx, srcImage, dstImage, verifier)
+	case desc.MediaType.IsIndex():
 		syncer.log.Debug().Msg("detected multi-platform image index")
 
-		return syncer.syncMultiPlatform(ctx, srcImage, dstImage)
+		result, err = syncer.syncMultiPlatform(ctx, srcImage, dstImage, opts, verifier)
+	case info.IsArtifact():
+		syncer.log.Info().
+			Str("artifact_type", info.Kind()).
+			Bool("chart", info.IsHelmChart()).
+			Bool("provenance", info.HasLayer(registry.HelmChartProvenanceMediaType)).
+			Msg("detected artifact")
+
+		// Artifacts have no platforms: the manifest, config and all layers (e.g., chart provenance)
+		// are copied verbatim
+		result, err = syncer.syncSinglePlatform(ctx, srcImage, dstImage, opts, verifier)
+	default:
+		syncer.log.Debug().Msg("detected single-platform image")
+
+		result, err = syncer.syncSinglePlatform(ctx, srcImage, dstImage, opts, verifier)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	result.ArtifactType = info.Kind()
+	result.Chart = info.IsHelmChart()
+
+	result.Mounted = mounted
+
+	if verifier != nil {
+		result.VerifiedBlobs = verifier.digests()
+
+		syncer.log.Debug().
+			Int("verified_blobs", len(result.VerifiedBlobs)).
+			Msg("blob digests verified")
+	}
+
+	if opts.CopySignatures {
+		if err := syncer.copySignatures(ctx, srcImage, dstImage, desc.Digest.String(), result); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// UpToDate reports whether dstImage already points at the content a sync of srcImage with opts pushes: the
+// platform-filtered manifest list built from a source index (not the source index itself), or the source
+// manifest, with or without the previous-digest annotation. Nothing is pushed, and no layer is fetched.
+func (syncer *Syncer) UpToDate(ctx context.Context, srcImage, dstImage string, opts Options) (bool, error) {
+	exists, err := syncer.dstClient.CheckExists(ctx, dstImage)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	current, err := syncer.dstClient.GetDigest(ctx, dstImage)
+	if err != nil {
+		return false, fmt.Errorf("failed to get destination digest: %w", err)
+	}
+
+	digestWith, err := syncer.pushedDigest(ctx, srcImage, opts)
+	if err != nil {
+		return false, err
+	}
+
+	candidate, err := digestWith(nil)
+	if err != nil || candidate == current || !opts.RecordPreviousDigest {
+		return candidate == current, err
+	}
+
+	// Re-syncs keep the annotation the tag already has (see historyAnnotations)
+	annotations, err := syncer.dstClient.GetAnnotations(ctx, dstImage)
+	if err != nil {
+		return false, fmt.Errorf("failed to get destination annotations: %w", err)
+	}
+
+	previous, ok := annotations[PreviousDigestAnnotation]
+	if !ok {
+		return false, nil
 	}
 
-	syncer.log.Debug().Msg("detected single-platform image")
+	candidate, err = digestWith(map[string]string{PreviousDigestAnnotation: previous})
 
-	return syncer.syncSinglePlatform(ctx, srcImage, dstImage)
+	return candidate == current, err
+}
+
+// pushedDigest returns the function computing the digest a sync of srcImage with opts pushes, given the
+// annotations it adds.
+func (syncer *Syncer) pushedDigest(
+	ctx context.Context,
+	srcImage string,
+	opts Options,
+) (func(map[string]string) (string, error), error) {
+	desc, err := syncer.srcClient.GetManifest(ctx, srcImage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source image: %w", err)
+	}
+
+	info, err := registry.ParseManifestInfo(desc.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect source manifest: %w", err)
+	}
+
+	verbatim := func(map[string]string) (string, error) {
+		return desc.Digest.String(), nil
+	}
+
+	// Single manifests (images and artifacts) are pushed as they are, or annotated
+	if !desc.MediaType.IsIndex() {
+		img, err := syncer.srcClient.GetImageHandle(ctx, srcImage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get source image: %w", err)
+		}
+
+		return func(annotations map[string]string) (string, error) {
+			return imageDigest(annotateImage(img, annotations))
+		}, nil
+	}
+
+	if info.IsArtifact() || opts.CopySignatures {
+		return verbatim, nil
+	}
+
+	platformImages, err := syncer.platformImages(ctx, srcImage, opts, nil)
+	if err != nil || platformImages == nil {
+		return verbatim, err
+	}
+
+	idx := syncer.dstClient.BuildManifestList(platformImages)
+
+	return func(annotations map[string]string) (string, error) {
+		return indexDigest(annotateIndex(idx, annotations))
+	}, nil
 }
 
 // syncMultiPlatform syncs a multi-platform image by copying each platform separately.
@@ -62,19 +291,104 @@ func (syncer *Syncer) SyncImage(ctx context.Context, srcImage, dstImage string)
 // 2. Copy each platform image by digest
 // 3. Create and push manifest list at destination
 // Returns the destination manifest list digest (computed locally for security).
-func (syncer *Syncer) syncMultiPlatform(ctx context.Context, srcImage, dstImage string) (string, error) {
+func (syncer *Syncer) syncMultiPlatform(
+	ctx context.Context,
+	srcImage, dstImage string,
+	opts Options,
+	verifier *blobVerifier,
+) (*Result, error) {
+	platformImages, err := syncer.platformImages(ctx, srcImage, opts, verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	// Indexes without any supported platform entry (e.g., wasi/wasm modules, referrer or bundle indexes)
+	// cannot be resolved per platform: copy them unchanged instead of pushing an empty manifest list
+	if platformImages == nil {
+		syncer.log.Info().
+			Strs("platforms", supportedPlatforms(opts)).
+			Msg("index has no manifest for the synced platforms, copying verbatim")
+
+		return syncer.syncIndexVerbatim(ctx, srcImage, dstImage, verifier)
+	}
+
+	// Create and push manifest list
+	syncer.log.Debug().
+		Str("destination", dstImage).
+		Msg("creating manifest list")
+
+	idx := syncer.dstClient.BuildManifestList(platformImages)
+	result := &Result{}
+
+	if opts.RecordPreviousDigest {
+		annotations, err := syncer.historyAnnotations(ctx, dstImage, func(anns map[string]string) (string, error) {
+			return indexDigest(annotateIndex(idx, anns))
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		idx = annotateIndex(idx, annotations)
+		result.PreviousDigest = annotations[PreviousDigestAnnotation]
+	}
+
+	digest, err := syncer.dstClient.PushIndex(ctx, dstImage, idx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create manifest list: %w", err)
+	}
+
+	if opts.VerifyPushedDigest {
+		if err := syncer.dstClient.VerifyPushedDigest(ctx, dstImage, digest); err != nil {
+			return nil, err
+		}
+	}
+
+	syncer.log.Debug().
+		Str("digest", digest).
+		Msg("manifest list created successfully")
+
+	result.Digest = digest
+
+	return result, nil
+}
+
+// supportedPlatforms returns the platforms of multi-platform images synced with opts.
+func supportedPlatforms(opts Options) []string {
+	if len(opts.Platforms) == 0 {
+		return DefaultPlatforms
+	}
+
+	return opts.Platforms
+}
+
+// platformImages fetches the images of the synced platforms of the source index, by digest, or returns nil when
+// the index has none of them.
+func (syncer *Syncer) platformImages(
+	ctx context.Context,
+	srcImage string,
+	opts Options,
+	verifier *blobVerifier,
+) (map[string]v1.Image, error) {
 	// Get platform-specific digests
 	platformDigests, err := syncer.srcClient.GetPlatformDigests(ctx, srcImage)
 	if err != nil {
-		return "", fmt.Errorf("failed to get platform digests: %w", err)
+		return nil, fmt.Errorf("failed to get platform digests: %w", err)
 	}
 
 	syncer.log.Debug().
 		Int("platforms", len(platformDigests)).
 		Msg("found platforms in source image")
 
-	// Only sync linux/amd64 and linux/arm64 platforms
-	supportedPlatforms := []string{"linux/amd64", "linux/arm64"}
+	// Only sync the requested platforms
+	supported := supportedPlatforms(opts)
+
+	if !slices.ContainsFunc(supported, func(platform string) bool {
+		_, ok := platformDigests[platform]
+
+		return ok
+	}) {
+		return nil, nil
+	}
 
 	// Copy each supported platform separately and collect the images
 	platformImages := make(map[string]v1.Image)
@@ -82,21 +396,10 @@ func (syncer *Syncer) syncMultiPlatform(ctx context.Context, srcImage, dstImage
 	for platform, digest := range platformDigests {
 		// Check context cancellation before each platform
 		if err := ctx.Err(); err != nil {
-			return "", fmt.Errorf("sync cancelled: %w", err)
-		}
-
-		// Skip unsupported platforms
-		supported := false
-
-		for _, sp := range supportedPlatforms {
-			if platform == sp {
-				supported = true
-
-				break
-			}
+			return nil, fmt.Errorf("sync cancelled: %w", err)
 		}
 
-		if !supported {
+		if !slices.Contains(supported, platform) {
 			syncer.log.Debug().
 				Str("platform", platform).
 				Msg("skipping unsupported platform")
@@ -115,52 +418,222 @@ func (syncer *Syncer) syncMultiPlatform(ctx context.Context, srcImage, dstImage
 		// Note: The image will be pushed by digest (not by tag) when PushManifestList is called
 		img, err := syncer.srcClient.FetchPlatformImage(ctx, stripTag(srcImage), digest)
 		if err != nil {
-			return "", fmt.Errorf("failed to fetch platform %s: %w", platform, err)
+			return nil, fmt.Errorf("failed to fetch platform %s: %w", platform, err)
 		}
 
 		// Use the TRUSTED source image (fetched by digest) for manifest list
 		// SECURITY: Never fetch from destination - only use source images verified by digest
+		if verifier != nil {
+			img = verifier.wrapImage(img)
+		}
+
 		platformImages[platform] = img
 	}
 
-	// Create and push manifest list
-	syncer.log.Debug().
-		Str("destination", dstImage).
-		Msg("creating manifest list")
+	return platformImages, nil
+}
 
-	digest, err := syncer.dstClient.PushManifestList(ctx, dstImage, platformImages)
+// syncIndexVerbatim copies an index and all its children unchanged.
+// Returns the destination index digest (computed locally from the source index).
+// Verbatim copies are streamed by go-containerregistry and are not blob-verified.
+func (syncer *Syncer) syncIndexVerbatim(
+	ctx context.Context,
+	srcImage, dstImage string,
+	verifier *blobVerifier,
+) (*Result, error) {
+	if verifier != nil {
+		syncer.log.Warn().Msg("blob verification is not supported for verbatim index copies, relying on registry digest checks")
+	}
+
+	// SECURITY: fetched from source by digest, never from destination
+	idx, err := syncer.srcClient.CopyIndex(ctx, srcImage, dstImage, syncer.dstClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy index: %w", err)
+	}
+
+	digest, err := idx.Digest()
 	if err != nil {
-		return "", fmt.Errorf("failed to create manifest list: %w", err)
+		return nil, fmt.Errorf("failed to compute index digest: %w", err)
 	}
 
 	syncer.log.Debug().
-		Str("digest", digest).
-		Msg("manifest list created successfully")
+		Str("digest", digest.String()).
+		Msg("index copied verbatim")
 
-	return digest, nil
+	return &Result{Digest: digest.String()}, nil
 }
 
 // syncSinglePlatform syncs a single-platform image.
 // Returns the destination image digest (computed locally for security).
-func (syncer *Syncer) syncSinglePlatform(ctx context.Context, srcImage, dstImage string) (string, error) {
+func (syncer *Syncer) syncSinglePlatform(
+	ctx context.Context,
+	srcImage, dstImage string,
+	opts Options,
+	verifier *blobVerifier,
+) (*Result, error) {
+	if opts.RecordPreviousDigest || verifier != nil {
+		return syncer.syncSinglePlatformWithHistory(ctx, srcImage, dstImage, opts, verifier)
+	}
+
 	// Copy the image and get the TRUSTED source image
 	// CopyImage returns the image fetched from source BY DIGEST
 	// SECURITY: Never fetch from destination - only use source image verified by digest
 	img, err := syncer.srcClient.CopyImage(ctx, srcImage, dstImage, syncer.dstClient)
 	if err != nil {
-		return "", fmt.Errorf("failed to copy image: %w", err)
+		return nil, fmt.Errorf("failed to copy image: %w", err)
 	}
 
 	// Compute digest from TRUSTED source image (not from destination)
 	digest, err := img.Digest()
 	if err != nil {
-		return "", fmt.Errorf("failed to compute image digest: %w", err)
+		return nil, fmt.Errorf("failed to compute image digest: %w", err)
 	}
 
 	syncer.log.Debug().
 		Str("digest", digest.String()).
 		Msg("single-platform image synced successfully")
 
+	return &Result{Digest: digest.String()}, nil
+}
+
+// syncSinglePlatformWithHistory syncs a single-platform image through the client, optionally annotating it
+// with the previous destination digest and verifying its blobs.
+// The annotated manifest differs from the source manifest, so the returned digest is that of the annotated image.
+func (syncer *Syncer) syncSinglePlatformWithHistory(
+	ctx context.Context,
+	srcImage, dstImage string,
+	opts Options,
+	verifier *blobVerifier,
+) (*Result, error) {
+	// SECURITY: fetched from source by digest, never from destination
+	img, err := syncer.srcClient.GetImageHandle(ctx, srcImage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source image: %w", err)
+	}
+
+	if verifier != nil {
+		img = verifier.wrapImage(img)
+	}
+
+	var annotations map[string]string
+
+	if opts.RecordPreviousDigest {
+		annotations, err = syncer.historyAnnotations(ctx, dstImage, func(anns map[string]string) (string, error) {
+			return imageDigest(annotateImage(img, anns))
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	digest, err := syncer.dstClient.PushImage(ctx, dstImage, annotateImage(img, annotations))
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy image: %w", err)
+	}
+
+	syncer.log.Debug().
+		Str("digest", digest).
+		Msg("single-platform image synced successfully")
+
+	return &Result{Digest: digest, PreviousDigest: annotations[PreviousDigestAnnotation]}, nil
+}
+
+// historyAnnotations determines the previous-digest annotation for a push to dstImage.
+// digestWith computes the digest the pushed manifest would have with the given annotations.
+// Re-syncing identical content is idempotent: if the tag already points at the same content
+// (annotated or not), the existing annotation is preserved instead of chaining a new one.
+func (syncer *Syncer) historyAnnotations(
+	ctx context.Context,
+	dstImage string,
+	digestWith func(map[string]string) (string, error),
+) (map[string]string, error) {
+	exists, err := syncer.dstClient.CheckExists(ctx, dstImage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check destination: %w", err)
+	}
+
+	if !exists {
+		syncer.log.Debug().Msg("destination tag does not exist, no previous digest to record")
+
+		return nil, nil
+	}
+
+	currentDigest, err := syncer.dstClient.GetDigest(ctx, dstImage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get destination digest: %w", err)
+	}
+
+	currentAnnotations, err := syncer.dstClient.GetAnnotations(ctx, dstImage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get destination annotations: %w", err)
+	}
+
+	// Tag already points at this content with its history annotation
+	if previous, ok := currentAnnotations[PreviousDigestAnnotation]; ok {
+		kept := map[string]string{PreviousDigestAnnotation: previous}
+
+		candidate, err := digestWith(kept)
+		if err != nil {
+			return nil, err
+		}
+
+		if candidate == currentDigest {
+			return kept, nil
+		}
+	}
+
+	// Tag already points at this content without annotation (e.g. synced before history was enabled)
+	candidate, err := digestWith(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if candidate == currentDigest {
+		return nil, nil
+	}
+
+	syncer.log.Debug().
+		Str("previous_digest", currentDigest).
+		Msg("recording previous destination digest")
+
+	return map[string]string{PreviousDigestAnnotation: currentDigest}, nil
+}
+
+// annotateIndex returns idx with the given annotations, or idx unchanged if there are none.
+func annotateIndex(idx v1.ImageIndex, annotations map[string]string) v1.ImageIndex {
+	if len(annotations) == 0 {
+		return idx
+	}
+
+	//nolint:forcetypeassert // mutate.Annotations returns an index for an index input
+	return mutate.Annotations(idx, annotations).(v1.ImageIndex)
+}
+
+// annotateImage returns img with the given annotations, or img unchanged if there are none.
+func annotateImage(img v1.Image, annotations map[string]string) v1.Image {
+	if len(annotations) == 0 {
+		return img
+	}
+
+	//nolint:forcetypeassert // mutate.Annotations returns an image for an image input
+	return mutate.Annotations(img, annotations).(v1.Image)
+}
+
+func indexDigest(idx v1.ImageIndex) (string, error) {
+	digest, err := idx.Digest()
+	if err != nil {
+		return "", fmt.Errorf("failed to compute index digest: %w", err)
+	}
+
+	return digest.String(), nil
+}
+
+func imageDigest(img v1.Image) (string, error) {
+	digest, err := img.Digest()
+	if err != nil {
+		return "", fmt.Errorf("failed to compute image digest: %w", err)
+	}
+
 	return digest.String(), nil
 }
 
@@ -1,8 +1,25 @@
 package sync_test
 
 import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/rs/zerolog"
 
 	"github.com/farcloser/quark/internal/registry"
@@ -174,3 +191,497 @@ func TestSyncer_CheckExists_InvalidReference(t *testing.T) {
 		})
 	}
 }
+
+// INTENTION: With RecordPreviousDigest, overwriting a tag records the prior digest,
+// and re-syncing identical content leaves the tag untouched.
+func TestSyncer_SyncImageWithOptions_RecordPreviousDigest(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+	syncer := sync.NewSyncer(client, client, zerolog.Nop())
+	opts := sync.Options{RecordPreviousDigest: true}
+	dstRef := host + "/mirror/app:1.0"
+
+	first := pushRandomImage(t, host+"/upstream/app")
+
+	result, err := syncer.SyncImageWithOptions(t.Context(), first, dstRef, opts)
+	if err != nil {
+		t.Fatalf("first sync failed: %v", err)
+	}
+
+	if result.PreviousDigest != "" {
+		t.Errorf("first sync PreviousDigest = %q, want empty", result.PreviousDigest)
+	}
+
+	again, err := syncer.SyncImageWithOptions(t.Context(), first, dstRef, opts)
+	if err != nil {
+		t.Fatalf("repeated sync failed: %v", err)
+	}
+
+	if again.Digest != result.Digest {
+		t.Errorf("repeated sync digest = %q, want unchanged %q", again.Digest, result.Digest)
+	}
+
+	second := pushRandomImage(t, host+"/upstream/app")
+
+	updated, err := syncer.SyncImageWithOptions(t.Context(), second, dstRef, opts)
+	if err != nil {
+		t.Fatalf("second sync failed: %v", err)
+	}
+
+	if updated.PreviousDigest != result.Digest {
+		t.Errorf("PreviousDigest = %q, want %q", updated.PreviousDigest, result.Digest)
+	}
+
+	annotations, err := client.GetAnnotations(t.Context(), dstRef)
+	if err != nil {
+		t.Fatalf("GetAnnotations() failed: %v", err)
+	}
+
+	if annotations[sync.PreviousDigestAnnotation] != result.Digest {
+		t.Errorf("annotation = %q, want %q", annotations[sync.PreviousDigestAnnotation], result.Digest)
+	}
+}
+
+// pushRandomImage pushes a random single-platform image to repo and returns its digest reference.
+func pushRandomImage(t *testing.T, repo string) string {
+	t.Helper()
+
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+
+	return pushImage(t, repo, img)
+}
+
+// pushImage pushes img to repo and returns its digest reference.
+func pushImage(t *testing.T, repo string, img v1.Image) string {
+	t.Helper()
+
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatalf("failed to compute digest: %v", err)
+	}
+
+	ref := repo + "@" + digest.String()
+
+	parsed, err := name.ParseReference(ref)
+	if err != nil {
+		t.Fatalf("failed to parse reference: %v", err)
+	}
+
+	if err := remote.Write(parsed, img); err != nil {
+		t.Fatalf("failed to push image: %v", err)
+	}
+
+	return ref
+}
+
+// INTENTION: Syncing within the same registry mounts blobs from the source repository
+// instead of uploading them.
+func TestSyncer_SyncImageWithOptions_SameRegistryMountsBlobs(t *testing.T) {
+	t.Parallel()
+
+	var uploads atomic.Int32
+
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		// Blob content is sent with PATCH (chunked) or PUT with a body (monolithic)
+		if strings.Contains(req.URL.Path, "/blobs/uploads/") &&
+			(req.Method == http.MethodPatch || (req.Method == http.MethodPut && req.ContentLength > 0)) {
+			uploads.Add(1)
+		}
+
+		handler.ServeHTTP(writer, req)
+	}))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+	syncer := sync.NewSyncer(client, client, zerolog.Nop())
+
+	source := pushRandomImage(t, host+"/staging/app")
+	uploads.Store(0)
+
+	result, err := syncer.SyncImageWithOptions(t.Context(), source, host+"/production/app:1.0", sync.Options{})
+	if err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+
+	if !result.Mounted {
+		t.Error("Mounted = false, want true for same-registry sync")
+	}
+
+	if count := uploads.Load(); count != 0 {
+		t.Errorf("blob uploads = %d, want 0 (blobs should be mounted)", count)
+	}
+
+	// Control: a cross-registry sync must upload blob content
+	other := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(other.Close)
+
+	otherHost := strings.TrimPrefix(other.URL, "http://")
+	crossSyncer := sync.NewSyncer(registry.NewClient(otherHost, "", "", zerolog.Nop()), client, zerolog.Nop())
+	crossSource := pushRandomImage(t, otherHost+"/upstream/app")
+
+	result, err = crossSyncer.SyncImageWithOptions(t.Context(), crossSource, host+"/mirror/app:1.0", sync.Options{})
+	if err != nil {
+		t.Fatalf("cross-registry sync failed: %v", err)
+	}
+
+	if result.Mounted {
+		t.Error("Mounted = true, want false for cross-registry sync")
+	}
+
+	if uploads.Load() == 0 {
+		t.Error("blob uploads = 0, want uploads for cross-registry sync")
+	}
+}
+
+// INTENTION: Helm charts are mirrored verbatim: same digest, provenance layer preserved.
+func TestSyncer_SyncImageWithOptions_HelmChart(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+
+	chart := mutate.MediaType(empty.Image, types.OCIManifestSchema1)
+	chart = mutate.ConfigMediaType(chart, registry.HelmChartConfigMediaType)
+
+	chart, err := mutate.Append(chart,
+		mutate.Addendum{Layer: static.NewLayer([]byte("chart-tgz"), registry.HelmChartContentMediaType)},
+		mutate.Addendum{Layer: static.NewLayer([]byte("provenance"), registry.HelmChartProvenanceMediaType)},
+	)
+	if err != nil {
+		t.Fatalf("failed to build chart: %v", err)
+	}
+
+	srcDigest, err := client.PushImage(t.Context(), host+"/charts/foo:1.2.3", chart)
+	if err != nil {
+		t.Fatalf("failed to push chart: %v", err)
+	}
+
+	syncer := sync.NewSyncer(client, client, zerolog.Nop())
+
+	result, err := syncer.SyncImageWithOptions(
+		t.Context(), host+"/charts/foo@"+srcDigest, host+"/mirror/charts/foo:1.2.3", sync.Options{},
+	)
+	if err != nil {
+		t.Fatalf("SyncImageWithOptions() failed: %v", err)
+	}
+
+	if !result.Chart {
+		t.Error("Result.Chart = false, want true")
+	}
+
+	if result.Digest != srcDigest {
+		t.Errorf("Digest = %q, want source digest %q", result.Digest, srcDigest)
+	}
+
+	desc, err := client.GetImage(t.Context(), host+"/mirror/charts/foo:1.2.3")
+	if err != nil {
+		t.Fatalf("failed to get mirrored chart: %v", err)
+	}
+
+	info, err := registry.ParseManifestInfo(desc.Manifest)
+	if err != nil {
+		t.Fatalf("ParseManifestInfo() failed: %v", err)
+	}
+
+	if !info.IsHelmChart() || !info.HasLayer(registry.HelmChartProvenanceMediaType) {
+		t.Errorf("mirrored manifest = %+v, want helm chart with provenance", info)
+	}
+
+	provenanceRef, err := name.NewDigest(host + "/mirror/charts/foo@" + mustLayerDigest(t, chart, 1))
+	if err != nil {
+		t.Fatalf("failed to parse provenance reference: %v", err)
+	}
+
+	provenance, err := remote.Layer(provenanceRef)
+	if err != nil {
+		t.Fatalf("failed to get provenance layer: %v", err)
+	}
+
+	if _, err := provenance.Compressed(); err != nil {
+		t.Errorf("provenance blob missing at destination: %v", err)
+	}
+}
+
+func mustLayerDigest(t *testing.T, img v1.Image, index int) string {
+	t.Helper()
+
+	layers, err := img.Layers()
+	if err != nil {
+		t.Fatalf("failed to get layers: %v", err)
+	}
+
+	digest, err := layers[index].Digest()
+	if err != nil {
+		t.Fatalf("failed to get layer digest: %v", err)
+	}
+
+	return digest.String()
+}
+
+// INTENTION: Indexes without linux/amd64 or linux/arm64 entries (e.g., WASM modules) are copied verbatim,
+// instead of being reduced to an empty manifest list.
+func TestSyncer_SyncImageWithOptions_WasmIndexVerbatim(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+
+	module := mutate.MediaType(empty.Image, types.OCIManifestSchema1)
+	module = mutate.ConfigMediaType(module, "application/vnd.wasm.config.v0+json")
+
+	module, err := mutate.Append(module, mutate.Addendum{
+		Layer: static.NewLayer([]byte("\x00asm"), "application/vnd.wasm.content.layer.v1+wasm"),
+	})
+	if err != nil {
+		t.Fatalf("failed to build module: %v", err)
+	}
+
+	idx := mutate.AppendManifests(mutate.IndexMediaType(empty.Index, types.OCIImageIndex), mutate.IndexAddendum{
+		Add:        module,
+		Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "wasip1", Architecture: "wasm"}},
+	})
+
+	srcDigest, err := client.PushIndex(t.Context(), host+"/wasm/app:1.0", idx)
+	if err != nil {
+		t.Fatalf("failed to push index: %v", err)
+	}
+
+	syncer := sync.NewSyncer(client, client, zerolog.Nop())
+
+	result, err := syncer.SyncImageWithOptions(
+		t.Context(), host+"/wasm/app@"+srcDigest, host+"/mirror/wasm/app:1.0", sync.Options{},
+	)
+	if err != nil {
+		t.Fatalf("SyncImageWithOptions() failed: %v", err)
+	}
+
+	if result.Digest != srcDigest {
+		t.Errorf("Digest = %q, want source digest %q", result.Digest, srcDigest)
+	}
+}
+
+// INTENTION: With VerifyBlobs, a cross-registry sync records every layer digest it verified,
+// and a corrupted blob fails the sync before the destination tag is written.
+func TestSyncer_SyncImageWithOptions_VerifyBlobs(t *testing.T) {
+	t.Parallel()
+
+	var corrupt atomic.Bool
+
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+	source := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if !corrupt.Load() || req.Method != http.MethodGet || !strings.Contains(req.URL.Path, "/blobs/sha256:") {
+			handler.ServeHTTP(writer, req)
+
+			return
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		body := recorder.Body.Bytes()
+		if len(body) > 0 {
+			body[0] ^= 0xff
+		}
+
+		writer.WriteHeader(recorder.Code)
+		_, _ = writer.Write(body)
+	}))
+	t.Cleanup(source.Close)
+
+	destination := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(destination.Close)
+
+	srcHost := strings.TrimPrefix(source.URL, "http://")
+	dstHost := strings.TrimPrefix(destination.URL, "http://")
+	dstClient := registry.NewClient(dstHost, "", "", zerolog.Nop())
+	syncer := sync.NewSyncer(registry.NewClient(srcHost, "", "", zerolog.Nop()), dstClient, zerolog.Nop())
+	opts := sync.Options{VerifyBlobs: true}
+
+	img, err := random.Image(256, 2)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+
+	srcRef := pushImage(t, srcHost+"/upstream/app", img)
+
+	result, err := syncer.SyncImageWithOptions(t.Context(), srcRef, dstHost+"/mirror/app:1.0", opts)
+	if err != nil {
+		t.Fatalf("verified sync failed: %v", err)
+	}
+
+	want := []string{mustLayerDigest(t, img, 0), mustLayerDigest(t, img, 1)}
+	if want[0] > want[1] {
+		want[0], want[1] = want[1], want[0]
+	}
+
+	if strings.Join(result.VerifiedBlobs, ",") != strings.Join(want, ",") {
+		t.Errorf("VerifiedBlobs = %v, want %v", result.VerifiedBlobs, want)
+	}
+
+	corrupt.Store(true)
+
+	corrupted := pushRandomImage(t, srcHost+"/upstream/other")
+
+	if _, err := syncer.SyncImageWithOptions(t.Context(), corrupted, dstHost+"/mirror/other:1.0", opts); err == nil {
+		t.Fatal("sync of corrupted blob succeeded, want error")
+	}
+
+	exists, err := dstClient.CheckExists(t.Context(), dstHost+"/mirror/other:1.0")
+	if err != nil {
+		t.Fatalf("CheckExists() failed: %v", err)
+	}
+
+	if exists {
+		t.Error("destination tag exists after corrupted sync, want none")
+	}
+}
+
+// INTENTION: With VerifyPushedDigest, a multi-platform sync fails when the destination stores a different
+// manifest list than the one pushed (a rewriting proxy), and succeeds when the registry keeps it unchanged.
+func TestSyncer_SyncImageWithOptions_VerifyPushedDigest(t *testing.T) {
+	t.Parallel()
+
+	var rewrite atomic.Bool
+
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		// The proxy re-serializes manifests pushed by tag, which changes their digest
+		if rewrite.Load() && req.Method == http.MethodPut && strings.Contains(req.URL.Path, "/manifests/1.0") {
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Errorf("failed to read manifest: %v", err)
+			}
+
+			body = append(body, '\n')
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			req.ContentLength = int64(len(body))
+		}
+
+		handler.ServeHTTP(writer, req)
+	}))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := registry.NewClient(host, "", "", zerolog.Nop())
+
+	idx := mutate.IndexMediaType(empty.Index, types.OCIImageIndex)
+
+	for _, arch := range []string{"amd64", "arm64"} {
+		img, err := random.Image(256, 1)
+		if err != nil {
+			t.Fatalf("failed to create random image: %v", err)
+		}
+
+		idx = mutate.AppendManifests(idx, mutate.IndexAddendum{
+			Add:        img,
+			Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: arch}},
+		})
+	}
+
+	srcDigest, err := client.PushIndex(t.Context(), host+"/upstream/app:source", idx)
+	if err != nil {
+		t.Fatalf("failed to push index: %v", err)
+	}
+
+	syncer := sync.NewSyncer(client, client, zerolog.Nop())
+	opts := sync.Options{VerifyPushedDigest: true}
+	source := host + "/upstream/app@" + srcDigest
+
+	if _, err := syncer.SyncImageWithOptions(t.Context(), source, host+"/mirror/app:1.0", opts); err != nil {
+		t.Fatalf("verified sync failed: %v", err)
+	}
+
+	rewrite.Store(true)
+
+	_, err = syncer.SyncImageWithOptions(t.Context(), source, host+"/rewritten/app:1.0", opts)
+	if !errors.Is(err, registry.ErrManifestRewritten) {
+		t.Errorf("sync through rewriting proxy error = %v, want %v", err, registry.ErrManifestRewritten)
+	}
+}
+
+// INTENTION: With CopySignatures, a signed multi-platform image is copied verbatim so that its notation
+// signatures, copied along, still refer to it at the destination; other referrers (e.g., SBOMs) are left out.
+func TestSyncer_SyncImageWithOptions_CopySignatures(t *testing.T) {
+	t.Parallel()
+
+	var hosts []string
+
+	for range 2 {
+		server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+		t.Cleanup(server.Close)
+
+		hosts = append(hosts, strings.TrimPrefix(server.URL, "http://"))
+	}
+
+	srcClient := registry.NewClient(hosts[0], "", "", zerolog.Nop())
+	dstClient := registry.NewClient(hosts[1], "", "", zerolog.Nop())
+
+	idx, err := random.Index(256, 1, 2)
+	if err != nil {
+		t.Fatalf("failed to create random index: %v", err)
+	}
+
+	srcDigest, err := srcClient.PushIndex(t.Context(), hosts[0]+"/upstream/app:1.0", idx)
+	if err != nil {
+		t.Fatalf("failed to push index: %v", err)
+	}
+
+	manifest, err := idx.RawManifest()
+	if err != nil {
+		t.Fatalf("failed to get index manifest: %v", err)
+	}
+
+	subject := v1.Descriptor{
+		MediaType: types.OCIImageIndex,
+		Digest:    v1.Hash{Algorithm: "sha256", Hex: strings.TrimPrefix(srcDigest, "sha256:")},
+		Size:      int64(len(manifest)),
+	}
+
+	referrer := func(artifactType types.MediaType) string {
+		artifact, ok := mutate.Subject(
+			mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), artifactType), subject,
+		).(v1.Image)
+		if !ok {
+			t.Fatal("referrer is not an image")
+		}
+
+		ref := pushImage(t, hosts[0]+"/upstream/app", artifact)
+
+		return ref[strings.Index(ref, "@")+1:]
+	}
+
+	signature := referrer(registry.NotationSignatureArtifactType)
+	referrer("application/vnd.example.sbom")
+
+	syncer := sync.NewSyncer(srcClient, dstClient, zerolog.Nop())
+
+	result, err := syncer.SyncImageWithOptions(t.Context(), hosts[0]+"/upstream/app@"+srcDigest,
+		hosts[1]+"/mirror/app:1.0", sync.Options{CopySignatures: true, Platforms: []string{"linux/amd64"}})
+	if err != nil {
+		t.Fatalf("SyncImageWithOptions() failed: %v", err)
+	}
+
+	if result.Digest != srcDigest {
+		t.Errorf("Digest = %q, want source digest %q", result.Digest, srcDigest)
+	}
+
+	if len(result.Signatures) != 1 || result.Signatures[0] != signature {
+		t.Errorf("Signatures = %v, want [%s]", result.Signatures, signature)
+	}
+}
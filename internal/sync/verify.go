@@ -0,0 +1,174 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+	gosync "sync"
+
+	"github.com/google/go-containerregistry/pkg/v1"
+)
+
+var (
+	// ErrBlobDigestMismatch indicates a downloaded blob does not match the digest declared by its manifest.
+	ErrBlobDigestMismatch = errors.New("blob digest mismatch")
+	// ErrBlobSizeMismatch indicates a downloaded blob does not match the size declared by its manifest.
+	ErrBlobSizeMismatch = errors.New("blob size mismatch")
+)
+
+// blobVerifier verifies layer blobs against their manifest digest and size while they stream
+// through a copy, and records the digests that were verified.
+type blobVerifier struct {
+	verified map[string]bool
+	mu       gosync.Mutex
+}
+
+func newBlobVerifier() *blobVerifier {
+	return &blobVerifier{
+		verified: make(map[string]bool),
+	}
+}
+
+// digests returns the verified blob digests, sorted.
+func (verifier *blobVerifier) digests() []string {
+	verifier.mu.Lock()
+	defer verifier.mu.Unlock()
+
+	result := make([]string, 0, len(verifier.verified))
+	for digest := range verifier.verified {
+		result = append(result, digest)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+func (verifier *blobVerifier) record(digest v1.Hash) {
+	verifier.mu.Lock()
+	defer verifier.mu.Unlock()
+
+	verifier.verified[digest.String()] = true
+}
+
+// wrapImage returns img with layers verified as they are read.
+// Wrapped layers are no longer mountable: do not wrap images copied within a registry.
+func (verifier *blobVerifier) wrapImage(img v1.Image) v1.Image {
+	return &verifyingImage{Image: img, verifier: verifier}
+}
+
+// verifyingImage is a v1.Image whose layers verify their content while being read.
+type verifyingImage struct {
+	v1.Image
+
+	verifier *blobVerifier
+}
+
+// Layers implements v1.Image.
+func (img *verifyingImage) Layers() ([]v1.Layer, error) {
+	layers, err := img.Image.Layers()
+	if err != nil {
+		//nolint:wrapcheck // Pass through v1.Image errors unchanged
+		return nil, err
+	}
+
+	wrapped := make([]v1.Layer, 0, len(layers))
+	for _, layer := range layers {
+		wrapped = append(wrapped, &verifyingLayer{Layer: layer, verifier: img.verifier})
+	}
+
+	return wrapped, nil
+}
+
+// LayerByDigest implements v1.Image.
+func (img *verifyingImage) LayerByDigest(digest v1.Hash) (v1.Layer, error) {
+	layer, err := img.Image.LayerByDigest(digest)
+	if err != nil {
+		//nolint:wrapcheck // Pass through v1.Image errors unchanged
+		return nil, err
+	}
+
+	return &verifyingLayer{Layer: layer, verifier: img.verifier}, nil
+}
+
+// verifyingLayer is a v1.Layer whose compressed content is verified while being read.
+type verifyingLayer struct {
+	v1.Layer
+
+	verifier *blobVerifier
+}
+
+// Compressed implements v1.Layer.
+func (layer *verifyingLayer) Compressed() (io.ReadCloser, error) {
+	digest, err := layer.Digest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob digest: %w", err)
+	}
+
+	size, err := layer.Size()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob size: %w", err)
+	}
+
+	hasher, err := v1.Hasher(digest.Algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported blob digest algorithm %q: %w", digest.Algorithm, err)
+	}
+
+	reader, err := layer.Layer.Compressed()
+	if err != nil {
+		//nolint:wrapcheck // Pass through v1.Layer errors unchanged
+		return nil, err
+	}
+
+	return &verifyingReader{
+		ReadCloser: reader,
+		hasher:     hasher,
+		digest:     digest,
+		size:       size,
+		verifier:   layer.verifier,
+	}, nil
+}
+
+// verifyingReader hashes a blob as it streams and checks it against the expected digest and size.
+// Oversized streams fail as soon as they exceed the declared size.
+type verifyingReader struct {
+	io.ReadCloser
+
+	hasher   hash.Hash
+	digest   v1.Hash
+	size     int64
+	read     int64
+	verifier *blobVerifier
+}
+
+// Read implements io.Reader.
+func (reader *verifyingReader) Read(buf []byte) (int, error) {
+	n, err := reader.ReadCloser.Read(buf)
+	if n > 0 {
+		_, _ = reader.hasher.Write(buf[:n])
+		reader.read += int64(n)
+
+		if reader.read > reader.size {
+			return n, fmt.Errorf("%w: %s exceeds declared size %d", ErrBlobSizeMismatch, reader.digest, reader.size)
+		}
+	}
+
+	if errors.Is(err, io.EOF) {
+		if reader.read != reader.size {
+			return n, fmt.Errorf("%w: %s is %d bytes, declared %d", ErrBlobSizeMismatch, reader.digest, reader.read, reader.size)
+		}
+
+		actual := v1.Hash{Algorithm: reader.digest.Algorithm, Hex: fmt.Sprintf("%x", reader.hasher.Sum(nil))}
+		if actual != reader.digest {
+			return n, fmt.Errorf("%w: expected %s, got %s", ErrBlobDigestMismatch, reader.digest, actual)
+		}
+
+		reader.verifier.record(reader.digest)
+	}
+
+	//nolint:wrapcheck // io.EOF must be returned unwrapped
+	return n, err
+}
@@ -27,26 +27,70 @@
 //
 // Never use short commit hashes in production - always use at least 7 characters
 // for collision resistance (Go will accept and expand them).
+//
+// # Release Binaries
+//
+// Tools that are not written in Go (e.g., hadolint) cannot be installed with `go install`.
+// They are downloaded as prebuilt release binaries instead, and every asset is verified
+// against a SHA-256 checksum pinned in this package before it is installed.
+// Platforms without a pinned checksum are refused rather than installed unverified.
 package tools
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/filesystem"
 )
 
-var errToolNotInPath = errors.New("tool installed but not found in PATH")
+var (
+	errToolNotInPath        = errors.New("tool installed but not found in PATH")
+	errUnsupportedPlatform  = errors.New("no pinned release asset for platform")
+	errDownloadFailed       = errors.New("release download failed")
+	errChecksumMismatch     = errors.New("release checksum mismatch")
+	errReleaseURLIncomplete = errors.New("release URL template must contain {version} and {asset}")
+
+	// ErrToolUnavailable indicates a tool is neither installed nor installable.
+	ErrToolUnavailable = errors.New("tool not installed and cannot be installed")
+)
+
+// releaseDownloadTimeout bounds a single release binary download.
+const releaseDownloadTimeout = 5 * time.Minute
 
 // Tool represents an external tool that can be auto-installed.
 type Tool struct {
-	Name       string // Binary name (e.g., "trivy")
-	ImportPath string // Go import path (e.g., "github.com/aquasecurity/trivy/cmd/trivy")
-	Version    string // Commit hash for immutable pinning (e.g., "9aabfd2")
+	Name       string   // Binary name (e.g., "trivy")
+	ImportPath string   // Go import path (e.g., "github.com/aquasecurity/trivy/cmd/trivy")
+	Version    string   // Commit hash for immutable pinning (e.g., "9aabfd2"), or release tag for Release tools
+	Release    *Release // Prebuilt release binary (non-Go tools); ImportPath is unused when set
+}
+
+// Release describes a tool distributed as prebuilt release binaries.
+type Release struct {
+	// URL template for downloads; {version} and {asset} are substituted
+	// (e.g., "https://github.com/hadolint/hadolint/releases/download/{version}/{asset}").
+	URL string
+	// Assets maps "os/arch" (runtime.GOOS/runtime.GOARCH) to the release asset.
+	Assets map[string]ReleaseAsset
+}
+
+// ReleaseAsset is a single release binary with its pinned checksum.
+type ReleaseAsset struct {
+	Name   string // Asset file name (e.g., "hadolint-Linux-x86_64")
+	SHA256 string // Hex-encoded SHA-256 of the asset
 }
 
 //nolint:gochecknoglobals
@@ -64,6 +108,41 @@ var (
 		ImportPath: "github.com/goodwithtech/dockle/cmd/dockle",
 		Version:    "5436857", // v0.4.15 released 2025-01-06
 	}
+
+	// Cosign signature verifier - pinned to module version v2.4.1, which the Go checksum database
+	// makes immutable like a commit hash.
+	Cosign = Tool{
+		Name:       "cosign",
+		ImportPath: "github.com/sigstore/cosign/v2/cmd/cosign",
+		Version:    "v2.4.1",
+	}
+
+	// Notation (Notary Project) signature verifier - pinned to module version v1.2.0, immutable through the
+	// Go checksum database like Cosign.
+	Notation = Tool{
+		Name:       "notation",
+		ImportPath: "github.com/notaryproject/notation/cmd/notation",
+		Version:    "v1.2.0",
+	}
+
+	// Hadolint Dockerfile linter (Haskell, installed from release binaries) - pinned to v2.12.0.
+	Hadolint = Tool{
+		Name:    "hadolint",
+		Version: "v2.12.0", // released 2022-11-09
+		Release: &Release{
+			URL: "https://github.com/hadolint/hadolint/releases/download/{version}/{asset}",
+			Assets: map[string]ReleaseAsset{
+				"linux/amd64": {
+					Name:   "hadolint-Linux-x86_64",
+					SHA256: "56de6d5e5ec427e17b74fa48d51271c7fc0d61244bf5c90e828aab8362d55010",
+				},
+				"linux/arm64": {
+					Name:   "hadolint-Linux-arm64",
+					SHA256: "5798551bf19f33951881f15eb238f90aef023f11e7ec7e9f4c37961cb87c5df6",
+				},
+			},
+		},
+	}
 )
 
 // Installer manages tool installation.
@@ -115,14 +194,21 @@ func (installer *Installer) Ensure(tool Tool) (string, error) {
 		Str("version", tool.Version).
 		Msg("tool not found, installing...")
 
-	if err := installer.install(tool); err != nil {
-		return "", fmt.Errorf("failed to install %s: %w", tool.Name, err)
-	}
+	if tool.Release != nil {
+		path, err = installer.installRelease(tool)
+		if err != nil {
+			return "", fmt.Errorf("failed to install %s: %w", tool.Name, err)
+		}
+	} else {
+		if err := installer.install(tool); err != nil {
+			return "", fmt.Errorf("failed to install %s: %w", tool.Name, err)
+		}
 
-	// Verify installation
-	path, err = exec.LookPath(tool.Name)
-	if err != nil {
-		return "", fmt.Errorf("%w: %s", errToolNotInPath, tool.Name)
+		// Verify installation
+		path, err = exec.LookPath(tool.Name)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", errToolNotInPath, tool.Name)
+		}
 	}
 
 	installer.log.Info().
@@ -135,6 +221,29 @@ func (installer *Installer) Ensure(tool Tool) (string, error) {
 	return path, nil
 }
 
+// Available checks, without installing anything, that Ensure can provide the tool: it is in PATH, or can be
+// installed (the go toolchain is in PATH, or a release binary is pinned for this platform).
+func Available(tool Tool) error {
+	if _, err := exec.LookPath(tool.Name); err == nil {
+		return nil
+	}
+
+	if tool.Release != nil {
+		platform := runtime.GOOS + "/" + runtime.GOARCH
+		if _, ok := tool.Release.Assets[platform]; !ok {
+			return fmt.Errorf("%w: %s: %w: %s", ErrToolUnavailable, tool.Name, errUnsupportedPlatform, platform)
+		}
+
+		return nil
+	}
+
+	if _, err := exec.LookPath("go"); err != nil {
+		return fmt.Errorf("%w: %s: go toolchain not found in PATH", ErrToolUnavailable, tool.Name)
+	}
+
+	return nil
+}
+
 // install installs a tool using go install with commit hash pinning.
 func (installer *Installer) install(tool Tool) error {
 	// Build go install command with commit hash
@@ -162,6 +271,82 @@ func (installer *Installer) install(tool Tool) error {
 	return nil
 }
 
+// installRelease downloads a release binary, verifies its pinned checksum and installs it
+// next to go-installed tools (GOBIN or GOPATH/bin). Returns the installed binary path.
+// The binary is written to a temporary file and only moved into place once verified.
+func (installer *Installer) installRelease(tool Tool) (string, error) {
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+
+	asset, ok := tool.Release.Assets[platform]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", errUnsupportedPlatform, platform)
+	}
+
+	if !strings.Contains(tool.Release.URL, "{version}") || !strings.Contains(tool.Release.URL, "{asset}") {
+		return "", errReleaseURLIncomplete
+	}
+
+	url := strings.NewReplacer("{version}", tool.Version, "{asset}", asset.Name).Replace(tool.Release.URL)
+
+	installer.log.Debug().
+		Str("url", url).
+		Str("sha256", asset.SHA256).
+		Msg("downloading release binary")
+
+	client := &http.Client{Timeout: releaseDownloadTimeout}
+
+	//nolint:noctx // Installation is not bound to an operation context
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errDownloadFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: %s returned %s", errDownloadFailed, url, resp.Status)
+	}
+
+	target := installer.GetToolPath(tool)
+
+	if err := os.MkdirAll(filepath.Dir(target), filesystem.DirPermissionsDefault); err != nil {
+		return "", fmt.Errorf("failed to create install directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+tool.Name+"-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	// Removing after a successful rename is a harmless no-op
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+
+	_, err = io.Copy(io.MultiWriter(tmp, hash), resp.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errDownloadFailed, err)
+	}
+
+	if actual := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(actual, asset.SHA256) {
+		return "", fmt.Errorf("%w: %s: expected %s, got %s", errChecksumMismatch, asset.Name, asset.SHA256, actual)
+	}
+
+	//nolint:gosec,mnd // Installed tools must be executable
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return "", fmt.Errorf("failed to make binary executable: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return "", fmt.Errorf("failed to install binary: %w", err)
+	}
+
+	return target, nil
+}
+
 // GetToolPath returns the expected path for a tool in GOPATH/bin or GOBIN.
 func (*Installer) GetToolPath(tool Tool) string {
 	// Check GOBIN first
@@ -1,7 +1,14 @@
 package tools_test
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/rs/zerolog"
@@ -131,6 +138,134 @@ func TestInstaller_Ensure_ToolNotInPath(t *testing.T) {
 	t.Skip("Skipping - would install real tools")
 }
 
+// INTENTION: Release binaries are only installed when they match the pinned checksum.
+func TestInstaller_Ensure_Release(t *testing.T) {
+	binary := []byte("#!/bin/sh\necho release-tool\n")
+	sum := sha256.Sum256(binary)
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1.0.0/release-tool-asset" {
+			http.NotFound(writer, req)
+
+			return
+		}
+
+		_, _ = writer.Write(binary)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		sha256  string
+		wantErr bool
+	}{
+		{name: "matching checksum", sha256: hex.EncodeToString(sum[:]), wantErr: false},
+		{
+			name:    "checksum mismatch",
+			sha256:  "0000000000000000000000000000000000000000000000000000000000000000",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gobin := t.TempDir()
+			t.Setenv("GOBIN", gobin)
+
+			tool := tools.Tool{
+				Name:    "quark-test-release-tool",
+				Version: "v1.0.0",
+				Release: &tools.Release{
+					URL: server.URL + "/{version}/{asset}",
+					Assets: map[string]tools.ReleaseAsset{
+						runtime.GOOS + "/" + runtime.GOARCH: {Name: "release-tool-asset", SHA256: tt.sha256},
+					},
+				},
+			}
+
+			path, err := tools.NewInstaller(zerolog.Nop()).Ensure(tool)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Ensure() error = nil, want checksum error")
+				}
+
+				if _, statErr := os.Stat(filepath.Join(gobin, tool.Name)); !os.IsNotExist(statErr) {
+					t.Errorf("unverified binary was installed (stat error = %v)", statErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Ensure() unexpected error = %v", err)
+			}
+
+			if path != filepath.Join(gobin, tool.Name) {
+				t.Errorf("Ensure() = %q, want %q", path, filepath.Join(gobin, tool.Name))
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatalf("installed binary missing: %v", err)
+			}
+
+			if info.Mode().Perm()&0o100 == 0 {
+				t.Errorf("installed binary mode = %v, want executable", info.Mode())
+			}
+		})
+	}
+}
+
+// INTENTION: Platforms without a pinned release asset are refused, never installed unverified.
+func TestInstaller_Ensure_ReleaseUnsupportedPlatform(t *testing.T) {
+	t.Setenv("GOBIN", t.TempDir())
+
+	tool := tools.Tool{
+		Name:    "quark-test-release-tool",
+		Version: "v1.0.0",
+		Release: &tools.Release{
+			URL:    "https://example.invalid/{version}/{asset}",
+			Assets: map[string]tools.ReleaseAsset{},
+		},
+	}
+
+	if _, err := tools.NewInstaller(zerolog.Nop()).Ensure(tool); err == nil {
+		t.Error("Ensure() error = nil, want unsupported platform error")
+	}
+}
+
+// INTENTION: Available reports, without installing anything, whether a tool can be provided: release tools
+// only on platforms with a pinned asset, go tools only with a go toolchain.
+func TestAvailable(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	release := tools.Tool{
+		Name:    "quark-test-release-tool",
+		Version: "v1.0.0",
+		Release: &tools.Release{
+			URL: "https://example.invalid/{version}/{asset}",
+			Assets: map[string]tools.ReleaseAsset{
+				runtime.GOOS + "/" + runtime.GOARCH: {Name: "tool", SHA256: "0"},
+			},
+		},
+	}
+
+	if err := tools.Available(release); err != nil {
+		t.Errorf("Available(release) error = %v, want nil", err)
+	}
+
+	release.Release.Assets = map[string]tools.ReleaseAsset{}
+	if err := tools.Available(release); !errors.Is(err, tools.ErrToolUnavailable) {
+		t.Errorf("Available(unsupported release) error = %v, want ErrToolUnavailable", err)
+	}
+
+	// No go toolchain in PATH
+	if err := tools.Available(tools.Trivy); !errors.Is(err, tools.ErrToolUnavailable) {
+		t.Errorf("Available(trivy) error = %v, want ErrToolUnavailable", err)
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
 		(len(s) > 0 && len(substr) > 0 && findSubstring(s, substr)))
@@ -0,0 +1,154 @@
+package trivy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// KnownExploitedFeedURL is the CISA Known Exploited Vulnerabilities (KEV) catalog.
+const KnownExploitedFeedURL = "https://www.cisa.gov/sites/default/files/feeds/known_exploited_vulnerabilities.json"
+
+const knownExploitedFeedTimeout = 30 * time.Second
+
+// ErrKnownExploitedFeed indicates the known exploited vulnerabilities feed could not be loaded.
+var ErrKnownExploitedFeed = errors.New("failed to load known exploited vulnerabilities feed")
+
+// KnownExploited is a catalog of vulnerabilities known to be exploited in the wild.
+type KnownExploited struct {
+	// CVE ID -> whether the vulnerability is known to be used in ransomware campaigns
+	ransomware map[string]bool
+}
+
+// knownExploitedFeed is the document format of the CISA KEV catalog.
+type knownExploitedFeed struct {
+	Vulnerabilities []struct {
+		CveID                      string `json:"cveID"`
+		KnownRansomwareCampaignUse string `json:"knownRansomwareCampaignUse"`
+	} `json:"vulnerabilities"`
+}
+
+// LoadKnownExploited loads a catalog in the CISA KEV format from location: an http(s) URL
+// (e.g., KnownExploitedFeedURL, or an internal mirror), or a local file for air-gapped runners.
+func LoadKnownExploited(ctx context.Context, location string) (*KnownExploited, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		data, err = fetchKnownExploited(ctx, location)
+	} else {
+		data, err = os.ReadFile(location)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrKnownExploitedFeed, location, err)
+	}
+
+	var feed knownExploitedFeed
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrKnownExploitedFeed, location, err)
+	}
+
+	if len(feed.Vulnerabilities) == 0 {
+		return nil, fmt.Errorf("%w: %s: no vulnerabilities", ErrKnownExploitedFeed, location)
+	}
+
+	catalog := &KnownExploited{ransomware: make(map[string]bool, len(feed.Vulnerabilities))}
+	for _, vuln := range feed.Vulnerabilities {
+		catalog.ransomware[vuln.CveID] = strings.EqualFold(vuln.KnownRansomwareCampaignUse, "Known")
+	}
+
+	return catalog, nil
+}
+
+// fetchKnownExploited downloads the feed at url.
+func fetchKnownExploited(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, knownExploitedFeedTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download feed: %w", err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	//nolint:wrapcheck // Wrapped by the caller
+	return io.ReadAll(resp.Body)
+}
+
+// Len returns the number of vulnerabilities in the catalog.
+func (catalog *KnownExploited) Len() int {
+	return len(catalog.ransomware)
+}
+
+// Enrich flags the vulnerabilities of results found in the catalog, and returns how many were flagged.
+func (catalog *KnownExploited) Enrich(results []PlatformResult) int {
+	flagged := 0
+
+	for _, platformResult := range results {
+		if platformResult.Result == nil {
+			continue
+		}
+
+		for targetIdx := range platformResult.Result.Results {
+			vulns := platformResult.Result.Results[targetIdx].Vulnerabilities
+
+			for idx := range vulns {
+				ransomware, ok := catalog.ransomware[vulns[idx].VulnerabilityID]
+				if !ok {
+					continue
+				}
+
+				vulns[idx].KnownExploited = true
+				vulns[idx].KnownRansomwareUse = ransomware
+				flagged++
+			}
+		}
+	}
+
+	return flagged
+}
+
+// knownExploitedNote is the table output line flagging an exploited vulnerability.
+func knownExploitedNote(vuln Vulnerability) string {
+	if vuln.KnownRansomwareUse {
+		return "  Known exploited (CISA KEV), used in ransomware campaigns\n"
+	}
+
+	return "  Known exploited (CISA KEV)\n"
+}
+
+// GetKnownExploited returns the vulnerabilities of result flagged as known exploited.
+func GetKnownExploited(result *ScanResult) []Vulnerability {
+	var exploited []Vulnerability
+
+	for _, scanResult := range result.Results {
+		for _, vuln := range scanResult.Vulnerabilities {
+			if vuln.KnownExploited {
+				exploited = append(exploited, vuln)
+			}
+		}
+	}
+
+	return exploited
+}
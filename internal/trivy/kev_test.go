@@ -0,0 +1,117 @@
+package trivy_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/trivy"
+)
+
+const testKnownExploitedFeed = `{
+  "catalogVersion": "2026.10.01",
+  "vulnerabilities": [
+    {"cveID": "CVE-2024-0001", "knownRansomwareCampaignUse": "Known"},
+    {"cveID": "CVE-2024-0002", "knownRansomwareCampaignUse": "Unknown"}
+  ]
+}`
+
+// INTENTION: A catalog loads from a URL or a local file, and flags the findings it lists (with ransomware use),
+// leaving the others untouched.
+func TestKnownExploited_Enrich(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		_, _ = writer.Write([]byte(testKnownExploitedFeed))
+	}))
+	t.Cleanup(server.Close)
+
+	file := filepath.Join(t.TempDir(), "kev.json")
+	if err := os.WriteFile(file, []byte(testKnownExploitedFeed), 0o600); err != nil {
+		t.Fatalf("failed to write feed: %v", err)
+	}
+
+	for _, location := range []string{server.URL, file} {
+		catalog, err := trivy.LoadKnownExploited(t.Context(), location)
+		if err != nil {
+			t.Fatalf("LoadKnownExploited(%s) error = %v", location, err)
+		}
+
+		results := []trivy.PlatformResult{{
+			Platform: "linux/amd64",
+			Result: &trivy.ScanResult{Results: []trivy.Result{{
+				Target: "alpine",
+				Vulnerabilities: []trivy.Vulnerability{
+					{VulnerabilityID: "CVE-2024-0001", Severity: "LOW"},
+					{VulnerabilityID: "CVE-2024-0002", Severity: "HIGH"},
+					{VulnerabilityID: "CVE-2024-0003", Severity: "CRITICAL"},
+				},
+			}}},
+		}}
+
+		if flagged := catalog.Enrich(results); flagged != 2 {
+			t.Errorf("Enrich() = %d, want 2", flagged)
+		}
+
+		vulns := results[0].Result.Results[0].Vulnerabilities
+		if !vulns[0].KnownExploited || !vulns[0].KnownRansomwareUse {
+			t.Errorf("CVE-2024-0001 = %+v, want known exploited with ransomware use", vulns[0])
+		}
+
+		if !vulns[1].KnownExploited || vulns[1].KnownRansomwareUse {
+			t.Errorf("CVE-2024-0002 = %+v, want known exploited without ransomware use", vulns[1])
+		}
+
+		if vulns[2].KnownExploited {
+			t.Errorf("CVE-2024-0003 = %+v, want not exploited", vulns[2])
+		}
+
+		if exploited := trivy.GetKnownExploited(trivy.Aggregate(results)); len(exploited) != 2 {
+			t.Errorf("GetKnownExploited() = %v, want 2 vulnerabilities", exploited)
+		}
+
+		output, err := trivy.NewScanner(zerolog.Nop()).FormatOutput(results[0].Result, "table")
+		if err != nil {
+			t.Fatalf("FormatOutput() error = %v", err)
+		}
+
+		if !strings.Contains(output, "Known exploited (CISA KEV), used in ransomware campaigns") {
+			t.Errorf("FormatOutput() = %q, want the exploited flag", output)
+		}
+	}
+}
+
+// INTENTION: Unreachable, unreadable or empty feeds fail with ErrKnownExploitedFeed, never with an empty catalog
+// that would let exploited vulnerabilities pass.
+func TestLoadKnownExploited_Invalid(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/empty":
+			_, _ = writer.Write([]byte(`{"vulnerabilities": []}`))
+		case "/html":
+			_, _ = writer.Write([]byte(`<html></html>`))
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	for _, location := range []string{
+		server.URL + "/missing",
+		server.URL + "/empty",
+		server.URL + "/html",
+		filepath.Join(t.TempDir(), "missing.json"),
+	} {
+		if _, err := trivy.LoadKnownExploited(t.Context(), location); !errors.Is(err, trivy.ErrKnownExploitedFeed) {
+			t.Errorf("LoadKnownExploited(%s) error = %v, want %v", location, err, trivy.ErrKnownExploitedFeed)
+		}
+	}
+}
@@ -6,11 +6,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os/exec"
+	"os"
+	"slices"
 	"strings"
 
 	"github.com/rs/zerolog"
 
+	"github.com/farcloser/quark/internal/dockerconfig"
+	"github.com/farcloser/quark/internal/subprocess"
 	"github.com/farcloser/quark/internal/tools"
 )
 
@@ -20,6 +23,10 @@ var errUnsupportedFormat = errors.New("unsupported format")
 type Scanner struct {
 	log       zerolog.Logger
 	installer *tools.Installer
+
+	// Trivy server (client/server mode), empty for local scanning
+	serverURL   string
+	serverToken string
 }
 
 // NewScanner creates a new Trivy scanner.
@@ -30,6 +37,17 @@ func NewScanner(log zerolog.Logger) *Scanner {
 	}
 }
 
+// WithServer configures the scanner to run in client mode against a Trivy server.
+// The server maintains the vulnerability database, so clients skip the DB download.
+// The token is passed through the environment (TRIVY_TOKEN) to keep it out of the process list.
+// An empty serverURL keeps local scanning.
+func (scanner *Scanner) WithServer(serverURL, token string) *Scanner {
+	scanner.serverURL = serverURL
+	scanner.serverToken = token
+
+	return scanner
+}
+
 // Severity represents vulnerability severity levels.
 type Severity string
 
@@ -56,6 +74,10 @@ type Vulnerability struct {
 	FixedVersion     string `json:"FixedVersion"`
 	Severity         string `json:"Severity"`
 	Title            string `json:"Title"`
+
+	// Enrichment from a known exploited vulnerabilities catalog (see KnownExploited.Enrich)
+	KnownExploited     bool `json:"KnownExploited,omitempty"`
+	KnownRansomwareUse bool `json:"KnownRansomwareUse,omitempty"`
 }
 
 // Result represents a scan result for a specific target.
@@ -73,9 +95,20 @@ type ScanResult struct {
 	Results []Result `json:"Results"`
 }
 
-// ScanImage scans an image for vulnerabilities across multiple platforms.
-// Always scans both linux/amd64 and linux/arm64 platforms and aggregates results.
-// If registry credentials are provided, logs in to the registry before scanning.
+// DefaultPlatforms are the platforms scanned when none are requested.
+//
+//nolint:gochecknoglobals // Default configuration
+var DefaultPlatforms = []string{"linux/amd64", "linux/arm64"}
+
+// PlatformResult holds the scan result for a single platform.
+type PlatformResult struct {
+	Platform string
+	Result   *ScanResult
+}
+
+// ScanImage scans an image for vulnerabilities across the default platforms (linux/amd64, linux/arm64)
+// and aggregates results.
+// If registry credentials are provided, trivy is given a temporary docker config holding them.
 func (scanner *Scanner) ScanImage(
 	ctx context.Context,
 	imageRef string,
@@ -85,55 +118,114 @@ func (scanner *Scanner) ScanImage(
 	username string,
 	password string,
 ) (*ScanResult, error) {
+	results, err := scanner.ScanPlatforms(
+		ctx,
+		imageRef,
+		DefaultPlatforms,
+		severities,
+		outputFormat,
+		registryHost,
+		username,
+		password,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return Aggregate(results), nil
+}
+
+// ScanPlatforms scans each requested platform image of imageRef and returns one result per platform,
+// in the order requested.
+// If registry credentials are provided, trivy is given a temporary docker config holding them,
+// scrubbed once all platforms are scanned.
+func (scanner *Scanner) ScanPlatforms(
+	ctx context.Context,
+	imageRef string,
+	platforms []string,
+	severities []Severity,
+	outputFormat string,
+	registryHost string,
+	username string,
+	password string,
+) ([]PlatformResult, error) {
 	// Ensure trivy is installed
 	trivyPath, err := scanner.installer.Ensure(tools.Trivy)
 	if err != nil {
 		return nil, fmt.Errorf("failed to ensure trivy is installed: %w", err)
 	}
 
-	// Login to registry if credentials provided
+	// Credentials are handed to trivy through a docker config scoped to this scan and scrubbed afterwards,
+	// instead of a persistent `trivy registry login` or TRIVY_USERNAME/TRIVY_PASSWORD pre-set in the environment
+	env := os.Environ()
+
+	if scanner.serverToken != "" {
+		env = append(env, "TRIVY_TOKEN="+scanner.serverToken)
+	}
+
 	if registryHost != "" && username != "" && password != "" {
-		if err := scanner.registryLogin(ctx, trivyPath, registryHost, username, password); err != nil {
-			return nil, fmt.Errorf("failed to login to registry: %w", err)
+		config, err := dockerconfig.Write(registryHost, username, password)
+		if err != nil {
+			return nil, err
 		}
+
+		defer func() {
+			if err := config.Scrub(); err != nil {
+				scanner.log.Warn().Err(err).Str("dir", config.Dir()).Msg("failed to scrub temporary docker config")
+			}
+		}()
+
+		// Pre-set trivy credentials would take precedence over the scoped config
+		env = slices.DeleteFunc(env, func(entry string) bool {
+			return strings.HasPrefix(entry, "TRIVY_USERNAME=") || strings.HasPrefix(entry, "TRIVY_PASSWORD=") ||
+				strings.HasPrefix(entry, "TRIVY_REGISTRY_TOKEN=")
+		})
+		env = append(env, config.Env())
 	}
 
-	// XXX FIXME. Platforms should not be hardcoded like that here.
 	scanner.log.Info().
 		Str("image", imageRef).
-		Strs("platforms", []string{"linux/amd64", "linux/arm64"}).
+		Strs("platforms", platforms).
 		Msg("scanning image across multiple platforms")
 
-	// Scan both required platforms
-	platforms := []string{"linux/amd64", "linux/arm64"}
-
-	var aggregatedResult ScanResult
+	results := make([]PlatformResult, 0, len(platforms))
 
 	for _, platform := range platforms {
 		scanner.log.Debug().
 			Str("platform", platform).
 			Msg("scanning platform")
 
-		result, err := scanner.scanPlatform(ctx, trivyPath, imageRef, platform, severities, outputFormat)
+		result, err := scanner.scanPlatform(ctx, trivyPath, env, imageRef, platform, severities, outputFormat)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan platform %s: %w", platform, err)
 		}
 
-		// Aggregate results from all platforms
-		aggregatedResult.Results = append(aggregatedResult.Results, result.Results...)
+		results = append(results, PlatformResult{Platform: platform, Result: result})
 	}
 
 	scanner.log.Info().
-		Int("total_results", len(aggregatedResult.Results)).
+		Int("platforms", len(results)).
 		Msg("multi-platform scan complete")
 
-	return &aggregatedResult, nil
+	return results, nil
+}
+
+// Aggregate merges per-platform results into a single result.
+func Aggregate(results []PlatformResult) *ScanResult {
+	var aggregated ScanResult
+
+	for _, platformResult := range results {
+		aggregated.Results = append(aggregated.Results, platformResult.Result.Results...)
+	}
+
+	return &aggregated
 }
 
 // scanPlatform scans a specific platform.
 func (scanner *Scanner) scanPlatform(
 	ctx context.Context,
 	trivyPath string,
+	env []string,
 	imageRef string,
 	platform string,
 	severities []Severity,
@@ -146,18 +238,32 @@ func (scanner *Scanner) scanPlatform(
 		"--format", "json", // Always use JSON for parsing
 		"--severity", strings.Join(severityStrings(severities), ","),
 		"--quiet", // Suppress progress output
-		imageRef,
 	}
 
+	if scanner.serverURL != "" {
+		args = append(args, "--server", scanner.serverURL)
+	}
+
+	args = append(args, imageRef)
+
 	//nolint:gosec // Command args are from trusted config
-	cmd := exec.CommandContext(ctx, trivyPath, args...)
+	cmd := subprocess.Command(ctx, trivyPath, args...)
+	cmd.Env = env
 
 	// Separate stdout and stderr to avoid mixing JSON with progress messages
 	var stdout, stderr strings.Builder
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
+	subprocess.Echo(ctx, cmd)
+
 	runErr := cmd.Run()
+
+	// A cancelled scan (plan timeout, interrupted run) has no usable output
+	if runErr != nil && ctx.Err() != nil {
+		return nil, fmt.Errorf("trivy scan interrupted: %w", subprocess.Error(ctx, runErr, stderr.String()))
+	}
+
 	if runErr != nil {
 		// Trivy returns non-zero exit code when vulnerabilities are found
 		// We still want to parse the output in this case
@@ -185,7 +291,11 @@ func (scanner *Scanner) scanPlatform(
 			Str("stderr", stderr.String()).
 			Msg("failed to parse trivy JSON output")
 
-		return nil, fmt.Errorf("failed to parse Trivy output: %w", err)
+		if runErr != nil {
+			return nil, fmt.Errorf("trivy command failed: %w", subprocess.Error(ctx, runErr, stderr.String()))
+		}
+
+		return nil, fmt.Errorf("failed to parse Trivy output: %w", subprocess.Error(ctx, err, stderr.String()))
 	}
 
 	scanner.log.Debug().
@@ -277,6 +387,10 @@ func formatTable(result *ScanResult) string {
 				_, _ = builder.WriteString(fmt.Sprintf("  Fixed in: %s\n", vuln.FixedVersion))
 			}
 
+			if vuln.KnownExploited {
+				_, _ = builder.WriteString(knownExploitedNote(vuln))
+			}
+
 			if vuln.Title != "" {
 				_, _ = builder.WriteString(fmt.Sprintf("  %s\n", vuln.Title))
 			}
@@ -290,41 +404,3 @@ func formatTable(result *ScanResult) string {
 
 	return builder.String()
 }
-
-// registryLogin logs in to a registry using trivy registry login.
-// This stores credentials in Docker's config (~/.docker/config.json) and keeps
-// them out of the process list. Credentials are only sent to the specific registry.
-func (scanner *Scanner) registryLogin(ctx context.Context, trivyPath, registryHost, username, password string) error {
-	scanner.log.Debug().
-		Str("registry", registryHost).
-		Msg("logging in to registry")
-
-	// Use --password-stdin to avoid password in process list
-	cmd := exec.CommandContext(
-		ctx,
-		trivyPath,
-		"registry",
-		"login",
-		registryHost,
-		"--username",
-		username,
-		"--password-stdin",
-	)
-	cmd.Stdin = strings.NewReader(password)
-
-	// Capture output for error reporting
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		scanner.log.Error().
-			Str("output", string(output)).
-			Msg("registry login failed")
-
-		return fmt.Errorf("trivy registry login failed: %w\n%s", err, output)
-	}
-
-	scanner.log.Debug().
-		Str("registry", registryHost).
-		Msg("registry login successful")
-
-	return nil
-}
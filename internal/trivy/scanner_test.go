@@ -419,3 +419,34 @@ func findSubstring(s, substr string) bool {
 
 	return false
 }
+
+// INTENTION: Aggregate merges per-platform results in order, preserving all targets.
+func TestAggregate(t *testing.T) {
+	t.Parallel()
+
+	results := []trivy.PlatformResult{
+		{
+			Platform: "linux/amd64",
+			Result: &trivy.ScanResult{Results: []trivy.Result{
+				{Target: "amd64", Vulnerabilities: []trivy.Vulnerability{{VulnerabilityID: "CVE-1"}}},
+			}},
+		},
+		{
+			Platform: "linux/arm64",
+			Result: &trivy.ScanResult{Results: []trivy.Result{
+				{Target: "arm64", Vulnerabilities: []trivy.Vulnerability{{VulnerabilityID: "CVE-2"}}},
+			}},
+		},
+	}
+
+	aggregated := trivy.Aggregate(results)
+
+	if len(aggregated.Results) != 2 {
+		t.Fatalf("Aggregate() results = %d, want 2", len(aggregated.Results))
+	}
+
+	if aggregated.Results[0].Target != "amd64" || aggregated.Results[1].Target != "arm64" {
+		t.Errorf("Aggregate() targets = %q, %q, want amd64, arm64",
+			aggregated.Results[0].Target, aggregated.Results[1].Target)
+	}
+}
@@ -2,6 +2,7 @@
 package version
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
@@ -12,15 +13,31 @@ import (
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/registry"
 )
 
 var errNoValidVersionsFound = errors.New("no valid versions found")
 
+// customVersionPattern matches the versions returned by variant parsers: numbers separated by dots.
+var customVersionPattern = regexp.MustCompile(`^v?[0-9]+(\.[0-9]+)*$`)
+
+// VariantParser splits a tag into its version and variant (e.g., "jdk17-alpine-2024" into "2024" and
+// "jdk17-alpine"), for images with unconventional tag schemes.
+// Tags whose version is not made of numbers separated by dots are ignored.
+type VariantParser func(tag string) (version, variant string)
+
 // Checker checks for image version updates from OCI registries.
 type Checker struct {
 	username string
 	password string
 	log      zerolog.Logger
+
+	// Additional remote options (e.g., user agent, transport)
+	extraOptions []remote.Option
+
+	// Custom tag parsing (ExtractVariant rules when nil)
+	variantParser VariantParser
 }
 
 // NewChecker creates a new version checker with optional authentication.
@@ -32,6 +49,23 @@ func NewChecker(username, password string, log zerolog.Logger) *Checker {
 	}
 }
 
+// WithRemoteOptions adds go-containerregistry options applied to every registry request
+// (e.g., registry.TransportOptions for user agent, metering and request logging).
+func (checker *Checker) WithRemoteOptions(opts ...remote.Option) *Checker {
+	checker.extraOptions = append(checker.extraOptions, opts...)
+
+	return checker
+}
+
+// WithVariantParser replaces the default tag parsing rules (see ExtractVariant) with parser:
+// the current version and the registry tags are split with it, and only tags of the same variant
+// are compared, by version.
+func (checker *Checker) WithVariantParser(parser VariantParser) *Checker {
+	checker.variantParser = parser
+
+	return checker
+}
+
 // Info contains version information for an image.
 type Info struct {
 	CurrentVersion  string
@@ -49,8 +83,7 @@ type Info struct {
 func (checker *Checker) CheckVersion(imageRef, currentVersion, variant string) (*Info, error) {
 	// Auto-extract variant from currentVersion if not explicitly provided
 	if variant == "" {
-		_, extractedVariant := extractVariant(currentVersion)
-		variant = extractedVariant
+		_, variant = checker.parseTag(currentVersion)
 	}
 
 	checker.log.Debug().
@@ -65,45 +98,35 @@ func (checker *Checker) CheckVersion(imageRef, currentVersion, variant string) (
 		return nil, fmt.Errorf("failed to parse repository: %w", err)
 	}
 
-	// List all tags from registry
-	tags, err := remote.List(repo, checker.remoteOptions()...)
+	// Filter versions page by page: registries list tags in lexical order, so the latest version can be on
+	// any page, but only the releases of the variant are kept
+	releases, err := checker.listReleases(repo, variant)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list tags: %w", err)
-	}
-
-	// Filter versions
-	var versions []string
-
-	for _, tag := range tags {
-		if isValidVersion(tag, variant) {
-			versions = append(versions, tag)
-		}
+		return nil, err
 	}
 
-	if len(versions) == 0 {
+	if len(releases) == 0 {
 		return nil, fmt.Errorf("%w: %s", errNoValidVersionsFound, imageRef)
 	}
 
 	// Sort versions semantically
-	sort.Slice(versions, func(i, j int) bool {
-		return compareVersions(versions[i], versions[j]) < 0
+	sort.Slice(releases, func(i, j int) bool {
+		return compareVersions(releases[i].version, releases[j].version) < 0
 	})
 
 	// Only fetch digest for the latest version (not all versions)
-	latestVersion := versions[len(versions)-1]
+	latestVersion := releases[len(releases)-1].tag
 
 	latestTagRef, err := name.ParseReference(fmt.Sprintf("%s:%s", imageRef, latestVersion))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse latest version reference: %w", err)
 	}
 
-	latestDesc, err := remote.Get(latestTagRef, checker.remoteOptions()...)
+	latestDigest, err := checker.digest(latestTagRef)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get latest version digest: %w", err)
 	}
 
-	latestDigest := latestDesc.Digest.String()
-
 	info := &Info{
 		CurrentVersion:  currentVersion,
 		LatestVersion:   latestVersion,
@@ -127,18 +150,77 @@ func (checker *Checker) CheckVersion(imageRef, currentVersion, variant string) (
 	return info, nil
 }
 
+// release is a tag, with its version for comparisons.
+type release struct {
+	tag     string
+	version string
+}
+
+// listReleases lists the tags of repo page by page, and returns the releases of variant.
+func (checker *Checker) listReleases(repo name.Repository, variant string) ([]release, error) {
+	puller, err := remote.NewPuller(append(checker.remoteOptions(), remote.WithPageSize(registry.DefaultTagPageSize))...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	lister, err := puller.Lister(context.Background(), repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", registry.Classify(err))
+	}
+
+	var releases []release
+
+	for lister.HasNext() {
+		page, err := lister.Next(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tags: %w", registry.Classify(err))
+		}
+
+		for _, tag := range page.Tags {
+			if version, ok := checker.releaseVersion(tag, variant); ok {
+				releases = append(releases, release{tag: tag, version: version})
+			}
+		}
+	}
+
+	return releases, nil
+}
+
 // remoteOptions returns remote options with authentication if configured.
 func (checker *Checker) remoteOptions() []remote.Option {
+	opts := append([]remote.Option{}, checker.extraOptions...)
+
 	if checker.username != "" && checker.password != "" {
 		auth := &authn.Basic{
 			Username: checker.username,
 			Password: checker.password,
 		}
 
-		return []remote.Option{remote.WithAuth(auth)}
+		opts = append(opts, remote.WithAuth(auth))
+	}
+
+	return opts
+}
+
+// parseTag splits a tag into its version and variant, with the variant parser when set.
+func (checker *Checker) parseTag(tag string) (version, variant string) {
+	if checker.variantParser != nil {
+		return checker.variantParser(tag)
 	}
 
-	return []remote.Option{}
+	return ExtractVariant(tag)
+}
+
+// releaseVersion returns the version of tag, and whether it is a release of variant.
+func (checker *Checker) releaseVersion(tag, variant string) (string, bool) {
+	if checker.variantParser == nil {
+		// compareVersions ignores the prefix and variant suffix
+		return tag, isValidVersion(tag, variant)
+	}
+
+	version, tagVariant := checker.variantParser(tag)
+
+	return version, tagVariant == variant && customVersionPattern.MatchString(version)
 }
 
 // isValidVersion checks if a tag is a valid semantic version.
@@ -224,13 +306,13 @@ func stripVersionPrefix(version string) string {
 	return version
 }
 
-// extractVariant extracts the variant suffix from a version string.
+// ExtractVariant extracts the variant suffix from a version string: the default tag parsing rules.
 // Examples:
 //   - "0.50.0-distroless-static" → version="0.50.0", variant="distroless-static"
 //   - "2.9.13-alpine" → version="2.9.13", variant="alpine"
 //   - "1.2.3" → version="1.2.3", variant=""
 //   - "v1.2.3-alpine" → version="1.2.3", variant="alpine"
-func extractVariant(fullVersion string) (version, variant string) {
+func ExtractVariant(fullVersion string) (version, variant string) {
 	// Strip 'v' prefix first
 	fullVersion = strings.TrimPrefix(fullVersion, "v")
 
@@ -250,10 +332,28 @@ func (checker *Checker) GetTagDigest(imageRef string) (string, error) {
 		return "", fmt.Errorf("failed to parse image reference: %w", err)
 	}
 
-	desc, err := remote.Get(ref, checker.remoteOptions()...)
+	digest, err := checker.digest(ref)
 	if err != nil {
 		return "", fmt.Errorf("failed to get image descriptor: %w", err)
 	}
 
-	return desc.Digest.String(), nil
+	return digest, nil
+}
+
+// digest resolves the digest ref points to with a HEAD request, falling back to a GET for registries
+// failing HEAD requests or answering them without a digest.
+func (checker *Checker) digest(ref name.Reference) (string, error) {
+	desc, err := remote.Head(ref, checker.remoteOptions()...)
+	if err == nil {
+		return desc.Digest.String(), nil
+	}
+
+	checker.log.Debug().Err(err).Str("ref", ref.String()).Msg("HEAD request failed, fetching manifest")
+
+	full, err := remote.Get(ref, checker.remoteOptions()...)
+	if err != nil {
+		return "", registry.Classify(err)
+	}
+
+	return full.Digest.String(), nil
 }
@@ -1,8 +1,16 @@
 package version_test
 
 import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/rs/zerolog"
 
 	"github.com/farcloser/quark/internal/version"
@@ -189,3 +197,78 @@ func findSubstring(s, substr string) bool {
 
 	return false
 }
+
+// INTENTION: The default tag rules split the variant at the first hyphen and drop a leading "v".
+func TestExtractVariant(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		tag         string
+		wantVersion string
+		wantVariant string
+	}{
+		{tag: "1.2.3", wantVersion: "1.2.3"},
+		{tag: "v1.2.3-alpine", wantVersion: "1.2.3", wantVariant: "alpine"},
+		{tag: "0.50.0-distroless-static", wantVersion: "0.50.0", wantVariant: "distroless-static"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			t.Parallel()
+
+			gotVersion, gotVariant := version.ExtractVariant(tt.tag)
+			if gotVersion != tt.wantVersion || gotVariant != tt.wantVariant {
+				t.Errorf("ExtractVariant(%q) = %q, %q, want %q, %q",
+					tt.tag, gotVersion, gotVariant, tt.wantVersion, tt.wantVariant)
+			}
+		})
+	}
+}
+
+// INTENTION: A variant parser lets unconventional tag schemes be checked: only tags of the current variant
+// with a numeric version are compared, by the version the parser extracts.
+func TestChecker_CheckVersion_VariantParser(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	repo := strings.TrimPrefix(server.URL, "http://") + "/runtime/java"
+
+	for _, tag := range []string{"jdk17-alpine-2023", "jdk17-alpine-2024", "jdk21-alpine-2025", "jdk17-alpine-nightly"} {
+		img, err := random.Image(256, 1)
+		if err != nil {
+			t.Fatalf("failed to create random image: %v", err)
+		}
+
+		ref, err := name.ParseReference(repo + ":" + tag)
+		if err != nil {
+			t.Fatalf("failed to parse reference: %v", err)
+		}
+
+		if err := remote.Write(ref, img); err != nil {
+			t.Fatalf("failed to push %s: %v", tag, err)
+		}
+	}
+
+	// The version is the last dash-separated part, the variant what precedes it
+	parser := func(tag string) (string, string) {
+		idx := strings.LastIndex(tag, "-")
+		if idx == -1 {
+			return tag, ""
+		}
+
+		return tag[idx+1:], tag[:idx]
+	}
+
+	checker := version.NewChecker("", "", zerolog.Nop()).WithVariantParser(parser)
+
+	info, err := checker.CheckVersion(repo, "jdk17-alpine-2023", "")
+	if err != nil {
+		t.Fatalf("CheckVersion() error = %v", err)
+	}
+
+	if info.LatestVersion != "jdk17-alpine-2024" || !info.UpdateAvailable || info.LatestDigest == "" {
+		t.Errorf("CheckVersion() = %+v, want update to jdk17-alpine-2024", info)
+	}
+}
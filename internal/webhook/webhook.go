@@ -0,0 +1,149 @@
+// Package webhook delivers signed JSON events to HTTP endpoints, so downstream systems (deploy bots, CMDBs)
+// learn about quark results without polling.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 of the timestamp and the request body ("sha256=<hex>"), when a
+	// secret is set.
+	SignatureHeader = "X-Quark-Signature"
+	// TimestampHeader carries the time of the delivery attempt (Unix seconds), signed with the body so receivers
+	// can reject replayed deliveries.
+	TimestampHeader = "X-Quark-Timestamp"
+	// EventHeader carries the event type (e.g., "sync").
+	EventHeader = "X-Quark-Event"
+)
+
+const (
+	// deliveryAttempts is how many times a delivery is attempted before it fails.
+	deliveryAttempts = 3
+	// deliveryBackoff is the wait before the second attempt, doubled before each next one.
+	deliveryBackoff = time.Second
+	// deliveryTimeout bounds each attempt, by default.
+	deliveryTimeout = 10 * time.Second
+)
+
+// ErrDeliveryFailed indicates the endpoint did not accept an event.
+var ErrDeliveryFailed = errors.New("webhook delivery failed")
+
+// Sender delivers events to an endpoint.
+type Sender struct {
+	// URL is the endpoint events are POSTed to.
+	URL string
+	// Secret signs the events (unsigned when empty).
+	Secret string
+	// Client sends the requests (http.DefaultClient when nil).
+	Client *http.Client
+	// Backoff is the wait before the second attempt (one second when zero).
+	Backoff time.Duration
+	// Timeout bounds each attempt (10 seconds when zero).
+	Timeout time.Duration
+}
+
+// Send POSTs payload, encoded as JSON, as an event of type event. Network failures, 429 and 5xx answers are
+// attempted again with a backoff; other answers fail at once.
+func (sender *Sender) Send(ctx context.Context, event string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", event, err)
+	}
+
+	backoff := sender.Backoff
+	if backoff <= 0 {
+		backoff = deliveryBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		retryable, err := sender.post(ctx, event, body)
+		if err == nil || !retryable || attempt >= deliveryAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+// post makes a delivery attempt, and reports whether a failure may succeed on another attempt.
+func (sender *Sender) post(ctx context.Context, event string, body []byte) (bool, error) {
+	timeout := sender.Timeout
+	if timeout <= 0 {
+		timeout = deliveryTimeout
+	}
+
+	// The attempt timing out is retryable, ctx being canceled is not
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(attemptCtx, http.MethodPost, sender.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrDeliveryFailed, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+
+	if sender.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, Sign(sender.Secret, timestamp, body))
+	}
+
+	client := sender.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("%w: %w", ErrDeliveryFailed, err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		//nolint:mnd // Error bodies are short documents
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+
+		return retryable, fmt.Errorf("%w: %s: %s %s", ErrDeliveryFailed, event, resp.Status,
+			strings.TrimSpace(string(detail)))
+	}
+
+	return false, nil
+}
+
+// Sign returns the signature of timestamp and body with secret, as sent in SignatureHeader ("sha256=<hex>"): the
+// HMAC-SHA256 of timestamp, ".", and body. Receivers recompute it over TimestampHeader and the raw request body,
+// compare with hmac.Equal, and reject old timestamps.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,139 @@
+package webhook_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/farcloser/quark/internal/webhook"
+)
+
+// INTENTION: A delivery carries the event type and the JSON payload, signed with the secret together with the
+// delivery time so receivers can authenticate it and reject replays.
+func TestSender_Send(t *testing.T) {
+	t.Parallel()
+
+	var (
+		event     string
+		timestamp string
+		signature string
+		body      []byte
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		event = req.Header.Get(webhook.EventHeader)
+		timestamp = req.Header.Get(webhook.TimestampHeader)
+		signature = req.Header.Get(webhook.SignatureHeader)
+		body, _ = io.ReadAll(req.Body)
+
+		writer.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	sender := &webhook.Sender{URL: server.URL, Secret: "s3cret"}
+
+	sent := time.Now().Unix()
+
+	if err := sender.Send(t.Context(), "sync", map[string]string{"digest": "sha256:abc"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if event != "sync" {
+		t.Errorf("event = %q, want sync", event)
+	}
+
+	if seconds, err := strconv.ParseInt(timestamp, 10, 64); err != nil || seconds < sent || seconds > sent+1 {
+		t.Errorf("timestamp = %q, want the delivery time", timestamp)
+	}
+
+	if signature != webhook.Sign("s3cret", timestamp, body) {
+		t.Errorf("signature = %q, want %q", signature, webhook.Sign("s3cret", timestamp, body))
+	}
+
+	if signature == webhook.Sign("s3cret", "0", body) {
+		t.Errorf("signature = %q, want it to depend on the timestamp", signature)
+	}
+
+	var payload map[string]string
+	if err := json.Unmarshal(body, &payload); err != nil || payload["digest"] != "sha256:abc" {
+		t.Errorf("payload = %s, want the digest", body)
+	}
+}
+
+// INTENTION: Transient answers and timed out attempts are retried until the endpoint accepts the event; client
+// errors are not.
+func TestSender_Send_Retries(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/rejected":
+			attempts.Add(1)
+			writer.WriteHeader(http.StatusBadRequest)
+		case attempts.Add(1) < 3:
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			writer.WriteHeader(http.StatusOK)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	sender := &webhook.Sender{URL: server.URL, Backoff: time.Millisecond}
+	if err := sender.Send(t.Context(), "sync", nil); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if attempts.Load() != 3 {
+		t.Errorf("attempts = %d, want 3", attempts.Load())
+	}
+
+	attempts.Store(0)
+
+	sender = &webhook.Sender{URL: server.URL + "/rejected", Backoff: time.Millisecond}
+	if err := sender.Send(t.Context(), "sync", nil); !errors.Is(err, webhook.ErrDeliveryFailed) {
+		t.Fatalf("Send() error = %v, want ErrDeliveryFailed", err)
+	}
+
+	if attempts.Load() != 1 {
+		t.Errorf("attempts = %d, want 1", attempts.Load())
+	}
+}
+
+// INTENTION: An attempt timing out is retried, as long as the context of the delivery is not done.
+func TestSender_Send_RetriesTimeout(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if attempts.Add(1) == 1 {
+			// Answered too late for the attempt
+			_, _ = io.ReadAll(req.Body)
+
+			select {
+			case <-req.Context().Done():
+			case <-time.After(time.Second):
+			}
+		}
+
+		writer.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	sender := &webhook.Sender{URL: server.URL, Backoff: time.Millisecond, Timeout: 50 * time.Millisecond}
+	if err := sender.Send(t.Context(), "sync", nil); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if attempts.Load() != 2 {
+		t.Errorf("attempts = %d, want 2", attempts.Load())
+	}
+}
@@ -0,0 +1,253 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// artifactFile is a file published as an artifact blob.
+type artifactFile struct {
+	path      string
+	mediaType string
+}
+
+// Artifact represents publishing a non-image OCI artifact (SBOM, policy bundle, report, ...).
+type Artifact struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	image        *Image
+	registry     *Registry
+	artifactType string
+	files        []artifactFile
+	annotations  map[string]string
+
+	// Results populated after execution
+	digest string
+}
+
+// ArtifactBuilder builds an Artifact.
+type ArtifactBuilder struct {
+	builderState
+
+	plan     *Plan
+	artifact *Artifact
+}
+
+// Destination sets the reference the artifact is pushed to.
+// The image must have a version (the tag to push).
+// Registry credentials are looked up from the plan's registry collection using the image domain.
+func (builder *ArtifactBuilder) Destination(image *Image) *ArtifactBuilder {
+	builder.artifact.image = image
+	builder.artifact.registry = builder.plan.getRegistry(image.Domain())
+
+	return builder
+}
+
+// ArtifactType sets the artifact type (e.g., "application/vnd.example.sbom").
+func (builder *ArtifactBuilder) ArtifactType(artifactType string) *ArtifactBuilder {
+	builder.artifact.artifactType = artifactType
+
+	return builder
+}
+
+// File adds a file as an artifact blob with the given media type (e.g., "application/spdx+json").
+// The file base name is recorded as the blob title, so ORAS pulls restore it.
+func (builder *ArtifactBuilder) File(path, mediaType string) *ArtifactBuilder {
+	builder.artifact.files = append(builder.artifact.files, artifactFile{path: path, mediaType: mediaType})
+
+	return builder
+}
+
+// Annotation adds a manifest annotation.
+func (builder *ArtifactBuilder) Annotation(key, value string) *ArtifactBuilder {
+	builder.artifact.annotations[key] = value
+
+	return builder
+}
+
+// RunOnlyOn restricts the artifact to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *ArtifactBuilder) RunOnlyOn(envs ...Environment) *ArtifactBuilder {
+	builder.artifact.runOnlyOn = append(builder.artifact.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the artifact mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *ArtifactBuilder) Resource(resource Resource) *ArtifactBuilder {
+	builder.artifact.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the artifact start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *ArtifactBuilder) DependsOn(ops ...Dependency) *ArtifactBuilder {
+	builder.artifact.add(ops)
+
+	return builder
+}
+
+// When makes the artifact run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the artifact.
+func (builder *ArtifactBuilder) When(conditions ...Condition) *ArtifactBuilder {
+	builder.artifact.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a artifact push named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *ArtifactBuilder) Clone(name string) *ArtifactBuilder {
+	clone := builder.plan.Artifact(name)
+	clone.artifact.envGuard = builder.artifact.envGuard.clone()
+	clone.artifact.resourceHint = builder.artifact.resourceHint
+	clone.artifact.dependencyList = builder.artifact.dependencyList.clone()
+	clone.artifact.conditionList = builder.artifact.conditionList.clone()
+	clone.artifact.image = builder.artifact.image
+	clone.artifact.registry = builder.artifact.registry
+	clone.artifact.artifactType = builder.artifact.artifactType
+	clone.artifact.files = slices.Clone(builder.artifact.files)
+	clone.artifact.annotations = maps.Clone(builder.artifact.annotations)
+
+	return clone
+}
+
+// Reset makes the builder usable again for a artifact push named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *ArtifactBuilder) Reset(name string) *ArtifactBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build validates and adds the artifact to the plan.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *ArtifactBuilder) Build() (*Artifact, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.artifact.opName); err != nil {
+		return nil, err
+	}
+
+	if builder.artifact.image == nil {
+		return nil, ErrArtifactDestinationRequired
+	}
+
+	if builder.artifact.image.Version() == "" {
+		return nil, fmt.Errorf("%w for image %q", ErrArtifactVersionRequired, builder.artifact.image.Name())
+	}
+
+	if builder.artifact.artifactType == "" {
+		return nil, ErrArtifactTypeRequired
+	}
+
+	if len(builder.artifact.files) == 0 {
+		return nil, ErrArtifactFileRequired
+	}
+
+	builder.plan.artifacts = append(builder.plan.artifacts, builder.artifact)
+	builder.plan.addOperation(builder.artifact)
+
+	return builder.artifact, nil
+}
+
+func (artifact *Artifact) execute(ctx context.Context) error {
+	tagRef, err := artifact.image.tagRef()
+	if err != nil {
+		return fmt.Errorf("failed to build artifact reference: %w", err)
+	}
+
+	blobs := make([]registry.ArtifactBlob, 0, len(artifact.files))
+
+	for _, file := range artifact.files {
+		// Files are streamed to the registry, not read into memory: fail early if they cannot be read
+		if _, err := os.Stat(file.path); err != nil {
+			return fmt.Errorf("failed to read artifact file: %w", err)
+		}
+
+		blobs = append(blobs, registry.ArtifactBlob{
+			MediaType: file.mediaType,
+			Title:     filepath.Base(file.path),
+			Open: func() (io.ReadCloser, error) {
+				//nolint:gosec // File paths are from plan configuration
+				return os.Open(file.path)
+			},
+		})
+	}
+
+	artifact.log.Info().
+		Str("destination", tagRef).
+		Str("artifact_type", artifact.artifactType).
+		Int("files", len(blobs)).
+		Msg("pushing artifact")
+
+	if err := artifact.registry.ensureRepository(ctx, artifact.image.Path(), artifact.log); err != nil {
+		return err
+	}
+
+	client := newRegistryClient(artifact.registry, artifact.log)
+
+	pushed, err := client.PushArtifact(ctx, tagRef, artifact.artifactType, blobs, artifact.annotations)
+	if err != nil {
+		return fmt.Errorf("failed to push artifact: %w", err)
+	}
+
+	artifact.digest = pushed
+	// Subsequent operations reference the artifact by digest
+	artifact.image.ref.Digest = digest.Digest(pushed)
+
+	artifact.log.Info().
+		Str("destination", tagRef).
+		Str("digest", pushed).
+		Msg("artifact pushed")
+
+	return nil
+}
+
+// Digest returns the pushed artifact manifest digest (empty before execution).
+func (artifact *Artifact) Digest() string {
+	return artifact.digest
+}
+
+// destructiveChange implements destructiveOperation: publishing overwrites an existing tag.
+func (artifact *Artifact) destructiveChange(ctx context.Context) (string, error) {
+	tagRef, err := artifact.image.tagRef()
+	if err != nil {
+		return "", fmt.Errorf("failed to build artifact reference: %w", err)
+	}
+
+	return tagOverwrite(ctx, newRegistryClient(artifact.registry, artifact.log), tagRef, "")
+}
+
+// producedDigest implements producingOperation.
+func (artifact *Artifact) producedDigest() string {
+	return artifact.Digest()
+}
+
+// destination implements destinationOperation: the artifact image.
+func (artifact *Artifact) destination() *Image {
+	return artifact.image
+}
+
+// resolveRegistries implements registryOperation.
+func (artifact *Artifact) resolveRegistries(lookup func(image *Image) *Registry) {
+	artifact.registry = lookup(artifact.image)
+}
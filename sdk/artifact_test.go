@@ -0,0 +1,106 @@
+package sdk_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: Artifact requires a tagged destination, an artifact type and at least one file.
+func TestArtifactBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	tagged, err := sdk.NewImage("my-org/app-sbom").
+		Domain("ghcr.io").
+		Version("1.0.0").
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	untagged, err := sdk.NewImage("my-org/app-sbom").
+		Domain("ghcr.io").
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	const sbomType = "application/vnd.example.sbom"
+
+	tests := []struct {
+		name    string
+		build   func(*sdk.Plan) (*sdk.Artifact, error)
+		wantErr error
+	}{
+		{
+			name: "valid artifact",
+			build: func(plan *sdk.Plan) (*sdk.Artifact, error) {
+				return plan.Artifact("sbom").
+					Destination(tagged).
+					ArtifactType(sbomType).
+					File("sbom.spdx.json", "application/spdx+json").
+					Annotation("org.opencontainers.image.source", "https://github.com/my-org/app").
+					Build()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "missing destination",
+			build: func(plan *sdk.Plan) (*sdk.Artifact, error) {
+				return plan.Artifact("sbom").ArtifactType(sbomType).File("sbom.json", "application/json").Build()
+			},
+			wantErr: sdk.ErrArtifactDestinationRequired,
+		},
+		{
+			name: "destination without version",
+			build: func(plan *sdk.Plan) (*sdk.Artifact, error) {
+				return plan.Artifact("sbom").
+					Destination(untagged).
+					ArtifactType(sbomType).
+					File("sbom.json", "application/json").
+					Build()
+			},
+			wantErr: sdk.ErrArtifactVersionRequired,
+		},
+		{
+			name: "missing artifact type",
+			build: func(plan *sdk.Plan) (*sdk.Artifact, error) {
+				return plan.Artifact("sbom").Destination(tagged).File("sbom.json", "application/json").Build()
+			},
+			wantErr: sdk.ErrArtifactTypeRequired,
+		},
+		{
+			name: "missing files",
+			build: func(plan *sdk.Plan) (*sdk.Artifact, error) {
+				return plan.Artifact("sbom").Destination(tagged).ArtifactType(sbomType).Build()
+			},
+			wantErr: sdk.ErrArtifactFileRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			plan := sdk.NewPlan(testPlanName)
+			artifact, err := tt.build(plan)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Build() unexpected error = %v", err)
+			}
+
+			if artifact == nil {
+				t.Error("Build() returned nil artifact with nil error")
+			}
+		})
+	}
+}
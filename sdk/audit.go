@@ -4,12 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"slices"
 	"strings"
 	"time"
 
-	"github.com/rs/zerolog"
-
 	"github.com/farcloser/quark/internal/audit"
+	"github.com/farcloser/quark/internal/exceptions"
 )
 
 // AuditRuleSet represents audit rule severity.
@@ -65,21 +66,33 @@ func (r *AuditRuleSet) UnmarshalJSON(data []byte) error {
 
 // Audit represents a Dockerfile and image quality audit.
 type Audit struct {
-	opName       string
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
 	dockerfile   string
 	image        *Image
 	registry     *Registry
 	ruleSet      AuditRuleSet
 	ignoreChecks []string
 	timeout      time.Duration
-	log          zerolog.Logger
+
+	// exceptions is set by executor before execution (nil when Plan.Exceptions is unset)
+	exceptions *exceptions.Set
+
+	// Results populated after execution
+	issues []string
+	waived []string
 }
 
 // AuditBuilder builds an Audit.
 type AuditBuilder struct {
+	builderState
+
 	plan  *Plan
 	audit *Audit
-	built bool
 }
 
 // Dockerfile sets the Dockerfile path to audit.
@@ -92,8 +105,10 @@ func (builder *AuditBuilder) Dockerfile(dockerfile string) *AuditBuilder {
 // Source sets the image to audit.
 // Registry credentials are looked up from the plan's registry collection using the image domain.
 // If no registry is found, anonymous access will be used.
+// Images output by another operation (see Sync.OutputImage) make the audit depend on it.
 func (builder *AuditBuilder) Source(image *Image) *AuditBuilder {
 	builder.audit.image = image
+	builder.audit.consume(image)
 	builder.audit.registry = builder.plan.getRegistry(image.Domain())
 
 	return builder
@@ -121,15 +136,75 @@ func (builder *AuditBuilder) Timeout(duration time.Duration) *AuditBuilder {
 	return builder
 }
 
+// RunOnlyOn restricts the audit to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *AuditBuilder) RunOnlyOn(envs ...Environment) *AuditBuilder {
+	builder.audit.runOnlyOn = append(builder.audit.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the audit mostly uses (default: sdk.ResourceCPU),
+// for executors bounding how many operations of each class run at once.
+func (builder *AuditBuilder) Resource(resource Resource) *AuditBuilder {
+	builder.audit.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the audit start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *AuditBuilder) DependsOn(ops ...Dependency) *AuditBuilder {
+	builder.audit.add(ops)
+
+	return builder
+}
+
+// When makes the audit run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the audit.
+func (builder *AuditBuilder) When(conditions ...Condition) *AuditBuilder {
+	builder.audit.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a audit named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *AuditBuilder) Clone(name string) *AuditBuilder {
+	clone := builder.plan.Audit(name)
+	clone.audit.envGuard = builder.audit.envGuard.clone()
+	clone.audit.resourceHint = builder.audit.resourceHint
+	clone.audit.dependencyList = builder.audit.dependencyList.clone()
+	clone.audit.conditionList = builder.audit.conditionList.clone()
+	clone.audit.dockerfile = builder.audit.dockerfile
+	clone.audit.image = builder.audit.image
+	clone.audit.registry = builder.audit.registry
+	clone.audit.ruleSet = builder.audit.ruleSet
+	clone.audit.ignoreChecks = slices.Clone(builder.audit.ignoreChecks)
+	clone.audit.timeout = builder.audit.timeout
+
+	return clone
+}
+
+// Reset makes the builder usable again for a audit named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *AuditBuilder) Reset(name string) *AuditBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
 // Build validates and adds the audit to the plan.
 // The builder becomes unusable after Build() is called.
-// Create a new builder for each operation.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
 func (builder *AuditBuilder) Build() (*Audit, error) {
-	if builder.built {
-		return nil, ErrBuilderAlreadyUsed
+	if err := builder.use(); err != nil {
+		return nil, err
 	}
 
-	builder.built = true
+	if err := builder.plan.checkOperationName(builder.audit.opName); err != nil {
+		return nil, err
+	}
 
 	if builder.audit.dockerfile == "" && builder.audit.image == nil {
 		return nil, ErrAuditSourceRequired
@@ -140,7 +215,7 @@ func (builder *AuditBuilder) Build() (*Audit, error) {
 	}
 
 	builder.plan.audits = append(builder.plan.audits, builder.audit)
-	builder.plan.operations = append(builder.plan.operations, builder.audit)
+	builder.plan.addOperation(builder.audit)
 
 	return builder.audit, nil
 }
@@ -156,9 +231,13 @@ func (auditJob *Audit) execute(ctx context.Context) error {
 	var imageRef string
 
 	if auditJob.image != nil {
-		ref, err := auditJob.image.tagRef()
+		// Prefer digest so the audited content is exactly what was synced/built, fall back to tag
+		ref, err := auditJob.image.digestRef()
 		if err != nil {
-			return fmt.Errorf("failed to build image reference: %w", err)
+			ref, err = auditJob.image.tagRef()
+			if err != nil {
+				return fmt.Errorf("failed to build image reference: %w", err)
+			}
 		}
 
 		imageRef = ref
@@ -173,15 +252,26 @@ func (auditJob *Audit) execute(ctx context.Context) error {
 	auditor := audit.NewAuditor(auditJob.log)
 	allPassed := true
 
+	// Waived checks are not checked: the exceptions applicable to the image also cover its Dockerfile
+	applicable := waivers(auditJob.exceptions, imageRef, time.Now().UTC())
+	ignored := waiverIDs(applicable)
+
+	auditJob.waived = nil
+	for _, exception := range applicable {
+		auditJob.waived = append(auditJob.waived, describeWaiver(exception))
+	}
+
 	// Audit Dockerfile if provided
 	if auditJob.dockerfile != "" {
-		result, err := auditor.AuditDockerfile(ctx, auditJob.dockerfile)
+		result, err := auditor.AuditDockerfile(ctx, auditJob.dockerfile, ignored...)
 		if err != nil {
 			return fmt.Errorf("failed to audit Dockerfile: %w", err)
 		}
 
 		auditJob.log.Info().Msg(result.Output)
 
+		auditJob.issues = append(auditJob.issues, result.Issues...)
+
 		if !result.Passed {
 			allPassed = false
 		}
@@ -189,9 +279,14 @@ func (auditJob *Audit) execute(ctx context.Context) error {
 
 	// Audit image if provided
 	if auditJob.image != nil {
+		// Artifacts (WASM modules, signatures, charts, ...) cannot be audited as container images
+		if err := newRegistryClient(auditJob.registry, auditJob.log).CheckRunnable(ctx, imageRef); err != nil {
+			return fmt.Errorf("cannot audit %s: %w", imageRef, err)
+		}
+
 		opts := audit.ImageAuditOptions{
 			RuleSet:      auditJob.ruleSet.String(),
-			IgnoreChecks: auditJob.ignoreChecks,
+			IgnoreChecks: append(slices.Clone(auditJob.ignoreChecks), ignored...),
 		}
 
 		if auditJob.registry != nil {
@@ -207,6 +302,8 @@ func (auditJob *Audit) execute(ctx context.Context) error {
 
 		auditJob.log.Info().Msg(result.Output)
 
+		auditJob.issues = append(auditJob.issues, result.Issues...)
+
 		if !result.Passed {
 			allPassed = false
 		}
@@ -223,7 +320,48 @@ func (auditJob *Audit) execute(ctx context.Context) error {
 	return nil
 }
 
-// operationName returns the audit operation name (implements operation interface).
-func (auditJob *Audit) operationName() string {
-	return auditJob.opName
+// Issues returns the Dockerfile and image issues found, one line per issue
+// (including issues tolerated by the rule set). Only valid after plan execution.
+func (auditJob *Audit) Issues() []string {
+	return auditJob.issues
+}
+
+// Waived describes the exceptions applicable to the audit, whose checks were not checked (see Plan.Exceptions).
+// Only valid after plan execution.
+func (auditJob *Audit) Waived() []string {
+	return auditJob.waived
+}
+
+// plannedChanges implements dryRunOperation: the Dockerfile must exist, and the image when its digest is known.
+func (auditJob *Audit) plannedChanges(ctx context.Context) ([]string, error) {
+	var changes []string
+
+	if auditJob.dockerfile != "" {
+		if _, err := os.Stat(auditJob.dockerfile); err != nil {
+			return nil, fmt.Errorf("failed to read Dockerfile: %w", err)
+		}
+
+		changes = append(changes, "Would lint "+auditJob.dockerfile)
+	}
+
+	if auditJob.image != nil {
+		imageRef, err := checkImage(ctx, newRegistryClient(auditJob.registry, auditJob.log), auditJob.image)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, "Would audit "+imageRef)
+	}
+
+	return changes, nil
+}
+
+// cacheSources implements cacheableOperation: an audit reads the audited image, if any.
+func (auditJob *Audit) cacheSources() []*Image {
+	return []*Image{auditJob.image}
+}
+
+// resolveRegistries implements registryOperation.
+func (auditJob *Audit) resolveRegistries(lookup func(image *Image) *Registry) {
+	auditJob.registry = lookup(auditJob.image)
 }
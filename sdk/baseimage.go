@@ -0,0 +1,212 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/farcloser/quark/internal/dockerfile"
+)
+
+// BaseImageCheck represents checking the base images of a Dockerfile: each FROM line is registered
+// as a VersionCheck (so build inputs join the update workflow), and FROM lines not pinned by digest are flagged.
+type BaseImageCheck struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	dockerfile     string
+	failOnUnpinned bool
+
+	// Populated by Build()
+	bases         []dockerfile.BaseImage
+	versionChecks []*VersionCheck
+}
+
+// BaseImageCheckBuilder builds a BaseImageCheck.
+type BaseImageCheckBuilder struct {
+	builderState
+
+	plan  *Plan
+	check *BaseImageCheck
+}
+
+// Dockerfile sets the Dockerfile whose FROM lines are checked.
+// Global build arguments (ARG NAME=default before the first FROM) are expanded with their defaults.
+func (builder *BaseImageCheckBuilder) Dockerfile(path string) *BaseImageCheckBuilder {
+	builder.check.dockerfile = path
+
+	return builder
+}
+
+// FailOnUnpinned fails the check when a FROM line is not pinned by digest (default: warn only).
+func (builder *BaseImageCheckBuilder) FailOnUnpinned(enabled bool) *BaseImageCheckBuilder {
+	builder.check.failOnUnpinned = enabled
+
+	return builder
+}
+
+// RunOnlyOn restricts the check, and the version checks it registers, to the given environments
+// (e.g., sdk.EnvCI): they are skipped when the plan is executed elsewhere.
+func (builder *BaseImageCheckBuilder) RunOnlyOn(envs ...Environment) *BaseImageCheckBuilder {
+	builder.check.runOnlyOn = append(builder.check.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the check mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *BaseImageCheckBuilder) Resource(resource Resource) *BaseImageCheckBuilder {
+	builder.check.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the check start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *BaseImageCheckBuilder) DependsOn(ops ...Dependency) *BaseImageCheckBuilder {
+	builder.check.add(ops)
+
+	return builder
+}
+
+// When makes the check run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the check.
+func (builder *BaseImageCheckBuilder) When(conditions ...Condition) *BaseImageCheckBuilder {
+	builder.check.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a base image check named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *BaseImageCheckBuilder) Clone(name string) *BaseImageCheckBuilder {
+	clone := builder.plan.BaseImageCheck(name)
+	clone.check.envGuard = builder.check.envGuard.clone()
+	clone.check.resourceHint = builder.check.resourceHint
+	clone.check.dependencyList = builder.check.dependencyList.clone()
+	clone.check.conditionList = builder.check.conditionList.clone()
+	clone.check.dockerfile = builder.check.dockerfile
+	clone.check.failOnUnpinned = builder.check.failOnUnpinned
+
+	return clone
+}
+
+// Reset makes the builder usable again for a base image check named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *BaseImageCheckBuilder) Reset(name string) *BaseImageCheckBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build parses the Dockerfile, adds the check to the plan, then registers a VersionCheck
+// (named "<check>/<image>") for each base image with a version.
+// Base images without a version (implicitly "latest") cannot be version checked and are only flagged.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *BaseImageCheckBuilder) Build() (*BaseImageCheck, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.check.opName); err != nil {
+		return nil, err
+	}
+
+	check := builder.check
+
+	if check.dockerfile == "" {
+		return nil, ErrBaseImageCheckDockerfileRequired
+	}
+
+	bases, err := dockerfile.ParseFile(check.dockerfile)
+	if err != nil {
+		return nil, err
+	}
+
+	check.bases = bases
+
+	builder.plan.addOperation(check)
+
+	// Stages often share a base image: check each reference once
+	checked := make(map[string]bool)
+
+	for _, base := range bases {
+		if len(base.Unresolved) > 0 || checked[base.Reference] {
+			continue
+		}
+
+		checked[base.Reference] = true
+
+		image, err := NewImage(base.Reference).Build()
+		if err != nil {
+			return nil, fmt.Errorf("invalid base image %q in %s line %d: %w", base.Reference, check.dockerfile, base.Line, err)
+		}
+
+		if image.Version() == "" {
+			continue
+		}
+
+		versionCheck, err := builder.plan.VersionCheck(check.opName + "/" + base.Reference).
+			Source(image).
+			RunOnlyOn(check.runOnlyOn...).
+			Build()
+		if err != nil {
+			return nil, err
+		}
+
+		check.versionChecks = append(check.versionChecks, versionCheck)
+	}
+
+	return check, nil
+}
+
+func (check *BaseImageCheck) execute(_ context.Context) error {
+	unpinned := check.Unpinned()
+
+	for _, base := range check.bases {
+		event := check.log.Info()
+		if !base.Pinned() {
+			event = check.log.Warn()
+		}
+
+		event = event.
+			Str("dockerfile", check.dockerfile).
+			Int("line", base.Line).
+			Str("image", base.Reference).
+			Bool("pinned", base.Pinned())
+
+		if len(base.Unresolved) > 0 {
+			event = event.Strs("unresolved_args", base.Unresolved)
+		}
+
+		event.Msg("base image")
+	}
+
+	if len(unpinned) > 0 && check.failOnUnpinned {
+		return fmt.Errorf("%w in %s: %s", ErrUnpinnedBaseImage, check.dockerfile, strings.Join(unpinned, ", "))
+	}
+
+	return nil
+}
+
+// Unpinned returns the base images not pinned by digest.
+func (check *BaseImageCheck) Unpinned() []string {
+	var unpinned []string
+
+	for _, base := range check.bases {
+		if !base.Pinned() {
+			unpinned = append(unpinned, base.Reference)
+		}
+	}
+
+	return unpinned
+}
+
+// VersionChecks returns the version checks registered for the base images.
+func (check *BaseImageCheck) VersionChecks() []*VersionCheck {
+	return check.versionChecks
+}
@@ -0,0 +1,85 @@
+package sdk_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/sdk"
+)
+
+const testBaseImageDockerfile = `ARG GO_VERSION=1.24
+FROM golang:${GO_VERSION} AS build
+FROM build AS test
+FROM alpine:3.20@sha256:0000000000000000000000000000000000000000000000000000000000000000
+FROM alpine:3.20@sha256:0000000000000000000000000000000000000000000000000000000000000000 AS runtime
+FROM debian
+FROM registry.example.com/base:$UNSET
+`
+
+// INTENTION: Each versioned FROM line is registered as a VersionCheck in the plan, and base images
+// not pinned by digest (including unversioned and unresolvable ones) are reported.
+func TestBaseImageCheckBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "Dockerfile")
+	if err := os.WriteFile(path, []byte(testBaseImageDockerfile), filesystem.FilePermissionsDefault); err != nil {
+		t.Fatalf("Failed to write Dockerfile: %v", err)
+	}
+
+	plan := sdk.NewPlan(testPlanName)
+
+	check, err := plan.BaseImageCheck("bases").Dockerfile(path).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	// golang:1.24 and alpine:3.20, once for both stages (debian has no version, base has an unresolved argument)
+	if checks := check.VersionChecks(); len(checks) != 2 {
+		t.Fatalf("registered %d version checks, want 2", len(checks))
+	}
+
+	wantUnpinned := []string{"golang:1.24", "debian", "registry.example.com/base:"}
+
+	unpinned := check.Unpinned()
+	if len(unpinned) != len(wantUnpinned) {
+		t.Fatalf("Unpinned() = %v, want %v", unpinned, wantUnpinned)
+	}
+
+	for idx, ref := range unpinned {
+		if ref != wantUnpinned[idx] {
+			t.Errorf("Unpinned()[%d] = %q, want %q", idx, ref, wantUnpinned[idx])
+		}
+	}
+}
+
+// INTENTION: Without a Dockerfile the check cannot be built, and FailOnUnpinned turns unpinned
+// base images into an execution failure.
+func TestBaseImageCheck_Errors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := sdk.NewPlan(testPlanName).BaseImageCheck("bases").Build(); !errors.Is(
+		err,
+		sdk.ErrBaseImageCheckDockerfileRequired,
+	) {
+		t.Errorf("Build() without Dockerfile error = %v, want %v", err, sdk.ErrBaseImageCheckDockerfileRequired)
+	}
+
+	path := filepath.Join(t.TempDir(), "Dockerfile")
+	if err := os.WriteFile(path, []byte("FROM scratch\nFROM debian\n"), filesystem.FilePermissionsDefault); err != nil {
+		t.Fatalf("Failed to write Dockerfile: %v", err)
+	}
+
+	plan := sdk.NewPlan(testPlanName)
+
+	if _, err := plan.BaseImageCheck("bases").Dockerfile(path).FailOnUnpinned(true).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if err := plan.Execute(context.Background()); !errors.Is(err, sdk.ErrUnpinnedBaseImage) {
+		t.Errorf("Execute() error = %v, want %v", err, sdk.ErrUnpinnedBaseImage)
+	}
+}
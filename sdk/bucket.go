@@ -0,0 +1,118 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/farcloser/quark/internal/relay"
+)
+
+// Bucket is an object storage bucket (AWS S3, Google Cloud Storage, or any S3-compatible store)
+// usable as a Transport, e.g., as a Bundle destination or an Export/Import relay.
+type Bucket struct {
+	location string
+	bucket   *relay.Bucket
+}
+
+// BucketBuilder builds a Bucket.
+type BucketBuilder struct {
+	location     string
+	endpoint     string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+// NewBucket creates a new Bucket builder for a bucket URL: "s3://bucket/prefix" or "gs://bucket/prefix".
+// Google Cloud Storage buckets are accessed through their S3-compatible API with HMAC keys.
+func NewBucket(location string) *BucketBuilder {
+	return &BucketBuilder{location: location}
+}
+
+// Endpoint sets a custom S3-compatible endpoint (e.g., "https://minio.internal:9000").
+func (builder *BucketBuilder) Endpoint(endpoint string) *BucketBuilder {
+	builder.endpoint = endpoint
+
+	return builder
+}
+
+// Region sets the bucket region (defaults to "us-east-1" for S3).
+func (builder *BucketBuilder) Region(region string) *BucketBuilder {
+	builder.region = region
+
+	return builder
+}
+
+// Credentials sets the access key and secret key (HMAC keys for GCS).
+// Without credentials, requests are unauthenticated (public buckets only).
+func (builder *BucketBuilder) Credentials(accessKey, secretKey string) *BucketBuilder {
+	builder.accessKey = accessKey
+	builder.secretKey = secretKey
+	secretValues.Add(secretKey)
+
+	return builder
+}
+
+// SessionToken sets a session token for temporary credentials.
+func (builder *BucketBuilder) SessionToken(token string) *BucketBuilder {
+	builder.sessionToken = token
+	secretValues.Add(token)
+
+	return builder
+}
+
+// Build validates and creates the Bucket.
+func (builder *BucketBuilder) Build() (*Bucket, error) {
+	parsed, err := url.Parse(builder.location)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "s3" && parsed.Scheme != "gs") {
+		return nil, fmt.Errorf("%w: %q (expected s3://bucket/prefix or gs://bucket/prefix)",
+			ErrInvalidBucketURL, builder.location)
+	}
+
+	endpoint := builder.endpoint
+	if endpoint == "" && parsed.Scheme == "gs" {
+		endpoint = relay.GCSEndpoint
+	}
+
+	bucket, err := relay.NewBucket(relay.BucketConfig{
+		Endpoint:     endpoint,
+		Region:       builder.region,
+		Bucket:       parsed.Host,
+		Prefix:       strings.Trim(parsed.Path, "/"),
+		AccessKey:    builder.accessKey,
+		SecretKey:    builder.secretKey,
+		SessionToken: builder.sessionToken,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBucketURL, err)
+	}
+
+	return &Bucket{location: strings.TrimSuffix(builder.location, "/"), bucket: bucket}, nil
+}
+
+// Put implements Transport.
+func (bucket *Bucket) Put(ctx context.Context, key string, reader io.Reader) error {
+	//nolint:wrapcheck // Errors are already descriptive
+	return bucket.bucket.Put(ctx, key, reader)
+}
+
+// Get implements Transport.
+func (bucket *Bucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	//nolint:wrapcheck // Errors are already descriptive
+	return bucket.bucket.Get(ctx, key)
+}
+
+// Exists implements Transport.
+func (bucket *Bucket) Exists(ctx context.Context, key string) (bool, error) {
+	//nolint:wrapcheck // Errors are already descriptive
+	return bucket.bucket.Exists(ctx, key)
+}
+
+// Location returns the bucket URL of an object key (e.g., "s3://bucket/prefix/key").
+func (bucket *Bucket) Location(key string) string {
+	return bucket.location + "/" + key
+}
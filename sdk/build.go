@@ -2,34 +2,69 @@ package sdk
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
-	"github.com/rs/zerolog"
+	"github.com/opencontainers/go-digest"
 
 	"github.com/farcloser/quark/internal/buildkit"
+	"github.com/farcloser/quark/internal/registry"
 	"github.com/farcloser/quark/ssh"
 )
 
+// defaultDiskSpaceFactor is the default multiplier of the build context size used to estimate build storage.
+const defaultDiskSpaceFactor = 3
+
+// ErrBuildNodeDiskSpace is returned when a build node does not have enough free disk space for a build.
+var ErrBuildNodeDiskSpace = buildkit.ErrInsufficientDiskSpace
+
 // Build represents a container image build operation.
 type Build struct {
-	opName     string
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+	retryPolicy
+
 	context    string
 	dockerfile string
 	nodes      []*BuildNode
+	selector   map[string]string
 	tag        string
 	timeout    time.Duration
-	log        zerolog.Logger
 
-	// sshPool is set by executor before execution
-	sshPool *ssh.Pool
+	// Reproducible build timestamp (SOURCE_DATE_EPOCH), nil to keep build times
+	timestamp *time.Time
+
+	// Disk space preflight estimate
+	diskFactor float64
+	imageSize  int64
+
+	// sizeErr records an invalid ExpectedImageSize value, reported at Build() time
+	sizeErr error
+
+	// Image pushed under the tag; its digest is resolved after the build once requested with OutputImage,
+	// or for provenance
+	output *Image
+	digest string
+
+	// sshPool, outputRegistry and resolveDigest are set by executor before execution
+	sshPool        *ssh.Pool
+	outputRegistry *Registry
+	resolveDigest  bool
 }
 
 // BuildBuilder builds a Build.
 type BuildBuilder struct {
+	builderState
+
 	plan  *Plan
 	build *Build
-	built bool
 }
 
 // Context sets the build context directory.
@@ -53,6 +88,19 @@ func (builder *BuildBuilder) Node(node *BuildNode) *BuildBuilder {
 	return builder
 }
 
+// NodeSelector adds every build node carrying all the given labels (see BuildNodeBuilder.Label).
+// Nodes are matched when Build() is called, so they must be declared before the build.
+// Selected nodes are added after nodes set with Node(), in declaration order.
+func (builder *BuildBuilder) NodeSelector(selector map[string]string) *BuildBuilder {
+	if builder.build.selector == nil {
+		builder.build.selector = map[string]string{}
+	}
+
+	maps.Copy(builder.build.selector, selector)
+
+	return builder
+}
+
 // Tag sets the image tag.
 func (builder *BuildBuilder) Tag(tag string) *BuildBuilder {
 	builder.build.tag = tag
@@ -60,6 +108,36 @@ func (builder *BuildBuilder) Tag(tag string) *BuildBuilder {
 	return builder
 }
 
+// Timestamp makes the build reproducible (e.g., with the time of the commit built): SOURCE_DATE_EPOCH is set to
+// timestamp for the Dockerfile, and the creation time, history and file timestamps of the image pushed are set to
+// it, so building the same sources twice pushes the same digest. Requires a BuildKit version supporting
+// rewrite-timestamp (v0.13 or later).
+func (builder *BuildBuilder) Timestamp(timestamp time.Time) *BuildBuilder {
+	normalized := timestamp.UTC().Round(0)
+	builder.build.timestamp = &normalized
+
+	return builder
+}
+
+// DiskSpaceFactor sets the multiplier applied to the build context size to estimate the build storage
+// a node needs (default 3). Before uploading the context, the build fails early if the node does not have
+// the context size, plus context size × factor + expected image size, free.
+func (builder *BuildBuilder) DiskSpaceFactor(factor float64) *BuildBuilder {
+	builder.build.diskFactor = factor
+
+	return builder
+}
+
+// ExpectedImageSize sets the expected size of the built image (e.g., "2GiB") for the disk space preflight.
+// Defaults to zero, which is fine for images dominated by their build context.
+func (builder *BuildBuilder) ExpectedImageSize(size string) *BuildBuilder {
+	parsed, err := parseSize(size)
+	builder.build.imageSize = parsed
+	builder.build.sizeErr = err
+
+	return builder
+}
+
 // Timeout sets the operation timeout.
 // If not set, the operation will use the context timeout from Plan.Execute().
 func (builder *BuildBuilder) Timeout(duration time.Duration) *BuildBuilder {
@@ -68,15 +146,94 @@ func (builder *BuildBuilder) Timeout(duration time.Duration) *BuildBuilder {
 	return builder
 }
 
+// RunOnlyOn restricts the build to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *BuildBuilder) RunOnlyOn(envs ...Environment) *BuildBuilder {
+	builder.build.runOnlyOn = append(builder.build.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the build mostly uses (default: sdk.ResourceRemote),
+// for executors bounding how many operations of each class run at once.
+func (builder *BuildBuilder) Resource(resource Resource) *BuildBuilder {
+	builder.build.resource = resource
+
+	return builder
+}
+
+// Retry attempts the build up to attempts times (including the first) when it fails with a transient error
+// (e.g., a registry or SSH connection failure), waiting backoff before the second attempt and twice as long
+// before each next one. Failures retrying cannot fix (e.g., authentication, missing images, digest mismatches)
+// are not retried. The timeout, if any, applies to each attempt.
+func (builder *BuildBuilder) Retry(attempts int, backoff time.Duration) *BuildBuilder {
+	builder.build.set(attempts, backoff)
+
+	return builder
+}
+
+// DependsOn makes the build start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *BuildBuilder) DependsOn(ops ...Dependency) *BuildBuilder {
+	builder.build.add(ops)
+
+	return builder
+}
+
+// When makes the build run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the build.
+func (builder *BuildBuilder) When(conditions ...Condition) *BuildBuilder {
+	builder.build.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a build named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *BuildBuilder) Clone(name string) *BuildBuilder {
+	clone := builder.plan.Build(name)
+	clone.build.envGuard = builder.build.envGuard.clone()
+	clone.build.resourceHint = builder.build.resourceHint
+	clone.build.dependencyList = builder.build.dependencyList.clone()
+	clone.build.conditionList = builder.build.conditionList.clone()
+	clone.build.retryPolicy = builder.build.retryPolicy.clone()
+	clone.build.context = builder.build.context
+	clone.build.dockerfile = builder.build.dockerfile
+	clone.build.nodes = slices.Clone(builder.build.nodes)
+	clone.build.selector = maps.Clone(builder.build.selector)
+	clone.build.tag = builder.build.tag
+	clone.build.timeout = builder.build.timeout
+	clone.build.timestamp = builder.build.timestamp
+	clone.build.diskFactor = builder.build.diskFactor
+	clone.build.imageSize = builder.build.imageSize
+	clone.build.sizeErr = builder.build.sizeErr
+
+	return clone
+}
+
+// Reset makes the builder usable again for a build named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *BuildBuilder) Reset(name string) *BuildBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
 // Build validates and adds the build to the plan.
 // The builder becomes unusable after Build() is called.
-// Create a new builder for each operation.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
 func (builder *BuildBuilder) Build() (*Build, error) {
-	if builder.built {
-		return nil, ErrBuilderAlreadyUsed
+	if err := builder.use(); err != nil {
+		return nil, err
 	}
 
-	builder.built = true
+	if err := builder.plan.checkOperationName(builder.build.opName); err != nil {
+		return nil, err
+	}
+
+	if err := builder.build.validate(); err != nil {
+		return nil, err
+	}
 
 	if builder.build.context == "" {
 		return nil, ErrBuildContextRequired
@@ -86,6 +243,12 @@ func (builder *BuildBuilder) Build() (*Build, error) {
 		builder.build.dockerfile = "Dockerfile"
 	}
 
+	if builder.build.selector != nil {
+		if err := builder.selectNodes(); err != nil {
+			return nil, err
+		}
+	}
+
 	if len(builder.build.nodes) == 0 {
 		return nil, ErrBuildNodeRequired
 	}
@@ -97,12 +260,50 @@ func (builder *BuildBuilder) Build() (*Build, error) {
 		return nil, ErrBuildTagRequired
 	}
 
+	output, err := NewImage(builder.build.tag).Build()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %w", ErrInvalidBuildTag, builder.build.tag, err)
+	}
+
+	builder.build.output = output
+
+	if builder.build.sizeErr != nil {
+		return nil, builder.build.sizeErr
+	}
+
+	if builder.build.diskFactor < 0 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDiskSpaceFactor, builder.build.diskFactor)
+	}
+
 	builder.plan.builds = append(builder.plan.builds, builder.build)
-	builder.plan.operations = append(builder.plan.operations, builder.build)
+	builder.plan.addOperation(builder.build)
 
 	return builder.build, nil
 }
 
+// selectNodes adds the plan build nodes matching the selector, skipping nodes already set.
+func (builder *BuildBuilder) selectNodes() error {
+	matched := 0
+
+	for _, node := range builder.plan.buildNodes {
+		if !node.matches(builder.build.selector) {
+			continue
+		}
+
+		matched++
+
+		if !slices.Contains(builder.build.nodes, node) {
+			builder.build.nodes = append(builder.build.nodes, node)
+		}
+	}
+
+	if matched == 0 {
+		return fmt.Errorf("%w %v for build %q", ErrBuildNodeSelectorNoMatch, builder.build.selector, builder.build.opName)
+	}
+
+	return nil
+}
+
 func (build *Build) execute(ctx context.Context) error {
 	// Apply timeout if configured
 	if build.timeout > 0 {
@@ -130,20 +331,55 @@ func (build *Build) execute(ctx context.Context) error {
 
 	firstNode := build.nodes[0]
 
-	sshClient, err := build.sshPool.GetClient(firstNode.endpoint)
+	var (
+		sshClient ssh.Connection
+		err       error
+	)
+
+	if firstNode.forwardAgent {
+		build.log.Warn().
+			Str("node", firstNode.name).
+			Msg("forwarding local SSH agent to build node")
+
+		sshClient, err = build.sshPool.GetClientWithAgentForwarding(firstNode.endpoint)
+	} else {
+		sshClient, err = build.sshPool.GetClient(firstNode.endpoint)
+	}
+
 	if err != nil {
 		return fmt.Errorf("failed to connect to build node: %w", err)
 	}
 
 	// Create buildkit client
 	bkClient := buildkit.NewClient(sshClient, build.log)
+	if firstNode.forwardAgent {
+		bkClient.ExposeSSHAgent()
+	}
+
+	if build.timestamp != nil {
+		bkClient.SourceDateEpoch(*build.timestamp)
+	}
+
+	bkClient.OnOutput(func(stream, line string) {
+		emitEvent(ctx, BuildLog{Operation: build.opName, Stream: stream, Line: line})
+	})
 
-	// Upload build context
 	remotePath := "/tmp/quark-build-" + build.opName
+
+	if err := build.checkDiskSpace(ctx, bkClient, remotePath); err != nil {
+		return err
+	}
+
+	// Upload build context
 	if err := bkClient.UploadContext(ctx, build.context, remotePath); err != nil {
 		return fmt.Errorf("failed to upload build context: %w", err)
 	}
 
+	// BuildKit pushes the output with its own credentials, into an existing repository
+	if err := build.outputRegistry.ensureRepository(ctx, build.output.Path(), build.log); err != nil {
+		return err
+	}
+
 	// Execute multi-platform build
 	remoteDockerfile := fmt.Sprintf("%s/%s", remotePath, build.dockerfile)
 
@@ -158,14 +394,121 @@ func (build *Build) execute(ctx context.Context) error {
 		return fmt.Errorf("failed to build image: %w", err)
 	}
 
+	// The tag was moved by buildx, outside of the registry client
+	registry.InvalidateManifest(ctx, build.tag)
+
 	build.log.Info().
 		Str("tag", builtTag).
 		Msg("build complete")
 
+	if build.resolveDigest {
+		return build.resolveOutput(ctx)
+	}
+
+	return nil
+}
+
+// resolveOutput records the digest the tag points to once pushed, for the operations reading OutputImage.
+func (build *Build) resolveOutput(ctx context.Context) error {
+	tagRef, err := build.output.tagRef()
+	if err != nil {
+		return fmt.Errorf("failed to build output reference: %w", err)
+	}
+
+	pushed, err := newRegistryClient(build.outputRegistry, build.log).HeadDigest(ctx, tagRef)
+	if err != nil {
+		return fmt.Errorf("failed to resolve built image digest: %w", err)
+	}
+
+	parsed, err := digest.Parse(pushed)
+	if err != nil {
+		return fmt.Errorf("failed to parse built image digest: %w", err)
+	}
+
+	build.digest = pushed
+	build.output.ref.Digest = parsed
+
+	build.log.Info().Str("tag", tagRef).Str("digest", pushed).Msg("built image digest resolved")
+
+	return nil
+}
+
+// OutputImage returns the image pushed by the build, for the operations reading it (e.g., ScanBuilder.Source):
+// they depend on the build without DependsOn, and use the digest the tag points to once the build pushed it.
+func (build *Build) OutputImage() *Image {
+	build.output.producer = build
+
+	return build.output
+}
+
+// Digest returns the digest of the pushed image, resolved after the build when OutputImage was requested,
+// or provenance is recorded.
+// Returns empty string otherwise, or if the build has not been executed yet.
+func (build *Build) Digest() string {
+	return build.digest
+}
+
+// checkDiskSpace fails early when the node cannot hold the build, instead of a buildkit
+// "no space left on device" error minutes into the build.
+// The preflight is skipped with a warning if free space cannot be determined.
+func (build *Build) checkDiskSpace(ctx context.Context, bkClient *buildkit.Client, remotePath string) error {
+	contextSize, err := buildkit.ContextSize(build.context)
+	if err != nil {
+		return err
+	}
+
+	factor := build.diskFactor
+	if factor == 0 {
+		factor = defaultDiskSpaceFactor
+	}
+
+	err = bkClient.CheckDiskSpace(ctx, remotePath, buildkit.DiskEstimate{
+		ContextSize: contextSize,
+		Factor:      factor,
+		ImageSize:   build.imageSize,
+	})
+
+	switch {
+	case errors.Is(err, buildkit.ErrInsufficientDiskSpace):
+		return fmt.Errorf("%w (node %q)", err, build.nodes[0].name)
+	case err != nil:
+		build.log.Warn().Err(err).Msg("skipping disk space preflight")
+	}
+
 	return nil
 }
 
-// operationName returns the build operation name (implements operation interface).
-func (build *Build) operationName() string {
-	return build.opName
+// Nodes returns the build nodes, including nodes matched by NodeSelector.
+func (build *Build) Nodes() []*BuildNode {
+	return slices.Clone(build.nodes)
+}
+
+// plannedChanges implements dryRunOperation: builds only run on their nodes, so they are described.
+func (build *Build) plannedChanges(_ context.Context) ([]string, error) {
+	nodes := make([]string, 0, len(build.nodes))
+	for _, node := range build.nodes {
+		nodes = append(nodes, fmt.Sprintf("%s (%s)", node.Name(), node.platform))
+	}
+
+	return []string{fmt.Sprintf("Would build %s on %s and push %s", build.context, strings.Join(nodes, ", "), build.tag)}, nil
+}
+
+// cacheSources implements cacheableOperation: a build reads none, it reads its base images from the Dockerfile.
+func (build *Build) cacheSources() []*Image {
+	return nil
+}
+
+// outputDigest implements outputOperation.
+func (build *Build) outputDigest() (*Image, *string) {
+	return build.output, &build.digest
+}
+
+// producedDigest implements producingOperation.
+func (build *Build) producedDigest() string {
+	return build.Digest()
+}
+
+// destination implements destinationOperation: the output image.
+func (build *Build) destination() *Image {
+	return build.output
 }
@@ -3,6 +3,7 @@ package sdk_test
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"testing"
 
 	"github.com/farcloser/quark/sdk"
@@ -80,6 +81,43 @@ func TestBuildBuilder_Build(t *testing.T) {
 			},
 			wantErr: sdk.ErrBuildNodeRequired,
 		},
+		{
+			name: "valid build with disk space estimate",
+			build: func(plan *sdk.Plan, buildNode *sdk.BuildNode) (*sdk.Build, error) {
+				return plan.Build("test-build-disk").
+					Context("/path/to/context").
+					Node(buildNode).
+					Tag("myapp:latest").
+					DiskSpaceFactor(2).
+					ExpectedImageSize("2GiB").
+					Build()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "invalid expected image size",
+			build: func(plan *sdk.Plan, buildNode *sdk.BuildNode) (*sdk.Build, error) {
+				return plan.Build("test-build-bad-size").
+					Context("/path/to/context").
+					Node(buildNode).
+					Tag("myapp:latest").
+					ExpectedImageSize("lots").
+					Build()
+			},
+			wantErr: sdk.ErrInvalidSize,
+		},
+		{
+			name: "negative disk space factor",
+			build: func(plan *sdk.Plan, buildNode *sdk.BuildNode) (*sdk.Build, error) {
+				return plan.Build("test-build-bad-factor").
+					Context("/path/to/context").
+					Node(buildNode).
+					Tag("myapp:latest").
+					DiskSpaceFactor(-1).
+					Build()
+			},
+			wantErr: sdk.ErrInvalidDiskSpaceFactor,
+		},
 		{
 			name: "missing tag",
 			build: func(plan *sdk.Plan, buildNode *sdk.BuildNode) (*sdk.Build, error) {
@@ -184,6 +222,40 @@ func TestBuildNodeBuilder_Build(t *testing.T) {
 			},
 			wantErr: sdk.ErrBuildNodeEndpointRequired,
 		},
+		{
+			name: "valid build node with labels",
+			build: func(plan *sdk.Plan) (*sdk.BuildNode, error) {
+				return plan.BuildNode("test-node-labels").
+					Endpoint("ssh://builder@192.168.1.100").
+					Platform(sdk.PlatformAMD64).
+					Label("gpu", "true").
+					Label("zone", "eu").
+					Build()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "valid build node with agent forwarding",
+			build: func(plan *sdk.Plan) (*sdk.BuildNode, error) {
+				return plan.BuildNode("test-node-forward-agent").
+					Endpoint("ssh://builder@192.168.1.100").
+					Platform(sdk.PlatformAMD64).
+					ForwardAgent(true).
+					Build()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "empty label key",
+			build: func(plan *sdk.Plan) (*sdk.BuildNode, error) {
+				return plan.BuildNode("test-node-empty-label").
+					Endpoint("ssh://builder@192.168.1.100").
+					Platform(sdk.PlatformAMD64).
+					Label("", "true").
+					Build()
+			},
+			wantErr: sdk.ErrBuildNodeLabelKeyRequired,
+		},
 		{
 			name: "missing platform",
 			build: func(plan *sdk.Plan) (*sdk.BuildNode, error) {
@@ -228,3 +300,97 @@ func TestBuildNodeBuilder_Build(t *testing.T) {
 		})
 	}
 }
+
+// INTENTION: NodeSelector adds every declared node carrying all selector labels, in declaration order,
+// without duplicating nodes also set with Node(); a selector matching nothing is an error.
+func TestBuildBuilder_NodeSelector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		selector  map[string]string
+		explicit  string
+		wantNodes []string
+		wantErr   error
+	}{
+		{
+			name:      "single label",
+			selector:  map[string]string{"zone": "eu"},
+			wantNodes: []string{"eu-amd64", "eu-arm64-gpu"},
+		},
+		{
+			name:      "all labels must match",
+			selector:  map[string]string{"zone": "eu", "gpu": "true"},
+			wantNodes: []string{"eu-arm64-gpu"},
+		},
+		{
+			name:      "explicit node is not duplicated",
+			selector:  map[string]string{"zone": "eu"},
+			explicit:  "eu-arm64-gpu",
+			wantNodes: []string{"eu-arm64-gpu", "eu-amd64"},
+		},
+		{
+			name:     "no match",
+			selector: map[string]string{"zone": "ap"},
+			wantErr:  sdk.ErrBuildNodeSelectorNoMatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			plan := sdk.NewPlan(testPlanName)
+			declared := map[string]*sdk.BuildNode{}
+
+			for _, spec := range []struct {
+				name     string
+				platform sdk.Platform
+				labels   map[string]string
+			}{
+				{name: "eu-amd64", platform: sdk.PlatformAMD64, labels: map[string]string{"zone": "eu"}},
+				{name: "us-amd64", platform: sdk.PlatformAMD64, labels: map[string]string{"zone": "us"}},
+				{
+					name:     "eu-arm64-gpu",
+					platform: sdk.PlatformARM64,
+					labels:   map[string]string{"zone": "eu", "gpu": "true"},
+				},
+			} {
+				builder := plan.BuildNode(spec.name).Endpoint(spec.name + ".example.com").Platform(spec.platform)
+				for key, value := range spec.labels {
+					builder.Label(key, value)
+				}
+
+				node, err := builder.Build()
+				if err != nil {
+					t.Fatalf("Failed to create test build node: %v", err)
+				}
+
+				declared[spec.name] = node
+			}
+
+			builder := plan.Build("test-build").Context("/path/to/context").Tag("myapp:latest")
+			if tt.explicit != "" {
+				builder.Node(declared[tt.explicit])
+			}
+
+			build, err := builder.NodeSelector(tt.selector).Build()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			got := make([]string, 0, len(build.Nodes()))
+			for _, node := range build.Nodes() {
+				got = append(got, node.Name())
+			}
+
+			if !slices.Equal(got, tt.wantNodes) {
+				t.Errorf("Nodes() = %v, want %v", got, tt.wantNodes)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package sdk
+
+// builderState tracks whether a builder was used. Embedded by all builders, so that reuse is rejected
+// uniformly: Build() succeeds once, then returns ErrBuilderAlreadyUsed.
+// To define similar operations from one configuration, use the builder Clone() or Reset() methods.
+type builderState struct {
+	built bool
+}
+
+// use marks the builder as used, failing if Build() was already called.
+func (state *builderState) use() error {
+	if state.built {
+		return ErrBuilderAlreadyUsed
+	}
+
+	state.built = true
+
+	return nil
+}
+
+// Built reports whether Build() was called on the builder.
+func (state *builderState) Built() bool {
+	return state.built
+}
@@ -305,3 +305,57 @@ func TestBuilderReuse_ImmutabilityCheck(t *testing.T) {
 		t.Errorf("image was mutated after Build(): expected %s, got %s", originalVersion, img1.Version())
 	}
 }
+
+// INTENTION: Clone and Reset derive new builders from a configured one (templating similar operations),
+// and changes made to a derived builder never affect the operations already built.
+func TestBuilder_CloneAndReset(t *testing.T) {
+	t.Parallel()
+
+	plan := sdk.NewPlan(testPlanName)
+
+	source, err := sdk.NewImage("test/source").Domain(testDomain).Version(testVersion).Digest(testDigest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create source image: %v", err)
+	}
+
+	destination, err := sdk.NewImage("test/destination").Domain("ghcr.io").Version(testVersion).Build()
+	if err != nil {
+		t.Fatalf("Failed to create destination image: %v", err)
+	}
+
+	builder := plan.Sync("sync-1").Source(source).Destination(destination).RunOnlyOn(sdk.EnvCI)
+
+	if _, err := builder.Build(); err != nil {
+		t.Fatalf(errFirstBuildMsg, err)
+	}
+
+	if !builder.Built() {
+		t.Error("Built() = false after Build()")
+	}
+
+	// Clone keeps the configuration: the clone builds without setting source and destination again
+	clone := builder.Clone("sync-2")
+	if clone.Built() {
+		t.Error("clone Built() = true, want a usable builder")
+	}
+
+	if _, err := clone.Build(); err != nil {
+		t.Errorf("clone Build() error = %v", err)
+	}
+
+	// Reset makes the builder usable again, keeping its configuration
+	if _, err := builder.Reset("sync-3").Build(); err != nil {
+		t.Errorf("Build() after Reset() error = %v", err)
+	}
+
+	// Image clones keep domain, version and digest under a new name
+	image, err := sdk.NewImage("test/image").Domain("ghcr.io").Version(testVersion).Clone("test/other").Build()
+	if err != nil {
+		t.Fatalf("clone Build() error = %v", err)
+	}
+
+	if image.Path() != "test/other" || image.Domain() != "ghcr.io" || image.Version() != testVersion {
+		t.Errorf("cloned image = %s/%s:%s, want ghcr.io/test/other:%s",
+			image.Domain(), image.Path(), image.Version(), testVersion)
+	}
+}
@@ -1,6 +1,8 @@
 package sdk
 
 import (
+	"fmt"
+	"maps"
 	"strings"
 
 	"github.com/rs/zerolog"
@@ -11,14 +13,18 @@ type BuildNode struct {
 	name     string
 	endpoint string
 	platform Platform
-	log      zerolog.Logger
+	labels   map[string]string
+	// forwardAgent forwards the local SSH agent to builds on this node
+	forwardAgent bool
+	log          zerolog.Logger
 }
 
 // BuildNodeBuilder builds a BuildNode.
 type BuildNodeBuilder struct {
-	plan  *Plan
-	node  *BuildNode
-	built bool
+	builderState
+
+	plan *Plan
+	node *BuildNode
 }
 
 // Endpoint sets the SSH endpoint (IP, hostname, or SSH config alias).
@@ -35,16 +41,53 @@ func (builder *BuildNodeBuilder) Platform(platform Platform) *BuildNodeBuilder {
 	return builder
 }
 
+// Label attaches a label to the node (e.g., "gpu"="true", "zone"="eu").
+// Builds select labeled nodes with BuildBuilder.NodeSelector instead of referencing them directly.
+func (builder *BuildNodeBuilder) Label(key, value string) *BuildNodeBuilder {
+	builder.node.labels[key] = value
+
+	return builder
+}
+
+// ForwardAgent forwards the local SSH agent (SSH_AUTH_SOCK) to the node and exposes it to builds
+// (buildx --ssh default), so Dockerfiles can use RUN --mount=type=ssh, e.g., to fetch private git submodules.
+// Disabled by default. SECURITY: anyone with root access on the node can use the forwarded agent to
+// authenticate as you while the build runs. Only enable it for trusted nodes.
+// The node sshd must allow agent forwarding (AllowAgentForwarding yes).
+func (builder *BuildNodeBuilder) ForwardAgent(enabled bool) *BuildNodeBuilder {
+	builder.node.forwardAgent = enabled
+
+	return builder
+}
+
+// Clone returns a new builder for a build node named name, with the same configuration.
+// It can be called before or after Build(), to define similar nodes from one template.
+func (builder *BuildNodeBuilder) Clone(name string) *BuildNodeBuilder {
+	clone := builder.plan.BuildNode(name)
+	clone.node.endpoint = builder.node.endpoint
+	clone.node.platform = builder.node.platform
+	clone.node.labels = maps.Clone(builder.node.labels)
+	clone.node.forwardAgent = builder.node.forwardAgent
+
+	return clone
+}
+
+// Reset makes the builder usable again for a build node named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *BuildNodeBuilder) Reset(name string) *BuildNodeBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
 // Build validates and adds the build node to the plan.
 // The builder becomes unusable after Build() is called.
-// Create a new builder for each operation.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
 func (builder *BuildNodeBuilder) Build() (*BuildNode, error) {
-	if builder.built {
-		return nil, ErrBuilderAlreadyUsed
+	if err := builder.use(); err != nil {
+		return nil, err
 	}
 
-	builder.built = true
-
 	builder.node.endpoint = strings.TrimSpace(builder.node.endpoint)
 	if builder.node.endpoint == "" {
 		return nil, ErrBuildNodeEndpointRequired
@@ -54,6 +97,12 @@ func (builder *BuildNodeBuilder) Build() (*BuildNode, error) {
 		return nil, ErrBuildNodePlatformRequired
 	}
 
+	for key := range builder.node.labels {
+		if strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("%w for buildnode %q", ErrBuildNodeLabelKeyRequired, builder.node.name)
+		}
+	}
+
 	builder.plan.buildNodes = append(builder.plan.buildNodes, builder.node)
 
 	return builder.node, nil
@@ -73,3 +122,24 @@ func (node *BuildNode) Endpoint() string {
 func (node *BuildNode) Platform() Platform {
 	return node.platform
 }
+
+// ForwardsAgent reports whether the local SSH agent is forwarded to the node.
+func (node *BuildNode) ForwardsAgent() bool {
+	return node.forwardAgent
+}
+
+// Labels returns a copy of the node labels.
+func (node *BuildNode) Labels() map[string]string {
+	return maps.Clone(node.labels)
+}
+
+// matches reports whether the node carries every label of selector.
+func (node *BuildNode) matches(selector map[string]string) bool {
+	for key, value := range selector {
+		if label, ok := node.labels[key]; !ok || label != value {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,326 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/internal/relay"
+)
+
+// bundleImage is an image delivered in a bundle.
+type bundleImage struct {
+	image    *Image
+	registry *Registry
+}
+
+// bundleManifest is the index manifest published next to a bundle archive.
+type bundleManifest struct {
+	Archive string               `json:"archive"`
+	Size    int64                `json:"size"`
+	SHA256  string               `json:"sha256"`
+	Images  []bundleManifestItem `json:"images"`
+}
+
+// bundleManifestItem describes one image of a bundle archive.
+type bundleManifestItem struct {
+	Reference string `json:"reference"`
+	Digest    string `json:"digest"`
+}
+
+// Bundle represents delivering a set of images as an OCI layout tarball to a destination store
+// (typically an object storage bucket), for customer-managed or disconnected environments.
+type Bundle struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	archiveName string
+	images      []bundleImage
+	destination Transport
+
+	// Results populated after execution
+	archiveDigest string
+	archiveSize   int64
+}
+
+// BundleBuilder builds a Bundle.
+type BundleBuilder struct {
+	builderState
+
+	plan   *Plan
+	bundle *Bundle
+}
+
+// Image adds an image to the bundle.
+// The image MUST have a digest specified - bundling by tag alone is not allowed for security - unless it is the
+// output of another operation (see Sync.OutputImage): the bundle then depends on it, and bundles the produced digest.
+// Multi-platform indexes are bundled with all their platforms.
+// Registry credentials are looked up from the plan's registry collection using the image domain.
+func (builder *BundleBuilder) Image(image *Image) *BundleBuilder {
+	builder.bundle.consume(image)
+	builder.bundle.images = append(builder.bundle.images, bundleImage{
+		image:    image,
+		registry: builder.plan.getRegistry(image.Domain()),
+	})
+
+	return builder
+}
+
+// ArchiveName sets the base name of the published objects (defaults to the operation name):
+// "<name>.tar" (OCI layout tarball), "<name>.tar.sha256" (checksum) and "<name>.json" (index manifest).
+func (builder *BundleBuilder) ArchiveName(name string) *BundleBuilder {
+	builder.bundle.archiveName = name
+
+	return builder
+}
+
+// Destination sets the store the bundle is published to (e.g., a Bucket).
+func (builder *BundleBuilder) Destination(destination Transport) *BundleBuilder {
+	builder.bundle.destination = destination
+
+	return builder
+}
+
+// RunOnlyOn restricts the bundle to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *BundleBuilder) RunOnlyOn(envs ...Environment) *BundleBuilder {
+	builder.bundle.runOnlyOn = append(builder.bundle.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the bundle mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *BundleBuilder) Resource(resource Resource) *BundleBuilder {
+	builder.bundle.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the bundle start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *BundleBuilder) DependsOn(ops ...Dependency) *BundleBuilder {
+	builder.bundle.add(ops)
+
+	return builder
+}
+
+// When makes the bundle run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the bundle.
+func (builder *BundleBuilder) When(conditions ...Condition) *BundleBuilder {
+	builder.bundle.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a bundle named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *BundleBuilder) Clone(name string) *BundleBuilder {
+	clone := builder.plan.Bundle(name)
+	clone.bundle.envGuard = builder.bundle.envGuard.clone()
+	clone.bundle.resourceHint = builder.bundle.resourceHint
+	clone.bundle.dependencyList = builder.bundle.dependencyList.clone()
+	clone.bundle.conditionList = builder.bundle.conditionList.clone()
+	clone.bundle.archiveName = builder.bundle.archiveName
+	clone.bundle.images = slices.Clone(builder.bundle.images)
+	clone.bundle.destination = builder.bundle.destination
+
+	return clone
+}
+
+// Reset makes the builder usable again for a bundle named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *BundleBuilder) Reset(name string) *BundleBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build validates and adds the bundle to the plan.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *BundleBuilder) Build() (*Bundle, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.bundle.opName); err != nil {
+		return nil, err
+	}
+
+	if len(builder.bundle.images) == 0 {
+		return nil, ErrBundleImageRequired
+	}
+
+	// Images produced by another operation get their digest when it executes
+	for _, entry := range builder.bundle.images {
+		if entry.image.Digest() == "" && entry.image.producer == nil {
+			return nil, fmt.Errorf("%w for image %q", ErrBundleImageDigestRequired, entry.image.Name())
+		}
+	}
+
+	if builder.bundle.destination == nil {
+		return nil, ErrBundleDestinationRequired
+	}
+
+	if builder.bundle.archiveName == "" {
+		builder.bundle.archiveName = builder.bundle.opName
+	}
+
+	if builder.bundle.archiveName == "" || strings.Contains(builder.bundle.archiveName, "/") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidBundleName, builder.bundle.archiveName)
+	}
+
+	builder.plan.bundles = append(builder.plan.bundles, builder.bundle)
+	builder.plan.addOperation(builder.bundle)
+
+	return builder.bundle, nil
+}
+
+func (bundle *Bundle) execute(ctx context.Context) error {
+	archive := bundle.archiveName + ".tar"
+
+	bundle.log.Info().
+		Int("images", len(bundle.images)).
+		Str("archive", archive).
+		Msg("creating image bundle")
+
+	// Stage the OCI layout locally, then publish it as a single archive
+	staging, err := os.MkdirTemp("", "quark-bundle-*")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	defer func() {
+		_ = os.RemoveAll(staging)
+	}()
+
+	layout := relay.NewDirectory(staging)
+	ociLayout := relay.NewBundle(layout, bundle.log)
+	manifest := bundleManifest{Archive: archive}
+
+	for _, entry := range bundle.images {
+		sourceRef, refName, err := bundleRefs(entry.image)
+		if err != nil {
+			return err
+		}
+
+		client := newRegistryClient(entry.registry, bundle.log)
+
+		written, err := writeToBundle(ctx, client, ociLayout, sourceRef, refName)
+		if err != nil {
+			return err
+		}
+
+		manifest.Images = append(manifest.Images, bundleManifestItem{Reference: refName, Digest: written})
+
+		bundle.log.Debug().
+			Str("image", refName).
+			Str("digest", written).
+			Msg("image added to bundle")
+	}
+
+	tarball, err := os.CreateTemp("", "quark-bundle-*.tar")
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+
+	defer func() {
+		_ = tarball.Close()
+		_ = os.Remove(tarball.Name())
+	}()
+
+	if err := tarball.Chmod(filesystem.FilePermissionsDefault); err != nil {
+		return fmt.Errorf("failed to set archive permissions: %w", err)
+	}
+
+	hasher := sha256.New()
+	counter := &countingWriter{}
+
+	if err := layout.WriteTar(ctx, io.MultiWriter(tarball, hasher, counter)); err != nil {
+		return fmt.Errorf("failed to archive bundle: %w", err)
+	}
+
+	if _, err := tarball.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind archive: %w", err)
+	}
+
+	manifest.SHA256 = hex.EncodeToString(hasher.Sum(nil))
+	manifest.Size = counter.written
+
+	// Archive first, checksum next, index manifest last: consumers waiting for the manifest
+	// always find a complete archive
+	if err := bundle.destination.Put(ctx, archive, tarball); err != nil {
+		return fmt.Errorf("failed to publish archive: %w", err)
+	}
+
+	checksum := fmt.Sprintf("%s  %s\n", manifest.SHA256, archive)
+	if err := bundle.destination.Put(ctx, archive+".sha256", strings.NewReader(checksum)); err != nil {
+		return fmt.Errorf("failed to publish checksum: %w", err)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode bundle manifest: %w", err)
+	}
+
+	if err := bundle.destination.Put(ctx, bundle.archiveName+".json", bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to publish bundle manifest: %w", err)
+	}
+
+	bundle.archiveDigest = "sha256:" + manifest.SHA256
+	bundle.archiveSize = manifest.Size
+
+	bundle.log.Info().
+		Str("archive", archive).
+		Str("sha256", manifest.SHA256).
+		Int64("size", manifest.Size).
+		Msg("image bundle published")
+
+	return nil
+}
+
+// ArchiveDigest returns the sha256 digest of the published archive (empty before execution).
+func (bundle *Bundle) ArchiveDigest() string {
+	return bundle.archiveDigest
+}
+
+// ArchiveSize returns the size in bytes of the published archive (zero before execution).
+func (bundle *Bundle) ArchiveSize() int64 {
+	return bundle.archiveSize
+}
+
+// countingWriter counts bytes written.
+type countingWriter struct {
+	written int64
+}
+
+// Write implements io.Writer.
+func (writer *countingWriter) Write(buf []byte) (int, error) {
+	writer.written += int64(len(buf))
+
+	return len(buf), nil
+}
+
+// producedDigest implements producingOperation.
+func (bundle *Bundle) producedDigest() string {
+	return bundle.ArchiveDigest()
+}
+
+// resolveRegistries implements registryOperation.
+func (bundle *Bundle) resolveRegistries(lookup func(image *Image) *Registry) {
+	for idx := range bundle.images {
+		bundle.images[idx].registry = lookup(bundle.images[idx].image)
+	}
+}
@@ -0,0 +1,127 @@
+package sdk_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: Bundle requires digest-pinned images, a destination and a flat archive name.
+func TestBundleBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	pinned, err := sdk.NewImage("library/alpine").Version("3.20").Digest(testDigest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	unpinned, err := sdk.NewImage("library/alpine").Version("3.20").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	bucket, err := sdk.NewBucket("s3://deliveries/acme").Credentials("key", "secret").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test bucket: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		build   func(*sdk.Plan) (*sdk.Bundle, error)
+		wantErr error
+	}{
+		{
+			name: "valid bundle",
+			build: func(plan *sdk.Plan) (*sdk.Bundle, error) {
+				return plan.Bundle("acme-2024.10").Image(pinned).Destination(bucket).Build()
+			},
+		},
+		{
+			name: "missing images",
+			build: func(plan *sdk.Plan) (*sdk.Bundle, error) {
+				return plan.Bundle("acme").Destination(bucket).Build()
+			},
+			wantErr: sdk.ErrBundleImageRequired,
+		},
+		{
+			name: "image without digest",
+			build: func(plan *sdk.Plan) (*sdk.Bundle, error) {
+				return plan.Bundle("acme").Image(pinned).Image(unpinned).Destination(bucket).Build()
+			},
+			wantErr: sdk.ErrBundleImageDigestRequired,
+		},
+		{
+			name: "missing destination",
+			build: func(plan *sdk.Plan) (*sdk.Bundle, error) {
+				return plan.Bundle("acme").Image(pinned).Build()
+			},
+			wantErr: sdk.ErrBundleDestinationRequired,
+		},
+		{
+			name: "archive name with path",
+			build: func(plan *sdk.Plan) (*sdk.Bundle, error) {
+				return plan.Bundle("acme").Image(pinned).Destination(bucket).ArchiveName("a/b").Build()
+			},
+			wantErr: sdk.ErrInvalidBundleName,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			bundle, err := tt.build(sdk.NewPlan(testPlanName))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil || bundle == nil {
+				t.Fatalf("Build() = %v, %v, want bundle", bundle, err)
+			}
+		})
+	}
+}
+
+// INTENTION: Buckets are addressed by s3:// or gs:// URLs.
+func TestNewBucket(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		location string
+		wantErr  bool
+	}{
+		{location: "s3://deliveries/acme"},
+		{location: "gs://deliveries"},
+		{location: "https://deliveries/acme", wantErr: true},
+		{location: "s3:///acme", wantErr: true},
+		{location: "deliveries", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			t.Parallel()
+
+			bucket, err := sdk.NewBucket(tt.location).Build()
+			if tt.wantErr {
+				if !errors.Is(err, sdk.ErrInvalidBucketURL) {
+					t.Errorf("Build() error = %v, want ErrInvalidBucketURL", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Build() unexpected error = %v", err)
+			}
+
+			if got := bucket.Location("bundle.tar"); got != tt.location+"/bundle.tar" {
+				t.Errorf("Location() = %q, want %q", got, tt.location+"/bundle.tar")
+			}
+		})
+	}
+}
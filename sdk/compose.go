@@ -0,0 +1,456 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/internal/compose"
+	"github.com/farcloser/quark/internal/dockerfile"
+	"github.com/farcloser/quark/internal/reference"
+	"github.com/farcloser/quark/internal/registry"
+	syncsvc "github.com/farcloser/quark/internal/sync"
+	"github.com/farcloser/quark/internal/version"
+)
+
+// ComposeImage is the outcome of a ComposeImages operation for one service image.
+type ComposeImage struct {
+	// Service is the Compose service name.
+	Service string
+	// Reference is the image reference as written in the Compose file.
+	Reference string
+	// LatestVersion is the latest version found when checking for updates (empty if not checked).
+	LatestVersion string
+	// UpdateAvailable reports whether a newer version than the referenced tag is available.
+	UpdateAvailable bool
+	// Digest is the digest of the image the service now references (empty if not resolved).
+	Digest string
+	// Mirror is the reference of the image synced into the mirror repository (empty without MirrorTo).
+	Mirror string
+	// Rewritten is the reference the service is rewritten to (equal to Reference if unchanged).
+	Rewritten string
+}
+
+// ComposeImages represents maintaining the service images of a Compose file (docker-compose.yml):
+// checking them for updates, pinning or updating their references, and syncing them into a private registry.
+type ComposeImages struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	file         string
+	checkUpdates bool
+	update       bool
+	pin          bool
+	mirror       string
+	write        bool
+	patch        string
+
+	// Populated by Build()
+	services       []compose.ServiceImage
+	registries     map[string]*Registry
+	mirrorRef      *reference.ImageReference
+	mirrorRegistry *Registry
+
+	// Results populated after execution
+	images []ComposeImage
+	diff   string
+}
+
+// ComposeImagesBuilder builds a ComposeImages operation.
+type ComposeImagesBuilder struct {
+	builderState
+
+	plan    *Plan
+	compose *ComposeImages
+}
+
+// File sets the Compose file whose service images are maintained.
+func (builder *ComposeImagesBuilder) File(path string) *ComposeImagesBuilder {
+	builder.compose.file = path
+
+	return builder
+}
+
+// CheckUpdates checks each service image for a newer version of its tag, like VersionCheck.
+// Updates are reported without changing the file.
+func (builder *ComposeImagesBuilder) CheckUpdates(enabled bool) *ComposeImagesBuilder {
+	builder.compose.checkUpdates = enabled
+
+	return builder
+}
+
+// Update moves each service image to the latest version of its tag (implies CheckUpdates).
+func (builder *ComposeImagesBuilder) Update(enabled bool) *ComposeImagesBuilder {
+	builder.compose.update = enabled
+
+	return builder
+}
+
+// Pin pins each service image to the current digest of its tag, as name:tag@sha256:...
+func (builder *ComposeImagesBuilder) Pin(enabled bool) *ComposeImagesBuilder {
+	builder.compose.pin = enabled
+
+	return builder
+}
+
+// MirrorTo syncs each service image into repository (e.g., "registry.example.com/mirror"), keeping its path
+// and tag (nginx:1.27 is synced as registry.example.com/mirror/library/nginx:1.27),
+// and points the services at the mirrored images.
+func (builder *ComposeImagesBuilder) MirrorTo(repository string) *ComposeImagesBuilder {
+	builder.compose.mirror = repository
+
+	return builder
+}
+
+// Write rewrites the Compose file in place (default: the changes are only reported).
+func (builder *ComposeImagesBuilder) Write(enabled bool) *ComposeImagesBuilder {
+	builder.compose.write = enabled
+
+	return builder
+}
+
+// Patch writes the changes as a unified diff to path, to be reviewed or applied with git apply.
+func (builder *ComposeImagesBuilder) Patch(path string) *ComposeImagesBuilder {
+	builder.compose.patch = path
+
+	return builder
+}
+
+// RunOnlyOn restricts the operation to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *ComposeImagesBuilder) RunOnlyOn(envs ...Environment) *ComposeImagesBuilder {
+	builder.compose.runOnlyOn = append(builder.compose.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the operation mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *ComposeImagesBuilder) Resource(resource Resource) *ComposeImagesBuilder {
+	builder.compose.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the operation start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *ComposeImagesBuilder) DependsOn(ops ...Dependency) *ComposeImagesBuilder {
+	builder.compose.add(ops)
+
+	return builder
+}
+
+// When makes the operation run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the operation.
+func (builder *ComposeImagesBuilder) When(conditions ...Condition) *ComposeImagesBuilder {
+	builder.compose.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a Compose images operation named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *ComposeImagesBuilder) Clone(name string) *ComposeImagesBuilder {
+	clone := builder.plan.ComposeImages(name)
+	clone.compose.envGuard = builder.compose.envGuard.clone()
+	clone.compose.resourceHint = builder.compose.resourceHint
+	clone.compose.dependencyList = builder.compose.dependencyList.clone()
+	clone.compose.conditionList = builder.compose.conditionList.clone()
+	clone.compose.file = builder.compose.file
+	clone.compose.checkUpdates = builder.compose.checkUpdates
+	clone.compose.update = builder.compose.update
+	clone.compose.pin = builder.compose.pin
+	clone.compose.mirror = builder.compose.mirror
+	clone.compose.write = builder.compose.write
+	clone.compose.patch = builder.compose.patch
+
+	return clone
+}
+
+// Reset makes the builder usable again for a Compose images operation named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *ComposeImagesBuilder) Reset(name string) *ComposeImagesBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build parses the Compose file and adds the operation to the plan.
+// Registry credentials are looked up from the plan's registry collection using each image domain.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *ComposeImagesBuilder) Build() (*ComposeImages, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.compose.opName); err != nil {
+		return nil, err
+	}
+
+	comp := builder.compose
+
+	if comp.file == "" {
+		return nil, ErrComposeFileRequired
+	}
+
+	if !comp.checkUpdates && !comp.update && !comp.pin && comp.mirror == "" {
+		return nil, ErrComposeTaskRequired
+	}
+
+	services, err := compose.ParseFile(comp.file)
+	if err != nil {
+		return nil, err
+	}
+
+	comp.services = services
+	comp.registries = make(map[string]*Registry)
+
+	for _, service := range services {
+		if service.Built || service.Interpolated {
+			continue
+		}
+
+		ref, err := reference.Parse(service.Reference)
+		if err != nil {
+			return nil, fmt.Errorf("invalid image %q for service %q in %s line %d: %w",
+				service.Reference, service.Service, comp.file, service.Line, err)
+		}
+
+		if _, ok := comp.registries[ref.Domain]; !ok {
+			comp.registries[ref.Domain] = builder.plan.getRegistry(ref.Domain)
+		}
+	}
+
+	if comp.mirror != "" {
+		mirrorRef, err := reference.Parse(comp.mirror)
+		if err != nil || mirrorRef.ExplicitTag != "" || mirrorRef.Digest != "" {
+			return nil, fmt.Errorf("%w: %q", ErrComposeInvalidMirror, comp.mirror)
+		}
+
+		comp.mirrorRef = mirrorRef
+		comp.mirrorRegistry = builder.plan.getRegistry(mirrorRef.Domain)
+	}
+
+	builder.plan.addOperation(comp)
+
+	return comp, nil
+}
+
+func (comp *ComposeImages) execute(ctx context.Context) error {
+	comp.images = nil
+
+	var replacements []compose.Replacement
+
+	for _, service := range comp.services {
+		if service.Built || service.Interpolated {
+			comp.log.Warn().
+				Str("service", service.Service).
+				Str("image", service.Reference).
+				Bool("built", service.Built).
+				Bool("interpolated", service.Interpolated).
+				Msg("skipping image built locally or using variables")
+
+			continue
+		}
+
+		image, err := comp.process(ctx, service)
+		if err != nil {
+			return err
+		}
+
+		comp.images = append(comp.images, image)
+
+		if image.Rewritten != service.Reference {
+			replacements = append(replacements, compose.Replacement{
+				Line:   service.Line,
+				Column: service.Column,
+				Old:    service.Reference,
+				New:    image.Rewritten,
+			})
+		}
+	}
+
+	if len(replacements) == 0 {
+		comp.log.Info().Str("file", comp.file).Int("images", len(comp.images)).Msg("compose images up to date")
+
+		return nil
+	}
+
+	return comp.rewrite(replacements)
+}
+
+// process checks, resolves and mirrors a service image, and returns the reference to write instead.
+func (comp *ComposeImages) process(ctx context.Context, service compose.ServiceImage) (ComposeImage, error) {
+	image := ComposeImage{Service: service.Service, Reference: service.Reference, Rewritten: service.Reference}
+
+	ref, err := reference.Parse(service.Reference)
+	if err != nil {
+		return image, fmt.Errorf("invalid image %q for service %q: %w", service.Reference, service.Service, err)
+	}
+
+	reg := comp.registries[ref.Domain]
+	client := newRegistryClient(reg, comp.log)
+
+	tag := ref.ExplicitTag
+	if tag == "" {
+		tag = "latest"
+	}
+
+	targetTag := tag
+
+	if comp.checkUpdates || comp.update {
+		var username, password string
+		if reg != nil {
+			username = reg.username
+			password = reg.password
+		}
+
+		checker := version.NewChecker(username, password, comp.log).WithRemoteOptions(client.TransportOptions(ctx)...)
+
+		info, err := checker.CheckVersion(ref.Name(), tag, "")
+		if err != nil {
+			// Like VersionCheck, tags that are not versions do not fail the plan
+			comp.log.Warn().Err(err).Str("service", service.Service).Str("image", service.Reference).
+				Msg("failed to check version (skipping update check)")
+		} else {
+			image.LatestVersion = info.LatestVersion
+			image.UpdateAvailable = info.UpdateAvailable
+
+			if info.UpdateAvailable {
+				comp.log.Warn().
+					Str("service", service.Service).
+					Str("current", tag).
+					Str("latest", info.LatestVersion).
+					Msg("⚠ UPDATE AVAILABLE")
+
+				if comp.update {
+					targetTag = info.LatestVersion
+				}
+			}
+		}
+	}
+
+	// Untagged references are not changed unless they are pinned, mirrored or updated
+	if targetTag == tag && !comp.pin && comp.mirrorRef == nil {
+		return image, nil
+	}
+
+	digest, err := client.GetDigest(ctx, ref.Name()+":"+targetTag)
+	if err != nil {
+		return image, fmt.Errorf("failed to resolve image %q for service %q: %w",
+			ref.Name()+":"+targetTag, service.Service, err)
+	}
+
+	image.Digest = digest
+
+	// Keep the name as written (e.g., "nginx" rather than "docker.io/library/nginx")
+	name, _, _ := strings.Cut(service.Reference, "@")
+	if ref.ExplicitTag != "" {
+		name = strings.TrimSuffix(name, ":"+ref.ExplicitTag)
+	}
+
+	if comp.mirrorRef != nil {
+		name = comp.mirrorRef.Name() + "/" + ref.Path
+		image.Mirror = name + ":" + targetTag
+
+		mirrored, err := comp.sync(ctx, ref.Name()+"@"+digest, image.Mirror, client)
+		if err != nil {
+			return image, fmt.Errorf("failed to mirror image %q for service %q: %w", service.Reference, service.Service, err)
+		}
+
+		image.Digest = mirrored
+	}
+
+	// Untagged references are tagged explicitly, so the tag the digest was resolved from stays visible
+	image.Rewritten = name + ":" + targetTag
+
+	// References already pinned stay pinned
+	if comp.pin || ref.Digest != "" {
+		image.Rewritten += "@" + image.Digest
+	}
+
+	return image, nil
+}
+
+// sync copies the image at sourceRef (a digest reference) to destRef, and returns the destination digest.
+func (comp *ComposeImages) sync(ctx context.Context, sourceRef, destRef string, srcClient *registry.Client) (string, error) {
+	dstClient := newRegistryClient(comp.mirrorRegistry, comp.log.With().Str("registry", "mirror").Logger())
+
+	result, err := syncsvc.NewSyncer(srcClient, dstClient, comp.log).
+		SyncImageWithOptions(ctx, sourceRef, destRef, syncsvc.Options{})
+	if err != nil {
+		return "", err
+	}
+
+	comp.log.Info().
+		Str("source", sourceRef).
+		Str("destination", destRef).
+		Str("digest", result.Digest).
+		Msg("image mirrored")
+
+	return result.Digest, nil
+}
+
+// rewrite writes the changed references to the Compose file or a patch, or reports them.
+func (comp *ComposeImages) rewrite(replacements []compose.Replacement) error {
+	// #nosec G304 -- Compose file path provided by the plan author
+	content, err := os.ReadFile(comp.file)
+	if err != nil {
+		return fmt.Errorf("failed to read Compose file: %w", err)
+	}
+
+	rewritten, err := compose.Rewrite(string(content), replacements)
+	if err != nil {
+		return fmt.Errorf("failed to rewrite %s: %w", comp.file, err)
+	}
+
+	comp.diff = dockerfile.Diff(comp.file, string(content), rewritten)
+
+	if comp.patch != "" {
+		if err := os.WriteFile(comp.patch, []byte(comp.diff), filesystem.FilePermissionsDefault); err != nil {
+			return fmt.Errorf("failed to write patch: %w", err)
+		}
+
+		comp.log.Info().Str("patch", comp.patch).Msg("compose image changes written as patch")
+	}
+
+	if !comp.write {
+		comp.log.Info().
+			Str("file", comp.file).
+			Int("changes", len(replacements)).
+			Str("diff", comp.diff).
+			Msg("compose images changed (enable Write to rewrite the Compose file)")
+
+		return nil
+	}
+
+	info, err := os.Stat(comp.file)
+	if err != nil {
+		return fmt.Errorf("failed to read Compose file: %w", err)
+	}
+
+	if err := os.WriteFile(comp.file, []byte(rewritten), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write Compose file: %w", err)
+	}
+
+	comp.log.Info().Str("file", comp.file).Int("changes", len(replacements)).Msg("compose images rewritten")
+
+	return nil
+}
+
+// Images returns the outcome for each service image, in file order
+// (images built locally or using variables are skipped). Only valid after plan execution.
+func (comp *ComposeImages) Images() []ComposeImage {
+	return comp.images
+}
+
+// Diff returns the unified diff of the rewritten Compose file (empty if nothing changed).
+func (comp *ComposeImages) Diff() string {
+	return comp.diff
+}
@@ -0,0 +1,197 @@
+package sdk_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: service images are checked for updates, moved to the latest version, pinned and mirrored as
+// configured; images built locally are left alone, and without Write the file is only reported.
+func TestComposeImages(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+	current := pushRandomImage(t, host+"/my-org/app:1.0")
+	latest := pushRandomImage(t, host+"/my-org/app:1.1")
+
+	content := "services:\n  app:\n    image: \"" + host + "/my-org/app:1.0\" # pinned by quark\n" +
+		"  web:\n    build: .\n    image: local/web:dev\n"
+
+	tests := []struct {
+		name      string
+		configure func(*sdk.ComposeImagesBuilder) *sdk.ComposeImagesBuilder
+		want      string
+		wantImage sdk.ComposeImage
+	}{
+		{
+			name: "check updates",
+			configure: func(builder *sdk.ComposeImagesBuilder) *sdk.ComposeImagesBuilder {
+				return builder.CheckUpdates(true).Write(true)
+			},
+			want: content,
+			wantImage: sdk.ComposeImage{
+				LatestVersion:   "1.1",
+				UpdateAvailable: true,
+				Rewritten:       host + "/my-org/app:1.0",
+			},
+		},
+		{
+			name: "pin without write",
+			configure: func(builder *sdk.ComposeImagesBuilder) *sdk.ComposeImagesBuilder {
+				return builder.Pin(true)
+			},
+			want: content,
+			wantImage: sdk.ComposeImage{
+				Digest:    current,
+				Rewritten: host + "/my-org/app:1.0@" + current,
+			},
+		},
+		{
+			name: "update and pin",
+			configure: func(builder *sdk.ComposeImagesBuilder) *sdk.ComposeImagesBuilder {
+				return builder.Update(true).Pin(true).Write(true)
+			},
+			want: strings.Replace(content, "app:1.0", "app:1.1@"+latest, 1),
+			wantImage: sdk.ComposeImage{
+				LatestVersion:   "1.1",
+				UpdateAvailable: true,
+				Digest:          latest,
+				Rewritten:       host + "/my-org/app:1.1@" + latest,
+			},
+		},
+		{
+			name: "mirror",
+			configure: func(builder *sdk.ComposeImagesBuilder) *sdk.ComposeImagesBuilder {
+				return builder.MirrorTo(host + "/mirror").Write(true)
+			},
+			want: strings.Replace(content, "/my-org/app:1.0", "/mirror/my-org/app:1.0", 1),
+			wantImage: sdk.ComposeImage{
+				Digest:    current,
+				Mirror:    host + "/mirror/my-org/app:1.0",
+				Rewritten: host + "/mirror/my-org/app:1.0",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "docker-compose.yml")
+			if err := os.WriteFile(path, []byte(content), filesystem.FilePermissionsDefault); err != nil {
+				t.Fatalf("Failed to write Compose file: %v", err)
+			}
+
+			plan := sdk.NewPlan(testPlanName)
+
+			comp, err := test.configure(plan.ComposeImages("compose").File(path)).Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			if err := plan.Execute(context.Background()); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			written, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read Compose file: %v", err)
+			}
+
+			if string(written) != test.want {
+				t.Errorf("Compose file =\n%s\nwant\n%s", written, test.want)
+			}
+
+			images := comp.Images()
+			if len(images) != 1 {
+				t.Fatalf("Images() = %+v, want the app image only", images)
+			}
+
+			want := test.wantImage
+			want.Service = "app"
+			want.Reference = host + "/my-org/app:1.0"
+
+			if images[0] != want {
+				t.Errorf("Images()[0] = %+v, want %+v", images[0], want)
+			}
+		})
+	}
+}
+
+// INTENTION: ComposeImages requires a Compose file, at least one task and a mirror without tag or digest.
+func TestComposeImagesBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "docker-compose.yml")
+	if err := os.WriteFile(path, []byte("services:\n  app:\n    image: nginx:1.27\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write Compose file: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		configure func(*sdk.ComposeImagesBuilder) *sdk.ComposeImagesBuilder
+		wantErr   error
+	}{
+		{
+			name: "pin",
+			configure: func(builder *sdk.ComposeImagesBuilder) *sdk.ComposeImagesBuilder {
+				return builder.File(path).Pin(true)
+			},
+		},
+		{
+			name: "missing file",
+			configure: func(builder *sdk.ComposeImagesBuilder) *sdk.ComposeImagesBuilder {
+				return builder.Pin(true)
+			},
+			wantErr: sdk.ErrComposeFileRequired,
+		},
+		{
+			name: "no task",
+			configure: func(builder *sdk.ComposeImagesBuilder) *sdk.ComposeImagesBuilder {
+				return builder.File(path)
+			},
+			wantErr: sdk.ErrComposeTaskRequired,
+		},
+		{
+			name: "tagged mirror",
+			configure: func(builder *sdk.ComposeImagesBuilder) *sdk.ComposeImagesBuilder {
+				return builder.File(path).MirrorTo("registry.example.com/mirror:latest")
+			},
+			wantErr: sdk.ErrComposeInvalidMirror,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			plan := sdk.NewPlan(testPlanName)
+
+			_, err := tt.configure(plan.ComposeImages("compose")).Build()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+)
+
+// Condition decides, when an operation is about to run, whether it runs: it is evaluated after the operations
+// it depends on completed, so it can inspect their results (e.g., VersionCheck.UpdateAvailable).
+type Condition func(ctx context.Context) (bool, error)
+
+// conditionList declares the conditions an operation runs under.
+// Operations embed it; When builder methods fill it.
+type conditionList struct {
+	when []Condition
+}
+
+// require appends conditions to the list.
+func (list *conditionList) require(conditions []Condition) {
+	list.when = append(list.when, conditions...)
+}
+
+// conditions returns the conditions the operation runs under.
+func (list *conditionList) conditions() []Condition {
+	return list.when
+}
+
+// clone returns a copy of the list, for builder Clone() methods.
+func (list *conditionList) clone() conditionList {
+	return conditionList{when: append([]Condition(nil), list.when...)}
+}
+
+// conditionsHold evaluates the conditions of op in order, and reports whether they all hold.
+// Evaluation stops at the first condition that does not hold or fails.
+func conditionsHold(ctx context.Context, op operation) (bool, error) {
+	for idx, condition := range op.conditions() {
+		holds, err := condition(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to evaluate condition %d of operation %q: %w", idx+1, op.operationName(), err)
+		}
+
+		if !holds {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,64 @@
+package sdk_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+var errConditionFailed = errors.New("condition failed")
+
+// INTENTION: Operations whose conditions do not hold are skipped without running (later conditions are not
+// evaluated), and a failing condition fails its operation without running it.
+func TestBuilder_When(t *testing.T) {
+	t.Parallel()
+
+	plan := sdk.NewPlan(testPlanName)
+
+	evaluated := 0
+
+	never := func(context.Context) (bool, error) {
+		evaluated++
+
+		return false, nil
+	}
+
+	always := func(context.Context) (bool, error) {
+		evaluated++
+
+		return true, nil
+	}
+
+	failing := func(context.Context) (bool, error) {
+		return false, errConditionFailed
+	}
+
+	// Executing the syncs would fail: the source does not exist
+	skipped, err := newTestSync(t, plan, "skipped").When(always, never, always).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if _, err := newTestSync(t, plan, "failing").DependsOn(skipped).When(failing).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	err = plan.Execute(t.Context())
+	if !errors.Is(err, errConditionFailed) {
+		t.Fatalf("Execute() error = %v, want %v", err, errConditionFailed)
+	}
+
+	if evaluated != 2 {
+		t.Errorf("conditions evaluated %d times, want 2", evaluated)
+	}
+
+	want := []sdk.OperationStatus{sdk.StatusSkipped, sdk.StatusFailed}
+
+	for idx, op := range plan.Report().Operations {
+		if op.Status != want[idx] {
+			t.Errorf("operation %q status = %s, want %s", op.Name, op.Status, want[idx])
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package sdk
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-isatty"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+var (
+	// confirmMutex serializes confirmation prompts: all plans of the process share the terminal.
+	confirmMutex sync.Mutex
+
+	// stdinReader is shared by all prompts, so input buffered beyond one answer is kept for the next prompt.
+	stdinReader = bufio.NewReader(os.Stdin)
+)
+
+// destructiveOperation is implemented by operations that can overwrite existing registry state.
+type destructiveOperation interface {
+	// destructiveChange describes what the operation would destroy (e.g., an existing tag it overwrites),
+	// or returns an empty string if it would not destroy anything.
+	destructiveChange(ctx context.Context) (string, error)
+}
+
+// ConfirmDestructive enables confirmation of destructive operations: before an operation overwrites
+// an existing tag (Sync, Import, Artifact, Rebase, Flatten, Mutate, Index), re-points it (Rollback) or deletes
+// manifests (Prune), the user is prompted on the terminal.
+// When stdin is not a terminal, execution fails instead, unless confirmations are pre-approved with
+// AssumeYes or QUARK_YES=true (set by the CLI --yes flag).
+func (plan *Plan) ConfirmDestructive(enabled bool) {
+	plan.confirmDestructive = enabled
+}
+
+// AssumeYes pre-approves all destructive operation confirmations.
+func (plan *Plan) AssumeYes(enabled bool) {
+	plan.assumeYes = enabled
+}
+
+// confirm asks for confirmation of the destructive change an operation would make, if any.
+func (plan *Plan) confirm(ctx context.Context, op operation) error {
+	destructive, ok := op.(destructiveOperation)
+	if !plan.confirmDestructive || !ok {
+		return nil
+	}
+
+	change, err := destructive.destructiveChange(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check operation %q for destructive changes: %w", op.operationName(), err)
+	}
+
+	if change == "" {
+		return nil
+	}
+
+	if plan.assumeYes || plan.processEnv("QUARK_YES", "") == "true" {
+		plan.log.Warn().
+			Str("operation", op.operationName()).
+			Str("change", change).
+			Msg("destructive operation confirmed by --yes")
+
+		return nil
+	}
+
+	if !isTerminal(os.Stdin) {
+		return fmt.Errorf("%w: operation %q would %s (run with --yes to confirm)",
+			ErrConfirmationRequired, op.operationName(), change)
+	}
+
+	confirmMutex.Lock()
+	defer confirmMutex.Unlock()
+
+	fmt.Fprintf(os.Stderr, "Operation %q will %s. Continue? [y/N] ", op.operationName(), change)
+
+	answer, err := stdinReader.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return fmt.Errorf("%w: operation %q", ErrDestructiveNotConfirmed, op.operationName())
+	}
+}
+
+// isTerminal reports whether file is an interactive terminal.
+func isTerminal(file *os.File) bool {
+	return isatty.IsTerminal(file.Fd()) || isatty.IsCygwinTerminal(file.Fd())
+}
+
+// tagOverwrite describes overwriting tagRef, or returns an empty string if the tag does not exist
+// or already points at newDigest (empty when unknown before execution).
+func tagOverwrite(ctx context.Context, client *registry.Client, tagRef, newDigest string) (string, error) {
+	exists, err := client.CheckExists(ctx, tagRef)
+	if err != nil {
+		return "", fmt.Errorf("failed to check tag: %w", err)
+	}
+
+	if !exists {
+		return "", nil
+	}
+
+	current, err := client.GetDigest(ctx, tagRef)
+	if err != nil {
+		return "", fmt.Errorf("failed to get tag digest: %w", err)
+	}
+
+	if newDigest != "" && current == newDigest {
+		return "", nil
+	}
+
+	return fmt.Sprintf("overwrite existing tag %s (currently %s)", tagRef, current), nil
+}
@@ -0,0 +1,163 @@
+package sdk_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// pushRandomImage pushes a random image to ref and returns its digest.
+func pushRandomImage(t *testing.T, ref string) string {
+	t.Helper()
+
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatalf("Failed to create random image: %v", err)
+	}
+
+	parsed, err := name.ParseReference(ref)
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+
+	if err := remote.Write(parsed, img); err != nil {
+		t.Fatalf("Failed to push image: %v", err)
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatalf("Failed to get image digest: %v", err)
+	}
+
+	return digest.String()
+}
+
+// INTENTION: With ConfirmDestructive, re-pointing an existing tag fails without a terminal
+// unless confirmed with AssumeYes; a rollback to the current digest needs no confirmation.
+func TestPlan_ConfirmDestructive(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		assumeYes bool
+		toCurrent bool
+		wantErr   error
+	}{
+		{name: "tag overwrite without confirmation fails", wantErr: sdk.ErrConfirmationRequired},
+		{name: "tag overwrite confirmed with assume yes", assumeYes: true},
+		{name: "rollback to current digest needs no confirmation", toCurrent: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+			t.Cleanup(server.Close)
+
+			serverURL, err := url.Parse(server.URL)
+			if err != nil {
+				t.Fatalf("Failed to parse server URL: %v", err)
+			}
+
+			host := serverURL.Host
+			current := pushRandomImage(t, host+"/my-org/app:1.0.0")
+			previous := pushRandomImage(t, host+"/my-org/app:0.9.0")
+
+			target := previous
+			if tt.toCurrent {
+				target = current
+			}
+
+			image, err := sdk.NewImage("my-org/app").Domain(host).Version("1.0.0").Build()
+			if err != nil {
+				t.Fatalf("Failed to create test image: %v", err)
+			}
+
+			plan := sdk.NewPlan(testPlanName)
+			plan.ConfirmDestructive(true)
+			plan.AssumeYes(tt.assumeYes)
+
+			if _, err := plan.Rollback("rollback").Image(image).ToDigest(target).Build(); err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			// Test processes never have a terminal on stdin
+			err = plan.Execute(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Execute() error = %v, want %v", err, tt.wantErr)
+			}
+
+			want := target
+			if tt.wantErr != nil {
+				want = current
+			}
+
+			tag, err := name.ParseReference(host + "/my-org/app:1.0.0")
+			if err != nil {
+				t.Fatalf("Failed to parse reference: %v", err)
+			}
+
+			desc, err := remote.Head(tag)
+			if err != nil {
+				t.Fatalf("Failed to resolve tag: %v", err)
+			}
+
+			if desc.Digest.String() != want {
+				t.Errorf("tag digest = %s, want %s", desc.Digest, want)
+			}
+		})
+	}
+}
+
+// INTENTION: An identical re-sync of a multi-platform image overwrites nothing, even though the destination holds
+// the filtered manifest list rather than the source index, so it needs no confirmation.
+func TestPlan_ConfirmDestructive_MultiPlatformResync(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+	digest := pushMultiPlatformIndex(t, host+"/source/app:1.0.0")
+
+	for run := range 2 {
+		source, err := sdk.NewImage("source/app").Domain(host).Version("1.0.0").Digest(digest).Build()
+		if err != nil {
+			t.Fatalf("Failed to create source image: %v", err)
+		}
+
+		destination, err := sdk.NewImage("mirror/app").Domain(host).Version("1.0.0").Build()
+		if err != nil {
+			t.Fatalf("Failed to create destination image: %v", err)
+		}
+
+		plan := sdk.NewPlan(testPlanName)
+		plan.ConfirmDestructive(true)
+
+		if _, err := plan.Sync("mirror").Source(source).Destination(destination).Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		// Test processes never have a terminal on stdin: any confirmation would fail
+		if err := plan.Execute(context.Background()); err != nil {
+			t.Fatalf("run %d: Execute() error = %v", run, err)
+		}
+	}
+}
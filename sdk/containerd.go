@@ -0,0 +1,301 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/internal/containerd"
+	"github.com/farcloser/quark/internal/relay"
+	"github.com/farcloser/quark/ssh"
+)
+
+// ContainerdTool is the command line client importing images on containerd hosts.
+type ContainerdTool struct {
+	value string
+}
+
+//nolint:gochecknoglobals // ContainerdTool enum pattern requires global variables
+var (
+	// ContainerdCtr imports with `ctr images import`, shipped with containerd.
+	ContainerdCtr = ContainerdTool{string(containerd.ToolCtr)}
+	// ContainerdNerdctl imports with `nerdctl load`.
+	ContainerdNerdctl = ContainerdTool{string(containerd.ToolNerdctl)}
+)
+
+// String returns the string representation of the tool.
+func (tool ContainerdTool) String() string {
+	return tool.value
+}
+
+// ContainerdImport represents importing an image from a registry directly into the containerd image store
+// of remote hosts over SSH, for clusters pulling from their local store rather than from a registry.
+type ContainerdImport struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	image     *Image
+	registry  *Registry
+	nodes     []*BuildNode
+	namespace string
+	tool      ContainerdTool
+
+	// sshPool is set by executor before execution
+	sshPool *ssh.Pool
+
+	// Results populated after execution
+	digest   string
+	imported []string
+}
+
+// ContainerdImportBuilder builds a ContainerdImport.
+type ContainerdImportBuilder struct {
+	builderState
+
+	plan *Plan
+	imp  *ContainerdImport
+}
+
+// Source sets the image to import.
+// The image must have a digest when the import executes: set it explicitly, or import
+// an image synced or built earlier in the plan. Multi-platform indexes are imported with all their platforms.
+// Registry credentials are looked up from the plan's registry collection using the image domain.
+func (builder *ContainerdImportBuilder) Source(image *Image) *ContainerdImportBuilder {
+	builder.imp.image = image
+	builder.imp.consume(image)
+	builder.imp.registry = builder.plan.getRegistry(image.Domain())
+
+	return builder
+}
+
+// Nodes sets the hosts the image is imported on (e.g., the nodes of a cluster).
+// Only the SSH endpoint of the nodes is used.
+func (builder *ContainerdImportBuilder) Nodes(nodes ...*BuildNode) *ContainerdImportBuilder {
+	builder.imp.nodes = append(builder.imp.nodes, nodes...)
+
+	return builder
+}
+
+// Namespace sets the containerd namespace the image is imported into (default: "k8s.io", used by Kubernetes).
+func (builder *ContainerdImportBuilder) Namespace(namespace string) *ContainerdImportBuilder {
+	builder.imp.namespace = namespace
+
+	return builder
+}
+
+// Tool sets the command line client importing the image on the hosts (default: ContainerdCtr).
+func (builder *ContainerdImportBuilder) Tool(tool ContainerdTool) *ContainerdImportBuilder {
+	builder.imp.tool = tool
+
+	return builder
+}
+
+// RunOnlyOn restricts the import to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *ContainerdImportBuilder) RunOnlyOn(envs ...Environment) *ContainerdImportBuilder {
+	builder.imp.runOnlyOn = append(builder.imp.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the import mostly uses (default: sdk.ResourceRemote),
+// for executors bounding how many operations of each class run at once.
+func (builder *ContainerdImportBuilder) Resource(resource Resource) *ContainerdImportBuilder {
+	builder.imp.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the import start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *ContainerdImportBuilder) DependsOn(ops ...Dependency) *ContainerdImportBuilder {
+	builder.imp.add(ops)
+
+	return builder
+}
+
+// When makes the import run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the import.
+func (builder *ContainerdImportBuilder) When(conditions ...Condition) *ContainerdImportBuilder {
+	builder.imp.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a containerd import named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *ContainerdImportBuilder) Clone(name string) *ContainerdImportBuilder {
+	clone := builder.plan.ContainerdImport(name)
+	clone.imp.envGuard = builder.imp.envGuard.clone()
+	clone.imp.resourceHint = builder.imp.resourceHint
+	clone.imp.dependencyList = builder.imp.dependencyList.clone()
+	clone.imp.conditionList = builder.imp.conditionList.clone()
+	clone.imp.image = builder.imp.image
+	clone.imp.registry = builder.imp.registry
+	clone.imp.nodes = slices.Clone(builder.imp.nodes)
+	clone.imp.namespace = builder.imp.namespace
+	clone.imp.tool = builder.imp.tool
+
+	return clone
+}
+
+// Reset makes the builder usable again for a containerd import named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *ContainerdImportBuilder) Reset(name string) *ContainerdImportBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build validates and adds the containerd import to the plan.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *ContainerdImportBuilder) Build() (*ContainerdImport, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.imp.opName); err != nil {
+		return nil, err
+	}
+
+	if builder.imp.image == nil {
+		return nil, ErrContainerdImportSourceRequired
+	}
+
+	if _, err := builder.imp.image.pullRef(); err != nil {
+		return nil, err
+	}
+
+	if len(builder.imp.nodes) == 0 {
+		return nil, ErrContainerdImportNodeRequired
+	}
+
+	if slices.Contains(builder.imp.nodes, nil) {
+		return nil, ErrContainerdImportNodeRequired
+	}
+
+	if builder.imp.namespace == "" {
+		builder.imp.namespace = containerd.DefaultNamespace
+	}
+
+	if builder.imp.tool == (ContainerdTool{}) {
+		builder.imp.tool = ContainerdCtr
+	}
+
+	builder.plan.containerdImports = append(builder.plan.containerdImports, builder.imp)
+	builder.plan.addOperation(builder.imp)
+
+	return builder.imp, nil
+}
+
+func (imp *ContainerdImport) execute(ctx context.Context) error {
+	// Validate digest is present (may have been populated during plan execution)
+	if imp.image.Digest() == "" {
+		return fmt.Errorf("%w for image %q", ErrContainerdImportDigestRequired, imp.image.Name())
+	}
+
+	sourceRef, refName, err := bundleRefs(imp.image)
+	if err != nil {
+		return err
+	}
+
+	imp.log.Info().
+		Str("source", sourceRef).
+		Int("nodes", len(imp.nodes)).
+		Str("namespace", imp.namespace).
+		Msg("importing image into containerd")
+
+	// Stage the image once as an OCI layout tarball, then upload it to every node
+	staging, err := os.MkdirTemp("", "quark-containerd-*")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	defer func() {
+		_ = os.RemoveAll(staging)
+	}()
+
+	layout := relay.NewDirectory(staging)
+	client := newRegistryClient(imp.registry, imp.log)
+
+	written, err := writeToBundle(ctx, client, relay.NewBundle(layout, imp.log), sourceRef, refName)
+	if err != nil {
+		return err
+	}
+
+	tarball, err := os.CreateTemp("", "quark-containerd-*.tar")
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+
+	defer func() {
+		_ = tarball.Close()
+		_ = os.Remove(tarball.Name())
+	}()
+
+	if err := tarball.Chmod(filesystem.FilePermissionsDefault); err != nil {
+		return fmt.Errorf("failed to set archive permissions: %w", err)
+	}
+
+	if err := layout.WriteTar(ctx, tarball); err != nil {
+		return fmt.Errorf("failed to archive image: %w", err)
+	}
+
+	if err := tarball.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	imp.digest = written
+
+	for _, node := range imp.nodes {
+		sshClient, err := imp.sshPool.GetClient(node.endpoint)
+		if err != nil {
+			return fmt.Errorf("failed to connect to node %q: %w", node.name, err)
+		}
+
+		importer := containerd.New(sshClient, imp.log.With().Str("node", node.name).Logger()).
+			WithNamespace(imp.namespace).
+			WithTool(containerd.Tool(imp.tool.value))
+
+		if err := importer.Import(ctx, tarball.Name()); err != nil {
+			return fmt.Errorf("failed to import image on node %q: %w", node.name, err)
+		}
+
+		imp.imported = append(imp.imported, node.name)
+
+		imp.log.Info().
+			Str("node", node.name).
+			Str("image", refName).
+			Str("digest", written).
+			Msg("image imported into containerd")
+	}
+
+	return nil
+}
+
+// Digest returns the imported manifest digest (empty before execution).
+func (imp *ContainerdImport) Digest() string {
+	return imp.digest
+}
+
+// Imported returns the names of the nodes the image was imported on, in order
+// (partial if the import failed).
+func (imp *ContainerdImport) Imported() []string {
+	return imp.imported
+}
+
+// producedDigest implements producingOperation.
+func (imp *ContainerdImport) producedDigest() string {
+	return imp.Digest()
+}
+
+// resolveRegistries implements registryOperation.
+func (imp *ContainerdImport) resolveRegistries(lookup func(image *Image) *Registry) {
+	imp.registry = lookup(imp.image)
+}
@@ -0,0 +1,161 @@
+package sdk_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: ContainerdImport requires a registry image and at least one node,
+// and defaults to ctr in the Kubernetes namespace.
+func TestContainerdImportBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		build   func(*sdk.Plan, *sdk.BuildNode) (*sdk.ContainerdImport, error)
+		wantErr error
+	}{
+		{
+			name: "defaults",
+			build: func(plan *sdk.Plan, node *sdk.BuildNode) (*sdk.ContainerdImport, error) {
+				image, err := sdk.NewImage("library/debian").Version("trixie-slim").Digest(testDigest).Build()
+				if err != nil {
+					return nil, err
+				}
+
+				return plan.ContainerdImport("debian").Source(image).Nodes(node).Build()
+			},
+		},
+		{
+			name: "nerdctl in a custom namespace",
+			build: func(plan *sdk.Plan, node *sdk.BuildNode) (*sdk.ContainerdImport, error) {
+				image, err := sdk.NewImage("library/debian").Version("trixie-slim").Build()
+				if err != nil {
+					return nil, err
+				}
+
+				return plan.ContainerdImport("debian").
+					Source(image).
+					Nodes(node).
+					Namespace("default").
+					Tool(sdk.ContainerdNerdctl).
+					Build()
+			},
+		},
+		{
+			name: "missing source",
+			build: func(plan *sdk.Plan, node *sdk.BuildNode) (*sdk.ContainerdImport, error) {
+				return plan.ContainerdImport("debian").Nodes(node).Build()
+			},
+			wantErr: sdk.ErrContainerdImportSourceRequired,
+		},
+		{
+			name: "missing node",
+			build: func(plan *sdk.Plan, _ *sdk.BuildNode) (*sdk.ContainerdImport, error) {
+				image, err := sdk.NewImage("library/debian").Digest(testDigest).Build()
+				if err != nil {
+					return nil, err
+				}
+
+				return plan.ContainerdImport("debian").Source(image).Build()
+			},
+			wantErr: sdk.ErrContainerdImportNodeRequired,
+		},
+		{
+			name: "nil node",
+			build: func(plan *sdk.Plan, node *sdk.BuildNode) (*sdk.ContainerdImport, error) {
+				image, err := sdk.NewImage("library/debian").Digest(testDigest).Build()
+				if err != nil {
+					return nil, err
+				}
+
+				return plan.ContainerdImport("debian").Source(image).Nodes(node, nil).Build()
+			},
+			wantErr: sdk.ErrContainerdImportNodeRequired,
+		},
+		{
+			name: "image without tag or digest",
+			build: func(plan *sdk.Plan, node *sdk.BuildNode) (*sdk.ContainerdImport, error) {
+				image, err := sdk.NewImage("library/debian").Build()
+				if err != nil {
+					return nil, err
+				}
+
+				return plan.ContainerdImport("debian").Source(image).Nodes(node).Build()
+			},
+			wantErr: sdk.ErrImageVersionRequired,
+		},
+		{
+			name: "image outside a registry",
+			build: func(plan *sdk.Plan, node *sdk.BuildNode) (*sdk.ContainerdImport, error) {
+				image, err := sdk.NewImage("docker-archive:/tmp/debian.tar").Build()
+				if err != nil {
+					return nil, err
+				}
+
+				return plan.ContainerdImport("debian").Source(image).Nodes(node).Build()
+			},
+			wantErr: sdk.ErrImageNotInRegistry,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			plan := sdk.NewPlan(testPlanName)
+
+			node, err := plan.BuildNode("test-node").
+				Endpoint("node-1.example.com").
+				Platform(sdk.PlatformAMD64).
+				Build()
+			if err != nil {
+				t.Fatalf("Failed to create test node: %v", err)
+			}
+
+			imp, err := tt.build(plan, node)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && imp == nil {
+				t.Error("Build() returned nil import with nil error")
+			}
+		})
+	}
+}
+
+// INTENTION: images are imported by digest only: an image still without a digest when the import
+// executes fails before anything is fetched or uploaded.
+func TestContainerdImport_DigestRequiredAtExecution(t *testing.T) {
+	t.Parallel()
+
+	plan := sdk.NewPlan(testPlanName)
+
+	node, err := plan.BuildNode("test-node").
+		Endpoint("node-1.example.com").
+		Platform(sdk.PlatformAMD64).
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to create test node: %v", err)
+	}
+
+	image, err := sdk.NewImage("library/debian").Version("trixie-slim").Build()
+	if err != nil {
+		t.Fatalf("Failed to create image: %v", err)
+	}
+
+	if _, err := plan.ContainerdImport("debian").Source(image).Nodes(node).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	// The node is unreachable: validation would fail first
+	plan.SkipValidation(true)
+
+	if err := plan.Execute(context.Background()); !errors.Is(err, sdk.ErrContainerdImportDigestRequired) {
+		t.Fatalf("Execute() error = %v, want %v", err, sdk.ErrContainerdImportDigestRequired)
+	}
+}
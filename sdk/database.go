@@ -0,0 +1,115 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/farcloser/quark/internal/database"
+)
+
+// Database records plan states, scan and version check results (see History) and the execution log of every
+// Execute (every operation with its status, timing, error and produced digest) to the SQLite database at path,
+// created and migrated to the current schema as needed. Plans sharing the database (e.g., those of `quark serve`)
+// can be queried together (see `quark history`). The latest state recorded for the plan is the reference of
+// SkipUnchanged when StateTo is not set. QUARK_DATABASE (set by the CLI --database flag) takes precedence, except
+// for plans run by an Orchestrator.
+func (plan *Plan) Database(path string) {
+	plan.databasePath = path
+}
+
+// openDatabase opens the plan database, if any, for an execution.
+func (plan *Plan) openDatabase(ctx context.Context) error {
+	plan.database = nil
+
+	path := plan.processEnv("QUARK_DATABASE", plan.databasePath)
+	if path == "" {
+		return nil
+	}
+
+	db, err := database.Open(ctx, path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrDatabase, err)
+	}
+
+	plan.database = db
+
+	return nil
+}
+
+// closeDatabase closes the plan database, if any, after an execution.
+func (plan *Plan) closeDatabase() {
+	if plan.database == nil {
+		return
+	}
+
+	if err := plan.database.Close(); err != nil {
+		plan.log.Warn().Err(err).Msg("failed to close database")
+	}
+
+	plan.database = nil
+}
+
+// writeDatabaseState records the plan state to the plan database, if any.
+func (plan *Plan) writeDatabaseState(ctx context.Context, state *PlanState) {
+	if plan.database == nil {
+		return
+	}
+
+	var content strings.Builder
+	if err := state.Write(&content); err != nil {
+		plan.log.Warn().Err(err).Msg("failed to record plan state")
+
+		return
+	}
+
+	if err := plan.database.WriteState(ctx, plan.name, state.Fingerprint, []byte(content.String())); err != nil {
+		plan.log.Warn().Err(err).Msg("failed to record plan state")
+	}
+}
+
+// readDatabaseState returns the latest plan state recorded to the plan database, or nil when none was.
+func (plan *Plan) readDatabaseState(ctx context.Context) (*PlanState, error) {
+	content, err := plan.database.State(ctx, plan.name)
+	if err != nil || content == nil {
+		return nil, err //nolint:wrapcheck // Already describes the failure
+	}
+
+	return parseState("database", content)
+}
+
+// recordRun appends the execution report to the execution log of the plan database, if any.
+// Failures are logged: the execution log never fails the execution it records.
+func (plan *Plan) recordRun(ctx context.Context, execErr error) {
+	if plan.database == nil {
+		return
+	}
+
+	run := database.Run{
+		Plan:       plan.name,
+		Started:    plan.report.Started,
+		Duration:   plan.report.Duration,
+		Operations: make([]database.RunOperation, 0, len(plan.report.Operations)),
+	}
+
+	if execErr != nil {
+		run.Error = secretValues.Error(execErr).Error()
+	}
+
+	for _, op := range plan.report.Operations {
+		run.Operations = append(run.Operations, database.RunOperation{
+			Name:     op.Name,
+			Kind:     op.Kind,
+			Status:   string(op.Status),
+			Started:  op.Started,
+			Duration: op.Duration,
+			Error:    op.Error,
+			Digest:   op.Digest,
+		})
+	}
+
+	// Record runs cancelled by their context too
+	if err := plan.database.AppendRun(context.WithoutCancel(ctx), run); err != nil {
+		plan.log.Warn().Err(err).Msg("failed to record execution")
+	}
+}
@@ -0,0 +1,80 @@
+package sdk_test
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/farcloser/quark/internal/database"
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: A plan database records every execution with the outcome of its operations, and its latest state is
+// the reference of SkipUnchanged without a state file.
+func TestPlan_Database(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	digest := pushRandomImage(t, host+"/my-org/app:1.0.0")
+	path := filepath.Join(t.TempDir(), "quark.db")
+
+	for _, want := range []sdk.OperationStatus{sdk.StatusSucceeded, sdk.StatusUnchanged} {
+		pinned, err := sdk.NewImage("my-org/app").Domain(host).Digest(digest).Build()
+		if err != nil {
+			t.Fatalf("Failed to create test image: %v", err)
+		}
+
+		stamped, err := sdk.NewImage("my-org/app").Domain(host).Version("stamped").Build()
+		if err != nil {
+			t.Fatalf("Failed to create test image: %v", err)
+		}
+
+		plan := sdk.NewPlan(testPlanName)
+		plan.Database(path)
+		plan.SkipUnchanged(true)
+
+		if _, err := plan.Mutate("stamp-app").Image(pinned).Destination(stamped).
+			SetLabel("org.opencontainers.image.revision", "abc123").Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		report, err := plan.ExecuteWithResult(t.Context())
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+
+		if got, _ := report.Operation("stamp-app"); got.Status != want {
+			t.Errorf("stamp-app status = %s, want %s", got.Status, want)
+		}
+	}
+
+	db, err := database.Open(t.Context(), path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	runs, err := db.Runs(t.Context(), testPlanName, 0)
+	if err != nil {
+		t.Fatalf("Runs() error = %v", err)
+	}
+
+	if len(runs) != 2 || runs[0].Error != "" || len(runs[0].Operations) != 1 {
+		t.Fatalf("Runs() = %+v, want both executions", runs)
+	}
+
+	if runs[0].Operations[0].Status != string(sdk.StatusUnchanged) || runs[1].Operations[0].Digest == "" {
+		t.Errorf("Runs() operations = %+v, %+v, want unchanged then mutated", runs[0].Operations, runs[1].Operations)
+	}
+}
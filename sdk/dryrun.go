@@ -0,0 +1,102 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// dryRunOperation is implemented by operations that can describe their changes without making them.
+type dryRunOperation interface {
+	// plannedChanges checks what the operation needs (source images, credentials) without mutating anything,
+	// and describes what it would do.
+	plannedChanges(ctx context.Context) ([]string, error)
+}
+
+// DryRun walks every operation of the plan without making changes: operations resolve their references,
+// check that their source images exist and that the registries accept the credentials, and describe what they
+// would copy, build or scan. The change report is printed (Markdown) and available from Report(), with
+// operations in the StatusPlanned state. Unlike Execute, all operations are checked even after a failure.
+// QUARK_DRY_RUN=true (set by the CLI --dry-run flag) makes Execute dry runs, except for plans run by an
+// Orchestrator.
+func (plan *Plan) DryRun(ctx context.Context) error {
+	plan.dryRun = true
+	defer func() { plan.dryRun = false }()
+
+	return plan.Execute(ctx)
+}
+
+// dryRunOperations checks and describes every operation, then prints the change report.
+func (plan *Plan) dryRunOperations(ctx context.Context, env Environment) error {
+	plan.log.Info().Msg("dry run (no changes will be made)")
+
+	var errs []error
+
+	for _, op := range plan.operations {
+		if !op.runsOn(env) {
+			plan.report.add(op, StatusSkipped, time.Time{}, 0, nil)
+
+			continue
+		}
+
+		started := time.Now().UTC()
+
+		changes, err := operationChanges(ctx, op)
+		if err != nil {
+			plan.report.add(op, StatusFailed, started, time.Since(started), err)
+
+			errs = append(errs, fmt.Errorf("operation %q: %w", op.operationName(), err))
+
+			continue
+		}
+
+		for _, change := range changes {
+			plan.log.Info().Str("operation", op.operationName()).Str("change", change).Msg("planned change")
+		}
+
+		plan.report.addPlanned(op, started, time.Since(started), changes)
+	}
+
+	if err := plan.report.Write(os.Stdout, ReportMarkdown); err != nil {
+		plan.log.Warn().Err(err).Msg("failed to print dry run report")
+	}
+
+	return errors.Join(errs...)
+}
+
+// operationChanges returns the changes op would make.
+func operationChanges(ctx context.Context, op operation) ([]string, error) {
+	if planner, ok := op.(dryRunOperation); ok {
+		return planner.plannedChanges(ctx)
+	}
+
+	return []string{"Would run " + op.kind()}, nil
+}
+
+// checkImage verifies that image exists, when its digest is known before execution, and returns its reference.
+// Images whose digest is produced by an earlier operation (e.g., a sync destination) are not checked.
+func checkImage(ctx context.Context, client *registry.Client, image *Image) (string, error) {
+	if image.Digest() == "" {
+		return image.String(), nil
+	}
+
+	ref, err := image.digestRef()
+	if err != nil {
+		return "", err
+	}
+
+	exists, err := client.CheckExists(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+
+	if !exists {
+		return "", fmt.Errorf("%w: %s", ErrDryRunImageNotFound, ref)
+	}
+
+	return ref, nil
+}
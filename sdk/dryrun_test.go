@@ -0,0 +1,77 @@
+package sdk_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: A dry run checks every operation, even after a failure, describes the changes of the valid ones,
+// and does not change the registry.
+func TestPlan_DryRun(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	digest := pushRandomImage(t, host+"/source/app:1.0.0")
+
+	plan := sdk.NewPlan(testPlanName)
+
+	for _, sync := range []struct{ name, digest string }{
+		{name: "missing", digest: testDigest},
+		{name: "mirror", digest: digest},
+	} {
+		source, err := sdk.NewImage("source/app").Domain(host).Version("1.0.0").Digest(sync.digest).Build()
+		if err != nil {
+			t.Fatalf("Failed to create source image: %v", err)
+		}
+
+		destination, err := sdk.NewImage("mirror/app").Domain(host).Version("1.0.0").Build()
+		if err != nil {
+			t.Fatalf("Failed to create destination image: %v", err)
+		}
+
+		if _, err := plan.Sync(sync.name).Source(source).Destination(destination).Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+	}
+
+	if err := plan.DryRun(t.Context()); !errors.Is(err, sdk.ErrDryRunImageNotFound) {
+		t.Fatalf("DryRun() error = %v, want %v", err, sdk.ErrDryRunImageNotFound)
+	}
+
+	operations := plan.Report().Operations
+	if len(operations) != 2 {
+		t.Fatalf("Report() = %d operations, want 2", len(operations))
+	}
+
+	if operations[0].Status != sdk.StatusFailed {
+		t.Errorf("missing source status = %s, want %s", operations[0].Status, sdk.StatusFailed)
+	}
+
+	want := "Would copy " + host + "/source/app@" + digest + " to " + host + "/mirror/app:1.0.0"
+	if operations[1].Status != sdk.StatusPlanned || !slices.Contains(operations[1].Details, want) {
+		t.Errorf("mirror = %s %q, want %s %q", operations[1].Status, operations[1].Details, sdk.StatusPlanned, want)
+	}
+
+	tag, err := name.ParseReference(host + "/mirror/app:1.0.0")
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+
+	if _, err := remote.Head(tag); err == nil {
+		t.Error("dry run pushed the destination tag")
+	}
+}
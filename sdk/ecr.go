@@ -0,0 +1,165 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/awssig"
+	"github.com/farcloser/quark/internal/ecr"
+)
+
+// repoCreation creates the missing ECR repositories of a registry before pushes (see AutoCreateRepo).
+type repoCreation struct {
+	enabled  bool
+	config   ecr.RepositoryConfig
+	creds    awssig.Credentials
+	endpoint string
+
+	// Repositories known to exist, checked once per registry
+	mu      sync.Mutex
+	ensured map[string]bool
+}
+
+// AutoCreateRepo makes pushes to the registry (syncs, imports, artifacts, builds) create their repository first
+// when it does not exist, instead of failing. Amazon ECR private registries only, which do not create repositories
+// on push. Requests to the ECR API are signed with AWSCredentials, or the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables.
+func (builder *RegistryBuilder) AutoCreateRepo(enabled bool) *RegistryBuilder {
+	builder.repos().enabled = enabled
+
+	return builder
+}
+
+// RepoTag adds a resource tag to the repositories created by AutoCreateRepo (e.g., "team", "platform").
+func (builder *RegistryBuilder) RepoTag(key, value string) *RegistryBuilder {
+	creation := builder.repos()
+	if creation.config.Tags == nil {
+		creation.config.Tags = make(map[string]string)
+	}
+
+	creation.config.Tags[key] = value
+
+	return builder
+}
+
+// RepoLifecyclePolicy sets the lifecycle policy (JSON document) of the repositories created by AutoCreateRepo,
+// e.g., expiring untagged images. Repositories that already exist are left unchanged.
+func (builder *RegistryBuilder) RepoLifecyclePolicy(policy string) *RegistryBuilder {
+	builder.repos().config.LifecyclePolicy = policy
+
+	return builder
+}
+
+// AWSCredentials sets the credentials AutoCreateRepo signs ECR API requests with (default: the AWS_* environment
+// variables). The session token is only needed with temporary credentials.
+func (builder *RegistryBuilder) AWSCredentials(accessKey, secretKey, sessionToken string) *RegistryBuilder {
+	builder.repos().creds = awssig.Credentials{
+		AccessKey:    accessKey,
+		SecretKey:    secretKey,
+		SessionToken: sessionToken,
+	}
+
+	return builder
+}
+
+// ECREndpoint sets the ECR API endpoint AutoCreateRepo calls (default: the regional endpoint of the registry,
+// e.g., "https://api.ecr.eu-west-1.amazonaws.com"), e.g., a VPC interface endpoint.
+func (builder *RegistryBuilder) ECREndpoint(endpoint string) *RegistryBuilder {
+	builder.repos().endpoint = endpoint
+
+	return builder
+}
+
+// repos returns the repository creation settings of the registry, created on first use.
+func (builder *RegistryBuilder) repos() *repoCreation {
+	if builder.registry.repos == nil {
+		builder.registry.repos = &repoCreation{}
+	}
+
+	return builder.registry.repos
+}
+
+// check validates the repository creation settings of the registry at host.
+func (creation *repoCreation) check(host string) error {
+	if !creation.enabled {
+		return nil
+	}
+
+	if _, _, err := ecr.ParseHost(host); err != nil {
+		return fmt.Errorf("%w: %s", ErrAutoCreateRepoUnsupported, host)
+	}
+
+	if policy := creation.config.LifecyclePolicy; policy != "" && !json.Valid([]byte(policy)) {
+		return ErrInvalidLifecyclePolicy
+	}
+
+	creation.ensured = make(map[string]bool)
+
+	return nil
+}
+
+// clone returns a copy of the settings, without the repositories known to exist.
+func (creation *repoCreation) clone() *repoCreation {
+	if creation == nil {
+		return nil
+	}
+
+	return &repoCreation{
+		enabled:  creation.enabled,
+		creds:    creation.creds,
+		endpoint: creation.endpoint,
+		config: ecr.RepositoryConfig{
+			Tags:            maps.Clone(creation.config.Tags),
+			LifecyclePolicy: creation.config.LifecyclePolicy,
+		},
+	}
+}
+
+// ensureRepository creates the repository (e.g., "team/app") in the registry if it does not exist and the registry
+// auto-creates repositories (see AutoCreateRepo). Each repository is checked once per registry.
+func (reg *Registry) ensureRepository(ctx context.Context, repository string, log zerolog.Logger) error {
+	if reg == nil || reg.repos == nil || !reg.repos.enabled {
+		return nil
+	}
+
+	creation := reg.repos
+
+	creation.mu.Lock()
+	defer creation.mu.Unlock()
+
+	if creation.ensured[repository] {
+		return nil
+	}
+
+	creds := creation.creds
+	if creds.AccessKey == "" {
+		creds = awssig.FromEnv()
+	}
+
+	client, err := ecr.NewClient(reg.host, creds)
+	if err != nil {
+		return fmt.Errorf("%w %s: %w", ErrRepositoryCreation, repository, err)
+	}
+
+	if creation.endpoint != "" {
+		client.WithEndpoint(creation.endpoint)
+	}
+
+	created, err := client.EnsureRepository(ctx, repository, creation.config)
+	if err != nil {
+		return fmt.Errorf("%w %s: %w", ErrRepositoryCreation, repository, err)
+	}
+
+	if created {
+		log.Info().Str("registry", reg.host).Str("repository", repository).Msg("ECR repository created")
+	}
+
+	creation.ensured[repository] = true
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package sdk_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+const testECRHost = "123456789012.dkr.ecr.eu-west-1.amazonaws.com"
+
+// INTENTION: Repository auto-creation is only accepted on ECR private registries, with a JSON lifecycle policy.
+func TestRegistryBuilder_AutoCreateRepo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		host    string
+		policy  string
+		wantErr error
+	}{
+		{name: "ecr registry", host: testECRHost, policy: `{"rules":[]}`},
+		{name: "other registry", host: "ghcr.io", wantErr: sdk.ErrAutoCreateRepoUnsupported},
+		{name: "invalid policy", host: testECRHost, policy: "rules: []", wantErr: sdk.ErrInvalidLifecyclePolicy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := sdk.NewPlan(testPlanName).Registry(tt.host).
+				AutoCreateRepo(true).
+				RepoTag("team", "platform").
+				RepoLifecyclePolicy(tt.policy).
+				Build()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// INTENTION: Pushes to an auto-creating registry create the missing repository through the ECR API, with its
+// tags, before pushing; a refused creation fails the push.
+func TestArtifact_AutoCreateRepo(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu      sync.Mutex
+		actions []string
+		bodies  []string
+	)
+
+	api := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		body := new(strings.Builder)
+		_, _ = io.Copy(body, req.Body)
+
+		actions = append(actions, strings.TrimPrefix(req.Header.Get("X-Amz-Target"),
+			"AmazonEC2ContainerRegistry_V20150921."))
+		bodies = append(bodies, body.String())
+
+		exception := "RepositoryNotFoundException"
+		if len(actions) > 1 {
+			exception = "AccessDeniedException"
+		}
+
+		writer.WriteHeader(http.StatusBadRequest)
+		_, _ = writer.Write([]byte(`{"__type":"` + exception + `","message":"` + exception + `"}`))
+	}))
+	t.Cleanup(api.Close)
+
+	plan := sdk.NewPlan(testPlanName)
+
+	if _, err := plan.Registry(testECRHost).
+		AutoCreateRepo(true).
+		RepoTag("team", "platform").
+		AWSCredentials("AKID", "secret", "").
+		ECREndpoint(api.URL).
+		Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	dest, err := sdk.NewImage("team/app-sbom").Domain(testECRHost).Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	file := filepath.Join(t.TempDir(), "sbom.json")
+	if err := os.WriteFile(file, []byte("{}"), 0o600); err != nil {
+		t.Fatalf("Failed to write artifact file: %v", err)
+	}
+
+	if _, err := plan.Artifact("sbom").
+		Destination(dest).
+		ArtifactType("application/vnd.example.sbom").
+		File(file, "application/json").
+		Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if err := plan.Execute(t.Context()); !errors.Is(err, sdk.ErrRepositoryCreation) {
+		t.Fatalf("Execute() error = %v, want %v", err, sdk.ErrRepositoryCreation)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if strings.Join(actions, ",") != "DescribeRepositories,CreateRepository" {
+		t.Fatalf("actions = %v, want DescribeRepositories then CreateRepository", actions)
+	}
+
+	if !strings.Contains(bodies[1], `"repositoryName":"team/app-sbom"`) ||
+		!strings.Contains(bodies[1], `{"Key":"team","Value":"platform"}`) {
+		t.Errorf("CreateRepository request = %s, want team/app-sbom tagged team=platform", bodies[1])
+	}
+}
@@ -1,12 +1,21 @@
 package sdk
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/joho/godotenv"
+
+	"github.com/farcloser/quark/filesystem"
 )
 
+// envKeyPattern matches valid environment variable names.
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 // LoadEnv loads environment variables from the specified .env file.
 // Values in the .env file will override existing environment variables.
 // Returns an error if the file doesn't exist or cannot be loaded.
@@ -18,6 +27,118 @@ func LoadEnv(path string) error {
 	return nil
 }
 
+// WriteEnv sets variables in the specified .env file, creating it if it doesn't exist.
+// Variables already in the file are updated in place (comments, ordering and "export" prefixes are kept),
+// new variables are appended in alphabetical order.
+// Typically used with VersionCheck.Env and Sync.Env to maintain the files plans load with LoadEnv.
+func WriteEnv(path string, values map[string]string) error {
+	for key := range values {
+		if !envKeyPattern.MatchString(key) {
+			return fmt.Errorf("%w: %q", ErrInvalidEnvKey, key)
+		}
+	}
+
+	perm := os.FileMode(filesystem.FilePermissionsDefault)
+
+	// #nosec G304 -- .env path provided by the plan author
+	content, err := os.ReadFile(path)
+
+	switch {
+	case err == nil:
+		if info, statErr := os.Stat(path); statErr == nil {
+			perm = info.Mode().Perm()
+		}
+	case errors.Is(err, os.ErrNotExist):
+	default:
+		return fmt.Errorf("failed to read .env file %q: %w", path, err)
+	}
+
+	var lines []string
+	if len(content) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	}
+
+	written := make(map[string]bool, len(values))
+
+	for idx, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		export, assignment := "", trimmed
+
+		if rest, ok := strings.CutPrefix(trimmed, "export "); ok {
+			export, assignment = "export ", strings.TrimSpace(rest)
+		}
+
+		key, _, ok := strings.Cut(assignment, "=")
+		if !ok || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		key = strings.TrimSpace(key)
+
+		value, ok := values[key]
+		if !ok {
+			continue
+		}
+
+		formatted, err := formatEnv(key, value)
+		if err != nil {
+			return err
+		}
+
+		lines[idx] = export + formatted
+		written[key] = true
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if !written[key] {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		formatted, err := formatEnv(key, values[key])
+		if err != nil {
+			return err
+		}
+
+		lines = append(lines, formatted)
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), perm); err != nil {
+		return fmt.Errorf("failed to write .env file %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// formatEnv formats a KEY=value line, quoting the value as LoadEnv expects.
+func formatEnv(key, value string) (string, error) {
+	formatted, err := godotenv.Marshal(map[string]string{key: value})
+	if err != nil {
+		return "", fmt.Errorf("failed to format %q: %w", key, err)
+	}
+
+	return formatted, nil
+}
+
+// imageEnv returns the <prefix>_VERSION and <prefix>_DIGEST variables of an image, omitting empty values.
+func imageEnv(prefix, version, digest string) map[string]string {
+	values := make(map[string]string)
+
+	if version != "" {
+		values[prefix+"_VERSION"] = version
+	}
+
+	if digest != "" {
+		values[prefix+"_DIGEST"] = digest
+	}
+
+	return values
+}
+
 // GetEnv retrieves an environment variable value.
 // Returns an error if the variable does not exist.
 // Empty values (FOO="") are allowed and will not cause an error.
@@ -0,0 +1,133 @@
+package sdk_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: WriteEnv updates existing variables in place, keeping comments, ordering and export prefixes,
+// appends new variables, and writes values LoadEnv reads back unchanged.
+func TestWriteEnv(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".env")
+
+	existing := "# Vector image\nexport VECTOR_VERSION=0.49.0\nVECTOR_DIGEST=sha256:old\n\nOTHER=kept\n"
+	if err := os.WriteFile(path, []byte(existing), filesystem.FilePermissionsDefault); err != nil {
+		t.Fatalf("Failed to write .env: %v", err)
+	}
+
+	err := sdk.WriteEnv(path, map[string]string{
+		"VECTOR_VERSION": "0.50.0-distroless-static",
+		"VECTOR_DIGEST":  "sha256:new",
+		"ALPINE_DIGEST":  "sha256:alpine",
+		"ALPINE_VERSION": "3.20",
+	})
+	if err != nil {
+		t.Fatalf("WriteEnv() error = %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read .env: %v", err)
+	}
+
+	want := `# Vector image
+export VECTOR_VERSION="0.50.0-distroless-static"
+VECTOR_DIGEST="sha256:new"
+
+OTHER=kept
+ALPINE_DIGEST="sha256:alpine"
+ALPINE_VERSION="3.20"
+`
+	if string(content) != want {
+		t.Errorf("WriteEnv() wrote\n%s\nwant\n%s", content, want)
+	}
+}
+
+// INTENTION: A missing .env file is created, and invalid variable names are rejected without writing.
+func TestWriteEnv_NewFileAndInvalidKey(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".env")
+
+	if err := sdk.WriteEnv(path, map[string]string{"BAD-KEY": "value"}); !errors.Is(err, sdk.ErrInvalidEnvKey) {
+		t.Errorf("WriteEnv() error = %v, want %v", err, sdk.ErrInvalidEnvKey)
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("WriteEnv() with an invalid key created the file (stat error = %v)", err)
+	}
+
+	if err := sdk.WriteEnv(path, map[string]string{"APP_DIGEST": "sha256:app"}); err != nil {
+		t.Fatalf("WriteEnv() error = %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read .env: %v", err)
+	}
+
+	if string(content) != "APP_DIGEST=\"sha256:app\"\n" {
+		t.Errorf("WriteEnv() wrote %q", content)
+	}
+}
+
+// INTENTION: After a sync, Env returns the destination version and digest under the given prefix,
+// ready to be written with WriteEnv; before execution it returns nothing.
+func TestSync_Env(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+	digest := pushRandomImage(t, host+"/upstream/vector:0.50.0")
+
+	source, err := sdk.NewImage("upstream/vector").Domain(host).Version("0.50.0").Digest(digest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create source image: %v", err)
+	}
+
+	destination, err := sdk.NewImage("mirror/vector").Domain(host).Version("0.50.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create destination image: %v", err)
+	}
+
+	plan := sdk.NewPlan(testPlanName)
+
+	sync, err := plan.Sync("mirror").Source(source).Destination(destination).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if env := sync.Env("VECTOR"); len(env) != 0 {
+		t.Errorf("Env() before execution = %v, want empty", env)
+	}
+
+	if err := plan.Execute(context.Background()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	env := sync.Env("VECTOR")
+	if env["VECTOR_VERSION"] != "0.50.0" || env["VECTOR_DIGEST"] != sync.DestDigest() || sync.DestDigest() == "" {
+		t.Errorf("Env() = %v, want VECTOR_VERSION=0.50.0 and VECTOR_DIGEST=%s", env, sync.DestDigest())
+	}
+}
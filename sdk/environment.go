@@ -0,0 +1,69 @@
+package sdk
+
+import (
+	"os"
+	"slices"
+	"strings"
+)
+
+// Environment is where a plan is executed.
+type Environment string
+
+const (
+	// EnvCI is a CI/CD runner (GitHub Actions, GitLab CI, Jenkins, ...).
+	EnvCI Environment = "ci"
+	// EnvLocal is anything else, typically a developer laptop.
+	EnvLocal Environment = "local"
+)
+
+// ciEnvVars are set by CI/CD systems on their runners.
+var ciEnvVars = []string{
+	"GITHUB_ACTIONS",
+	"GITLAB_CI",
+	"BUILDKITE",
+	"CIRCLECI",
+	"JENKINS_URL",
+	"TF_BUILD",
+	"TEAMCITY_VERSION",
+	"DRONE",
+	"BITBUCKET_BUILD_NUMBER",
+	"CODEBUILD_BUILD_ID",
+	"WOODPECKER",
+}
+
+// DetectEnvironment returns EnvCI when a CI/CD system is detected from its environment variables
+// (CI=true, or a provider-specific variable such as GITHUB_ACTIONS or GITLAB_CI), EnvLocal otherwise.
+func DetectEnvironment() Environment {
+	if value, ok := os.LookupEnv("CI"); ok && value != "" && !strings.EqualFold(value, "false") && value != "0" {
+		return EnvCI
+	}
+
+	for _, name := range ciEnvVars {
+		if os.Getenv(name) != "" {
+			return EnvCI
+		}
+	}
+
+	return EnvLocal
+}
+
+// envGuard restricts an operation to specific environments.
+// Operations embed it; RunOnlyOn builder methods fill it.
+type envGuard struct {
+	runOnlyOn []Environment
+}
+
+// runsOn reports whether the operation runs in env (unrestricted operations run everywhere).
+func (guard *envGuard) runsOn(env Environment) bool {
+	return len(guard.runOnlyOn) == 0 || slices.Contains(guard.runOnlyOn, env)
+}
+
+// clone returns a copy of the guard, for builder Clone() methods.
+func (guard *envGuard) clone() envGuard {
+	return envGuard{runOnlyOn: slices.Clone(guard.runOnlyOn)}
+}
+
+// environments returns the environments the operation is restricted to.
+func (guard *envGuard) environments() []Environment {
+	return guard.runOnlyOn
+}
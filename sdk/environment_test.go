@@ -0,0 +1,90 @@
+package sdk_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: CI is detected from the generic CI variable or provider-specific variables.
+//
+//nolint:paralleltest // Modifies process environment
+func TestDetectEnvironment(t *testing.T) {
+	ciVars := []string{
+		"CI", "GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE", "CIRCLECI", "JENKINS_URL", "TF_BUILD",
+		"TEAMCITY_VERSION", "DRONE", "BITBUCKET_BUILD_NUMBER", "CODEBUILD_BUILD_ID", "WOODPECKER",
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want sdk.Environment
+	}{
+		{name: "no CI variables", want: sdk.EnvLocal},
+		{name: "CI=true", env: map[string]string{"CI": "true"}, want: sdk.EnvCI},
+		{name: "CI=false", env: map[string]string{"CI": "false"}, want: sdk.EnvLocal},
+		{name: "GitHub Actions", env: map[string]string{"GITHUB_ACTIONS": "true"}, want: sdk.EnvCI},
+		{name: "Jenkins", env: map[string]string{"JENKINS_URL": "https://jenkins.internal/"}, want: sdk.EnvCI},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range ciVars {
+				t.Setenv(name, "")
+			}
+
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			if got := sdk.DetectEnvironment(); got != tt.want {
+				t.Errorf("DetectEnvironment() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// INTENTION: Operations restricted with RunOnlyOn are skipped in other environments
+// and executed in the ones they are restricted to.
+func TestPlan_RunOnlyOn(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		env     sdk.Environment
+		wantRun bool
+	}{
+		{name: "skipped locally", env: sdk.EnvLocal, wantRun: false},
+		{name: "executed in CI", env: sdk.EnvCI, wantRun: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dest, err := sdk.NewImage("my-org/app-sbom").Domain("ghcr.io").Version("1.0.0").Build()
+			if err != nil {
+				t.Fatalf("Failed to create test image: %v", err)
+			}
+
+			plan := sdk.NewPlan(testPlanName)
+			plan.Environment(tt.env)
+
+			// Executing the artifact fails before any network access: the file does not exist
+			if _, err := plan.Artifact("publish").
+				Destination(dest).
+				ArtifactType("application/vnd.example.sbom").
+				File(filepath.Join(t.TempDir(), "missing.json"), "application/json").
+				RunOnlyOn(sdk.EnvCI).
+				Build(); err != nil {
+				t.Fatalf("Build() failed: %v", err)
+			}
+
+			err = plan.Execute(t.Context())
+			if ran := err != nil; ran != tt.wantRun {
+				t.Errorf("Execute() error = %v, want operation executed = %v", err, tt.wantRun)
+			}
+		})
+	}
+}
@@ -1,6 +1,12 @@
 package sdk
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/farcloser/quark/internal/exceptions"
+	"github.com/farcloser/quark/internal/registry"
+	"github.com/farcloser/quark/internal/trivy"
+)
 
 // 1Password errors.
 var (
@@ -57,6 +63,18 @@ var (
 
 	// ErrVulnerabilitiesFound indicates vulnerabilities were found at or above threshold.
 	ErrVulnerabilitiesFound = errors.New("vulnerabilities found at or above threshold")
+
+	// ErrKnownExploitedFound indicates vulnerabilities known to be exploited were found (see FailOnKnownExploited).
+	ErrKnownExploitedFound = errors.New("known exploited vulnerabilities found")
+
+	// ErrKnownExploitedFeed indicates the known exploited vulnerabilities feed could not be loaded.
+	ErrKnownExploitedFeed = trivy.ErrKnownExploitedFeed
+)
+
+// Exception errors.
+var (
+	// ErrInvalidExceptions indicates a waiver file that cannot be read or fails validation.
+	ErrInvalidExceptions = exceptions.ErrInvalid
 )
 
 // Audit errors.
@@ -75,6 +93,32 @@ var (
 
 	// ErrVersionCheckVersionRequired indicates version check image must have version specified.
 	ErrVersionCheckVersionRequired = errors.New("version check image must have version specified")
+
+	// ErrBaseImageCheckDockerfileRequired indicates base image check requires a Dockerfile.
+	ErrBaseImageCheckDockerfileRequired = errors.New("base image check Dockerfile is required")
+
+	// ErrUnpinnedBaseImage indicates a Dockerfile base image is not pinned by digest.
+	ErrUnpinnedBaseImage = errors.New("base image not pinned by digest")
+
+	// ErrPinBaseImagesDockerfileRequired indicates base image pinning requires a Dockerfile.
+	ErrPinBaseImagesDockerfileRequired = errors.New("pin base images Dockerfile is required")
+
+	// ErrComposeFileRequired indicates Compose image maintenance requires a Compose file.
+	ErrComposeFileRequired = errors.New("compose images file is required")
+
+	// ErrComposeTaskRequired indicates Compose image maintenance has nothing to do.
+	ErrComposeTaskRequired = errors.New(
+		"compose images requires at least one task (CheckUpdates, Update, Pin or MirrorTo)",
+	)
+
+	// ErrComposeInvalidMirror indicates the mirror is not a repository reference without tag or digest.
+	ErrComposeInvalidMirror = errors.New("compose images mirror must be a repository without tag or digest")
+
+	// ErrKubernetesManifestsRequired indicates Kubernetes manifest checks require manifest files.
+	ErrKubernetesManifestsRequired = errors.New("kubernetes manifests files are required")
+
+	// ErrKubernetesTaskRequired indicates Kubernetes manifest checks have nothing to do.
+	ErrKubernetesTaskRequired = errors.New("kubernetes manifests requires Scan or VersionCheck")
 )
 
 // Image errors.
@@ -90,12 +134,21 @@ var (
 
 	// ErrInvalidImageDigest indicates image digest has invalid format.
 	ErrInvalidImageDigest = errors.New("invalid image digest format")
+
+	// ErrImageProtocolComponents indicates Domain, Version or Digest were set on a protocol reference.
+	ErrImageProtocolComponents = errors.New("protocol references cannot be combined with Domain, Version or Digest")
+
+	// ErrImageNotInRegistry indicates a registry operation on an image stored elsewhere (archive, containerd).
+	ErrImageNotInRegistry = errors.New("image is not in a registry")
 )
 
 // Environment errors.
 var (
 	// ErrEnvVarNotSet indicates required environment variable is not set.
 	ErrEnvVarNotSet = errors.New("required environment variable not set")
+
+	// ErrInvalidEnvKey indicates an environment variable name is not valid.
+	ErrInvalidEnvKey = errors.New("invalid environment variable name")
 )
 
 // BuildNode errors.
@@ -105,6 +158,9 @@ var (
 
 	// ErrBuildNodePlatformRequired indicates buildnode platform is required.
 	ErrBuildNodePlatformRequired = errors.New("buildnode platform is required")
+
+	// ErrBuildNodeLabelKeyRequired indicates a buildnode label has an empty key.
+	ErrBuildNodeLabelKeyRequired = errors.New("buildnode label key is required")
 )
 
 // Sync errors.
@@ -119,6 +175,17 @@ var (
 
 	// ErrSyncDestinationRequired indicates sync destination image is required.
 	ErrSyncDestinationRequired = errors.New("sync destination image is required")
+
+	// ErrSyncSignaturesConflict indicates CopySignatures and RecordPreviousDigest were both enabled.
+	ErrSyncSignaturesConflict = errors.New(
+		"sync cannot copy signatures and record the previous digest (the annotation changes the signed digest)",
+	)
+
+	// ErrInvalidRewriteRule indicates a malformed plan rewrite rule.
+	ErrInvalidRewriteRule = errors.New("invalid rewrite rule")
+
+	// ErrInvalidWebhook indicates an invalid sync webhook or notification URL.
+	ErrInvalidWebhook = errors.New("invalid webhook URL (expected http(s)://host[:port]/path)")
 )
 
 // Build errors (additional).
@@ -131,6 +198,15 @@ var (
 
 	// ErrBuildTagRequired indicates build tag is required.
 	ErrBuildTagRequired = errors.New("build tag is required")
+
+	// ErrInvalidBuildTag indicates a build tag that is not a valid image reference.
+	ErrInvalidBuildTag = errors.New("invalid build tag")
+
+	// ErrBuildNodeSelectorNoMatch indicates no declared build node matches a build node selector.
+	ErrBuildNodeSelectorNoMatch = errors.New("no build node matches selector")
+
+	// ErrInvalidDiskSpaceFactor indicates a negative build disk space factor.
+	ErrInvalidDiskSpaceFactor = errors.New("build disk space factor must not be negative")
 )
 
 // Audit errors (additional).
@@ -152,6 +228,9 @@ var (
 
 	// ErrInvalidScanFormat indicates an invalid scan format value.
 	ErrInvalidScanFormat = errors.New("invalid scan format")
+
+	// ErrInvalidScannerServer indicates an invalid Trivy server URL.
+	ErrInvalidScannerServer = errors.New("invalid scanner server URL (expected http(s)://host[:port])")
 )
 
 // Audit errors (JSON validation).
@@ -159,3 +238,477 @@ var (
 	// ErrInvalidAuditRuleSet indicates an invalid audit rule set value.
 	ErrInvalidAuditRuleSet = errors.New("invalid audit rule set")
 )
+
+// Rollback errors.
+var (
+	// ErrRollbackImageRequired indicates rollback image is required.
+	ErrRollbackImageRequired = errors.New("rollback image is required")
+
+	// ErrRollbackVersionRequired indicates rollback image must have a version (the tag to re-point).
+	ErrRollbackVersionRequired = errors.New("rollback image must have version specified")
+
+	// ErrRollbackDigestRequired indicates rollback target digest is required.
+	ErrRollbackDigestRequired = errors.New("rollback target digest is required")
+
+	// ErrRollbackDigestNotFound indicates the rollback target digest no longer exists in the registry.
+	ErrRollbackDigestNotFound = errors.New("rollback target digest not found in registry")
+)
+
+// Size check errors.
+var (
+	// ErrSizeCheckImageRequired indicates size check image is required.
+	ErrSizeCheckImageRequired = errors.New("size check image is required")
+
+	// ErrSizeCheckLimitRequired indicates size check requires at least one limit.
+	ErrSizeCheckLimitRequired = errors.New("size check requires MaxSize or MaxLayers")
+
+	// ErrInvalidSize indicates an invalid size value.
+	ErrInvalidSize = errors.New("invalid size (expected e.g. \"200MiB\", \"1.5GB\", \"1024\")")
+
+	// ErrImageSizeExceeded indicates an image exceeds the configured size budget.
+	ErrImageSizeExceeded = errors.New("image size exceeds budget")
+
+	// ErrImageLayersExceeded indicates an image exceeds the configured layer budget.
+	ErrImageLayersExceeded = errors.New("image layer count exceeds budget")
+)
+
+// Profile errors.
+var (
+	// ErrProfileNameRequired indicates profile name is required.
+	ErrProfileNameRequired = errors.New("profile name is required")
+
+	// ErrDuplicateProfile indicates a profile name is defined twice in the plan.
+	ErrDuplicateProfile = errors.New("duplicate profile name")
+
+	// ErrUnknownProfile indicates the selected profile is not defined in the plan.
+	ErrUnknownProfile = errors.New("unknown profile")
+
+	// ErrProfileApplied indicates another profile was selected once a profile was applied.
+	ErrProfileApplied = errors.New("plan already executed with another profile")
+)
+
+// Provenance errors.
+var (
+	// ErrProvenanceAttach indicates the provenance statement could not be attached to a produced image.
+	ErrProvenanceAttach = errors.New("failed to attach provenance")
+)
+
+// Verify errors.
+var (
+	// ErrVerifyImageRequired indicates verify image is required.
+	ErrVerifyImageRequired = errors.New("verify image is required")
+
+	// ErrVerifyDigestRequired indicates verify image must have digest.
+	ErrVerifyDigestRequired = errors.New("verify image must have digest specified")
+
+	// ErrVerifyKeyRequired indicates verify requires a cosign key or identity, or a notation trust policy.
+	ErrVerifyKeyRequired = errors.New("verify requires CosignKey, CosignIdentity or NotationTrustPolicy")
+
+	// ErrVerifyConflictingKeys indicates verify has several verification methods.
+	ErrVerifyConflictingKeys = errors.New(
+		"verify accepts one of CosignKey, CosignIdentity or NotationTrustPolicy",
+	)
+
+	// ErrVerifyIdentityIncomplete indicates a cosign identity without identity or issuer.
+	ErrVerifyIdentityIncomplete = errors.New("cosign identity requires both identity and issuer")
+
+	// ErrInvalidRekorURL indicates an invalid transparency log URL.
+	ErrInvalidRekorURL = errors.New("invalid Rekor URL (expected http or https URL)")
+
+	// ErrSignatureVerificationFailed indicates the image is not signed with the expected key or identity.
+	ErrSignatureVerificationFailed = errors.New("signature verification failed")
+)
+
+// Artifact errors.
+var (
+	// ErrArtifactDestinationRequired indicates artifact destination is required.
+	ErrArtifactDestinationRequired = errors.New("artifact destination is required")
+
+	// ErrArtifactVersionRequired indicates artifact destination must have a version (the tag to push).
+	ErrArtifactVersionRequired = errors.New("artifact destination must have version specified")
+
+	// ErrArtifactTypeRequired indicates artifact type is required.
+	ErrArtifactTypeRequired = errors.New("artifact type is required")
+
+	// ErrArtifactFileRequired indicates artifact requires at least one file.
+	ErrArtifactFileRequired = errors.New("artifact requires at least one file")
+)
+
+// Export errors.
+var (
+	// ErrExportSourceRequired indicates export source is required.
+	ErrExportSourceRequired = errors.New("export source is required")
+
+	// ErrExportSourceDigestRequired indicates export source must have digest.
+	ErrExportSourceDigestRequired = errors.New("export source must have digest specified")
+
+	// ErrExportTransportRequired indicates export transport is required.
+	ErrExportTransportRequired = errors.New("export transport is required")
+)
+
+// Import errors.
+var (
+	// ErrImportTransportRequired indicates import transport is required.
+	ErrImportTransportRequired = errors.New("import transport is required")
+
+	// ErrImportSourceRequired indicates import source is required.
+	ErrImportSourceRequired = errors.New("import source is required")
+
+	// ErrImportSourceDigestRequired indicates import source must have digest.
+	ErrImportSourceDigestRequired = errors.New("import source must have digest specified")
+
+	// ErrImportDestinationRequired indicates import destination is required.
+	ErrImportDestinationRequired = errors.New("import destination is required")
+)
+
+// Bundle errors.
+var (
+	// ErrBundleImageRequired indicates a bundle requires at least one image.
+	ErrBundleImageRequired = errors.New("bundle requires at least one image")
+
+	// ErrBundleImageDigestRequired indicates bundle images must have digest.
+	ErrBundleImageDigestRequired = errors.New("bundle image must have digest specified")
+
+	// ErrBundleDestinationRequired indicates bundle destination is required.
+	ErrBundleDestinationRequired = errors.New("bundle destination is required")
+
+	// ErrInvalidBundleName indicates an invalid bundle archive name.
+	ErrInvalidBundleName = errors.New("invalid bundle archive name")
+
+	// ErrInvalidBucketURL indicates an invalid bucket URL.
+	ErrInvalidBucketURL = errors.New("invalid bucket URL")
+)
+
+// Confirmation errors.
+var (
+	// ErrConfirmationRequired indicates a destructive operation needs confirmation in a non-interactive context.
+	ErrConfirmationRequired = errors.New("destructive operation requires confirmation")
+
+	// ErrDestructiveNotConfirmed indicates the user declined a destructive operation.
+	ErrDestructiveNotConfirmed = errors.New("destructive operation not confirmed")
+)
+
+// Node maintenance errors.
+var (
+	// ErrNodeMaintenanceNodeRequired indicates node maintenance requires a build node.
+	ErrNodeMaintenanceNodeRequired = errors.New("node maintenance build node is required")
+
+	// ErrNodeMaintenanceTaskRequired indicates node maintenance has nothing to do.
+	ErrNodeMaintenanceTaskRequired = errors.New(
+		"node maintenance requires at least one task (PruneBuildCache or WarmCache)",
+	)
+
+	// ErrInvalidPruneAge indicates a negative build cache prune age.
+	ErrInvalidPruneAge = errors.New("build cache prune age must not be negative")
+)
+
+// Node provisioning errors.
+var (
+	// ErrProvisionNodeRequired indicates provisioning requires a build node.
+	ErrProvisionNodeRequired = errors.New("provision build node is required")
+
+	// ErrProvisionTaskRequired indicates provisioning has nothing to install.
+	ErrProvisionTaskRequired = errors.New("provisioning requires at least one tool (InstallDocker or InstallBuildx)")
+
+	// ErrProvisionBuildxVersionRequired indicates a buildx checksum was pinned without a buildx version.
+	ErrProvisionBuildxVersionRequired = errors.New("buildx checksum requires a buildx version (InstallBuildx)")
+)
+
+// Containerd import errors.
+var (
+	// ErrContainerdImportSourceRequired indicates containerd import source is required.
+	ErrContainerdImportSourceRequired = errors.New("containerd import source is required")
+
+	// ErrContainerdImportDigestRequired indicates containerd import source must have a digest when executed.
+	ErrContainerdImportDigestRequired = errors.New("containerd import source must have digest specified")
+
+	// ErrContainerdImportNodeRequired indicates containerd import requires at least one node.
+	ErrContainerdImportNodeRequired = errors.New("containerd import requires at least one node")
+)
+
+// Remote run errors.
+var (
+	// ErrRemoteRunNodeRequired indicates remote run requires a node.
+	ErrRemoteRunNodeRequired = errors.New("remote run node is required")
+
+	// ErrRemoteRunCommandRequired indicates remote run requires a command.
+	ErrRemoteRunCommandRequired = errors.New("remote run command is required")
+
+	// ErrRemoteRunDependencyNotInPlan indicates remote run depends on a sync not built in the same plan.
+	ErrRemoteRunDependencyNotInPlan = errors.New("remote run dependency must be built in the same plan first")
+
+	// ErrRemoteRunFailed indicates the remote command failed.
+	ErrRemoteRunFailed = errors.New("remote command failed")
+)
+
+// Report errors.
+var (
+	// ErrInvalidReportFormat indicates an unknown execution report format.
+	ErrInvalidReportFormat = errors.New("invalid report format")
+)
+
+// Orchestrator errors.
+var (
+	// ErrNamespaceRequired indicates an orchestrated plan requires a namespace.
+	ErrNamespaceRequired = errors.New("plan namespace is required")
+
+	// ErrDuplicateNamespace indicates a namespace already has a plan in the orchestrator.
+	ErrDuplicateNamespace = errors.New("namespace already has a plan")
+
+	// ErrPlanFailed indicates an orchestrated plan failed.
+	ErrPlanFailed = errors.New("plan failed")
+)
+
+// Registry errors.
+// Registry failures returned by operations match these causes with errors.Is.
+var (
+	// ErrRegistryUnauthorized indicates the registry rejected the credentials, or required some (HTTP 401).
+	ErrRegistryUnauthorized = registry.ErrUnauthorized
+
+	// ErrRegistryForbidden indicates the credentials lack access to the repository (HTTP 403).
+	ErrRegistryForbidden = registry.ErrForbidden
+
+	// ErrRegistryRateLimited indicates the registry throttled requests (HTTP 429).
+	ErrRegistryRateLimited = registry.ErrRateLimited
+
+	// ErrRegistryNotFound indicates the repository, manifest or blob does not exist (HTTP 404).
+	ErrRegistryNotFound = registry.ErrNotFound
+
+	// ErrRegistryBlobUnknown indicates a blob referenced by a manifest does not exist in the repository.
+	// Blob unknown errors also match ErrRegistryNotFound.
+	ErrRegistryBlobUnknown = registry.ErrBlobUnknown
+
+	// ErrRegistryTagImmutable indicates the registry refused to overwrite an existing tag (immutable tags).
+	ErrRegistryTagImmutable = registry.ErrTagImmutable
+
+	// ErrRegistryUnhealthy indicates a request was not sent because the registry host failed too many
+	// consecutive requests (see Plan.CircuitBreaker).
+	ErrRegistryUnhealthy = registry.ErrRegistryUnhealthy
+
+	// ErrRegistryManifestRewritten indicates the destination tag does not point to the manifest list pushed
+	// by a sync (SyncBuilder.VerifyPushedDigest): the registry or a proxy rewrote it.
+	ErrRegistryManifestRewritten = registry.ErrManifestRewritten
+)
+
+// Repository creation errors.
+var (
+	// ErrAutoCreateRepoUnsupported indicates AutoCreateRepo on a registry that is not an Amazon ECR private registry.
+	ErrAutoCreateRepoUnsupported = errors.New("repository auto-creation requires an Amazon ECR registry")
+
+	// ErrInvalidLifecyclePolicy indicates a repository lifecycle policy that is not a JSON document.
+	ErrInvalidLifecyclePolicy = errors.New("repository lifecycle policy must be a JSON document")
+
+	// ErrRepositoryCreation indicates a missing repository could not be created before a push.
+	ErrRepositoryCreation = errors.New("failed to create repository")
+)
+
+// Platform errors.
+var (
+	// ErrInvalidPlatform indicates a malformed platform string.
+	ErrInvalidPlatform = errors.New("invalid platform")
+
+	// ErrUnsupportedPlatform indicates a platform outside the supported catalog.
+	ErrUnsupportedPlatform = errors.New("unsupported platform")
+)
+
+// Dry run errors.
+var (
+	// ErrDryRunImageNotFound indicates a dry run found an image an operation needs missing from its registry.
+	ErrDryRunImageNotFound = errors.New("image not found in registry")
+)
+
+// Plan state errors.
+var (
+	// ErrInvalidPlanState indicates a plan state file could not be read (see ReadState).
+	ErrInvalidPlanState = errors.New("invalid plan state")
+)
+
+// Validation errors.
+var (
+	// ErrPlanValidation indicates the plan validation found something an operation needs missing or failing.
+	ErrPlanValidation = errors.New("plan validation failed")
+)
+
+// Harbor errors.
+var (
+	// ErrHarborURLRequired indicates Harbor project requires the Harbor instance URL.
+	ErrHarborURLRequired = errors.New("harbor URL is required")
+
+	// ErrInvalidHarborURL indicates the Harbor instance URL is not an absolute HTTP(S) URL.
+	ErrInvalidHarborURL = errors.New("invalid harbor URL")
+
+	// ErrHarborProjectRequired indicates Harbor project requires a project name.
+	ErrHarborProjectRequired = errors.New("harbor project name is required")
+
+	// ErrInvalidHarborRetention indicates a retention rule keeping fewer than one artifact or day.
+	ErrInvalidHarborRetention = errors.New("invalid harbor retention rule")
+
+	// ErrHarborRobotRegistryRequired indicates a robot account is provisioned without registry to authenticate.
+	ErrHarborRobotRegistryRequired = errors.New("harbor robot requires a registry")
+)
+
+// Scheduling errors.
+var (
+	// ErrDuplicateOperationName indicates an operation is built with the name of another operation of the plan.
+	ErrDuplicateOperationName = errors.New("operation name already used in the plan")
+
+	// ErrInvalidRetryPolicy indicates a Retry() with fewer than one attempt or a negative backoff.
+	ErrInvalidRetryPolicy = errors.New("invalid retry policy")
+
+	// ErrDependencyNotInPlan indicates an operation depends on an operation not built in the same plan before it.
+	ErrDependencyNotInPlan = errors.New("operation dependency must be built in the same plan first")
+)
+
+// Plan document errors.
+var (
+	// ErrPlanDocumentInvalid indicates a declarative plan document failed to render or parse.
+	ErrPlanDocumentInvalid = errors.New("invalid plan document")
+
+	// ErrPlanDocumentUnknownReference indicates a plan document references an operation or build node it does
+	// not define (before the reference, for operations).
+	ErrPlanDocumentUnknownReference = errors.New("plan document references an undefined entry")
+)
+
+// Composition errors.
+var (
+	// ErrIncludeNameRequired indicates an included plan has no name to namespace its operations with.
+	ErrIncludeNameRequired = errors.New("included plan name is required")
+
+	// ErrPlanAlreadyIncluded indicates a plan is included twice, in the same or another plan, or in itself.
+	ErrPlanAlreadyIncluded = errors.New("plan already included")
+)
+
+// GHCR errors.
+var (
+	// ErrGHCRImageRequired indicates GHCR package requires the package image.
+	ErrGHCRImageRequired = errors.New("GHCR package image is required")
+
+	// ErrGHCRVersionRequired indicates the GHCR package image has no version (the tag to annotate).
+	ErrGHCRVersionRequired = errors.New("GHCR package image version is required")
+
+	// ErrInvalidGHCRImage indicates a GHCR package image without owner (e.g., ghcr.io/app).
+	ErrInvalidGHCRImage = errors.New("invalid GHCR package image")
+
+	// ErrInvalidGHCRRepository indicates a linked repository that is not "owner/name".
+	ErrInvalidGHCRRepository = errors.New("invalid GHCR package repository")
+
+	// ErrGHCRSettingsRequired indicates GHCR package sets neither metadata nor visibility.
+	ErrGHCRSettingsRequired = errors.New("GHCR package requires a repository, description, label or visibility")
+
+	// ErrInvalidPackageVisibility indicates an unknown package visibility.
+	ErrInvalidPackageVisibility = errors.New("invalid package visibility")
+
+	// ErrGHCRVisibilityMismatch indicates the package has another visibility than expected.
+	ErrGHCRVisibilityMismatch = errors.New("GHCR package visibility mismatch")
+)
+
+// Repository documentation errors.
+var (
+	// ErrRepositoryDocsImageRequired indicates repository documentation requires the repository image.
+	ErrRepositoryDocsImageRequired = errors.New("repository documentation image is required")
+
+	// ErrInvalidRepositoryDocsImage indicates a repository that is not namespace/name.
+	ErrInvalidRepositoryDocsImage = errors.New("invalid repository documentation image")
+
+	// ErrRepositoryDocsRequired indicates repository documentation sets neither a description nor a README.
+	ErrRepositoryDocsRequired = errors.New("repository documentation requires a description or a README")
+
+	// ErrRepositoryDescriptionTooLong indicates a short description longer than Docker Hub accepts.
+	ErrRepositoryDescriptionTooLong = errors.New("repository description too long")
+
+	// ErrRepositoryReadmeTooLong indicates a README larger than Docker Hub accepts.
+	ErrRepositoryReadmeTooLong = errors.New("repository README too long")
+)
+
+// Notification errors.
+var (
+	// ErrInvalidNotifyFormat indicates an unknown notification format.
+	ErrInvalidNotifyFormat = errors.New("invalid notification format")
+
+	// ErrInvalidNotifyEvent indicates an unknown notification event.
+	ErrInvalidNotifyEvent = errors.New("invalid notification event")
+)
+
+// Rebase errors.
+var (
+	// ErrRebaseImageRequired indicates a rebase requires the application image.
+	ErrRebaseImageRequired = errors.New("rebase image is required")
+
+	// ErrRebaseBaseRequired indicates a rebase requires the old and new base images.
+	ErrRebaseBaseRequired = errors.New("rebase old and new base images are required")
+
+	// ErrRebaseVersionRequired indicates the rebase destination has no tag to push to.
+	ErrRebaseVersionRequired = errors.New("rebase destination version is required")
+)
+
+// Graph errors.
+var (
+	// ErrInvalidGraphFormat indicates an unknown graph format.
+	ErrInvalidGraphFormat = errors.New("invalid graph format")
+)
+
+// Flatten errors.
+var (
+	// ErrFlattenImageRequired indicates a flattening requires the image to flatten.
+	ErrFlattenImageRequired = errors.New("flatten image is required")
+
+	// ErrFlattenDestinationRequired indicates a flattening requires the image to push the flattened image to.
+	ErrFlattenDestinationRequired = errors.New("flatten destination is required")
+
+	// ErrFlattenVersionRequired indicates the flatten destination has no tag to push to.
+	ErrFlattenVersionRequired = errors.New("flatten destination version is required")
+)
+
+// Mutate errors.
+var (
+	// ErrMutateImageRequired indicates a mutation requires the image to change.
+	ErrMutateImageRequired = errors.New("mutate image is required")
+
+	// ErrMutateChangeRequired indicates a mutation requires at least one config change.
+	ErrMutateChangeRequired = errors.New("mutate requires a config change or a timestamp")
+
+	// ErrMutateVersionRequired indicates the mutation destination has no tag to push to.
+	ErrMutateVersionRequired = errors.New("mutate destination version is required")
+)
+
+// Index errors.
+var (
+	// ErrIndexImageRequired indicates a manifest list requires at least one image.
+	ErrIndexImageRequired = errors.New("index requires at least one image")
+
+	// ErrInvalidIndexImage indicates an index image that is not a valid image reference.
+	ErrInvalidIndexImage = errors.New("invalid index image")
+
+	// ErrIndexTagRequired indicates a manifest list requires the tag to push it to.
+	ErrIndexTagRequired = errors.New("index tag is required")
+
+	// ErrInvalidIndexTag indicates an index tag that is not a valid image reference.
+	ErrInvalidIndexTag = errors.New("invalid index tag")
+
+	// ErrIndexVersionRequired indicates the index tag has no version to push to.
+	ErrIndexVersionRequired = errors.New("index tag version is required")
+)
+
+// Prune errors.
+var (
+	// ErrPruneRepositoryRequired indicates a prune requires the repository to prune.
+	ErrPruneRepositoryRequired = errors.New("prune repository is required")
+
+	// ErrInvalidRetentionPolicy indicates a retention policy keeping nothing, or with invalid rules.
+	ErrInvalidRetentionPolicy = errors.New("invalid retention policy")
+)
+
+// Schedule errors.
+var (
+	// ErrInvalidSchedule indicates a plan schedule that is not a valid cron expression.
+	ErrInvalidSchedule = errors.New("invalid plan schedule")
+
+	// ErrPlanRunning indicates an execution of a scheduled plan requested while the plan runs.
+	ErrPlanRunning = errors.New("plan is already running")
+)
+
+// Database errors.
+var (
+	// ErrDatabase indicates a plan database that cannot be opened or migrated.
+	ErrDatabase = errors.New("plan database unavailable")
+)
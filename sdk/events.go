@@ -0,0 +1,155 @@
+package sdk
+
+import (
+	"context"
+	"time"
+
+	"github.com/farcloser/quark/internal/registry"
+	"github.com/farcloser/quark/internal/trivy"
+)
+
+// Event is emitted to event sinks as a plan executes, to drive custom UIs and CI integrations.
+// It is one of OperationStarted, OperationFinished, LayerCopied, ScanFinding or BuildLog.
+type Event interface {
+	isEvent()
+}
+
+// OperationStarted is emitted before an operation executes (and before its destructive change confirmation).
+type OperationStarted struct {
+	Operation string
+	// Kind is the operation type, as shown in reports (e.g., "sync", "version-check").
+	Kind    string
+	Started time.Time
+}
+
+// OperationFinished is emitted once an operation succeeded, or failed after its last attempt.
+type OperationFinished struct {
+	Operation string
+	Kind      string
+	Duration  time.Duration
+	// Err is the failure (nil when the operation succeeded).
+	Err error
+}
+
+// LayerCopied is emitted for every blob (layer or image config) an operation uploads to, or mounts in,
+// a registry repository. Blobs the repository already had are not copied, and not emitted.
+type LayerCopied struct {
+	Operation string
+	// Repository is the destination repository, with its registry host (e.g., "ghcr.io/org/app").
+	Repository string
+	Digest     string
+	// Mounted is true when the registry mounted the blob from another repository instead of receiving it.
+	Mounted bool
+}
+
+// ScanFinding is emitted for every vulnerability a scan finds, per platform, before severity checks.
+type ScanFinding struct {
+	Operation        string
+	Platform         string
+	Target           string // Scanned target within the image (e.g., OS packages, a lock file)
+	VulnerabilityID  string
+	Severity         string
+	Package          string
+	InstalledVersion string
+	FixedVersion     string
+	Title            string
+	// KnownExploited is true for vulnerabilities known to be exploited in the wild (see Plan.KnownExploitedFeed).
+	KnownExploited bool
+}
+
+// BuildLog is emitted for every line of build output.
+type BuildLog struct {
+	Operation string
+	Stream    string // "stdout" or "stderr"
+	Line      string
+}
+
+func (OperationStarted) isEvent()  {}
+func (OperationFinished) isEvent() {}
+func (LayerCopied) isEvent()       {}
+func (ScanFinding) isEvent()       {}
+func (BuildLog) isEvent()          {}
+
+// EventSink receives the events of a plan execution.
+// Calls are serialized, also when operations run in parallel: sinks need no locking, but should return
+// quickly (e.g., by forwarding events to a buffered channel).
+type EventSink func(event Event)
+
+// eventContextKey is the context key under which the event emitter of an executing operation is stored.
+type eventContextKey struct{}
+
+// Events registers sinks receiving every event of the plan executions, in registration order.
+func (plan *Plan) Events(sinks ...EventSink) {
+	plan.eventSinks = append(plan.eventSinks, sinks...)
+}
+
+// emit passes event to each sink in turn, one event at a time.
+func (plan *Plan) emit(event Event) {
+	if len(plan.eventSinks) == 0 {
+		return
+	}
+
+	plan.eventMutex.Lock()
+	defer plan.eventMutex.Unlock()
+
+	for _, sink := range plan.eventSinks {
+		sink(event)
+	}
+}
+
+// withEvents returns the context op executes with, carrying the emitters of its events.
+func (plan *Plan) withEvents(ctx context.Context, op operation) context.Context {
+	if len(plan.eventSinks) == 0 {
+		return ctx
+	}
+
+	name := op.operationName()
+
+	ctx = registry.WithBlobObserver(ctx, func(transfer registry.BlobTransfer) {
+		plan.emit(LayerCopied{
+			Operation:  name,
+			Repository: transfer.Repository,
+			Digest:     transfer.Digest,
+			Mounted:    transfer.Mounted,
+		})
+	})
+
+	return context.WithValue(ctx, eventContextKey{}, plan.emit)
+}
+
+// emitEvent emits event to the sinks of the plan executing ctx, if any.
+func emitEvent(ctx context.Context, event Event) {
+	if emit, ok := ctx.Value(eventContextKey{}).(func(Event)); ok {
+		emit(event)
+	}
+}
+
+// emitScanFindings emits a ScanFinding for every vulnerability of results.
+func emitScanFindings(ctx context.Context, operation string, results []trivy.PlatformResult) {
+	if ctx.Value(eventContextKey{}) == nil {
+		return
+	}
+
+	for _, platformResult := range results {
+		if platformResult.Result == nil {
+			continue
+		}
+
+		for _, target := range platformResult.Result.Results {
+			for _, vuln := range target.Vulnerabilities {
+				emitEvent(ctx, ScanFinding{
+					Operation:        operation,
+					Platform:         platformResult.Platform,
+					Target:           target.Target,
+					VulnerabilityID:  vuln.VulnerabilityID,
+					Severity:         vuln.Severity,
+					Package:          vuln.PkgName,
+					InstalledVersion: vuln.InstalledVersion,
+					FixedVersion:     vuln.FixedVersion,
+					Title:            vuln.Title,
+					KnownExploited:   vuln.KnownExploited,
+				})
+			}
+		}
+	}
+}
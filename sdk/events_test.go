@@ -0,0 +1,82 @@
+package sdk_test
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: Event sinks receive the start of an operation, every blob it copies to the destination
+// repository, then its end, all attributed to the operation.
+func TestPlan_Events(t *testing.T) {
+	t.Parallel()
+
+	// Distinct registries: the blobs are transferred
+	var hosts []string
+
+	for range 2 {
+		server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+		t.Cleanup(server.Close)
+
+		serverURL, err := url.Parse(server.URL)
+		if err != nil {
+			t.Fatalf("Failed to parse server URL: %v", err)
+		}
+
+		hosts = append(hosts, serverURL.Host)
+	}
+
+	digest := pushRandomImage(t, hosts[0]+"/source/app:1.0.0")
+
+	plan := sdk.NewPlan(testPlanName)
+
+	source, err := sdk.NewImage("source/app").Domain(hosts[0]).Version("1.0.0").Digest(digest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create source image: %v", err)
+	}
+
+	destination, err := sdk.NewImage("mirror/app").Domain(hosts[1]).Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create destination image: %v", err)
+	}
+
+	if _, err := plan.Sync("mirror").Source(source).Destination(destination).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	var events []sdk.Event
+
+	plan.Events(func(event sdk.Event) {
+		events = append(events, event)
+	})
+
+	if err := plan.Execute(t.Context()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if len(events) < 3 {
+		t.Fatalf("events = %+v, want a start, copied blobs and an end", events)
+	}
+
+	if started, ok := events[0].(sdk.OperationStarted); !ok || started.Operation != "mirror" || started.Kind != "sync" {
+		t.Errorf("first event = %+v, want the start of sync mirror", events[0])
+	}
+
+	if finished, ok := events[len(events)-1].(sdk.OperationFinished); !ok || finished.Operation != "mirror" ||
+		finished.Err != nil {
+		t.Errorf("last event = %+v, want the success of mirror", events[len(events)-1])
+	}
+
+	for _, event := range events[1 : len(events)-1] {
+		copied, ok := event.(sdk.LayerCopied)
+		if !ok || copied.Operation != "mirror" || copied.Repository != hosts[1]+"/mirror/app" || copied.Digest == "" {
+			t.Errorf("event = %+v, want a blob copied to %s/mirror/app by mirror", event, hosts[1])
+		}
+	}
+}
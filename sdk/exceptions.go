@@ -0,0 +1,85 @@
+package sdk
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/farcloser/quark/internal/exceptions"
+)
+
+// exceptionExpiryNotice is how long before their expiry exceptions are reported as expiring.
+const exceptionExpiryNotice = 14 * 24 * time.Hour
+
+// Exceptions loads the waiver file at path (conventionally quark-exceptions.yaml), shared by the scans and
+// audits of the plan: findings it waives (vulnerability or audit check IDs, optionally scoped to images) do not
+// fail them until the exception expires. The file is validated now; expired and soon expiring exceptions are
+// reported as warnings of each execution report.
+func (plan *Plan) Exceptions(path string) error {
+	set, err := exceptions.Load(path)
+	if err != nil {
+		return err
+	}
+
+	plan.exceptions = set
+	plan.exceptionsFile = path
+
+	return nil
+}
+
+// exceptionWarnings returns the warnings about exceptions that expired, or expire soon, at now.
+func (plan *Plan) exceptionWarnings(now time.Time) []string {
+	if plan.exceptions == nil {
+		return nil
+	}
+
+	var warnings []string
+
+	for _, exception := range plan.exceptions.Expired(now) {
+		warnings = append(warnings, fmt.Sprintf("Exception %s (owner: %s) expired on %s: no longer waived",
+			exception.ID, exception.Owner, exception.Expires.Format(time.DateOnly)))
+	}
+
+	for _, exception := range plan.exceptions.Expiring(now, exceptionExpiryNotice) {
+		warnings = append(warnings, fmt.Sprintf("Exception %s (owner: %s) expires on %s",
+			exception.ID, exception.Owner, exception.Expires.Format(time.DateOnly)))
+	}
+
+	return warnings
+}
+
+// waivers returns the exceptions of the plan applicable to image (empty for Dockerfiles) at now.
+func waivers(set *exceptions.Set, image string, now time.Time) []exceptions.Exception {
+	if set == nil {
+		return nil
+	}
+
+	return set.Applicable(image, now)
+}
+
+// waiverIDs returns the IDs of the exceptions.
+func waiverIDs(applicable []exceptions.Exception) []string {
+	ids := make([]string, 0, len(applicable))
+	for _, exception := range applicable {
+		ids = append(ids, exception.ID)
+	}
+
+	return ids
+}
+
+// describeWaiver describes an applied exception in reports.
+func describeWaiver(exception exceptions.Exception) string {
+	return fmt.Sprintf("Waived %s (owner: %s, until %s): %s",
+		exception.ID, exception.Owner, exception.Expires.Format(time.DateOnly), exception.Reason)
+}
+
+// waivedBy returns the exception waiving the finding id, if any.
+func waivedBy(applicable []exceptions.Exception, id string) (exceptions.Exception, bool) {
+	for _, exception := range applicable {
+		if strings.EqualFold(exception.ID, id) {
+			return exception, true
+		}
+	}
+
+	return exceptions.Exception{}, false
+}
@@ -0,0 +1,95 @@
+package sdk_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: Checks waived by the exceptions file do not fail audits, the report lists the applied exceptions,
+// and warns about exceptions that expired or expire soon.
+func TestPlan_Exceptions(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	dockerfile := filepath.Join(dir, "Dockerfile")
+
+	content := "FROM debian:latest\nRUN apt-get update && apt-get install -y curl\n"
+	if err := os.WriteFile(dockerfile, []byte(content), filesystem.FilePermissionsPrivate); err != nil {
+		t.Fatalf("Failed to write Dockerfile: %v", err)
+	}
+
+	soon := time.Now().UTC().AddDate(0, 0, 3).Format(time.DateOnly)
+
+	var waived strings.Builder
+
+	waived.WriteString("exceptions:\n")
+
+	for _, check := range []string{"DL3007", "DL3008", "DL3009", "DL3015", "DL3057"} {
+		fmt.Fprintf(&waived, "  - id: %s\n    reason: legacy image\n    owner: team-platform\n    expires: %s\n",
+			check, soon)
+	}
+
+	waived.WriteString("  - id: CVE-2020-0001\n    reason: fixed upstream\n    owner: team-security\n" +
+		"    expires: 2020-01-01\n")
+
+	path := filepath.Join(dir, "quark-exceptions.yaml")
+	if err := os.WriteFile(path, []byte(waived.String()), filesystem.FilePermissionsPrivate); err != nil {
+		t.Fatalf("Failed to write exceptions: %v", err)
+	}
+
+	plan := sdk.NewPlan(testPlanName)
+
+	if err := plan.Exceptions(path); err != nil {
+		t.Fatalf("Exceptions() error = %v", err)
+	}
+
+	if _, err := plan.Audit("lint").Dockerfile(dockerfile).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	report, err := plan.ExecuteWithResult(t.Context())
+	if err != nil {
+		t.Fatalf("Execute() error = %v, want waived checks to pass", err)
+	}
+
+	lint, _ := report.Operation("lint")
+	if details := strings.Join(lint.Details, "\n"); !strings.Contains(details,
+		"Waived DL3008 (owner: team-platform, until "+soon+"): legacy image") {
+		t.Errorf("Details = %q, want the applied exceptions", details)
+	}
+
+	warnings := strings.Join(report.Warnings, "\n")
+	for _, want := range []string{
+		"Exception CVE-2020-0001 (owner: team-security) expired on 2020-01-01",
+		"Exception DL3008 (owner: team-platform) expires on " + soon,
+	} {
+		if !strings.Contains(warnings, want) {
+			t.Errorf("Warnings = %q, want %q", warnings, want)
+		}
+	}
+}
+
+// INTENTION: Invalid exceptions files are rejected when loaded, before any execution.
+func TestPlan_Exceptions_Invalid(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "quark-exceptions.yaml")
+	content := []byte("exceptions:\n  - id: CVE-2020-0001\n")
+	if err := os.WriteFile(path, content, filesystem.FilePermissionsPrivate); err != nil {
+		t.Fatalf("Failed to write exceptions: %v", err)
+	}
+
+	for _, file := range []string{path, path + ".missing"} {
+		if err := sdk.NewPlan(testPlanName).Exceptions(file); !errors.Is(err, sdk.ErrInvalidExceptions) {
+			t.Errorf("Exceptions(%s) error = %v, want %v", file, err, sdk.ErrInvalidExceptions)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Dependency is an operation other operations of the plan can depend on: the result of an operation
+// builder Build() (e.g., *Sync, *Scan).
+type Dependency interface {
+	operation
+}
+
+// dependencyList declares the operations an operation waits for.
+// Operations embed it; DependsOn builder methods fill it.
+type dependencyList struct {
+	dependsOn []operation
+}
+
+// add appends deps to the list.
+func (list *dependencyList) add(deps []Dependency) {
+	for _, dep := range deps {
+		list.dependsOn = append(list.dependsOn, dep)
+	}
+}
+
+// consume makes the operation wait for the operation producing image, if any (see Sync.OutputImage).
+func (list *dependencyList) consume(image *Image) {
+	if image == nil || image.producer == nil || slices.Contains(list.dependsOn, image.producer) {
+		return
+	}
+
+	list.dependsOn = append(list.dependsOn, image.producer)
+}
+
+// dependencies returns the operations the operation waits for.
+func (list *dependencyList) dependencies() []operation {
+	return list.dependsOn
+}
+
+// clone returns a copy of the list, for builder Clone() methods.
+func (list *dependencyList) clone() dependencyList {
+	return dependencyList{dependsOn: append([]operation(nil), list.dependsOn...)}
+}
+
+// MaxParallelism sets how many operations of the plan run at the same time (default: 1).
+// With a single slot, operations run one after the other in the order they were added. With more, an operation
+// starts as soon as a slot is free and the operations it depends on (DependsOn) completed: independent
+// operations run concurrently. Operations skipped by RunOnlyOn, When or SkipUnchanged count as completed for their
+// dependents.
+// Once an operation fails, no other operation starts; the running ones complete.
+func (plan *Plan) MaxParallelism(limit int) {
+	plan.maxParallelism = limit
+}
+
+// operationOutcome is the result of an operation execution.
+type operationOutcome struct {
+	idx      int
+	status   OperationStatus
+	started  time.Time
+	duration time.Duration
+	err      error
+}
+
+// runOperations executes the operations of the plan, honoring their dependencies, with at most
+// MaxParallelism running at once, then records their outcome in the report, in plan order.
+func (plan *Plan) runOperations(ctx context.Context, env Environment, logDir string) error {
+	ops := plan.operations
+	outcomes := make([]*operationOutcome, len(ops))
+
+	defer func() {
+		for idx, op := range ops {
+			if outcome := outcomes[idx]; outcome != nil {
+				plan.report.add(op, outcome.status, outcome.started, outcome.duration, outcome.err)
+			} else {
+				plan.report.add(op, StatusNotRun, time.Time{}, 0, nil)
+			}
+		}
+	}()
+
+	position := make(map[operation]int, len(ops))
+	for idx, op := range ops {
+		position[op] = idx
+	}
+
+	// Dependencies must be earlier operations of the plan, which also rules out cycles
+	for idx, op := range ops {
+		for _, dep := range op.dependencies() {
+			if depIdx, ok := position[dep]; !ok || depIdx >= idx {
+				return fmt.Errorf("%w: %q depends on %q", ErrDependencyNotInPlan, op.operationName(), dep.operationName())
+			}
+		}
+	}
+
+	parallelism := max(plan.maxParallelism, 1)
+	started := make([]bool, len(ops))
+	done := make(chan operationOutcome)
+
+	var (
+		running int
+		errs    []error
+	)
+
+	for {
+		// Start ready operations in plan order, while slots are free and nothing failed
+		for idx := 0; idx < len(ops) && running < parallelism && len(errs) == 0; idx++ {
+			if started[idx] || !dependenciesCompleted(ops[idx], position, outcomes) {
+				continue
+			}
+
+			started[idx] = true
+			running++
+
+			go func() {
+				done <- plan.runOperation(ctx, idx, ops[idx], env, logDir)
+			}()
+		}
+
+		if running == 0 {
+			break
+		}
+
+		outcome := <-done
+		running--
+		outcomes[outcome.idx] = &outcome
+
+		if outcome.status == StatusFailed {
+			errs = append(errs, outcome.err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// dependenciesCompleted reports whether the operations op depends on completed without failure.
+func dependenciesCompleted(op operation, position map[operation]int, outcomes []*operationOutcome) bool {
+	for _, dep := range op.dependencies() {
+		outcome := outcomes[position[dep]]
+		if outcome == nil || outcome.status == StatusFailed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// runOperation executes op (the operation at idx in the plan), unless it is restricted to other environments.
+func (plan *Plan) runOperation(
+	ctx context.Context,
+	idx int,
+	op operation,
+	env Environment,
+	logDir string,
+) operationOutcome {
+	if !op.runsOn(env) {
+		plan.log.Info().
+			Str("operation", op.operationName()).
+			Str("environment", string(env)).
+			Interface("run_only_on", op.environments()).
+			Msg("skipping operation restricted to other environments")
+
+		return operationOutcome{idx: idx, status: StatusSkipped}
+	}
+
+	if plan.unchanged(op) {
+		plan.log.Info().
+			Str("operation", op.operationName()).
+			Msg("skipping operation unchanged since the last successful execution")
+
+		return operationOutcome{idx: idx, status: StatusUnchanged}
+	}
+
+	started := time.Now().UTC()
+
+	run, err := conditionsHold(ctx, op)
+	if err == nil && !run {
+		plan.log.Info().Str("operation", op.operationName()).Msg("skipping operation whose conditions do not hold")
+
+		return operationOutcome{idx: idx, status: StatusSkipped}
+	}
+
+	plan.notifyStart(op, started)
+
+	if err == nil {
+		err = plan.confirm(ctx, op)
+	}
+
+	if err == nil {
+		err = plan.executeScoped(plan.withEvents(ctx, op), op, logDir)
+	}
+
+	duration := time.Since(started)
+	plan.notifyDone(op, started, duration, err)
+
+	if err != nil {
+		return operationOutcome{idx: idx, status: StatusFailed, started: started, duration: duration, err: err}
+	}
+
+	return operationOutcome{idx: idx, status: StatusSucceeded, started: started, duration: duration}
+}
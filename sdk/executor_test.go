@@ -0,0 +1,106 @@
+package sdk_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: With parallelism, independent operations run even when another fails, operations depending on
+// a failed one do not run, and the report keeps the plan order.
+func TestPlan_MaxParallelism(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+	digest := pushRandomImage(t, host+"/source/app:1.0.0")
+
+	plan := sdk.NewPlan("parallel")
+	plan.MaxParallelism(4)
+
+	syncTo := func(name, sourceDigest string, deps ...sdk.Dependency) *sdk.Sync {
+		t.Helper()
+
+		source, err := sdk.NewImage("source/app").Domain(host).Version("1.0.0").Digest(sourceDigest).Build()
+		if err != nil {
+			t.Fatalf("Failed to create source image: %v", err)
+		}
+
+		destination, err := sdk.NewImage("mirror/" + name).Domain(host).Version("1.0.0").Build()
+		if err != nil {
+			t.Fatalf("Failed to create destination image: %v", err)
+		}
+
+		sync, err := plan.Sync(name).Source(source).Destination(destination).DependsOn(deps...).Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		return sync
+	}
+
+	// Not pushed: the sync fails, and its dependent does not run
+	missing := syncTo("missing", testDigest)
+	syncTo("dependent", digest, missing)
+	syncTo("independent", digest)
+
+	if err := plan.Execute(t.Context()); err == nil {
+		t.Fatal("Execute() should fail")
+	}
+
+	want := map[string]sdk.OperationStatus{
+		"missing":     sdk.StatusFailed,
+		"dependent":   sdk.StatusNotRun,
+		"independent": sdk.StatusSucceeded,
+	}
+
+	operations := plan.Report().Operations
+	if len(operations) != len(want) {
+		t.Fatalf("Report() = %d operations, want %d", len(operations), len(want))
+	}
+
+	for idx, name := range []string{"missing", "dependent", "independent"} {
+		if operations[idx].Name != name || operations[idx].Status != want[name] {
+			t.Errorf("Report().Operations[%d] = %s %s, want %s %s",
+				idx, operations[idx].Name, operations[idx].Status, name, want[name])
+		}
+	}
+}
+
+// INTENTION: Dependencies must be operations built before in the same plan.
+func TestPlan_DependencyNotInPlan(t *testing.T) {
+	t.Parallel()
+
+	other, err := newTestSync(t, sdk.NewPlan("other"), "other").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	plan := sdk.NewPlan(testPlanName)
+
+	if _, err := newTestSync(t, plan, "mirror").DependsOn(other).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if err := plan.Execute(t.Context()); !errors.Is(err, sdk.ErrDependencyNotInPlan) {
+		t.Errorf("Execute() error = %v, want %v", err, sdk.ErrDependencyNotInPlan)
+	}
+
+	if status := plan.Report().Operations[0].Status; status != sdk.StatusNotRun {
+		t.Errorf("Report().Operations[0].Status = %s, want %s", status, sdk.StatusNotRun)
+	}
+}
@@ -0,0 +1,44 @@
+package sdk
+
+import (
+	"context"
+	"sync"
+
+	"github.com/farcloser/quark/internal/trivy"
+)
+
+// KnownExploitedFeed sets where scans read the catalog of vulnerabilities known to be exploited in the wild, in
+// the CISA KEV format: an http(s) URL (e.g., an internal mirror) or a local file, for air-gapped runners.
+// Setting it enriches the findings of every scan of the plan; scans with FailOnKnownExploited use the CISA
+// catalog when it is unset.
+func (plan *Plan) KnownExploitedFeed(location string) {
+	plan.knownExploitedFeed = location
+}
+
+// exploitCatalog loads the known exploited catalog on first use, once for all the scans of an execution.
+type exploitCatalog struct {
+	location string
+
+	once    sync.Once
+	catalog *trivy.KnownExploited
+	err     error
+}
+
+// newExploitCatalog returns the catalog of the plan, not loaded yet.
+func (plan *Plan) newExploitCatalog() *exploitCatalog {
+	location := plan.knownExploitedFeed
+	if location == "" {
+		location = trivy.KnownExploitedFeedURL
+	}
+
+	return &exploitCatalog{location: location}
+}
+
+// load returns the catalog, loading it on the first call.
+func (exploits *exploitCatalog) load(ctx context.Context) (*trivy.KnownExploited, error) {
+	exploits.once.Do(func() {
+		exploits.catalog, exploits.err = trivy.LoadKnownExploited(ctx, exploits.location)
+	})
+
+	return exploits.catalog, exploits.err
+}
@@ -0,0 +1,149 @@
+package sdk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// unavailableFile is the fingerprint of a local file that cannot be read (missing, or not accessible).
+const unavailableFile = "unavailable"
+
+// Fingerprint returns a stable hash of the plan ("sha256:<hex>"): its name, its operations in order with their
+// settings (images with their digests, platforms, rule sets, severity checks...), and the content of the local
+// files they read (build contexts, Dockerfiles, compose files, Kubernetes manifests, artifact files, READMEs,
+// cosign keys, trust policies and the exceptions file). Two plans with the same fingerprint do the same thing, so
+// CI can skip a run when the fingerprint matches the one of the state of the last run (PlanState.Fingerprint).
+// Images referenced by tag alone are hashed by tag: pin their digest for the fingerprint to follow the registry.
+func (plan *Plan) Fingerprint() string {
+	return plan.State().Fingerprint
+}
+
+// fingerprintInput is what a plan fingerprint hashes. JSON objects are encoded with their keys sorted.
+type fingerprintInput struct {
+	Plan       string           `json:"plan"`
+	Operations []OperationState `json:"operations"`
+	// Files are the fingerprints of the local files and directories read by the operations, by path
+	Files map[string]string `json:"files,omitempty"`
+}
+
+// fingerprint returns the fingerprint of the plan with the operations of its state.
+func (plan *Plan) fingerprint(operations []OperationState) string {
+	input := fingerprintInput{Plan: plan.name, Operations: operations, Files: map[string]string{}}
+
+	paths := []string{plan.exceptionsFile}
+	for _, op := range plan.operations {
+		paths = append(paths, operationFiles(op)...)
+	}
+
+	for _, path := range paths {
+		if _, done := input.Files[path]; path != "" && !done {
+			input.Files[path] = pathFingerprint(path)
+		}
+	}
+
+	hasher := sha256.New()
+	// Encoding strings and maps of strings cannot fail
+	_ = json.NewEncoder(hasher).Encode(input)
+
+	return "sha256:" + hex.EncodeToString(hasher.Sum(nil))
+}
+
+// operationFiles returns the local files and directories op reads.
+func operationFiles(op operation) []string {
+	switch typed := op.(type) {
+	case *Build:
+		// The Dockerfile is in the context
+		return []string{typed.context}
+	case *Audit:
+		return []string{typed.dockerfile}
+	case *BaseImageCheck:
+		return []string{typed.dockerfile}
+	case *PinBaseImages:
+		return []string{typed.dockerfile}
+	case *ComposeImages:
+		return []string{typed.file}
+	case *KubernetesManifests:
+		return typed.files
+	case *Artifact:
+		paths := make([]string, 0, len(typed.files))
+		for _, file := range typed.files {
+			paths = append(paths, file.path)
+		}
+
+		return paths
+	case *RepositoryDocs:
+		return []string{typed.readme}
+	case *Verify:
+		// URLs and KMS URIs are not local files: their settings are hashed
+		return []string{typed.key, typed.policy}
+	default:
+		return nil
+	}
+}
+
+// pathFingerprint returns the hash of the content of the file at path, or of the names and content of the
+// files in the directory at path, or unavailableFile when it cannot be read.
+func pathFingerprint(path string) string {
+	hasher := sha256.New()
+
+	err := filepath.WalkDir(path, func(current string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(path, current)
+		if err != nil {
+			return err //nolint:wrapcheck // Reported as an unavailable file
+		}
+
+		switch {
+		case entry.Type().IsRegular():
+			_, _ = io.WriteString(hasher, "file "+filepath.ToSlash(relative)+"\x00")
+
+			return hashFile(hasher, current)
+		case entry.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(current)
+			if err != nil {
+				return err //nolint:wrapcheck // Reported as an unavailable file
+			}
+
+			_, _ = io.WriteString(hasher, "link "+filepath.ToSlash(relative)+"\x00"+target+"\x00")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return unavailableFile
+	}
+
+	return "sha256:" + hex.EncodeToString(hasher.Sum(nil))
+}
+
+// hashFile writes the content of the file at path to hasher, followed by its length.
+func hashFile(hasher hash.Hash, path string) error {
+	//nolint:gosec // File paths are from plan configuration
+	file, err := os.Open(path)
+	if err != nil {
+		return err //nolint:wrapcheck // Reported as an unavailable file
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	size, err := io.Copy(hasher, file)
+	if err != nil {
+		return err //nolint:wrapcheck // Reported as an unavailable file
+	}
+
+	_, _ = io.WriteString(hasher, "\x00"+strconv.FormatInt(size, 10)+"\x00")
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package sdk_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: The fingerprint of a plan is the same for identical plans, and changes with the digest of its images
+// and with the content of the build context; the plan state records it.
+func TestPlan_Fingerprint(t *testing.T) {
+	t.Parallel()
+
+	buildContext := t.TempDir()
+	dockerfile := filepath.Join(buildContext, "Dockerfile")
+
+	if err := os.WriteFile(dockerfile, []byte("FROM alpine:3.20\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write Dockerfile: %v", err)
+	}
+
+	newPlan := func(digest string) *sdk.Plan {
+		t.Helper()
+
+		plan := sdk.NewPlan(testPlanName)
+
+		image, err := sdk.NewImage("my-org/app").Domain("ghcr.io").Version("1.0.0").Digest(digest).Build()
+		if err != nil {
+			t.Fatalf("Failed to create test image: %v", err)
+		}
+
+		if _, err := plan.Rollback("rollback-app").Image(image).ToDigest(testDigest).Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		node, err := plan.BuildNode("node").Endpoint("ssh://builder@192.168.1.100").Platform(sdk.PlatformAMD64).Build()
+		if err != nil {
+			t.Fatalf("Failed to create test node: %v", err)
+		}
+
+		if _, err := plan.Build("build-app").Context(buildContext).Node(node).Tag("myapp:latest").Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		return plan
+	}
+
+	fingerprint := newPlan(testDigest).Fingerprint()
+	if !strings.HasPrefix(fingerprint, "sha256:") {
+		t.Fatalf("Fingerprint() = %q, want a sha256 hash", fingerprint)
+	}
+
+	if again := newPlan(testDigest).Fingerprint(); again != fingerprint {
+		t.Errorf("Fingerprint() = %q, want the fingerprint of the identical plan %q", again, fingerprint)
+	}
+
+	if state := newPlan(testDigest).State(); state.Fingerprint != fingerprint {
+		t.Errorf("State().Fingerprint = %q, want %q", state.Fingerprint, fingerprint)
+	}
+
+	otherDigest := "sha256:" + strings.Repeat("b", 64)
+	if changed := newPlan(otherDigest).Fingerprint(); changed == fingerprint {
+		t.Errorf("Fingerprint() = %q after an image digest change, want a different fingerprint", changed)
+	}
+
+	if err := os.WriteFile(dockerfile, []byte("FROM alpine:3.21\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write Dockerfile: %v", err)
+	}
+
+	if changed := newPlan(testDigest).Fingerprint(); changed == fingerprint {
+		t.Errorf("Fingerprint() = %q after a Dockerfile change, want a different fingerprint", changed)
+	}
+}
@@ -0,0 +1,251 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// Flatten represents squashing the layers of an image into a single layer holding its final filesystem, keeping
+// its config, and pushing it under another tag: for consumers limited in layer count, and to drop files (e.g.,
+// build secrets) deleted by upper layers but still readable in the layers below.
+type Flatten struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	image        *Image
+	registry     *Registry
+	destImage    *Image
+	destRegistry *Registry
+
+	// Results populated after execution
+	digest    string
+	flattened bool
+}
+
+// FlattenBuilder builds a Flatten.
+type FlattenBuilder struct {
+	builderState
+
+	plan    *Plan
+	flatten *Flatten
+}
+
+// Image sets the image to flatten (e.g., ghcr.io/my-org/app:1.0.0), by tag or digest. When it is the output of
+// another operation (see Build.OutputImage), the flattening depends on it.
+// Registry credentials are looked up from the plan's registry collection using the image domain.
+func (builder *FlattenBuilder) Image(image *Image) *FlattenBuilder {
+	builder.flatten.image = image
+	builder.flatten.consume(image)
+	builder.flatten.registry = builder.plan.getRegistry(image.Domain())
+
+	return builder
+}
+
+// Destination sets the image the flattened image is pushed to (e.g., ghcr.io/my-org/app:1.0.0-flat).
+// The destination must have a version.
+func (builder *FlattenBuilder) Destination(image *Image) *FlattenBuilder {
+	builder.flatten.destImage = image
+	builder.flatten.destRegistry = builder.plan.getRegistry(image.Domain())
+
+	return builder
+}
+
+// RunOnlyOn restricts the flattening to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *FlattenBuilder) RunOnlyOn(envs ...Environment) *FlattenBuilder {
+	builder.flatten.runOnlyOn = append(builder.flatten.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the flattening mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *FlattenBuilder) Resource(resource Resource) *FlattenBuilder {
+	builder.flatten.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the flattening start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *FlattenBuilder) DependsOn(ops ...Dependency) *FlattenBuilder {
+	builder.flatten.add(ops)
+
+	return builder
+}
+
+// When makes the flattening run only if the given conditions all hold once the operations it depends on
+// completed; otherwise it is skipped. A failing condition fails the flattening.
+func (builder *FlattenBuilder) When(conditions ...Condition) *FlattenBuilder {
+	builder.flatten.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a flattening named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *FlattenBuilder) Clone(name string) *FlattenBuilder {
+	clone := builder.plan.Flatten(name)
+	clone.flatten.envGuard = builder.flatten.envGuard.clone()
+	clone.flatten.resourceHint = builder.flatten.resourceHint
+	clone.flatten.dependencyList = builder.flatten.dependencyList.clone()
+	clone.flatten.conditionList = builder.flatten.conditionList.clone()
+	clone.flatten.image = builder.flatten.image
+	clone.flatten.registry = builder.flatten.registry
+	clone.flatten.destImage = builder.flatten.destImage
+	clone.flatten.destRegistry = builder.flatten.destRegistry
+
+	return clone
+}
+
+// Reset makes the builder usable again for a flattening named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *FlattenBuilder) Reset(name string) *FlattenBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build validates and adds the flattening to the plan.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *FlattenBuilder) Build() (*Flatten, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.flatten.opName); err != nil {
+		return nil, err
+	}
+
+	flatten := builder.flatten
+
+	if flatten.image == nil {
+		return nil, ErrFlattenImageRequired
+	}
+
+	if flatten.destImage == nil {
+		return nil, ErrFlattenDestinationRequired
+	}
+
+	for _, image := range []*Image{flatten.image, flatten.destImage} {
+		if err := image.checkRegistry(); err != nil {
+			return nil, err
+		}
+	}
+
+	if flatten.destImage.Version() == "" {
+		return nil, fmt.Errorf("%w for image %q", ErrFlattenVersionRequired, flatten.destImage.Name())
+	}
+
+	builder.plan.flattens = append(builder.plan.flattens, flatten)
+	builder.plan.addOperation(flatten)
+
+	return flatten, nil
+}
+
+func (flatten *Flatten) execute(ctx context.Context) error {
+	ref, err := flatten.image.pullRef()
+	if err != nil {
+		return fmt.Errorf("failed to build image reference: %w", err)
+	}
+
+	destRef, err := flatten.destImage.tagRef()
+	if err != nil {
+		return fmt.Errorf("failed to build destination reference: %w", err)
+	}
+
+	flatten.log.Info().
+		Str("image", ref).
+		Str("destination", destRef).
+		Msg("flattening image")
+
+	image := registry.RemoteImage{Ref: ref, Client: newRegistryClient(flatten.registry, flatten.log)}
+
+	previous, err := image.Client.GetDigest(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("failed to get image digest: %w", err)
+	}
+
+	flattened, err := newRegistryClient(flatten.destRegistry, flatten.log).Flatten(ctx, image, destRef)
+	if err != nil {
+		return fmt.Errorf("failed to flatten %s: %w", ref, err)
+	}
+
+	flatten.digest = flattened
+	flatten.flattened = flattened != previous
+	// Subsequent operations (e.g., scanning) see the flattened image
+	flatten.destImage.ref.Digest = digest.Digest(flattened)
+
+	flatten.log.Info().
+		Str("destination", destRef).
+		Str("digest", flattened).
+		Bool("flattened", flatten.flattened).
+		Msg("flatten complete")
+
+	return nil
+}
+
+// plannedChanges implements dryRunOperation: the image must exist, when its digest is known.
+func (flatten *Flatten) plannedChanges(ctx context.Context) ([]string, error) {
+	imageRef, err := checkImage(ctx, newRegistryClient(flatten.registry, flatten.log), flatten.image)
+	if err != nil {
+		return nil, err
+	}
+
+	destRef, err := flatten.destImage.tagRef()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build destination reference: %w", err)
+	}
+
+	return []string{fmt.Sprintf("Would flatten %s into a single layer and push it to %s", imageRef, destRef)}, nil
+}
+
+// destructiveChange implements destructiveOperation: a flattening overwrites the destination tag.
+func (flatten *Flatten) destructiveChange(ctx context.Context) (string, error) {
+	destRef, err := flatten.destImage.tagRef()
+	if err != nil {
+		return "", fmt.Errorf("failed to build destination reference: %w", err)
+	}
+
+	return tagOverwrite(ctx, newRegistryClient(flatten.destRegistry, flatten.log), destRef, "")
+}
+
+// Digest returns the digest of the flattened image (empty before execution).
+func (flatten *Flatten) Digest() string {
+	return flatten.digest
+}
+
+// Flattened reports whether the execution squashed layers (false when the image had a single layer per platform
+// already, and was pushed as is).
+func (flatten *Flatten) Flattened() bool {
+	return flatten.flattened
+}
+
+// cacheSources implements cacheableOperation: a flattening reads the flattened image.
+func (flatten *Flatten) cacheSources() []*Image {
+	return []*Image{flatten.image}
+}
+
+// producedDigest implements producingOperation.
+func (flatten *Flatten) producedDigest() string {
+	return flatten.Digest()
+}
+
+// destination implements destinationOperation: the destination image.
+func (flatten *Flatten) destination() *Image {
+	return flatten.destImage
+}
+
+// resolveRegistries implements registryOperation.
+func (flatten *Flatten) resolveRegistries(lookup func(image *Image) *Registry) {
+	flatten.registry = lookup(flatten.image)
+	flatten.destRegistry = lookup(flatten.destImage)
+}
@@ -0,0 +1,188 @@
+package sdk_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: A flattening requires the image and a destination with a tag to push the flattened image to.
+func TestFlattenBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	app, err := sdk.NewImage("my-org/app").Domain("ghcr.io").Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	flat, err := sdk.NewImage("my-org/app").Domain("ghcr.io").Version("1.0.0-flat").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	pinned, err := sdk.NewImage("my-org/app").Domain("ghcr.io").Digest(testDigest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		build   func(*sdk.Plan) (*sdk.Flatten, error)
+		wantErr error
+	}{
+		{
+			name: "valid",
+			build: func(plan *sdk.Plan) (*sdk.Flatten, error) {
+				return plan.Flatten("flatten").Image(pinned).Destination(flat).Build()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "missing image",
+			build: func(plan *sdk.Plan) (*sdk.Flatten, error) {
+				return plan.Flatten("flatten").Destination(flat).Build()
+			},
+			wantErr: sdk.ErrFlattenImageRequired,
+		},
+		{
+			name: "missing destination",
+			build: func(plan *sdk.Plan) (*sdk.Flatten, error) {
+				return plan.Flatten("flatten").Image(app).Build()
+			},
+			wantErr: sdk.ErrFlattenDestinationRequired,
+		},
+		{
+			name: "pinned destination",
+			build: func(plan *sdk.Plan) (*sdk.Flatten, error) {
+				return plan.Flatten("flatten").Image(app).Destination(pinned).Build()
+			},
+			wantErr: sdk.ErrFlattenVersionRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			flatten, err := tt.build(sdk.NewPlan(testPlanName))
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil || flatten == nil {
+				t.Errorf("Build() = %v, %v, want a flattening", flatten, err)
+			}
+		})
+	}
+}
+
+// INTENTION: Executing a flattening pushes a single-layer image to the destination and updates the destination
+// digest for subsequent operations.
+func TestFlatten_Execute(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	layered, err := random.Image(256, 3)
+	if err != nil {
+		t.Fatalf("Failed to create random image: %v", err)
+	}
+
+	appRef, err := name.ParseReference(host + "/my-org/app:1.0.0")
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+
+	if err := remote.Write(appRef, layered); err != nil {
+		t.Fatalf("Failed to push image: %v", err)
+	}
+
+	app, err := sdk.NewImage("my-org/app").Domain(host).Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	flat, err := sdk.NewImage("my-org/app").Domain(host).Version("1.0.0-flat").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	plan := sdk.NewPlan(testPlanName)
+
+	flatten, err := plan.Flatten("flatten").Image(app).Destination(flat).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if err := plan.Execute(t.Context()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if !flatten.Flattened() || flat.Digest() != flatten.Digest() {
+		t.Errorf("Flattened() = %v, Digest() = %q, destination digest = %q", flatten.Flattened(), flatten.Digest(),
+			flat.Digest())
+	}
+
+	ref, err := name.ParseReference(host + "/my-org/app:1.0.0-flat")
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+
+	img, err := remote.Image(ref)
+	if err != nil {
+		t.Fatalf("Failed to get flattened image: %v", err)
+	}
+
+	if layers, err := img.Layers(); err != nil || len(layers) != 1 {
+		t.Errorf("Layers() = %d, %v, want a single layer", len(layers), err)
+	}
+}
+
+// INTENTION: Flattening the output of a build makes the flattening depend on the build.
+func TestFlattenBuilder_ConsumesBuildOutput(t *testing.T) {
+	t.Parallel()
+
+	plan := sdk.NewPlan(testPlanName)
+
+	node, err := plan.BuildNode("node").Endpoint("ssh://builder@192.168.1.100").Platform(sdk.PlatformAMD64).Build()
+	if err != nil {
+		t.Fatalf("Failed to create test node: %v", err)
+	}
+
+	build, err := plan.Build("build-app").Context("/path/to/context").Node(node).Tag("ghcr.io/my-org/app:1.0.0").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	flat, err := sdk.NewImage("my-org/app").Domain("ghcr.io").Version("1.0.0-flat").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	if _, err := plan.Flatten("flatten").Image(build.OutputImage()).Destination(flat).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if got := plan.State().Operations[1].Settings["depends on"]; got != "build-app" {
+		t.Errorf("depends on = %q, want the build", got)
+	}
+}
@@ -0,0 +1,405 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/farcloser/quark/internal/ghcr"
+)
+
+// PackageVisibility represents the visibility of a GHCR package.
+type PackageVisibility struct {
+	value string
+}
+
+//nolint:gochecknoglobals // PackageVisibility enum pattern requires global variables
+var (
+	// PackagePublic makes the package pullable anonymously.
+	PackagePublic = PackageVisibility{"public"}
+	// PackagePrivate restricts the package to the users and teams granted access.
+	PackagePrivate = PackageVisibility{"private"}
+	// PackageInternal restricts the package to the members of the enterprise (GitHub Enterprise Cloud).
+	PackageInternal = PackageVisibility{"internal"}
+)
+
+// String returns the string representation of the visibility.
+func (v *PackageVisibility) String() string {
+	return v.value
+}
+
+// parsePackageVisibility parses a package visibility name ("public", "private" or "internal").
+func parsePackageVisibility(name string) (PackageVisibility, error) {
+	switch strings.ToLower(name) {
+	case "public":
+		return PackagePublic, nil
+	case "private":
+		return PackagePrivate, nil
+	case "internal":
+		return PackageInternal, nil
+	default:
+		return PackageVisibility{}, fmt.Errorf("%w: %q (valid: public, private, internal)",
+			ErrInvalidPackageVisibility, name)
+	}
+}
+
+// GHCRPackage represents managing the metadata of a GHCR package pushed by a build or sync: its linked repository,
+// description and labels, and its visibility.
+type GHCRPackage struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	image       *Image
+	registry    *Registry
+	repository  string
+	description string
+	labels      map[string]string
+	visibility  PackageVisibility
+	token       string
+	apiURL      string
+
+	// Results populated after execution
+	digest    string
+	annotated bool
+	linked    string
+}
+
+// GHCRPackageBuilder builds a GHCRPackage.
+type GHCRPackageBuilder struct {
+	builderState
+
+	plan *Plan
+	pkg  *GHCRPackage
+}
+
+// Image sets the package image (e.g., ghcr.io/my-org/app:1.0.0), typically the output of a build or the
+// destination of a sync the operation depends on. The image must have a version (the tag to annotate).
+// Registry credentials are looked up from the plan's registry collection using the image domain.
+func (builder *GHCRPackageBuilder) Image(image *Image) *GHCRPackageBuilder {
+	builder.pkg.image = image
+	builder.pkg.registry = builder.plan.getRegistry(image.Domain())
+
+	return builder
+}
+
+// Repository links the package to a GitHub repository ("owner/name"), which grants the repository workflows
+// access to the package and shows its README on the package page.
+func (builder *GHCRPackageBuilder) Repository(repository string) *GHCRPackageBuilder {
+	builder.pkg.repository = repository
+
+	return builder
+}
+
+// Description sets the package description shown on the package page.
+func (builder *GHCRPackageBuilder) Description(description string) *GHCRPackageBuilder {
+	builder.pkg.description = description
+
+	return builder
+}
+
+// Label adds a package label (e.g., "org.opencontainers.image.licenses", "Apache-2.0").
+func (builder *GHCRPackageBuilder) Label(key, value string) *GHCRPackageBuilder {
+	builder.pkg.labels[key] = value
+
+	return builder
+}
+
+// Visibility sets the visibility the package must have. GitHub has no API to change it: execution fails with the
+// package settings page when the package has another visibility, so it is changed once by hand instead of
+// being pushed with the wrong one.
+func (builder *GHCRPackageBuilder) Visibility(visibility PackageVisibility) *GHCRPackageBuilder {
+	builder.pkg.visibility = visibility
+
+	return builder
+}
+
+// Token sets the GitHub token the package is read with (default: the GITHUB_TOKEN environment variable).
+// Only needed for Visibility and Repository.
+func (builder *GHCRPackageBuilder) Token(token string) *GHCRPackageBuilder {
+	builder.pkg.token = token
+	secretValues.Add(token)
+
+	return builder
+}
+
+// APIURL sets the GitHub API root (default: "https://api.github.com"), e.g., for GitHub Enterprise Server.
+func (builder *GHCRPackageBuilder) APIURL(apiURL string) *GHCRPackageBuilder {
+	builder.pkg.apiURL = apiURL
+
+	return builder
+}
+
+// RunOnlyOn restricts the package management to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *GHCRPackageBuilder) RunOnlyOn(envs ...Environment) *GHCRPackageBuilder {
+	builder.pkg.runOnlyOn = append(builder.pkg.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the package management mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *GHCRPackageBuilder) Resource(resource Resource) *GHCRPackageBuilder {
+	builder.pkg.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the package management start only once the given operations, built before it in the plan,
+// completed (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *GHCRPackageBuilder) DependsOn(ops ...Dependency) *GHCRPackageBuilder {
+	builder.pkg.add(ops)
+
+	return builder
+}
+
+// When makes the package management run only if the given conditions all hold once the operations it depends on
+// completed; otherwise it is skipped. A failing condition fails the package management.
+func (builder *GHCRPackageBuilder) When(conditions ...Condition) *GHCRPackageBuilder {
+	builder.pkg.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a package management named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *GHCRPackageBuilder) Clone(name string) *GHCRPackageBuilder {
+	clone := builder.plan.GHCRPackage(name)
+	clone.pkg.envGuard = builder.pkg.envGuard.clone()
+	clone.pkg.resourceHint = builder.pkg.resourceHint
+	clone.pkg.dependencyList = builder.pkg.dependencyList.clone()
+	clone.pkg.conditionList = builder.pkg.conditionList.clone()
+	clone.pkg.image = builder.pkg.image
+	clone.pkg.registry = builder.pkg.registry
+	clone.pkg.repository = builder.pkg.repository
+	clone.pkg.description = builder.pkg.description
+	clone.pkg.labels = maps.Clone(builder.pkg.labels)
+	clone.pkg.visibility = builder.pkg.visibility
+	clone.pkg.token = builder.pkg.token
+	clone.pkg.apiURL = builder.pkg.apiURL
+
+	return clone
+}
+
+// Reset makes the builder usable again for a package management named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *GHCRPackageBuilder) Reset(name string) *GHCRPackageBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build validates and adds the package management to the plan.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *GHCRPackageBuilder) Build() (*GHCRPackage, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.pkg.opName); err != nil {
+		return nil, err
+	}
+
+	pkg := builder.pkg
+
+	if pkg.image == nil {
+		return nil, ErrGHCRImageRequired
+	}
+
+	if pkg.image.Version() == "" {
+		return nil, fmt.Errorf("%w for image %q", ErrGHCRVersionRequired, pkg.image.Name())
+	}
+
+	if !strings.Contains(pkg.image.Path(), "/") {
+		return nil, fmt.Errorf("%w: %q has no owner", ErrInvalidGHCRImage, pkg.image.Path())
+	}
+
+	if pkg.repository != "" {
+		owner, name, found := strings.Cut(pkg.repository, "/")
+		if !found || owner == "" || name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("%w: %q (expected owner/name)", ErrInvalidGHCRRepository, pkg.repository)
+		}
+	}
+
+	if len(pkg.annotations()) == 0 && pkg.visibility == (PackageVisibility{}) {
+		return nil, ErrGHCRSettingsRequired
+	}
+
+	builder.plan.ghcrPackages = append(builder.plan.ghcrPackages, pkg)
+	builder.plan.addOperation(pkg)
+
+	return pkg, nil
+}
+
+// annotations returns the manifest annotations GHCR reads the package metadata from.
+func (pkg *GHCRPackage) annotations() map[string]string {
+	annotations := maps.Clone(pkg.labels)
+
+	if pkg.repository != "" {
+		annotations[ghcr.AnnotationSource] = "https://github.com/" + pkg.repository
+	}
+
+	if pkg.description != "" {
+		annotations[ghcr.AnnotationDescription] = pkg.description
+	}
+
+	return annotations
+}
+
+// owner returns the package owner and name (e.g., "my-org" and "team/app" for ghcr.io/my-org/team/app).
+func (pkg *GHCRPackage) owner() (string, string) {
+	owner, name, _ := strings.Cut(pkg.image.Path(), "/")
+
+	return owner, name
+}
+
+// client returns a GitHub API client.
+func (pkg *GHCRPackage) client() *ghcr.Client {
+	token := pkg.token
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+
+	client := ghcr.NewClient(token)
+	if pkg.apiURL != "" {
+		client.WithAPIURL(pkg.apiURL)
+	}
+
+	return client
+}
+
+func (pkg *GHCRPackage) execute(ctx context.Context) error {
+	tagRef, err := pkg.image.tagRef()
+	if err != nil {
+		return fmt.Errorf("failed to build package reference: %w", err)
+	}
+
+	if annotations := pkg.annotations(); len(annotations) > 0 {
+		pkg.log.Info().Str("image", tagRef).Int("annotations", len(annotations)).Msg("annotating package")
+
+		client := newRegistryClient(pkg.registry, pkg.log)
+
+		previous, err := client.GetDigest(ctx, tagRef)
+		if err != nil {
+			return fmt.Errorf("failed to get package digest: %w", err)
+		}
+
+		annotated, err := client.Annotate(ctx, tagRef, annotations)
+		if err != nil {
+			return fmt.Errorf("failed to annotate package: %w", err)
+		}
+
+		pkg.digest = annotated
+		pkg.annotated = annotated != previous
+		// Subsequent operations reference the annotated manifest
+		pkg.image.ref.Digest = digest.Digest(annotated)
+	}
+
+	if pkg.visibility != (PackageVisibility{}) || pkg.repository != "" {
+		if err := pkg.checkPackage(ctx); err != nil {
+			return err
+		}
+	}
+
+	pkg.log.Info().
+		Str("image", tagRef).
+		Str("digest", pkg.digest).
+		Bool("annotated", pkg.annotated).
+		Str("linked_repository", pkg.linked).
+		Msg("package ready")
+
+	return nil
+}
+
+// checkPackage reads the package from the GitHub API: its visibility must be the expected one.
+func (pkg *GHCRPackage) checkPackage(ctx context.Context) error {
+	owner, name := pkg.owner()
+
+	found, err := pkg.client().Package(ctx, owner, name)
+	if err != nil {
+		return fmt.Errorf("failed to get package: %w", err)
+	}
+
+	pkg.linked = found.LinkedRepository()
+
+	if pkg.visibility != (PackageVisibility{}) && found.Visibility != pkg.visibility.value {
+		return fmt.Errorf("%w: %s/%s is %s, want %s (change it at %s)", ErrGHCRVisibilityMismatch,
+			owner, name, found.Visibility, pkg.visibility.value, found.SettingsURL())
+	}
+
+	// GitHub links the package on push: it is not linked when the repository is in another organization
+	if pkg.repository != "" && !strings.EqualFold(pkg.linked, pkg.repository) {
+		pkg.log.Warn().
+			Str("repository", pkg.repository).
+			Str("linked_repository", pkg.linked).
+			Msg("package not linked to the repository, link it from the package page")
+	}
+
+	return nil
+}
+
+// plannedChanges implements dryRunOperation: the package must exist.
+func (pkg *GHCRPackage) plannedChanges(ctx context.Context) ([]string, error) {
+	tagRef, err := pkg.image.tagRef()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build package reference: %w", err)
+	}
+
+	var changes []string
+
+	if annotations := pkg.annotations(); len(annotations) > 0 {
+		current, err := newRegistryClient(pkg.registry, pkg.log).GetAnnotations(ctx, tagRef)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get package annotations: %w", err)
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(annotations)) {
+			if current[key] != annotations[key] {
+				changes = append(changes, fmt.Sprintf("Would annotate %s with %s=%s", tagRef, key, annotations[key]))
+			}
+		}
+	}
+
+	if pkg.visibility != (PackageVisibility{}) {
+		changes = append(changes, "Would check the package is "+pkg.visibility.value)
+	}
+
+	return changes, nil
+}
+
+// Digest returns the digest of the package manifest once annotated (empty before execution, or without
+// annotations to apply).
+func (pkg *GHCRPackage) Digest() string {
+	return pkg.digest
+}
+
+// Annotated reports whether the execution pushed an annotated manifest (false when the package already had the
+// annotations).
+func (pkg *GHCRPackage) Annotated() bool {
+	return pkg.annotated
+}
+
+// LinkedRepository returns the repository the package is linked to ("owner/name"), as read by the execution
+// (empty if none, or without Visibility and Repository).
+func (pkg *GHCRPackage) LinkedRepository() string {
+	return pkg.linked
+}
+
+// producedDigest implements producingOperation.
+func (pkg *GHCRPackage) producedDigest() string {
+	return pkg.Digest()
+}
+
+// resolveRegistries implements registryOperation.
+func (pkg *GHCRPackage) resolveRegistries(lookup func(image *Image) *Registry) {
+	pkg.registry = lookup(pkg.image)
+}
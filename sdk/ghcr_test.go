@@ -0,0 +1,152 @@
+package sdk_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: GHCR package management requires a tagged image with an owner, an owner/name repository, and
+// something to manage.
+func TestGHCRPackageBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	tagged, err := sdk.NewImage("my-org/app").Domain("ghcr.io").Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	untagged, err := sdk.NewImage("my-org/app").Domain("ghcr.io").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	ownerless, err := sdk.NewImage("app").Domain("ghcr.io").Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		build   func(*sdk.Plan) (*sdk.GHCRPackage, error)
+		wantErr error
+	}{
+		{
+			name: "valid package",
+			build: func(plan *sdk.Plan) (*sdk.GHCRPackage, error) {
+				return plan.GHCRPackage("app").Image(tagged).Repository("my-org/app").
+					Description("The app").Label("org.opencontainers.image.licenses", "Apache-2.0").
+					Visibility(sdk.PackagePublic).Build()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "missing image",
+			build: func(plan *sdk.Plan) (*sdk.GHCRPackage, error) {
+				return plan.GHCRPackage("app").Visibility(sdk.PackagePublic).Build()
+			},
+			wantErr: sdk.ErrGHCRImageRequired,
+		},
+		{
+			name: "image without version",
+			build: func(plan *sdk.Plan) (*sdk.GHCRPackage, error) {
+				return plan.GHCRPackage("app").Image(untagged).Visibility(sdk.PackagePublic).Build()
+			},
+			wantErr: sdk.ErrGHCRVersionRequired,
+		},
+		{
+			name: "image without owner",
+			build: func(plan *sdk.Plan) (*sdk.GHCRPackage, error) {
+				return plan.GHCRPackage("app").Image(ownerless).Visibility(sdk.PackagePublic).Build()
+			},
+			wantErr: sdk.ErrInvalidGHCRImage,
+		},
+		{
+			name: "repository without owner",
+			build: func(plan *sdk.Plan) (*sdk.GHCRPackage, error) {
+				return plan.GHCRPackage("app").Image(tagged).Repository("app").Build()
+			},
+			wantErr: sdk.ErrInvalidGHCRRepository,
+		},
+		{
+			name: "nothing to manage",
+			build: func(plan *sdk.Plan) (*sdk.GHCRPackage, error) {
+				return plan.GHCRPackage("app").Image(tagged).Build()
+			},
+			wantErr: sdk.ErrGHCRSettingsRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pkg, err := tt.build(sdk.NewPlan(testPlanName))
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil || pkg == nil {
+				t.Errorf("Build() = %v, %v, want a package", pkg, err)
+			}
+		})
+	}
+}
+
+// INTENTION: A package with another visibility than expected fails the execution with the settings page where it
+// is changed, as GitHub has no API to change it; the package is read with the configured token.
+func TestGHCRPackage_VisibilityMismatch(t *testing.T) {
+	t.Parallel()
+
+	api := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer ghp_test" {
+			writer.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		if req.URL.EscapedPath() != "/orgs/my-org/packages/container/team%2Fapp" {
+			writer.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = writer.Write([]byte(`{"name":"team/app","visibility":"private",` +
+			`"html_url":"https://github.com/orgs/my-org/packages/container/package/team%2Fapp"}`))
+	}))
+	t.Cleanup(api.Close)
+
+	image, err := sdk.NewImage("my-org/team/app").Domain("ghcr.io").Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	plan := sdk.NewPlan(testPlanName)
+
+	if _, err := plan.GHCRPackage("app").
+		Image(image).
+		Visibility(sdk.PackagePublic).
+		Token("ghp_test").
+		APIURL(api.URL).
+		Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	err = plan.Execute(t.Context())
+	if !errors.Is(err, sdk.ErrGHCRVisibilityMismatch) {
+		t.Fatalf("Execute() error = %v, want %v", err, sdk.ErrGHCRVisibilityMismatch)
+	}
+
+	if !strings.Contains(err.Error(), "package/team%2Fapp/settings") {
+		t.Errorf("Execute() error = %v, want the package settings page", err)
+	}
+}
@@ -0,0 +1,172 @@
+package sdk
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GraphFormat represents an operation dependency graph format.
+type GraphFormat struct {
+	value string
+}
+
+//nolint:gochecknoglobals // GraphFormat enum pattern requires global variables
+var (
+	// GraphDOT renders a Graphviz DOT digraph (e.g., `dot -Tsvg plan.dot -o plan.svg`).
+	GraphDOT = GraphFormat{"dot"}
+	// GraphMermaid renders a Mermaid flowchart, shown as a diagram by GitHub and GitLab Markdown.
+	GraphMermaid = GraphFormat{"mermaid"}
+)
+
+// String returns the string representation of the format.
+func (f *GraphFormat) String() string {
+	return f.value
+}
+
+// ParseGraphFormat parses a graph format name ("dot", "graphviz" or "mermaid").
+func ParseGraphFormat(name string) (GraphFormat, error) {
+	switch strings.ToLower(name) {
+	case "dot", "graphviz":
+		return GraphDOT, nil
+	case "mermaid":
+		return GraphMermaid, nil
+	default:
+		return GraphFormat{}, fmt.Errorf("%w: %q (valid: dot, mermaid)", ErrInvalidGraphFormat, name)
+	}
+}
+
+// Graph writes the operation dependency graph of the plan in format: one node per operation (its name and kind),
+// and an edge from each operation to the operations depending on it. Operations of included plans are grouped by
+// namespace. Nothing is executed.
+func (plan *Plan) Graph(out io.Writer, format GraphFormat) error {
+	return plan.State().Graph(out, format)
+}
+
+// graphNode is an operation of a plan graph.
+type graphNode struct {
+	id, name, kind string
+}
+
+// graphCluster is the operations of an included plan (namespace empty for the operations of the plan itself).
+type graphCluster struct {
+	namespace string
+	nodes     []graphNode
+}
+
+// Graph writes the operation dependency graph of the state in format (see Plan.Graph).
+func (state *PlanState) Graph(out io.Writer, format GraphFormat) error {
+	var (
+		clusters []*graphCluster
+		byName   = make(map[string]graphNode, len(state.Operations))
+		edges    [][2]graphNode
+	)
+
+	for idx, op := range state.Operations {
+		node := graphNode{id: fmt.Sprintf("op%d", idx), name: op.Name, kind: op.Kind}
+		byName[op.Name] = node
+
+		namespace := ""
+		if slash := strings.LastIndex(op.Name, "/"); slash >= 0 {
+			namespace = op.Name[:slash]
+		}
+
+		if len(clusters) == 0 || clusters[len(clusters)-1].namespace != namespace {
+			clusters = append(clusters, &graphCluster{namespace: namespace})
+		}
+
+		clusters[len(clusters)-1].nodes = append(clusters[len(clusters)-1].nodes, node)
+	}
+
+	for _, op := range state.Operations {
+		// Dependencies are built before the operation: they are all known
+		for dep := range strings.SplitSeq(op.Settings["depends on"], ",") {
+			if from, ok := byName[dep]; ok {
+				edges = append(edges, [2]graphNode{from, byName[op.Name]})
+			}
+		}
+	}
+
+	var graph strings.Builder
+
+	switch format {
+	case GraphDOT:
+		writeDOT(&graph, state.Plan, clusters, edges)
+	case GraphMermaid:
+		writeMermaid(&graph, state.Plan, clusters, edges)
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidGraphFormat, format.value)
+	}
+
+	if _, err := io.WriteString(out, graph.String()); err != nil {
+		return fmt.Errorf("failed to write plan graph: %w", err)
+	}
+
+	return nil
+}
+
+// writeDOT renders the graph as a Graphviz digraph.
+func writeDOT(graph *strings.Builder, plan string, clusters []*graphCluster, edges [][2]graphNode) {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace
+	quote := func(value string) string {
+		return `"` + escape(value) + `"`
+	}
+
+	fmt.Fprintf(graph, "digraph %s {\n", quote(plan))
+	graph.WriteString("  rankdir=LR;\n  node [shape=box, style=rounded, fontname=\"Helvetica\"];\n")
+
+	for idx, cluster := range clusters {
+		indent := "  "
+
+		if cluster.namespace != "" {
+			fmt.Fprintf(graph, "  subgraph cluster_%d {\n    label=%s;\n", idx, quote(cluster.namespace))
+
+			indent = "    "
+		}
+
+		for _, node := range cluster.nodes {
+			fmt.Fprintf(graph, "%s%s [label=\"%s\\n%s\"];\n", indent, node.id, escape(node.name), escape(node.kind))
+		}
+
+		if cluster.namespace != "" {
+			graph.WriteString("  }\n")
+		}
+	}
+
+	for _, edge := range edges {
+		fmt.Fprintf(graph, "  %s -> %s;\n", edge[0].id, edge[1].id)
+	}
+
+	graph.WriteString("}\n")
+}
+
+// writeMermaid renders the graph as a Mermaid flowchart.
+func writeMermaid(graph *strings.Builder, plan string, clusters []*graphCluster, edges [][2]graphNode) {
+	quote := func(value string) string {
+		return `"` + strings.ReplaceAll(value, `"`, "#quot;") + `"`
+	}
+
+	fmt.Fprintf(graph, "---\ntitle: %s\n---\nflowchart LR\n", quote(plan))
+
+	for idx, cluster := range clusters {
+		indent := "  "
+
+		if cluster.namespace != "" {
+			fmt.Fprintf(graph, "  subgraph cluster%d [%s]\n", idx, quote(cluster.namespace))
+
+			indent = "    "
+		}
+
+		for _, node := range cluster.nodes {
+			fmt.Fprintf(graph, "%s%s[%s]\n", indent, node.id, quote(node.name+"<br/><i>"+node.kind+"</i>"))
+		}
+
+		if cluster.namespace != "" {
+			graph.WriteString("  end\n")
+		}
+	}
+
+	for _, edge := range edges {
+		fmt.Fprintf(graph, "  %s --> %s\n", edge[0].id, edge[1].id)
+	}
+}
@@ -0,0 +1,80 @@
+package sdk_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: The plan graph has a node per operation, named with its kind, an edge per dependency, and groups
+// the operations of included plans; both formats render the same graph.
+func TestPlan_Graph(t *testing.T) {
+	t.Parallel()
+
+	image, err := sdk.NewImage("my-org/app").Domain("ghcr.io").Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	shared := sdk.NewPlan("base")
+
+	check, err := shared.VersionCheck("check-app").Source(image).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	plan := sdk.NewPlan(testPlanName)
+	if err := plan.Include(shared); err != nil {
+		t.Fatalf("Include() error = %v", err)
+	}
+
+	if _, err := plan.Rollback("rollback-app").Image(image).ToDigest(testDigest).DependsOn(check).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	tests := []struct {
+		format sdk.GraphFormat
+		want   []string
+	}{
+		{
+			format: sdk.GraphDOT,
+			want: []string{
+				`digraph "` + testPlanName + `" {`,
+				`subgraph cluster_0 {`,
+				`label="base";`,
+				`op0 [label="base/check-app\nversion-check"];`,
+				`op1 [label="rollback-app\nrollback"];`,
+				`op0 -> op1;`,
+			},
+		},
+		{
+			format: sdk.GraphMermaid,
+			want: []string{
+				"flowchart LR",
+				`subgraph cluster0 ["base"]`,
+				`op0["base/check-app<br/><i>version-check</i>"]`,
+				`op1["rollback-app<br/><i>rollback</i>"]`,
+				"op0 --> op1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		var graph strings.Builder
+		if err := plan.Graph(&graph, tt.format); err != nil {
+			t.Fatalf("Graph(%s) error = %v", tt.format.String(), err)
+		}
+
+		for _, want := range tt.want {
+			if !strings.Contains(graph.String(), want) {
+				t.Errorf("Graph(%s) = %s, want %q", tt.format.String(), graph.String(), want)
+			}
+		}
+	}
+
+	if _, err := sdk.ParseGraphFormat("svg"); !errors.Is(err, sdk.ErrInvalidGraphFormat) {
+		t.Errorf("ParseGraphFormat(svg) error = %v, want %v", err, sdk.ErrInvalidGraphFormat)
+	}
+}
@@ -0,0 +1,330 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/farcloser/quark/internal/harbor"
+)
+
+// HarborProject represents bootstrapping a Harbor project (e.g., a mirror destination): the project, its tag
+// retention and immutability rules, and the robot account pushing to it.
+type HarborProject struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	url       string
+	username  string
+	password  string
+	project   string
+	public    bool
+	retention []harbor.RetentionRule
+	schedule  string
+	immutable []string
+
+	// Robot account provisioned for the project, and the registry authenticating with it
+	robotName     string
+	robotRegistry *Registry
+
+	// Results populated after execution
+	created    bool
+	rulesAdded int
+	robot      string
+}
+
+// HarborProjectBuilder builds a HarborProject.
+type HarborProjectBuilder struct {
+	builderState
+
+	plan    *Plan
+	harbor  *HarborProject
+	invalid error
+}
+
+// URL sets the Harbor instance URL (e.g., "https://harbor.example.com").
+func (builder *HarborProjectBuilder) URL(rawURL string) *HarborProjectBuilder {
+	builder.harbor.url = rawURL
+
+	return builder
+}
+
+// Credentials sets the Harbor administrator the project is managed with (a system administrator, or a project
+// administrator for an existing project).
+func (builder *HarborProjectBuilder) Credentials(username, password string) *HarborProjectBuilder {
+	builder.harbor.username = username
+	builder.harbor.password = password
+	secretValues.Add(password)
+
+	return builder
+}
+
+// Project sets the project name (e.g., "mirror" for harbor.example.com/mirror/...).
+func (builder *HarborProjectBuilder) Project(name string) *HarborProjectBuilder {
+	builder.harbor.project = name
+
+	return builder
+}
+
+// Public makes the project readable anonymously (default: private).
+func (builder *HarborProjectBuilder) Public(public bool) *HarborProjectBuilder {
+	builder.harbor.public = public
+
+	return builder
+}
+
+// RetainLatest keeps the count most recently pushed artifacts with tags matching pattern (doublestar, "**" for
+// all tags). Rules add up: artifacts matching any rule are retained, the others are deleted by the retention runs.
+func (builder *HarborProjectBuilder) RetainLatest(pattern string, count int) *HarborProjectBuilder {
+	if count <= 0 {
+		builder.invalid = fmt.Errorf("%w: keep %d latest", ErrInvalidHarborRetention, count)
+	}
+
+	builder.harbor.retention = append(builder.harbor.retention, harbor.RetentionRule{Tags: pattern, KeepLatest: count})
+
+	return builder
+}
+
+// RetainDays keeps the artifacts with tags matching pattern pushed in the last days days (see RetainLatest).
+func (builder *HarborProjectBuilder) RetainDays(pattern string, days int) *HarborProjectBuilder {
+	if days <= 0 {
+		builder.invalid = fmt.Errorf("%w: keep %d days", ErrInvalidHarborRetention, days)
+	}
+
+	builder.harbor.retention = append(builder.harbor.retention, harbor.RetentionRule{Tags: pattern, KeepDays: days})
+
+	return builder
+}
+
+// RetentionSchedule sets when the retention policy runs (cron expression with seconds, e.g., "0 0 0 * * *" for
+// daily). Without schedule, it only runs when triggered from Harbor.
+func (builder *HarborProjectBuilder) RetentionSchedule(cron string) *HarborProjectBuilder {
+	builder.harbor.schedule = cron
+
+	return builder
+}
+
+// ImmutableTags protects the tags matching patterns (doublestar, e.g., "v*") from being overwritten or deleted,
+// in all repositories of the project.
+func (builder *HarborProjectBuilder) ImmutableTags(patterns ...string) *HarborProjectBuilder {
+	builder.harbor.immutable = append(builder.harbor.immutable, patterns...)
+
+	return builder
+}
+
+// Robot provisions the project robot account name (pull and push access), and makes registry (typically the plan
+// registry of the Harbor host) authenticate with it once provisioned, replacing its credentials and authentication
+// methods. The secret of an existing robot is rotated on every execution. Operations using the registry must
+// depend on the Harbor project (DependsOn).
+func (builder *HarborProjectBuilder) Robot(name string, registry *Registry) *HarborProjectBuilder {
+	builder.harbor.robotName = name
+	builder.harbor.robotRegistry = registry
+
+	return builder
+}
+
+// RunOnlyOn restricts the Harbor project bootstrap to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *HarborProjectBuilder) RunOnlyOn(envs ...Environment) *HarborProjectBuilder {
+	builder.harbor.runOnlyOn = append(builder.harbor.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the Harbor project bootstrap mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *HarborProjectBuilder) Resource(resource Resource) *HarborProjectBuilder {
+	builder.harbor.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the Harbor project bootstrap start only once the given operations, built before it in the
+// plan, completed (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *HarborProjectBuilder) DependsOn(ops ...Dependency) *HarborProjectBuilder {
+	builder.harbor.add(ops)
+
+	return builder
+}
+
+// When makes the Harbor project bootstrap run only if the given conditions all hold once the operations it
+// depends on completed; otherwise it is skipped. A failing condition fails the bootstrap.
+func (builder *HarborProjectBuilder) When(conditions ...Condition) *HarborProjectBuilder {
+	builder.harbor.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a Harbor project bootstrap named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *HarborProjectBuilder) Clone(name string) *HarborProjectBuilder {
+	clone := builder.plan.HarborProject(name)
+	clone.invalid = builder.invalid
+	clone.harbor.envGuard = builder.harbor.envGuard.clone()
+	clone.harbor.resourceHint = builder.harbor.resourceHint
+	clone.harbor.dependencyList = builder.harbor.dependencyList.clone()
+	clone.harbor.conditionList = builder.harbor.conditionList.clone()
+	clone.harbor.url = builder.harbor.url
+	clone.harbor.username = builder.harbor.username
+	clone.harbor.password = builder.harbor.password
+	clone.harbor.project = builder.harbor.project
+	clone.harbor.public = builder.harbor.public
+	clone.harbor.retention = append([]harbor.RetentionRule(nil), builder.harbor.retention...)
+	clone.harbor.schedule = builder.harbor.schedule
+	clone.harbor.immutable = append([]string(nil), builder.harbor.immutable...)
+	clone.harbor.robotName = builder.harbor.robotName
+	clone.harbor.robotRegistry = builder.harbor.robotRegistry
+
+	return clone
+}
+
+// Reset makes the builder usable again for a Harbor project bootstrap named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *HarborProjectBuilder) Reset(name string) *HarborProjectBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build validates and adds the Harbor project bootstrap to the plan.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *HarborProjectBuilder) Build() (*HarborProject, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.harbor.opName); err != nil {
+		return nil, err
+	}
+
+	if builder.harbor.url == "" {
+		return nil, ErrHarborURLRequired
+	}
+
+	parsed, err := url.Parse(builder.harbor.url)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidHarborURL, builder.harbor.url)
+	}
+
+	if builder.harbor.project == "" {
+		return nil, ErrHarborProjectRequired
+	}
+
+	if builder.invalid != nil {
+		return nil, builder.invalid
+	}
+
+	if builder.harbor.robotName != "" && builder.harbor.robotRegistry == nil {
+		return nil, fmt.Errorf("%w for robot %q", ErrHarborRobotRegistryRequired, builder.harbor.robotName)
+	}
+
+	builder.plan.harborProjects = append(builder.plan.harborProjects, builder.harbor)
+	builder.plan.addOperation(builder.harbor)
+
+	return builder.harbor, nil
+}
+
+// client returns a client of the Harbor instance.
+func (project *HarborProject) client() *harbor.Client {
+	return harbor.NewClient(project.url, project.username, project.password)
+}
+
+func (project *HarborProject) execute(ctx context.Context) error {
+	client := project.client()
+
+	project.log.Info().Str("url", project.url).Str("project", project.project).Msg("bootstrapping harbor project")
+
+	created, err := client.EnsureProject(ctx, project.project, project.public)
+	if err != nil {
+		return fmt.Errorf("failed to ensure project: %w", err)
+	}
+
+	project.created = created
+
+	if len(project.retention) > 0 {
+		if err := client.SetRetention(ctx, project.project, project.retention, project.schedule); err != nil {
+			return fmt.Errorf("failed to set retention policy: %w", err)
+		}
+	}
+
+	if len(project.immutable) > 0 {
+		added, err := client.EnsureImmutableTags(ctx, project.project, project.immutable)
+		project.rulesAdded = added
+
+		if err != nil {
+			return fmt.Errorf("failed to set immutable tags: %w", err)
+		}
+	}
+
+	if project.robotName != "" {
+		robot, err := client.EnsureRobot(ctx, project.project, project.robotName)
+		if err != nil {
+			return fmt.Errorf("failed to provision robot account: %w", err)
+		}
+
+		project.robot = robot.Name
+		project.robotRegistry.useCredentials(robot.Name, robot.Secret)
+	}
+
+	project.log.Info().
+		Str("project", project.project).
+		Bool("created", project.created).
+		Int("immutability_rules_added", project.rulesAdded).
+		Str("robot", project.robot).
+		Msg("harbor project ready")
+
+	return nil
+}
+
+// plannedChanges implements dryRunOperation: the administrator must be accepted.
+func (project *HarborProject) plannedChanges(ctx context.Context) ([]string, error) {
+	client := project.client()
+
+	if err := client.CheckAuth(ctx); err != nil {
+		return nil, fmt.Errorf("harbor %s rejected the credentials: %w", project.url, err)
+	}
+
+	var changes []string
+
+	_, err := client.Project(ctx, project.project)
+
+	switch {
+	case errors.Is(err, harbor.ErrProjectNotFound):
+		changes = append(changes, "Would create project "+project.project)
+	case err != nil:
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	default:
+		changes = append(changes, "Would update project "+project.project)
+	}
+
+	if len(project.retention) > 0 {
+		changes = append(changes, fmt.Sprintf("Would set a retention policy of %d rules", len(project.retention)))
+	}
+
+	if len(project.immutable) > 0 {
+		changes = append(changes, "Would protect tags "+strings.Join(project.immutable, ", "))
+	}
+
+	if project.robotName != "" {
+		changes = append(changes, fmt.Sprintf("Would provision robot %s and rotate its secret", project.robotName))
+	}
+
+	return changes, nil
+}
+
+// Created reports whether the execution created the project.
+func (project *HarborProject) Created() bool {
+	return project.created
+}
+
+// Robot returns the full name of the robot account provisioned by the execution (e.g., "robot$mirror+sync"),
+// empty if none.
+func (project *HarborProject) Robot() string {
+	return project.robot
+}
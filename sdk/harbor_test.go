@@ -0,0 +1,134 @@
+package sdk_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/farcloser/quark/sdk"
+	"github.com/farcloser/quark/testutil"
+)
+
+// INTENTION: Harbor project bootstraps require an HTTP(S) URL, a project, valid retention rules, and a registry
+// for their robot.
+func TestHarborProjectBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		build   func(*sdk.Plan) (*sdk.HarborProject, error)
+		wantErr error
+	}{
+		{
+			name: "valid project",
+			build: func(plan *sdk.Plan) (*sdk.HarborProject, error) {
+				return plan.HarborProject("harbor").URL("https://harbor.example.com").Project("mirror").
+					RetainLatest("**", 10).ImmutableTags("v*").Build()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "missing url",
+			build: func(plan *sdk.Plan) (*sdk.HarborProject, error) {
+				return plan.HarborProject("harbor").Project("mirror").Build()
+			},
+			wantErr: sdk.ErrHarborURLRequired,
+		},
+		{
+			name: "url without scheme",
+			build: func(plan *sdk.Plan) (*sdk.HarborProject, error) {
+				return plan.HarborProject("harbor").URL("harbor.example.com").Project("mirror").Build()
+			},
+			wantErr: sdk.ErrInvalidHarborURL,
+		},
+		{
+			name: "missing project",
+			build: func(plan *sdk.Plan) (*sdk.HarborProject, error) {
+				return plan.HarborProject("harbor").URL("https://harbor.example.com").Build()
+			},
+			wantErr: sdk.ErrHarborProjectRequired,
+		},
+		{
+			name: "retention keeping nothing",
+			build: func(plan *sdk.Plan) (*sdk.HarborProject, error) {
+				return plan.HarborProject("harbor").URL("https://harbor.example.com").Project("mirror").
+					RetainDays("**", 0).Build()
+			},
+			wantErr: sdk.ErrInvalidHarborRetention,
+		},
+		{
+			name: "robot without registry",
+			build: func(plan *sdk.Plan) (*sdk.HarborProject, error) {
+				return plan.HarborProject("harbor").URL("https://harbor.example.com").Project("mirror").
+					Robot("sync", nil).Build()
+			},
+			wantErr: sdk.ErrHarborRobotRegistryRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			project, err := tt.build(sdk.NewPlan(testPlanName))
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil || project == nil {
+				t.Errorf("Build() = %v, %v, want a project", project, err)
+			}
+		})
+	}
+}
+
+// INTENTION: Executing a Harbor project bootstrap creates the project and its rules, and makes the registry
+// authenticate with the provisioned robot, so the operations depending on it push with the robot.
+func TestHarborProject_Execute(t *testing.T) {
+	t.Parallel()
+
+	fake := testutil.NewFakeHarbor(t, "admin", "Harbor12345")
+	plan := sdk.NewPlan("harbor")
+
+	registry, err := plan.Registry("harbor.example.com").Build()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+
+	project, err := plan.HarborProject("mirror-project").
+		URL(fake.URL).
+		Credentials("admin", "Harbor12345").
+		Project("mirror").
+		RetainLatest("**", 10).
+		RetentionSchedule("0 0 0 * * *").
+		ImmutableTags("v*").
+		Robot("sync", registry).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if err := plan.Execute(t.Context()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if !project.Created() || project.Robot() != "robot$mirror+sync" {
+		t.Errorf("Created() = %v, Robot() = %q, want a created project and robot$mirror+sync",
+			project.Created(), project.Robot())
+	}
+
+	fake.Snapshot(func(state *testutil.FakeHarbor) {
+		if len(state.Retentions) != 1 || len(state.ImmutableTags["mirror"]) != 1 {
+			t.Errorf("state = %v %v, want one retention policy and immutability rule",
+				state.Retentions, state.ImmutableTags)
+		}
+
+		if registry.Username() != "robot$mirror+sync" || registry.Password() != state.Robots["robot$mirror+sync"] {
+			t.Errorf("registry credentials = %q, want the robot and its secret", registry.Username())
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package sdk
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/history"
+	"github.com/farcloser/quark/internal/trivy"
+)
+
+// History stores scan and version check results per image and per run under dir,
+// so findings can be compared over time (see `quark history show IMAGE`).
+// Records are appended: the directory can be kept across runs (e.g., as a CI cache).
+// Results are recorded to the plan database too, if any (see Database).
+func (plan *Plan) History(dir string) {
+	plan.historyDir = dir
+}
+
+// historyRecorder appends operation results to the plan history.
+// A nil recorder (history disabled) records nothing.
+type historyRecorder struct {
+	stores  []history.Backend
+	plan    string
+	started time.Time
+	log     zerolog.Logger
+}
+
+// newHistoryRecorder creates a recorder for a plan run, or nil if history is disabled.
+func newHistoryRecorder(plan *Plan, started time.Time) *historyRecorder {
+	var stores []history.Backend
+
+	if plan.historyDir != "" {
+		stores = append(stores, history.NewStore(plan.historyDir))
+	}
+
+	if plan.database != nil {
+		stores = append(stores, plan.database)
+	}
+
+	if len(stores) == 0 {
+		return nil
+	}
+
+	return &historyRecorder{
+		stores:  stores,
+		plan:    plan.name,
+		started: started,
+		log:     plan.log,
+	}
+}
+
+// record appends a record for an operation on an image.
+// Failures are logged: history never fails the operation it records.
+func (recorder *historyRecorder) record(operation string, img *Image, record history.Record) {
+	if recorder == nil {
+		return
+	}
+
+	record.Time = recorder.started
+	record.Plan = recorder.plan
+	record.Operation = operation
+	record.Image = img.ref.Name()
+	record.Version = img.Version()
+
+	if record.Digest == "" {
+		record.Digest = img.Digest()
+	}
+
+	for _, store := range recorder.stores {
+		if err := store.Append(record); err != nil {
+			recorder.log.Warn().Err(err).Str("image", record.Image).Msg("failed to record history")
+		}
+	}
+}
+
+// historyVulnerabilities merges per-platform findings into history records,
+// listing the platforms each finding was found on.
+func historyVulnerabilities(results []trivy.PlatformResult) []history.Vulnerability {
+	index := make(map[string]int)
+
+	var vulns []history.Vulnerability
+
+	for _, platformResult := range results {
+		for _, target := range platformResult.Result.Results {
+			for _, vuln := range target.Vulnerabilities {
+				key := vuln.VulnerabilityID + "\x00" + vuln.PkgName
+
+				idx, seen := index[key]
+				if !seen {
+					idx = len(vulns)
+					index[key] = idx
+					vulns = append(vulns, history.Vulnerability{
+						ID:               vuln.VulnerabilityID,
+						Severity:         vuln.Severity,
+						Package:          vuln.PkgName,
+						InstalledVersion: vuln.InstalledVersion,
+						FixedVersion:     vuln.FixedVersion,
+					})
+				}
+
+				if platformResult.Platform != "" && !slices.Contains(vulns[idx].Platforms, platformResult.Platform) {
+					vulns[idx].Platforms = append(vulns[idx].Platforms, platformResult.Platform)
+				}
+			}
+		}
+	}
+
+	sort.Slice(vulns, func(i, j int) bool {
+		if vulns[i].ID != vulns[j].ID {
+			return vulns[i].ID < vulns[j].ID
+		}
+
+		return vulns[i].Package < vulns[j].Package
+	})
+
+	return vulns
+}
@@ -0,0 +1,75 @@
+package sdk_test
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/farcloser/quark/internal/history"
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: With History set, each run appends version check results to the image history.
+func TestPlan_History(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+	current := pushRandomImage(t, host+"/my-org/app:1.0.0")
+	latest := pushRandomImage(t, host+"/my-org/app:1.1.0")
+
+	image, err := sdk.NewImage("my-org/app").Domain(host).Version("1.0.0").Digest(current).Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	dir := t.TempDir()
+
+	for range 2 {
+		plan := sdk.NewPlan(testPlanName)
+		plan.History(dir)
+
+		if _, err := plan.VersionCheck("check-app").Source(image).Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		if err := plan.Execute(context.Background()); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+	}
+
+	records, err := history.NewStore(dir).Records(host + "/my-org/app")
+	if err != nil {
+		t.Fatalf("Records() error = %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("recorded %d runs, want 2", len(records))
+	}
+
+	record := records[1]
+	if record.Kind != history.KindVersionCheck || record.Plan != testPlanName || record.Operation != "check-app" {
+		t.Errorf("record = %+v, want version check from %s/check-app", record, testPlanName)
+	}
+
+	if record.Version != "1.0.0" || record.Digest != current || record.LatestVersion != "1.1.0" ||
+		record.LatestDigest != latest || !record.UpdateAvailable {
+		t.Errorf("record = %+v, want 1.0.0 (%s) with update to 1.1.0 (%s)", record, current, latest)
+	}
+
+	if records[0].Time.After(records[1].Time) {
+		t.Errorf("records out of order: %s after %s", records[0].Time, records[1].Time)
+	}
+}
@@ -10,11 +10,43 @@ import (
 	"github.com/farcloser/quark/internal/reference"
 )
 
+// helmOCIScheme is the scheme Helm uses for charts stored in OCI registries.
+const helmOCIScheme = "oci://"
+
+// ImageProtocol identifies where an image is stored.
+type ImageProtocol struct {
+	value string
+}
+
+//nolint:gochecknoglobals // ImageProtocol enum pattern requires global variables
+var (
+	// ProtocolRegistry represents an image in an OCI registry (references without a protocol prefix).
+	ProtocolRegistry = ImageProtocol{""}
+	// ProtocolDockerArchive represents an image saved with `docker save` ("docker-archive:path[:reference]").
+	ProtocolDockerArchive = ImageProtocol{string(reference.ProtocolDockerArchive)}
+	// ProtocolOCIArchive represents an image in an OCI layout tarball ("oci-archive:path[:name]").
+	ProtocolOCIArchive = ImageProtocol{string(reference.ProtocolOCIArchive)}
+	// ProtocolContainerd represents an image in the local containerd store ("containerd://reference").
+	ProtocolContainerd = ImageProtocol{string(reference.ProtocolContainerd)}
+)
+
+// String returns the string representation of the protocol ("registry" for registry images).
+func (protocol ImageProtocol) String() string {
+	if protocol.value == "" {
+		return "registry"
+	}
+
+	return protocol.value
+}
+
 // Image represents a container image reference with optional version and digest.
 type Image struct {
 	ref *reference.ImageReference
 	log zerolog.Logger
 
+	// Operation producing the image (see Sync.OutputImage, Build.OutputImage): operations reading it depend on it
+	producer operation
+
 	// Builder state (fields set before Build() is called)
 	builderName    string
 	builderDomain  string
@@ -24,8 +56,9 @@ type Image struct {
 
 // ImageBuilder builds an Image.
 type ImageBuilder struct {
+	builderState
+
 	image *Image
-	built bool
 }
 
 // NewImage creates a new Image builder with the specified name.
@@ -33,6 +66,9 @@ type ImageBuilder struct {
 //   - Short: "alpine", "debian" (normalized to docker.io/library/alpine, docker.io/library/debian)
 //   - Repository: "timberio/vector", "org/image" (normalized to docker.io/timberio/vector, docker.io/org/image)
 //   - Fully qualified: "ghcr.io/foo/bar:v1.0", "docker.io/library/alpine:3.19"
+//   - Helm chart: "oci://ghcr.io/charts/foo:1.2.3" (the oci:// scheme used by Helm is accepted and dropped)
+//   - Local images: "docker-archive:app.tar[:reference]", "oci-archive:app.oci.tar[:name]",
+//     "containerd://ghcr.io/foo/bar:v1.0" (see Protocol())
 //
 // You can also use Domain(), Version(), and Digest() methods to set components explicitly.
 func NewImage(name string) *ImageBuilder {
@@ -67,21 +103,55 @@ func (builder *ImageBuilder) Digest(digest string) *ImageBuilder {
 	return builder
 }
 
+// Clone returns a new builder for the image name, with the same domain, version and digest.
+// It can be called before or after Build(), to define similar images from one template.
+func (builder *ImageBuilder) Clone(name string) *ImageBuilder {
+	clone := NewImage(name)
+	clone.image.builderDomain = builder.image.builderDomain
+	clone.image.builderVersion = builder.image.builderVersion
+	clone.image.builderDigest = builder.image.builderDigest
+
+	return clone
+}
+
+// Reset makes the builder usable again for the image name, keeping its domain, version and digest.
+// The result of a previous Build() is not affected by later changes.
+func (builder *ImageBuilder) Reset(name string) *ImageBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
 // Build validates and returns the Image.
 // The builder becomes unusable after Build() is called.
-// Create a new builder for each operation.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
 func (builder *ImageBuilder) Build() (*Image, error) {
-	if builder.built {
-		return nil, ErrBuilderAlreadyUsed
+	if err := builder.use(); err != nil {
+		return nil, err
 	}
 
-	builder.built = true
-
-	name := strings.TrimSpace(builder.image.builderName)
+	// Helm references OCI registries as oci://registry/repo
+	name := strings.TrimPrefix(strings.TrimSpace(builder.image.builderName), helmOCIScheme)
 	if name == "" {
 		return nil, ErrImageNameRequired
 	}
 
+	// Protocol references are complete: components cannot be added to them
+	if reference.HasProtocol(name) {
+		ref, err := reference.Parse(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid image reference: %w", err)
+		}
+
+		if builder.image.builderDomain != "" || builder.image.builderVersion != "" || builder.image.builderDigest != "" {
+			return nil, fmt.Errorf("%w: %q", ErrImageProtocolComponents, name)
+		}
+
+		builder.image.ref = ref
+
+		return builder.image, nil
+	}
+
 	// Construct reference string from builder fields
 	refString := ""
 	if builder.image.builderDomain != "" {
@@ -148,9 +218,38 @@ func (img *Image) Digest() string {
 	return img.ref.Digest.String()
 }
 
+// Protocol returns where the image is stored (ProtocolRegistry unless the reference has a protocol prefix).
+func (img *Image) Protocol() ImageProtocol {
+	return ImageProtocol{string(img.ref.Protocol)}
+}
+
+// Archive returns the path of the archive holding the image (docker-archive and oci-archive protocols),
+// or empty.
+func (img *Image) Archive() string {
+	return img.ref.Archive
+}
+
+// String returns the full image reference, including its protocol prefix if any.
+func (img *Image) String() string {
+	return img.ref.String()
+}
+
+// checkRegistry returns ErrImageNotInRegistry if the image is not stored in a registry.
+func (img *Image) checkRegistry() error {
+	if img.ref.Protocol != "" {
+		return fmt.Errorf("%w: %s", ErrImageNotInRegistry, img.ref.String())
+	}
+
+	return nil
+}
+
 // tagRef returns the tag reference format: "domain/name:version".
 // Returns error if version is not set.
 func (img *Image) tagRef() (string, error) {
+	if err := img.checkRegistry(); err != nil {
+		return "", err
+	}
+
 	if img.ref.ExplicitTag == "" {
 		return "", fmt.Errorf("%w for image %q", ErrImageVersionRequired, img.ref.Path)
 	}
@@ -158,9 +257,47 @@ func (img *Image) tagRef() (string, error) {
 	return img.ref.Name() + ":" + img.ref.Tag, nil
 }
 
+// retarget points the image to domain and tag (kept when empty), with the same path and digest.
+// Operations sharing the image follow it (see Profile).
+func (img *Image) retarget(domain, tag string) error {
+	if err := img.checkRegistry(); err != nil {
+		return err
+	}
+
+	if domain == "" {
+		domain = img.ref.Domain
+	}
+
+	if tag == "" {
+		tag = img.ref.ExplicitTag
+	}
+
+	raw := domain + "/" + img.ref.Path
+	if tag != "" {
+		raw += ":" + tag
+	}
+
+	if img.ref.Digest != "" {
+		raw += "@" + img.ref.Digest.String()
+	}
+
+	ref, err := reference.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid image reference %q: %w", raw, err)
+	}
+
+	img.ref = ref
+
+	return nil
+}
+
 // digestRef returns the digest reference format: "domain/name@digest".
 // Returns error if digest is not set.
 func (img *Image) digestRef() (string, error) {
+	if err := img.checkRegistry(); err != nil {
+		return "", err
+	}
+
 	if img.ref.Digest == "" {
 		return "", fmt.Errorf("%w for image %q", ErrImageDigestRequired, img.ref.Path)
 	}
@@ -240,11 +240,21 @@ func TestImageBuilder_NameValidation(t *testing.T) {
 			imgName: "my_app",
 			wantErr: false,
 		},
+		{
+			name:    "helm oci scheme",
+			imgName: "oci://ghcr.io/charts/foo",
+			wantErr: false,
+		},
 		{
 			name:    "empty name fails",
 			imgName: "",
 			wantErr: true,
 		},
+		{
+			name:    "bare oci scheme fails",
+			imgName: "oci://",
+			wantErr: true,
+		},
 		{
 			name:    "whitespace name fails",
 			imgName: "  ",
@@ -346,3 +356,105 @@ func TestImageBuilder_DigestValidation(t *testing.T) {
 		})
 	}
 }
+
+// INTENTION: Helm chart references (oci://registry/repo) resolve to the registry and repository.
+func TestImageBuilder_HelmOCIReference(t *testing.T) {
+	t.Parallel()
+
+	img, err := sdk.NewImage("oci://ghcr.io/charts/foo").Version("1.2.3").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if img.Domain() != "ghcr.io" || img.Path() != "charts/foo" || img.Version() != "1.2.3" {
+		t.Errorf("got domain=%q path=%q version=%q, want ghcr.io charts/foo 1.2.3", img.Domain(), img.Path(), img.Version())
+	}
+}
+
+// INTENTION: Protocol references (archives, containerd) expose where the image is stored, cannot be
+// combined with components, and fail registry operations with ErrImageNotInRegistry.
+func TestImageBuilder_ProtocolReference(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		ref          string
+		wantProtocol sdk.ImageProtocol
+		wantArchive  string
+		wantString   string
+	}{
+		{
+			name:         "registry",
+			ref:          "ghcr.io/org/app:1.0",
+			wantProtocol: sdk.ProtocolRegistry,
+			wantString:   "ghcr.io/org/app:1.0",
+		},
+		{
+			name:         "docker archive",
+			ref:          "docker-archive:/tmp/app.tar:ghcr.io/org/app:1.0",
+			wantProtocol: sdk.ProtocolDockerArchive,
+			wantArchive:  "/tmp/app.tar",
+			wantString:   "docker-archive:/tmp/app.tar:ghcr.io/org/app:1.0",
+		},
+		{
+			name:         "oci archive",
+			ref:          "oci-archive:app.oci.tar",
+			wantProtocol: sdk.ProtocolOCIArchive,
+			wantArchive:  "app.oci.tar",
+			wantString:   "oci-archive:app.oci.tar",
+		},
+		{
+			name:         "containerd",
+			ref:          "containerd://ghcr.io/org/app:1.0",
+			wantProtocol: sdk.ProtocolContainerd,
+			wantString:   "containerd://ghcr.io/org/app:1.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			img, err := sdk.NewImage(tt.ref).Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			if img.Protocol() != tt.wantProtocol {
+				t.Errorf("Protocol() = %v, want %v", img.Protocol(), tt.wantProtocol)
+			}
+
+			if img.Archive() != tt.wantArchive {
+				t.Errorf("Archive() = %q, want %q", img.Archive(), tt.wantArchive)
+			}
+
+			if img.String() != tt.wantString {
+				t.Errorf("String() = %q, want %q", img.String(), tt.wantString)
+			}
+		})
+	}
+
+	_, err := sdk.NewImage("docker-archive:/tmp/app.tar").Version("1.0").Build()
+	if !errors.Is(err, sdk.ErrImageProtocolComponents) {
+		t.Errorf("Build() with Version error = %v, want %v", err, sdk.ErrImageProtocolComponents)
+	}
+
+	if _, err := sdk.NewImage("docker-archive:").Build(); err == nil {
+		t.Error("Build() with an empty archive path error = nil, want error")
+	}
+
+	archived, err := sdk.NewImage("docker-archive:/tmp/app.tar:ghcr.io/org/app:1.0").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	plan := sdk.NewPlan(testPlanName)
+
+	if _, err := plan.VersionCheck("archived").Source(archived).Build(); err != nil {
+		t.Fatalf("VersionCheck Build() error = %v", err)
+	}
+
+	if err := plan.Execute(t.Context()); !errors.Is(err, sdk.ErrImageNotInRegistry) {
+		t.Errorf("Execute() error = %v, want %v", err, sdk.ErrImageNotInRegistry)
+	}
+}
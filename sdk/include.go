@@ -0,0 +1,82 @@
+package sdk
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Include adds the registries, build nodes and operations of other to the plan, so pieces shared by several
+// pipelines (e.g., registries, base image version checks) are defined once, in a function returning a plan,
+// and composed into each pipeline.
+// Included operations are namespaced with the name of other (e.g., "base/check-alpine"), run in the order they
+// were added, after the operations the plan already has, and keep their dependencies. Operations built
+// afterwards can depend on them. Registries of the plan take precedence over the registries of other for the
+// same domain. Execution settings of other (e.g., profiles, reports, parallelism) are not included.
+// A plan can only be included once (build one per pipeline including it), and no longer executes on its own.
+func (plan *Plan) Include(other *Plan) error {
+	if other.name == "" {
+		return ErrIncludeNameRequired
+	}
+
+	if other == plan || other.included {
+		return fmt.Errorf("%w: %q", ErrPlanAlreadyIncluded, other.name)
+	}
+
+	// Names are checked first, so a failed include leaves both plans unchanged
+	for _, op := range other.operations {
+		if err := plan.checkOperationName(other.name + "/" + op.operationName()); err != nil {
+			return fmt.Errorf("including plan %q: %w", other.name, err)
+		}
+	}
+
+	for _, op := range other.operations {
+		op.setName(other.name + "/" + op.operationName())
+		plan.addOperation(op)
+	}
+
+	for domain, reg := range other.registries {
+		if _, exists := plan.registries[domain]; !exists {
+			plan.registries[domain] = reg
+		}
+	}
+
+	for _, node := range other.buildNodes {
+		if !slices.Contains(plan.buildNodes, node) {
+			plan.buildNodes = append(plan.buildNodes, node)
+		}
+	}
+
+	plan.syncs = append(plan.syncs, other.syncs...)
+	plan.builds = append(plan.builds, other.builds...)
+	plan.scans = append(plan.scans, other.scans...)
+	plan.audits = append(plan.audits, other.audits...)
+	plan.versionChecks = append(plan.versionChecks, other.versionChecks...)
+	plan.rollbacks = append(plan.rollbacks, other.rollbacks...)
+	plan.rebases = append(plan.rebases, other.rebases...)
+	plan.flattens = append(plan.flattens, other.flattens...)
+	plan.mutations = append(plan.mutations, other.mutations...)
+	plan.indexes = append(plan.indexes, other.indexes...)
+	plan.prunes = append(plan.prunes, other.prunes...)
+	plan.sizeChecks = append(plan.sizeChecks, other.sizeChecks...)
+	plan.verifications = append(plan.verifications, other.verifications...)
+	plan.artifacts = append(plan.artifacts, other.artifacts...)
+	plan.exports = append(plan.exports, other.exports...)
+	plan.imports = append(plan.imports, other.imports...)
+	plan.bundles = append(plan.bundles, other.bundles...)
+	plan.maintenances = append(plan.maintenances, other.maintenances...)
+	plan.provisions = append(plan.provisions, other.provisions...)
+	plan.containerdImports = append(plan.containerdImports, other.containerdImports...)
+	plan.remoteRuns = append(plan.remoteRuns, other.remoteRuns...)
+	plan.harborProjects = append(plan.harborProjects, other.harborProjects...)
+	plan.ghcrPackages = append(plan.ghcrPackages, other.ghcrPackages...)
+	plan.repositoryDocs = append(plan.repositoryDocs, other.repositoryDocs...)
+
+	// Included operations use the registries of the plan for the domains both define
+	plan.resolveRegistries()
+
+	other.included = true
+
+	plan.log.Debug().Str("included", other.name).Int("operations", len(other.operations)).Msg("plan included")
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package sdk_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: Included operations run in the including plan under namespaced names, and operations of the
+// including plan can depend on them; an included plan cannot be included again, nor executed on its own.
+func TestPlan_Include(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+	digest := pushRandomImage(t, host+"/source/app:1.0.0")
+
+	source, err := sdk.NewImage("source/app").Domain(host).Version("1.0.0").Digest(digest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create source image: %v", err)
+	}
+
+	// Shared piece, built once per pipeline
+	base := func() (*sdk.Plan, *sdk.VersionCheck) {
+		shared := sdk.NewPlan("base")
+
+		check, err := shared.VersionCheck("check").Source(source).Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		return shared, check
+	}
+
+	shared, check := base()
+	plan := sdk.NewPlan("pipeline")
+
+	if err := plan.Include(shared); err != nil {
+		t.Fatalf("Include() error = %v", err)
+	}
+
+	mirror, err := sdk.NewImage("mirror/app").Domain(host).Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create mirror image: %v", err)
+	}
+
+	if _, err := plan.Sync("mirror").Source(source).Destination(mirror).DependsOn(check).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if err := plan.Execute(t.Context()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	for _, name := range []string{"base/check", "mirror"} {
+		if op, ok := plan.Report().Operation(name); !ok || op.Status != sdk.StatusSucceeded {
+			t.Errorf("Report().Operation(%q) = %+v, want succeeded", name, op)
+		}
+	}
+
+	if err := sdk.NewPlan("other").Include(shared); !errors.Is(err, sdk.ErrPlanAlreadyIncluded) {
+		t.Errorf("Include() again error = %v, want %v", err, sdk.ErrPlanAlreadyIncluded)
+	}
+
+	if err := shared.Execute(t.Context()); !errors.Is(err, sdk.ErrPlanAlreadyIncluded) {
+		t.Errorf("Execute() of the included plan error = %v, want %v", err, sdk.ErrPlanAlreadyIncluded)
+	}
+
+	// A second instance collides with the namespaced names of the first
+	second, _ := base()
+	if err := plan.Include(second); !errors.Is(err, sdk.ErrDuplicateOperationName) {
+		t.Errorf("Include() of a second instance error = %v, want %v", err, sdk.ErrDuplicateOperationName)
+	}
+}
@@ -0,0 +1,249 @@
+package sdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/farcloser/quark/internal/dockerfile"
+)
+
+// SkipUnchanged skips the operations whose inputs did not change since the last successful execution, turning
+// repeated executions of a large plan into incremental ones. Each operation has a cache key hashing its settings
+// (images with their digests, platforms, rule sets...), the content of the local files it reads (build contexts
+// with their Dockerfile, audited Dockerfiles, the exceptions file of scans and audits) and the cache keys of the
+// operations it depends on. The keys of the operations an execution completes are recorded in the plan state
+// (see StateTo or Database, one of them required), and operations whose key matches the state are reported as
+// StatusUnchanged.
+//
+// Only syncs, builds, scans, audits, size checks, verifications, rebases, flattenings, mutations and manifest
+// lists are skipped, and only when the images they read are pinned by digest or produced by the operations they
+// depend on: images referenced by tag alone may have changed. Builds are skipped only when every FROM of their
+// Dockerfile is pinned by digest. Operations skipped as unchanged give the operations reading their output image
+// (see Sync.OutputImage) the digest they pushed last, recorded in the state; an operation whose digest the state
+// does not record runs when its output image is read. The images they pushed are not checked again, and scans do
+// not look for vulnerabilities published since: run the plan without SkipUnchanged periodically.
+// Also enabled by QUARK_SKIP_UNCHANGED=true (set by the CLI --skip-unchanged flag).
+func (plan *Plan) SkipUnchanged(enabled bool) {
+	plan.skipUnchanged = enabled
+}
+
+// cacheInput is what an operation cache key hashes. JSON objects are encoded with their keys sorted.
+type cacheInput struct {
+	Kind     string            `json:"kind"`
+	Settings map[string]string `json:"settings,omitempty"`
+	// Files are the fingerprints of the local files and directories read by the operation, by path
+	Files map[string]string `json:"files,omitempty"`
+	// Dependencies are the cache keys of the operations it depends on, in order
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// prepareCache computes the cache keys of the operations before they execute, when the state records them, and
+// reads the keys of the last successful execution when unchanged operations are skipped.
+func (plan *Plan) prepareCache(ctx context.Context) {
+	plan.cacheKeys, plan.previousKeys, plan.previousDigests = nil, nil, nil
+
+	path := plan.processEnv("QUARK_STATE", plan.statePath)
+	skip := plan.skipUnchanged || plan.processEnv("QUARK_SKIP_UNCHANGED", "") == "true"
+
+	if path == "" && plan.database == nil {
+		if skip {
+			plan.log.Warn().Msg("skipping unchanged operations requires a plan state, executing every operation")
+		}
+
+		return
+	}
+
+	plan.cacheKeys = plan.computeCacheKeys()
+
+	if !skip {
+		return
+	}
+
+	var (
+		previous *PlanState
+		err      error
+	)
+
+	// The state file is the reference when both are set, as it is by `quark plan-diff`
+	if path != "" {
+		previous, err = ReadState(path)
+	} else {
+		previous, err = plan.readDatabaseState(ctx)
+	}
+
+	if err != nil || previous == nil {
+		plan.log.Warn().Err(err).Msg("no previous plan state, executing every operation")
+
+		return
+	}
+
+	plan.previousKeys = make(map[string]string, len(previous.Operations))
+	plan.previousDigests = make(map[string]string, len(previous.Operations))
+
+	for _, op := range previous.Operations {
+		plan.previousKeys[op.Name] = op.CacheKey
+		plan.previousDigests[op.Name] = op.Digest
+	}
+}
+
+// computeCacheKeys returns the cache key of each operation of the plan that can be skipped when unchanged.
+func (plan *Plan) computeCacheKeys() map[operation]string {
+	keys := make(map[operation]string, len(plan.operations))
+	files := map[string]string{}
+
+	for _, op := range plan.operations {
+		if key := plan.cacheKey(op, keys, files); key != "" {
+			keys[op] = key
+		}
+	}
+
+	return keys
+}
+
+// cacheKey returns the cache key of op ("sha256:<hex>"), given the keys of the earlier operations and the
+// fingerprints of the files already hashed, or an empty string when op cannot be skipped.
+func (plan *Plan) cacheKey(op operation, keys map[operation]string, files map[string]string) string {
+	cacheable, ok := op.(cacheableOperation)
+	if !ok {
+		return ""
+	}
+
+	for _, image := range cacheable.cacheSources() {
+		if image != nil && image.Digest() == "" && image.producer == nil {
+			return ""
+		}
+	}
+
+	// Builds read their base images from the Dockerfile
+	if build, ok := op.(*Build); ok && !basesPinned(filepath.Join(build.context, build.dockerfile)) {
+		return ""
+	}
+
+	input := cacheInput{Kind: op.kind(), Settings: operationSettings(op), Files: map[string]string{}}
+
+	// Operations depending on one that always runs always run too
+	for _, dep := range op.dependencies() {
+		key, ok := keys[dep]
+		if !ok {
+			return ""
+		}
+
+		input.Dependencies = append(input.Dependencies, key)
+	}
+
+	paths := operationFiles(op)
+
+	switch op.(type) {
+	case *Scan, *Audit:
+		paths = append(paths, plan.exceptionsFile)
+	}
+
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+
+		if _, done := files[path]; !done {
+			files[path] = pathFingerprint(path)
+		}
+
+		input.Files[path] = files[path]
+	}
+
+	hasher := sha256.New()
+	// Encoding strings and maps of strings cannot fail
+	_ = json.NewEncoder(hasher).Encode(input)
+
+	return "sha256:" + hex.EncodeToString(hasher.Sum(nil))
+}
+
+// cacheableOperation is implemented by operations that can be skipped when unchanged. Operations checking
+// external state (version checks, base image checks, node maintenance...) or whose settings the state does not
+// describe always run.
+type cacheableOperation interface {
+	// cacheSources returns the images the operation reads.
+	cacheSources() []*Image
+}
+
+// outputOperation is implemented by operations whose output image other operations can read (see OutputImage).
+type outputOperation interface {
+	// outputDigest returns the output image, and the result holding the digest the operation pushed.
+	outputDigest() (*Image, *string)
+}
+
+// basesPinned reports whether every base image of the Dockerfile at path is pinned by digest.
+func basesPinned(path string) bool {
+	bases, err := dockerfile.ParseFile(path)
+	if err != nil {
+		return false
+	}
+
+	for _, base := range bases {
+		if !base.Pinned() || len(base.Unresolved) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// unchanged reports whether op has the cache key recorded for it by the last successful execution, and restores
+// the digest it pushed then.
+func (plan *Plan) unchanged(op operation) bool {
+	key := plan.cacheKeys[op]
+
+	return key != "" && plan.previousKeys[op.operationName()] == key && plan.restoreOutput(op)
+}
+
+// restoreOutput sets the digest op pushed in the last successful execution on its output image, as executing it
+// would, and reports whether the operations reading the image get a digest: when the state does not record it
+// (e.g., a build whose digest was not resolved), op must run if its output image is read.
+func (plan *Plan) restoreOutput(op operation) bool {
+	produced, ok := op.(outputOperation)
+	if !ok {
+		return true
+	}
+
+	output, result := produced.outputDigest()
+
+	recorded := plan.previousDigests[op.operationName()]
+
+	parsed, err := digest.Parse(recorded)
+	if err != nil {
+		return output.producer == nil
+	}
+
+	*result = recorded
+	output.ref.Digest = parsed
+
+	return true
+}
+
+// completedOperations returns the cache keys and digests of the operations the last execution completed (executed
+// or unchanged), by name, to record in the plan state.
+func (plan *Plan) completedOperations() (keys, digests map[string]string) {
+	keys, digests = map[string]string{}, map[string]string{}
+
+	for _, op := range plan.operations {
+		key := plan.cacheKeys[op]
+		if key == "" {
+			continue
+		}
+
+		if entry, ok := plan.report.Operation(op.operationName()); ok &&
+			(entry.Status == StatusSucceeded || entry.Status == StatusUnchanged) {
+			keys[op.operationName()] = key
+
+			if produced := operationDigest(op); produced != "" {
+				digests[op.operationName()] = produced
+			}
+		}
+	}
+
+	return keys, digests
+}
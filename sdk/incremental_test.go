@@ -0,0 +1,243 @@
+package sdk_test
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: With SkipUnchanged, an operation whose inputs match the cache key recorded in the state by the last
+// successful execution is reported unchanged instead of executed; changing its settings runs it again, and
+// operations reading images referenced by tag alone always run.
+func TestPlan_SkipUnchanged(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	digest := pushRandomImage(t, host+"/my-org/app:1.0.0")
+	pushRandomImage(t, host+"/my-org/tools:1.0.0")
+
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	execute := func(revision string) *sdk.Report {
+		t.Helper()
+
+		pinned, err := sdk.NewImage("my-org/app").Domain(host).Digest(digest).Build()
+		if err != nil {
+			t.Fatalf("Failed to create test image: %v", err)
+		}
+
+		stamped, err := sdk.NewImage("my-org/app").Domain(host).Version("stamped").Build()
+		if err != nil {
+			t.Fatalf("Failed to create test image: %v", err)
+		}
+
+		tagged, err := sdk.NewImage("my-org/tools").Domain(host).Version("1.0.0").Build()
+		if err != nil {
+			t.Fatalf("Failed to create test image: %v", err)
+		}
+
+		plan := sdk.NewPlan(testPlanName)
+		plan.StateTo(statePath)
+		plan.SkipUnchanged(true)
+
+		if _, err := plan.Mutate("stamp-app").Image(pinned).Destination(stamped).
+			SetLabel("org.opencontainers.image.revision", revision).Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		if _, err := plan.Mutate("stamp-tools").Image(tagged).SetUser("nonroot").Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		report, err := plan.ExecuteWithResult(t.Context())
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+
+		return report
+	}
+
+	tests := []struct {
+		revision  string
+		wantApp   sdk.OperationStatus
+		wantTools sdk.OperationStatus
+	}{
+		{"abc123", sdk.StatusSucceeded, sdk.StatusSucceeded},
+		{"abc123", sdk.StatusUnchanged, sdk.StatusSucceeded},
+		{"def456", sdk.StatusSucceeded, sdk.StatusSucceeded},
+		{"def456", sdk.StatusUnchanged, sdk.StatusSucceeded},
+	}
+
+	for run, tt := range tests {
+		report := execute(tt.revision)
+
+		app, _ := report.Operation("stamp-app")
+		tools, _ := report.Operation("stamp-tools")
+
+		if app.Status != tt.wantApp || tools.Status != tt.wantTools {
+			t.Errorf("run %d: statuses = %s, %s, want %s, %s", run, app.Status, tools.Status, tt.wantApp,
+				tt.wantTools)
+		}
+	}
+
+	state, err := sdk.ReadState(statePath)
+	if err != nil {
+		t.Fatalf("ReadState() error = %v", err)
+	}
+
+	for _, op := range state.Operations {
+		if hasKey := op.CacheKey != ""; hasKey != (op.Name == "stamp-app") {
+			t.Errorf("%s: CacheKey = %q, want one only for the operation reading pinned images", op.Name, op.CacheKey)
+		}
+	}
+}
+
+// INTENTION: An operation skipped as unchanged gives the operations reading its output image the digest it pushed
+// last, recorded in the state: a sync promoting the output of an unchanged sync runs with the staged digest.
+func TestPlan_SkipUnchanged_OutputImage(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	digest := pushRandomImage(t, host+"/source/app:1.0.0")
+
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	execute := func(promote bool) (*sdk.Report, *sdk.Sync) {
+		t.Helper()
+
+		source, err := sdk.NewImage("source/app").Domain(host).Version("1.0.0").Digest(digest).Build()
+		if err != nil {
+			t.Fatalf("Failed to create source image: %v", err)
+		}
+
+		staging, err := sdk.NewImage("staging/app").Domain(host).Version("1.0.0").Build()
+		if err != nil {
+			t.Fatalf("Failed to create staging image: %v", err)
+		}
+
+		release, err := sdk.NewImage("release/app").Domain(host).Version("1.0.0").Build()
+		if err != nil {
+			t.Fatalf("Failed to create release image: %v", err)
+		}
+
+		plan := sdk.NewPlan(testPlanName)
+		plan.StateTo(statePath)
+		plan.SkipUnchanged(true)
+
+		stage, err := plan.Sync("stage").Source(source).Destination(staging).Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		var promotion *sdk.Sync
+
+		if promote {
+			promotion, err = plan.Sync("promote").Source(stage.OutputImage()).Destination(release).Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+		}
+
+		report, err := plan.ExecuteWithResult(t.Context())
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+
+		return report, promotion
+	}
+
+	execute(false)
+
+	tests := []struct {
+		wantStage   sdk.OperationStatus
+		wantPromote sdk.OperationStatus
+	}{
+		{sdk.StatusUnchanged, sdk.StatusSucceeded},
+		{sdk.StatusUnchanged, sdk.StatusUnchanged},
+	}
+
+	for run, tt := range tests {
+		report, promotion := execute(true)
+
+		stage, _ := report.Operation("stage")
+		promote, _ := report.Operation("promote")
+
+		if stage.Status != tt.wantStage || promote.Status != tt.wantPromote {
+			t.Errorf("run %d: statuses = %s, %s, want %s, %s", run, stage.Status, promote.Status, tt.wantStage,
+				tt.wantPromote)
+		}
+
+		if promotion.DestDigest() != digest {
+			t.Errorf("run %d: promoted digest = %q, want %q", run, promotion.DestDigest(), digest)
+		}
+	}
+}
+
+// INTENTION: Settings changing what a sync checks or pushes are part of its cache key: enabling blob verification
+// or the previous digest record runs an unchanged sync again.
+func TestPlan_SkipUnchanged_SyncSettings(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	digest := pushRandomImage(t, host+"/source/app:1.0.0")
+
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	tests := []struct {
+		verifyBlobs    bool
+		recordPrevious bool
+		want           sdk.OperationStatus
+	}{
+		{want: sdk.StatusSucceeded},
+		{want: sdk.StatusUnchanged},
+		{verifyBlobs: true, want: sdk.StatusSucceeded},
+		{verifyBlobs: true, want: sdk.StatusUnchanged},
+		{verifyBlobs: true, recordPrevious: true, want: sdk.StatusSucceeded},
+	}
+
+	for run, tt := range tests {
+		source, err := sdk.NewImage("source/app").Domain(host).Version("1.0.0").Digest(digest).Build()
+		if err != nil {
+			t.Fatalf("Failed to create source image: %v", err)
+		}
+
+		destination, err := sdk.NewImage("mirror/app").Domain(host).Version("1.0.0").Build()
+		if err != nil {
+			t.Fatalf("Failed to create destination image: %v", err)
+		}
+
+		plan := sdk.NewPlan(testPlanName)
+		plan.StateTo(statePath)
+		plan.SkipUnchanged(true)
+
+		if _, err := plan.Sync("mirror").Source(source).Destination(destination).VerifyBlobs(tt.verifyBlobs).
+			RecordPreviousDigest(tt.recordPrevious).Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		report, err := plan.ExecuteWithResult(t.Context())
+		if err != nil {
+			t.Fatalf("run %d: Execute() error = %v", run, err)
+		}
+
+		if result, _ := report.Operation("mirror"); result.Status != tt.want {
+			t.Errorf("run %d: status = %s, want %s", run, result.Status, tt.want)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// Index represents pushing a multi-platform manifest list of existing single-platform images, each listed under
+// the platform of its config: for images built and tagged per architecture (e.g., app:1.0-amd64, app:1.0-arm64)
+// published under one tag (app:1.0).
+type Index struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	sources    []string
+	images     []*Image
+	registries []*Registry
+	tag        string
+	output     *Image
+
+	// outputRegistry is set at Build() time, from the tag domain
+	outputRegistry *Registry
+
+	// Results populated after execution
+	digest    string
+	platforms []string
+}
+
+// IndexBuilder builds an Index.
+type IndexBuilder struct {
+	builderState
+
+	plan  *Plan
+	index *Index
+}
+
+// Add adds a single-platform image to the manifest list (e.g., "ghcr.io/my-org/app:1.0-arm64"), by tag or digest.
+// Its platform is read from its config: images must be of distinct platforms.
+func (builder *IndexBuilder) Add(image string) *IndexBuilder {
+	builder.index.sources = append(builder.index.sources, image)
+
+	return builder
+}
+
+// Tag sets the tag the manifest list is pushed to (e.g., "ghcr.io/my-org/app:1.0").
+func (builder *IndexBuilder) Tag(tag string) *IndexBuilder {
+	builder.index.tag = tag
+
+	return builder
+}
+
+// RunOnlyOn restricts the manifest list push to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *IndexBuilder) RunOnlyOn(envs ...Environment) *IndexBuilder {
+	builder.index.runOnlyOn = append(builder.index.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the manifest list push mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *IndexBuilder) Resource(resource Resource) *IndexBuilder {
+	builder.index.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the manifest list push start only once the given operations (e.g., the builds pushing the
+// images), built before it in the plan, completed (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *IndexBuilder) DependsOn(ops ...Dependency) *IndexBuilder {
+	builder.index.add(ops)
+
+	return builder
+}
+
+// When makes the manifest list push run only if the given conditions all hold once the operations it depends on
+// completed; otherwise it is skipped. A failing condition fails the push.
+func (builder *IndexBuilder) When(conditions ...Condition) *IndexBuilder {
+	builder.index.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a manifest list push named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *IndexBuilder) Clone(name string) *IndexBuilder {
+	clone := builder.plan.Index(name)
+	clone.index.envGuard = builder.index.envGuard.clone()
+	clone.index.resourceHint = builder.index.resourceHint
+	clone.index.dependencyList = builder.index.dependencyList.clone()
+	clone.index.conditionList = builder.index.conditionList.clone()
+	clone.index.sources = slices.Clone(builder.index.sources)
+	clone.index.tag = builder.index.tag
+
+	return clone
+}
+
+// Reset makes the builder usable again for a manifest list push named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *IndexBuilder) Reset(name string) *IndexBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build validates and adds the manifest list push to the plan.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *IndexBuilder) Build() (*Index, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.index.opName); err != nil {
+		return nil, err
+	}
+
+	index := builder.index
+
+	if len(index.sources) == 0 {
+		return nil, ErrIndexImageRequired
+	}
+
+	if index.tag == "" {
+		return nil, ErrIndexTagRequired
+	}
+
+	for _, source := range index.sources {
+		image, err := NewImage(source).Build()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %w", ErrInvalidIndexImage, source, err)
+		}
+
+		if err := image.checkRegistry(); err != nil {
+			return nil, err
+		}
+
+		index.images = append(index.images, image)
+		index.registries = append(index.registries, builder.plan.getRegistry(image.Domain()))
+	}
+
+	output, err := NewImage(index.tag).Build()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %w", ErrInvalidIndexTag, index.tag, err)
+	}
+
+	if err := output.checkRegistry(); err != nil {
+		return nil, err
+	}
+
+	if output.Version() == "" {
+		return nil, fmt.Errorf("%w for image %q", ErrIndexVersionRequired, output.Name())
+	}
+
+	index.output = output
+	index.outputRegistry = builder.plan.getRegistry(output.Domain())
+
+	builder.plan.indexes = append(builder.plan.indexes, index)
+	builder.plan.addOperation(index)
+
+	return index, nil
+}
+
+func (index *Index) execute(ctx context.Context) error {
+	tagRef, err := index.output.tagRef()
+	if err != nil {
+		return fmt.Errorf("failed to build tag reference: %w", err)
+	}
+
+	images := make([]registry.RemoteImage, 0, len(index.images))
+
+	for idx, image := range index.images {
+		ref, err := image.pullRef()
+		if err != nil {
+			return fmt.Errorf("failed to build image reference: %w", err)
+		}
+
+		client := newRegistryClient(index.registries[idx], index.log)
+		images = append(images, registry.RemoteImage{Ref: ref, Client: client})
+	}
+
+	index.log.Info().
+		Strs("images", index.sources).
+		Str("tag", tagRef).
+		Msg("pushing manifest list")
+
+	platforms, pushed, err := newRegistryClient(index.outputRegistry, index.log).CombineImages(ctx, images, tagRef)
+	if err != nil {
+		return fmt.Errorf("failed to push manifest list %s: %w", tagRef, err)
+	}
+
+	index.digest = pushed
+	index.platforms = platforms
+	// Subsequent operations (e.g., scanning) see the pushed manifest list
+	index.output.ref.Digest = digest.Digest(pushed)
+
+	index.log.Info().
+		Str("tag", tagRef).
+		Str("digest", pushed).
+		Strs("platforms", platforms).
+		Msg("manifest list pushed")
+
+	return nil
+}
+
+// plannedChanges implements dryRunOperation: the images must exist, when their digest is known.
+func (index *Index) plannedChanges(ctx context.Context) ([]string, error) {
+	refs := make([]string, 0, len(index.images))
+
+	for idx, image := range index.images {
+		ref, err := checkImage(ctx, newRegistryClient(index.registries[idx], index.log), image)
+		if err != nil {
+			return nil, err
+		}
+
+		refs = append(refs, ref)
+	}
+
+	tagRef, err := index.output.tagRef()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build tag reference: %w", err)
+	}
+
+	return []string{fmt.Sprintf("Would push a manifest list of %s to %s", strings.Join(refs, ", "), tagRef)}, nil
+}
+
+// destructiveChange implements destructiveOperation: the manifest list overwrites the tag.
+func (index *Index) destructiveChange(ctx context.Context) (string, error) {
+	tagRef, err := index.output.tagRef()
+	if err != nil {
+		return "", fmt.Errorf("failed to build tag reference: %w", err)
+	}
+
+	return tagOverwrite(ctx, newRegistryClient(index.outputRegistry, index.log), tagRef, "")
+}
+
+// OutputImage returns the manifest list pushed to the tag, for the operations reading it (e.g., ScanBuilder.Source):
+// they depend on the push without DependsOn, and use the digest of the manifest list once pushed.
+func (index *Index) OutputImage() *Image {
+	index.output.producer = index
+
+	return index.output
+}
+
+// Digest returns the digest of the pushed manifest list (empty before execution).
+func (index *Index) Digest() string {
+	return index.digest
+}
+
+// Platforms returns the platforms of the pushed manifest list (e.g., linux/amd64), sorted (empty before execution).
+func (index *Index) Platforms() []string {
+	return index.platforms
+}
+
+// cacheSources implements cacheableOperation: a manifest list reads the platform images.
+func (index *Index) cacheSources() []*Image {
+	return index.images
+}
+
+// outputDigest implements outputOperation.
+func (index *Index) outputDigest() (*Image, *string) {
+	return index.output, &index.digest
+}
+
+// producedDigest implements producingOperation.
+func (index *Index) producedDigest() string {
+	return index.Digest()
+}
+
+// destination implements destinationOperation: the output image.
+func (index *Index) destination() *Image {
+	return index.output
+}
+
+// resolveRegistries implements registryOperation.
+func (index *Index) resolveRegistries(lookup func(image *Image) *Registry) {
+	for idx, image := range index.images {
+		index.registries[idx] = lookup(image)
+	}
+
+	index.outputRegistry = lookup(index.output)
+}
@@ -0,0 +1,164 @@
+package sdk_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: A manifest list requires at least one image and a tag with a version, all valid image references.
+func TestIndexBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		build   func(*sdk.Plan) (*sdk.Index, error)
+		wantErr error
+	}{
+		{
+			name: "valid",
+			build: func(plan *sdk.Plan) (*sdk.Index, error) {
+				return plan.Index("index").Add("ghcr.io/my-org/app:1.0-amd64").Add("ghcr.io/my-org/app:1.0-arm64").
+					Tag("ghcr.io/my-org/app:1.0").Build()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "missing image",
+			build: func(plan *sdk.Plan) (*sdk.Index, error) {
+				return plan.Index("index").Tag("ghcr.io/my-org/app:1.0").Build()
+			},
+			wantErr: sdk.ErrIndexImageRequired,
+		},
+		{
+			name: "missing tag",
+			build: func(plan *sdk.Plan) (*sdk.Index, error) {
+				return plan.Index("index").Add("ghcr.io/my-org/app:1.0-amd64").Build()
+			},
+			wantErr: sdk.ErrIndexTagRequired,
+		},
+		{
+			name: "invalid image",
+			build: func(plan *sdk.Plan) (*sdk.Index, error) {
+				return plan.Index("index").Add("ghcr.io/My-Org/app:1.0-amd64").Tag("ghcr.io/my-org/app:1.0").Build()
+			},
+			wantErr: sdk.ErrInvalidIndexImage,
+		},
+		{
+			name: "pinned tag",
+			build: func(plan *sdk.Plan) (*sdk.Index, error) {
+				return plan.Index("index").Add("ghcr.io/my-org/app:1.0-amd64").
+					Tag("ghcr.io/my-org/app@" + testDigest).Build()
+			},
+			wantErr: sdk.ErrIndexVersionRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			index, err := tt.build(sdk.NewPlan(testPlanName))
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil || index == nil {
+				t.Errorf("Build() = %v, %v, want a manifest list", index, err)
+			}
+		})
+	}
+}
+
+// INTENTION: Executing a manifest list pushes the per-architecture images under one tag, each under the platform
+// of its config, and pins the output image for subsequent operations.
+func TestIndex_Execute(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	for _, arch := range []string{"amd64", "arm64"} {
+		img, err := random.Image(256, 1)
+		if err != nil {
+			t.Fatalf("Failed to create random image: %v", err)
+		}
+
+		config, err := img.ConfigFile()
+		if err != nil {
+			t.Fatalf("Failed to get config: %v", err)
+		}
+
+		config.OS = "linux"
+		config.Architecture = arch
+
+		img, err = mutate.ConfigFile(img, config)
+		if err != nil {
+			t.Fatalf("Failed to set config: %v", err)
+		}
+
+		ref, err := name.ParseReference(host + "/my-org/app:1.0-" + arch)
+		if err != nil {
+			t.Fatalf("Failed to parse reference: %v", err)
+		}
+
+		if err := remote.Write(ref, img); err != nil {
+			t.Fatalf("Failed to push image: %v", err)
+		}
+	}
+
+	plan := sdk.NewPlan(testPlanName)
+
+	index, err := plan.Index("index").Add(host + "/my-org/app:1.0-amd64").Add(host + "/my-org/app:1.0-arm64").
+		Tag(host + "/my-org/app:1.0").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	output := index.OutputImage()
+
+	if err := plan.Execute(t.Context()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if want := []string{"linux/amd64", "linux/arm64"}; !slices.Equal(index.Platforms(), want) {
+		t.Errorf("Platforms() = %v, want %v", index.Platforms(), want)
+	}
+
+	if index.Digest() == "" || output.Digest() != index.Digest() {
+		t.Errorf("Digest() = %q, output digest = %q, want the pushed manifest list", index.Digest(), output.Digest())
+	}
+
+	ref, err := name.ParseReference(host + "/my-org/app:1.0")
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+
+	idx, err := remote.Index(ref)
+	if err != nil {
+		t.Fatalf("Failed to get manifest list: %v", err)
+	}
+
+	if manifest, err := idx.IndexManifest(); err != nil || len(manifest.Manifests) != 2 {
+		t.Errorf("IndexManifest() = %v, %v, want the two platforms", manifest, err)
+	}
+}
@@ -0,0 +1,122 @@
+package sdk
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// junitSuites is the root element of a JUnit XML report.
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+// junitSuite is the plan run.
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Errors    int         `xml:"errors,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+	SystemOut string      `xml:"system-out,omitempty"`
+}
+
+// junitCase is an operation.
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Skipped   *junitMessage `xml:"skipped"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// junitMessage is a failure or skip, with its message and details.
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// junitSeconds formats a duration as JUnit times are: seconds, with millisecond precision.
+func junitSeconds(duration time.Duration) string {
+	return fmt.Sprintf("%.3f", duration.Seconds())
+}
+
+// writeJUnit renders the report as JUnit XML, for CI systems showing test reports (Jenkins, GitLab, ...):
+// one test suite for the plan, one test case per operation (class name "<plan>.<kind>"). Failures carry the
+// operation error and details (e.g., vulnerability counts per platform, audit issues); skipped and not run
+// operations are skipped test cases; details of succeeded and planned operations are their standard output.
+func (report *Report) writeJUnit(out io.Writer) error {
+	suite := junitSuite{
+		Name:      report.Plan,
+		Time:      junitSeconds(report.Duration),
+		Timestamp: report.Started.Format("2006-01-02T15:04:05"),
+		SystemOut: strings.Join(append(report.summary(), report.Warnings...), "\n"),
+	}
+
+	for _, op := range report.Operations {
+		testCase := junitCase{
+			Name:      op.Name,
+			ClassName: report.Plan + "." + op.Kind,
+			Time:      junitSeconds(op.Duration),
+		}
+
+		details := strings.Join(op.Details, "\n")
+
+		switch op.Status {
+		case StatusFailed:
+			suite.Failures++
+			testCase.Failure = &junitMessage{
+				Message: op.Error,
+				Type:    op.Kind,
+				Text:    strings.TrimSpace(op.Error + "\n" + details),
+			}
+		case StatusSkipped, StatusNotRun, StatusUnchanged:
+			suite.Skipped++
+			testCase.Skipped = &junitMessage{Message: string(op.Status)}
+		default:
+			testCase.SystemOut = details
+		}
+
+		suite.Tests++
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	doc := junitSuites{
+		Name:     report.Plan,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitSuite{suite},
+	}
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+
+	if _, err := io.WriteString(out, "\n"); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return nil
+}
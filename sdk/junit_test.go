@@ -0,0 +1,80 @@
+package sdk_test
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: JUnit reports have one test case per operation, failures carrying the error and the operation
+// details (e.g., vulnerabilities), and skipped or not run operations as skipped test cases, so CI systems render
+// quark runs as test reports.
+func TestReport_WriteJUnit(t *testing.T) {
+	t.Parallel()
+
+	report := &sdk.Report{
+		Plan:     "mirror",
+		Started:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Duration: 1500 * time.Millisecond,
+		Operations: []sdk.OperationReport{
+			{Name: "mirror-alpine", Kind: "sync", Status: sdk.StatusSucceeded, Duration: time.Second,
+				Details: []string{"Digest: sha256:abc"}},
+			{Name: "scan-alpine", Kind: "scan", Status: sdk.StatusFailed, Error: "critical vulnerabilities found",
+				Details: []string{"linux/amd64: CRITICAL=1 HIGH=3"}},
+			{Name: "ci-only", Kind: "sync", Status: sdk.StatusSkipped},
+			{Name: "after", Kind: "audit", Status: sdk.StatusNotRun},
+		},
+	}
+
+	var rendered strings.Builder
+	if err := report.Write(&rendered, sdk.ReportJUnit); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	var parsed struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Skipped  int `xml:"skipped,attr"`
+		Suites   []struct {
+			Name  string `xml:"name,attr"`
+			Cases []struct {
+				Name      string `xml:"name,attr"`
+				ClassName string `xml:"classname,attr"`
+				Failure   *struct {
+					Message string `xml:"message,attr"`
+					Text    string `xml:",chardata"`
+				} `xml:"failure"`
+				Skipped *struct {
+					Message string `xml:"message,attr"`
+				} `xml:"skipped"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+
+	if err := xml.Unmarshal([]byte(rendered.String()), &parsed); err != nil {
+		t.Fatalf("report is not XML: %v\n%s", err, rendered.String())
+	}
+
+	if parsed.Tests != 4 || parsed.Failures != 1 || parsed.Skipped != 2 {
+		t.Errorf("tests = %d, failures = %d, skipped = %d, want 4, 1 and 2",
+			parsed.Tests, parsed.Failures, parsed.Skipped)
+	}
+
+	if len(parsed.Suites) != 1 || len(parsed.Suites[0].Cases) != 4 {
+		t.Fatalf("suites = %+v, want one suite of 4 test cases", parsed.Suites)
+	}
+
+	failed := parsed.Suites[0].Cases[1]
+	if failed.ClassName != "mirror.scan" || failed.Failure == nil ||
+		failed.Failure.Message != "critical vulnerabilities found" ||
+		!strings.Contains(failed.Failure.Text, "CRITICAL=1 HIGH=3") {
+		t.Errorf("failed test case = %+v, want the scan error and vulnerabilities", failed)
+	}
+
+	if notRun := parsed.Suites[0].Cases[3]; notRun.Skipped == nil || notRun.Skipped.Message != "not run" {
+		t.Errorf("not run test case = %+v, want skipped", notRun)
+	}
+}
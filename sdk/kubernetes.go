@@ -0,0 +1,334 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/farcloser/quark/internal/kubernetes"
+	"github.com/farcloser/quark/internal/reference"
+)
+
+// KubernetesWorkload is a Kubernetes object running containers, found in manifests.
+type KubernetesWorkload struct {
+	// Kind is the object kind (e.g., "Deployment").
+	Kind string
+	// Namespace is the object namespace (empty when not set in the manifest).
+	Namespace string
+	// Name is the object name.
+	Name string
+	// Containers lists the containers of the pod template, init containers first.
+	Containers []KubernetesContainer
+}
+
+// String returns the workload as kind/name, prefixed with the namespace when set (e.g., "web/Deployment/api").
+func (workload KubernetesWorkload) String() string {
+	return kubernetes.Workload{Kind: workload.Kind, Namespace: workload.Namespace, Name: workload.Name}.String()
+}
+
+// KubernetesContainer is a container of a workload, with the operations checking its image.
+// Containers running the same image share the same operations.
+type KubernetesContainer struct {
+	// Name is the container name.
+	Name string
+	// Init reports whether the container is an init container.
+	Init bool
+	// Image is the container image. Tag-only images get their digest when the manifests operation executes.
+	Image *Image
+	// Scan scans the image (nil without KubernetesManifestsBuilder.Scan).
+	Scan *Scan
+	// VersionCheck checks the image for updates (nil without KubernetesManifestsBuilder.VersionCheck,
+	// or for images without tag).
+	VersionCheck *VersionCheck
+}
+
+// KubernetesManifests represents gating Kubernetes manifests (plain YAML or Helm-rendered output):
+// the images of their workloads are resolved to digests, and scanned and checked for updates
+// by one Scan and VersionCheck per distinct image, added to the plan right after it.
+type KubernetesManifests struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	files        []string
+	scan         *ScanBuilder
+	versionCheck bool
+
+	// Populated by Build()
+	workloads []KubernetesWorkload
+	images    []*Image
+	registry  map[*Image]*Registry
+}
+
+// KubernetesManifestsBuilder builds a KubernetesManifests operation.
+type KubernetesManifestsBuilder struct {
+	builderState
+
+	plan      *Plan
+	manifests *KubernetesManifests
+}
+
+// Files adds manifest files. Each file can hold several YAML documents (e.g., `helm template` output)
+// and List objects.
+func (builder *KubernetesManifestsBuilder) Files(paths ...string) *KubernetesManifestsBuilder {
+	builder.manifests.files = append(builder.manifests.files, paths...)
+
+	return builder
+}
+
+// Scan scans each distinct image with the configuration of template, a scan builder that is not built itself
+// (e.g., plan.Scan("policy").Severity(sdk.SeverityCritical)): scans are derived from it with Clone().
+func (builder *KubernetesManifestsBuilder) Scan(template *ScanBuilder) *KubernetesManifestsBuilder {
+	builder.manifests.scan = template
+
+	return builder
+}
+
+// VersionCheck checks each distinct tagged image for a newer version.
+func (builder *KubernetesManifestsBuilder) VersionCheck(enabled bool) *KubernetesManifestsBuilder {
+	builder.manifests.versionCheck = enabled
+
+	return builder
+}
+
+// RunOnlyOn restricts the operation and the scans and version checks it adds to the given environments
+// (e.g., sdk.EnvCI): they are skipped when the plan is executed elsewhere.
+func (builder *KubernetesManifestsBuilder) RunOnlyOn(envs ...Environment) *KubernetesManifestsBuilder {
+	builder.manifests.runOnlyOn = append(builder.manifests.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the operation mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *KubernetesManifestsBuilder) Resource(resource Resource) *KubernetesManifestsBuilder {
+	builder.manifests.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the operation start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *KubernetesManifestsBuilder) DependsOn(ops ...Dependency) *KubernetesManifestsBuilder {
+	builder.manifests.add(ops)
+
+	return builder
+}
+
+// When makes the operation run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the operation.
+func (builder *KubernetesManifestsBuilder) When(conditions ...Condition) *KubernetesManifestsBuilder {
+	builder.manifests.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a manifests operation named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *KubernetesManifestsBuilder) Clone(name string) *KubernetesManifestsBuilder {
+	clone := builder.plan.KubernetesManifests(name)
+	clone.manifests.envGuard = builder.manifests.envGuard.clone()
+	clone.manifests.resourceHint = builder.manifests.resourceHint
+	clone.manifests.dependencyList = builder.manifests.dependencyList.clone()
+	clone.manifests.conditionList = builder.manifests.conditionList.clone()
+	clone.manifests.files = slices.Clone(builder.manifests.files)
+	clone.manifests.scan = builder.manifests.scan
+	clone.manifests.versionCheck = builder.manifests.versionCheck
+
+	return clone
+}
+
+// Reset makes the builder usable again for a manifests operation named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *KubernetesManifestsBuilder) Reset(name string) *KubernetesManifestsBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build parses the manifests, and adds the operation to the plan, followed by a Scan and a VersionCheck
+// for each distinct image, as configured. They are named after the operation and the image
+// (e.g., "cluster/scan/nginx:1.27").
+// Registry credentials are looked up from the plan's registry collection using each image domain.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *KubernetesManifestsBuilder) Build() (*KubernetesManifests, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.manifests.opName); err != nil {
+		return nil, err
+	}
+
+	manifests := builder.manifests
+
+	if len(manifests.files) == 0 {
+		return nil, ErrKubernetesManifestsRequired
+	}
+
+	if manifests.scan == nil && !manifests.versionCheck {
+		return nil, ErrKubernetesTaskRequired
+	}
+
+	var workloads []kubernetes.Workload
+
+	for _, file := range manifests.files {
+		parsed, err := kubernetes.ParseFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		workloads = append(workloads, parsed...)
+	}
+
+	// One image per distinct reference, shared by the containers running it
+	images := make(map[string]*Image)
+	manifests.registry = make(map[*Image]*Registry)
+
+	for _, workload := range workloads {
+		for _, ctr := range workload.Containers {
+			if _, ok := images[ctr.Image]; ok {
+				continue
+			}
+
+			image, err := manifestImage(ctr.Image)
+			if err != nil {
+				return nil, fmt.Errorf("invalid image %q for container %q of %s: %w", ctr.Image, ctr.Name, workload, err)
+			}
+
+			images[ctr.Image] = image
+			manifests.images = append(manifests.images, image)
+			manifests.registry[image] = builder.plan.getRegistry(image.Domain())
+		}
+	}
+
+	builder.plan.addOperation(manifests)
+
+	scans := make(map[*Image]*Scan)
+	checks := make(map[*Image]*VersionCheck)
+
+	for _, image := range manifests.images {
+		ref := image.String()
+
+		if manifests.scan != nil {
+			scanBuilder := manifests.scan.Clone(manifests.opName + "/scan/" + ref).Source(image)
+			scanBuilder.scan.runOnlyOn = append(scanBuilder.scan.runOnlyOn, manifests.runOnlyOn...)
+
+			scan, err := scanBuilder.Build()
+			if err != nil {
+				return nil, err
+			}
+
+			scans[image] = scan
+		}
+
+		if manifests.versionCheck && image.Version() != "" {
+			checkBuilder := builder.plan.VersionCheck(manifests.opName + "/version-check/" + ref).Source(image)
+			checkBuilder.check.runOnlyOn = slices.Clone(manifests.runOnlyOn)
+
+			check, err := checkBuilder.Build()
+			if err != nil {
+				return nil, err
+			}
+
+			checks[image] = check
+		}
+	}
+
+	for _, workload := range workloads {
+		entry := KubernetesWorkload{Kind: workload.Kind, Namespace: workload.Namespace, Name: workload.Name}
+
+		for _, ctr := range workload.Containers {
+			image := images[ctr.Image]
+
+			entry.Containers = append(entry.Containers, KubernetesContainer{
+				Name:         ctr.Name,
+				Init:         ctr.Init,
+				Image:        image,
+				Scan:         scans[image],
+				VersionCheck: checks[image],
+			})
+		}
+
+		manifests.workloads = append(manifests.workloads, entry)
+	}
+
+	return manifests, nil
+}
+
+// manifestImage returns the image of a container reference. Untagged references use "latest", like Kubernetes.
+func manifestImage(rawRef string) (*Image, error) {
+	ref, err := reference.Parse(rawRef)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref.Protocol != "" {
+		return nil, ErrImageNotInRegistry
+	}
+
+	builder := NewImage(ref.Name())
+	if ref.ExplicitTag != "" || ref.Digest == "" {
+		builder.Version(ref.Tag)
+	}
+
+	if ref.Digest != "" {
+		builder.Digest(ref.Digest.String())
+	}
+
+	return builder.Build()
+}
+
+func (manifests *KubernetesManifests) execute(ctx context.Context) error {
+	manifests.log.Info().
+		Int("workloads", len(manifests.workloads)).
+		Int("images", len(manifests.images)).
+		Msg("resolving Kubernetes manifest images")
+
+	// Scans require digests: resolve tags once, so all operations on an image see the same content
+	for _, image := range manifests.images {
+		if image.Digest() != "" {
+			continue
+		}
+
+		tagRef, err := image.tagRef()
+		if err != nil {
+			return fmt.Errorf("failed to build tag reference: %w", err)
+		}
+
+		resolved, err := newRegistryClient(manifests.registry[image], manifests.log).GetDigest(ctx, tagRef)
+		if err != nil {
+			return fmt.Errorf("failed to resolve image %q: %w", tagRef, err)
+		}
+
+		parsed, err := digest.Parse(resolved)
+		if err != nil {
+			return fmt.Errorf("failed to parse resolved digest: %w", err)
+		}
+
+		image.ref.Digest = parsed
+
+		manifests.log.Debug().Str("image", tagRef).Str("digest", resolved).Msg("image resolved")
+	}
+
+	for _, workload := range manifests.workloads {
+		refs := make([]string, 0, len(workload.Containers))
+		for _, ctr := range workload.Containers {
+			refs = append(refs, ctr.Image.String())
+		}
+
+		manifests.log.Info().Str("workload", workload.String()).Strs("images", refs).Msg("workload images")
+	}
+
+	return nil
+}
+
+// Workloads returns the workloads found in the manifests, in order, with the operations checking their images.
+func (manifests *KubernetesManifests) Workloads() []KubernetesWorkload {
+	return manifests.workloads
+}
@@ -0,0 +1,154 @@
+package sdk_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: images of manifest workloads are resolved to digests once, and each distinct image is checked
+// by a single operation shared by the containers running it.
+func TestKubernetesManifests(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+	current := pushRandomImage(t, host+"/my-org/api:1.0")
+	pushRandomImage(t, host+"/my-org/api:1.1")
+
+	content := "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: api\n  namespace: web\nspec:\n" +
+		"  template:\n    spec:\n      initContainers:\n        - name: migrate\n          image: " +
+		host + "/my-org/api:1.0\n      containers:\n        - name: api\n          image: " +
+		host + "/my-org/api:1.0\n"
+
+	path := filepath.Join(t.TempDir(), "manifests.yaml")
+	if err := os.WriteFile(path, []byte(content), filesystem.FilePermissionsDefault); err != nil {
+		t.Fatalf("Failed to write manifests: %v", err)
+	}
+
+	plan := sdk.NewPlan(testPlanName)
+
+	manifests, err := plan.KubernetesManifests("cluster").Files(path).VersionCheck(true).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if err := plan.Execute(context.Background()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	workloads := manifests.Workloads()
+	if len(workloads) != 1 || len(workloads[0].Containers) != 2 {
+		t.Fatalf("Workloads() = %+v, want one workload with two containers", workloads)
+	}
+
+	if got := workloads[0].String(); got != "web/Deployment/api" {
+		t.Errorf("String() = %q, want %q", got, "web/Deployment/api")
+	}
+
+	migrate, api := workloads[0].Containers[0], workloads[0].Containers[1]
+
+	if !migrate.Init || api.Init {
+		t.Errorf("Init = %v, %v, want true, false", migrate.Init, api.Init)
+	}
+
+	if migrate.Image != api.Image || migrate.VersionCheck != api.VersionCheck {
+		t.Error("Containers running the same image should share the image and its version check")
+	}
+
+	if got := api.Image.Digest(); got != current {
+		t.Errorf("Image.Digest() = %q, want %q", got, current)
+	}
+
+	if api.Scan != nil {
+		t.Error("Scan should be nil without Scan()")
+	}
+
+	if !api.VersionCheck.UpdateAvailable() || api.VersionCheck.LatestVersion() != "1.1" {
+		t.Errorf("VersionCheck latest = %q, want update to 1.1", api.VersionCheck.LatestVersion())
+	}
+}
+
+// INTENTION: KubernetesManifests requires manifest files, at least one task, and images stored in registries.
+func TestKubernetesManifestsBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.yaml")
+	if err := os.WriteFile(valid, []byte("kind: Pod\nmetadata:\n  name: web\nspec:\n  containers:\n"+
+		"    - name: web\n      image: nginx\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write manifests: %v", err)
+	}
+
+	archive := filepath.Join(dir, "archive.yaml")
+	if err := os.WriteFile(archive, []byte("kind: Pod\nmetadata:\n  name: web\nspec:\n  containers:\n"+
+		"    - name: web\n      image: oci-archive:/tmp/web.tar\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write manifests: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		configure func(*sdk.Plan, *sdk.KubernetesManifestsBuilder) *sdk.KubernetesManifestsBuilder
+		wantErr   error
+	}{
+		{
+			name: "scan",
+			configure: func(plan *sdk.Plan, builder *sdk.KubernetesManifestsBuilder) *sdk.KubernetesManifestsBuilder {
+				return builder.Files(valid).Scan(plan.Scan("policy").Severity(sdk.SeverityCritical))
+			},
+		},
+		{
+			name: "missing files",
+			configure: func(plan *sdk.Plan, builder *sdk.KubernetesManifestsBuilder) *sdk.KubernetesManifestsBuilder {
+				return builder.VersionCheck(true)
+			},
+			wantErr: sdk.ErrKubernetesManifestsRequired,
+		},
+		{
+			name: "no task",
+			configure: func(plan *sdk.Plan, builder *sdk.KubernetesManifestsBuilder) *sdk.KubernetesManifestsBuilder {
+				return builder.Files(valid)
+			},
+			wantErr: sdk.ErrKubernetesTaskRequired,
+		},
+		{
+			name: "archive image",
+			configure: func(plan *sdk.Plan, builder *sdk.KubernetesManifestsBuilder) *sdk.KubernetesManifestsBuilder {
+				return builder.Files(archive).VersionCheck(true)
+			},
+			wantErr: sdk.ErrImageNotInRegistry,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			plan := sdk.NewPlan(testPlanName)
+
+			_, err := tt.configure(plan, plan.KubernetesManifests("cluster")).Build()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
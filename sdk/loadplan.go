@@ -0,0 +1,1425 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/farcloser/quark/internal/cron"
+	"github.com/farcloser/quark/internal/plantemplate"
+)
+
+// LoadOptions configure LoadPlanWithOptions.
+type LoadOptions struct {
+	// Strict fails loading on undefined template variables and unset environment variables,
+	// instead of rendering them as empty values.
+	Strict bool
+	// Vars is the template data of the document (e.g., {"images": [...]} for {{ range .images }}).
+	Vars map[string]any
+}
+
+// planDocument is a declarative plan (YAML or JSON).
+type planDocument struct {
+	Name                string                   `json:"name"`
+	Schedule            string                   `json:"schedule"`
+	MaxParallelism      int                      `json:"maxParallelism"`
+	RegistryConcurrency map[string]int           `json:"registryConcurrency"`
+	DefaultPlatforms    []string                 `json:"defaultPlatforms"`
+	KnownExploitedFeed  string                   `json:"knownExploitedFeed"`
+	Exceptions          string                   `json:"exceptions"`
+	SyncWebhook         *webhookDocument         `json:"syncWebhook"`
+	Notifications       []notifyDocument         `json:"notifications"`
+	Registries          []registryDocument       `json:"registries"`
+	Profiles            []profileDocument        `json:"profiles"`
+	Includes            []string                 `json:"includes"`
+	RewriteRules        []rewriteRuleDocument    `json:"rewriteRules"`
+	Images              map[string]string        `json:"images"`
+	BuildNodes          []buildNodeDocument      `json:"buildNodes"`
+	HarborProjects      []harborDocument         `json:"harborProjects"`
+	VersionChecks       []versionCheckDocument   `json:"versionChecks"`
+	Verifications       []verifyDocument         `json:"verifications"`
+	Syncs               []syncDocument           `json:"syncs"`
+	Builds              []buildDocument          `json:"builds"`
+	Rebases             []rebaseDocument         `json:"rebases"`
+	Flattens            []flattenDocument        `json:"flattens"`
+	Mutations           []mutateDocument         `json:"mutations"`
+	Indexes             []indexDocument          `json:"indexes"`
+	GHCRPackages        []ghcrPackageDocument    `json:"ghcrPackages"`
+	RepositoryDocs      []repositoryDocsDocument `json:"repositoryDocs"`
+	Scans               []scanDocument           `json:"scans"`
+	Audits              []auditDocument          `json:"audits"`
+	Prunes              []pruneDocument          `json:"prunes"`
+}
+
+type registryDocument struct {
+	Host                string            `json:"host"`
+	Username            string            `json:"username"`
+	Password            string            `json:"password"`
+	Token               string            `json:"token"`
+	AutoCreateRepo      bool              `json:"autoCreateRepo"`
+	RepoTags            map[string]string `json:"repoTags"`
+	RepoLifecyclePolicy string            `json:"repoLifecyclePolicy"`
+}
+
+type profileDocument struct {
+	Name       string             `json:"name"`
+	Registries []registryDocument `json:"registries"`
+	Domains    map[string]string  `json:"domains"`
+	TagSuffix  string             `json:"tagSuffix"`
+}
+
+type webhookDocument struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret"`
+}
+
+type notifyDocument struct {
+	URL    string   `json:"url"`
+	Format string   `json:"format"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+type rewriteRuleDocument struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+type buildNodeDocument struct {
+	Name         string            `json:"name"`
+	Endpoint     string            `json:"endpoint"`
+	Platform     string            `json:"platform"`
+	Labels       map[string]string `json:"labels"`
+	ForwardAgent bool              `json:"forwardAgent"`
+}
+
+// operationDocument holds the settings shared by all operations.
+type operationDocument struct {
+	Name      string        `json:"name"`
+	RunOnlyOn []Environment `json:"runOnlyOn"`
+	Resource  Resource      `json:"resource"`
+	DependsOn []string      `json:"dependsOn"`
+}
+
+// retryDocument is the retry policy of an operation (see SyncBuilder.Retry).
+type retryDocument struct {
+	Attempts int    `json:"attempts"`
+	Backoff  string `json:"backoff"`
+}
+
+type versionCheckDocument struct {
+	operationDocument
+
+	Source string         `json:"source"`
+	Retry  *retryDocument `json:"retry"`
+}
+
+type harborDocument struct {
+	operationDocument
+
+	URL               string                    `json:"url"`
+	Username          string                    `json:"username"`
+	Password          string                    `json:"password"`
+	Project           string                    `json:"project"`
+	Public            bool                      `json:"public"`
+	Retention         []harborRetentionDocument `json:"retention"`
+	RetentionSchedule string                    `json:"retentionSchedule"`
+	ImmutableTags     []string                  `json:"immutableTags"`
+	Robot             string                    `json:"robot"`
+}
+
+type harborRetentionDocument struct {
+	Tags       string `json:"tags"`
+	KeepLatest int    `json:"keepLatest"`
+	KeepDays   int    `json:"keepDays"`
+}
+
+type syncDocument struct {
+	operationDocument
+
+	Source               string         `json:"source"`
+	Destination          string         `json:"destination"`
+	Platforms            []string       `json:"platforms"`
+	RecordPreviousDigest bool           `json:"recordPreviousDigest"`
+	VerifyBlobs          bool           `json:"verifyBlobs"`
+	VerifyPushedDigest   bool           `json:"verifyPushedDigest"`
+	DigestTagFallback    bool           `json:"digestTagFallback"`
+	CopySignatures       bool           `json:"copySignatures"`
+	Retry                *retryDocument `json:"retry"`
+}
+
+type buildDocument struct {
+	operationDocument
+
+	Context           string            `json:"context"`
+	Dockerfile        string            `json:"dockerfile"`
+	Nodes             []string          `json:"nodes"`
+	NodeSelector      map[string]string `json:"nodeSelector"`
+	Tag               string            `json:"tag"`
+	DiskSpaceFactor   float64           `json:"diskSpaceFactor"`
+	ExpectedImageSize string            `json:"expectedImageSize"`
+	Timestamp         string            `json:"timestamp"`
+	Timeout           string            `json:"timeout"`
+	Retry             *retryDocument    `json:"retry"`
+}
+
+type rebaseDocument struct {
+	operationDocument
+
+	Image       string `json:"image"`
+	OldBase     string `json:"oldBase"`
+	NewBase     string `json:"newBase"`
+	Destination string `json:"destination"`
+}
+
+type flattenDocument struct {
+	operationDocument
+
+	Image       string `json:"image"`
+	Destination string `json:"destination"`
+}
+
+type mutateDocument struct {
+	operationDocument
+
+	Image       string            `json:"image"`
+	Destination string            `json:"destination"`
+	Labels      map[string]string `json:"labels"`
+	Env         map[string]string `json:"env"`
+	User        string            `json:"user"`
+	Entrypoint  []string          `json:"entrypoint"`
+	Cmd         []string          `json:"cmd"`
+	Timestamp   string            `json:"timestamp"`
+}
+
+type indexDocument struct {
+	operationDocument
+
+	Images []string `json:"images"`
+	Tag    string   `json:"tag"`
+}
+
+type ghcrPackageDocument struct {
+	operationDocument
+
+	Image       string            `json:"image"`
+	Repository  string            `json:"repository"`
+	Description string            `json:"description"`
+	Labels      map[string]string `json:"labels"`
+	Visibility  string            `json:"visibility"`
+	Token       string            `json:"token"`
+	APIURL      string            `json:"apiURL"`
+}
+
+type repositoryDocsDocument struct {
+	operationDocument
+
+	Image       string `json:"image"`
+	Description string `json:"description"`
+	README      string `json:"readme"`
+	Token       string `json:"token"`
+	APIURL      string `json:"apiURL"`
+}
+
+type scanSeverityDocument struct {
+	Threshold ScanSeverity `json:"threshold"`
+	Action    *ScanAction  `json:"action"`
+}
+
+type scanDocument struct {
+	operationDocument
+
+	Source               string                 `json:"source"`
+	Severity             []scanSeverityDocument `json:"severity"`
+	EvaluateAll          bool                   `json:"evaluateAll"`
+	FailOnKnownExploited bool                   `json:"failOnKnownExploited"`
+	Format               *ScanFormat            `json:"format"`
+	Platforms            []string               `json:"platforms"`
+	Timeout              string                 `json:"timeout"`
+	Retry                *retryDocument         `json:"retry"`
+}
+
+type auditDocument struct {
+	operationDocument
+
+	Dockerfile   string        `json:"dockerfile"`
+	Source       string        `json:"source"`
+	RuleSet      *AuditRuleSet `json:"ruleSet"`
+	IgnoreChecks []string      `json:"ignoreChecks"`
+	Timeout      string        `json:"timeout"`
+}
+
+type pruneDocument struct {
+	operationDocument
+
+	Repository string                  `json:"repository"`
+	Policy     retentionPolicyDocument `json:"policy"`
+}
+
+type retentionPolicyDocument struct {
+	KeepLastPerMinor  int      `json:"keepLastPerMinor"`
+	KeepTags          []string `json:"keepTags"`
+	DropTags          []string `json:"dropTags"`
+	DropUntaggedAfter string   `json:"dropUntaggedAfter"`
+}
+
+type verifyDocument struct {
+	operationDocument
+
+	Image       string `json:"image"`
+	Key         string `json:"key"`
+	Identity    string `json:"identity"`
+	Issuer      string `json:"issuer"`
+	RekorURL    string `json:"rekorURL"`
+	TrustPolicy string `json:"trustPolicy"`
+	Timeout     string `json:"timeout"`
+}
+
+// LoadPlan reads a declarative plan document (YAML, or JSON for .json files) and builds the plan it describes:
+// registries, images, build nodes, version checks, verifications, syncs, builds, rebases, flattenings, mutations,
+// manifest lists, GHCR packages, repository documentation, scans, audits and prunes. Documents are rendered as
+// templates first (see LoadPlanWithOptions).
+//
+//	name: mirror
+//	registries:
+//	  - host: ghcr.io
+//	    username: '{{ env "GHCR_USER" }}'
+//	    password: '{{ secret "op://ci/ghcr" "token" }}'
+//	images:
+//	  alpine: docker.io/library/alpine:3.20@sha256:...
+//	  mirror: ghcr.io/org/alpine:3.20
+//	syncs:
+//	  - name: mirror-alpine
+//	    source: alpine
+//	    destination: mirror
+//	scans:
+//	  - name: scan-alpine
+//	    source: mirror
+//	    dependsOn: [mirror-alpine]
+//	    severity:
+//	      - threshold: critical
+//
+// Operations reference images by their key in images, or by a full reference. Operations using the same
+// image share it, so a scan of a sync destination sees the digest pushed by the sync. Operations are added in
+// this order: included documents (includes, paths relative to the document, see Plan.Include), Harbor projects,
+// version checks, verifications, syncs, builds, rebases, flattenings, mutations, manifest lists, GHCR packages,
+// repository documentation, scans, audits, prunes; dependsOn names operations added before.
+// The plan name defaults to the file name without extension; schedule is its cron schedule (see Schedule).
+func LoadPlan(path string) (*Plan, error) {
+	return LoadPlanWithOptions(path, LoadOptions{})
+}
+
+// LoadPlanWithOptions is LoadPlan with template options.
+// Documents are Go text/templates, rendered before parsing, with the functions env "NAME", envOr "NAME"
+// "default", secret "op://vault/item" "field" (resolved through 1Password), split "," "a,b" and quote "value".
+func LoadPlanWithOptions(path string, opts LoadOptions) (*Plan, error) {
+	return loadPlan(path, opts, nil)
+}
+
+// loadPlan loads the document at path, included by the documents at including (outermost first).
+func loadPlan(path string, opts LoadOptions, including []string) (*Plan, error) {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve plan document path: %w", err)
+	}
+
+	if slices.Contains(including, absolute) {
+		return nil, fmt.Errorf("%w: include cycle through %s", ErrPlanDocumentInvalid, path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan document: %w", err)
+	}
+
+	rendered, err := plantemplate.Render(path, content, plantemplate.Options{
+		Strict: opts.Strict,
+		Vars:   opts.Vars,
+		Secret: resolveSecret,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPlanDocumentInvalid, err)
+	}
+
+	doc, err := parsePlanDocument(rendered, strings.EqualFold(filepath.Ext(path), ".json"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrPlanDocumentInvalid, path, err)
+	}
+
+	if doc.Name == "" {
+		doc.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	return doc.plan(&planLoader{
+		dir:       filepath.Dir(absolute),
+		opts:      opts,
+		including: append(slices.Clone(including), absolute),
+	})
+}
+
+// resolveSecret resolves template secret references through 1Password.
+func resolveSecret(reference, field string) (string, error) {
+	secrets, err := GetSecret(context.Background(), reference, []string{field})
+	if err != nil {
+		return "", err
+	}
+
+	return secrets[field], nil
+}
+
+// parsePlanDocument decodes a rendered document. YAML documents are converted to JSON, so both formats share
+// the JSON decoding of the SDK enums (e.g., ScanSeverity). Unknown fields are rejected, to report typos.
+func parsePlanDocument(content []byte, isJSON bool) (*planDocument, error) {
+	if !isJSON {
+		var raw any
+		if err := yaml.Unmarshal(content, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+
+		converted, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert YAML: %w", err)
+		}
+
+		content = converted
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+
+	var doc planDocument
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode plan: %w", err)
+	}
+
+	return &doc, nil
+}
+
+// planLoader builds a plan from a document, resolving the references between its entries.
+type planLoader struct {
+	plan       *Plan
+	doc        *planDocument
+	images     map[string]*Image
+	nodes      map[string]*BuildNode
+	operations map[string]Dependency
+
+	// Directory the paths of the document (includes, build contexts, files...) are relative to, and documents
+	// being loaded (cycle detection)
+	dir       string
+	opts      LoadOptions
+	including []string
+}
+
+func (doc *planDocument) plan(loader *planLoader) (*Plan, error) {
+	loader.plan = NewPlan(doc.Name)
+	loader.doc = doc
+	loader.images = make(map[string]*Image)
+	loader.nodes = make(map[string]*BuildNode)
+	loader.operations = make(map[string]Dependency)
+
+	loader.plan.MaxParallelism(doc.MaxParallelism)
+
+	if doc.Schedule != "" {
+		if _, err := cron.Parse(doc.Schedule); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSchedule, err)
+		}
+
+		loader.plan.cron = doc.Schedule
+	}
+
+	for domain, limit := range doc.RegistryConcurrency {
+		loader.plan.RegistryConcurrency(domain, limit)
+	}
+
+	platforms, err := parsePlatforms(doc.DefaultPlatforms)
+	if err != nil {
+		return nil, fmt.Errorf("default platforms: %w", err)
+	}
+
+	loader.plan.DefaultPlatforms(platforms...)
+	loader.plan.KnownExploitedFeed(loader.path(doc.KnownExploitedFeed))
+
+	if doc.Exceptions != "" {
+		if err := loader.plan.Exceptions(loader.path(doc.Exceptions)); err != nil {
+			return nil, err
+		}
+	}
+
+	if doc.SyncWebhook != nil {
+		if err := loader.plan.SyncWebhook(doc.SyncWebhook.URL, doc.SyncWebhook.Secret); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := loader.notifications(); err != nil {
+		return nil, err
+	}
+
+	steps := []func() error{
+		loader.registries,
+		loader.profiles,
+		loader.includes,
+		loader.rewriteRules,
+		loader.buildNodes,
+		loader.harborProjects,
+		loader.versionChecks,
+		loader.verifications,
+		loader.syncs,
+		loader.builds,
+		loader.rebases,
+		loader.flattens,
+		loader.mutations,
+		loader.indexes,
+		loader.ghcrPackages,
+		loader.repositoryDocs,
+		loader.scans,
+		loader.audits,
+		loader.prunes,
+	}
+
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return nil, err
+		}
+	}
+
+	return loader.plan, nil
+}
+
+func (loader *planLoader) registries() error {
+	for _, entry := range loader.doc.Registries {
+		if err := entry.build(loader.plan.Registry(entry.Host)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (loader *planLoader) profiles() error {
+	for _, entry := range loader.doc.Profiles {
+		builder := loader.plan.Profile(entry.Name).TagSuffix(entry.TagSuffix)
+
+		for _, registry := range entry.Registries {
+			if err := registry.build(builder.Registry(registry.Host)); err != nil {
+				return fmt.Errorf("profile %q: %w", entry.Name, err)
+			}
+		}
+
+		for domain, replacement := range entry.Domains {
+			builder.Domain(domain, replacement)
+		}
+
+		if _, err := builder.Build(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// build configures builder with the document credentials, and builds the registry.
+func (entry registryDocument) build(builder *RegistryBuilder) error {
+	builder.Username(entry.Username).Password(entry.Password)
+	if entry.Token != "" {
+		builder.TokenAuth(entry.Token)
+	}
+
+	if entry.AutoCreateRepo {
+		builder.AutoCreateRepo(true).RepoLifecyclePolicy(entry.RepoLifecyclePolicy)
+
+		for key, value := range entry.RepoTags {
+			builder.RepoTag(key, value)
+		}
+	}
+
+	if _, err := builder.Build(); err != nil {
+		return fmt.Errorf("registry %q: %w", entry.Host, err)
+	}
+
+	return nil
+}
+
+// path resolves a local path of the document against the document directory, so documents load the same files
+// whatever the working directory. Empty and absolute paths, and URLs or KMS URIs ("scheme://..."), are kept.
+func (loader *planLoader) path(value string) string {
+	if value == "" || filepath.IsAbs(value) || strings.Contains(value, "://") {
+		return value
+	}
+
+	return filepath.Join(loader.dir, value)
+}
+
+// includes includes the plans of the included documents: their operations are referenced by namespaced name
+// (e.g., "base/check-alpine"), their build nodes by name.
+func (loader *planLoader) includes() error {
+	for _, entry := range loader.doc.Includes {
+		included, err := loadPlan(loader.path(entry), loader.opts, loader.including)
+		if err != nil {
+			return fmt.Errorf("include %q: %w", entry, err)
+		}
+
+		if err := loader.plan.Include(included); err != nil {
+			return err
+		}
+
+		for _, op := range included.operations {
+			loader.operations[op.operationName()] = op
+		}
+
+		for _, node := range included.buildNodes {
+			if _, exists := loader.nodes[node.name]; !exists {
+				loader.nodes[node.name] = node
+			}
+		}
+	}
+
+	return nil
+}
+
+func (loader *planLoader) rewriteRules() error {
+	for _, entry := range loader.doc.RewriteRules {
+		if err := loader.plan.RewriteRule(entry.Pattern, entry.Replacement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (loader *planLoader) buildNodes() error {
+	for _, entry := range loader.doc.BuildNodes {
+		builder := loader.plan.BuildNode(entry.Name).Endpoint(entry.Endpoint).ForwardAgent(entry.ForwardAgent)
+
+		if entry.Platform != "" {
+			platform, err := ParsePlatform(entry.Platform)
+			if err != nil {
+				return fmt.Errorf("build node %q: %w", entry.Name, err)
+			}
+
+			builder.Platform(platform)
+		}
+
+		for key, value := range entry.Labels {
+			builder.Label(key, value)
+		}
+
+		node, err := builder.Build()
+		if err != nil {
+			return fmt.Errorf("build node %q: %w", entry.Name, err)
+		}
+
+		loader.nodes[entry.Name] = node
+	}
+
+	return nil
+}
+
+// notifications adds the notification endpoints.
+func (loader *planLoader) notifications() error {
+	for _, entry := range loader.doc.Notifications {
+		builder := loader.plan.Notify(entry.URL).Secret(entry.Secret)
+
+		if entry.Format != "" {
+			format, err := parseNotifyFormat(entry.Format)
+			if err != nil {
+				return fmt.Errorf("notification %q: %w", entry.URL, err)
+			}
+
+			builder.Format(format)
+		}
+
+		for _, name := range entry.Events {
+			event, err := parseNotifyEvent(name)
+			if err != nil {
+				return fmt.Errorf("notification %q: %w", entry.URL, err)
+			}
+
+			builder.Events(event)
+		}
+
+		if _, err := builder.Build(); err != nil {
+			return fmt.Errorf("notification %q: %w", entry.URL, err)
+		}
+	}
+
+	return nil
+}
+
+// harborProjects adds the Harbor project bootstraps. Robots authenticate the plan registry of the Harbor host,
+// declared without credentials if the document does not declare it.
+func (loader *planLoader) harborProjects() error {
+	for _, entry := range loader.doc.HarborProjects {
+		builder := loader.plan.HarborProject(entry.Name).
+			URL(entry.URL).
+			Credentials(entry.Username, entry.Password).
+			Project(entry.Project).
+			Public(entry.Public).
+			RetentionSchedule(entry.RetentionSchedule).
+			ImmutableTags(entry.ImmutableTags...)
+
+		for _, rule := range entry.Retention {
+			if rule.KeepDays > 0 {
+				builder.RetainDays(rule.Tags, rule.KeepDays)
+			} else {
+				builder.RetainLatest(rule.Tags, rule.KeepLatest)
+			}
+		}
+
+		if entry.Robot != "" {
+			registry, err := loader.harborRegistry(entry.URL)
+			if err != nil {
+				return fmt.Errorf("harbor project %q: %w", entry.Name, err)
+			}
+
+			builder.Robot(entry.Robot, registry)
+		}
+
+		deps, err := loader.dependencies(entry.operationDocument)
+		if err != nil {
+			return err
+		}
+
+		project, err := builder.RunOnlyOn(entry.RunOnlyOn...).Resource(entry.Resource).DependsOn(deps...).Build()
+		if err != nil {
+			return fmt.Errorf("harbor project %q: %w", entry.Name, err)
+		}
+
+		loader.operations[entry.Name] = project
+	}
+
+	return nil
+}
+
+// harborRegistry returns the plan registry of the host of the Harbor instance at rawURL, declaring it if needed.
+func (loader *planLoader) harborRegistry(rawURL string) (*Registry, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidHarborURL, rawURL)
+	}
+
+	if registry, ok := loader.plan.registries[normalizeDomain(parsed.Host)]; ok {
+		return registry, nil
+	}
+
+	registry, err := loader.plan.Registry(parsed.Host).Build()
+	if err != nil {
+		return nil, fmt.Errorf("registry %q: %w", parsed.Host, err)
+	}
+
+	return registry, nil
+}
+
+func (loader *planLoader) versionChecks() error {
+	for _, entry := range loader.doc.VersionChecks {
+		builder := loader.plan.VersionCheck(entry.Name)
+
+		if entry.Source != "" {
+			image, err := loader.image(entry.Source)
+			if err != nil {
+				return fmt.Errorf("version check %q: %w", entry.Name, err)
+			}
+
+			builder.Source(image)
+		}
+
+		if err := applyRetry(entry.Retry, builder.Retry); err != nil {
+			return fmt.Errorf("version check %q: %w", entry.Name, err)
+		}
+
+		deps, err := loader.dependencies(entry.operationDocument)
+		if err != nil {
+			return err
+		}
+
+		check, err := builder.RunOnlyOn(entry.RunOnlyOn...).Resource(entry.Resource).DependsOn(deps...).Build()
+		if err != nil {
+			return fmt.Errorf("version check %q: %w", entry.Name, err)
+		}
+
+		loader.operations[entry.Name] = check
+	}
+
+	return nil
+}
+
+func (loader *planLoader) syncs() error {
+	for _, entry := range loader.doc.Syncs {
+		builder := loader.plan.Sync(entry.Name).
+			RecordPreviousDigest(entry.RecordPreviousDigest).
+			VerifyBlobs(entry.VerifyBlobs).
+			VerifyPushedDigest(entry.VerifyPushedDigest).
+			DigestTagFallback(entry.DigestTagFallback).
+			CopySignatures(entry.CopySignatures)
+
+		if entry.Source != "" {
+			image, err := loader.image(entry.Source)
+			if err != nil {
+				return fmt.Errorf("sync %q: %w", entry.Name, err)
+			}
+
+			builder.Source(image)
+		}
+
+		if entry.Destination != "" {
+			image, err := loader.image(entry.Destination)
+			if err != nil {
+				return fmt.Errorf("sync %q: %w", entry.Name, err)
+			}
+
+			builder.Destination(image)
+		}
+
+		platforms, err := parsePlatforms(entry.Platforms)
+		if err != nil {
+			return fmt.Errorf("sync %q: %w", entry.Name, err)
+		}
+
+		if len(platforms) > 0 {
+			builder.Platforms(platforms...)
+		}
+
+		if err := applyRetry(entry.Retry, builder.Retry); err != nil {
+			return fmt.Errorf("sync %q: %w", entry.Name, err)
+		}
+
+		deps, err := loader.dependencies(entry.operationDocument)
+		if err != nil {
+			return err
+		}
+
+		sync, err := builder.RunOnlyOn(entry.RunOnlyOn...).Resource(entry.Resource).DependsOn(deps...).Build()
+		if err != nil {
+			return fmt.Errorf("sync %q: %w", entry.Name, err)
+		}
+
+		loader.operations[entry.Name] = sync
+	}
+
+	return nil
+}
+
+func (loader *planLoader) builds() error {
+	for _, entry := range loader.doc.Builds {
+		builder := loader.plan.Build(entry.Name).
+			Context(loader.path(entry.Context)).
+			Dockerfile(entry.Dockerfile).
+			Tag(entry.Tag).
+			DiskSpaceFactor(entry.DiskSpaceFactor)
+
+		for _, name := range entry.Nodes {
+			node, ok := loader.nodes[name]
+			if !ok {
+				return fmt.Errorf("%w: build %q uses build node %q", ErrPlanDocumentUnknownReference, entry.Name, name)
+			}
+
+			builder.Node(node)
+		}
+
+		if entry.NodeSelector != nil {
+			builder.NodeSelector(entry.NodeSelector)
+		}
+
+		if entry.ExpectedImageSize != "" {
+			builder.ExpectedImageSize(entry.ExpectedImageSize)
+		}
+
+		if err := applyTimestamp(entry.Timestamp, builder.Timestamp); err != nil {
+			return fmt.Errorf("build %q: %w", entry.Name, err)
+		}
+
+		timeout, err := parseTimeout(entry.Timeout)
+		if err != nil {
+			return fmt.Errorf("build %q: %w", entry.Name, err)
+		}
+
+		if err := applyRetry(entry.Retry, builder.Retry); err != nil {
+			return fmt.Errorf("build %q: %w", entry.Name, err)
+		}
+
+		deps, err := loader.dependencies(entry.operationDocument)
+		if err != nil {
+			return err
+		}
+
+		build, err := builder.Timeout(timeout).
+			RunOnlyOn(entry.RunOnlyOn...).
+			Resource(entry.Resource).
+			DependsOn(deps...).
+			Build()
+		if err != nil {
+			return fmt.Errorf("build %q: %w", entry.Name, err)
+		}
+
+		loader.operations[entry.Name] = build
+	}
+
+	return nil
+}
+
+func (loader *planLoader) scans() error {
+	for _, entry := range loader.doc.Scans {
+		builder := loader.plan.Scan(entry.Name).
+			EvaluateAll(entry.EvaluateAll).
+			FailOnKnownExploited(entry.FailOnKnownExploited)
+
+		if entry.Source != "" {
+			image, err := loader.image(entry.Source)
+			if err != nil {
+				return fmt.Errorf("scan %q: %w", entry.Name, err)
+			}
+
+			builder.Source(image)
+		}
+
+		for _, check := range entry.Severity {
+			if check.Action != nil {
+				builder.Severity(check.Threshold, *check.Action)
+			} else {
+				builder.Severity(check.Threshold)
+			}
+		}
+
+		if entry.Format != nil {
+			builder.Format(*entry.Format)
+		}
+
+		platforms, err := parsePlatforms(entry.Platforms)
+		if err != nil {
+			return fmt.Errorf("scan %q: %w", entry.Name, err)
+		}
+
+		if len(platforms) > 0 {
+			builder.Platforms(platforms...)
+		}
+
+		timeout, err := parseTimeout(entry.Timeout)
+		if err != nil {
+			return fmt.Errorf("scan %q: %w", entry.Name, err)
+		}
+
+		if err := applyRetry(entry.Retry, builder.Retry); err != nil {
+			return fmt.Errorf("scan %q: %w", entry.Name, err)
+		}
+
+		deps, err := loader.dependencies(entry.operationDocument)
+		if err != nil {
+			return err
+		}
+
+		scan, err := builder.Timeout(timeout).
+			RunOnlyOn(entry.RunOnlyOn...).
+			Resource(entry.Resource).
+			DependsOn(deps...).
+			Build()
+		if err != nil {
+			return fmt.Errorf("scan %q: %w", entry.Name, err)
+		}
+
+		loader.operations[entry.Name] = scan
+	}
+
+	return nil
+}
+
+// rebases adds the rebases of application images onto patched base images, typically of build outputs.
+func (loader *planLoader) rebases() error {
+	for _, entry := range loader.doc.Rebases {
+		builder := loader.plan.Rebase(entry.Name)
+
+		for _, ref := range []struct {
+			key string
+			set func(*Image) *RebaseBuilder
+		}{
+			{entry.Image, builder.Image},
+			{entry.OldBase, builder.OldBase},
+			{entry.NewBase, builder.NewBase},
+			{entry.Destination, builder.Destination},
+		} {
+			if ref.key == "" {
+				continue
+			}
+
+			image, err := loader.image(ref.key)
+			if err != nil {
+				return fmt.Errorf("rebase %q: %w", entry.Name, err)
+			}
+
+			ref.set(image)
+		}
+
+		deps, err := loader.dependencies(entry.operationDocument)
+		if err != nil {
+			return err
+		}
+
+		rebase, err := builder.RunOnlyOn(entry.RunOnlyOn...).Resource(entry.Resource).DependsOn(deps...).Build()
+		if err != nil {
+			return fmt.Errorf("rebase %q: %w", entry.Name, err)
+		}
+
+		loader.operations[entry.Name] = rebase
+	}
+
+	return nil
+}
+
+// flattens adds the flattening of images into a single layer, typically of build outputs.
+func (loader *planLoader) flattens() error {
+	for _, entry := range loader.doc.Flattens {
+		builder := loader.plan.Flatten(entry.Name)
+
+		for _, ref := range []struct {
+			key string
+			set func(*Image) *FlattenBuilder
+		}{
+			{entry.Image, builder.Image},
+			{entry.Destination, builder.Destination},
+		} {
+			if ref.key == "" {
+				continue
+			}
+
+			image, err := loader.image(ref.key)
+			if err != nil {
+				return fmt.Errorf("flatten %q: %w", entry.Name, err)
+			}
+
+			ref.set(image)
+		}
+
+		deps, err := loader.dependencies(entry.operationDocument)
+		if err != nil {
+			return err
+		}
+
+		flatten, err := builder.RunOnlyOn(entry.RunOnlyOn...).Resource(entry.Resource).DependsOn(deps...).Build()
+		if err != nil {
+			return fmt.Errorf("flatten %q: %w", entry.Name, err)
+		}
+
+		loader.operations[entry.Name] = flatten
+	}
+
+	return nil
+}
+
+// mutations adds the image config changes, typically stamping build outputs or fixing upstream images.
+func (loader *planLoader) mutations() error {
+	for _, entry := range loader.doc.Mutations {
+		builder := loader.plan.Mutate(entry.Name)
+
+		for _, ref := range []struct {
+			key string
+			set func(*Image) *MutateBuilder
+		}{
+			{entry.Image, builder.Image},
+			{entry.Destination, builder.Destination},
+		} {
+			if ref.key == "" {
+				continue
+			}
+
+			image, err := loader.image(ref.key)
+			if err != nil {
+				return fmt.Errorf("mutation %q: %w", entry.Name, err)
+			}
+
+			ref.set(image)
+		}
+
+		for key, value := range entry.Labels {
+			builder.SetLabel(key, value)
+		}
+
+		for key, value := range entry.Env {
+			builder.SetEnv(key, value)
+		}
+
+		if entry.Entrypoint != nil {
+			builder.SetEntrypoint(entry.Entrypoint...)
+		}
+
+		if entry.Cmd != nil {
+			builder.SetCmd(entry.Cmd...)
+		}
+
+		if err := applyTimestamp(entry.Timestamp, builder.Timestamp); err != nil {
+			return fmt.Errorf("mutation %q: %w", entry.Name, err)
+		}
+
+		deps, err := loader.dependencies(entry.operationDocument)
+		if err != nil {
+			return err
+		}
+
+		mutate, err := builder.SetUser(entry.User).RunOnlyOn(entry.RunOnlyOn...).Resource(entry.Resource).
+			DependsOn(deps...).Build()
+		if err != nil {
+			return fmt.Errorf("mutation %q: %w", entry.Name, err)
+		}
+
+		loader.operations[entry.Name] = mutate
+	}
+
+	return nil
+}
+
+// indexes adds the manifest lists of images tagged per architecture, typically pushed by builds (image references,
+// like build tags).
+func (loader *planLoader) indexes() error {
+	for _, entry := range loader.doc.Indexes {
+		builder := loader.plan.Index(entry.Name).Tag(entry.Tag)
+
+		for _, image := range entry.Images {
+			builder.Add(image)
+		}
+
+		deps, err := loader.dependencies(entry.operationDocument)
+		if err != nil {
+			return err
+		}
+
+		index, err := builder.RunOnlyOn(entry.RunOnlyOn...).Resource(entry.Resource).DependsOn(deps...).Build()
+		if err != nil {
+			return fmt.Errorf("index %q: %w", entry.Name, err)
+		}
+
+		loader.operations[entry.Name] = index
+	}
+
+	return nil
+}
+
+// ghcrPackages adds the GHCR package metadata and visibility management, typically of sync destinations and builds.
+func (loader *planLoader) ghcrPackages() error {
+	for _, entry := range loader.doc.GHCRPackages {
+		builder := loader.plan.GHCRPackage(entry.Name).
+			Repository(entry.Repository).
+			Description(entry.Description).
+			Token(entry.Token).
+			APIURL(entry.APIURL)
+
+		if entry.Image != "" {
+			image, err := loader.image(entry.Image)
+			if err != nil {
+				return fmt.Errorf("GHCR package %q: %w", entry.Name, err)
+			}
+
+			builder.Image(image)
+		}
+
+		for key, value := range entry.Labels {
+			builder.Label(key, value)
+		}
+
+		if entry.Visibility != "" {
+			visibility, err := parsePackageVisibility(entry.Visibility)
+			if err != nil {
+				return fmt.Errorf("GHCR package %q: %w", entry.Name, err)
+			}
+
+			builder.Visibility(visibility)
+		}
+
+		deps, err := loader.dependencies(entry.operationDocument)
+		if err != nil {
+			return err
+		}
+
+		pkg, err := builder.RunOnlyOn(entry.RunOnlyOn...).Resource(entry.Resource).DependsOn(deps...).Build()
+		if err != nil {
+			return fmt.Errorf("GHCR package %q: %w", entry.Name, err)
+		}
+
+		loader.operations[entry.Name] = pkg
+	}
+
+	return nil
+}
+
+// repositoryDocs adds the Docker Hub and Quay repository documentation pushes.
+func (loader *planLoader) repositoryDocs() error {
+	for _, entry := range loader.doc.RepositoryDocs {
+		builder := loader.plan.RepositoryDocs(entry.Name).
+			Description(entry.Description).
+			README(loader.path(entry.README)).
+			Token(entry.Token).
+			APIURL(entry.APIURL)
+
+		if entry.Image != "" {
+			image, err := loader.image(entry.Image)
+			if err != nil {
+				return fmt.Errorf("repository docs %q: %w", entry.Name, err)
+			}
+
+			builder.Image(image)
+		}
+
+		deps, err := loader.dependencies(entry.operationDocument)
+		if err != nil {
+			return err
+		}
+
+		docs, err := builder.RunOnlyOn(entry.RunOnlyOn...).Resource(entry.Resource).DependsOn(deps...).Build()
+		if err != nil {
+			return fmt.Errorf("repository docs %q: %w", entry.Name, err)
+		}
+
+		loader.operations[entry.Name] = docs
+	}
+
+	return nil
+}
+
+func (loader *planLoader) audits() error {
+	for _, entry := range loader.doc.Audits {
+		builder := loader.plan.Audit(entry.Name).Dockerfile(loader.path(entry.Dockerfile)).
+			IgnoreChecks(entry.IgnoreChecks...)
+
+		if entry.Source != "" {
+			image, err := loader.image(entry.Source)
+			if err != nil {
+				return fmt.Errorf("audit %q: %w", entry.Name, err)
+			}
+
+			builder.Source(image)
+		}
+
+		if entry.RuleSet != nil {
+			builder.RuleSet(*entry.RuleSet)
+		}
+
+		timeout, err := parseTimeout(entry.Timeout)
+		if err != nil {
+			return fmt.Errorf("audit %q: %w", entry.Name, err)
+		}
+
+		deps, err := loader.dependencies(entry.operationDocument)
+		if err != nil {
+			return err
+		}
+
+		audit, err := builder.Timeout(timeout).
+			RunOnlyOn(entry.RunOnlyOn...).
+			Resource(entry.Resource).
+			DependsOn(deps...).
+			Build()
+		if err != nil {
+			return fmt.Errorf("audit %q: %w", entry.Name, err)
+		}
+
+		loader.operations[entry.Name] = audit
+	}
+
+	return nil
+}
+
+func (loader *planLoader) verifications() error {
+	for _, entry := range loader.doc.Verifications {
+		builder := loader.plan.Verify(entry.Name).RekorURL(entry.RekorURL).
+			NotationTrustPolicy(loader.path(entry.TrustPolicy))
+
+		if entry.Image != "" {
+			image, err := loader.image(entry.Image)
+			if err != nil {
+				return fmt.Errorf("verification %q: %w", entry.Name, err)
+			}
+
+			builder.Image(image)
+		}
+
+		if entry.Key != "" {
+			builder.CosignKey(loader.path(entry.Key))
+		}
+
+		if entry.Identity != "" || entry.Issuer != "" {
+			builder.CosignIdentity(entry.Identity, entry.Issuer)
+		}
+
+		timeout, err := parseTimeout(entry.Timeout)
+		if err != nil {
+			return fmt.Errorf("verification %q: %w", entry.Name, err)
+		}
+
+		deps, err := loader.dependencies(entry.operationDocument)
+		if err != nil {
+			return err
+		}
+
+		verify, err := builder.Timeout(timeout).
+			RunOnlyOn(entry.RunOnlyOn...).
+			Resource(entry.Resource).
+			DependsOn(deps...).
+			Build()
+		if err != nil {
+			return fmt.Errorf("verification %q: %w", entry.Name, err)
+		}
+
+		loader.operations[entry.Name] = verify
+	}
+
+	return nil
+}
+
+// image returns the image named key in the document images, or the image of the reference key.
+// Each image is built once, so operations using it share its digest.
+func (loader *planLoader) image(key string) (*Image, error) {
+	if image, ok := loader.images[key]; ok {
+		return image, nil
+	}
+
+	ref := key
+	if defined, ok := loader.doc.Images[key]; ok {
+		ref = defined
+	}
+
+	image, err := NewImage(ref).Build()
+	if err != nil {
+		return nil, fmt.Errorf("image %q: %w", key, err)
+	}
+
+	loader.images[key] = image
+
+	return image, nil
+}
+
+// dependencies returns the operations entry depends on, which must be added before it.
+func (loader *planLoader) dependencies(entry operationDocument) ([]Dependency, error) {
+	deps := make([]Dependency, 0, len(entry.DependsOn))
+
+	for _, name := range entry.DependsOn {
+		dep, ok := loader.operations[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q depends on %q", ErrPlanDocumentUnknownReference, entry.Name, name)
+		}
+
+		deps = append(deps, dep)
+	}
+
+	return deps, nil
+}
+
+// parsePlatforms parses platform strings (e.g., "linux/arm64").
+func parsePlatforms(values []string) ([]Platform, error) {
+	platforms := make([]Platform, 0, len(values))
+
+	for _, value := range values {
+		platform, err := ParsePlatform(value)
+		if err != nil {
+			return nil, err
+		}
+
+		platforms = append(platforms, platform)
+	}
+
+	return platforms, nil
+}
+
+// prunes adds the prunes of repositories, after the operations pushing to them.
+func (loader *planLoader) prunes() error {
+	for _, entry := range loader.doc.Prunes {
+		builder := loader.plan.Prune(entry.Name)
+
+		if entry.Repository != "" {
+			image, err := loader.image(entry.Repository)
+			if err != nil {
+				return fmt.Errorf("prune %q: %w", entry.Name, err)
+			}
+
+			builder.Repository(image)
+		}
+
+		policy := RetentionPolicy{
+			KeepLastPerMinor: entry.Policy.KeepLastPerMinor,
+			KeepTags:         entry.Policy.KeepTags,
+			DropTags:         entry.Policy.DropTags,
+		}
+
+		if entry.Policy.DropUntaggedAfter != "" {
+			age, err := time.ParseDuration(entry.Policy.DropUntaggedAfter)
+			if err != nil {
+				return fmt.Errorf("prune %q: %w: drop untagged after: %w", entry.Name, ErrInvalidRetentionPolicy, err)
+			}
+
+			policy.DropUntaggedAfter = age
+		}
+
+		deps, err := loader.dependencies(entry.operationDocument)
+		if err != nil {
+			return err
+		}
+
+		prune, err := builder.Policy(policy).RunOnlyOn(entry.RunOnlyOn...).Resource(entry.Resource).
+			DependsOn(deps...).Build()
+		if err != nil {
+			return fmt.Errorf("prune %q: %w", entry.Name, err)
+		}
+
+		loader.operations[entry.Name] = prune
+	}
+
+	return nil
+}
+
+// parseTimeout parses a duration string (e.g., "10m"); empty means no timeout.
+func parseTimeout(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout: %w", err)
+	}
+
+	return duration, nil
+}
+
+// applyTimestamp sets the reproducible timestamp of an operation to value, an RFC 3339 time
+// (e.g., "2025-01-01T00:00:00Z"), with setTimestamp, a Timestamp builder method (none when value is empty).
+func applyTimestamp[B any](value string, setTimestamp func(timestamp time.Time) B) error {
+	if value == "" {
+		return nil
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp: %w", err)
+	}
+
+	setTimestamp(timestamp)
+
+	return nil
+}
+
+// applyRetry sets the retry policy of doc with retry, a Retry builder method (no policy when doc is nil).
+func applyRetry[B any](doc *retryDocument, retry func(attempts int, backoff time.Duration) B) error {
+	if doc == nil {
+		return nil
+	}
+
+	var backoff time.Duration
+
+	if doc.Backoff != "" {
+		parsed, err := time.ParseDuration(doc.Backoff)
+		if err != nil {
+			return fmt.Errorf("invalid retry backoff: %w", err)
+		}
+
+		backoff = parsed
+	}
+
+	retry(doc.Attempts, backoff)
+
+	return nil
+}
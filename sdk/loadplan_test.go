@@ -0,0 +1,299 @@
+package sdk_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// writePlanDocument writes content to a file named name in a temporary directory and returns its path.
+func writePlanDocument(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write plan document: %v", err)
+	}
+
+	return path
+}
+
+// INTENTION: A templated YAML document builds a plan that runs like its Go equivalent: operations share the
+// images they reference by key, so the sync destination carries the pushed digest.
+func TestLoadPlan_YAML(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+	digest := pushRandomImage(t, host+"/source/app:1.0.0")
+
+	path := writePlanDocument(t, "mirror.yaml", `
+images:
+  app: {{ .host }}/source/app:1.0.0@{{ .digest }}
+  mirror: {{ .host }}/mirror/app:1.0.0
+syncs:
+{{- range .names }}
+  - name: sync-{{ . }}
+    source: app
+    destination: mirror
+{{- end }}
+versionChecks:
+  - name: check
+    source: app
+`)
+
+	plan, err := sdk.LoadPlanWithOptions(path, sdk.LoadOptions{
+		Strict: true,
+		Vars:   map[string]any{"host": host, "digest": digest, "names": []string{"first", "second"}},
+	})
+	if err != nil {
+		t.Fatalf("LoadPlanWithOptions() error = %v", err)
+	}
+
+	if err := plan.Execute(t.Context()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	// Version checks are added before syncs, whatever the document order
+	want := []string{"check", "sync-first", "sync-second"}
+
+	operations := plan.Report().Operations
+	if len(operations) != len(want) {
+		t.Fatalf("Report() = %d operations, want %d", len(operations), len(want))
+	}
+
+	for idx, name := range want {
+		if operations[idx].Name != name || operations[idx].Status != sdk.StatusSucceeded {
+			t.Errorf("Report().Operations[%d] = %s %s, want %s %s",
+				idx, operations[idx].Name, operations[idx].Status, name, sdk.StatusSucceeded)
+		}
+	}
+}
+
+// INTENTION: Invalid documents fail to load with an error naming the problem: unknown fields (typos), invalid
+// enum values, strict template variables, references to operations that are not defined before, and include cycles.
+func TestLoadPlan_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr error
+	}{
+		{
+			name:    "unknown field",
+			file:    "plan.yaml",
+			content: "syncs:\n  - name: mirror\n    sorce: alpine\n",
+			wantErr: sdk.ErrPlanDocumentInvalid,
+		},
+		{
+			name:    "invalid severity",
+			file:    "plan.json",
+			content: `{"scans": [{"name": "scan", "source": "alpine", "severity": [{"threshold": "severe"}]}]}`,
+			wantErr: sdk.ErrInvalidScanSeverity,
+		},
+		{
+			name:    "undefined variable",
+			file:    "plan.yaml",
+			content: "name: {{ .missing }}\n",
+			wantErr: sdk.ErrPlanDocumentInvalid,
+		},
+		{
+			name: "dependency defined after",
+			file: "plan.yaml",
+			content: `
+images:
+  alpine: alpine:3.20@` + testDigest + `
+versionChecks:
+  - name: check
+    source: alpine
+    dependsOn: [mirror]
+syncs:
+  - name: mirror
+    source: alpine
+    destination: registry.internal/alpine:3.20
+`,
+			wantErr: sdk.ErrPlanDocumentUnknownReference,
+		},
+		{
+			name:    "include cycle",
+			file:    "plan.yaml",
+			content: "includes: [plan.yaml]\n",
+			wantErr: sdk.ErrPlanDocumentInvalid,
+		},
+		{
+			name:    "builder validation",
+			file:    "plan.yaml",
+			content: "syncs:\n  - name: mirror\n",
+			wantErr: sdk.ErrSyncSourceRequired,
+		},
+		{
+			name:    "invalid schedule",
+			file:    "plan.yaml",
+			content: "schedule: every night\n",
+			wantErr: sdk.ErrInvalidSchedule,
+		},
+		{
+			name:    "invalid retention age",
+			file:    "plan.yaml",
+			content: "prunes:\n  - name: prune\n    repository: ghcr.io/org/app\n    policy: {dropUntaggedAfter: 30d}\n",
+			wantErr: sdk.ErrInvalidRetentionPolicy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := writePlanDocument(t, tt.file, tt.content)
+
+			_, err := sdk.LoadPlanWithOptions(path, sdk.LoadOptions{Strict: true})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("LoadPlanWithOptions() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// INTENTION: Documents include other documents, relative to their directory: included operations are added first,
+// under namespaced names the including document depends on.
+func TestLoadPlan_Includes(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	base := `
+name: base
+images:
+  alpine: alpine:3.20@` + testDigest + `
+versionChecks:
+  - name: check
+    source: alpine
+`
+	if err := os.WriteFile(filepath.Join(dir, "base.yaml"), []byte(base), 0o600); err != nil {
+		t.Fatalf("Failed to write plan document: %v", err)
+	}
+
+	pipeline := `
+includes: [base.yaml]
+images:
+  alpine: alpine:3.20@` + testDigest + `
+syncs:
+  - name: mirror
+    source: alpine
+    destination: registry.internal/alpine:3.20
+    dependsOn: [base/check]
+`
+	path := filepath.Join(dir, "pipeline.yaml")
+	if err := os.WriteFile(path, []byte(pipeline), 0o600); err != nil {
+		t.Fatalf("Failed to write plan document: %v", err)
+	}
+
+	plan, err := sdk.LoadPlan(path)
+	if err != nil {
+		t.Fatalf("LoadPlan() error = %v", err)
+	}
+
+	operations := plan.State().Operations
+	if len(operations) != 2 || operations[0].Name != "base/check" || operations[1].Name != "mirror" {
+		t.Errorf("State().Operations = %+v, want base/check then mirror", operations)
+	}
+}
+
+// INTENTION: Local paths of a document resolve against its directory, not the working directory, so
+// `quark execute -p dir/plan.yaml` reads the same files from anywhere; a build Dockerfile stays relative to its
+// context, and URLs or KMS URIs are kept.
+func TestLoadPlan_RelativePaths(t *testing.T) {
+	dir := t.TempDir()
+
+	exceptions := `
+exceptions:
+  - id: CVE-2024-1234
+    reason: Not reachable
+    owner: team-security
+    expires: 2099-12-31
+`
+	if err := os.WriteFile(filepath.Join(dir, "exceptions.yaml"), []byte(exceptions), 0o600); err != nil {
+		t.Fatalf("Failed to write exceptions: %v", err)
+	}
+
+	document := `
+name: relative
+exceptions: exceptions.yaml
+knownExploitedFeed: https://example.com/kev.json
+buildNodes:
+  - name: node
+    endpoint: ssh://builder@192.168.1.100
+    platform: linux/amd64
+images:
+  alpine: alpine:3.20@` + testDigest + `
+builds:
+  - name: build
+    context: app
+    dockerfile: build/Dockerfile
+    nodes: [node]
+    tag: registry.internal/app:1.0
+verifications:
+  - name: verify
+    image: alpine
+    key: awskms:///alias/signing
+repositoryDocs:
+  - name: docs
+    image: docker.io/my-org/app
+    readme: docs/README.md
+audits:
+  - name: audit
+    dockerfile: app/Dockerfile
+`
+
+	path := filepath.Join(dir, "plan.yaml")
+	if err := os.WriteFile(path, []byte(document), 0o600); err != nil {
+		t.Fatalf("Failed to write plan document: %v", err)
+	}
+
+	// From another directory, with the document path relative to it like a -p flag
+	workDir := t.TempDir()
+	t.Chdir(workDir)
+
+	relative, err := filepath.Rel(workDir, path)
+	if err != nil {
+		t.Fatalf("Failed to relativize plan document path: %v", err)
+	}
+
+	plan, err := sdk.LoadPlan(relative)
+	if err != nil {
+		t.Fatalf("LoadPlan() error = %v", err)
+	}
+
+	want := map[string]map[string]string{
+		"build":  {"context": filepath.Join(dir, "app"), "dockerfile": "build/Dockerfile"},
+		"verify": {"cosign key": "awskms:///alias/signing"},
+		"docs":   {"readme": filepath.Join(dir, "docs", "README.md")},
+		"audit":  {"dockerfile": filepath.Join(dir, "app", "Dockerfile")},
+	}
+
+	for _, op := range plan.State().Operations {
+		for setting, value := range want[op.Name] {
+			if op.Settings[setting] != value {
+				t.Errorf("%s %s = %q, want %q", op.Name, setting, op.Settings[setting], value)
+			}
+		}
+	}
+}
@@ -2,22 +2,55 @@ package sdk
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/internal/logfmt"
+)
+
+// Log output formats, selected with the LOG_FORMAT environment variable.
+const (
+	logFormatConsole = "console"
+	logFormatJSON    = "json"
+	logFormatLogfmt  = "logfmt"
 )
 
 // ConfigureDefaultLogger configures the global zerolog logger with sensible defaults.
 // It uses a console writer with RFC3339 timestamps for human-readable output.
 // If a log level is provided, it sets that level. Otherwise, it reads from the LOG_LEVEL
 // environment variable (defaults to "info" if not set or invalid).
+//
+// The output is configured through the environment:
+//   - LOG_FORMAT: "console" (default), "json" (one JSON object per line) or "logfmt" (key=value pairs)
+//   - NO_COLOR: any non-empty value disables console colors (https://no-color.org)
+//   - LOG_TIME_FORMAT: "rfc3339" (default), "rfc3339nano", "unix", "unixms" or a Go time layout
+//
+// Registered secrets (see RegisterSecret) are masked.
 func ConfigureDefaultLogger(ctx context.Context, level ...zerolog.Level) {
-	zerolog.TimeFieldFormat = time.RFC3339
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	timeFormat := timeFieldFormat(os.Getenv("LOG_TIME_FORMAT"))
+	zerolog.TimeFieldFormat = timeFormat
+
+	format := os.Getenv("LOG_FORMAT")
+
+	writer := logWriter(format, os.Stderr, os.Getenv("NO_COLOR") == "", timeFormat)
+	log.Logger = log.Output(RedactingWriter(writer))
 	log.Logger.WithContext(ctx)
 
+	switch format {
+	case "", logFormatConsole, logFormatJSON, logFormatLogfmt:
+	default:
+		log.Warn().Str("LOG_FORMAT", format).Msg("Invalid log format, defaulting to console")
+	}
+
 	if len(level) > 0 {
 		// Explicit level provided
 		zerolog.SetGlobalLevel(level[0])
@@ -39,3 +72,81 @@ func ConfigureDefaultLogger(ctx context.Context, level ...zerolog.Level) {
 		zerolog.SetGlobalLevel(parsedLevel)
 	}
 }
+
+// timeFieldFormat returns the zerolog time field format for a LOG_TIME_FORMAT value.
+func timeFieldFormat(value string) string {
+	switch strings.ToLower(value) {
+	case "", "rfc3339":
+		return time.RFC3339
+	case "rfc3339nano":
+		return time.RFC3339Nano
+	case "unix":
+		return zerolog.TimeFormatUnix
+	case "unixms":
+		return zerolog.TimeFormatUnixMs
+	default:
+		return value
+	}
+}
+
+// logWriter returns the writer formatting zerolog events to out.
+func logWriter(format string, out io.Writer, color bool, timeFormat string) io.Writer {
+	switch format {
+	case logFormatJSON:
+		return out
+	case logFormatLogfmt:
+		return logfmt.New(out)
+	default:
+		writer := zerolog.ConsoleWriter{Out: out, NoColor: !color}
+
+		// Unix timestamps are shown with the console default layout
+		switch timeFormat {
+		case zerolog.TimeFormatUnix, zerolog.TimeFormatUnixMs:
+		default:
+			writer.TimeFormat = timeFormat
+		}
+
+		return writer
+	}
+}
+
+// LogOperationsTo writes the logs of each operation to its own file in dir (<operation name>.log, one JSON
+// event per line) instead of the plan output, which only records where they went.
+// This keeps the output of large plans readable, and the logs of a failed operation in one place.
+// QUARK_LOG_DIR takes precedence, except for plans run by an Orchestrator.
+func (plan *Plan) LogOperationsTo(dir string) {
+	plan.operationLogDir = dir
+}
+
+// unsafeFileChars matches characters replaced in operation log file names.
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// scopeOperationLog redirects the logs of op to its file in dir, and returns the function restoring them.
+func (plan *Plan) scopeOperationLog(dir string, op operation) (func(), error) {
+	logger := op.logger()
+
+	if err := os.MkdirAll(dir, filesystem.DirPermissionsPrivate); err != nil {
+		return nil, fmt.Errorf("failed to create operation log directory: %w", err)
+	}
+
+	path := filepath.Join(dir, unsafeFileChars.ReplaceAllString(op.operationName(), "_")+".log")
+
+	// #nosec G304 -- Log directory provided by the plan author
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, filesystem.FilePermissionsPrivate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open operation log: %w", err)
+	}
+
+	plan.log.Info().Str("operation", op.operationName()).Str("log", path).Msg("logging operation to file")
+
+	original := *logger
+	*logger = original.Output(RedactingWriter(file))
+
+	return func() {
+		*logger = original
+
+		if err := file.Close(); err != nil {
+			plan.log.Warn().Err(err).Str("log", path).Msg("failed to close operation log")
+		}
+	}, nil
+}
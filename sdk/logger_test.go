@@ -0,0 +1,66 @@
+package sdk_test
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: with LogOperationsTo, each operation logs to its own file, named after the operation
+// with unsafe characters replaced, keeping its contextual fields.
+func TestPlan_LogOperationsTo(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+	digest := pushRandomImage(t, host+"/my-org/app:1.0")
+
+	source, err := sdk.NewImage("my-org/app").Domain(host).Version("1.0").Digest(digest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create source image: %v", err)
+	}
+
+	destination, err := sdk.NewImage("mirror/app").Domain(host).Version("1.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create destination image: %v", err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "logs")
+
+	plan := sdk.NewPlan(testPlanName)
+	plan.LogOperationsTo(dir)
+
+	if _, err := plan.Sync("mirror/app:1.0").Source(source).Destination(destination).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if err := plan.Execute(context.Background()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "mirror_app_1.0.log"))
+	if err != nil {
+		t.Fatalf("Failed to read operation log: %v", err)
+	}
+
+	if !strings.Contains(string(content), `"sync":"mirror/app:1.0"`) {
+		t.Errorf("operation log = %s, want sync events", content)
+	}
+}
@@ -0,0 +1,214 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/farcloser/quark/internal/buildkit"
+	"github.com/farcloser/quark/ssh"
+)
+
+// NodeMaintenance represents housekeeping on a build node: pruning old build cache
+// and pre-pulling base images, so build farms don't silently fill their disks
+// and the first builds of the day don't start cold.
+type NodeMaintenance struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	node       *BuildNode
+	prune      bool
+	pruneAge   time.Duration
+	warmImages []*Image
+
+	// sshPool is set by executor before execution
+	sshPool *ssh.Pool
+
+	// Results populated after execution
+	reclaimed []string
+}
+
+// NodeMaintenanceBuilder builds a NodeMaintenance.
+type NodeMaintenanceBuilder struct {
+	builderState
+
+	plan        *Plan
+	maintenance *NodeMaintenance
+}
+
+// PruneBuildCache removes build cache records not used for longer than olderThan
+// (all build cache if olderThan is zero).
+func (builder *NodeMaintenanceBuilder) PruneBuildCache(olderThan time.Duration) *NodeMaintenanceBuilder {
+	builder.maintenance.prune = true
+	builder.maintenance.pruneAge = olderThan
+
+	return builder
+}
+
+// WarmCache pre-pulls images (typically base images) on the node for the node platform.
+// Images are pulled by digest when set, by tag otherwise.
+// Pruning, if configured, runs before warming.
+func (builder *NodeMaintenanceBuilder) WarmCache(images ...*Image) *NodeMaintenanceBuilder {
+	builder.maintenance.warmImages = append(builder.maintenance.warmImages, images...)
+
+	return builder
+}
+
+// RunOnlyOn restricts the maintenance to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *NodeMaintenanceBuilder) RunOnlyOn(envs ...Environment) *NodeMaintenanceBuilder {
+	builder.maintenance.runOnlyOn = append(builder.maintenance.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the maintenance mostly uses (default: sdk.ResourceRemote),
+// for executors bounding how many operations of each class run at once.
+func (builder *NodeMaintenanceBuilder) Resource(resource Resource) *NodeMaintenanceBuilder {
+	builder.maintenance.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the maintenance start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *NodeMaintenanceBuilder) DependsOn(ops ...Dependency) *NodeMaintenanceBuilder {
+	builder.maintenance.add(ops)
+
+	return builder
+}
+
+// When makes the maintenance run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the maintenance.
+func (builder *NodeMaintenanceBuilder) When(conditions ...Condition) *NodeMaintenanceBuilder {
+	builder.maintenance.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for the maintenance of node, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *NodeMaintenanceBuilder) Clone(node *BuildNode) *NodeMaintenanceBuilder {
+	clone := builder.plan.NodeMaintenance(node)
+	clone.maintenance.envGuard = builder.maintenance.envGuard.clone()
+	clone.maintenance.resourceHint = builder.maintenance.resourceHint
+	clone.maintenance.dependencyList = builder.maintenance.dependencyList.clone()
+	clone.maintenance.conditionList = builder.maintenance.conditionList.clone()
+	clone.maintenance.prune = builder.maintenance.prune
+	clone.maintenance.pruneAge = builder.maintenance.pruneAge
+	clone.maintenance.warmImages = slices.Clone(builder.maintenance.warmImages)
+
+	return clone
+}
+
+// Reset makes the builder usable again for the maintenance of node, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *NodeMaintenanceBuilder) Reset(node *BuildNode) *NodeMaintenanceBuilder {
+	*builder = *builder.Clone(node)
+
+	return builder
+}
+
+// Build validates and adds the maintenance to the plan.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *NodeMaintenanceBuilder) Build() (*NodeMaintenance, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.maintenance.opName); err != nil {
+		return nil, err
+	}
+
+	if builder.maintenance.node == nil {
+		return nil, ErrNodeMaintenanceNodeRequired
+	}
+
+	if !builder.maintenance.prune && len(builder.maintenance.warmImages) == 0 {
+		return nil, fmt.Errorf("%w for node %q", ErrNodeMaintenanceTaskRequired, builder.maintenance.node.Name())
+	}
+
+	if builder.maintenance.pruneAge < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPruneAge, builder.maintenance.pruneAge)
+	}
+
+	for _, image := range builder.maintenance.warmImages {
+		if _, err := image.pullRef(); err != nil {
+			return nil, err
+		}
+	}
+
+	builder.plan.maintenances = append(builder.plan.maintenances, builder.maintenance)
+	builder.plan.addOperation(builder.maintenance)
+
+	return builder.maintenance, nil
+}
+
+// pullRef returns the reference an image is pulled by: by digest when set, by tag otherwise.
+func (img *Image) pullRef() (string, error) {
+	if img.Digest() != "" {
+		return img.digestRef()
+	}
+
+	return img.tagRef()
+}
+
+func (maintenance *NodeMaintenance) execute(ctx context.Context) error {
+	node := maintenance.node
+
+	maintenance.log.Info().
+		Str("node", node.name).
+		Bool("prune", maintenance.prune).
+		Int("warm_images", len(maintenance.warmImages)).
+		Msg("running node maintenance")
+
+	sshClient, err := maintenance.sshPool.GetClient(node.endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to connect to build node: %w", err)
+	}
+
+	bkClient := buildkit.NewClient(sshClient, maintenance.log)
+
+	if maintenance.prune {
+		reclaimed, err := bkClient.PruneCache(ctx, maintenance.pruneAge)
+		if err != nil {
+			return fmt.Errorf("failed to prune build cache on node %q: %w", node.name, err)
+		}
+
+		maintenance.reclaimed = reclaimed
+
+		maintenance.log.Info().
+			Str("node", node.name).
+			Strs("reclaimed", reclaimed).
+			Msg("build cache pruned")
+	}
+
+	for _, image := range maintenance.warmImages {
+		ref, err := image.pullRef()
+		if err != nil {
+			return fmt.Errorf("failed to build image reference: %w", err)
+		}
+
+		if err := bkClient.PullImage(ctx, ref, node.platform.String()); err != nil {
+			return fmt.Errorf("failed to warm cache on node %q: %w", node.name, err)
+		}
+
+		maintenance.log.Info().
+			Str("node", node.name).
+			Str("image", ref).
+			Msg("image pulled")
+	}
+
+	return nil
+}
+
+// Reclaimed returns the space reclaimed by pruning, per pruned builder as reported by buildx
+// (e.g., "1.2GB"; empty before execution).
+func (maintenance *NodeMaintenance) Reclaimed() []string {
+	return maintenance.reclaimed
+}
@@ -0,0 +1,108 @@
+package sdk_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: NodeMaintenance requires a node and at least one task; warmed images must be pullable
+// by tag or digest, and the prune age must not be negative.
+func TestNodeMaintenanceBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		build   func(*sdk.Plan, *sdk.BuildNode) (*sdk.NodeMaintenance, error)
+		wantErr error
+	}{
+		{
+			name: "prune build cache",
+			build: func(plan *sdk.Plan, node *sdk.BuildNode) (*sdk.NodeMaintenance, error) {
+				return plan.NodeMaintenance(node).PruneBuildCache(72 * time.Hour).Build()
+			},
+		},
+		{
+			name: "prune all build cache",
+			build: func(plan *sdk.Plan, node *sdk.BuildNode) (*sdk.NodeMaintenance, error) {
+				return plan.NodeMaintenance(node).PruneBuildCache(0).Build()
+			},
+		},
+		{
+			name: "warm cache by tag and digest",
+			build: func(plan *sdk.Plan, node *sdk.BuildNode) (*sdk.NodeMaintenance, error) {
+				byTag, err := sdk.NewImage("library/debian").Version("trixie-slim").Build()
+				if err != nil {
+					return nil, err
+				}
+
+				byDigest, err := sdk.NewImage("library/golang").Digest(testDigest).Build()
+				if err != nil {
+					return nil, err
+				}
+
+				return plan.NodeMaintenance(node).WarmCache(byTag, byDigest).Build()
+			},
+		},
+		{
+			name: "missing node",
+			build: func(plan *sdk.Plan, _ *sdk.BuildNode) (*sdk.NodeMaintenance, error) {
+				return plan.NodeMaintenance(nil).PruneBuildCache(time.Hour).Build()
+			},
+			wantErr: sdk.ErrNodeMaintenanceNodeRequired,
+		},
+		{
+			name: "no task",
+			build: func(plan *sdk.Plan, node *sdk.BuildNode) (*sdk.NodeMaintenance, error) {
+				return plan.NodeMaintenance(node).Build()
+			},
+			wantErr: sdk.ErrNodeMaintenanceTaskRequired,
+		},
+		{
+			name: "negative prune age",
+			build: func(plan *sdk.Plan, node *sdk.BuildNode) (*sdk.NodeMaintenance, error) {
+				return plan.NodeMaintenance(node).PruneBuildCache(-time.Hour).Build()
+			},
+			wantErr: sdk.ErrInvalidPruneAge,
+		},
+		{
+			name: "warm image without tag or digest",
+			build: func(plan *sdk.Plan, node *sdk.BuildNode) (*sdk.NodeMaintenance, error) {
+				image, err := sdk.NewImage("library/debian").Build()
+				if err != nil {
+					return nil, err
+				}
+
+				return plan.NodeMaintenance(node).WarmCache(image).Build()
+			},
+			wantErr: sdk.ErrImageVersionRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			plan := sdk.NewPlan(testPlanName)
+
+			node, err := plan.BuildNode("test-node").
+				Endpoint("build-amd64.example.com").
+				Platform(sdk.PlatformAMD64).
+				Build()
+			if err != nil {
+				t.Fatalf("Failed to create test build node: %v", err)
+			}
+
+			maintenance, err := tt.build(plan, node)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && maintenance == nil {
+				t.Error("Build() returned nil maintenance with nil error")
+			}
+		})
+	}
+}
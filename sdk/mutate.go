@@ -0,0 +1,408 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// Mutate represents changing the config of an image (labels, environment, user, entrypoint, timestamps) without
+// rebuilding it, pinning the result by digest: to stamp build metadata, to fix upstream images running as root,
+// or to make build outputs reproducible.
+type Mutate struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	image        *Image
+	registry     *Registry
+	destImage    *Image
+	destRegistry *Registry
+	labels       map[string]string
+	env          map[string]string
+	user         string
+	entrypoint   []string
+	cmd          []string
+	timestamp    *time.Time
+
+	// Results populated after execution
+	digest  string
+	mutated bool
+}
+
+// MutateBuilder builds a Mutate.
+type MutateBuilder struct {
+	builderState
+
+	plan   *Plan
+	mutate *Mutate
+}
+
+// Image sets the image to change (e.g., docker.io/library/nginx:1.27), by tag or digest. When it is the output
+// of another operation (see Build.OutputImage), the mutation depends on it.
+// Registry credentials are looked up from the plan's registry collection using the image domain.
+func (builder *MutateBuilder) Image(image *Image) *MutateBuilder {
+	builder.mutate.image = image
+	builder.mutate.consume(image)
+	builder.mutate.registry = builder.plan.getRegistry(image.Domain())
+
+	return builder
+}
+
+// Destination sets the image the changed image is pushed to (default: the tag of Image, which is moved).
+// The destination must have a version.
+func (builder *MutateBuilder) Destination(image *Image) *MutateBuilder {
+	builder.mutate.destImage = image
+	builder.mutate.destRegistry = builder.plan.getRegistry(image.Domain())
+
+	return builder
+}
+
+// SetLabel sets an image label (e.g., "org.opencontainers.image.revision", the commit of the build).
+func (builder *MutateBuilder) SetLabel(key, value string) *MutateBuilder {
+	builder.mutate.labels[key] = value
+
+	return builder
+}
+
+// SetEnv sets an environment variable, replacing its value in the image if already set.
+func (builder *MutateBuilder) SetEnv(key, value string) *MutateBuilder {
+	builder.mutate.env[key] = value
+
+	return builder
+}
+
+// SetUser sets the user the image runs as (e.g., "nonroot", "65532:65532").
+func (builder *MutateBuilder) SetUser(user string) *MutateBuilder {
+	builder.mutate.user = user
+
+	return builder
+}
+
+// SetEntrypoint replaces the entrypoint of the image. The command (arguments) of the image is kept.
+func (builder *MutateBuilder) SetEntrypoint(entrypoint ...string) *MutateBuilder {
+	builder.mutate.entrypoint = entrypoint
+
+	return builder
+}
+
+// SetCmd replaces the command (the arguments of the entrypoint) of the image.
+func (builder *MutateBuilder) SetCmd(cmd ...string) *MutateBuilder {
+	builder.mutate.cmd = cmd
+
+	return builder
+}
+
+// Timestamp sets the creation time of the image and of its history entries (e.g., time.Unix(0, 0), or the time of
+// the commit built): images built twice from the same sources then get the same digest, for reproducible build
+// verification. The time is recorded in UTC.
+func (builder *MutateBuilder) Timestamp(timestamp time.Time) *MutateBuilder {
+	// Monotonic clock readings and time zones would change the digest, not the time
+	utc := timestamp.UTC().Round(0)
+	builder.mutate.timestamp = &utc
+
+	return builder
+}
+
+// RunOnlyOn restricts the mutation to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *MutateBuilder) RunOnlyOn(envs ...Environment) *MutateBuilder {
+	builder.mutate.runOnlyOn = append(builder.mutate.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the mutation mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *MutateBuilder) Resource(resource Resource) *MutateBuilder {
+	builder.mutate.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the mutation start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *MutateBuilder) DependsOn(ops ...Dependency) *MutateBuilder {
+	builder.mutate.add(ops)
+
+	return builder
+}
+
+// When makes the mutation run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the mutation.
+func (builder *MutateBuilder) When(conditions ...Condition) *MutateBuilder {
+	builder.mutate.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a mutation named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *MutateBuilder) Clone(name string) *MutateBuilder {
+	clone := builder.plan.Mutate(name)
+	clone.mutate.envGuard = builder.mutate.envGuard.clone()
+	clone.mutate.resourceHint = builder.mutate.resourceHint
+	clone.mutate.dependencyList = builder.mutate.dependencyList.clone()
+	clone.mutate.conditionList = builder.mutate.conditionList.clone()
+	clone.mutate.image = builder.mutate.image
+	clone.mutate.registry = builder.mutate.registry
+	clone.mutate.destImage = builder.mutate.destImage
+	clone.mutate.destRegistry = builder.mutate.destRegistry
+	clone.mutate.labels = maps.Clone(builder.mutate.labels)
+	clone.mutate.env = maps.Clone(builder.mutate.env)
+	clone.mutate.user = builder.mutate.user
+	clone.mutate.entrypoint = slices.Clone(builder.mutate.entrypoint)
+	clone.mutate.cmd = slices.Clone(builder.mutate.cmd)
+	clone.mutate.timestamp = builder.mutate.timestamp
+
+	return clone
+}
+
+// Reset makes the builder usable again for a mutation named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *MutateBuilder) Reset(name string) *MutateBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build validates and adds the mutation to the plan.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *MutateBuilder) Build() (*Mutate, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.mutate.opName); err != nil {
+		return nil, err
+	}
+
+	mutate := builder.mutate
+
+	if mutate.image == nil {
+		return nil, ErrMutateImageRequired
+	}
+
+	if len(mutate.labels) == 0 && len(mutate.env) == 0 && mutate.user == "" && mutate.entrypoint == nil &&
+		mutate.cmd == nil && mutate.timestamp == nil {
+		return nil, ErrMutateChangeRequired
+	}
+
+	for _, image := range []*Image{mutate.image, mutate.destination()} {
+		if err := image.checkRegistry(); err != nil {
+			return nil, err
+		}
+	}
+
+	if mutate.destination().Version() == "" {
+		return nil, fmt.Errorf("%w for image %q", ErrMutateVersionRequired, mutate.destination().Name())
+	}
+
+	builder.plan.mutations = append(builder.plan.mutations, mutate)
+	builder.plan.addOperation(mutate)
+
+	return mutate, nil
+}
+
+// destination returns the image the changed image is pushed to.
+func (mutate *Mutate) destination() *Image {
+	if mutate.destImage != nil {
+		return mutate.destImage
+	}
+
+	return mutate.image
+}
+
+// destinationRegistry returns the registry of the destination.
+func (mutate *Mutate) destinationRegistry() *Registry {
+	if mutate.destImage != nil {
+		return mutate.destRegistry
+	}
+
+	return mutate.registry
+}
+
+// change applies the changes of the mutation to the config file of an image.
+func (mutate *Mutate) change(configFile *v1.ConfigFile) {
+	config := &configFile.Config
+
+	if mutate.timestamp != nil {
+		configFile.Created = v1.Time{Time: *mutate.timestamp}
+
+		for idx := range configFile.History {
+			configFile.History[idx].Created = v1.Time{Time: *mutate.timestamp}
+		}
+	}
+
+	if len(mutate.labels) > 0 {
+		if config.Labels == nil {
+			config.Labels = make(map[string]string, len(mutate.labels))
+		}
+
+		maps.Copy(config.Labels, mutate.labels)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(mutate.env)) {
+		variable := key + "=" + mutate.env[key]
+
+		idx := slices.IndexFunc(config.Env, func(entry string) bool {
+			return strings.HasPrefix(entry, key+"=")
+		})
+		if idx < 0 {
+			config.Env = append(config.Env, variable)
+		} else {
+			config.Env[idx] = variable
+		}
+	}
+
+	if mutate.user != "" {
+		config.User = mutate.user
+	}
+
+	if mutate.entrypoint != nil {
+		config.Entrypoint = slices.Clone(mutate.entrypoint)
+	}
+
+	if mutate.cmd != nil {
+		config.Cmd = slices.Clone(mutate.cmd)
+	}
+}
+
+func (mutate *Mutate) execute(ctx context.Context) error {
+	ref, err := mutate.image.pullRef()
+	if err != nil {
+		return fmt.Errorf("failed to build image reference: %w", err)
+	}
+
+	destRef, err := mutate.destination().tagRef()
+	if err != nil {
+		return fmt.Errorf("failed to build destination reference: %w", err)
+	}
+
+	mutate.log.Info().
+		Str("image", ref).
+		Str("destination", destRef).
+		Msg("changing image config")
+
+	image := registry.RemoteImage{Ref: ref, Client: newRegistryClient(mutate.registry, mutate.log)}
+
+	previous, err := image.Client.GetDigest(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("failed to get image digest: %w", err)
+	}
+
+	mutated, err := newRegistryClient(mutate.destinationRegistry(), mutate.log).
+		MutateConfig(ctx, image, destRef, mutate.change)
+	if err != nil {
+		return fmt.Errorf("failed to change the config of %s: %w", ref, err)
+	}
+
+	mutate.digest = mutated
+	mutate.mutated = mutated != previous
+	// Subsequent operations (e.g., scanning) see the changed image, pinned by digest
+	mutate.destination().ref.Digest = digest.Digest(mutated)
+
+	mutate.log.Info().
+		Str("destination", destRef).
+		Str("digest", mutated).
+		Bool("mutated", mutate.mutated).
+		Msg("config change complete")
+
+	return nil
+}
+
+// plannedChanges implements dryRunOperation: the image must exist, when its digest is known.
+func (mutate *Mutate) plannedChanges(ctx context.Context) ([]string, error) {
+	imageRef, err := checkImage(ctx, newRegistryClient(mutate.registry, mutate.log), mutate.image)
+	if err != nil {
+		return nil, err
+	}
+
+	destRef, err := mutate.destination().tagRef()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build destination reference: %w", err)
+	}
+
+	return []string{fmt.Sprintf("Would change the config of %s (%s) and push it to %s", imageRef,
+		strings.Join(mutate.changes(), ", "), destRef)}, nil
+}
+
+// changes describes the changes of the mutation (e.g., "user=nonroot").
+func (mutate *Mutate) changes() []string {
+	var changes []string
+
+	for _, key := range slices.Sorted(maps.Keys(mutate.labels)) {
+		changes = append(changes, "label "+key+"="+mutate.labels[key])
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(mutate.env)) {
+		changes = append(changes, "env "+key+"="+mutate.env[key])
+	}
+
+	if mutate.user != "" {
+		changes = append(changes, "user="+mutate.user)
+	}
+
+	if mutate.entrypoint != nil {
+		changes = append(changes, fmt.Sprintf("entrypoint=%q", mutate.entrypoint))
+	}
+
+	if mutate.cmd != nil {
+		changes = append(changes, fmt.Sprintf("cmd=%q", mutate.cmd))
+	}
+
+	if mutate.timestamp != nil {
+		changes = append(changes, "timestamp="+mutate.timestamp.Format(time.RFC3339))
+	}
+
+	return changes
+}
+
+// destructiveChange implements destructiveOperation: a mutation overwrites the destination tag, the image tag
+// by default.
+func (mutate *Mutate) destructiveChange(ctx context.Context) (string, error) {
+	destRef, err := mutate.destination().tagRef()
+	if err != nil {
+		return "", fmt.Errorf("failed to build destination reference: %w", err)
+	}
+
+	return tagOverwrite(ctx, newRegistryClient(mutate.destinationRegistry(), mutate.log), destRef, "")
+}
+
+// Digest returns the digest of the changed image (empty before execution).
+func (mutate *Mutate) Digest() string {
+	return mutate.digest
+}
+
+// Mutated reports whether the execution pushed a changed image (false when the image config already had the
+// changes).
+func (mutate *Mutate) Mutated() bool {
+	return mutate.mutated
+}
+
+// cacheSources implements cacheableOperation: a mutation reads the mutated image.
+func (mutate *Mutate) cacheSources() []*Image {
+	return []*Image{mutate.image}
+}
+
+// producedDigest implements producingOperation.
+func (mutate *Mutate) producedDigest() string {
+	return mutate.Digest()
+}
+
+// resolveRegistries implements registryOperation.
+func (mutate *Mutate) resolveRegistries(lookup func(image *Image) *Registry) {
+	mutate.registry = lookup(mutate.image)
+	mutate.destRegistry = lookup(mutate.destImage)
+}
@@ -0,0 +1,251 @@
+package sdk_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: A mutation requires the image, at least one config change, and a tag to push the changed image to
+// (the image tag by default).
+func TestMutateBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	app, err := sdk.NewImage("my-org/app").Domain("ghcr.io").Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	pinned, err := sdk.NewImage("my-org/app").Domain("ghcr.io").Digest(testDigest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		build   func(*sdk.Plan) (*sdk.Mutate, error)
+		wantErr error
+	}{
+		{
+			name: "in place",
+			build: func(plan *sdk.Plan) (*sdk.Mutate, error) {
+				return plan.Mutate("mutate").Image(app).SetUser("nonroot").Build()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "pinned image to a destination",
+			build: func(plan *sdk.Plan) (*sdk.Mutate, error) {
+				return plan.Mutate("mutate").Image(pinned).SetLabel("team", "platform").Destination(app).Build()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "missing image",
+			build: func(plan *sdk.Plan) (*sdk.Mutate, error) {
+				return plan.Mutate("mutate").SetUser("nonroot").Build()
+			},
+			wantErr: sdk.ErrMutateImageRequired,
+		},
+		{
+			name: "no change",
+			build: func(plan *sdk.Plan) (*sdk.Mutate, error) {
+				return plan.Mutate("mutate").Image(app).Build()
+			},
+			wantErr: sdk.ErrMutateChangeRequired,
+		},
+		{
+			name: "pinned image in place",
+			build: func(plan *sdk.Plan) (*sdk.Mutate, error) {
+				return plan.Mutate("mutate").Image(pinned).SetEnv("APP_ENV", "production").Build()
+			},
+			wantErr: sdk.ErrMutateVersionRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mutate, err := tt.build(sdk.NewPlan(testPlanName))
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil || mutate == nil {
+				t.Errorf("Build() = %v, %v, want a mutation", mutate, err)
+			}
+		})
+	}
+}
+
+// INTENTION: Executing a mutation pushes the image with the changed config and pins the image by digest for
+// subsequent operations; a second run finds the config already changed.
+func TestMutate_Execute(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	pushRandomImage(t, host+"/library/nginx:1.27")
+
+	var mutated string
+
+	for run := range 2 {
+		image, err := sdk.NewImage("library/nginx").Domain(host).Version("1.27").Build()
+		if err != nil {
+			t.Fatalf("Failed to create test image: %v", err)
+		}
+
+		plan := sdk.NewPlan(testPlanName)
+
+		mutate, err := plan.Mutate("run-as-nonroot").
+			Image(image).
+			SetUser("nonroot").
+			SetEnv("APP_ENV", "production").
+			SetLabel("org.opencontainers.image.revision", "abc123").
+			Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		if err := plan.Execute(t.Context()); err != nil {
+			t.Fatalf("run %d: Execute() error = %v", run, err)
+		}
+
+		if mutate.Mutated() != (run == 0) || image.Digest() != mutate.Digest() {
+			t.Errorf("run %d: Mutated() = %v, Digest() = %q, image digest = %q", run, mutate.Mutated(),
+				mutate.Digest(), image.Digest())
+		}
+
+		if run == 0 {
+			mutated = mutate.Digest()
+		} else if mutate.Digest() != mutated {
+			t.Errorf("run %d: Digest() = %q, want unchanged %q", run, mutate.Digest(), mutated)
+		}
+	}
+
+	ref, err := name.ParseReference(host + "/library/nginx:1.27")
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+
+	img, err := remote.Image(ref)
+	if err != nil {
+		t.Fatalf("Failed to get mutated image: %v", err)
+	}
+
+	config, err := img.ConfigFile()
+	if err != nil {
+		t.Fatalf("Failed to get config: %v", err)
+	}
+
+	if config.Config.User != "nonroot" || !slices.Contains(config.Config.Env, "APP_ENV=production") ||
+		config.Config.Labels["org.opencontainers.image.revision"] != "abc123" {
+		t.Errorf("config = %+v, want the user, environment variable and label set", config.Config)
+	}
+}
+
+// INTENTION: A mutation with a timestamp sets the creation time of the image and of its history, whatever the time
+// zone of the timestamp, so mutating again with the same instant changes nothing.
+func TestMutate_Timestamp(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	pushRandomImage(t, host+"/my-org/app:1.0.0")
+
+	epoch := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	for run, timestamp := range []time.Time{epoch, epoch.In(time.FixedZone("CET", 3600))} {
+		image, err := sdk.NewImage("my-org/app").Domain(host).Version("1.0.0").Build()
+		if err != nil {
+			t.Fatalf("Failed to create test image: %v", err)
+		}
+
+		plan := sdk.NewPlan(testPlanName)
+
+		mutate, err := plan.Mutate("reproducible").Image(image).Timestamp(timestamp).Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		if err := plan.Execute(t.Context()); err != nil {
+			t.Fatalf("run %d: Execute() error = %v", run, err)
+		}
+
+		if mutate.Mutated() != (run == 0) {
+			t.Errorf("run %d: Mutated() = %v, want %v", run, mutate.Mutated(), run == 0)
+		}
+	}
+
+	ref, err := name.ParseReference(host + "/my-org/app:1.0.0")
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+
+	img, err := remote.Image(ref)
+	if err != nil {
+		t.Fatalf("Failed to get mutated image: %v", err)
+	}
+
+	config, err := img.ConfigFile()
+	if err != nil {
+		t.Fatalf("Failed to get config: %v", err)
+	}
+
+	if !config.Created.Equal(epoch) {
+		t.Errorf("Created = %v, want %v", config.Created, epoch)
+	}
+
+	for idx, history := range config.History {
+		if !history.Created.Equal(epoch) {
+			t.Errorf("History[%d].Created = %v, want %v", idx, history.Created, epoch)
+		}
+	}
+}
+
+// INTENTION: Mutating the output of a build makes the mutation depend on the build.
+func TestMutateBuilder_ConsumesBuildOutput(t *testing.T) {
+	t.Parallel()
+
+	plan := sdk.NewPlan(testPlanName)
+
+	node, err := plan.BuildNode("node").Endpoint("ssh://builder@192.168.1.100").Platform(sdk.PlatformAMD64).Build()
+	if err != nil {
+		t.Fatalf("Failed to create test node: %v", err)
+	}
+
+	build, err := plan.Build("build-app").Context("/path/to/context").Node(node).Tag("ghcr.io/my-org/app:1.0.0").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if _, err := plan.Mutate("mutate").Image(build.OutputImage()).SetUser("nonroot").Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if got := plan.State().Operations[1].Settings["depends on"]; got != "build-app" {
+		t.Errorf("depends on = %q, want the build", got)
+	}
+}
@@ -0,0 +1,43 @@
+package sdk
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// UniqueName returns name if no operation of the plan uses it yet, or name followed by the first free
+// counter (e.g., "mirror-2", "mirror-3"), for plans deriving operation names from their inputs
+// (e.g., plan.Sync(plan.UniqueName("mirror-" + image.Name()))).
+// The returned name is reserved: later calls return other names, so a plan defined in the same order always
+// gets the same names.
+func (plan *Plan) UniqueName(name string) string {
+	unique := name
+
+	for counter := 2; ; counter++ {
+		if _, used := plan.operationNames[unique]; !used {
+			break
+		}
+
+		unique = name + "-" + strconv.Itoa(counter)
+	}
+
+	plan.operationNames[unique] = false
+
+	return unique
+}
+
+// checkOperationName rejects names already used by an operation of the plan:
+// logs, reports and result history identify operations by name.
+func (plan *Plan) checkOperationName(name string) error {
+	if plan.operationNames[name] {
+		return fmt.Errorf("%w: %q", ErrDuplicateOperationName, name)
+	}
+
+	return nil
+}
+
+// addOperation appends op to the operations of the plan, and records its name.
+func (plan *Plan) addOperation(op operation) {
+	plan.operations = append(plan.operations, op)
+	plan.operationNames[op.operationName()] = true
+}
@@ -0,0 +1,68 @@
+package sdk_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: Operation names are unique in a plan, whatever the operation kind; a Build() failing validation
+// does not take its name.
+func TestPlan_DuplicateOperationName(t *testing.T) {
+	t.Parallel()
+
+	plan := sdk.NewPlan(testPlanName)
+
+	if _, err := newTestSync(t, plan, "mirror").Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if _, err := newTestSync(t, plan, "mirror").Build(); !errors.Is(err, sdk.ErrDuplicateOperationName) {
+		t.Errorf("Build() error = %v, want %v", err, sdk.ErrDuplicateOperationName)
+	}
+
+	image, err := sdk.NewImage("alpine").Version("3.20").Build()
+	if err != nil {
+		t.Fatalf("Failed to create image: %v", err)
+	}
+
+	if _, err := plan.VersionCheck("mirror").Source(image).Build(); !errors.Is(err, sdk.ErrDuplicateOperationName) {
+		t.Errorf("VersionCheck Build() error = %v, want %v", err, sdk.ErrDuplicateOperationName)
+	}
+
+	if _, err := plan.Scan("scan").Build(); !errors.Is(err, sdk.ErrScanImageRequired) {
+		t.Fatalf("Scan Build() error = %v, want %v", err, sdk.ErrScanImageRequired)
+	}
+
+	if _, err := plan.VersionCheck("scan").Source(image).Build(); err != nil {
+		t.Errorf("VersionCheck Build() error = %v, name of a failed Build() should be free", err)
+	}
+}
+
+// INTENTION: UniqueName suffixes names already used or handed out with the first free counter, so derived
+// names are the same for every run of a plan.
+func TestPlan_UniqueName(t *testing.T) {
+	t.Parallel()
+
+	plan := sdk.NewPlan(testPlanName)
+
+	if _, err := newTestSync(t, plan, "mirror").Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	want := []string{"mirror-2", "mirror-3"}
+	for _, name := range want {
+		if got := plan.UniqueName("mirror"); got != name {
+			t.Errorf("UniqueName() = %q, want %q", got, name)
+		}
+	}
+
+	if got := plan.UniqueName("scan"); got != "scan" {
+		t.Errorf("UniqueName() = %q, want %q", got, "scan")
+	}
+
+	if _, err := newTestSync(t, plan, want[0]).Build(); err != nil {
+		t.Errorf("Build() error = %v, reserved names should be usable", err)
+	}
+}
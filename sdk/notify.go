@@ -0,0 +1,342 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/farcloser/quark/internal/webhook"
+)
+
+// RunEvent is the webhook event type of RunNotification.
+const RunEvent = "run"
+
+// NotifyFormat represents the payload format of a notification endpoint.
+type NotifyFormat struct {
+	value string
+}
+
+//nolint:gochecknoglobals // NotifyFormat enum pattern requires global variables
+var (
+	// NotifySlack posts a Slack incoming webhook message.
+	NotifySlack = NotifyFormat{"slack"}
+	// NotifyTeams posts a Microsoft Teams workflow message (adaptive card).
+	NotifyTeams = NotifyFormat{"teams"}
+	// NotifyJSON posts a RunNotification, signed like the sync webhook.
+	NotifyJSON = NotifyFormat{"json"}
+)
+
+// String returns the string representation of the format.
+func (f *NotifyFormat) String() string {
+	return f.value
+}
+
+// parseNotifyFormat parses a notification format name ("slack", "teams" or "json").
+func parseNotifyFormat(name string) (NotifyFormat, error) {
+	switch strings.ToLower(name) {
+	case "slack":
+		return NotifySlack, nil
+	case "teams":
+		return NotifyTeams, nil
+	case "json":
+		return NotifyJSON, nil
+	default:
+		return NotifyFormat{}, fmt.Errorf("%w: %q (valid: slack, teams, json)", ErrInvalidNotifyFormat, name)
+	}
+}
+
+// NotifyEvent represents an outcome of a run notifications are sent for.
+type NotifyEvent struct {
+	value string
+}
+
+//nolint:gochecknoglobals // NotifyEvent enum pattern requires global variables
+var (
+	// NotifyOnSuccess notifies runs that succeeded.
+	NotifyOnSuccess = NotifyEvent{"success"}
+	// NotifyOnFailure notifies runs that failed.
+	NotifyOnFailure = NotifyEvent{"failure"}
+	// NotifyOnUpdate notifies runs whose version checks found updates.
+	NotifyOnUpdate = NotifyEvent{"update"}
+)
+
+// String returns the string representation of the event.
+func (e *NotifyEvent) String() string {
+	return e.value
+}
+
+// parseNotifyEvent parses a notification event name ("success", "failure" or "update").
+func parseNotifyEvent(name string) (NotifyEvent, error) {
+	switch strings.ToLower(name) {
+	case "success":
+		return NotifyOnSuccess, nil
+	case "failure":
+		return NotifyOnFailure, nil
+	case "update":
+		return NotifyOnUpdate, nil
+	default:
+		return NotifyEvent{}, fmt.Errorf("%w: %q (valid: success, failure, update)", ErrInvalidNotifyEvent, name)
+	}
+}
+
+// RunFailure is a failed operation in a RunNotification.
+type RunFailure struct {
+	Operation string `json:"operation"`
+	Kind      string `json:"kind"`
+	Error     string `json:"error"`
+}
+
+// RunUpdate is an update found by a version check in a RunNotification.
+type RunUpdate struct {
+	Operation string `json:"operation"`
+	// Update is the current and latest versions (e.g., "3.19 -> 3.20").
+	Update string `json:"update"`
+}
+
+// RunNotification is the JSON payload POSTed to NotifyJSON endpoints at the end of a run (see Plan.Notify).
+type RunNotification struct {
+	Event string `json:"event"` // Always RunEvent
+	Plan  string `json:"plan"`
+	// Events are the outcomes of the run (e.g., ["failure", "update"]).
+	Events    []string `json:"events"`
+	Succeeded bool     `json:"succeeded"`
+	// Error is the execution error, when the run failed.
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+	// Summary is the summary of the execution report (produced digests, vulnerabilities, audit issues, updates).
+	Summary  []string     `json:"summary,omitempty"`
+	Failures []RunFailure `json:"failures,omitempty"`
+	Updates  []RunUpdate  `json:"updates,omitempty"`
+	Time     time.Time    `json:"time"`
+}
+
+// Notifier posts a summary of each run of the plan to an endpoint (see Plan.Notify).
+type Notifier struct {
+	sender *webhook.Sender
+	format NotifyFormat
+	events []NotifyEvent
+}
+
+// NotifyBuilder builds a Notifier.
+type NotifyBuilder struct {
+	builderState
+
+	plan     *Plan
+	endpoint string
+	secret   string
+	notifier *Notifier
+}
+
+// Notify returns a builder for a notification endpoint: at the end of every Execute, a summary of the run
+// (outcome, report summary, failed operations with their error, updates found by version checks) is posted to
+// endpoint, a Slack or Microsoft Teams incoming webhook, or any HTTP endpoint accepting JSON.
+func (plan *Plan) Notify(endpoint string) *NotifyBuilder {
+	return &NotifyBuilder{plan: plan, endpoint: endpoint, notifier: &Notifier{}}
+}
+
+// Format sets the payload format (default: detected from the endpoint, hooks.slack.com for Slack,
+// *.webhook.office.com and *.logic.azure.com for Teams, NotifyJSON otherwise).
+func (builder *NotifyBuilder) Format(format NotifyFormat) *NotifyBuilder {
+	builder.notifier.format = format
+
+	return builder
+}
+
+// Events restricts notifications to runs with one of the given outcomes (default: every run). For example,
+// Events(sdk.NotifyOnFailure, sdk.NotifyOnUpdate) stays silent on successful runs without updates.
+func (builder *NotifyBuilder) Events(events ...NotifyEvent) *NotifyBuilder {
+	builder.notifier.events = append(builder.notifier.events, events...)
+
+	return builder
+}
+
+// Secret makes the requests carry the HMAC-SHA256 of their X-Quark-Timestamp header and body in the
+// X-Quark-Signature header ("sha256=<hex>"), for endpoints authenticating notifications.
+func (builder *NotifyBuilder) Secret(secret string) *NotifyBuilder {
+	builder.secret = secret
+	secretValues.Add(secret)
+
+	return builder
+}
+
+// Build validates and adds the notification endpoint to the plan.
+// The builder becomes unusable after Build() is called.
+func (builder *NotifyBuilder) Build() (*Notifier, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	parsed, err := url.Parse(builder.endpoint)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidWebhook, builder.endpoint)
+	}
+
+	notifier := builder.notifier
+	notifier.sender = &webhook.Sender{URL: builder.endpoint, Secret: builder.secret}
+
+	if notifier.format == (NotifyFormat{}) {
+		notifier.format = detectNotifyFormat(parsed.Hostname())
+	}
+
+	builder.plan.notifiers = append(builder.plan.notifiers, notifier)
+
+	return notifier, nil
+}
+
+// detectNotifyFormat returns the format of the endpoint at host.
+func detectNotifyFormat(host string) NotifyFormat {
+	switch {
+	case host == "hooks.slack.com":
+		return NotifySlack
+	case strings.HasSuffix(host, ".webhook.office.com"), strings.HasSuffix(host, ".logic.azure.com"):
+		return NotifyTeams
+	default:
+		return NotifyJSON
+	}
+}
+
+// sendNotifications posts the run summary to the notification endpoints whose events match the outcome of the run.
+// Failed deliveries are added to the report warnings: they never change the outcome of the execution.
+// Dry runs and validations are not notified.
+func (plan *Plan) sendNotifications(ctx context.Context, execErr error) {
+	if len(plan.notifiers) == 0 || plan.dryRun || plan.processEnv("QUARK_DRY_RUN", "") == "true" ||
+		plan.processEnv("QUARK_VALIDATE", "") == "true" {
+		return
+	}
+
+	notification := plan.runNotification(execErr)
+
+	for _, notifier := range plan.notifiers {
+		if !notifier.matches(notification.Events) {
+			continue
+		}
+
+		if err := notifier.sender.Send(ctx, RunEvent, notifier.payload(notification)); err != nil {
+			warning := fmt.Sprintf("Failed to send %s notification: %v", notifier.format.value, err)
+			plan.report.Warnings = append(plan.report.Warnings, warning)
+			plan.log.Warn().Err(err).Str("format", notifier.format.value).Msg("failed to send notification")
+
+			continue
+		}
+
+		plan.log.Debug().Str("format", notifier.format.value).Msg("notification sent")
+	}
+}
+
+// runNotification summarizes the last execution.
+func (plan *Plan) runNotification(execErr error) RunNotification {
+	report := plan.report
+
+	notification := RunNotification{
+		Event:     RunEvent,
+		Plan:      report.Plan,
+		Succeeded: execErr == nil && report.Succeeded(),
+		Duration:  report.Duration,
+		Summary:   report.summary(),
+		Time:      time.Now().UTC(),
+	}
+
+	if execErr != nil {
+		notification.Error = execErr.Error()
+	}
+
+	for _, op := range report.Operations {
+		if op.Status == StatusFailed {
+			notification.Failures = append(notification.Failures, RunFailure{
+				Operation: op.Name,
+				Kind:      op.Kind,
+				Error:     op.Error,
+			})
+		}
+
+		if op.Update != "" {
+			notification.Updates = append(notification.Updates, RunUpdate{Operation: op.Name, Update: op.Update})
+		}
+	}
+
+	if notification.Succeeded {
+		notification.Events = append(notification.Events, NotifyOnSuccess.value)
+	} else {
+		notification.Events = append(notification.Events, NotifyOnFailure.value)
+	}
+
+	if len(notification.Updates) > 0 {
+		notification.Events = append(notification.Events, NotifyOnUpdate.value)
+	}
+
+	return notification
+}
+
+// matches reports whether the notifier is notified of a run with the given events.
+func (notifier *Notifier) matches(events []string) bool {
+	if len(notifier.events) == 0 {
+		return true
+	}
+
+	for _, event := range notifier.events {
+		if slices.Contains(events, event.value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// payload returns the request body of the notification in the notifier format.
+func (notifier *Notifier) payload(notification RunNotification) any {
+	switch notifier.format {
+	case NotifySlack:
+		return map[string]string{"text": notificationText(notification, "*%s*", "`%s`", "\n")}
+	case NotifyTeams:
+		return map[string]any{
+			"type": "message",
+			"attachments": []map[string]any{{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]any{
+					"type":    "AdaptiveCard",
+					"version": "1.4",
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"body": []map[string]any{{
+						"type": "TextBlock",
+						"wrap": true,
+						"text": notificationText(notification, "**%s**", "`%s`", "\n\n"),
+					}},
+				},
+			}},
+		}
+	default:
+		return notification
+	}
+}
+
+// notificationText renders the notification as a chat message, with the bold and code markup and the line
+// separator of the chat. Updates are listed by the report summary.
+func notificationText(notification RunNotification, bold, code, separator string) string {
+	outcome := "succeeded"
+	if !notification.Succeeded {
+		outcome = "failed"
+	}
+
+	lines := []string{
+		fmt.Sprintf(bold, fmt.Sprintf("Quark plan %s %s", fmt.Sprintf(code, notification.Plan), outcome)) +
+			fmt.Sprintf(" (took %s)", notification.Duration),
+	}
+
+	for _, line := range notification.Summary {
+		lines = append(lines, "• "+line)
+	}
+
+	for _, failure := range notification.Failures {
+		lines = append(lines, fmt.Sprintf("❌ %s (%s): %s", fmt.Sprintf(code, failure.Operation), failure.Kind,
+			failure.Error))
+	}
+
+	if len(notification.Failures) == 0 && notification.Error != "" {
+		lines = append(lines, "❌ "+notification.Error)
+	}
+
+	return strings.Join(lines, separator)
+}
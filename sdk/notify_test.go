@@ -0,0 +1,106 @@
+package sdk_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/farcloser/quark/internal/webhook"
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: At the end of a failed run, the endpoints notified of failures receive the failed operations and
+// their error (signed JSON, or a chat message), while endpoints filtering on other outcomes stay silent.
+func TestPlan_Notify(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		received = make(map[string][]byte)
+	)
+
+	endpoint := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		body, _ := io.ReadAll(req.Body)
+		signature := webhook.Sign("s3cret", req.Header.Get(webhook.TimestampHeader), body)
+		received[req.URL.Path] = body
+
+		if req.URL.Path == "/json" && req.Header.Get(webhook.SignatureHeader) != signature {
+			writer.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(endpoint.Close)
+
+	plan := sdk.NewPlan(testPlanName)
+
+	notifiers := []*sdk.NotifyBuilder{
+		plan.Notify(endpoint.URL + "/json").Secret("s3cret").Events(sdk.NotifyOnFailure),
+		plan.Notify(endpoint.URL + "/slack").Format(sdk.NotifySlack),
+		plan.Notify(endpoint.URL+"/updates").Events(sdk.NotifyOnUpdate, sdk.NotifyOnSuccess),
+	}
+
+	for _, builder := range notifiers {
+		if _, err := builder.Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+	}
+
+	if _, err := plan.Notify("hooks.slack.com/services/x").Build(); !errors.Is(err, sdk.ErrInvalidWebhook) {
+		t.Errorf("Build() without scheme error = %v, want %v", err, sdk.ErrInvalidWebhook)
+	}
+
+	dest, err := sdk.NewImage("my-org/app-sbom").Domain("ghcr.io").Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	// Executing an artifact fails before any network access: the file does not exist
+	if _, err := plan.Artifact("publish").
+		Destination(dest).
+		ArtifactType("application/vnd.example.sbom").
+		File(filepath.Join(t.TempDir(), "missing.json"), "application/json").
+		Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if err := plan.Execute(t.Context()); err == nil {
+		t.Fatal("Execute() succeeded, want artifact failure")
+	}
+
+	if warnings := plan.Report().Warnings; len(warnings) > 0 {
+		t.Errorf("Warnings = %v, want delivered notifications", warnings)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := received["/updates"]; ok {
+		t.Error("endpoint notified of updates and successes received a failed run")
+	}
+
+	var notification sdk.RunNotification
+	if err := json.Unmarshal(received["/json"], &notification); err != nil {
+		t.Fatalf("JSON notification = %q: %v", received["/json"], err)
+	}
+
+	if notification.Succeeded || len(notification.Failures) != 1 || notification.Failures[0].Operation != "publish" ||
+		!strings.Contains(notification.Failures[0].Error, "missing.json") {
+		t.Errorf("JSON notification = %+v, want the failed artifact", notification)
+	}
+
+	var message struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(received["/slack"], &message); err != nil ||
+		!strings.Contains(message.Text, "failed") || !strings.Contains(message.Text, "`publish`") {
+		t.Errorf("Slack message = %q, want the failed run and operation", received["/slack"])
+	}
+}
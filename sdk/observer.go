@@ -0,0 +1,112 @@
+package sdk
+
+import (
+	"time"
+)
+
+// OperationEvent describes an operation to plan observers.
+type OperationEvent struct {
+	Name string
+	// Kind is the operation type, as shown in reports (e.g., "sync", "version-check").
+	Kind    string
+	Started time.Time
+	// Duration is how long the operation ran (zero on start).
+	Duration time.Duration
+	// Err is the failure (nil unless the operation failed).
+	Err error
+}
+
+// PlanObserver is notified as the operations of a plan run, to emit custom metrics or notifications.
+// Calls are serialized, also when operations run in parallel: implementations need no locking, but should
+// return quickly. Operations skipped by RunOnlyOn, not reached after a failure, or checked by a dry run are
+// not notified.
+type PlanObserver interface {
+	// OnStart is called before the operation executes (and before its destructive change confirmation).
+	OnStart(event OperationEvent)
+	// OnSuccess is called once the operation succeeded.
+	OnSuccess(event OperationEvent)
+	// OnFailure is called once the operation failed, after its last attempt when it is retried.
+	OnFailure(event OperationEvent)
+}
+
+// ObserverFuncs is a PlanObserver calling the functions that are set.
+type ObserverFuncs struct {
+	Start   func(event OperationEvent)
+	Success func(event OperationEvent)
+	Failure func(event OperationEvent)
+}
+
+// OnStart implements PlanObserver.
+func (funcs ObserverFuncs) OnStart(event OperationEvent) {
+	if funcs.Start != nil {
+		funcs.Start(event)
+	}
+}
+
+// OnSuccess implements PlanObserver.
+func (funcs ObserverFuncs) OnSuccess(event OperationEvent) {
+	if funcs.Success != nil {
+		funcs.Success(event)
+	}
+}
+
+// OnFailure implements PlanObserver.
+func (funcs ObserverFuncs) OnFailure(event OperationEvent) {
+	if funcs.Failure != nil {
+		funcs.Failure(event)
+	}
+}
+
+// Observe registers observers notified of every operation execution, in registration order.
+func (plan *Plan) Observe(observers ...PlanObserver) {
+	plan.observers = append(plan.observers, observers...)
+}
+
+// notifyStart notifies the observers and event sinks that op started.
+func (plan *Plan) notifyStart(op operation, started time.Time) {
+	plan.emit(OperationStarted{Operation: op.operationName(), Kind: op.kind(), Started: started})
+
+	plan.notify(func(observer PlanObserver, event OperationEvent) {
+		observer.OnStart(event)
+	}, OperationEvent{Name: op.operationName(), Kind: op.kind(), Started: started})
+}
+
+// notifyDone notifies the observers and event sinks that op succeeded, or failed with err.
+func (plan *Plan) notifyDone(op operation, started time.Time, duration time.Duration, err error) {
+	plan.emit(OperationFinished{
+		Operation: op.operationName(),
+		Kind:      op.kind(),
+		Duration:  duration,
+		Err:       err,
+	})
+
+	event := OperationEvent{
+		Name:     op.operationName(),
+		Kind:     op.kind(),
+		Started:  started,
+		Duration: duration,
+		Err:      err,
+	}
+
+	plan.notify(func(observer PlanObserver, event OperationEvent) {
+		if event.Err != nil {
+			observer.OnFailure(event)
+		} else {
+			observer.OnSuccess(event)
+		}
+	}, event)
+}
+
+// notify calls each observer in turn, one event at a time.
+func (plan *Plan) notify(call func(observer PlanObserver, event OperationEvent), event OperationEvent) {
+	if len(plan.observers) == 0 {
+		return
+	}
+
+	plan.observerMutex.Lock()
+	defer plan.observerMutex.Unlock()
+
+	for _, observer := range plan.observers {
+		call(observer, event)
+	}
+}
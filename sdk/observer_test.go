@@ -0,0 +1,103 @@
+package sdk_test
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: Observers are notified when operations start, succeed and fail, with their kind, duration and
+// error; operations not reached after a failure are not notified.
+func TestPlan_Observe(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+	digest := pushRandomImage(t, host+"/source/app:1.0.0")
+
+	plan := sdk.NewPlan(testPlanName)
+
+	source, err := sdk.NewImage("source/app").Domain(host).Version("1.0.0").Digest(digest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create source image: %v", err)
+	}
+
+	destination, err := sdk.NewImage("mirror/app").Domain(host).Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create destination image: %v", err)
+	}
+
+	if _, err := plan.Sync("mirror").Source(source).Destination(destination).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	// Publishing fails: the file does not exist
+	sbom, err := sdk.NewImage("mirror/app-sbom").Domain(host).Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create artifact image: %v", err)
+	}
+
+	if _, err := plan.Artifact("publish").
+		Destination(sbom).
+		ArtifactType("application/vnd.example.sbom").
+		File(filepath.Join(t.TempDir(), "missing.json"), "application/json").
+		Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if _, err := newTestSync(t, plan, "after").Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	var events []string
+
+	plan.Observe(sdk.ObserverFuncs{
+		Start: func(event sdk.OperationEvent) {
+			events = append(events, "start "+event.Kind+" "+event.Name)
+		},
+		Success: func(event sdk.OperationEvent) {
+			if event.Duration <= 0 || event.Started.IsZero() {
+				t.Errorf("success of %s without start or duration", event.Name)
+			}
+
+			events = append(events, "success "+event.Name)
+		},
+		Failure: func(event sdk.OperationEvent) {
+			if event.Err == nil {
+				t.Errorf("failure of %s without error", event.Name)
+			}
+
+			events = append(events, "failure "+event.Name)
+		},
+	})
+
+	if err := plan.Execute(t.Context()); err == nil {
+		t.Fatal("Execute() should fail")
+	}
+
+	want := []string{"start sync mirror", "success mirror", "start artifact publish", "failure publish"}
+
+	if len(events) != len(want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+
+	for idx := range want {
+		if events[idx] != want[idx] {
+			t.Errorf("events[%d] = %q, want %q", idx, events[idx], want[idx])
+		}
+	}
+}
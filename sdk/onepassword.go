@@ -4,8 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os/exec"
 	"strings"
+
+	"github.com/farcloser/quark/internal/subprocess"
 )
 
 const (
@@ -13,9 +14,13 @@ const (
 	opCLI = "op"
 )
 
+// opConcealed is the type of 1Password fields holding secrets (passwords, tokens, credentials).
+const opConcealed = "CONCEALED"
+
 // opField represents a field in a 1Password item.
 type opField struct {
 	Label string `json:"label"`
+	Type  string `json:"type"`
 	Value string `json:"value"`
 }
 
@@ -31,10 +36,18 @@ type opItemResponse struct {
 // Uses `op signin` which is idempotent - it only prompts for authentication
 // if not already authenticated. Requires 1Password desktop app integration.
 func AuthenticateOp(ctx context.Context) error {
-	cmd := exec.CommandContext(ctx, opCLI, "signin")
+	cmd := subprocess.Command(ctx, opCLI, "signin")
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	subprocess.Echo(ctx, cmd)
 
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to authenticate with 1Password: %w (check 1Password authentication)", err)
+		err = fmt.Errorf("failed to authenticate with 1Password: %w (check 1Password authentication)",
+			subprocess.Error(ctx, err, stderr.String()))
+
+		return secretValues.Error(err)
 	}
 
 	return nil
@@ -79,11 +92,13 @@ func GetSecretDocument(ctx context.Context, reference string) ([]byte, error) {
 
 	// Use op document get for retrieving document content
 	//nolint:gosec // G204: Variables are from parsed/validated reference, passed as separate args (no shell injection)
-	cmd := exec.CommandContext(ctx, opCLI, "document", "get", item, "--vault", vault)
+	cmd := subprocess.Command(ctx, opCLI, "document", "get", item, "--vault", vault)
+	subprocess.Echo(ctx, cmd)
 
-	output, err := cmd.CombinedOutput()
+	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get document: %w (check 1Password authentication)", err)
+		return nil, secretValues.Error(fmt.Errorf("failed to get document: %w (check 1Password authentication)",
+			subprocess.Error(ctx, err, "")))
 	}
 
 	if len(output) == 0 {
@@ -111,7 +126,8 @@ func GetSecretDocument(ctx context.Context, reference string) ([]byte, error) {
 // - Desktop app integration with biometric authentication
 // - Vault names with spaces and special characters (e.g., parentheses)
 //
-// Returns a map of field names to their string values.
+// Returns a map of field names to their string values. Concealed fields (passwords, tokens) are registered as
+// secrets, masked in logs and errors (see RegisterSecret).
 // Returns an error if any requested field is not found in the item.
 // Requires the `op` CLI to be installed and authenticated.
 func GetSecret(ctx context.Context, itemRef string, fields []string) (map[string]string, error) {
@@ -142,11 +158,14 @@ func GetSecret(ctx context.Context, itemRef string, fields []string) (map[string
 
 	// Get the entire item as JSON
 	//nolint:gosec // G204: Variables are from parsed/validated reference, passed as separate args (no shell injection)
-	cmd := exec.CommandContext(ctx, opCLI, "item", "get", item, "--vault", vault, "--format", "json")
+	cmd := subprocess.Command(ctx, opCLI, "item", "get", item, "--vault", vault, "--format", "json")
+	subprocess.Echo(ctx, cmd)
 
-	output, err := cmd.CombinedOutput()
+	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get item: %w (check 1Password authentication)", err)
+		// The output of op may hold secrets read earlier
+		return nil, secretValues.Error(fmt.Errorf("failed to get item: %w (check 1Password authentication)",
+			subprocess.Error(ctx, err, "")))
 	}
 
 	// Parse JSON response
@@ -158,8 +177,11 @@ func GetSecret(ctx context.Context, itemRef string, fields []string) (map[string
 
 	// Build field map
 	fieldMap := make(map[string]string)
+	concealed := make(map[string]bool)
+
 	for _, field := range itemData.Fields {
 		fieldMap[field.Label] = field.Value
+		concealed[field.Label] = field.Type == opConcealed
 	}
 
 	// Extract requested fields
@@ -172,6 +194,11 @@ func GetSecret(ctx context.Context, itemRef string, fields []string) (map[string
 		}
 
 		result[fieldName] = value
+
+		// Concealed fields are masked in logs and errors; usernames or domains stay readable
+		if concealed[fieldName] {
+			secretValues.Add(value)
+		}
 	}
 
 	return result, nil
@@ -0,0 +1,52 @@
+package sdk
+
+import (
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// operationCore holds the name, kind and logger of an operation.
+// Operations embed it; plan constructors fill it.
+type operationCore struct {
+	opName string
+	opKind string
+	log    zerolog.Logger
+}
+
+// newOperationCore returns the core of an operation of kind (e.g., "version-check") named name, logging with
+// its name under its kind ("version_check").
+func (plan *Plan) newOperationCore(kind, name string) operationCore {
+	return operationCore{
+		opName: name,
+		opKind: kind,
+		log:    plan.log.With().Str(strings.ReplaceAll(kind, "-", "_"), name).Logger(),
+	}
+}
+
+// named returns the core with another operation name (e.g., "maintenance-<node>"), keeping its logger.
+func (core operationCore) named(name string) operationCore {
+	core.opName = name
+
+	return core
+}
+
+// operationName returns the operation name.
+func (core *operationCore) operationName() string {
+	return core.opName
+}
+
+// setName renames the operation (e.g., "<plan>/<name>" once included in another plan).
+func (core *operationCore) setName(name string) {
+	core.opName = name
+}
+
+// kind returns the operation type name shown in reports (e.g., "sync").
+func (core *operationCore) kind() string {
+	return core.opKind
+}
+
+// logger returns the operation logger, for the executor to redirect (see Plan.LogDir).
+func (core *operationCore) logger() *zerolog.Logger {
+	return &core.log
+}
@@ -0,0 +1,148 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// Orchestrator executes several plans concurrently in one process, each in its own namespace
+// (e.g., a service running quark pipelines for many teams).
+//
+// Plans are isolated from each other: each logs with its namespace, resolves credentials from its own
+// registries, and opens its own SSH connections, manifest cache and traffic meter when executed.
+// Process-wide settings the CLI passes through the environment (QUARK_YES, QUARK_REPORT, QUARK_REPORT_FORMAT,
+// QUARK_PR_COMMENT) do not apply to orchestrated plans: configure each plan instead.
+type Orchestrator struct {
+	log         zerolog.Logger
+	concurrency int
+	limiter     *resourceLimiter
+
+	mutex   sync.Mutex
+	plans   []*orchestratedPlan
+	results []PlanResult
+}
+
+// orchestratedPlan is a plan registered in an orchestrator.
+type orchestratedPlan struct {
+	namespace string
+	plan      *Plan
+}
+
+// PlanResult is the outcome of an orchestrated plan execution.
+type PlanResult struct {
+	Namespace string
+	Plan      string
+	// Err is the execution error (nil if the plan succeeded).
+	Err error
+	// Report is the execution report of the plan.
+	Report *Report
+}
+
+// NewOrchestrator creates an orchestrator whose plans log through logger.
+func NewOrchestrator(logger zerolog.Logger) *Orchestrator {
+	return &Orchestrator{log: logger, limiter: newResourceLimiter()}
+}
+
+// Concurrency limits how many plans execute at the same time (default: 0, all plans at once).
+func (orchestrator *Orchestrator) Concurrency(limit int) {
+	orchestrator.concurrency = limit
+}
+
+// ResourceLimit bounds how many operations of class resource run at the same time across all plans
+// (default: 0, unbounded). Plans waiting for a class do not hold back the others: e.g., limiting
+// sdk.ResourceNetwork lets scans of some plans run while syncs of other plans wait for the network.
+// Operations declare their class with the Resource builder methods, or use the default of their kind.
+func (orchestrator *Orchestrator) ResourceLimit(resource Resource, limit int) {
+	orchestrator.limiter.setLimit(resource, limit)
+}
+
+// NewPlan creates a plan in namespace (e.g., a team name); each namespace has at most one plan.
+func (orchestrator *Orchestrator) NewPlan(namespace, name string) (*Plan, error) {
+	if namespace == "" {
+		return nil, ErrNamespaceRequired
+	}
+
+	orchestrator.mutex.Lock()
+	defer orchestrator.mutex.Unlock()
+
+	for _, existing := range orchestrator.plans {
+		if existing.namespace == namespace {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateNamespace, namespace)
+		}
+	}
+
+	plan := newPlan(name, orchestrator.log.With().Str("namespace", namespace).Logger())
+	plan.isolated = true
+	plan.limiter = orchestrator.limiter
+
+	orchestrator.plans = append(orchestrator.plans, &orchestratedPlan{namespace: namespace, plan: plan})
+
+	return plan, nil
+}
+
+// Execute runs all plans concurrently and waits for them to complete.
+// A failing plan does not stop the others: the returned error joins the failures of all plans,
+// and Results reports the outcome of each.
+func (orchestrator *Orchestrator) Execute(ctx context.Context) error {
+	orchestrator.mutex.Lock()
+	plans := orchestrator.plans
+	orchestrator.mutex.Unlock()
+
+	limit := orchestrator.concurrency
+	if limit <= 0 || limit > len(plans) {
+		limit = len(plans)
+	}
+
+	results := make([]PlanResult, len(plans))
+	slots := make(chan struct{}, max(limit, 1))
+
+	var group sync.WaitGroup
+
+	for idx, entry := range plans {
+		group.Add(1)
+
+		go func() {
+			defer group.Done()
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			err := entry.plan.Execute(ctx)
+
+			results[idx] = PlanResult{
+				Namespace: entry.namespace,
+				Plan:      entry.plan.name,
+				Err:       err,
+				Report:    entry.plan.Report(),
+			}
+		}()
+	}
+
+	group.Wait()
+
+	orchestrator.mutex.Lock()
+	orchestrator.results = results
+	orchestrator.mutex.Unlock()
+
+	var errs []error
+
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("%w: namespace %q: %w", ErrPlanFailed, result.Namespace, result.Err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Results returns the outcome of each plan of the last execution, in the order plans were created.
+func (orchestrator *Orchestrator) Results() []PlanResult {
+	orchestrator.mutex.Lock()
+	defer orchestrator.mutex.Unlock()
+
+	return orchestrator.results
+}
@@ -0,0 +1,144 @@
+package sdk_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: Plans of different namespaces execute independently: a failing plan does not stop the others,
+// each plan gets its own result and report, and the returned error names the failing namespace.
+func TestOrchestrator_Execute(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+	digest := pushRandomImage(t, host+"/team-a/app:1.0.0")
+
+	orchestrator := sdk.NewOrchestrator(zerolog.Nop())
+	orchestrator.Concurrency(1)
+
+	teams := map[string]string{
+		"team-a": digest,
+		// Not pushed: the sync of team-b fails
+		"team-b": testDigest,
+	}
+
+	for _, namespace := range []string{"team-a", "team-b"} {
+		plan, err := orchestrator.NewPlan(namespace, "mirror")
+		if err != nil {
+			t.Fatalf("NewPlan(%q) error = %v", namespace, err)
+		}
+
+		source, err := sdk.NewImage("team-a/app").Domain(host).Version("1.0.0").Digest(teams[namespace]).Build()
+		if err != nil {
+			t.Fatalf("Failed to create source image: %v", err)
+		}
+
+		destination, err := sdk.NewImage(namespace + "/mirror").Domain(host).Version("1.0.0").Build()
+		if err != nil {
+			t.Fatalf("Failed to create destination image: %v", err)
+		}
+
+		if _, err := plan.Sync("mirror").Source(source).Destination(destination).Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+	}
+
+	if _, err := orchestrator.NewPlan("team-a", "other"); !errors.Is(err, sdk.ErrDuplicateNamespace) {
+		t.Errorf("NewPlan() for an existing namespace error = %v, want %v", err, sdk.ErrDuplicateNamespace)
+	}
+
+	err = orchestrator.Execute(context.Background())
+	if !errors.Is(err, sdk.ErrPlanFailed) {
+		t.Fatalf("Execute() error = %v, want %v", err, sdk.ErrPlanFailed)
+	}
+
+	results := orchestrator.Results()
+	if len(results) != 2 {
+		t.Fatalf("Results() = %d results, want 2", len(results))
+	}
+
+	if results[0].Namespace != "team-a" || results[0].Err != nil || !results[0].Report.Succeeded() {
+		t.Errorf("team-a result = %+v, want success", results[0])
+	}
+
+	if results[1].Namespace != "team-b" || results[1].Err == nil || results[1].Report.Succeeded() {
+		t.Errorf("team-b result = %+v, want failure", results[1])
+	}
+}
+
+// INTENTION: Resource limits are shared by the plans of an orchestrator: plans queue for the resource class
+// of their operations, whether the default of their kind or declared, and all complete.
+func TestOrchestrator_ResourceLimit(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+	digest := pushRandomImage(t, host+"/shared/app:1.0.0")
+
+	orchestrator := sdk.NewOrchestrator(zerolog.Nop())
+	orchestrator.ResourceLimit(sdk.ResourceNetwork, 1)
+	orchestrator.ResourceLimit(sdk.ResourceCPU, 1)
+
+	namespaces := []string{"team-a", "team-b", "team-c"}
+
+	for idx, namespace := range namespaces {
+		plan, err := orchestrator.NewPlan(namespace, "mirror")
+		if err != nil {
+			t.Fatalf("NewPlan(%q) error = %v", namespace, err)
+		}
+
+		source, err := sdk.NewImage("shared/app").Domain(host).Version("1.0.0").Digest(digest).Build()
+		if err != nil {
+			t.Fatalf("Failed to create source image: %v", err)
+		}
+
+		destination, err := sdk.NewImage(namespace + "/mirror").Domain(host).Version("1.0.0").Build()
+		if err != nil {
+			t.Fatalf("Failed to create destination image: %v", err)
+		}
+
+		builder := plan.Sync("mirror").Source(source).Destination(destination)
+		if idx == 0 {
+			builder.Resource(sdk.ResourceCPU)
+		}
+
+		if _, err := builder.Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+	}
+
+	if err := orchestrator.Execute(context.Background()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	for _, result := range orchestrator.Results() {
+		if !result.Report.Succeeded() {
+			t.Errorf("%s result = %+v, want success", result.Namespace, result)
+		}
+	}
+}
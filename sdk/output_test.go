@@ -0,0 +1,140 @@
+package sdk_test
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: An operation reading the output image of another one needs neither a digest nor DependsOn: it
+// waits for the producer, even with parallelism, and reads the digest the producer pushed.
+func TestSync_OutputImage(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+	digest := pushRandomImage(t, host+"/source/app:1.0.0")
+
+	plan := sdk.NewPlan("output")
+	plan.MaxParallelism(4)
+
+	source, err := sdk.NewImage("source/app").Domain(host).Version("1.0.0").Digest(digest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create source image: %v", err)
+	}
+
+	staging, err := sdk.NewImage("staging/app").Domain(host).Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create staging image: %v", err)
+	}
+
+	release, err := sdk.NewImage("release/app").Domain(host).Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create release image: %v", err)
+	}
+
+	stage, err := plan.Sync("stage").Source(source).Destination(staging).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	// Declared before the sync it reads from runs: no digest yet
+	promote, err := plan.Sync("promote").Source(stage.OutputImage()).Destination(release).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v, want the output image accepted without digest", err)
+	}
+
+	if err := plan.Execute(t.Context()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if promote.DestDigest() != digest || stage.DestDigest() != digest {
+		t.Errorf("DestDigest() = %q and %q, want %q", stage.DestDigest(), promote.DestDigest(), digest)
+	}
+}
+
+// INTENTION: Bundles and exports take output images like syncs: they are accepted without digest when built, and
+// deliver the digest the producer pushed.
+func TestBundleAndExport_OutputImage(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+	digest := pushRandomImage(t, host+"/source/app:1.0.0")
+
+	plan := sdk.NewPlan("output")
+	plan.MaxParallelism(4)
+
+	source, err := sdk.NewImage("source/app").Domain(host).Version("1.0.0").Digest(digest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create source image: %v", err)
+	}
+
+	staging, err := sdk.NewImage("staging/app").Domain(host).Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create staging image: %v", err)
+	}
+
+	stage, err := plan.Sync("stage").Source(source).Destination(staging).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	deliveries := t.TempDir()
+
+	bundle, err := plan.Bundle("delivery").
+		Image(stage.OutputImage()).
+		Destination(sdk.NewDirectoryTransport(deliveries)).
+		Build()
+	if err != nil {
+		t.Fatalf("Bundle Build() error = %v, want the output image accepted without digest", err)
+	}
+
+	export, err := plan.Export("export").
+		Source(stage.OutputImage()).
+		To(sdk.NewDirectoryTransport(t.TempDir())).
+		Build()
+	if err != nil {
+		t.Fatalf("Export Build() error = %v, want the output image accepted without digest", err)
+	}
+
+	if err := plan.Execute(t.Context()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if export.Digest() != digest {
+		t.Errorf("export Digest() = %q, want %q", export.Digest(), digest)
+	}
+
+	data, err := os.ReadFile(filepath.Join(deliveries, "delivery.json"))
+	if err != nil {
+		t.Fatalf("Failed to read bundle manifest: %v", err)
+	}
+
+	if bundle.ArchiveDigest() == "" || !strings.Contains(string(data), digest) {
+		t.Errorf("bundle manifest = %s, want %s delivered", data, digest)
+	}
+}
@@ -0,0 +1,290 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/internal/dockerfile"
+	"github.com/farcloser/quark/internal/reference"
+)
+
+// PinBaseImages represents pinning the base images of a Dockerfile: each FROM line is resolved to
+// the current digest of its tag and rewritten as name:tag@sha256:..., for reproducible builds.
+type PinBaseImages struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	dockerfile string
+	write      bool
+	patch      string
+
+	// Populated by Build()
+	bases      []dockerfile.BaseImage
+	registries map[string]*Registry
+
+	// Results populated after execution
+	diff   string
+	pinned int
+}
+
+// PinBaseImagesBuilder builds a PinBaseImages operation.
+type PinBaseImagesBuilder struct {
+	builderState
+
+	plan *Plan
+	pin  *PinBaseImages
+}
+
+// Dockerfile sets the Dockerfile whose FROM lines are pinned.
+func (builder *PinBaseImagesBuilder) Dockerfile(path string) *PinBaseImagesBuilder {
+	builder.pin.dockerfile = path
+
+	return builder
+}
+
+// Write rewrites the Dockerfile in place (default: the changes are only reported).
+func (builder *PinBaseImagesBuilder) Write(enabled bool) *PinBaseImagesBuilder {
+	builder.pin.write = enabled
+
+	return builder
+}
+
+// Patch writes the changes as a unified diff to path, to be reviewed or applied with git apply.
+func (builder *PinBaseImagesBuilder) Patch(path string) *PinBaseImagesBuilder {
+	builder.pin.patch = path
+
+	return builder
+}
+
+// RunOnlyOn restricts the operation to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *PinBaseImagesBuilder) RunOnlyOn(envs ...Environment) *PinBaseImagesBuilder {
+	builder.pin.runOnlyOn = append(builder.pin.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the operation mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *PinBaseImagesBuilder) Resource(resource Resource) *PinBaseImagesBuilder {
+	builder.pin.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the operation start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *PinBaseImagesBuilder) DependsOn(ops ...Dependency) *PinBaseImagesBuilder {
+	builder.pin.add(ops)
+
+	return builder
+}
+
+// When makes the operation run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the operation.
+func (builder *PinBaseImagesBuilder) When(conditions ...Condition) *PinBaseImagesBuilder {
+	builder.pin.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a base image pinning named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *PinBaseImagesBuilder) Clone(name string) *PinBaseImagesBuilder {
+	clone := builder.plan.PinBaseImages(name)
+	clone.pin.envGuard = builder.pin.envGuard.clone()
+	clone.pin.resourceHint = builder.pin.resourceHint
+	clone.pin.dependencyList = builder.pin.dependencyList.clone()
+	clone.pin.conditionList = builder.pin.conditionList.clone()
+	clone.pin.dockerfile = builder.pin.dockerfile
+	clone.pin.write = builder.pin.write
+	clone.pin.patch = builder.pin.patch
+
+	return clone
+}
+
+// Reset makes the builder usable again for a base image pinning named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *PinBaseImagesBuilder) Reset(name string) *PinBaseImagesBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build parses the Dockerfile and adds the operation to the plan.
+// Registry credentials are looked up from the plan's registry collection using each base image domain.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *PinBaseImagesBuilder) Build() (*PinBaseImages, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.pin.opName); err != nil {
+		return nil, err
+	}
+
+	pin := builder.pin
+
+	if pin.dockerfile == "" {
+		return nil, ErrPinBaseImagesDockerfileRequired
+	}
+
+	bases, err := dockerfile.ParseFile(pin.dockerfile)
+	if err != nil {
+		return nil, err
+	}
+
+	pin.bases = bases
+	pin.registries = make(map[string]*Registry)
+
+	for _, base := range bases {
+		if len(base.Unresolved) > 0 {
+			continue
+		}
+
+		ref, err := reference.Parse(base.Reference)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base image %q in %s line %d: %w", base.Reference, pin.dockerfile, base.Line, err)
+		}
+
+		if _, ok := pin.registries[ref.Domain]; !ok {
+			pin.registries[ref.Domain] = builder.plan.getRegistry(ref.Domain)
+		}
+	}
+
+	builder.plan.addOperation(pin)
+
+	return pin, nil
+}
+
+func (pin *PinBaseImages) execute(ctx context.Context) error {
+	var replacements []dockerfile.Replacement
+
+	for _, base := range pin.bases {
+		if len(base.Unresolved) > 0 {
+			pin.log.Warn().
+				Int("line", base.Line).
+				Str("image", base.Raw).
+				Strs("unresolved_args", base.Unresolved).
+				Msg("cannot pin base image with unresolved build arguments")
+
+			continue
+		}
+
+		replacement, err := pin.resolve(ctx, base)
+		if err != nil {
+			return err
+		}
+
+		if replacement.New != replacement.Old {
+			replacements = append(replacements, replacement)
+		}
+	}
+
+	pin.pinned = len(replacements)
+
+	if len(replacements) == 0 {
+		pin.log.Info().Str("dockerfile", pin.dockerfile).Msg("base images already pinned to current digests")
+
+		return nil
+	}
+
+	// #nosec G304 -- Dockerfile path provided by the plan author
+	content, err := os.ReadFile(pin.dockerfile)
+	if err != nil {
+		return fmt.Errorf("failed to read Dockerfile: %w", err)
+	}
+
+	rewritten, err := dockerfile.Rewrite(string(content), replacements)
+	if err != nil {
+		return fmt.Errorf("failed to pin %s: %w", pin.dockerfile, err)
+	}
+
+	pin.diff = dockerfile.Diff(pin.dockerfile, string(content), rewritten)
+
+	if pin.patch != "" {
+		if err := os.WriteFile(pin.patch, []byte(pin.diff), filesystem.FilePermissionsDefault); err != nil {
+			return fmt.Errorf("failed to write patch: %w", err)
+		}
+
+		pin.log.Info().Str("patch", pin.patch).Msg("base image pins written as patch")
+	}
+
+	if !pin.write {
+		pin.log.Info().
+			Str("dockerfile", pin.dockerfile).
+			Int("pins", pin.pinned).
+			Str("diff", pin.diff).
+			Msg("base images not pinned to current digests (enable Write to rewrite the Dockerfile)")
+
+		return nil
+	}
+
+	info, err := os.Stat(pin.dockerfile)
+	if err != nil {
+		return fmt.Errorf("failed to read Dockerfile: %w", err)
+	}
+
+	if err := os.WriteFile(pin.dockerfile, []byte(rewritten), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write Dockerfile: %w", err)
+	}
+
+	pin.log.Info().Str("dockerfile", pin.dockerfile).Int("pins", pin.pinned).Msg("base images pinned")
+
+	return nil
+}
+
+// resolve returns the replacement pinning a base image to the current digest of its tag.
+func (pin *PinBaseImages) resolve(ctx context.Context, base dockerfile.BaseImage) (dockerfile.Replacement, error) {
+	ref, err := reference.Parse(base.Reference)
+	if err != nil {
+		return dockerfile.Replacement{}, fmt.Errorf("invalid base image %q: %w", base.Reference, err)
+	}
+
+	// Digest-only references are already immutable: there is no tag to resolve
+	if ref.ExplicitTag == "" && ref.Digest != "" {
+		return dockerfile.Replacement{Line: base.Line, Old: base.Raw, New: base.Raw}, nil
+	}
+
+	tag := ref.ExplicitTag
+	if tag == "" {
+		tag = "latest"
+	}
+
+	client := newRegistryClient(pin.registries[ref.Domain], pin.log)
+
+	digest, err := client.GetDigest(ctx, ref.Name()+":"+tag)
+	if err != nil {
+		return dockerfile.Replacement{}, fmt.Errorf("failed to resolve base image %q: %w", base.Reference, err)
+	}
+
+	pinned := dockerfile.PinnedReference(base.Raw, digest, ref.ExplicitTag != "")
+
+	if current := ref.Digest.String(); current != "" && current != digest {
+		pin.log.Warn().
+			Str("image", base.Reference).
+			Str("pinned_digest", current).
+			Str("current_digest", digest).
+			Msg("base image tag moved since it was pinned")
+	}
+
+	pin.log.Debug().Int("line", base.Line).Str("image", pinned).Msg("base image resolved")
+
+	return dockerfile.Replacement{Line: base.Line, Old: base.Raw, New: pinned}, nil
+}
+
+// Diff returns the unified diff of the pinned Dockerfile (empty if nothing changed).
+func (pin *PinBaseImages) Diff() string {
+	return pin.diff
+}
+
+// Pinned returns the number of FROM lines pinned (or to pin, when Write is disabled).
+func (pin *PinBaseImages) Pinned() int {
+	return pin.pinned
+}
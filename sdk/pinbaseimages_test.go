@@ -0,0 +1,118 @@
+package sdk_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: FROM lines are rewritten as name:tag@digest with the current digest of their tag, the patch
+// describes the same change, and without Write the Dockerfile is left untouched.
+func TestPinBaseImages(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+	digest := pushRandomImage(t, host+"/my-org/base:1.0")
+
+	content := "ARG BASE_VERSION=1.0\nFROM " + host + "/my-org/base:${BASE_VERSION} AS build\nFROM scratch\n"
+	pinned := "ARG BASE_VERSION=1.0\nFROM " + host + "/my-org/base:${BASE_VERSION}@" + digest + " AS build\nFROM scratch\n"
+
+	tests := []struct {
+		name  string
+		write bool
+		want  string
+	}{
+		{name: "report only", write: false, want: content},
+		{name: "write", write: true, want: pinned},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			path := filepath.Join(dir, "Dockerfile")
+			patch := filepath.Join(dir, "pins.patch")
+
+			if err := os.WriteFile(path, []byte(content), filesystem.FilePermissionsDefault); err != nil {
+				t.Fatalf("Failed to write Dockerfile: %v", err)
+			}
+
+			plan := sdk.NewPlan(testPlanName)
+
+			pin, err := plan.PinBaseImages("pin").Dockerfile(path).Write(test.write).Patch(patch).Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			if err := plan.Execute(context.Background()); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			written, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read Dockerfile: %v", err)
+			}
+
+			if string(written) != test.want {
+				t.Errorf("Dockerfile =\n%s\nwant\n%s", written, test.want)
+			}
+
+			diff, err := os.ReadFile(patch)
+			if err != nil {
+				t.Fatalf("Failed to read patch: %v", err)
+			}
+
+			wantLine := "+FROM " + host + "/my-org/base:${BASE_VERSION}@" + digest
+			if pin.Pinned() != 1 || string(diff) != pin.Diff() || !strings.Contains(pin.Diff(), wantLine) {
+				t.Errorf("Pinned() = %d, Diff() =\n%s\npatch =\n%s", pin.Pinned(), pin.Diff(), diff)
+			}
+
+			// Pinning again is a no-op once written
+			if test.write {
+				plan := sdk.NewPlan(testPlanName)
+
+				again, err := plan.PinBaseImages("pin").Dockerfile(path).Write(true).Build()
+				if err != nil {
+					t.Fatalf("Build() error = %v", err)
+				}
+
+				if err := plan.Execute(context.Background()); err != nil || again.Pinned() != 0 {
+					t.Errorf("second Execute() error = %v, Pinned() = %d, want nil and 0", err, again.Pinned())
+				}
+			}
+		})
+	}
+}
+
+// INTENTION: A Dockerfile is required to pin base images.
+func TestPinBaseImagesBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	if _, err := sdk.NewPlan(testPlanName).PinBaseImages("pin").Build(); !errors.Is(
+		err,
+		sdk.ErrPinBaseImagesDockerfileRequired,
+	) {
+		t.Errorf("Build() error = %v, want %v", err, sdk.ErrPinBaseImagesDockerfileRequired)
+	}
+}
@@ -2,18 +2,39 @@ package sdk
 
 import (
 	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
+	"github.com/farcloser/quark/internal/database"
+	"github.com/farcloser/quark/internal/exceptions"
+	"github.com/farcloser/quark/internal/registry"
+	"github.com/farcloser/quark/internal/subprocess"
+	"github.com/farcloser/quark/internal/webhook"
 	"github.com/farcloser/quark/ssh"
 )
 
+// defaultBreakerThreshold is the number of consecutive failed requests after which a registry host is
+// considered unhealthy.
+const defaultBreakerThreshold = 10
+
 // operation is an internal interface for all executable operations.
 // This interface enables unified operation handling and simplifies adding new operation types.
 type operation interface {
 	execute(ctx context.Context) error
 	operationName() string
+	setName(name string)
+	kind() string
+	logger() *zerolog.Logger
+	runsOn(env Environment) bool
+	environments() []Environment
+	declaredResource() Resource
+	dependencies() []operation
+	conditions() []Condition
 }
 
 // Plan represents a declarative container image management plan.
@@ -22,16 +43,165 @@ type Plan struct {
 	log  zerolog.Logger
 
 	// Resources
-	registries    map[string]*Registry // keyed by normalized domain
-	buildNodes    []*BuildNode
-	syncs         []*Sync
-	builds        []*Build
-	scans         []*Scan
-	audits        []*Audit
-	versionChecks []*VersionCheck
-
-	// Operations in execution order (internal)
+	registries        map[string]*Registry // keyed by normalized domain
+	buildNodes        []*BuildNode
+	syncs             []*Sync
+	builds            []*Build
+	scans             []*Scan
+	audits            []*Audit
+	versionChecks     []*VersionCheck
+	rollbacks         []*Rollback
+	rebases           []*Rebase
+	flattens          []*Flatten
+	mutations         []*Mutate
+	indexes           []*Index
+	prunes            []*Prune
+	sizeChecks        []*SizeCheck
+	verifications     []*Verify
+	artifacts         []*Artifact
+	exports           []*Export
+	imports           []*Import
+	bundles           []*Bundle
+	maintenances      []*NodeMaintenance
+	provisions        []*ProvisionNode
+	containerdImports []*ContainerdImport
+	remoteRuns        []*RemoteRun
+	harborProjects    []*HarborProject
+	ghcrPackages      []*GHCRPackage
+	repositoryDocs    []*RepositoryDocs
+
+	// Operations in the order they were added (internal)
 	operations []operation
+
+	// Whether the operations of the plan were included in another plan (see Include)
+	included bool
+
+	// Operations running at the same time (sequential when zero)
+	maxParallelism int
+
+	// Registry traffic recorded during the last execution
+	meter *registry.Meter
+
+	// Names of the operations built in the plan (true), or reserved by UniqueName (false)
+	operationNames map[string]bool
+
+	// Rules deriving sync destinations from their source
+	rewriteRules []rewriteRule
+
+	// Platforms of syncs and scans that do not set theirs (linux/amd64 and linux/arm64 when empty)
+	defaultPlatforms []Platform
+
+	// Trivy server used by scans (empty for local scanning)
+	scannerServerURL   string
+	scannerServerToken string
+
+	// Known exploited vulnerabilities catalog scans are enriched from (CISA KEV when empty)
+	knownExploitedFeed string
+
+	// Findings waived for scans and audits (nil when no waiver file is loaded), and the waiver file
+	exceptions     *exceptions.Set
+	exceptionsFile string
+
+	// Endpoint notified after each successful sync (nil when disabled)
+	syncWebhook *webhook.Sender
+
+	// Endpoints notified of the outcome of each run (see Notify)
+	notifiers []*Notifier
+
+	// Registry HTTP settings
+	userAgent   string
+	logRequests bool
+
+	// Log external and remote commands (secrets redacted)
+	echoCommands bool
+
+	// Concurrent digest lookups (registry default when zero)
+	digestConcurrency int
+
+	// Requests in flight per registry domain (unlimited when absent)
+	registryConcurrency map[string]int
+
+	// Execution profiles by name, the selected one, and the one applied by a previous execution
+	profiles       map[string]*Profile
+	profileName    string
+	appliedProfile string
+
+	// Circuit breaker: consecutive failed requests before a registry host is considered unhealthy
+	// (default when zero, disabled when negative), and the breaker of the last execution
+	breakerThreshold int
+	breaker          *registry.Breaker
+
+	// Execute without checking tools, credentials, build nodes and files first
+	skipValidation bool
+
+	// Execution environment (detected when empty)
+	environment Environment
+
+	// How long cancelled external tools (trivy, dockle, op) have to exit before they are killed (default when zero)
+	toolGrace time.Duration
+
+	// Directory scan and version check results are recorded to (disabled when empty)
+	historyDir string
+
+	// Execution report of the last run, and where to write it (disabled when empty)
+	report       *Report
+	reportPath   string
+	reportFormat ReportFormat
+	commentOnPR  bool
+
+	// Where to write the execution timeline (disabled when empty)
+	tracePath string
+
+	// Where to write the plan state after successful executions (disabled when empty)
+	statePath string
+
+	// Database recording plan states, history and the execution log (disabled when empty), open while executing
+	databasePath string
+	database     *database.DB
+
+	// Skip operations unchanged since the last successful execution, with the cache keys of the operations of
+	// the current execution, and the cache keys and digests recorded by the last successful one, by name
+	skipUnchanged   bool
+	cacheKeys       map[operation]string
+	previousKeys    map[string]string
+	previousDigests map[string]string
+
+	// Where to write the provenance of successful executions (disabled when empty), and whether to attach it
+	provenancePath   string
+	attachProvenance bool
+
+	// Notified of operation executions, one event at a time
+	observers     []PlanObserver
+	observerMutex sync.Mutex
+
+	// Receive the events of executions, one event at a time
+	eventSinks []EventSink
+	eventMutex sync.Mutex
+
+	// Dry run: operations are checked and described, not executed
+	dryRun bool
+
+	// Destructive operation confirmation
+	confirmDestructive bool
+	assumeYes          bool
+
+	// Run by an Orchestrator: process-wide CLI settings are ignored
+	isolated bool
+	// Per-operation log files
+	operationLogDir string
+
+	// Resource limits shared with the other plans of an Orchestrator (nil: unbounded)
+	limiter *resourceLimiter
+
+	// Cron schedule of the plan document, used by Schedule (empty: on demand only)
+	cron string
+}
+
+// RegistryTraffic reports bytes transferred with a registry host during plan execution.
+type RegistryTraffic struct {
+	Host       string
+	Downloaded int64
+	Uploaded   int64
 }
 
 // normalizeDomain normalizes a registry domain.
@@ -155,13 +325,30 @@ func (plan *Plan) getRegistry(domain string) *Registry {
 
 // NewPlan creates a new Plan with the given name.
 func NewPlan(name string) *Plan {
+	return newPlan(name, log.Logger)
+}
+
+// newPlan creates a plan logging through logger.
+func newPlan(name string, logger zerolog.Logger) *Plan {
 	return &Plan{
-		name:       name,
-		log:        log.Logger.With().Str("plan", name).Logger(),
-		registries: make(map[string]*Registry),
+		name:           name,
+		log:            logger.With().Str("plan", name).Logger(),
+		registries:     make(map[string]*Registry),
+		profiles:       make(map[string]*Profile),
+		operationNames: make(map[string]bool),
 	}
 }
 
+// processEnv reads a setting the CLI passes through the environment (e.g., QUARK_YES).
+// Plans run by an Orchestrator share the process environment with other tenants, so they ignore it.
+func (plan *Plan) processEnv(key, fallback string) string {
+	if plan.isolated {
+		return fallback
+	}
+
+	return GetEnvWithFallback(key, fallback)
+}
+
 // Registry creates a new Registry builder.
 func (plan *Plan) Registry(host string) *RegistryBuilder {
 	return &RegistryBuilder{
@@ -173,13 +360,26 @@ func (plan *Plan) Registry(host string) *RegistryBuilder {
 	}
 }
 
+// Profile creates a new Profile builder.
+func (plan *Plan) Profile(name string) *ProfileBuilder {
+	return &ProfileBuilder{
+		plan: plan,
+		profile: &Profile{
+			name:       name,
+			registries: make(map[string]*Registry),
+			domains:    make(map[string]string),
+		},
+	}
+}
+
 // BuildNode creates a new BuildNode builder.
 func (plan *Plan) BuildNode(name string) *BuildNodeBuilder {
 	return &BuildNodeBuilder{
 		plan: plan,
 		node: &BuildNode{
-			name: name,
-			log:  plan.log.With().Str("buildnode", name).Logger(),
+			name:   name,
+			labels: map[string]string{},
+			log:    plan.log.With().Str("buildnode", name).Logger(),
 		},
 	}
 }
@@ -189,8 +389,7 @@ func (plan *Plan) Sync(name string) *SyncBuilder {
 	return &SyncBuilder{
 		plan: plan,
 		sync: &Sync{
-			opName: name,
-			log:    plan.log.With().Str("sync", name).Logger(),
+			operationCore: plan.newOperationCore("sync", name),
 		},
 	}
 }
@@ -200,8 +399,7 @@ func (plan *Plan) Build(name string) *BuildBuilder {
 	return &BuildBuilder{
 		plan: plan,
 		build: &Build{
-			opName: name,
-			log:    plan.log.With().Str("build", name).Logger(),
+			operationCore: plan.newOperationCore("build", name),
 		},
 	}
 }
@@ -211,8 +409,7 @@ func (plan *Plan) Scan(name string) *ScanBuilder {
 	return &ScanBuilder{
 		plan: plan,
 		scan: &Scan{
-			opName: name,
-			log:    plan.log.With().Str("scan", name).Logger(),
+			operationCore: plan.newOperationCore("scan", name),
 		},
 	}
 }
@@ -222,8 +419,7 @@ func (plan *Plan) Audit(name string) *AuditBuilder {
 	return &AuditBuilder{
 		plan: plan,
 		audit: &Audit{
-			opName: name,
-			log:    plan.log.With().Str("audit", name).Logger(),
+			operationCore: plan.newOperationCore("audit", name),
 		},
 	}
 }
@@ -233,12 +429,348 @@ func (plan *Plan) VersionCheck(name string) *VersionCheckBuilder {
 	return &VersionCheckBuilder{
 		plan: plan,
 		check: &VersionCheck{
-			opName: name,
-			log:    plan.log.With().Str("version_check", name).Logger(),
+			operationCore: plan.newOperationCore("version-check", name),
+		},
+	}
+}
+
+// BaseImageCheck creates a new BaseImageCheck builder.
+func (plan *Plan) BaseImageCheck(name string) *BaseImageCheckBuilder {
+	return &BaseImageCheckBuilder{
+		plan: plan,
+		check: &BaseImageCheck{
+			operationCore: plan.newOperationCore("base-image-check", name),
+		},
+	}
+}
+
+// PinBaseImages creates a new PinBaseImages builder.
+func (plan *Plan) PinBaseImages(name string) *PinBaseImagesBuilder {
+	return &PinBaseImagesBuilder{
+		plan: plan,
+		pin: &PinBaseImages{
+			operationCore: plan.newOperationCore("pin-base-images", name),
+		},
+	}
+}
+
+// ComposeImages creates a new ComposeImages builder.
+func (plan *Plan) ComposeImages(name string) *ComposeImagesBuilder {
+	return &ComposeImagesBuilder{
+		plan: plan,
+		compose: &ComposeImages{
+			operationCore: plan.newOperationCore("compose-images", name),
+		},
+	}
+}
+
+// KubernetesManifests creates a new KubernetesManifests builder.
+func (plan *Plan) KubernetesManifests(name string) *KubernetesManifestsBuilder {
+	return &KubernetesManifestsBuilder{
+		plan: plan,
+		manifests: &KubernetesManifests{
+			operationCore: plan.newOperationCore("kubernetes-manifests", name),
+		},
+	}
+}
+
+// Rollback creates a new Rollback builder.
+func (plan *Plan) Rollback(name string) *RollbackBuilder {
+	return &RollbackBuilder{
+		plan: plan,
+		rollback: &Rollback{
+			operationCore: plan.newOperationCore("rollback", name),
+		},
+	}
+}
+
+// Rebase creates a new Rebase builder.
+func (plan *Plan) Rebase(name string) *RebaseBuilder {
+	return &RebaseBuilder{
+		plan: plan,
+		rebase: &Rebase{
+			operationCore: plan.newOperationCore("rebase", name),
+		},
+	}
+}
+
+// Flatten creates a new Flatten builder.
+func (plan *Plan) Flatten(name string) *FlattenBuilder {
+	return &FlattenBuilder{
+		plan: plan,
+		flatten: &Flatten{
+			operationCore: plan.newOperationCore("flatten", name),
+		},
+	}
+}
+
+// Prune creates a new Prune builder.
+func (plan *Plan) Prune(name string) *PruneBuilder {
+	return &PruneBuilder{
+		plan: plan,
+		prune: &Prune{
+			operationCore: plan.newOperationCore("prune", name),
+		},
+	}
+}
+
+// Mutate creates a new Mutate builder.
+func (plan *Plan) Mutate(name string) *MutateBuilder {
+	return &MutateBuilder{
+		plan: plan,
+		mutate: &Mutate{
+			operationCore: plan.newOperationCore("mutate", name),
+			labels:        map[string]string{},
+			env:           map[string]string{},
+		},
+	}
+}
+
+// Index creates a new Index builder.
+func (plan *Plan) Index(name string) *IndexBuilder {
+	return &IndexBuilder{
+		plan: plan,
+		index: &Index{
+			operationCore: plan.newOperationCore("index", name),
+		},
+	}
+}
+
+// HarborProject creates a new HarborProject builder.
+func (plan *Plan) HarborProject(name string) *HarborProjectBuilder {
+	return &HarborProjectBuilder{
+		plan: plan,
+		harbor: &HarborProject{
+			operationCore: plan.newOperationCore("harbor-project", name),
+		},
+	}
+}
+
+// GHCRPackage creates a new GHCRPackage builder.
+func (plan *Plan) GHCRPackage(name string) *GHCRPackageBuilder {
+	return &GHCRPackageBuilder{
+		plan: plan,
+		pkg: &GHCRPackage{
+			operationCore: plan.newOperationCore("ghcr-package", name),
+			labels:        make(map[string]string),
+		},
+	}
+}
+
+// RepositoryDocs creates a new RepositoryDocs builder.
+func (plan *Plan) RepositoryDocs(name string) *RepositoryDocsBuilder {
+	return &RepositoryDocsBuilder{
+		plan: plan,
+		docs: &RepositoryDocs{
+			operationCore: plan.newOperationCore("repository-docs", name),
+		},
+	}
+}
+
+// SizeCheck creates a new SizeCheck builder.
+func (plan *Plan) SizeCheck(name string) *SizeCheckBuilder {
+	return &SizeCheckBuilder{
+		plan: plan,
+		check: &SizeCheck{
+			operationCore: plan.newOperationCore("size-check", name),
+		},
+	}
+}
+
+// Verify creates a new Verify builder.
+func (plan *Plan) Verify(name string) *VerifyBuilder {
+	return &VerifyBuilder{
+		plan: plan,
+		verify: &Verify{
+			operationCore: plan.newOperationCore("verify", name),
+		},
+	}
+}
+
+// Artifact creates a new Artifact builder.
+func (plan *Plan) Artifact(name string) *ArtifactBuilder {
+	return &ArtifactBuilder{
+		plan: plan,
+		artifact: &Artifact{
+			operationCore: plan.newOperationCore("artifact", name),
+			annotations:   make(map[string]string),
+		},
+	}
+}
+
+// Export creates a new Export builder.
+func (plan *Plan) Export(name string) *ExportBuilder {
+	return &ExportBuilder{
+		plan: plan,
+		export: &Export{
+			operationCore: plan.newOperationCore("export", name),
+		},
+	}
+}
+
+// ContainerdImport creates a new ContainerdImport builder.
+func (plan *Plan) ContainerdImport(name string) *ContainerdImportBuilder {
+	return &ContainerdImportBuilder{
+		plan: plan,
+		imp: &ContainerdImport{
+			operationCore: plan.newOperationCore("containerd-import", name),
+		},
+	}
+}
+
+// RemoteRun creates a new RemoteRun builder.
+func (plan *Plan) RemoteRun(name string) *RemoteRunBuilder {
+	return &RemoteRunBuilder{
+		plan: plan,
+		run: &RemoteRun{
+			operationCore: plan.newOperationCore("remote-run", name),
+		},
+	}
+}
+
+// Import creates a new Import builder.
+func (plan *Plan) Import(name string) *ImportBuilder {
+	return &ImportBuilder{
+		plan: plan,
+		imp: &Import{
+			operationCore: plan.newOperationCore("import", name),
+		},
+	}
+}
+
+// Bundle creates a new Bundle builder.
+func (plan *Plan) Bundle(name string) *BundleBuilder {
+	return &BundleBuilder{
+		plan: plan,
+		bundle: &Bundle{
+			operationCore: plan.newOperationCore("bundle", name),
 		},
 	}
 }
 
+// NodeMaintenance creates a new NodeMaintenance builder for a build node.
+func (plan *Plan) NodeMaintenance(node *BuildNode) *NodeMaintenanceBuilder {
+	name := ""
+	if node != nil {
+		name = node.Name()
+	}
+
+	return &NodeMaintenanceBuilder{
+		plan: plan,
+		maintenance: &NodeMaintenance{
+			operationCore: plan.newOperationCore("node-maintenance", name).named("maintenance-" + name),
+			node:          node,
+		},
+	}
+}
+
+// ProvisionNode creates a new ProvisionNode builder for a build node.
+func (plan *Plan) ProvisionNode(node *BuildNode) *ProvisionNodeBuilder {
+	name := ""
+	if node != nil {
+		name = node.Name()
+	}
+
+	return &ProvisionNodeBuilder{
+		plan: plan,
+		provision: &ProvisionNode{
+			operationCore: plan.newOperationCore("provision-node", name).named("provision-" + name),
+			node:          node,
+		},
+	}
+}
+
+// ScannerServer configures all scans in the plan to run against a centrally maintained Trivy server
+// (shared vulnerability database, faster scans) instead of scanning locally.
+// The token may be empty if the server does not require authentication.
+// When unset, scans fall back to local scanning.
+func (plan *Plan) ScannerServer(serverURL, token string) error {
+	parsed, err := url.Parse(serverURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidScannerServer, serverURL)
+	}
+
+	plan.scannerServerURL = serverURL
+	plan.scannerServerToken = token
+	secretValues.Add(token)
+
+	return nil
+}
+
+// UserAgent sets the User-Agent sent on all registry HTTP requests.
+// Defaults to "quark/<version>".
+func (plan *Plan) UserAgent(userAgent string) {
+	plan.userAgent = userAgent
+}
+
+// LogRequests enables the registry request logger: every registry HTTP request is logged at
+// trace level (method, URL, status, duration), to debug registry-side throttling and proxy issues.
+// Run with LOG_LEVEL=trace to see the output.
+func (plan *Plan) LogRequests(enabled bool) {
+	plan.logRequests = enabled
+}
+
+// EchoCommands logs every command run by the plan at debug level: external tools (trivy, dockle, op)
+// with the variables they add to the environment, and commands run on build nodes over SSH.
+// Secrets (password and token flags and variables, URL passwords, authorization headers) are redacted,
+// so the output can be shared to reproduce failures manually. Run with LOG_LEVEL=debug to see the output.
+// QUARK_ECHO_COMMANDS=true (set by the CLI --echo-commands flag) also enables it, except for plans run by
+// an Orchestrator.
+func (plan *Plan) EchoCommands(enabled bool) {
+	plan.echoCommands = enabled
+}
+
+// DigestConcurrency sets how many tag digests are resolved at once when the plan prefetches the current
+// digests of its version checks with HEAD requests before running operations. Defaults to 8.
+func (plan *Plan) DigestConcurrency(limit int) {
+	plan.digestConcurrency = limit
+}
+
+// RegistryConcurrency limits how many requests the plan has in flight to the registry of domain at once, across
+// all operations (e.g., 3 for ghcr.io), so parallel syncs do not trip its rate limits: requests beyond the limit
+// wait for a slot. Uploads hold their slot until they complete, downloads until the registry starts answering.
+// A limit of zero or less removes the limit of domain. Registries are unlimited by default.
+func (plan *Plan) RegistryConcurrency(domain string, limit int) {
+	domain = normalizeDomain(domain)
+
+	if limit <= 0 {
+		delete(plan.registryConcurrency, domain)
+
+		return
+	}
+
+	if plan.registryConcurrency == nil {
+		plan.registryConcurrency = make(map[string]int)
+	}
+
+	plan.registryConcurrency[domain] = limit
+}
+
+// CircuitBreaker sets how many consecutive requests to a registry host may fail (network errors, HTTP 429
+// and 5xx responses, retries included) before the host is considered unhealthy: the remaining operations
+// targeting it then fail immediately with ErrRegistryUnhealthy, instead of each waiting for its own
+// timeouts and retries. Defaults to 10. A threshold of zero or less disables the breaker.
+func (plan *Plan) CircuitBreaker(threshold int) {
+	if threshold <= 0 {
+		threshold = -1
+	}
+
+	plan.breakerThreshold = threshold
+}
+
+// ToolGracePeriod sets how long external tools (trivy, dockle, op) have to exit after SIGTERM when the
+// execution context is cancelled or past its deadline, before they are killed.
+// Defaults to 10 seconds.
+func (plan *Plan) ToolGracePeriod(grace time.Duration) {
+	plan.toolGrace = grace
+}
+
+// Environment overrides the detected execution environment used by RunOnlyOn guards.
+// By default, the environment is detected from CI/CD environment variables (see DetectEnvironment).
+func (plan *Plan) Environment(env Environment) {
+	plan.environment = env
+}
+
 // executor implements plan execution logic.
 type executor struct {
 	plan    *Plan
@@ -256,7 +788,29 @@ func newExecutor(plan *Plan) *executor {
 }
 
 // Execute runs the plan with the given context.
-func (plan *Plan) Execute(ctx context.Context) error {
+func (plan *Plan) Execute(ctx context.Context) (err error) {
+	// Wrapped tool output (e.g., 1Password CLI errors) must not leak secrets
+	defer func() {
+		err = secretValues.Error(err)
+	}()
+
+	// Its operations belong to the including plan
+	if plan.included {
+		return fmt.Errorf("%w, execute the including plan instead: %q", ErrPlanAlreadyIncluded, plan.name)
+	}
+
+	// Before anything else, so states and dry runs describe the environment the plan executes in
+	if err := plan.applyProfile(); err != nil {
+		return err
+	}
+
+	// `quark plan-diff` only needs the state of Go plans: nothing is checked or executed
+	if plan.processEnv("QUARK_STATE_ONLY", "") == "true" {
+		plan.writeState(ctx, nil, nil)
+
+		return nil
+	}
+
 	plan.log.Info().Msg("executing plan")
 
 	// Create executor with SSH pool
@@ -267,26 +821,268 @@ func (plan *Plan) Execute(ctx context.Context) error {
 		}
 	}()
 
-	// Set sshPool for all Build operations
+	// Set sshPool for all operations running on build nodes
+	// Builds resolve the digest they pushed for the operations reading it, and for provenance
+	provenance := plan.provenanceEnabled()
+
 	for _, build := range plan.builds {
 		build.sshPool = exec.sshPool
+		build.resolveDigest = build.output.producer != nil || provenance
+
+		if build.resolveDigest {
+			build.outputRegistry = plan.getRegistry(build.output.Domain())
+		} else {
+			// Only read to create the output repository (see AutoCreateRepo): no anonymous access warning
+			build.outputRegistry = plan.registries[normalizeDomain(build.output.Domain())]
+		}
+	}
+
+	for _, maintenance := range plan.maintenances {
+		maintenance.sshPool = exec.sshPool
+	}
+
+	for _, provision := range plan.provisions {
+		provision.sshPool = exec.sshPool
+	}
+
+	for _, imp := range plan.containerdImports {
+		imp.sshPool = exec.sshPool
+	}
+
+	for _, run := range plan.remoteRuns {
+		run.sshPool = exec.sshPool
+	}
+
+	// Set scanner server for all Scan operations
+	// Scans share the known exploited catalog, loaded at most once per execution
+	exploits := plan.newExploitCatalog()
+
+	for _, scan := range plan.scans {
+		scan.serverURL = plan.scannerServerURL
+		scan.serverToken = plan.scannerServerToken
+		scan.exploits = nil
+		scan.exceptions = plan.exceptions
+
+		if scan.failOnKnownExploited || plan.knownExploitedFeed != "" {
+			scan.exploits = exploits
+		}
+	}
+
+	for _, auditJob := range plan.audits {
+		auditJob.exceptions = plan.exceptions
+	}
+
+	for _, sync := range plan.syncs {
+		sync.planName = plan.name
+		sync.webhook = plan.syncWebhook
+	}
+
+	// Closed once the execution is recorded by finishReport
+	if err := plan.openDatabase(ctx); err != nil {
+		return err
 	}
 
-	// Execute all operations in the order they were added
-	for _, op := range plan.operations {
-		if err := op.execute(ctx); err != nil {
+	defer plan.closeDatabase()
+
+	plan.report = &Report{Plan: plan.name, Started: time.Now().UTC()}
+	defer func() { plan.finishReport(ctx, err) }()
+
+	plan.report.Warnings = plan.exceptionWarnings(plan.report.Started)
+	for _, warning := range plan.report.Warnings {
+		plan.log.Warn().Msg(warning)
+	}
+
+	// Record scan and version check results to the plan history
+	recorder := newHistoryRecorder(plan, plan.report.Started)
+
+	for _, scan := range plan.scans {
+		scan.history = recorder
+	}
+
+	for _, check := range plan.versionChecks {
+		check.history = recorder
+	}
+
+	// Record registry traffic for egress reporting
+	plan.meter = registry.NewMeter()
+	ctx = registry.WithMeter(ctx, plan.meter)
+
+	// Serve repeated manifest lookups (e.g., VersionCheck+Sync+Scan on the same image) from memory
+	cache := registry.NewManifestCache()
+	ctx = registry.WithManifestCache(ctx, cache)
+
+	defer func() {
+		hits, misses := cache.Stats()
+		plan.log.Debug().Int("hits", hits).Int("misses", misses).Msg("manifest cache")
+	}()
+
+	userAgent := plan.userAgent
+	if userAgent == "" {
+		userAgent = "quark/" + Version
+	}
+
+	ctx = registry.WithUserAgent(ctx, userAgent)
+
+	if plan.logRequests {
+		ctx = registry.WithRequestLogging(ctx, plan.log)
+	}
+
+	if plan.echoCommands || plan.processEnv("QUARK_ECHO_COMMANDS", "") == "true" {
+		ctx = subprocess.WithEcho(ctx, plan.log)
+		exec.sshPool.EchoCommands(true)
+	}
+
+	// Fail fast against registries that stopped answering
+	plan.breaker = nil
+
+	if plan.breakerThreshold >= 0 {
+		threshold := plan.breakerThreshold
+		if threshold == 0 {
+			threshold = defaultBreakerThreshold
+		}
+
+		plan.breaker = registry.NewBreaker(threshold)
+		ctx = registry.WithBreaker(ctx, plan.breaker)
+	}
+
+	if len(plan.registryConcurrency) > 0 {
+		ctx = registry.WithLimiter(ctx, registry.NewLimiter(plan.registryConcurrency))
+	}
+
+	if plan.toolGrace > 0 {
+		ctx = subprocess.WithGrace(ctx, plan.toolGrace)
+	}
+
+	defer plan.logTraffic()
+
+	env := plan.environment
+	if env == "" {
+		env = DetectEnvironment()
+	}
+
+	plan.log.Debug().Str("environment", string(env)).Msg("execution environment")
+
+	validateOnly := plan.processEnv("QUARK_VALIDATE", "") == "true"
+
+	if !plan.skipValidation || validateOnly {
+		if err := plan.validate(ctx, env, exec.sshPool); err != nil {
 			return err
 		}
 	}
 
+	if validateOnly {
+		return nil
+	}
+
+	if plan.dryRun || plan.processEnv("QUARK_DRY_RUN", "") == "true" {
+		return plan.dryRunOperations(ctx, env)
+	}
+
+	// Before operations pin the images they produce: keys hash the inputs of the plan as declared
+	plan.prepareCache(ctx)
+
+	plan.prefetchVersionCheckDigests(ctx, env)
+
+	logDir := plan.processEnv("QUARK_LOG_DIR", plan.operationLogDir)
+
+	// Execute operations in the order they were added, concurrently when their dependencies allow it
+	if err := plan.runOperations(ctx, env, logDir); err != nil {
+		return err
+	}
+
+	if err := plan.recordProvenance(ctx, env); err != nil {
+		return err
+	}
+
+	keys, digests := plan.completedOperations()
+	plan.writeState(ctx, keys, digests)
+
 	plan.log.Info().Msg("plan execution complete")
 
 	return nil
 }
 
-// DryRun simulates plan execution without making changes.
-func (plan *Plan) DryRun() error {
-	plan.log.Info().Msg("dry run (no changes will be made)")
+// executeScoped executes op with its logs redirected to its file in logDir (when set).
+func (plan *Plan) executeScoped(ctx context.Context, op operation, logDir string) error {
+	if logDir == "" {
+		return plan.executeRetried(ctx, op)
+	}
 
-	return nil
+	restore, err := plan.scopeOperationLog(logDir, op)
+	if err != nil {
+		return err
+	}
+
+	defer restore()
+
+	return plan.executeRetried(ctx, op)
+}
+
+// executeLimited executes op once a slot of its resource class is available,
+// when the plan shares resource limits with concurrent plans.
+func (plan *Plan) executeLimited(ctx context.Context, op operation) error {
+	if plan.limiter == nil {
+		return op.execute(ctx)
+	}
+
+	resource := operationResource(op)
+
+	plan.log.Debug().Str("operation", op.operationName()).Str("resource", string(resource)).Msg("waiting for resource")
+
+	release, err := plan.limiter.acquire(ctx, resource)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	return op.execute(ctx)
+}
+
+// RegistryTraffic returns the bytes downloaded from and uploaded to each registry host
+// during the last plan execution, sorted by host.
+// Blob downloads redirected to storage/CDN hosts are attributed to the originating registry.
+// Traffic performed by external tools (trivy, dockle) and remote builds is not included.
+func (plan *Plan) RegistryTraffic() []RegistryTraffic {
+	if plan.meter == nil {
+		return nil
+	}
+
+	recorded := plan.meter.Traffic()
+	traffic := make([]RegistryTraffic, 0, len(recorded))
+
+	for _, entry := range recorded {
+		traffic = append(traffic, RegistryTraffic{
+			Host:       entry.Host,
+			Downloaded: entry.Downloaded,
+			Uploaded:   entry.Uploaded,
+		})
+	}
+
+	return traffic
+}
+
+// logTraffic logs the registry traffic recorded during execution, and the hosts found unhealthy.
+func (plan *Plan) logTraffic() {
+	for _, entry := range plan.RegistryTraffic() {
+		plan.log.Info().
+			Str("host", entry.Host).
+			Int64("downloaded_bytes", entry.Downloaded).
+			Int64("uploaded_bytes", entry.Uploaded).
+			Msg("registry traffic")
+	}
+
+	for _, host := range plan.UnhealthyRegistries() {
+		plan.log.Warn().Str("host", host).Msg("registry unhealthy: requests failed fast after consecutive failures")
+	}
+}
+
+// UnhealthyRegistries returns the registry hosts the circuit breaker tripped for during the last
+// plan execution, sorted.
+func (plan *Plan) UnhealthyRegistries() []string {
+	if plan.breaker == nil {
+		return nil
+	}
+
+	return plan.breaker.Unhealthy()
 }
@@ -1,6 +1,12 @@
 package sdk
 
-// Platform represents a container platform architecture.
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Platform represents a container platform (os/architecture[/variant]).
 type Platform struct {
 	value string
 }
@@ -11,9 +17,134 @@ var (
 	PlatformAMD64 = Platform{"linux/amd64"}
 	// PlatformARM64 represents linux/arm64.
 	PlatformARM64 = Platform{"linux/arm64"}
+	// PlatformARMv7 represents linux/arm/v7 (32-bit ARM, e.g., Raspberry Pi 2/3).
+	PlatformARMv7 = Platform{"linux/arm/v7"}
+	// PlatformPPC64LE represents linux/ppc64le (IBM POWER).
+	PlatformPPC64LE = Platform{"linux/ppc64le"}
+	// PlatformS390X represents linux/s390x (IBM Z).
+	PlatformS390X = Platform{"linux/s390x"}
+	// PlatformWindowsAMD64 represents windows/amd64.
+	PlatformWindowsAMD64 = Platform{"windows/amd64"}
+	// PlatformDarwinARM64 represents darwin/arm64 (Apple silicon).
+	PlatformDarwinARM64 = Platform{"darwin/arm64"}
 )
 
+// Platforms returns every supported platform.
+func Platforms() []Platform {
+	return []Platform{
+		PlatformAMD64,
+		PlatformARM64,
+		PlatformARMv7,
+		PlatformPPC64LE,
+		PlatformS390X,
+		PlatformWindowsAMD64,
+		PlatformDarwinARM64,
+	}
+}
+
+// ParsePlatform parses a platform string (e.g., "linux/arm/v7") into a supported Platform.
+// Parsing is case-insensitive, and accepts common aliases: x86_64 for amd64, aarch64 and arm64/v8 for arm64,
+// and arm for arm/v7.
+// Returns ErrInvalidPlatform for malformed strings and ErrUnsupportedPlatform for platforms outside the catalog.
+func ParsePlatform(platform string) (Platform, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(platform)), "/")
+	if len(parts) < 2 || len(parts) > 3 || slices.Contains(parts, "") {
+		return Platform{}, fmt.Errorf("%w: %q (expected os/architecture[/variant])", ErrInvalidPlatform, platform)
+	}
+
+	osName, arch, variant := parts[0], parts[1], ""
+	if len(parts) == 3 {
+		variant = parts[2]
+	}
+
+	switch arch {
+	case "x86_64", "x86-64":
+		arch = "amd64"
+	case "aarch64":
+		arch = "arm64"
+	default:
+	}
+
+	switch {
+	case arch == "arm64" && variant == "v8":
+		variant = ""
+	case arch == "arm" && variant == "":
+		variant = "v7"
+	default:
+	}
+
+	normalized := osName + "/" + arch
+	if variant != "" {
+		normalized += "/" + variant
+	}
+
+	for _, supported := range Platforms() {
+		if supported.value == normalized {
+			return supported, nil
+		}
+	}
+
+	return Platform{}, fmt.Errorf("%w: %q (supported: %s)", ErrUnsupportedPlatform, platform, supportedPlatforms())
+}
+
 // String returns the string representation of the platform.
 func (platform Platform) String() string {
 	return platform.value
 }
+
+// OS returns the operating system of the platform (e.g., "linux").
+func (platform Platform) OS() string {
+	osName, _, _ := strings.Cut(platform.value, "/")
+
+	return osName
+}
+
+// Architecture returns the CPU architecture of the platform (e.g., "arm").
+func (platform Platform) Architecture() string {
+	parts := strings.Split(platform.value, "/")
+	if len(parts) < 2 {
+		return ""
+	}
+
+	return parts[1]
+}
+
+// Variant returns the CPU variant of the platform (e.g., "v7"), or empty.
+func (platform Platform) Variant() string {
+	parts := strings.Split(platform.value, "/")
+	if len(parts) < 3 {
+		return ""
+	}
+
+	return parts[2]
+}
+
+// DefaultPlatforms sets the platforms Sync and Scan operations built afterwards use when they do not set their
+// own with Platforms (default: linux/amd64 and linux/arm64), e.g., arm64 only, or with linux/s390x.
+// Calling it without platforms restores the default. Builds take their platforms from their nodes.
+func (plan *Plan) DefaultPlatforms(platforms ...Platform) {
+	plan.defaultPlatforms = slices.Clone(platforms)
+}
+
+// platformsOrDefault returns platforms, or the default platforms of the plan when empty.
+func (plan *Plan) platformsOrDefault(platforms []Platform) []Platform {
+	if len(platforms) > 0 {
+		return platforms
+	}
+
+	if len(plan.defaultPlatforms) > 0 {
+		return slices.Clone(plan.defaultPlatforms)
+	}
+
+	return []Platform{PlatformAMD64, PlatformARM64}
+}
+
+// supportedPlatforms returns the supported platforms, for error messages.
+func supportedPlatforms() string {
+	names := make([]string, 0, len(Platforms()))
+	for _, platform := range Platforms() {
+		names = append(names, platform.value)
+	}
+
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,185 @@
+package sdk_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: Platform strings parse to catalog platforms, with aliases normalized, and invalid or
+// unsupported platforms fail with distinct errors.
+func TestParsePlatform(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    sdk.Platform
+		wantErr error
+	}{
+		{input: "linux/amd64", want: sdk.PlatformAMD64},
+		{input: "linux/arm64", want: sdk.PlatformARM64},
+		{input: "linux/arm/v7", want: sdk.PlatformARMv7},
+		{input: "linux/ppc64le", want: sdk.PlatformPPC64LE},
+		{input: "linux/s390x", want: sdk.PlatformS390X},
+		{input: "windows/amd64", want: sdk.PlatformWindowsAMD64},
+		{input: "darwin/arm64", want: sdk.PlatformDarwinARM64},
+		{input: " Linux/AMD64 ", want: sdk.PlatformAMD64},
+		{input: "linux/x86_64", want: sdk.PlatformAMD64},
+		{input: "linux/aarch64", want: sdk.PlatformARM64},
+		{input: "linux/arm64/v8", want: sdk.PlatformARM64},
+		{input: "linux/arm", want: sdk.PlatformARMv7},
+		{input: "", wantErr: sdk.ErrInvalidPlatform},
+		{input: "linux", wantErr: sdk.ErrInvalidPlatform},
+		{input: "linux//v7", wantErr: sdk.ErrInvalidPlatform},
+		{input: "linux/arm/v7/extra", wantErr: sdk.ErrInvalidPlatform},
+		{input: "linux/riscv64", wantErr: sdk.ErrUnsupportedPlatform},
+		{input: "linux/arm/v6", wantErr: sdk.ErrUnsupportedPlatform},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := sdk.ParsePlatform(tt.input)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ParsePlatform(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParsePlatform(%q) error = %v", tt.input, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("ParsePlatform(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// INTENTION: Every catalog platform round-trips through ParsePlatform and exposes its components.
+func TestPlatforms(t *testing.T) {
+	t.Parallel()
+
+	for _, platform := range sdk.Platforms() {
+		parsed, err := sdk.ParsePlatform(platform.String())
+		if err != nil || parsed != platform {
+			t.Errorf("ParsePlatform(%q) = %v, %v, want %v", platform, parsed, err, platform)
+		}
+
+		want := platform.OS() + "/" + platform.Architecture()
+		if platform.Variant() != "" {
+			want += "/" + platform.Variant()
+		}
+
+		if want != platform.String() {
+			t.Errorf("OS/Architecture/Variant of %q = %q", platform, want)
+		}
+	}
+
+	if sdk.PlatformARMv7.Variant() != "v7" || sdk.PlatformARMv7.Architecture() != "arm" {
+		t.Errorf("PlatformARMv7 = %s/%s, want arm/v7", sdk.PlatformARMv7.Architecture(), sdk.PlatformARMv7.Variant())
+	}
+}
+
+// INTENTION: Syncs without Platforms use the plan default platforms: only those are copied into the destination
+// manifest list, variants included (arm64 images carrying the v8 variant match linux/arm64).
+func TestPlan_DefaultPlatforms(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	idx := mutate.IndexMediaType(empty.Index, types.OCIImageIndex)
+
+	for _, platform := range []v1.Platform{
+		{OS: "linux", Architecture: "amd64"},
+		{OS: "linux", Architecture: "arm64", Variant: "v8"},
+		{OS: "linux", Architecture: "arm", Variant: "v7"},
+	} {
+		img, err := random.Image(256, 1)
+		if err != nil {
+			t.Fatalf("Failed to create random image: %v", err)
+		}
+
+		idx = mutate.AppendManifests(idx, mutate.IndexAddendum{Add: img, Descriptor: v1.Descriptor{Platform: &platform}})
+	}
+
+	sourceRef, err := name.ParseReference(host + "/source/app:1.0.0")
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+
+	if err := remote.WriteIndex(sourceRef, idx); err != nil {
+		t.Fatalf("Failed to push index: %v", err)
+	}
+
+	digest, err := idx.Digest()
+	if err != nil {
+		t.Fatalf("Failed to get index digest: %v", err)
+	}
+
+	plan := sdk.NewPlan(testPlanName)
+	plan.DefaultPlatforms(sdk.PlatformARM64, sdk.PlatformARMv7)
+
+	source, err := sdk.NewImage("source/app").Domain(host).Version("1.0.0").Digest(digest.String()).Build()
+	if err != nil {
+		t.Fatalf("Failed to create source image: %v", err)
+	}
+
+	destination, err := sdk.NewImage("mirror/app").Domain(host).Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create destination image: %v", err)
+	}
+
+	if _, err := plan.Sync("mirror").Source(source).Destination(destination).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if err := plan.Execute(t.Context()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	mirrorRef, err := name.ParseReference(host + "/mirror/app:1.0.0")
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+
+	mirrored, err := remote.Index(mirrorRef)
+	if err != nil {
+		t.Fatalf("Failed to get destination index: %v", err)
+	}
+
+	manifest, err := mirrored.IndexManifest()
+	if err != nil {
+		t.Fatalf("Failed to get destination index manifest: %v", err)
+	}
+
+	var platforms []string
+	for _, desc := range manifest.Manifests {
+		platforms = append(platforms, desc.Platform.OS+"/"+desc.Platform.Architecture+"/"+desc.Platform.Variant)
+	}
+
+	if got := strings.Join(platforms, ","); got != "linux/arm/v7,linux/arm64/" {
+		t.Errorf("destination platforms = %s, want linux/arm/v7 and linux/arm64", got)
+	}
+}
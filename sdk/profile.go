@@ -0,0 +1,220 @@
+package sdk
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Profile is a named execution environment (e.g., "staging", "production"): when selected, it swaps the
+// credentials of registries, and the domains and tags images are pushed to, so one plan drives every environment.
+type Profile struct {
+	name string
+
+	// Credentials replacing the plan registries of their domain, keyed by normalized domain
+	registries map[string]*Registry
+
+	// Destination domain -> profile domain (normalized)
+	domains map[string]string
+
+	// Appended to destination tags
+	tagSuffix string
+}
+
+// ProfileBuilder builds a Profile.
+type ProfileBuilder struct {
+	builderState
+
+	plan    *Plan
+	profile *Profile
+}
+
+// Registry returns a builder for the registry at host used by the profile: once built, it replaces the plan
+// registry of the same domain (credentials, authentication methods and tuning) when the profile is selected.
+func (builder *ProfileBuilder) Registry(host string) *RegistryBuilder {
+	registryBuilder := builder.plan.Registry(host)
+	registryBuilder.profile = builder.profile
+
+	return registryBuilder
+}
+
+// Domain makes the images pushed to domain (sync, import and artifact destinations, build tags) go to
+// replacement instead (e.g., Domain("ghcr.io", "registry.staging.example.com")), keeping their path.
+// Operations reading these images (e.g., a scan of a sync destination) follow them.
+func (builder *ProfileBuilder) Domain(domain, replacement string) *ProfileBuilder {
+	builder.profile.domains[normalizeDomain(domain)] = normalizeDomain(replacement)
+
+	return builder
+}
+
+// TagSuffix appends suffix to the tags images are pushed to (e.g., "-staging" pushes 1.2.0 as 1.2.0-staging).
+func (builder *ProfileBuilder) TagSuffix(suffix string) *ProfileBuilder {
+	builder.profile.tagSuffix = suffix
+
+	return builder
+}
+
+// Build validates and adds the profile to the plan.
+// The builder becomes unusable after Build() is called.
+func (builder *ProfileBuilder) Build() (*Profile, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if builder.profile.name == "" {
+		return nil, ErrProfileNameRequired
+	}
+
+	if _, exists := builder.plan.profiles[builder.profile.name]; exists {
+		return nil, fmt.Errorf("%w: %q", ErrDuplicateProfile, builder.profile.name)
+	}
+
+	builder.plan.profiles[builder.profile.name] = builder.profile
+
+	return builder.profile, nil
+}
+
+// Name returns the profile name.
+func (profile *Profile) Name() string {
+	return profile.name
+}
+
+// UseProfile selects the profile the plan executes with.
+// QUARK_PROFILE (set by the CLI --profile flag) selects it otherwise, except for plans run by an Orchestrator.
+// Without profile, the plan executes as defined.
+func (plan *Plan) UseProfile(name string) {
+	plan.profileName = name
+}
+
+// Profiles returns the names of the profiles of the plan, sorted.
+func (plan *Plan) Profiles() []string {
+	names := make([]string, 0, len(plan.profiles))
+	for name := range plan.profiles {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// applyProfile applies the selected profile, if any, once: later executions keep it.
+func (plan *Plan) applyProfile() error {
+	name := plan.profileName
+	if name == "" {
+		name = plan.processEnv("QUARK_PROFILE", "")
+	}
+
+	if name == "" || name == plan.appliedProfile {
+		return nil
+	}
+
+	if plan.appliedProfile != "" {
+		return fmt.Errorf("%w: %q, cannot switch to %q", ErrProfileApplied, plan.appliedProfile, name)
+	}
+
+	profile, ok := plan.profiles[name]
+	if !ok {
+		return fmt.Errorf("%w: %q (defined: %v)", ErrUnknownProfile, name, plan.Profiles())
+	}
+
+	// Registries are replaced in place: operations (and their clients) keep the pointers they were built with
+	for domain, reg := range profile.registries {
+		if existing := plan.registries[domain]; existing != nil {
+			*existing = *reg
+		} else {
+			plan.registries[domain] = reg
+		}
+	}
+
+	retargeted := make(map[*Image]bool)
+
+	for _, image := range plan.destinationImages() {
+		changed, err := profile.retarget(image)
+		if err != nil {
+			return err
+		}
+
+		retargeted[image] = changed
+	}
+
+	// Builds push the tag they were built with
+	for _, build := range plan.builds {
+		if retargeted[build.output] {
+			tagRef, err := build.output.tagRef()
+			if err != nil {
+				return fmt.Errorf("profile %q: %w", name, err)
+			}
+
+			build.tag = tagRef
+		}
+	}
+
+	plan.resolveRegistries()
+
+	plan.appliedProfile = name
+	plan.log.Info().Str("profile", name).Msg("execution profile applied")
+
+	return nil
+}
+
+// retarget points image to the domain and tag the profile pushes it to, and reports whether it changed.
+func (profile *Profile) retarget(image *Image) (bool, error) {
+	domain := profile.domains[normalizeDomain(image.Domain())]
+
+	tag := ""
+	if profile.tagSuffix != "" && image.Version() != "" {
+		tag = image.Version() + profile.tagSuffix
+	}
+
+	if domain == "" && tag == "" {
+		return false, nil
+	}
+
+	if err := image.retarget(domain, tag); err != nil {
+		return false, fmt.Errorf("profile %q: %w", profile.name, err)
+	}
+
+	return true, nil
+}
+
+// destinationOperation is implemented by operations pushing to (or pruning) an image repository.
+type destinationOperation interface {
+	// destination returns the image the operation pushes (or prunes).
+	destination() *Image
+}
+
+// registryOperation is implemented by operations reading or writing registries.
+type registryOperation interface {
+	// resolveRegistries sets the registries of the operation images, as lookup returns them.
+	resolveRegistries(lookup func(image *Image) *Registry)
+}
+
+// destinationImages returns the images the plan pushes (or prunes), once each.
+func (plan *Plan) destinationImages() []*Image {
+	var images []*Image
+
+	for _, op := range plan.operations {
+		if pushing, ok := op.(destinationOperation); ok {
+			images = appendUnique(images, pushing.destination())
+		}
+	}
+
+	return images
+}
+
+// resolveRegistries looks up again the registries of the operation images, once domains or registries changed.
+func (plan *Plan) resolveRegistries() {
+	lookup := func(image *Image) *Registry {
+		if image == nil {
+			return nil
+		}
+
+		return plan.registries[normalizeDomain(image.Domain())]
+	}
+
+	for _, op := range plan.operations {
+		if resolving, ok := op.(registryOperation); ok {
+			resolving.resolveRegistries(lookup)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package sdk_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: The selected profile pushes destinations to its domain and tags, without changing the plan
+// definition; other profiles are ignored.
+func TestPlan_UseProfile(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+	digest := pushRandomImage(t, host+"/source/app:1.0.0")
+
+	plan := sdk.NewPlan("profiles")
+
+	source, err := sdk.NewImage("source/app").Domain(host).Version("1.0.0").Digest(digest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create source image: %v", err)
+	}
+
+	// Unreachable unless the profile swaps the domain
+	release, err := sdk.NewImage("release/app").Domain("registry.production.invalid").Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create release image: %v", err)
+	}
+
+	sync, err := plan.Sync("release").Source(source).Destination(release).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	_, err = plan.Profile("staging").Domain("registry.production.invalid", host).TagSuffix("-rc").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if _, err := plan.Profile("other").TagSuffix("-other").Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	plan.UseProfile("staging")
+
+	if err := plan.Execute(t.Context()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if sync.Destination().Domain() != host || sync.Destination().Version() != "1.0.0-rc" {
+		t.Errorf("Destination() = %s, want %s/release/app:1.0.0-rc", sync.Destination(), host)
+	}
+
+	ref, err := name.ParseReference(host + "/release/app:1.0.0-rc")
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+
+	pushed, err := remote.Head(ref)
+	if err != nil {
+		t.Fatalf("Head() error = %v, want the image pushed by the profile", err)
+	}
+
+	if pushed.Digest.String() != digest {
+		t.Errorf("pushed digest = %s, want %s", pushed.Digest, digest)
+	}
+
+	// Executing again keeps the profile, and does not suffix twice
+	if err := plan.Execute(t.Context()); err != nil {
+		t.Fatalf("Execute() again error = %v", err)
+	}
+
+	if sync.Destination().Version() != "1.0.0-rc" {
+		t.Errorf("Destination().Version() = %q after a second execution, want 1.0.0-rc", sync.Destination().Version())
+	}
+
+	plan.UseProfile("other")
+
+	if err := plan.Execute(t.Context()); !errors.Is(err, sdk.ErrProfileApplied) {
+		t.Errorf("Execute() error = %v, want %v", err, sdk.ErrProfileApplied)
+	}
+}
+
+// INTENTION: Profiles need a unique name, and selecting an undefined profile fails before anything runs.
+func TestPlan_ProfileErrors(t *testing.T) {
+	t.Parallel()
+
+	plan := sdk.NewPlan(testPlanName)
+
+	if _, err := plan.Profile("").Build(); !errors.Is(err, sdk.ErrProfileNameRequired) {
+		t.Errorf("Build() error = %v, want %v", err, sdk.ErrProfileNameRequired)
+	}
+
+	if _, err := plan.Profile("staging").Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if _, err := plan.Profile("staging").Build(); !errors.Is(err, sdk.ErrDuplicateProfile) {
+		t.Errorf("Build() error = %v, want %v", err, sdk.ErrDuplicateProfile)
+	}
+
+	plan.UseProfile("production")
+
+	if err := plan.Execute(t.Context()); !errors.Is(err, sdk.ErrUnknownProfile) {
+		t.Errorf("Execute() error = %v, want %v", err, sdk.ErrUnknownProfile)
+	}
+}
@@ -0,0 +1,276 @@
+package sdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/internal/registry"
+)
+
+const (
+	// provenanceMediaType is the media type of in-toto statements, and the artifact type they are attached with.
+	provenanceMediaType = "application/vnd.in-toto+json"
+
+	inTotoStatementType     = "https://in-toto.io/Statement/v1"
+	slsaProvenanceType      = "https://slsa.dev/provenance/v1"
+	provenanceBuildType     = "https://github.com/farcloser/quark/plan/v1"
+	provenanceBuilderID     = "https://github.com/farcloser/quark"
+	provenanceBlobTitle     = "provenance.intoto.json"
+	predicateTypeAnnotation = "in-toto.io/predicate-type"
+)
+
+// inTotoStatement is an in-toto attestation statement (https://github.com/in-toto/attestation), with a SLSA
+// provenance predicate (https://slsa.dev/spec/v1.0/provenance).
+type inTotoStatement struct {
+	Type          string               `json:"_type"`
+	Subject       []resourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     slsaProvenance       `json:"predicate"`
+}
+
+// resourceDescriptor is an artifact of the statement, identified by its digests (algorithm -> hex value).
+type resourceDescriptor struct {
+	Name   string            `json:"name,omitempty"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest"`
+}
+
+type slsaProvenance struct {
+	BuildDefinition provenanceBuildDefinition `json:"buildDefinition"`
+	RunDetails      provenanceRunDetails      `json:"runDetails"`
+}
+
+type provenanceBuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   map[string]any       `json:"externalParameters"`
+	InternalParameters   map[string]any       `json:"internalParameters,omitempty"`
+	ResolvedDependencies []resourceDescriptor `json:"resolvedDependencies,omitempty"`
+}
+
+type provenanceRunDetails struct {
+	Builder  provenanceBuilder  `json:"builder"`
+	Metadata provenanceMetadata `json:"metadata"`
+}
+
+type provenanceBuilder struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+type provenanceMetadata struct {
+	InvocationID string    `json:"invocationId,omitempty"`
+	StartedOn    time.Time `json:"startedOn"`
+	FinishedOn   time.Time `json:"finishedOn"`
+}
+
+// ProvenanceTo writes an in-toto statement with SLSA provenance of every successful Execute to path: its
+// subjects are the images the plan pushed (sync, import and artifact destinations, builds), by digest, and its
+// dependencies the source images and Dockerfiles they were produced from. The statement can be signed
+// (e.g., `cosign attest --type slsaprovenance1 --predicate` with its predicate) or attached (see AttachProvenance).
+// QUARK_PROVENANCE (set by the CLI --provenance flag) takes precedence, except for plans run by an Orchestrator.
+func (plan *Plan) ProvenanceTo(path string) {
+	plan.provenancePath = path
+}
+
+// AttachProvenance attaches the provenance statement of every successful Execute to the images the plan pushed,
+// as an OCI referrer of each (artifact type "application/vnd.in-toto+json"), so it travels with them.
+// Registries without the referrers API are supported (referrers tag schema).
+func (plan *Plan) AttachProvenance(enabled bool) {
+	plan.attachProvenance = enabled
+}
+
+// provenanceEnabled reports whether the provenance of executions is written or attached.
+func (plan *Plan) provenanceEnabled() bool {
+	return plan.attachProvenance || plan.processEnv("QUARK_PROVENANCE", plan.provenancePath) != ""
+}
+
+// recordProvenance writes and attaches the provenance of the execution, where configured.
+func (plan *Plan) recordProvenance(ctx context.Context, env Environment) error {
+	if !plan.provenanceEnabled() {
+		return nil
+	}
+
+	statement, err := plan.provenance(env)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(statement, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode provenance: %w", err)
+	}
+
+	if path := plan.processEnv("QUARK_PROVENANCE", plan.provenancePath); path != "" {
+		if err := os.WriteFile(path, content, filesystem.FilePermissionsDefault); err != nil {
+			return fmt.Errorf("failed to write provenance: %w", err)
+		}
+
+		plan.log.Info().Str("path", path).Int("subjects", len(statement.Subject)).Msg("provenance written")
+	}
+
+	if !plan.attachProvenance {
+		return nil
+	}
+
+	blobs := []registry.ArtifactBlob{{MediaType: provenanceMediaType, Title: provenanceBlobTitle, Data: content}}
+	annotations := map[string]string{predicateTypeAnnotation: slsaProvenanceType}
+
+	for _, subject := range statement.Subject {
+		subjectRef := subject.Name + "@sha256:" + subject.Digest["sha256"]
+		domain, _, _ := strings.Cut(subject.Name, "/")
+
+		client := newRegistryClient(plan.getRegistry(domain), plan.log)
+
+		digest, err := client.AttachArtifact(ctx, subjectRef, provenanceMediaType, blobs, annotations)
+		if err != nil {
+			return fmt.Errorf("%w to %s: %w", ErrProvenanceAttach, subjectRef, err)
+		}
+
+		plan.log.Info().Str("image", subjectRef).Str("digest", digest).Msg("provenance attached")
+	}
+
+	return nil
+}
+
+// provenance describes the last execution: the images pushed by the operations that succeeded, and what they
+// were produced from.
+func (plan *Plan) provenance(env Environment) (*inTotoStatement, error) {
+	var subjects, dependencies []resourceDescriptor
+
+	seen := make(map[string]bool)
+
+	addDependency := func(dependency resourceDescriptor) {
+		key := dependency.Name + dependency.URI
+		for algorithm, value := range dependency.Digest {
+			key += algorithm + value
+		}
+
+		if !seen[key] {
+			seen[key] = true
+			dependencies = append(dependencies, dependency)
+		}
+	}
+
+	for _, op := range plan.operations {
+		entry, ok := plan.report.Operation(op.operationName())
+		if !ok || entry.Status != StatusSucceeded {
+			continue
+		}
+
+		var pushed *Image
+
+		switch typed := op.(type) {
+		case *Sync:
+			pushed = typed.destImage
+
+			if source, ok := imageDescriptor(typed.sourceImage, typed.sourceImage.Digest()); ok {
+				addDependency(source)
+			}
+		case *Import:
+			pushed = typed.destImage
+
+			if source, ok := imageDescriptor(typed.sourceImage, typed.sourceImage.Digest()); ok {
+				addDependency(source)
+			}
+		case *Build:
+			pushed = typed.output
+
+			dockerfile, err := fileDescriptor(filepath.Join(typed.context, typed.dockerfile))
+			if err != nil {
+				return nil, err
+			}
+
+			addDependency(dockerfile)
+		case *Artifact:
+			pushed = typed.image
+		}
+
+		if subject, ok := imageDescriptor(pushed, entry.Digest); ok {
+			subjects = append(subjects, subject)
+		}
+	}
+
+	internal := map[string]any{"environment": string(env)}
+	if plan.appliedProfile != "" {
+		internal["profile"] = plan.appliedProfile
+	}
+
+	return &inTotoStatement{
+		Type:          inTotoStatementType,
+		Subject:       subjects,
+		PredicateType: slsaProvenanceType,
+		Predicate: slsaProvenance{
+			BuildDefinition: provenanceBuildDefinition{
+				BuildType: provenanceBuildType,
+				ExternalParameters: map[string]any{
+					"plan":       plan.name,
+					"operations": plan.State().Operations,
+				},
+				InternalParameters:   internal,
+				ResolvedDependencies: dependencies,
+			},
+			RunDetails: provenanceRunDetails{
+				Builder: provenanceBuilder{
+					ID:      provenanceBuilderID,
+					Version: map[string]string{"quark": Version},
+				},
+				Metadata: provenanceMetadata{
+					InvocationID: invocationID(),
+					StartedOn:    plan.report.Started,
+					FinishedOn:   time.Now().UTC(),
+				},
+			},
+		},
+	}, nil
+}
+
+// imageDescriptor describes image at digest; images without (sha256) digest are not described.
+func imageDescriptor(image *Image, digest string) (resourceDescriptor, bool) {
+	hexDigest, ok := strings.CutPrefix(digest, "sha256:")
+	if image == nil || !ok || image.Protocol() != ProtocolRegistry {
+		return resourceDescriptor{}, false
+	}
+
+	return resourceDescriptor{
+		Name:   image.ref.Name(),
+		URI:    "oci://" + image.ref.Name(),
+		Digest: map[string]string{"sha256": hexDigest},
+	}, true
+}
+
+// fileDescriptor describes the file at path by the digest of its content.
+func fileDescriptor(path string) (resourceDescriptor, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return resourceDescriptor{}, fmt.Errorf("failed to read %s for provenance: %w", path, err)
+	}
+
+	sum := sha256.Sum256(content)
+
+	return resourceDescriptor{
+		Name:   filepath.ToSlash(path),
+		Digest: map[string]string{"sha256": hex.EncodeToString(sum[:])},
+	}, nil
+}
+
+// invocationID returns the URL of the CI run executing the plan (GitHub Actions, GitLab CI), if any.
+func invocationID() string {
+	if runID := os.Getenv("GITHUB_RUN_ID"); runID != "" {
+		invocation := os.Getenv("GITHUB_SERVER_URL") + "/" + os.Getenv("GITHUB_REPOSITORY") + "/actions/runs/" + runID
+		if attempt := os.Getenv("GITHUB_RUN_ATTEMPT"); attempt != "" {
+			invocation += "/attempts/" + attempt
+		}
+
+		return invocation
+	}
+
+	return os.Getenv("CI_JOB_URL")
+}
@@ -0,0 +1,124 @@
+package sdk_test
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: The provenance of an execution names the pushed images and their sources by digest, and is attached
+// to the pushed images, where it is found from their digest.
+func TestPlan_Provenance(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+	digest := pushRandomImage(t, host+"/source/app:1.0.0")
+
+	plan := sdk.NewPlan("provenance")
+
+	source, err := sdk.NewImage("source/app").Domain(host).Version("1.0.0").Digest(digest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create source image: %v", err)
+	}
+
+	release, err := sdk.NewImage("release/app").Domain(host).Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create release image: %v", err)
+	}
+
+	if _, err := plan.Sync("release").Source(source).Destination(release).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "provenance.json")
+	plan.ProvenanceTo(path)
+	plan.AttachProvenance(true)
+
+	if err := plan.Execute(t.Context()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read provenance: %v", err)
+	}
+
+	type descriptor struct {
+		Name   string            `json:"name"`
+		Digest map[string]string `json:"digest"`
+	}
+
+	var statement struct {
+		Type          string       `json:"_type"`
+		Subject       []descriptor `json:"subject"`
+		PredicateType string       `json:"predicateType"`
+		Predicate     struct {
+			BuildDefinition struct {
+				ResolvedDependencies []descriptor `json:"resolvedDependencies"`
+			} `json:"buildDefinition"`
+		} `json:"predicate"`
+	}
+
+	if err := json.Unmarshal(content, &statement); err != nil {
+		t.Fatalf("Failed to decode provenance: %v", err)
+	}
+
+	if statement.Type != "https://in-toto.io/Statement/v1" ||
+		statement.PredicateType != "https://slsa.dev/provenance/v1" {
+		t.Errorf("statement type = %q, predicate type = %q, want in-toto v1 with SLSA provenance v1",
+			statement.Type, statement.PredicateType)
+	}
+
+	// Syncs push the source image unchanged
+	hexDigest := strings.TrimPrefix(digest, "sha256:")
+
+	if len(statement.Subject) != 1 || statement.Subject[0].Name != host+"/release/app" ||
+		statement.Subject[0].Digest["sha256"] != hexDigest {
+		t.Errorf("subject = %+v, want %s/release/app at %s", statement.Subject, host, digest)
+	}
+
+	dependencies := statement.Predicate.BuildDefinition.ResolvedDependencies
+	if len(dependencies) != 1 || dependencies[0].Name != host+"/source/app" ||
+		dependencies[0].Digest["sha256"] != hexDigest {
+		t.Errorf("resolvedDependencies = %+v, want %s/source/app at %s", dependencies, host, digest)
+	}
+
+	subject, err := name.NewDigest(host + "/release/app@" + digest)
+	if err != nil {
+		t.Fatalf("Failed to parse subject reference: %v", err)
+	}
+
+	referrers, err := remote.Referrers(subject, remote.WithFilter("artifactType", "application/vnd.in-toto+json"))
+	if err != nil {
+		t.Fatalf("Referrers() error = %v", err)
+	}
+
+	manifest, err := referrers.IndexManifest()
+	if err != nil {
+		t.Fatalf("IndexManifest() error = %v", err)
+	}
+
+	if len(manifest.Manifests) != 1 {
+		t.Errorf("got %d provenance referrers, want 1", len(manifest.Manifests))
+	}
+}
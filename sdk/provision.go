@@ -0,0 +1,220 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/farcloser/quark/internal/provision"
+	"github.com/farcloser/quark/ssh"
+)
+
+// ProvisionNode represents installing build tooling on a build node,
+// so fresh build VMs don't require manual setup.
+type ProvisionNode struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	node          *BuildNode
+	installDocker bool
+	buildxVersion string
+	buildxSHA256  string
+	releaseURL    string
+
+	// sshPool is set by executor before execution
+	sshPool *ssh.Pool
+
+	// Results populated after execution
+	installed []string
+}
+
+// ProvisionNodeBuilder builds a ProvisionNode.
+type ProvisionNodeBuilder struct {
+	builderState
+
+	plan      *Plan
+	provision *ProvisionNode
+}
+
+// InstallDocker installs Docker with the distribution package manager when it is missing
+// (Debian, Ubuntu, Fedora, RHEL derivatives, Alpine, Arch), and makes sure the daemon is running.
+// Non-root SSH users need passwordless sudo; they are added to the docker group,
+// which takes effect on the next SSH connection to the node.
+func (builder *ProvisionNodeBuilder) InstallDocker(enabled bool) *ProvisionNodeBuilder {
+	builder.provision.installDocker = enabled
+
+	return builder
+}
+
+// InstallBuildx installs the given buildx release (e.g., "v0.19.3") as a system-wide docker CLI plugin,
+// unless that version is already installed.
+// The release is downloaded locally and uploaded to the node, so the node needs no internet access.
+// It is verified against the checksums published with the release, or against BuildxSHA256 when set.
+func (builder *ProvisionNodeBuilder) InstallBuildx(version string) *ProvisionNodeBuilder {
+	builder.provision.buildxVersion = version
+
+	return builder
+}
+
+// BuildxSHA256 pins the SHA-256 (hex) of the buildx binary for the node platform,
+// instead of trusting the checksums published with the release.
+func (builder *ProvisionNodeBuilder) BuildxSHA256(checksum string) *ProvisionNodeBuilder {
+	builder.provision.buildxSHA256 = checksum
+
+	return builder
+}
+
+// BuildxReleaseURL downloads buildx from a mirror of the GitHub releases
+// (assets are fetched from <url>/<version>/<asset>).
+func (builder *ProvisionNodeBuilder) BuildxReleaseURL(url string) *ProvisionNodeBuilder {
+	builder.provision.releaseURL = url
+
+	return builder
+}
+
+// RunOnlyOn restricts the provisioning to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *ProvisionNodeBuilder) RunOnlyOn(envs ...Environment) *ProvisionNodeBuilder {
+	builder.provision.runOnlyOn = append(builder.provision.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the provisioning mostly uses (default: sdk.ResourceRemote),
+// for executors bounding how many operations of each class run at once.
+func (builder *ProvisionNodeBuilder) Resource(resource Resource) *ProvisionNodeBuilder {
+	builder.provision.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the provisioning start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *ProvisionNodeBuilder) DependsOn(ops ...Dependency) *ProvisionNodeBuilder {
+	builder.provision.add(ops)
+
+	return builder
+}
+
+// When makes the provisioning run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the provisioning.
+func (builder *ProvisionNodeBuilder) When(conditions ...Condition) *ProvisionNodeBuilder {
+	builder.provision.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for the provisioning of node, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *ProvisionNodeBuilder) Clone(node *BuildNode) *ProvisionNodeBuilder {
+	clone := builder.plan.ProvisionNode(node)
+	clone.provision.envGuard = builder.provision.envGuard.clone()
+	clone.provision.resourceHint = builder.provision.resourceHint
+	clone.provision.dependencyList = builder.provision.dependencyList.clone()
+	clone.provision.conditionList = builder.provision.conditionList.clone()
+	clone.provision.installDocker = builder.provision.installDocker
+	clone.provision.buildxVersion = builder.provision.buildxVersion
+	clone.provision.buildxSHA256 = builder.provision.buildxSHA256
+	clone.provision.releaseURL = builder.provision.releaseURL
+
+	return clone
+}
+
+// Reset makes the builder usable again for the provisioning of node, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *ProvisionNodeBuilder) Reset(node *BuildNode) *ProvisionNodeBuilder {
+	*builder = *builder.Clone(node)
+
+	return builder
+}
+
+// Build validates and adds the provisioning to the plan.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *ProvisionNodeBuilder) Build() (*ProvisionNode, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.provision.opName); err != nil {
+		return nil, err
+	}
+
+	if builder.provision.node == nil {
+		return nil, ErrProvisionNodeRequired
+	}
+
+	if !builder.provision.installDocker && builder.provision.buildxVersion == "" {
+		return nil, fmt.Errorf("%w for node %q", ErrProvisionTaskRequired, builder.provision.node.Name())
+	}
+
+	if builder.provision.buildxSHA256 != "" && builder.provision.buildxVersion == "" {
+		return nil, ErrProvisionBuildxVersionRequired
+	}
+
+	builder.plan.provisions = append(builder.plan.provisions, builder.provision)
+	builder.plan.addOperation(builder.provision)
+
+	return builder.provision, nil
+}
+
+func (prov *ProvisionNode) execute(ctx context.Context) error {
+	node := prov.node
+
+	prov.log.Info().
+		Str("node", node.name).
+		Bool("docker", prov.installDocker).
+		Str("buildx", prov.buildxVersion).
+		Msg("provisioning build node")
+
+	sshClient, err := prov.sshPool.GetClient(node.endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to connect to build node: %w", err)
+	}
+
+	provisioner := provision.New(sshClient, prov.log)
+	if prov.releaseURL != "" {
+		provisioner.WithReleaseURL(prov.releaseURL)
+	}
+
+	if prov.installDocker {
+		installed, err := provisioner.EnsureDocker(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to provision docker on node %q: %w", node.name, err)
+		}
+
+		if installed {
+			prov.installed = append(prov.installed, "docker")
+		}
+	}
+
+	if prov.buildxVersion != "" {
+		// Platform is "linux/<arch>"
+		_, arch, _ := strings.Cut(node.platform.String(), "/")
+
+		installed, err := provisioner.EnsureBuildx(ctx, prov.buildxVersion, arch, prov.buildxSHA256)
+		if err != nil {
+			return fmt.Errorf("failed to provision buildx on node %q: %w", node.name, err)
+		}
+
+		if installed {
+			prov.installed = append(prov.installed, "buildx")
+		}
+	}
+
+	prov.log.Info().
+		Str("node", node.name).
+		Strs("installed", prov.installed).
+		Msg("build node provisioned")
+
+	return nil
+}
+
+// Installed returns the tools installed by the provisioning ("docker", "buildx"),
+// excluding tools that were already present (empty before execution).
+func (prov *ProvisionNode) Installed() []string {
+	return prov.installed
+}
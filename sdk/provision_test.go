@@ -0,0 +1,83 @@
+package sdk_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: ProvisionNode requires a node and at least one tool to install;
+// a pinned buildx checksum is meaningless without a buildx version.
+func TestProvisionNodeBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		build   func(*sdk.Plan, *sdk.BuildNode) (*sdk.ProvisionNode, error)
+		wantErr error
+	}{
+		{
+			name: "docker and buildx",
+			build: func(plan *sdk.Plan, node *sdk.BuildNode) (*sdk.ProvisionNode, error) {
+				return plan.ProvisionNode(node).InstallDocker(true).InstallBuildx("v0.19.3").Build()
+			},
+		},
+		{
+			name: "pinned buildx",
+			build: func(plan *sdk.Plan, node *sdk.BuildNode) (*sdk.ProvisionNode, error) {
+				return plan.ProvisionNode(node).
+					InstallBuildx("v0.19.3").
+					BuildxSHA256("a1b2c3").
+					BuildxReleaseURL("https://mirror.internal/buildx").
+					Build()
+			},
+		},
+		{
+			name: "missing node",
+			build: func(plan *sdk.Plan, _ *sdk.BuildNode) (*sdk.ProvisionNode, error) {
+				return plan.ProvisionNode(nil).InstallDocker(true).Build()
+			},
+			wantErr: sdk.ErrProvisionNodeRequired,
+		},
+		{
+			name: "nothing to install",
+			build: func(plan *sdk.Plan, node *sdk.BuildNode) (*sdk.ProvisionNode, error) {
+				return plan.ProvisionNode(node).InstallDocker(false).Build()
+			},
+			wantErr: sdk.ErrProvisionTaskRequired,
+		},
+		{
+			name: "checksum without buildx version",
+			build: func(plan *sdk.Plan, node *sdk.BuildNode) (*sdk.ProvisionNode, error) {
+				return plan.ProvisionNode(node).InstallDocker(true).BuildxSHA256("a1b2c3").Build()
+			},
+			wantErr: sdk.ErrProvisionBuildxVersionRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			plan := sdk.NewPlan(testPlanName)
+
+			node, err := plan.BuildNode("test-node").
+				Endpoint("build-amd64.example.com").
+				Platform(sdk.PlatformAMD64).
+				Build()
+			if err != nil {
+				t.Fatalf("Failed to create test build node: %v", err)
+			}
+
+			provision, err := tt.build(plan, node)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && provision == nil {
+				t.Error("Build() returned nil provision with nil error")
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/farcloser/quark/internal/retention"
+)
+
+// RetentionPolicy decides which manifests of a repository a Prune keeps. Manifests referenced by a kept tag are
+// kept with all their tags, and so are the platform manifests of a kept manifest list; the others (older versions,
+// tags matching DropTags) are left without a kept tag, and are deleted once older than DropUntaggedAfter. Tags that
+// are neither versions nor dropped (e.g., "latest", "main") keep their manifests.
+type RetentionPolicy struct {
+	// KeepLastPerMinor keeps the newest version tags of each minor version (e.g., 1.4.9 and 1.4.8 of 1.4 with 2),
+	// variants apart (1.4.9-alpine is a 1.4-alpine version).
+	KeepLastPerMinor int
+	// KeepTags keeps the manifests referenced by tags matching these patterns (e.g., "stable", "latest", "*-lts"),
+	// whatever their other tags.
+	KeepTags []string
+	// DropTags gives up the tags matching these patterns, which are not versions (e.g., "pr-*" for pull request
+	// builds).
+	DropTags []string
+	// DropUntaggedAfter is how old manifests without a kept tag must be to be deleted (any age when zero), from
+	// their image creation time. Manifests whose creation time is unknown or the reproducible epoch
+	// (1970-01-01, see BuildBuilder.Timestamp) are kept.
+	DropUntaggedAfter time.Duration
+}
+
+// Prune represents deleting the manifests of a repository its retention policy does not keep.
+type Prune struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	image    *Image
+	registry *Registry
+	policy   RetentionPolicy
+
+	// Results populated after execution
+	deleted []string
+	kept    int
+}
+
+// PruneBuilder builds a Prune.
+type PruneBuilder struct {
+	builderState
+
+	plan  *Plan
+	prune *Prune
+}
+
+// Repository sets the repository to prune (e.g., an image of it, without version).
+// Registry credentials are looked up from the plan's registry collection using the image domain.
+func (builder *PruneBuilder) Repository(image *Image) *PruneBuilder {
+	builder.prune.image = image
+	builder.prune.registry = builder.plan.getRegistry(image.Domain())
+
+	return builder
+}
+
+// Policy sets the retention policy of the repository.
+func (builder *PruneBuilder) Policy(policy RetentionPolicy) *PruneBuilder {
+	builder.prune.policy = policy
+
+	return builder
+}
+
+// RunOnlyOn restricts the prune to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *PruneBuilder) RunOnlyOn(envs ...Environment) *PruneBuilder {
+	builder.prune.runOnlyOn = append(builder.prune.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the prune mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *PruneBuilder) Resource(resource Resource) *PruneBuilder {
+	builder.prune.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the prune start only once the given operations (e.g., the syncs pushing new versions), built
+// before it in the plan, completed (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *PruneBuilder) DependsOn(ops ...Dependency) *PruneBuilder {
+	builder.prune.add(ops)
+
+	return builder
+}
+
+// When makes the prune run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the prune.
+func (builder *PruneBuilder) When(conditions ...Condition) *PruneBuilder {
+	builder.prune.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a prune named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *PruneBuilder) Clone(name string) *PruneBuilder {
+	clone := builder.plan.Prune(name)
+	clone.prune.envGuard = builder.prune.envGuard.clone()
+	clone.prune.resourceHint = builder.prune.resourceHint
+	clone.prune.dependencyList = builder.prune.dependencyList.clone()
+	clone.prune.conditionList = builder.prune.conditionList.clone()
+	clone.prune.image = builder.prune.image
+	clone.prune.registry = builder.prune.registry
+	clone.prune.policy = builder.prune.policy
+	clone.prune.policy.KeepTags = slices.Clone(builder.prune.policy.KeepTags)
+	clone.prune.policy.DropTags = slices.Clone(builder.prune.policy.DropTags)
+
+	return clone
+}
+
+// Reset makes the builder usable again for a prune named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *PruneBuilder) Reset(name string) *PruneBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build validates and adds the prune to the plan.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *PruneBuilder) Build() (*Prune, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.prune.opName); err != nil {
+		return nil, err
+	}
+
+	prune := builder.prune
+
+	if prune.image == nil {
+		return nil, ErrPruneRepositoryRequired
+	}
+
+	if err := prune.image.checkRegistry(); err != nil {
+		return nil, err
+	}
+
+	policy := prune.policy
+
+	// A policy keeping nothing would empty the repository
+	if policy.KeepLastPerMinor <= 0 && len(policy.KeepTags) == 0 {
+		return nil, fmt.Errorf("%w: keep the last versions per minor version, or tags", ErrInvalidRetentionPolicy)
+	}
+
+	if policy.KeepLastPerMinor < 0 || policy.DropUntaggedAfter < 0 {
+		return nil, fmt.Errorf("%w: negative count or age", ErrInvalidRetentionPolicy)
+	}
+
+	for _, pattern := range slices.Concat(policy.KeepTags, policy.DropTags) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%w: tag pattern %q: %w", ErrInvalidRetentionPolicy, pattern, err)
+		}
+	}
+
+	builder.plan.prunes = append(builder.plan.prunes, prune)
+	builder.plan.addOperation(prune)
+
+	return prune, nil
+}
+
+// evaluate lists the manifests of the repository and returns those the policy deletes, with the number kept.
+func (prune *Prune) evaluate(ctx context.Context) ([]retention.Manifest, int, error) {
+	repository := prune.image.ref.Name()
+	client := newRegistryClient(prune.registry, prune.log)
+
+	tags, err := client.ListTags(ctx, repository)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list tags of %s: %w", repository, err)
+	}
+
+	var manifests []retention.Manifest
+
+	index := map[string]int{}
+
+	for _, tag := range tags {
+		summary, err := client.SummarizeManifest(ctx, repository+":"+tag)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read %s:%s: %w", repository, tag, err)
+		}
+
+		idx, seen := index[summary.Digest]
+		if !seen {
+			idx = len(manifests)
+			index[summary.Digest] = idx
+			manifests = append(manifests, retention.Manifest{
+				Digest:   summary.Digest,
+				Created:  summary.Created,
+				Children: summary.Children,
+			})
+		}
+
+		manifests[idx].Tags = append(manifests[idx].Tags, tag)
+	}
+
+	keep, drop := retention.Policy{
+		KeepPerMinor: prune.policy.KeepLastPerMinor,
+		KeepTags:     prune.policy.KeepTags,
+		DropTags:     prune.policy.DropTags,
+		UntaggedAge:  prune.policy.DropUntaggedAfter,
+	}.Evaluate(manifests, time.Now())
+
+	return drop, len(keep), nil
+}
+
+func (prune *Prune) execute(ctx context.Context) error {
+	repository := prune.image.ref.Name()
+
+	prune.log.Info().Str("repository", repository).Msg("pruning repository")
+
+	drop, kept, err := prune.evaluate(ctx)
+	if err != nil {
+		return err
+	}
+
+	client := newRegistryClient(prune.registry, prune.log)
+
+	prune.deleted = nil
+	prune.kept = kept
+
+	for _, manifest := range drop {
+		if err := client.DeleteManifest(ctx, repository+"@"+manifest.Digest, manifest.Tags...); err != nil {
+			return fmt.Errorf("failed to delete %s@%s (%s): %w", repository, manifest.Digest,
+				strings.Join(manifest.Tags, ", "), err)
+		}
+
+		prune.deleted = append(prune.deleted, manifest.Digest)
+
+		prune.log.Info().
+			Str("digest", manifest.Digest).
+			Strs("tags", manifest.Tags).
+			Msg("manifest deleted")
+	}
+
+	prune.log.Info().
+		Str("repository", repository).
+		Int("deleted", len(prune.deleted)).
+		Int("kept", kept).
+		Msg("repository pruned")
+
+	return nil
+}
+
+// plannedChanges implements dryRunOperation: the manifests the policy would delete.
+func (prune *Prune) plannedChanges(ctx context.Context) ([]string, error) {
+	drop, kept, err := prune.evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repository := prune.image.ref.Name()
+
+	if len(drop) == 0 {
+		return []string{fmt.Sprintf("Would keep the %d manifests of %s", kept, repository)}, nil
+	}
+
+	changes := make([]string, 0, len(drop))
+	for _, manifest := range drop {
+		changes = append(changes, fmt.Sprintf("Would delete %s@%s (%s)", repository, manifest.Digest,
+			strings.Join(manifest.Tags, ", ")))
+	}
+
+	return changes, nil
+}
+
+// destructiveChange implements destructiveOperation: a prune deletes the manifests its policy does not keep.
+func (prune *Prune) destructiveChange(ctx context.Context) (string, error) {
+	drop, _, err := prune.evaluate(ctx)
+	if err != nil || len(drop) == 0 {
+		return "", err
+	}
+
+	var tags []string
+	for _, manifest := range drop {
+		tags = append(tags, manifest.Tags...)
+	}
+
+	return fmt.Sprintf("delete %d manifests of %s (tags %s)", len(drop), prune.image.ref.Name(),
+		strings.Join(tags, ", ")), nil
+}
+
+// Deleted returns the digests of the manifests deleted by the prune (empty before execution).
+func (prune *Prune) Deleted() []string {
+	return prune.deleted
+}
+
+// Kept returns the number of manifests the prune kept (zero before execution).
+func (prune *Prune) Kept() int {
+	return prune.kept
+}
+
+// destination implements destinationOperation: the pruned repository.
+func (prune *Prune) destination() *Image {
+	return prune.image
+}
+
+// resolveRegistries implements registryOperation.
+func (prune *Prune) resolveRegistries(lookup func(image *Image) *Registry) {
+	prune.registry = lookup(prune.image)
+}
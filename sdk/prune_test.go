@@ -0,0 +1,232 @@
+package sdk_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// pushCreatedImage pushes a random image created at created to each of refs, and returns its digest.
+func pushCreatedImage(t *testing.T, created time.Time, refs ...string) string {
+	t.Helper()
+
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatalf("Failed to create random image: %v", err)
+	}
+
+	img, err = mutate.CreatedAt(img, v1.Time{Time: created})
+	if err != nil {
+		t.Fatalf("Failed to set image creation time: %v", err)
+	}
+
+	for _, ref := range refs {
+		parsed, err := name.ParseReference(ref)
+		if err != nil {
+			t.Fatalf("Failed to parse reference: %v", err)
+		}
+
+		if err := remote.Write(parsed, img); err != nil {
+			t.Fatalf("Failed to push image: %v", err)
+		}
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatalf("Failed to get image digest: %v", err)
+	}
+
+	return digest.String()
+}
+
+// INTENTION: A prune deletes the manifests its policy does not keep: older versions of a minor version go, while
+// versions referenced by a protected tag and manifests younger than the untagged age stay. A dry run deletes
+// nothing.
+func TestPrune_Execute(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	repository := host + "/my-org/app"
+	old := time.Now().Add(-90 * 24 * time.Hour)
+
+	stable := pushCreatedImage(t, old, repository+":1.0.0", repository+":stable")
+	outdated := pushCreatedImage(t, old, repository+":1.0.1")
+	latest := pushCreatedImage(t, old, repository+":1.0.2")
+	branch := pushCreatedImage(t, time.Now(), repository+":main")
+
+	policy := sdk.RetentionPolicy{KeepLastPerMinor: 1, KeepTags: []string{"stable"}, DropUntaggedAfter: 720 * time.Hour}
+
+	execute := func(dryRun bool) *sdk.Prune {
+		t.Helper()
+
+		image, err := sdk.NewImage("my-org/app").Domain(host).Build()
+		if err != nil {
+			t.Fatalf("Failed to create test image: %v", err)
+		}
+
+		plan := sdk.NewPlan(testPlanName)
+
+		prune, err := plan.Prune("prune-app").Repository(image).Policy(policy).Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		run := plan.Execute
+		if dryRun {
+			run = plan.DryRun
+		}
+
+		if err := run(t.Context()); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+
+		return prune
+	}
+
+	if prune := execute(true); len(prune.Deleted()) != 0 {
+		t.Fatalf("dry run Deleted() = %v, want nothing", prune.Deleted())
+	}
+
+	prune := execute(false)
+	if !slices.Equal(prune.Deleted(), []string{outdated}) || prune.Kept() != 3 {
+		t.Fatalf("Deleted() = %v, Kept() = %d, want only %s deleted", prune.Deleted(), prune.Kept(), outdated)
+	}
+
+	for digest, wantExists := range map[string]bool{stable: true, outdated: false, latest: true, branch: true} {
+		ref, err := name.NewDigest(repository + "@" + digest)
+		if err != nil {
+			t.Fatalf("Failed to parse reference: %v", err)
+		}
+
+		if _, err := remote.Head(ref); (err == nil) != wantExists {
+			t.Errorf("%s exists = %v, want %v", digest, err == nil, wantExists)
+		}
+	}
+}
+
+// INTENTION: A prune only deletes what its policy gives up: the platform manifest of a kept manifest list stays
+// although its per-platform version tag is outdated, tags other than versions keep their manifests, and images
+// dated at the reproducible epoch are not too old.
+func TestPrune_Execute_Keeps(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	repository := host + "/my-org/app"
+	old := time.Now().Add(-90 * 24 * time.Hour)
+
+	platform := pushCreatedImage(t, old, repository+":1.0.0-amd64")
+	pushCreatedImage(t, old, repository+":1.0.1-amd64")
+	pushCreatedImage(t, old, repository+":latest")
+	pushCreatedImage(t, time.Unix(0, 0), repository+":2.0.0")
+	outdated := pushCreatedImage(t, old, repository+":2.0.1")
+	pushCreatedImage(t, old, repository+":2.0.2")
+
+	platformRef, err := name.NewDigest(repository + "@" + platform)
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+
+	img, err := remote.Image(platformRef)
+	if err != nil {
+		t.Fatalf("Failed to fetch platform image: %v", err)
+	}
+
+	index := mutate.AppendManifests(empty.Index, mutate.IndexAddendum{
+		Add:        img,
+		Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}},
+	})
+
+	indexRef, err := name.ParseReference(repository + ":1.0.0")
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+
+	if err := remote.WriteIndex(indexRef, index); err != nil {
+		t.Fatalf("Failed to push index: %v", err)
+	}
+
+	image, err := sdk.NewImage("my-org/app").Domain(host).Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	plan := sdk.NewPlan(testPlanName)
+
+	policy := sdk.RetentionPolicy{KeepLastPerMinor: 1, DropUntaggedAfter: 720 * time.Hour}
+
+	prune, err := plan.Prune("prune-app").Repository(image).Policy(policy).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if err := plan.Execute(t.Context()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if !slices.Equal(prune.Deleted(), []string{outdated}) || prune.Kept() != 6 {
+		t.Errorf("Deleted() = %v, Kept() = %d, want only %s deleted", prune.Deleted(), prune.Kept(), outdated)
+	}
+
+	if _, err := remote.Head(platformRef); err != nil {
+		t.Errorf("platform manifest of the kept index deleted: %v", err)
+	}
+}
+
+// INTENTION: A prune needs a repository and a policy keeping something, so a misconfigured policy cannot empty a
+// repository.
+func TestPruneBuilder_Validation(t *testing.T) {
+	t.Parallel()
+
+	image, err := sdk.NewImage("ghcr.io/my-org/app").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		image   *sdk.Image
+		policy  sdk.RetentionPolicy
+		wantErr error
+	}{
+		{"no repository", nil, sdk.RetentionPolicy{KeepLastPerMinor: 3}, sdk.ErrPruneRepositoryRequired},
+		{"keeps nothing", image, sdk.RetentionPolicy{DropUntaggedAfter: time.Hour}, sdk.ErrInvalidRetentionPolicy},
+		{"bad pattern", image, sdk.RetentionPolicy{KeepTags: []string{"["}}, sdk.ErrInvalidRetentionPolicy},
+		{"valid", image, sdk.RetentionPolicy{KeepTags: []string{"stable"}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			builder := sdk.NewPlan(testPlanName).Prune("prune").Policy(tt.policy)
+			if tt.image != nil {
+				builder.Repository(tt.image)
+			}
+
+			if _, err := builder.Build(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Build() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/farcloser/quark/internal/registry"
+)
+
+// Rebase represents moving the application layers of an image from its base image onto a patched base image,
+// without rebuilding it (crane rebase): base image vulnerabilities are fixed in every application at the cost of
+// a few manifest pushes.
+type Rebase struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	image           *Image
+	registry        *Registry
+	oldBase         *Image
+	oldBaseRegistry *Registry
+	newBase         *Image
+	newBaseRegistry *Registry
+	destImage       *Image
+	destRegistry    *Registry
+
+	// Results populated after execution
+	digest  string
+	rebased bool
+}
+
+// RebaseBuilder builds a Rebase.
+type RebaseBuilder struct {
+	builderState
+
+	plan   *Plan
+	rebase *Rebase
+}
+
+// Image sets the application image to rebase (e.g., ghcr.io/my-org/app:1.0.0), by tag or digest. When it is the
+// output of another operation (see Build.OutputImage), the rebase depends on it.
+// Registry credentials are looked up from the plan's registry collection using the image domain.
+func (builder *RebaseBuilder) Image(image *Image) *RebaseBuilder {
+	builder.rebase.image = image
+	builder.rebase.consume(image)
+	builder.rebase.registry = builder.plan.getRegistry(image.Domain())
+
+	return builder
+}
+
+// OldBase sets the base image the application was built on, typically pinned by digest (e.g.,
+// alpine:3.20@sha256:...): its tag usually points at the patched base already.
+// Multi-platform base images provide the base of each platform of the application.
+func (builder *RebaseBuilder) OldBase(image *Image) *RebaseBuilder {
+	builder.rebase.oldBase = image
+	builder.rebase.consume(image)
+	builder.rebase.oldBaseRegistry = builder.plan.getRegistry(image.Domain())
+
+	return builder
+}
+
+// NewBase sets the patched base image the application layers are moved onto (e.g., alpine:3.20).
+func (builder *RebaseBuilder) NewBase(image *Image) *RebaseBuilder {
+	builder.rebase.newBase = image
+	builder.rebase.consume(image)
+	builder.rebase.newBaseRegistry = builder.plan.getRegistry(image.Domain())
+
+	return builder
+}
+
+// Destination sets the image the rebased image is pushed to (default: the tag of Image, which is moved).
+// The destination must have a version.
+func (builder *RebaseBuilder) Destination(image *Image) *RebaseBuilder {
+	builder.rebase.destImage = image
+	builder.rebase.destRegistry = builder.plan.getRegistry(image.Domain())
+
+	return builder
+}
+
+// RunOnlyOn restricts the rebase to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *RebaseBuilder) RunOnlyOn(envs ...Environment) *RebaseBuilder {
+	builder.rebase.runOnlyOn = append(builder.rebase.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the rebase mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *RebaseBuilder) Resource(resource Resource) *RebaseBuilder {
+	builder.rebase.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the rebase start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *RebaseBuilder) DependsOn(ops ...Dependency) *RebaseBuilder {
+	builder.rebase.add(ops)
+
+	return builder
+}
+
+// When makes the rebase run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the rebase.
+func (builder *RebaseBuilder) When(conditions ...Condition) *RebaseBuilder {
+	builder.rebase.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a rebase named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *RebaseBuilder) Clone(name string) *RebaseBuilder {
+	clone := builder.plan.Rebase(name)
+	clone.rebase.envGuard = builder.rebase.envGuard.clone()
+	clone.rebase.resourceHint = builder.rebase.resourceHint
+	clone.rebase.dependencyList = builder.rebase.dependencyList.clone()
+	clone.rebase.conditionList = builder.rebase.conditionList.clone()
+	clone.rebase.image = builder.rebase.image
+	clone.rebase.registry = builder.rebase.registry
+	clone.rebase.oldBase = builder.rebase.oldBase
+	clone.rebase.oldBaseRegistry = builder.rebase.oldBaseRegistry
+	clone.rebase.newBase = builder.rebase.newBase
+	clone.rebase.newBaseRegistry = builder.rebase.newBaseRegistry
+	clone.rebase.destImage = builder.rebase.destImage
+	clone.rebase.destRegistry = builder.rebase.destRegistry
+
+	return clone
+}
+
+// Reset makes the builder usable again for a rebase named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *RebaseBuilder) Reset(name string) *RebaseBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build validates and adds the rebase to the plan.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *RebaseBuilder) Build() (*Rebase, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.rebase.opName); err != nil {
+		return nil, err
+	}
+
+	rebase := builder.rebase
+
+	if rebase.image == nil {
+		return nil, ErrRebaseImageRequired
+	}
+
+	if rebase.oldBase == nil || rebase.newBase == nil {
+		return nil, ErrRebaseBaseRequired
+	}
+
+	for _, image := range []*Image{rebase.image, rebase.oldBase, rebase.newBase, rebase.destination()} {
+		if err := image.checkRegistry(); err != nil {
+			return nil, err
+		}
+	}
+
+	if rebase.destination().Version() == "" {
+		return nil, fmt.Errorf("%w for image %q", ErrRebaseVersionRequired, rebase.destination().Name())
+	}
+
+	builder.plan.rebases = append(builder.plan.rebases, rebase)
+	builder.plan.addOperation(rebase)
+
+	return rebase, nil
+}
+
+// destination returns the image the rebased image is pushed to.
+func (rebase *Rebase) destination() *Image {
+	if rebase.destImage != nil {
+		return rebase.destImage
+	}
+
+	return rebase.image
+}
+
+// destinationRegistry returns the registry of the destination.
+func (rebase *Rebase) destinationRegistry() *Registry {
+	if rebase.destImage != nil {
+		return rebase.destRegistry
+	}
+
+	return rebase.registry
+}
+
+// remoteImage returns the registry image of image, read with the client of reg.
+func (rebase *Rebase) remoteImage(image *Image, reg *Registry) (registry.RemoteImage, error) {
+	ref, err := image.pullRef()
+	if err != nil {
+		return registry.RemoteImage{}, err
+	}
+
+	return registry.RemoteImage{Ref: ref, Client: newRegistryClient(reg, rebase.log)}, nil
+}
+
+func (rebase *Rebase) execute(ctx context.Context) error {
+	image, err := rebase.remoteImage(rebase.image, rebase.registry)
+	if err != nil {
+		return fmt.Errorf("failed to build image reference: %w", err)
+	}
+
+	oldBase, err := rebase.remoteImage(rebase.oldBase, rebase.oldBaseRegistry)
+	if err != nil {
+		return fmt.Errorf("failed to build old base reference: %w", err)
+	}
+
+	newBase, err := rebase.remoteImage(rebase.newBase, rebase.newBaseRegistry)
+	if err != nil {
+		return fmt.Errorf("failed to build new base reference: %w", err)
+	}
+
+	destRef, err := rebase.destination().tagRef()
+	if err != nil {
+		return fmt.Errorf("failed to build destination reference: %w", err)
+	}
+
+	rebase.log.Info().
+		Str("image", image.Ref).
+		Str("old_base", oldBase.Ref).
+		Str("new_base", newBase.Ref).
+		Str("destination", destRef).
+		Msg("rebasing image")
+
+	previous, err := image.Client.GetDigest(ctx, image.Ref)
+	if err != nil {
+		return fmt.Errorf("failed to get image digest: %w", err)
+	}
+
+	rebased, err := newRegistryClient(rebase.destinationRegistry(), rebase.log).
+		Rebase(ctx, image, oldBase, newBase, destRef)
+	if err != nil {
+		return fmt.Errorf("failed to rebase %s: %w", image.Ref, err)
+	}
+
+	rebase.digest = rebased
+	rebase.rebased = rebased != previous
+	// Subsequent operations (e.g., scanning) see the rebased image
+	rebase.destination().ref.Digest = digest.Digest(rebased)
+
+	rebase.log.Info().
+		Str("destination", destRef).
+		Str("digest", rebased).
+		Bool("rebased", rebase.rebased).
+		Msg("rebase complete")
+
+	return nil
+}
+
+// plannedChanges implements dryRunOperation: the image and both base images must exist, when their digest is
+// known.
+func (rebase *Rebase) plannedChanges(ctx context.Context) ([]string, error) {
+	imageRef, err := checkImage(ctx, newRegistryClient(rebase.registry, rebase.log), rebase.image)
+	if err != nil {
+		return nil, err
+	}
+
+	oldBaseRef, err := checkImage(ctx, newRegistryClient(rebase.oldBaseRegistry, rebase.log), rebase.oldBase)
+	if err != nil {
+		return nil, err
+	}
+
+	newBaseRef, err := checkImage(ctx, newRegistryClient(rebase.newBaseRegistry, rebase.log), rebase.newBase)
+	if err != nil {
+		return nil, err
+	}
+
+	destRef, err := rebase.destination().tagRef()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build destination reference: %w", err)
+	}
+
+	return []string{fmt.Sprintf("Would rebase %s from %s onto %s and push it to %s", imageRef, oldBaseRef,
+		newBaseRef, destRef)}, nil
+}
+
+// destructiveChange implements destructiveOperation: a rebase overwrites the destination tag, the image tag
+// by default.
+func (rebase *Rebase) destructiveChange(ctx context.Context) (string, error) {
+	destRef, err := rebase.destination().tagRef()
+	if err != nil {
+		return "", fmt.Errorf("failed to build destination reference: %w", err)
+	}
+
+	return tagOverwrite(ctx, newRegistryClient(rebase.destinationRegistry(), rebase.log), destRef, "")
+}
+
+// Digest returns the digest of the rebased image (empty before execution).
+func (rebase *Rebase) Digest() string {
+	return rebase.digest
+}
+
+// Rebased reports whether the execution pushed a rebased image (false when the image was already based on the
+// new base image).
+func (rebase *Rebase) Rebased() bool {
+	return rebase.rebased
+}
+
+// cacheSources implements cacheableOperation: a rebase reads the image and both base images.
+func (rebase *Rebase) cacheSources() []*Image {
+	return []*Image{rebase.image, rebase.oldBase, rebase.newBase}
+}
+
+// producedDigest implements producingOperation.
+func (rebase *Rebase) producedDigest() string {
+	return rebase.Digest()
+}
+
+// resolveRegistries implements registryOperation.
+func (rebase *Rebase) resolveRegistries(lookup func(image *Image) *Registry) {
+	rebase.registry = lookup(rebase.image)
+	rebase.oldBaseRegistry = lookup(rebase.oldBase)
+	rebase.newBaseRegistry = lookup(rebase.newBase)
+	rebase.destRegistry = lookup(rebase.destImage)
+}
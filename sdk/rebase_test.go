@@ -0,0 +1,250 @@
+package sdk_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: A rebase requires the application image and both base images, and a tag to push the rebased image
+// to (the image tag by default).
+func TestRebaseBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	app, err := sdk.NewImage("my-org/app").Domain("ghcr.io").Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	pinned, err := sdk.NewImage("my-org/app").Domain("ghcr.io").Digest(testDigest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	base, err := sdk.NewImage("alpine").Version("3.20").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		build   func(*sdk.Plan) (*sdk.Rebase, error)
+		wantErr error
+	}{
+		{
+			name: "in place",
+			build: func(plan *sdk.Plan) (*sdk.Rebase, error) {
+				return plan.Rebase("rebase").Image(app).OldBase(base).NewBase(base).Build()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "pinned image to a destination",
+			build: func(plan *sdk.Plan) (*sdk.Rebase, error) {
+				return plan.Rebase("rebase").Image(pinned).OldBase(base).NewBase(base).Destination(app).Build()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "missing image",
+			build: func(plan *sdk.Plan) (*sdk.Rebase, error) {
+				return plan.Rebase("rebase").OldBase(base).NewBase(base).Build()
+			},
+			wantErr: sdk.ErrRebaseImageRequired,
+		},
+		{
+			name: "missing new base",
+			build: func(plan *sdk.Plan) (*sdk.Rebase, error) {
+				return plan.Rebase("rebase").Image(app).OldBase(base).Build()
+			},
+			wantErr: sdk.ErrRebaseBaseRequired,
+		},
+		{
+			name: "pinned image in place",
+			build: func(plan *sdk.Plan) (*sdk.Rebase, error) {
+				return plan.Rebase("rebase").Image(pinned).OldBase(base).NewBase(base).Build()
+			},
+			wantErr: sdk.ErrRebaseVersionRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rebase, err := tt.build(sdk.NewPlan(testPlanName))
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil || rebase == nil {
+				t.Errorf("Build() = %v, %v, want a rebase", rebase, err)
+			}
+		})
+	}
+}
+
+// INTENTION: Executing a rebase moves the image tag to the application layers on top of the new base, and
+// updates the image digest for subsequent operations; a second run finds the image already rebased.
+func TestRebase_Execute(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	oldDigest := pushRandomImage(t, host+"/library/base:old")
+	pushRandomImage(t, host+"/library/base:new")
+
+	oldRef, err := name.ParseReference(host + "/library/base@" + oldDigest)
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+
+	oldBase, err := remote.Image(oldRef)
+	if err != nil {
+		t.Fatalf("Failed to get old base: %v", err)
+	}
+
+	layer, err := random.Layer(256, "application/vnd.docker.image.rootfs.diff.tar.gzip")
+	if err != nil {
+		t.Fatalf("Failed to create layer: %v", err)
+	}
+
+	appImage, err := mutate.AppendLayers(oldBase, layer)
+	if err != nil {
+		t.Fatalf("Failed to append layer: %v", err)
+	}
+
+	appRef, err := name.ParseReference(host + "/my-org/app:1.0.0")
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+
+	if err := remote.Write(appRef, appImage); err != nil {
+		t.Fatalf("Failed to push image: %v", err)
+	}
+
+	app, err := sdk.NewImage("my-org/app").Domain(host).Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	oldImage, err := sdk.NewImage("library/base").Domain(host).Version("old").Digest(oldDigest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	newImage, err := sdk.NewImage("library/base").Domain(host).Version("new").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	var rebased string
+
+	for run := range 2 {
+		plan := sdk.NewPlan(testPlanName)
+
+		rebase, err := plan.Rebase("rebase").Image(app).OldBase(oldImage).NewBase(newImage).Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		if err := plan.Execute(t.Context()); err != nil {
+			t.Fatalf("run %d: Execute() error = %v", run, err)
+		}
+
+		if rebase.Rebased() != (run == 0) || app.Digest() != rebase.Digest() {
+			t.Errorf("run %d: Rebased() = %v, Digest() = %q, image digest = %q", run, rebase.Rebased(),
+				rebase.Digest(), app.Digest())
+		}
+
+		if run == 0 {
+			rebased = rebase.Digest()
+		} else if rebase.Digest() != rebased {
+			t.Errorf("run %d: Digest() = %q, want unchanged %q", run, rebase.Digest(), rebased)
+		}
+	}
+
+	img, err := remote.Image(appRef)
+	if err != nil {
+		t.Fatalf("Failed to get rebased image: %v", err)
+	}
+
+	layers, err := img.Layers()
+	if err != nil || len(layers) != 2 {
+		t.Fatalf("Layers() = %d, %v, want the new base layer and the application layer", len(layers), err)
+	}
+
+	newRef, err := name.ParseReference(host + "/library/base:new")
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+
+	newBase, err := remote.Image(newRef)
+	if err != nil {
+		t.Fatalf("Failed to get new base: %v", err)
+	}
+
+	baseLayers, err := newBase.Layers()
+	if err != nil {
+		t.Fatalf("Failed to get new base layers: %v", err)
+	}
+
+	got, _ := layers[0].Digest()
+	want, _ := baseLayers[0].Digest()
+
+	top, _ := layers[1].Digest()
+	appLayer, _ := layer.Digest()
+
+	if got != want || top != appLayer {
+		t.Errorf("layers = [%s %s], want [%s %s]", got, top, want, appLayer)
+	}
+}
+
+// INTENTION: Rebasing the output of a build makes the rebase depend on the build.
+func TestRebaseBuilder_ConsumesBuildOutput(t *testing.T) {
+	t.Parallel()
+
+	plan := sdk.NewPlan(testPlanName)
+
+	node, err := plan.BuildNode("node").Endpoint("ssh://builder@192.168.1.100").Platform(sdk.PlatformAMD64).Build()
+	if err != nil {
+		t.Fatalf("Failed to create test node: %v", err)
+	}
+
+	build, err := plan.Build("build-app").Context("/path/to/context").Node(node).Tag("ghcr.io/my-org/app:1.0.0").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	base, err := sdk.NewImage("alpine").Version("3.20").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	if _, err := plan.Rebase("rebase").Image(build.OutputImage()).OldBase(base).NewBase(base).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if got := plan.State().Operations[1].Settings["depends on"]; got != "build-app" {
+		t.Errorf("depends on = %q, want the build", got)
+	}
+}
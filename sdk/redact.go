@@ -0,0 +1,29 @@
+package sdk
+
+import (
+	"io"
+
+	"github.com/farcloser/quark/internal/redact"
+)
+
+// secretValues are the secrets masked in logs and errors: registry passwords and tokens, API tokens, webhook
+// secrets, and values read with GetSecret. Process-wide, as GetSecret is called before plans exist.
+//
+//nolint:gochecknoglobals // Registered by package functions (GetSecret) and builders of every plan
+var secretValues = &redact.Secrets{}
+
+// RegisterSecret registers values (e.g., tokens read from the environment) to mask as "<redacted>" in the log
+// output of ConfigureDefaultLogger and operation log files, in execution errors and in reports. Registry
+// passwords and tokens, credentials passed to builders, and values read with GetSecret are registered
+// automatically. Values shorter than 4 characters are ignored.
+func RegisterSecret(values ...string) {
+	secretValues.Add(values...)
+}
+
+// RedactingWriter returns a writer masking the registered secrets in what it writes to out, for loggers not
+// configured by ConfigureDefaultLogger (e.g., the logger of an Orchestrator):
+//
+//	logger := zerolog.New(sdk.RedactingWriter(os.Stderr))
+func RedactingWriter(out io.Writer) io.Writer {
+	return secretValues.Writer(out)
+}
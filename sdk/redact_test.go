@@ -0,0 +1,33 @@
+package sdk_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: Registry passwords and registered secrets are masked in the output of redacting writers.
+func TestRedactingWriter(t *testing.T) {
+	t.Parallel()
+
+	plan := sdk.NewPlan(testPlanName)
+
+	if _, err := plan.Registry("registry.redact.test").Username("robot").Password("redact-test-password").
+		Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	sdk.RegisterSecret("redact-test-token")
+
+	var out strings.Builder
+
+	logger := zerolog.New(sdk.RedactingWriter(&out))
+	logger.Debug().Str("command", "login -p redact-test-password").Msg("auth with redact-test-token failed")
+
+	if strings.Contains(out.String(), "redact-test") || strings.Count(out.String(), "<redacted>") != 2 {
+		t.Errorf("log output = %s, want the password and token masked", out.String())
+	}
+}
@@ -2,6 +2,9 @@ package sdk
 
 import (
 	"context"
+	"net/http"
+	"slices"
+	"time"
 
 	"github.com/rs/zerolog"
 
@@ -14,13 +17,28 @@ type Registry struct {
 	username string
 	password string
 	log      zerolog.Logger
+
+	// HTTP connection tuning, and the transport shared by all clients of the registry (populated by Build())
+	tuning    registry.TransportConfig
+	transport http.RoundTripper
+
+	// Ordered authentication methods, and the chain shared by all clients of the registry (populated by Build())
+	auth      []registry.AuthMethod
+	authChain *registry.AuthChain
+
+	// Creation of missing ECR repositories before pushes (nil unless configured, see AutoCreateRepo)
+	repos *repoCreation
 }
 
 // RegistryBuilder builds a Registry.
 type RegistryBuilder struct {
+	builderState
+
 	plan     *Plan
 	registry *Registry
-	built    bool
+
+	// Profile the registry belongs to (nil for plan registries)
+	profile *Profile
 }
 
 // Username sets the registry username.
@@ -33,6 +51,89 @@ func (builder *RegistryBuilder) Username(username string) *RegistryBuilder {
 // Password sets the registry password.
 func (builder *RegistryBuilder) Password(password string) *RegistryBuilder {
 	builder.registry.password = password
+	secretValues.Add(password)
+
+	return builder
+}
+
+// AnonymousAuth adds anonymous access to the authentication methods of the registry.
+// Methods are tried in the order they are added; once one is accepted, requests start with it.
+// Without methods, the username and password are used (when set).
+func (builder *RegistryBuilder) AnonymousAuth() *RegistryBuilder {
+	builder.registry.auth = append(builder.registry.auth, registry.AuthMethod{Kind: registry.AuthAnonymous})
+
+	return builder
+}
+
+// BasicAuth adds a username and password (or personal access token) to the authentication methods of the registry.
+func (builder *RegistryBuilder) BasicAuth(username, password string) *RegistryBuilder {
+	secretValues.Add(password)
+
+	builder.registry.auth = append(builder.registry.auth, registry.AuthMethod{
+		Kind:     registry.AuthBasic,
+		Username: username,
+		Password: password,
+	})
+
+	return builder
+}
+
+// TokenAuth adds a bearer token to the authentication methods of the registry.
+func (builder *RegistryBuilder) TokenAuth(token string) *RegistryBuilder {
+	secretValues.Add(token)
+
+	builder.registry.auth = append(builder.registry.auth, registry.AuthMethod{
+		Kind:  registry.AuthToken,
+		Token: token,
+	})
+
+	return builder
+}
+
+// MaxIdleConnsPerHost sets how many idle connections to the registry are kept open for reuse (default: 50).
+// Raise it for massive multi-image syncs running many concurrent blob transfers.
+func (builder *RegistryBuilder) MaxIdleConnsPerHost(conns int) *RegistryBuilder {
+	builder.registry.tuning.MaxIdleConnsPerHost = conns
+
+	return builder
+}
+
+// HTTP2 enables or disables HTTP/2 with the registry (default: enabled when the registry supports it).
+// Some registries and proxies perform better over HTTP/1.1, with one connection per concurrent transfer.
+func (builder *RegistryBuilder) HTTP2(enabled bool) *RegistryBuilder {
+	builder.registry.tuning.DisableHTTP2 = !enabled
+
+	return builder
+}
+
+// KeepAlive sets the interval between TCP keepalive probes on registry connections (default: 30s).
+// A negative interval disables keepalives.
+func (builder *RegistryBuilder) KeepAlive(interval time.Duration) *RegistryBuilder {
+	builder.registry.tuning.KeepAlive = interval
+
+	return builder
+}
+
+// Clone returns a new builder for the registry at host, with the same credentials, authentication methods,
+// connection tuning and repository creation settings.
+// It can be called before or after Build(), to define similar registries from one template.
+func (builder *RegistryBuilder) Clone(host string) *RegistryBuilder {
+	clone := builder.plan.Registry(host)
+	clone.profile = builder.profile
+	clone.registry.username = builder.registry.username
+	clone.registry.password = builder.registry.password
+	clone.registry.tuning = builder.registry.tuning
+	clone.registry.auth = slices.Clone(builder.registry.auth)
+	clone.registry.repos = builder.registry.repos.clone()
+
+	return clone
+}
+
+// Reset makes the builder usable again for the registry at host, keeping its credentials, authentication methods,
+// connection tuning and repository creation settings.
+// The result of a previous Build() is not affected by later changes.
+func (builder *RegistryBuilder) Reset(host string) *RegistryBuilder {
+	*builder = *builder.Clone(host)
 
 	return builder
 }
@@ -40,22 +141,40 @@ func (builder *RegistryBuilder) Password(password string) *RegistryBuilder {
 // Build normalizes and stores the registry in the plan's registry collection.
 // Returns the Registry for direct use (e.g., version checking before plan execution).
 // The builder becomes unusable after Build() is called.
-// Create a new builder for each operation.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
 func (builder *RegistryBuilder) Build() (*Registry, error) {
-	if builder.built {
-		return nil, ErrBuilderAlreadyUsed
+	if err := builder.use(); err != nil {
+		return nil, err
 	}
 
-	builder.built = true
-
 	// Normalize the domain (empty string → docker.io)
 	normalizedDomain := normalizeDomain(builder.registry.host)
 
 	// Update registry to store normalized host
 	builder.registry.host = normalizedDomain
 
-	// Store in plan's registry map keyed by normalized domain
-	builder.plan.registries[normalizedDomain] = builder.registry
+	if builder.registry.repos != nil {
+		if err := builder.registry.repos.check(normalizedDomain); err != nil {
+			return nil, err
+		}
+	}
+
+	// One tuned transport per registry, so all its clients share the connection pool
+	if !builder.registry.tuning.IsZero() {
+		builder.registry.transport = registry.NewTransport(builder.registry.tuning)
+	}
+
+	// One authentication chain per registry, so all its clients start with the method that last worked
+	if len(builder.registry.auth) > 0 {
+		builder.registry.authChain = registry.NewAuthChain(builder.registry.auth...)
+	}
+
+	// Store in plan's registry map keyed by normalized domain (or the profile's, applied when it is selected)
+	if builder.profile != nil {
+		builder.profile.registries[normalizedDomain] = builder.registry
+	} else {
+		builder.plan.registries[normalizedDomain] = builder.registry
+	}
 
 	return builder.registry, nil
 }
@@ -75,12 +194,23 @@ func (reg *Registry) Password() string {
 	return reg.password
 }
 
+// useCredentials makes the registry authenticate with username and password, replacing its authentication methods
+// (e.g., with a robot account provisioned during execution). Clients created afterwards use them.
+func (reg *Registry) useCredentials(username, password string) {
+	secretValues.Add(password)
+
+	reg.username = username
+	reg.password = password
+	reg.auth = nil
+	reg.authChain = nil
+}
+
 // GetDigest returns the digest for an image reference.
 // The name parameter should be just the repository path (e.g., "library/alpine", "timberio/vector").
 // The version parameter is the tag (e.g., "3.19", "latest").
 // The registry domain is automatically prepended.
 func (reg *Registry) GetDigest(ctx context.Context, name, version string) (string, error) {
-	client := registry.NewClient(reg.host, reg.username, reg.password, reg.log)
+	client := newRegistryClient(reg, reg.log)
 	imageRef := reg.host + "/" + name + ":" + version
 	//nolint:wrapcheck
 	return client.GetDigest(ctx, imageRef)
@@ -90,8 +220,40 @@ func (reg *Registry) GetDigest(ctx context.Context, name, version string) (strin
 // The name parameter should be just the repository path (e.g., "library/alpine", "timberio/vector").
 // The registry domain is automatically prepended.
 func (reg *Registry) ListTags(ctx context.Context, name string) ([]string, error) {
-	client := registry.NewClient(reg.host, reg.username, reg.password, reg.log)
+	client := newRegistryClient(reg, reg.log)
 	repository := reg.host + "/" + name
 	//nolint:wrapcheck
 	return client.ListTags(ctx, repository)
 }
+
+// FindTags returns the tags of a repository accepted by match, in registry order (usually lexical),
+// and stops listing once limit tags are found (0 for no limit). Tags are listed page by page, so searching
+// repositories with tens of thousands of tags (e.g., "library/node") does not wait for all of them.
+// The name parameter should be just the repository path (e.g., "library/alpine", "timberio/vector").
+// The registry domain is automatically prepended.
+func (reg *Registry) FindTags(
+	ctx context.Context,
+	name string,
+	match func(tag string) bool,
+	limit int,
+) ([]string, error) {
+	client := newRegistryClient(reg, reg.log)
+	repository := reg.host + "/" + name
+	//nolint:wrapcheck
+	return client.FindTags(ctx, repository, match, limit)
+}
+
+// newRegistryClient creates a registry client for the given registry.
+// If reg is nil, an anonymous client is returned (host inferred from image references).
+func newRegistryClient(reg *Registry, log zerolog.Logger) *registry.Client {
+	if reg == nil {
+		return registry.NewClient("", "", "", log)
+	}
+
+	client := registry.NewClient(reg.host, reg.username, reg.password, log).WithTransport(reg.transport)
+	if reg.authChain != nil {
+		client.WithAuth(reg.authChain)
+	}
+
+	return client
+}
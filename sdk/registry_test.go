@@ -1,7 +1,14 @@
 package sdk_test
 
 import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
 
 	"github.com/farcloser/quark/sdk"
 )
@@ -197,3 +204,39 @@ func TestRegistryBuilder_HostPreservation(t *testing.T) {
 		})
 	}
 }
+
+// INTENTION: A registry with tuned connections (and its clones) should still serve registry requests.
+func TestRegistryBuilder_ConnectionTuning(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	digest := pushRandomImage(t, host+"/my-org/app:1.0")
+
+	plan := sdk.NewPlan(testPlanName)
+
+	builder := plan.Registry(host).
+		MaxIdleConnsPerHost(4).
+		HTTP2(false).
+		KeepAlive(time.Minute)
+
+	clone := builder.Clone(host)
+
+	for _, b := range []*sdk.RegistryBuilder{builder, clone} {
+		reg, err := b.Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		got, err := reg.GetDigest(t.Context(), "my-org/app", "1.0")
+		if err != nil {
+			t.Fatalf("GetDigest() error = %v", err)
+		}
+
+		if got != digest {
+			t.Errorf("GetDigest() = %q, want %q", got, digest)
+		}
+	}
+}
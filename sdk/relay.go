@@ -0,0 +1,504 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/farcloser/quark/internal/registry"
+	"github.com/farcloser/quark/internal/relay"
+)
+
+// Transport is an intermediate object store used for two-phase transfers across security boundaries:
+// an Export writes images into it on one side, an Import pushes them to a registry on the other side.
+// Content is stored as an OCI image layout; keys are slash-separated relative paths
+// (e.g., "blobs/sha256/<hex>", "index.json").
+// Implement Transport to relay through any object store (S3 bucket, removable media, ...).
+type Transport interface {
+	// Put stores the content of reader under key, replacing any existing object.
+	Put(ctx context.Context, key string, reader io.Reader) error
+	// Get opens the object stored under key. Must return an error wrapping ErrTransportNotFound if absent.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Exists reports whether an object is stored under key.
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// ErrTransportNotFound must be wrapped by Transport.Get when no object is stored under a key.
+var ErrTransportNotFound = relay.ErrNotFound
+
+// NewDirectoryTransport returns a Transport storing content as an OCI image layout in a local directory
+// (e.g., a bundle carried across an air gap).
+func NewDirectoryTransport(path string) Transport {
+	return relay.NewDirectory(path)
+}
+
+// Export represents writing an image from a registry into a Transport.
+type Export struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	image     *Image
+	registry  *Registry
+	transport Transport
+
+	// Results populated after execution
+	digest string
+}
+
+// ExportBuilder builds an Export.
+type ExportBuilder struct {
+	builderState
+
+	plan   *Plan
+	export *Export
+}
+
+// Source sets the image to export.
+// The image MUST have a digest specified - exporting by tag alone is not allowed for security - unless it is the
+// output of another operation (see Sync.OutputImage): the export then depends on it, and exports the produced digest.
+// Multi-platform indexes are exported with all their platforms.
+// Registry credentials are looked up from the plan's registry collection using the image domain.
+func (builder *ExportBuilder) Source(image *Image) *ExportBuilder {
+	builder.export.image = image
+	builder.export.consume(image)
+	builder.export.registry = builder.plan.getRegistry(image.Domain())
+
+	return builder
+}
+
+// To sets the transport the image is written to.
+func (builder *ExportBuilder) To(transport Transport) *ExportBuilder {
+	builder.export.transport = transport
+
+	return builder
+}
+
+// RunOnlyOn restricts the export to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *ExportBuilder) RunOnlyOn(envs ...Environment) *ExportBuilder {
+	builder.export.runOnlyOn = append(builder.export.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the export mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *ExportBuilder) Resource(resource Resource) *ExportBuilder {
+	builder.export.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the export start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *ExportBuilder) DependsOn(ops ...Dependency) *ExportBuilder {
+	builder.export.add(ops)
+
+	return builder
+}
+
+// When makes the export run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the export.
+func (builder *ExportBuilder) When(conditions ...Condition) *ExportBuilder {
+	builder.export.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a export named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *ExportBuilder) Clone(name string) *ExportBuilder {
+	clone := builder.plan.Export(name)
+	clone.export.envGuard = builder.export.envGuard.clone()
+	clone.export.resourceHint = builder.export.resourceHint
+	clone.export.dependencyList = builder.export.dependencyList.clone()
+	clone.export.conditionList = builder.export.conditionList.clone()
+	clone.export.image = builder.export.image
+	clone.export.registry = builder.export.registry
+	clone.export.transport = builder.export.transport
+
+	return clone
+}
+
+// Reset makes the builder usable again for a export named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *ExportBuilder) Reset(name string) *ExportBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build validates and adds the export to the plan.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *ExportBuilder) Build() (*Export, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.export.opName); err != nil {
+		return nil, err
+	}
+
+	if builder.export.image == nil {
+		return nil, ErrExportSourceRequired
+	}
+
+	// Images produced by another operation get their digest when it executes
+	if builder.export.image.Digest() == "" && builder.export.image.producer == nil {
+		return nil, fmt.Errorf("%w for image %q", ErrExportSourceDigestRequired, builder.export.image.Name())
+	}
+
+	if builder.export.transport == nil {
+		return nil, ErrExportTransportRequired
+	}
+
+	builder.plan.exports = append(builder.plan.exports, builder.export)
+	builder.plan.addOperation(builder.export)
+
+	return builder.export, nil
+}
+
+func (export *Export) execute(ctx context.Context) error {
+	sourceRef, refName, err := bundleRefs(export.image)
+	if err != nil {
+		return err
+	}
+
+	export.log.Info().
+		Str("source", sourceRef).
+		Msg("exporting image")
+
+	client := newRegistryClient(export.registry, export.log)
+
+	written, err := writeToBundle(ctx, client, relay.NewBundle(export.transport, export.log), sourceRef, refName)
+	if err != nil {
+		return err
+	}
+
+	export.digest = written
+
+	export.log.Info().
+		Str("source", sourceRef).
+		Str("digest", written).
+		Msg("image exported")
+
+	return nil
+}
+
+// bundleRefs returns the digest reference an image is fetched by, and the name of its bundle entry.
+// Entries are named after the tag when known, so bundles stay readable by OCI layout tooling.
+func bundleRefs(image *Image) (sourceRef, refName string, err error) {
+	sourceRef, err = image.digestRef()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to build source reference: %w", err)
+	}
+
+	if image.Version() == "" {
+		return sourceRef, sourceRef, nil
+	}
+
+	refName, err = image.tagRef()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to build source reference: %w", err)
+	}
+
+	return sourceRef, refName, nil
+}
+
+// writeToBundle writes the image or index at sourceRef (a digest reference) into bundle under refName.
+// Returns the written manifest digest.
+func writeToBundle(
+	ctx context.Context,
+	client *registry.Client,
+	bundle *relay.Bundle,
+	sourceRef, refName string,
+) (string, error) {
+	manifest, err := client.GetManifest(ctx, sourceRef)
+	if err != nil {
+		return "", fmt.Errorf("failed to get source image: %w", err)
+	}
+
+	// SECURITY: fetched from source by digest
+	if manifest.MediaType.IsIndex() {
+		idx, err := client.GetIndexHandle(ctx, sourceRef)
+		if err != nil {
+			return "", fmt.Errorf("failed to get source index: %w", err)
+		}
+
+		written, err := bundle.WriteIndex(ctx, refName, idx)
+		if err != nil {
+			return "", fmt.Errorf("failed to export index: %w", err)
+		}
+
+		return written, nil
+	}
+
+	img, err := client.GetImageHandle(ctx, sourceRef)
+	if err != nil {
+		return "", fmt.Errorf("failed to get source image: %w", err)
+	}
+
+	written, err := bundle.WriteImage(ctx, refName, img)
+	if err != nil {
+		return "", fmt.Errorf("failed to export image: %w", err)
+	}
+
+	return written, nil
+}
+
+// Digest returns the exported manifest digest (empty before execution).
+func (export *Export) Digest() string {
+	return export.digest
+}
+
+// producedDigest implements producingOperation.
+func (export *Export) producedDigest() string {
+	return export.Digest()
+}
+
+// resolveRegistries implements registryOperation.
+func (export *Export) resolveRegistries(lookup func(image *Image) *Registry) {
+	export.registry = lookup(export.image)
+}
+
+// Import represents pushing an image from a Transport to a registry.
+type Import struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	transport    Transport
+	sourceImage  *Image
+	destImage    *Image
+	destRegistry *Registry
+
+	// Results populated after execution
+	destDigest string
+}
+
+// ImportBuilder builds an Import.
+type ImportBuilder struct {
+	builderState
+
+	plan *Plan
+	imp  *Import
+}
+
+// From sets the transport the image is read from.
+func (builder *ImportBuilder) From(transport Transport) *ImportBuilder {
+	builder.imp.transport = transport
+
+	return builder
+}
+
+// Source sets the image to import.
+// The image MUST have a digest specified: the transport entry is looked up by digest,
+// and all content is verified against it.
+func (builder *ImportBuilder) Source(image *Image) *ImportBuilder {
+	builder.imp.sourceImage = image
+
+	return builder
+}
+
+// Destination sets the destination image.
+// The image should have name, domain, and version. Digest will be set after import.
+// Registry credentials are looked up from the plan's registry collection using the image domain.
+func (builder *ImportBuilder) Destination(image *Image) *ImportBuilder {
+	builder.imp.destImage = image
+	builder.imp.destRegistry = builder.plan.getRegistry(image.Domain())
+
+	return builder
+}
+
+// RunOnlyOn restricts the import to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *ImportBuilder) RunOnlyOn(envs ...Environment) *ImportBuilder {
+	builder.imp.runOnlyOn = append(builder.imp.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the import mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *ImportBuilder) Resource(resource Resource) *ImportBuilder {
+	builder.imp.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the import start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *ImportBuilder) DependsOn(ops ...Dependency) *ImportBuilder {
+	builder.imp.add(ops)
+
+	return builder
+}
+
+// When makes the import run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the import.
+func (builder *ImportBuilder) When(conditions ...Condition) *ImportBuilder {
+	builder.imp.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a import named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *ImportBuilder) Clone(name string) *ImportBuilder {
+	clone := builder.plan.Import(name)
+	clone.imp.envGuard = builder.imp.envGuard.clone()
+	clone.imp.resourceHint = builder.imp.resourceHint
+	clone.imp.dependencyList = builder.imp.dependencyList.clone()
+	clone.imp.conditionList = builder.imp.conditionList.clone()
+	clone.imp.transport = builder.imp.transport
+	clone.imp.sourceImage = builder.imp.sourceImage
+	clone.imp.destImage = builder.imp.destImage
+	clone.imp.destRegistry = builder.imp.destRegistry
+
+	return clone
+}
+
+// Reset makes the builder usable again for a import named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *ImportBuilder) Reset(name string) *ImportBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build validates and adds the import to the plan.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *ImportBuilder) Build() (*Import, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.imp.opName); err != nil {
+		return nil, err
+	}
+
+	if builder.imp.transport == nil {
+		return nil, ErrImportTransportRequired
+	}
+
+	if builder.imp.sourceImage == nil {
+		return nil, ErrImportSourceRequired
+	}
+
+	if builder.imp.sourceImage.Digest() == "" {
+		return nil, fmt.Errorf("%w for image %q", ErrImportSourceDigestRequired, builder.imp.sourceImage.Name())
+	}
+
+	if builder.imp.destImage == nil {
+		return nil, ErrImportDestinationRequired
+	}
+
+	builder.plan.imports = append(builder.plan.imports, builder.imp)
+	builder.plan.addOperation(builder.imp)
+
+	return builder.imp, nil
+}
+
+func (imp *Import) execute(ctx context.Context) error {
+	destRef, err := imp.destImage.tagRef()
+	if err != nil {
+		return fmt.Errorf("failed to build destination reference: %w", err)
+	}
+
+	sourceDigest := imp.sourceImage.Digest()
+
+	imp.log.Info().
+		Str("source", sourceDigest).
+		Str("destination", destRef).
+		Msg("importing image")
+
+	bundle := relay.NewBundle(imp.transport, imp.log)
+
+	entry, err := bundle.Find(ctx, sourceDigest)
+	if err != nil {
+		return fmt.Errorf("failed to find image in transport: %w", err)
+	}
+
+	if err := imp.destRegistry.ensureRepository(ctx, imp.destImage.Path(), imp.log); err != nil {
+		return err
+	}
+
+	client := newRegistryClient(imp.destRegistry, imp.log)
+
+	// SECURITY: manifests and blobs are verified against the source digest as they are read
+	var pushed string
+	if entry.MediaType.IsIndex() {
+		idx, err := bundle.Index(ctx, entry.Digest)
+		if err != nil {
+			return fmt.Errorf("failed to read index from transport: %w", err)
+		}
+
+		pushed, err = client.PushIndex(ctx, destRef, idx)
+		if err != nil {
+			return fmt.Errorf("failed to push index: %w", err)
+		}
+	} else {
+		img, err := bundle.Image(ctx, entry.Digest)
+		if err != nil {
+			return fmt.Errorf("failed to read image from transport: %w", err)
+		}
+
+		pushed, err = client.PushImage(ctx, destRef, img)
+		if err != nil {
+			return fmt.Errorf("failed to push image: %w", err)
+		}
+	}
+
+	imp.destDigest = pushed
+	// Subsequent operations (e.g., scanning) reference the imported image by digest
+	imp.destImage.ref.Digest = digest.Digest(pushed)
+
+	imp.log.Info().
+		Str("destination", destRef).
+		Str("dest_digest", pushed).
+		Msg("image imported")
+
+	return nil
+}
+
+// DestDigest returns the destination image digest after import (empty before execution).
+func (imp *Import) DestDigest() string {
+	return imp.destDigest
+}
+
+// destructiveChange implements destructiveOperation: an import overwrites an existing destination tag.
+func (imp *Import) destructiveChange(ctx context.Context) (string, error) {
+	destRef, err := imp.destImage.tagRef()
+	if err != nil {
+		return "", fmt.Errorf("failed to build destination reference: %w", err)
+	}
+
+	return tagOverwrite(ctx, newRegistryClient(imp.destRegistry, imp.log), destRef, imp.sourceImage.Digest())
+}
+
+// producedDigest implements producingOperation.
+func (imp *Import) producedDigest() string {
+	return imp.DestDigest()
+}
+
+// destination implements destinationOperation: the destination image.
+func (imp *Import) destination() *Image {
+	return imp.destImage
+}
+
+// resolveRegistries implements registryOperation.
+func (imp *Import) resolveRegistries(lookup func(image *Image) *Registry) {
+	imp.destRegistry = lookup(imp.destImage)
+}
+
+// Ensure the directory transport matches the SDK contract.
+var _ Transport = (*relay.Directory)(nil)
@@ -0,0 +1,160 @@
+package sdk_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: Export requires a digest-pinned source and a transport.
+func TestExportBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	pinned, err := sdk.NewImage("library/alpine").Version("3.20").Digest(testDigest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	unpinned, err := sdk.NewImage("library/alpine").Version("3.20").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	transport := sdk.NewDirectoryTransport(t.TempDir())
+
+	tests := []struct {
+		name    string
+		build   func(*sdk.Plan) (*sdk.Export, error)
+		wantErr error
+	}{
+		{
+			name: "valid export",
+			build: func(plan *sdk.Plan) (*sdk.Export, error) {
+				return plan.Export("bundle").Source(pinned).To(transport).Build()
+			},
+		},
+		{
+			name: "missing source",
+			build: func(plan *sdk.Plan) (*sdk.Export, error) {
+				return plan.Export("bundle").To(transport).Build()
+			},
+			wantErr: sdk.ErrExportSourceRequired,
+		},
+		{
+			name: "source without digest",
+			build: func(plan *sdk.Plan) (*sdk.Export, error) {
+				return plan.Export("bundle").Source(unpinned).To(transport).Build()
+			},
+			wantErr: sdk.ErrExportSourceDigestRequired,
+		},
+		{
+			name: "missing transport",
+			build: func(plan *sdk.Plan) (*sdk.Export, error) {
+				return plan.Export("bundle").Source(pinned).Build()
+			},
+			wantErr: sdk.ErrExportTransportRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			export, err := tt.build(sdk.NewPlan(testPlanName))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil || export == nil {
+				t.Fatalf("Build() = %v, %v, want export", export, err)
+			}
+		})
+	}
+}
+
+// INTENTION: Import requires a transport, a digest-pinned source and a destination.
+func TestImportBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	pinned, err := sdk.NewImage("library/alpine").Version("3.20").Digest(testDigest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	unpinned, err := sdk.NewImage("library/alpine").Version("3.20").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	dest, err := sdk.NewImage("mirror/alpine").Domain("registry.internal").Version("3.20").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	transport := sdk.NewDirectoryTransport(t.TempDir())
+
+	tests := []struct {
+		name    string
+		build   func(*sdk.Plan) (*sdk.Import, error)
+		wantErr error
+	}{
+		{
+			name: "valid import",
+			build: func(plan *sdk.Plan) (*sdk.Import, error) {
+				return plan.Import("load").From(transport).Source(pinned).Destination(dest).Build()
+			},
+		},
+		{
+			name: "missing transport",
+			build: func(plan *sdk.Plan) (*sdk.Import, error) {
+				return plan.Import("load").Source(pinned).Destination(dest).Build()
+			},
+			wantErr: sdk.ErrImportTransportRequired,
+		},
+		{
+			name: "missing source",
+			build: func(plan *sdk.Plan) (*sdk.Import, error) {
+				return plan.Import("load").From(transport).Destination(dest).Build()
+			},
+			wantErr: sdk.ErrImportSourceRequired,
+		},
+		{
+			name: "source without digest",
+			build: func(plan *sdk.Plan) (*sdk.Import, error) {
+				return plan.Import("load").From(transport).Source(unpinned).Destination(dest).Build()
+			},
+			wantErr: sdk.ErrImportSourceDigestRequired,
+		},
+		{
+			name: "missing destination",
+			build: func(plan *sdk.Plan) (*sdk.Import, error) {
+				return plan.Import("load").From(transport).Source(pinned).Build()
+			},
+			wantErr: sdk.ErrImportDestinationRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			imp, err := tt.build(sdk.NewPlan(testPlanName))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil || imp == nil {
+				t.Fatalf("Build() = %v, %v, want import", imp, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/carapace-sh/carapace-shlex"
+
+	"github.com/farcloser/quark/ssh"
+)
+
+// RemoteRun represents running a shell command on a remote host over SSH (e.g., restarting a service
+// after its image was synced), so simple single-host deployments can be driven from a plan.
+type RemoteRun struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	node    *BuildNode
+	command string
+	after   *Sync
+
+	// sshPool is set by executor before execution
+	sshPool *ssh.Pool
+
+	// Results populated after execution
+	output string
+}
+
+// RemoteRunBuilder builds a RemoteRun.
+type RemoteRunBuilder struct {
+	builderState
+
+	plan *Plan
+	run  *RemoteRun
+}
+
+// Node sets the host the command runs on. Only the SSH endpoint of the node is used.
+func (builder *RemoteRunBuilder) Node(node *BuildNode) *RemoteRunBuilder {
+	builder.run.node = node
+
+	return builder
+}
+
+// Command sets the shell command to run (e.g., "systemctl restart app").
+// It runs as the SSH user: use sudo in the command when needed.
+func (builder *RemoteRunBuilder) Command(command string) *RemoteRunBuilder {
+	builder.run.command = command
+
+	return builder
+}
+
+// After makes the command depend on a sync of the plan: it runs after the sync, is skipped when the sync
+// is skipped by RunOnlyOn, and sees the synced image in the QUARK_IMAGE and QUARK_DIGEST environment variables
+// (e.g., "docker pull \"$QUARK_IMAGE@$QUARK_DIGEST\" && systemctl restart app").
+func (builder *RemoteRunBuilder) After(sync *Sync) *RemoteRunBuilder {
+	builder.run.after = sync
+
+	return builder
+}
+
+// RunOnlyOn restricts the command to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *RemoteRunBuilder) RunOnlyOn(envs ...Environment) *RemoteRunBuilder {
+	builder.run.runOnlyOn = append(builder.run.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the command mostly uses (default: sdk.ResourceRemote),
+// for executors bounding how many operations of each class run at once.
+func (builder *RemoteRunBuilder) Resource(resource Resource) *RemoteRunBuilder {
+	builder.run.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the command start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *RemoteRunBuilder) DependsOn(ops ...Dependency) *RemoteRunBuilder {
+	builder.run.add(ops)
+
+	return builder
+}
+
+// When makes the command run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the command.
+func (builder *RemoteRunBuilder) When(conditions ...Condition) *RemoteRunBuilder {
+	builder.run.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a remote run named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *RemoteRunBuilder) Clone(name string) *RemoteRunBuilder {
+	clone := builder.plan.RemoteRun(name)
+	clone.run.envGuard = builder.run.envGuard.clone()
+	clone.run.resourceHint = builder.run.resourceHint
+	clone.run.dependencyList = builder.run.dependencyList.clone()
+	clone.run.conditionList = builder.run.conditionList.clone()
+	clone.run.node = builder.run.node
+	clone.run.command = builder.run.command
+	clone.run.after = builder.run.after
+
+	return clone
+}
+
+// Reset makes the builder usable again for a remote run named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *RemoteRunBuilder) Reset(name string) *RemoteRunBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build validates and adds the remote run to the plan.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *RemoteRunBuilder) Build() (*RemoteRun, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.run.opName); err != nil {
+		return nil, err
+	}
+
+	if builder.run.node == nil {
+		return nil, ErrRemoteRunNodeRequired
+	}
+
+	if strings.TrimSpace(builder.run.command) == "" {
+		return nil, ErrRemoteRunCommandRequired
+	}
+
+	// The dependency must run first: it must already be part of this plan
+	if builder.run.after != nil && !slices.Contains(builder.plan.syncs, builder.run.after) {
+		return nil, fmt.Errorf("%w: %q", ErrRemoteRunDependencyNotInPlan, builder.run.after.operationName())
+	}
+
+	builder.plan.remoteRuns = append(builder.plan.remoteRuns, builder.run)
+	builder.plan.addOperation(builder.run)
+
+	return builder.run, nil
+}
+
+// runsOn reports whether the command runs in env: it is skipped along with the sync it depends on.
+func (run *RemoteRun) runsOn(env Environment) bool {
+	if run.after != nil && !run.after.runsOn(env) {
+		return false
+	}
+
+	return run.envGuard.runsOn(env)
+}
+
+// dependencies returns the operations the command waits for, including the sync it runs after.
+func (run *RemoteRun) dependencies() []operation {
+	if run.after == nil {
+		return run.dependsOn
+	}
+
+	return append([]operation{run.after}, run.dependsOn...)
+}
+
+func (run *RemoteRun) execute(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("remote run cancelled: %w", err)
+	}
+
+	command, err := run.remoteCommand()
+	if err != nil {
+		return err
+	}
+
+	run.log.Info().
+		Str("node", run.node.name).
+		Str("command", run.command).
+		Msg("running remote command")
+
+	sshClient, err := run.sshPool.GetClient(run.node.endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to connect to node %q: %w", run.node.name, err)
+	}
+
+	stdout, stderr, err := sshClient.Execute(command)
+	run.output = stdout
+
+	if err != nil {
+		run.log.Error().
+			Str("stdout", stdout).
+			Str("stderr", stderr).
+			Err(err).
+			Msg("remote command failed")
+
+		return fmt.Errorf("%w on node %q: %w: %s", ErrRemoteRunFailed, run.node.name, err, strings.TrimSpace(stderr))
+	}
+
+	run.log.Info().
+		Str("node", run.node.name).
+		Str("stdout", strings.TrimSpace(stdout)).
+		Msg("remote command complete")
+
+	return nil
+}
+
+// remoteCommand returns the command to execute, with the synced image exported when depending on a sync.
+func (run *RemoteRun) remoteCommand() (string, error) {
+	if run.after == nil {
+		return run.command, nil
+	}
+
+	image, err := run.after.destImage.tagRef()
+	if err != nil {
+		return "", fmt.Errorf("failed to build image reference: %w", err)
+	}
+
+	return shlex.Join([]string{
+		"env",
+		"QUARK_IMAGE=" + image,
+		"QUARK_DIGEST=" + run.after.DestDigest(),
+		"sh", "-c", run.command,
+	}), nil
+}
+
+// Output returns the standard output of the command (empty before execution).
+func (run *RemoteRun) Output() string {
+	return run.output
+}
@@ -0,0 +1,142 @@
+package sdk_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// newTestSync builds a sync of debian into the test domain.
+func newTestSync(t *testing.T, plan *sdk.Plan, name string) *sdk.SyncBuilder {
+	t.Helper()
+
+	source, err := sdk.NewImage("library/debian").Digest(testDigest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create source image: %v", err)
+	}
+
+	dest, err := sdk.NewImage("mirror/debian").Domain(testDomain).Version(testVersion).Build()
+	if err != nil {
+		t.Fatalf("Failed to create destination image: %v", err)
+	}
+
+	return plan.Sync(name).Source(source).Destination(dest)
+}
+
+// INTENTION: RemoteRun requires a node and a command, and can only depend on a sync built earlier
+// in the same plan.
+func TestRemoteRunBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		build   func(*testing.T, *sdk.Plan, *sdk.BuildNode) (*sdk.RemoteRun, error)
+		wantErr error
+	}{
+		{
+			name: "command",
+			build: func(_ *testing.T, plan *sdk.Plan, node *sdk.BuildNode) (*sdk.RemoteRun, error) {
+				return plan.RemoteRun("restart").Node(node).Command("systemctl restart app").Build()
+			},
+		},
+		{
+			name: "after a sync",
+			build: func(t *testing.T, plan *sdk.Plan, node *sdk.BuildNode) (*sdk.RemoteRun, error) {
+				sync, err := newTestSync(t, plan, "debian").Build()
+				if err != nil {
+					return nil, err
+				}
+
+				return plan.RemoteRun("restart").Node(node).Command("systemctl restart app").After(sync).Build()
+			},
+		},
+		{
+			name: "missing node",
+			build: func(_ *testing.T, plan *sdk.Plan, _ *sdk.BuildNode) (*sdk.RemoteRun, error) {
+				return plan.RemoteRun("restart").Command("systemctl restart app").Build()
+			},
+			wantErr: sdk.ErrRemoteRunNodeRequired,
+		},
+		{
+			name: "missing command",
+			build: func(_ *testing.T, plan *sdk.Plan, node *sdk.BuildNode) (*sdk.RemoteRun, error) {
+				return plan.RemoteRun("restart").Node(node).Command("  ").Build()
+			},
+			wantErr: sdk.ErrRemoteRunCommandRequired,
+		},
+		{
+			name: "sync from another plan",
+			build: func(t *testing.T, plan *sdk.Plan, node *sdk.BuildNode) (*sdk.RemoteRun, error) {
+				sync, err := newTestSync(t, sdk.NewPlan("other"), "debian").Build()
+				if err != nil {
+					return nil, err
+				}
+
+				return plan.RemoteRun("restart").Node(node).Command("systemctl restart app").After(sync).Build()
+			},
+			wantErr: sdk.ErrRemoteRunDependencyNotInPlan,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			plan := sdk.NewPlan(testPlanName)
+
+			node, err := plan.BuildNode("app-host").
+				Endpoint("app.example.com").
+				Platform(sdk.PlatformAMD64).
+				Build()
+			if err != nil {
+				t.Fatalf("Failed to create test node: %v", err)
+			}
+
+			run, err := tt.build(t, plan, node)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && run == nil {
+				t.Error("Build() returned nil remote run with nil error")
+			}
+		})
+	}
+}
+
+// INTENTION: a remote run is skipped along with the sync it depends on, without connecting to the host.
+func TestRemoteRun_SkippedWithDependency(t *testing.T) {
+	t.Parallel()
+
+	plan := sdk.NewPlan(testPlanName)
+	plan.Environment(sdk.EnvLocal)
+
+	node, err := plan.BuildNode("app-host").
+		Endpoint("app.example.com").
+		Platform(sdk.PlatformAMD64).
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to create test node: %v", err)
+	}
+
+	sync, err := newTestSync(t, plan, "debian").RunOnlyOn(sdk.EnvCI).Build()
+	if err != nil {
+		t.Fatalf("Failed to create sync: %v", err)
+	}
+
+	if _, err := plan.RemoteRun("restart").Node(node).Command("systemctl restart app").After(sync).Build(); err != nil {
+		t.Fatalf("Failed to create remote run: %v", err)
+	}
+
+	if err := plan.Execute(context.Background()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	for _, op := range plan.Report().Operations {
+		if op.Status != sdk.StatusSkipped {
+			t.Errorf("operation %q status = %s, want %s", op.Name, op.Status, sdk.StatusSkipped)
+		}
+	}
+}
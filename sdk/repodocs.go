@@ -0,0 +1,318 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/farcloser/quark/internal/repodocs"
+)
+
+// dockerHubDomain is the registry domain of Docker Hub images.
+const dockerHubDomain = "docker.io"
+
+// RepositoryDocs represents pushing the documentation of a repository to its registry page: the short description
+// and README of a Docker Hub repository, or the description of a Quay repository.
+type RepositoryDocs struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	image       *Image
+	registry    *Registry
+	description string
+	readme      string
+	token       string
+	apiURL      string
+
+	// Results populated after execution
+	updated bool
+}
+
+// RepositoryDocsBuilder builds a RepositoryDocs.
+type RepositoryDocsBuilder struct {
+	builderState
+
+	plan *Plan
+	docs *RepositoryDocs
+}
+
+// Image sets the repository (e.g., docker.io/my-org/app or quay.io/my-org/app; the version is ignored).
+// Docker Hub credentials are looked up from the plan's registry collection using the image domain; other
+// domains are Quay registries.
+func (builder *RepositoryDocsBuilder) Image(image *Image) *RepositoryDocsBuilder {
+	builder.docs.image = image
+	builder.docs.registry = builder.plan.registries[normalizeDomain(image.Domain())]
+
+	return builder
+}
+
+// Description sets the short description (Docker Hub, up to 100 characters). Quay repositories, which only have
+// a description, use it when no README is set.
+func (builder *RepositoryDocsBuilder) Description(description string) *RepositoryDocsBuilder {
+	builder.docs.description = description
+
+	return builder
+}
+
+// README sets the Markdown file (e.g., "README.md") shown on the repository page: the full description on
+// Docker Hub (up to 25000 bytes), the description on Quay. The file is read at execution.
+func (builder *RepositoryDocsBuilder) README(path string) *RepositoryDocsBuilder {
+	builder.docs.readme = path
+
+	return builder
+}
+
+// Token sets the Quay OAuth application token (repository administration scope) the description is updated with
+// (default: the QUAY_TOKEN environment variable). Registry credentials, such as robot accounts, cannot use the
+// Quay API. Docker Hub repositories use the registry credentials instead.
+func (builder *RepositoryDocsBuilder) Token(token string) *RepositoryDocsBuilder {
+	builder.docs.token = token
+	secretValues.Add(token)
+
+	return builder
+}
+
+// APIURL sets the registry API root (default: "https://hub.docker.com" for Docker Hub, "https://<domain>/api/v1"
+// for Quay).
+func (builder *RepositoryDocsBuilder) APIURL(apiURL string) *RepositoryDocsBuilder {
+	builder.docs.apiURL = apiURL
+
+	return builder
+}
+
+// RunOnlyOn restricts the documentation push to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *RepositoryDocsBuilder) RunOnlyOn(envs ...Environment) *RepositoryDocsBuilder {
+	builder.docs.runOnlyOn = append(builder.docs.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the documentation push mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *RepositoryDocsBuilder) Resource(resource Resource) *RepositoryDocsBuilder {
+	builder.docs.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the documentation push start only once the given operations, built before it in the plan,
+// completed (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *RepositoryDocsBuilder) DependsOn(ops ...Dependency) *RepositoryDocsBuilder {
+	builder.docs.add(ops)
+
+	return builder
+}
+
+// When makes the documentation push run only if the given conditions all hold once the operations it depends on
+// completed; otherwise it is skipped. A failing condition fails the documentation push.
+func (builder *RepositoryDocsBuilder) When(conditions ...Condition) *RepositoryDocsBuilder {
+	builder.docs.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a documentation push named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *RepositoryDocsBuilder) Clone(name string) *RepositoryDocsBuilder {
+	clone := builder.plan.RepositoryDocs(name)
+	clone.docs.envGuard = builder.docs.envGuard.clone()
+	clone.docs.resourceHint = builder.docs.resourceHint
+	clone.docs.dependencyList = builder.docs.dependencyList.clone()
+	clone.docs.conditionList = builder.docs.conditionList.clone()
+	clone.docs.image = builder.docs.image
+	clone.docs.registry = builder.docs.registry
+	clone.docs.description = builder.docs.description
+	clone.docs.readme = builder.docs.readme
+	clone.docs.token = builder.docs.token
+	clone.docs.apiURL = builder.docs.apiURL
+
+	return clone
+}
+
+// Reset makes the builder usable again for a documentation push named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *RepositoryDocsBuilder) Reset(name string) *RepositoryDocsBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build validates and adds the documentation push to the plan.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *RepositoryDocsBuilder) Build() (*RepositoryDocs, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.docs.opName); err != nil {
+		return nil, err
+	}
+
+	docs := builder.docs
+
+	if docs.image == nil {
+		return nil, ErrRepositoryDocsImageRequired
+	}
+
+	if err := docs.image.checkRegistry(); err != nil {
+		return nil, err
+	}
+
+	if strings.Count(docs.image.Path(), "/") != 1 {
+		return nil, fmt.Errorf("%w: %q (expected namespace/name)", ErrInvalidRepositoryDocsImage, docs.image.Path())
+	}
+
+	if docs.description == "" && docs.readme == "" {
+		return nil, ErrRepositoryDocsRequired
+	}
+
+	if len(docs.description) > repodocs.MaxShortDescription {
+		return nil, fmt.Errorf("%w: %d characters (max %d)", ErrRepositoryDescriptionTooLong,
+			len(docs.description), repodocs.MaxShortDescription)
+	}
+
+	builder.plan.repositoryDocs = append(builder.plan.repositoryDocs, docs)
+	builder.plan.addOperation(docs)
+
+	return docs, nil
+}
+
+// dockerHub reports whether the repository is on Docker Hub (Quay otherwise).
+func (docs *RepositoryDocs) dockerHub() bool {
+	return normalizeDomain(docs.image.Domain()) == dockerHubDomain
+}
+
+// client returns a client of the registry API.
+func (docs *RepositoryDocs) client() repodocs.Client {
+	if docs.dockerHub() {
+		var username, password string
+		if docs.registry != nil {
+			username, password = docs.registry.Username(), docs.registry.Password()
+		}
+
+		hub := repodocs.NewDockerHub(username, password)
+		if docs.apiURL != "" {
+			hub.WithAPIURL(docs.apiURL)
+		}
+
+		return hub
+	}
+
+	apiURL := docs.apiURL
+	if apiURL == "" {
+		apiURL = "https://" + docs.image.Domain() + "/api/v1"
+	}
+
+	token := docs.token
+	if token == "" {
+		token = os.Getenv("QUAY_TOKEN")
+	}
+
+	return repodocs.NewQuay(apiURL, token)
+}
+
+// wanted returns the documentation to push, given the current one: fields the operation does not set are kept.
+func (docs *RepositoryDocs) wanted(current repodocs.Docs) (repodocs.Docs, error) {
+	wanted := current
+
+	if docs.readme != "" {
+		content, err := os.ReadFile(docs.readme)
+		if err != nil {
+			return repodocs.Docs{}, fmt.Errorf("failed to read README: %w", err)
+		}
+
+		if docs.dockerHub() && len(content) > repodocs.MaxFullDescription {
+			return repodocs.Docs{}, fmt.Errorf("%w: %s is %d bytes (max %d)", ErrRepositoryReadmeTooLong,
+				docs.readme, len(content), repodocs.MaxFullDescription)
+		}
+
+		wanted.Full = string(content)
+	}
+
+	if docs.description != "" {
+		if docs.dockerHub() {
+			wanted.Short = docs.description
+		} else if docs.readme == "" {
+			wanted.Full = docs.description
+		}
+	}
+
+	return wanted, nil
+}
+
+func (docs *RepositoryDocs) execute(ctx context.Context) error {
+	namespace, name, _ := strings.Cut(docs.image.Path(), "/")
+	client := docs.client()
+
+	docs.log.Info().Str("repository", docs.image.Name()).Msg("pushing repository documentation")
+
+	current, err := client.Docs(ctx, namespace, name)
+	if err != nil {
+		return fmt.Errorf("failed to get repository documentation: %w", err)
+	}
+
+	wanted, err := docs.wanted(current)
+	if err != nil {
+		return err
+	}
+
+	if wanted == current {
+		docs.log.Info().Str("repository", docs.image.Name()).Msg("repository documentation up to date")
+
+		return nil
+	}
+
+	if err := client.Update(ctx, namespace, name, wanted); err != nil {
+		return fmt.Errorf("failed to update repository documentation: %w", err)
+	}
+
+	docs.updated = true
+
+	docs.log.Info().Str("repository", docs.image.Name()).Msg("repository documentation updated")
+
+	return nil
+}
+
+// plannedChanges implements dryRunOperation: the repository must exist.
+func (docs *RepositoryDocs) plannedChanges(ctx context.Context) ([]string, error) {
+	namespace, name, _ := strings.Cut(docs.image.Path(), "/")
+
+	current, err := docs.client().Docs(ctx, namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository documentation: %w", err)
+	}
+
+	wanted, err := docs.wanted(current)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []string
+
+	if wanted.Short != current.Short {
+		changes = append(changes, fmt.Sprintf("Would set the description of %s to %q", docs.image.Name(), wanted.Short))
+	}
+
+	if wanted.Full != current.Full {
+		changes = append(changes, fmt.Sprintf("Would update the README of %s (%d bytes)", docs.image.Name(),
+			len(wanted.Full)))
+	}
+
+	return changes, nil
+}
+
+// Updated reports whether the execution changed the repository documentation (false when already up to date).
+func (docs *RepositoryDocs) Updated() bool {
+	return docs.updated
+}
+
+// resolveRegistries implements registryOperation.
+func (docs *RepositoryDocs) resolveRegistries(lookup func(image *Image) *Registry) {
+	docs.registry = lookup(docs.image)
+}
@@ -0,0 +1,171 @@
+package sdk_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: Repository documentation requires a namespace/name repository, and a description short enough for
+// Docker Hub or a README.
+func TestRepositoryDocsBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	repo, err := sdk.NewImage("my-org/app").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	nested, err := sdk.NewImage("my-org/team/app").Domain("quay.io").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		build   func(*sdk.Plan) (*sdk.RepositoryDocs, error)
+		wantErr error
+	}{
+		{
+			name: "valid docs",
+			build: func(plan *sdk.Plan) (*sdk.RepositoryDocs, error) {
+				return plan.RepositoryDocs("docs").Image(repo).Description("The app").README("README.md").Build()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "missing image",
+			build: func(plan *sdk.Plan) (*sdk.RepositoryDocs, error) {
+				return plan.RepositoryDocs("docs").README("README.md").Build()
+			},
+			wantErr: sdk.ErrRepositoryDocsImageRequired,
+		},
+		{
+			name: "nested repository",
+			build: func(plan *sdk.Plan) (*sdk.RepositoryDocs, error) {
+				return plan.RepositoryDocs("docs").Image(nested).README("README.md").Build()
+			},
+			wantErr: sdk.ErrInvalidRepositoryDocsImage,
+		},
+		{
+			name: "nothing to push",
+			build: func(plan *sdk.Plan) (*sdk.RepositoryDocs, error) {
+				return plan.RepositoryDocs("docs").Image(repo).Build()
+			},
+			wantErr: sdk.ErrRepositoryDocsRequired,
+		},
+		{
+			name: "description too long",
+			build: func(plan *sdk.Plan) (*sdk.RepositoryDocs, error) {
+				return plan.RepositoryDocs("docs").Image(repo).Description(strings.Repeat("a", 101)).Build()
+			},
+			wantErr: sdk.ErrRepositoryDescriptionTooLong,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			docs, err := tt.build(sdk.NewPlan(testPlanName))
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil || docs == nil {
+				t.Errorf("Build() = %v, %v, want docs", docs, err)
+			}
+		})
+	}
+}
+
+// INTENTION: Executing repository documentation logs in to Docker Hub with the plan registry credentials, and
+// replaces the README while keeping the description it does not set; a second run changes nothing.
+func TestRepositoryDocs_DockerHub(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu      sync.Mutex
+		updates int
+		repo    = map[string]string{"description": "Kept", "full_description": "# Old"}
+	)
+
+	hub := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if req.URL.Path == "/v2/users/login" {
+			var creds map[string]string
+			if err := json.NewDecoder(req.Body).Decode(&creds); err != nil || creds["password"] != "pat" {
+				writer.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			_, _ = writer.Write([]byte(`{"token":"jwt"}`))
+
+			return
+		}
+
+		if req.Method == http.MethodPatch {
+			updates++
+			_ = json.NewDecoder(req.Body).Decode(&repo)
+		}
+
+		_ = json.NewEncoder(writer).Encode(repo)
+	}))
+	t.Cleanup(hub.Close)
+
+	readme := filepath.Join(t.TempDir(), "README.md")
+	if err := os.WriteFile(readme, []byte("# App\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write README: %v", err)
+	}
+
+	image, err := sdk.NewImage("my-org/app").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	for run := range 2 {
+		plan := sdk.NewPlan(testPlanName)
+		// Validation would log in to the registry itself
+		plan.SkipValidation(true)
+
+		if _, err := plan.Registry("docker.io").Username("user").Password("pat").Build(); err != nil {
+			t.Fatalf("Failed to create registry: %v", err)
+		}
+
+		docs, err := plan.RepositoryDocs("docs").Image(image).README(readme).APIURL(hub.URL).Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		if err := plan.Execute(t.Context()); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+
+		if docs.Updated() != (run == 0) {
+			t.Errorf("run %d: Updated() = %v", run, docs.Updated())
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if updates != 1 || repo["full_description"] != "# App\n" || repo["description"] != "Kept" {
+		t.Errorf("updates = %d, repository = %v, want one update of the README", updates, repo)
+	}
+}
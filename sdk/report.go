@@ -0,0 +1,796 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/internal/prcomment"
+)
+
+// ReportFormat represents an execution report format.
+type ReportFormat struct {
+	value string
+}
+
+//nolint:gochecknoglobals // ReportFormat enum pattern requires global variables
+var (
+	// ReportMarkdown renders the report as a Markdown document (e.g., for PR comments).
+	ReportMarkdown = ReportFormat{"markdown"}
+	// ReportHTML renders the report as a standalone HTML page.
+	ReportHTML = ReportFormat{"html"}
+	// ReportJUnit renders the report as JUnit XML, one test case per operation (e.g., for Jenkins or GitLab).
+	ReportJUnit = ReportFormat{"junit"}
+)
+
+// String returns the string representation of the format.
+func (f *ReportFormat) String() string {
+	return f.value
+}
+
+// extension returns the file extension of the format.
+func (f *ReportFormat) extension() string {
+	switch *f {
+	case ReportHTML:
+		return ".html"
+	case ReportJUnit:
+		return ".xml"
+	default:
+		return ".md"
+	}
+}
+
+// parseReportFormat parses a report format name ("markdown", "md", "html", "junit" or "xml").
+func parseReportFormat(name string) (ReportFormat, error) {
+	switch strings.ToLower(name) {
+	case "markdown", "md":
+		return ReportMarkdown, nil
+	case "html":
+		return ReportHTML, nil
+	case "junit", "xml":
+		return ReportJUnit, nil
+	default:
+		return ReportFormat{}, fmt.Errorf("%w: %q (valid: markdown, html, junit)", ErrInvalidReportFormat, name)
+	}
+}
+
+// OperationStatus is the outcome of an operation in an execution report.
+type OperationStatus string
+
+const (
+	// StatusSucceeded indicates the operation completed successfully.
+	StatusSucceeded OperationStatus = "succeeded"
+	// StatusFailed indicates the operation failed (and stopped the plan).
+	StatusFailed OperationStatus = "failed"
+	// StatusSkipped indicates the operation was restricted to other environments (RunOnlyOn),
+	// or its conditions did not hold (When).
+	StatusSkipped OperationStatus = "skipped"
+	// StatusNotRun indicates the operation was not reached because an earlier operation failed.
+	StatusNotRun OperationStatus = "not run"
+	// StatusPlanned indicates the operation was checked by a dry run, which describes its changes in Details.
+	StatusPlanned OperationStatus = "planned"
+	// StatusUnchanged indicates the operation was not executed because its inputs did not change since the last
+	// successful execution (see Plan.SkipUnchanged).
+	StatusUnchanged OperationStatus = "unchanged"
+)
+
+// OperationReport is the outcome of one operation.
+type OperationReport struct {
+	Name   string
+	Kind   string
+	Status OperationStatus
+	// Started is when the operation started (zero when it did not run).
+	Started  time.Time
+	Duration time.Duration
+	// Error is the failure message (empty unless the operation failed).
+	Error string
+	// Digest is the digest the operation produced or pointed a tag to (sync and import destinations, artifacts,
+	// exports, bundle archives, rollbacks, rebases, flattened and mutated images, manifest lists, containerd
+	// imports), empty otherwise.
+	Digest string
+	// Details lists operation results (e.g., produced digests, vulnerability counts, available updates).
+	Details []string
+	// Vulnerabilities is the number of vulnerabilities a scan found per severity (e.g., "HIGH": 3), the highest
+	// count of its platforms; nil for other operations.
+	Vulnerabilities map[string]int
+	// Issues is the number of problems an audit found.
+	Issues int
+	// Update is the update a version check found (e.g., "3.19 -> 3.20"), empty otherwise.
+	Update string
+}
+
+// Report is the execution report of a plan run.
+type Report struct {
+	Plan       string
+	Started    time.Time
+	Duration   time.Duration
+	Operations []OperationReport
+	// Warnings lists problems that do not fail the plan (e.g., expired exceptions, see Plan.Exceptions).
+	Warnings []string
+}
+
+// Report returns the report of the last plan execution (nil before Execute).
+func (plan *Plan) Report() *Report {
+	return plan.report
+}
+
+// ReportTo writes the execution report to path after every Execute, whether or not it succeeds.
+// QUARK_REPORT and QUARK_REPORT_FORMAT (set by the CLI --report and --report-format flags) take precedence,
+// except for plans run by an Orchestrator.
+func (plan *Plan) ReportTo(path string, format ReportFormat) {
+	plan.reportPath = path
+	plan.reportFormat = format
+}
+
+// ExecuteWithResult runs the plan like Execute, and returns its execution report (see Report), so callers can
+// act on the outcome of each operation: status, duration, produced digest. The report is returned even when
+// execution fails, with the operations that did not run.
+func (plan *Plan) ExecuteWithResult(ctx context.Context) (*Report, error) {
+	err := plan.Execute(ctx)
+
+	return plan.report, err
+}
+
+// Operation returns the report of the operation named name.
+func (report *Report) Operation(name string) (OperationReport, bool) {
+	for _, op := range report.Operations {
+		if op.Name == name {
+			return op, true
+		}
+	}
+
+	return OperationReport{}, false
+}
+
+// WithStatus returns the reports of the operations in status (e.g., StatusSkipped), in plan order.
+func (report *Report) WithStatus(status OperationStatus) []OperationReport {
+	var matching []OperationReport
+
+	for _, op := range report.Operations {
+		if op.Status == status {
+			matching = append(matching, op)
+		}
+	}
+
+	return matching
+}
+
+// Succeeded returns whether every operation that ran succeeded.
+func (report *Report) Succeeded() bool {
+	for _, op := range report.Operations {
+		if op.Status == StatusFailed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Vulnerabilities returns the number of vulnerabilities the scans found per severity, summed over the scans.
+func (report *Report) Vulnerabilities() map[string]int {
+	totals := make(map[string]int)
+
+	for _, op := range report.Operations {
+		for severity, count := range op.Vulnerabilities {
+			totals[severity] += count
+		}
+	}
+
+	return totals
+}
+
+// summary returns the highlights of the run: produced digests, vulnerabilities, audit issues and available
+// updates, for the operations of these kinds that ran.
+func (report *Report) summary() []string {
+	var (
+		digests, scans, audits, issues int
+		updates                        []string
+	)
+
+	for _, op := range report.Operations {
+		if op.Digest != "" {
+			digests++
+		}
+
+		if op.Vulnerabilities != nil {
+			scans++
+		}
+
+		if op.Kind == "audit" && (op.Status == StatusSucceeded || op.Status == StatusFailed) {
+			audits++
+			issues += op.Issues
+		}
+
+		if op.Update != "" {
+			updates = append(updates, fmt.Sprintf("%s (%s)", op.Name, op.Update))
+		}
+	}
+
+	var lines []string
+
+	if digests > 0 {
+		lines = append(lines, fmt.Sprintf("Digests produced: %d", digests))
+	}
+
+	if scans > 0 {
+		lines = append(lines, fmt.Sprintf("Vulnerabilities: %s (%d scans)",
+			formatSeverityCounts(report.Vulnerabilities()), scans))
+	}
+
+	if audits > 0 {
+		lines = append(lines, fmt.Sprintf("Audit issues: %d (%d audits)", issues, audits))
+	}
+
+	if len(updates) > 0 {
+		lines = append(lines, "Updates available: "+strings.Join(updates, ", "))
+	}
+
+	return lines
+}
+
+// Write renders the report in the given format.
+func (report *Report) Write(out io.Writer, format ReportFormat) error {
+	switch format {
+	case ReportMarkdown:
+		return report.writeMarkdown(out)
+	case ReportHTML:
+		return report.writeHTML(out)
+	case ReportJUnit:
+		return report.writeJUnit(out)
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidReportFormat, format.value)
+	}
+}
+
+// add records the outcome of an operation.
+func (report *Report) add(op operation, status OperationStatus, started time.Time, duration time.Duration, err error) {
+	entry := OperationReport{
+		Name:     op.operationName(),
+		Kind:     op.kind(),
+		Status:   status,
+		Started:  started,
+		Duration: duration,
+	}
+
+	if err != nil {
+		entry.Error = secretValues.Redact(err.Error())
+	}
+
+	if status == StatusSucceeded || status == StatusFailed {
+		entry.Details = operationDetails(op)
+		entry.Digest = operationDigest(op)
+		operationFigures(op, &entry)
+	}
+
+	report.Operations = append(report.Operations, entry)
+}
+
+// addPlanned records the changes a dry run found an operation would make.
+func (report *Report) addPlanned(op operation, started time.Time, duration time.Duration, changes []string) {
+	report.Operations = append(report.Operations, OperationReport{
+		Name:     op.operationName(),
+		Kind:     op.kind(),
+		Status:   StatusPlanned,
+		Started:  started,
+		Duration: duration,
+		Details:  changes,
+	})
+}
+
+// CommentOnPullRequest posts the execution report (Markdown) as a comment on the pull request (GitHub)
+// or merge request (GitLab) the CI job runs for, after every Execute. Later runs update the same comment.
+// Requires GITHUB_TOKEN (GitHub Actions) or GITLAB_TOKEN (GitLab CI, api scope); skipped otherwise.
+// Also enabled by QUARK_PR_COMMENT=true (set by the CLI --pr-comment flag).
+func (plan *Plan) CommentOnPullRequest(enabled bool) {
+	plan.commentOnPR = enabled
+}
+
+// finishReport completes the report of the last execution (which failed with execErr, if not nil), sends the
+// notifications, then writes and publishes the report where configured.
+// Failures are logged: the report never changes the outcome of the execution.
+func (plan *Plan) finishReport(ctx context.Context, execErr error) {
+	plan.report.Duration = time.Since(plan.report.Started).Round(time.Millisecond)
+
+	plan.sendNotifications(ctx, execErr)
+
+	plan.writeReport()
+	plan.writeTrace()
+	plan.recordRun(ctx, execErr)
+
+	if plan.commentOnPR || plan.processEnv("QUARK_PR_COMMENT", "") == "true" {
+		plan.commentReport(ctx)
+	}
+}
+
+// commentReport upserts the Markdown report as a pull/merge request comment.
+func (plan *Plan) commentReport(ctx context.Context) {
+	commenter, err := prcomment.FromEnv(os.Getenv)
+	if err != nil {
+		plan.log.Warn().Err(err).Msg("failed to detect pull request")
+
+		return
+	}
+
+	if commenter == nil {
+		plan.log.Info().Msg("not running for a pull request with an API token, skipping report comment")
+
+		return
+	}
+
+	// One comment per plan, found again on later runs by this marker
+	marker := fmt.Sprintf("<!-- quark-report:%s -->", plan.name)
+
+	var body strings.Builder
+
+	body.WriteString(marker + "\n")
+
+	if err := plan.report.Write(&body, ReportMarkdown); err != nil {
+		plan.log.Warn().Err(err).Msg("failed to render report comment")
+
+		return
+	}
+
+	if err := commenter.Upsert(ctx, marker, body.String()); err != nil {
+		plan.log.Warn().Err(err).Str("target", commenter.Target()).Msg("failed to comment on pull request")
+
+		return
+	}
+
+	plan.log.Info().Str("target", commenter.Target()).Msg("report comment posted")
+}
+
+// writeReport writes the report of the last execution where configured (QUARK_REPORT or ReportTo).
+func (plan *Plan) writeReport() {
+	report := plan.report
+
+	path := plan.processEnv("QUARK_REPORT", plan.reportPath)
+	format := plan.reportFormat
+
+	if name := plan.processEnv("QUARK_REPORT_FORMAT", ""); name != "" {
+		parsed, err := parseReportFormat(name)
+		if err != nil {
+			plan.log.Warn().Err(err).Msg("failed to write execution report")
+
+			return
+		}
+
+		format = parsed
+	}
+
+	if format == (ReportFormat{}) {
+		format = ReportMarkdown
+	}
+
+	if path == "" {
+		// Format alone (e.g., --report-format html) writes to the default location
+		if plan.processEnv("QUARK_REPORT_FORMAT", "") == "" {
+			return
+		}
+
+		path = "quark-report" + format.extension()
+	}
+
+	var content strings.Builder
+	if err := report.Write(&content, format); err != nil {
+		plan.log.Warn().Err(err).Msg("failed to write execution report")
+
+		return
+	}
+
+	if err := os.WriteFile(path, []byte(content.String()), filesystem.FilePermissionsDefault); err != nil {
+		plan.log.Warn().Err(err).Str("path", path).Msg("failed to write execution report")
+
+		return
+	}
+
+	plan.log.Info().Str("path", path).Str("format", format.String()).Msg("execution report written")
+}
+
+// operationDetails returns the results of an executed operation shown in reports.
+func operationDetails(op operation) []string {
+	var details []string
+
+	switch typed := op.(type) {
+	case *Sync:
+		if typed.DestDigest() != "" {
+			details = append(details, "Destination digest: "+typed.DestDigest())
+		}
+
+		if typed.PreviousDigest() != "" && typed.PreviousDigest() != typed.DestDigest() {
+			details = append(details, "Previous digest: "+typed.PreviousDigest())
+		}
+
+		if typed.DigestTag() != "" {
+			details = append(details, fmt.Sprintf("Digest tag: %s (instead of %s)", typed.DigestTag(), typed.destImage.Version()))
+		}
+
+		if len(typed.Signatures()) > 0 {
+			details = append(details, fmt.Sprintf("Copied signatures: %d", len(typed.Signatures())))
+		}
+
+		if typed.NotifyError() != nil {
+			details = append(details, "Webhook notification failed: "+typed.NotifyError().Error())
+		}
+	case *Scan:
+		for _, summary := range typed.PlatformSummaries() {
+			details = append(details, fmt.Sprintf("%s: %s", summary.Platform, formatSeverityCounts(summary.Counts)))
+		}
+
+		details = append(details, typed.Waived()...)
+	case *Audit:
+		details = append(details, typed.Issues()...)
+		details = append(details, typed.Waived()...)
+	case *Verify:
+		if typed.Signatures() > 0 {
+			details = append(details, fmt.Sprintf("Verified signatures: %d", typed.Signatures()))
+		}
+
+		for _, signer := range typed.Signers() {
+			details = append(details, "Signed by: "+signer)
+		}
+	case *PinBaseImages:
+		if typed.Pinned() > 0 {
+			details = append(details, fmt.Sprintf("Pinned FROM lines: %d", typed.Pinned()))
+		}
+	case *ComposeImages:
+		for _, image := range typed.Images() {
+			if image.UpdateAvailable {
+				details = append(details, fmt.Sprintf("Update available: %s (%s -> %s)",
+					image.Service, image.Reference, image.LatestVersion))
+			}
+
+			if image.Rewritten != image.Reference {
+				details = append(details, fmt.Sprintf("Rewritten: %s -> %s", image.Service, image.Rewritten))
+			}
+		}
+	case *KubernetesManifests:
+		for _, workload := range typed.Workloads() {
+			for _, ctr := range workload.Containers {
+				details = append(details, fmt.Sprintf("%s: %s %s", workload, ctr.Name, ctr.Image))
+			}
+		}
+	case *BaseImageCheck:
+		for _, unpinned := range typed.Unpinned() {
+			details = append(details, "Not pinned by digest: "+unpinned)
+		}
+	case *VersionCheck:
+		if typed.Executed() {
+			if typed.UpdateAvailable() {
+				details = append(details, fmt.Sprintf("Update available: %s -> %s (%s)",
+					typed.CurrentVersion(), typed.LatestVersion(), typed.LatestDigest()))
+			} else {
+				details = append(details, "Up to date: "+typed.CurrentVersion())
+			}
+		}
+	case *Artifact:
+		if typed.Digest() != "" {
+			details = append(details, "Digest: "+typed.Digest())
+		}
+	case *Export:
+		if typed.Digest() != "" {
+			details = append(details, "Digest: "+typed.Digest())
+		}
+	case *Rebase:
+		if typed.Rebased() {
+			details = append(details, "Rebased onto: "+typed.newBase.String())
+		} else if typed.Digest() != "" {
+			details = append(details, "Already based on: "+typed.newBase.String())
+		}
+	case *Flatten:
+		if typed.Flattened() {
+			details = append(details, "Flattened to: "+typed.destImage.String())
+		} else if typed.Digest() != "" {
+			details = append(details, "Already flat: "+typed.destImage.String())
+		}
+	case *Mutate:
+		if typed.Mutated() {
+			details = append(details, "Config changed: "+strings.Join(typed.changes(), ", "))
+		} else if typed.Digest() != "" {
+			details = append(details, "Config already changed: "+strings.Join(typed.changes(), ", "))
+		}
+	case *Index:
+		if typed.Digest() != "" {
+			details = append(details, "Platforms: "+strings.Join(typed.Platforms(), ", "))
+		}
+	case *Prune:
+		for _, deleted := range typed.Deleted() {
+			details = append(details, "Deleted: "+deleted)
+		}
+
+		if typed.Kept() > 0 {
+			details = append(details, fmt.Sprintf("Kept: %d manifests", typed.Kept()))
+		}
+	case *GHCRPackage:
+		if typed.Annotated() {
+			details = append(details, "Annotated: "+typed.Digest())
+		}
+
+		if typed.LinkedRepository() != "" {
+			details = append(details, "Linked repository: "+typed.LinkedRepository())
+		}
+	case *RepositoryDocs:
+		if typed.Updated() {
+			details = append(details, "Updated documentation of "+typed.image.Name())
+		}
+	case *Import:
+		if typed.DestDigest() != "" {
+			details = append(details, "Destination digest: "+typed.DestDigest())
+		}
+	case *Bundle:
+		if typed.ArchiveDigest() != "" {
+			details = append(details, fmt.Sprintf("Archive: %s (%d bytes)", typed.ArchiveDigest(), typed.ArchiveSize()))
+		}
+	case *NodeMaintenance:
+		if len(typed.Reclaimed()) > 0 {
+			details = append(details, "Reclaimed: "+strings.Join(typed.Reclaimed(), ", "))
+		}
+	case *ProvisionNode:
+		if len(typed.Installed()) > 0 {
+			details = append(details, "Installed: "+strings.Join(typed.Installed(), ", "))
+		}
+	case *ContainerdImport:
+		if typed.Digest() != "" {
+			details = append(details, "Digest: "+typed.Digest())
+		}
+
+		if len(typed.Imported()) > 0 {
+			details = append(details, "Imported on: "+strings.Join(typed.Imported(), ", "))
+		}
+	case *HarborProject:
+		if typed.Created() {
+			details = append(details, "Created project: "+typed.project)
+		}
+
+		if typed.rulesAdded > 0 {
+			details = append(details, fmt.Sprintf("Immutability rules added: %d", typed.rulesAdded))
+		}
+
+		if typed.Robot() != "" {
+			details = append(details, "Robot account: "+typed.Robot())
+		}
+	}
+
+	if retried, ok := op.(retryableOperation); ok && retried.retries().made > 1 {
+		details = append(details, fmt.Sprintf("Attempts: %d", retried.retries().made))
+	}
+
+	return details
+}
+
+// producingOperation is implemented by operations producing a digest (pushed image, archive...).
+type producingOperation interface {
+	// producedDigest returns the digest the last execution produced (empty before execution).
+	producedDigest() string
+}
+
+// operationDigest returns the digest an executed operation produced, if any.
+func operationDigest(op operation) string {
+	if producing, ok := op.(producingOperation); ok {
+		return producing.producedDigest()
+	}
+
+	return ""
+}
+
+// operationFigures sets the results of an executed operation the report summarizes: scan vulnerabilities,
+// audit issues and version check updates.
+func operationFigures(op operation, entry *OperationReport) {
+	switch typed := op.(type) {
+	case *Scan:
+		if len(typed.PlatformSummaries()) == 0 {
+			return
+		}
+
+		entry.Vulnerabilities = make(map[string]int)
+
+		for _, summary := range typed.PlatformSummaries() {
+			for severity, count := range summary.Counts {
+				entry.Vulnerabilities[severity] = max(entry.Vulnerabilities[severity], count)
+			}
+		}
+	case *Audit:
+		entry.Issues = len(typed.Issues())
+	case *VersionCheck:
+		if typed.Executed() && typed.UpdateAvailable() {
+			entry.Update = typed.CurrentVersion() + " -> " + typed.LatestVersion()
+		}
+	}
+}
+
+// formatSeverityCounts formats vulnerability counts from most to least severe (e.g., "CRITICAL=1 HIGH=3").
+func formatSeverityCounts(counts map[string]int) string {
+	severities := make([]string, 0, len(counts))
+	for severity := range counts {
+		severities = append(severities, severity)
+	}
+
+	order := map[string]int{
+		SeverityCritical.value: 0,
+		SeverityHigh.value:     1,
+		SeverityMedium.value:   2,
+		SeverityLow.value:      3,
+		SeverityUnknown.value:  4,
+	}
+
+	sort.Slice(severities, func(i, j int) bool {
+		return order[severities[i]] < order[severities[j]]
+	})
+
+	parts := make([]string, 0, len(severities))
+	for _, severity := range severities {
+		parts = append(parts, fmt.Sprintf("%s=%d", severity, counts[severity]))
+	}
+
+	if len(parts) == 0 {
+		return "no vulnerabilities"
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// statusIcon returns the status marker used in rendered reports.
+func statusIcon(status OperationStatus) string {
+	switch status {
+	case StatusSucceeded:
+		return "✅"
+	case StatusFailed:
+		return "❌"
+	case StatusSkipped:
+		return "⏭️"
+	case StatusUnchanged:
+		return "♻️"
+	case StatusPlanned:
+		return "📝"
+	default:
+		return "⏸️"
+	}
+}
+
+// writeMarkdown renders the report as Markdown: a summary, an operation table, then per-operation details
+// in collapsible sections (rendered by GitHub and GitLab).
+func (report *Report) writeMarkdown(out io.Writer) error {
+	var doc strings.Builder
+
+	outcome := "succeeded"
+	if !report.Succeeded() {
+		outcome = "failed"
+	}
+
+	fmt.Fprintf(&doc, "## Quark plan `%s` %s\n\n", report.Plan, outcome)
+	fmt.Fprintf(&doc, "Started %s, took %s.\n\n", report.Started.Format(time.RFC3339), report.Duration)
+
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(&doc, "> ⚠️ %s\n\n", warning)
+	}
+
+	if summary := report.summary(); len(summary) > 0 {
+		for _, line := range summary {
+			fmt.Fprintf(&doc, "- %s\n", line)
+		}
+
+		doc.WriteString("\n")
+	}
+
+	doc.WriteString("| Operation | Kind | Status | Duration | Digest |\n")
+	doc.WriteString("|---|---|---|---|---|\n")
+
+	for _, op := range report.Operations {
+		digest := ""
+		if op.Digest != "" {
+			digest = "`" + op.Digest + "`"
+		}
+
+		fmt.Fprintf(&doc, "| `%s` | %s | %s %s | %s | %s |\n",
+			op.Name, op.Kind, statusIcon(op.Status), op.Status, op.Duration.Round(time.Millisecond), digest)
+	}
+
+	for _, op := range report.Operations {
+		if op.Error == "" && len(op.Details) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&doc, "\n<details><summary><code>%s</code> (%s)</summary>\n\n",
+			template.HTMLEscapeString(op.Name), op.Kind)
+
+		if op.Error != "" {
+			fmt.Fprintf(&doc, "**Error:** `%s`\n\n", strings.ReplaceAll(op.Error, "`", "'"))
+		}
+
+		for _, detail := range op.Details {
+			fmt.Fprintf(&doc, "- %s\n", detail)
+		}
+
+		doc.WriteString("\n</details>\n")
+	}
+
+	if _, err := io.WriteString(out, doc.String()); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return nil
+}
+
+//nolint:gochecknoglobals // Parsed once, immutable
+var reportHTMLTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"icon": statusIcon,
+	"summary": func(report *Report) []string {
+		return report.summary()
+	},
+	"rfc3339": func(t time.Time) string {
+		return t.Format(time.RFC3339)
+	},
+	"round": func(d time.Duration) time.Duration {
+		return d.Round(time.Millisecond)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Quark plan {{.Plan}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #1f2328; }
+table { border-collapse: collapse; margin-bottom: 1.5rem; }
+th, td { border: 1px solid #d0d7de; padding: 0.3rem 0.8rem; text-align: left; }
+details { border: 1px solid #d0d7de; border-radius: 6px; padding: 0.5rem 1rem; margin-bottom: 0.5rem; }
+summary { cursor: pointer; font-weight: 600; }
+.failed { color: #cf222e; }
+.warning { color: #9a6700; }
+.summary { font-weight: 600; }
+pre { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>Quark plan <code>{{.Plan}}</code> {{if .Succeeded}}succeeded{{else}}<span class="failed">failed</span>{{end}}</h1>
+<p>Started {{rfc3339 .Started}}, took {{.Duration}}.</p>
+{{- range .Warnings}}
+<p class="warning">⚠️ {{.}}</p>
+{{- end}}
+{{- with summary .}}
+<ul class="summary">
+{{- range .}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+<table>
+<tr><th>Operation</th><th>Kind</th><th>Status</th><th>Duration</th><th>Digest</th></tr>
+{{- range .Operations}}
+<tr><td><code>{{.Name}}</code></td><td>{{.Kind}}</td><td>{{icon .Status}} {{.Status}}</td><td>{{round .Duration}}</td>
+<td>{{with .Digest}}<code>{{.}}</code>{{end}}</td></tr>
+{{- end}}
+</table>
+{{- range .Operations}}
+{{- if or .Error .Details}}
+<details{{if .Error}} open{{end}}>
+<summary><code>{{.Name}}</code> ({{.Kind}})</summary>
+{{- if .Error}}
+<pre class="failed">{{.Error}}</pre>
+{{- end}}
+{{- if .Details}}
+<ul>
+{{- range .Details}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</details>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// writeHTML renders the report as a standalone HTML page with collapsible per-operation sections.
+func (report *Report) writeHTML(out io.Writer) error {
+	if err := reportHTMLTemplate.Execute(out, report); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package sdk_test
+
+import (
+	"html"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: The execution report lists every operation with its outcome (skipped by environment guard,
+// failed with its error, not reached after the failure), and is written after a failed execution too.
+func TestPlan_Report(t *testing.T) {
+	t.Parallel()
+
+	dest, err := sdk.NewImage("my-org/app-sbom").Domain("ghcr.io").Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	plan := sdk.NewPlan(testPlanName)
+	plan.Environment(sdk.EnvLocal)
+
+	reportPath := filepath.Join(t.TempDir(), "report.html")
+	plan.ReportTo(reportPath, sdk.ReportHTML)
+
+	if plan.Report() != nil {
+		t.Error("Report() before execution is not nil")
+	}
+
+	// Executing an artifact fails before any network access: the file does not exist
+	for _, name := range []string{"ci-only", "publish", "after"} {
+		builder := plan.Artifact(name).
+			Destination(dest).
+			ArtifactType("application/vnd.example.sbom").
+			File(filepath.Join(t.TempDir(), "missing.json"), "application/json")
+
+		if name == "ci-only" {
+			builder.RunOnlyOn(sdk.EnvCI)
+		}
+
+		if _, err := builder.Build(); err != nil {
+			t.Fatalf("Build() failed: %v", err)
+		}
+	}
+
+	if err := plan.Execute(t.Context()); err == nil {
+		t.Fatal("Execute() succeeded, want artifact failure")
+	}
+
+	report := plan.Report()
+	if report == nil || report.Succeeded() {
+		t.Fatalf("Report() = %+v, want failed report", report)
+	}
+
+	want := []sdk.OperationStatus{sdk.StatusSkipped, sdk.StatusFailed, sdk.StatusNotRun}
+	if len(report.Operations) != len(want) {
+		t.Fatalf("report has %d operations, want %d", len(report.Operations), len(want))
+	}
+
+	for idx, op := range report.Operations {
+		if op.Status != want[idx] || op.Kind != "artifact" {
+			t.Errorf("operation %q: status = %q, kind = %q, want %q artifact", op.Name, op.Status, op.Kind, want[idx])
+		}
+	}
+
+	if report.Operations[1].Error == "" {
+		t.Error("failed operation has no error")
+	}
+
+	var markdown strings.Builder
+	if err := report.Write(&markdown, sdk.ReportMarkdown); err != nil {
+		t.Fatalf("Write(markdown) error = %v", err)
+	}
+
+	for _, fragment := range []string{"| `publish` | artifact | ❌ failed |", "<details><summary><code>publish</code>"} {
+		if !strings.Contains(markdown.String(), fragment) {
+			t.Errorf("markdown report missing %q:\n%s", fragment, markdown.String())
+		}
+	}
+
+	html, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("HTML report not written: %v", err)
+	}
+
+	for _, fragment := range []string{"<!DOCTYPE html>", "<details open>", "not run"} {
+		if !strings.Contains(string(html), fragment) {
+			t.Errorf("HTML report missing %q", fragment)
+		}
+	}
+}
+
+// INTENTION: ExecuteWithResult returns the report of the run: operations are found by name with the digest
+// they produced, and by status (skipped operations).
+func TestPlan_ExecuteWithResult(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	digest := pushRandomImage(t, host+"/source/app:1.0.0")
+
+	plan := sdk.NewPlan(testPlanName)
+	plan.Environment(sdk.EnvLocal)
+
+	source, err := sdk.NewImage("source/app").Domain(host).Version("1.0.0").Digest(digest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create source image: %v", err)
+	}
+
+	destination, err := sdk.NewImage("mirror/app").Domain(host).Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create destination image: %v", err)
+	}
+
+	if _, err := plan.Sync("mirror").Source(source).Destination(destination).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if _, err := newTestSync(t, plan, "ci-only").RunOnlyOn(sdk.EnvCI).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	result, err := plan.ExecuteWithResult(t.Context())
+	if err != nil {
+		t.Fatalf("ExecuteWithResult() error = %v", err)
+	}
+
+	mirror, ok := result.Operation("mirror")
+	if !ok || mirror.Status != sdk.StatusSucceeded || mirror.Digest != digest {
+		t.Errorf("Operation(mirror) = %+v, %t, want succeeded with digest %s", mirror, ok, digest)
+	}
+
+	if _, ok := result.Operation("missing"); ok {
+		t.Error("Operation(missing) found")
+	}
+
+	skipped := result.WithStatus(sdk.StatusSkipped)
+	if len(skipped) != 1 || skipped[0].Name != "ci-only" || skipped[0].Digest != "" {
+		t.Errorf("WithStatus(skipped) = %+v, want ci-only without digest", skipped)
+	}
+}
+
+// INTENTION: Rendered reports summarize the run: produced digests, vulnerabilities by severity summed over the
+// scans, audit issues and available updates, and show the produced digests in the operation table.
+func TestReport_Summary(t *testing.T) {
+	t.Parallel()
+
+	report := &sdk.Report{
+		Plan: testPlanName,
+		Operations: []sdk.OperationReport{
+			{Name: "mirror", Kind: "sync", Status: sdk.StatusSucceeded, Digest: testDigest},
+			{
+				Name: "scan-app", Kind: "scan", Status: sdk.StatusSucceeded,
+				Vulnerabilities: map[string]int{"CRITICAL": 1, "HIGH": 2},
+			},
+			{
+				Name: "scan-base", Kind: "scan", Status: sdk.StatusSucceeded,
+				Vulnerabilities: map[string]int{"HIGH": 1},
+			},
+			{Name: "audit-app", Kind: "audit", Status: sdk.StatusFailed, Issues: 3},
+			{Name: "check-alpine", Kind: "version-check", Status: sdk.StatusSucceeded, Update: "3.19 -> 3.20"},
+		},
+	}
+
+	if got := report.Vulnerabilities(); got["CRITICAL"] != 1 || got["HIGH"] != 3 {
+		t.Errorf("Vulnerabilities() = %v, want CRITICAL=1 HIGH=3", got)
+	}
+
+	want := []string{
+		"Digests produced: 1",
+		"Vulnerabilities: CRITICAL=1 HIGH=3 (2 scans)",
+		"Audit issues: 3 (1 audits)",
+		"Updates available: check-alpine (3.19 -> 3.20)",
+	}
+
+	for _, format := range []sdk.ReportFormat{sdk.ReportMarkdown, sdk.ReportHTML} {
+		var rendered strings.Builder
+		if err := report.Write(&rendered, format); err != nil {
+			t.Fatalf("Write(%s) error = %v", format.String(), err)
+		}
+
+		content := html.UnescapeString(rendered.String())
+
+		for _, fragment := range append(want, testDigest) {
+			if !strings.Contains(content, fragment) {
+				t.Errorf("%s report missing %q:\n%s", format.String(), fragment, rendered.String())
+			}
+		}
+	}
+}
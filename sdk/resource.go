@@ -0,0 +1,90 @@
+package sdk
+
+import (
+	"context"
+	"sync"
+)
+
+// Resource is the resource class an operation mostly uses.
+// Concurrent executors bound the operations running at once per class, so that registry-bound operations
+// interleave with CPU-bound ones rather than saturating a single resource.
+type Resource string
+
+const (
+	// ResourceNetwork marks operations bound by registry traffic (e.g., Sync, Export, VersionCheck).
+	ResourceNetwork Resource = "network"
+	// ResourceCPU marks operations bound by local computation (e.g., Scan, Audit).
+	ResourceCPU Resource = "cpu"
+	// ResourceRemote marks operations running on build nodes over SSH (e.g., Build, RemoteRun).
+	ResourceRemote Resource = "remote"
+)
+
+// resourceHint declares the resource class of an operation.
+// Operations embed it; Resource builder methods fill it.
+type resourceHint struct {
+	resource Resource
+}
+
+// declaredResource returns the declared resource class (empty for the operation kind default).
+func (hint *resourceHint) declaredResource() Resource {
+	return hint.resource
+}
+
+// operationResource returns the resource class of op: the declared one, or the default of its kind.
+func operationResource(op operation) Resource {
+	if declared := op.declaredResource(); declared != "" {
+		return declared
+	}
+
+	switch op.(type) {
+	case *Scan, *Audit:
+		return ResourceCPU
+	case *Build, *NodeMaintenance, *ProvisionNode, *ContainerdImport, *RemoteRun:
+		return ResourceRemote
+	default:
+		return ResourceNetwork
+	}
+}
+
+// resourceLimiter bounds how many operations of each resource class run at the same time.
+// Classes without limit are not bounded.
+type resourceLimiter struct {
+	mutex sync.Mutex
+	slots map[Resource]chan struct{}
+}
+
+func newResourceLimiter() *resourceLimiter {
+	return &resourceLimiter{slots: make(map[Resource]chan struct{})}
+}
+
+// setLimit bounds the operations of class resource running at once (0 removes the bound).
+func (limiter *resourceLimiter) setLimit(resource Resource, limit int) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	if limit <= 0 {
+		delete(limiter.slots, resource)
+
+		return
+	}
+
+	limiter.slots[resource] = make(chan struct{}, limit)
+}
+
+// acquire waits for a slot of class resource, and returns the function releasing it.
+func (limiter *resourceLimiter) acquire(ctx context.Context, resource Resource) (func(), error) {
+	limiter.mutex.Lock()
+	slots := limiter.slots[resource]
+	limiter.mutex.Unlock()
+
+	if slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,121 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// retryPolicy declares how many times an operation is attempted before it fails.
+// Operations embed it; Retry builder methods fill it.
+type retryPolicy struct {
+	configured bool
+	attempts   int
+	backoff    time.Duration
+
+	// Attempts made by the last execution
+	made int
+}
+
+// retryableOperation is implemented by operations with a retry policy.
+type retryableOperation interface {
+	retries() *retryPolicy
+}
+
+// retries returns the retry policy of the operation.
+func (policy *retryPolicy) retries() *retryPolicy {
+	return policy
+}
+
+// set configures the policy, from a Retry builder method.
+func (policy *retryPolicy) set(attempts int, backoff time.Duration) {
+	policy.configured = true
+	policy.attempts = attempts
+	policy.backoff = backoff
+}
+
+// clone returns a copy of the policy, without execution results, for builder Clone() methods.
+func (policy *retryPolicy) clone() retryPolicy {
+	return retryPolicy{configured: policy.configured, attempts: policy.attempts, backoff: policy.backoff}
+}
+
+// validate rejects policies without attempts or with a negative backoff, at Build() time.
+// Operations without policy are attempted once.
+func (policy *retryPolicy) validate() error {
+	if policy.configured && (policy.attempts < 1 || policy.backoff < 0) {
+		return fmt.Errorf("%w: %d attempts, backoff %s", ErrInvalidRetryPolicy, policy.attempts, policy.backoff)
+	}
+
+	return nil
+}
+
+// permanentErrors are failures retrying cannot fix: findings, integrity failures and registry answers that
+// do not change from one attempt to the next.
+//
+//nolint:gochecknoglobals // Immutable list of sentinel errors
+var permanentErrors = []error{
+	ErrVulnerabilitiesFound,
+	ErrKnownExploitedFound,
+	ErrDigestMismatch,
+	ErrScanMustHaveDigest,
+	ErrBuildNodeDiskSpace,
+	ErrRegistryUnauthorized,
+	ErrRegistryForbidden,
+	ErrRegistryNotFound,
+	ErrRegistryTagImmutable,
+	ErrRegistryUnhealthy,
+	ErrRegistryManifestRewritten,
+}
+
+// retryable reports whether an operation that failed with err may succeed on another attempt.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	for _, permanent := range permanentErrors {
+		if errors.Is(err, permanent) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// executeRetried executes op, attempting it again after transient failures as its retry policy allows.
+// The backoff doubles after each failed attempt; the operation timeout applies to each attempt.
+func (plan *Plan) executeRetried(ctx context.Context, op operation) error {
+	retried, ok := op.(retryableOperation)
+	if !ok {
+		return plan.executeLimited(ctx, op)
+	}
+
+	policy := retried.retries()
+	backoff := policy.backoff
+
+	for attempt := 1; ; attempt++ {
+		policy.made = attempt
+
+		err := plan.executeLimited(ctx, op)
+		if err == nil || attempt >= policy.attempts || !retryable(ctx, err) {
+			return err
+		}
+
+		plan.log.Warn().
+			Err(err).
+			Str("operation", op.operationName()).
+			Int("attempt", attempt).
+			Int("attempts", policy.attempts).
+			Dur("backoff", backoff).
+			Msg("operation failed, retrying")
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
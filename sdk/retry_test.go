@@ -0,0 +1,111 @@
+package sdk_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: Operations with a retry policy are attempted again after a transient failure, and the report
+// tells how many attempts they took; failures retrying cannot fix (a missing image) are not retried.
+func TestSync_Retry(t *testing.T) {
+	t.Parallel()
+
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+
+	// The first manifest request of the mirror fails with an error the client does not retry itself
+	var failed atomic.Bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/v2/mirror/app/manifests/1.0.0" && failed.CompareAndSwap(false, true) {
+			writer.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		handler.ServeHTTP(writer, req)
+	}))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+	digest := pushRandomImage(t, host+"/source/app:1.0.0")
+
+	tests := []struct {
+		name         string
+		digest       string
+		wantErr      bool
+		wantAttempts string
+	}{
+		{name: "transient", digest: digest, wantAttempts: "Attempts: 2"},
+		{name: "missing", digest: testDigest, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		plan := sdk.NewPlan(testPlanName)
+
+		source, err := sdk.NewImage("source/app").Domain(host).Version("1.0.0").Digest(tt.digest).Build()
+		if err != nil {
+			t.Fatalf("Failed to create source image: %v", err)
+		}
+
+		destination, err := sdk.NewImage("mirror/app").Domain(host).Version("1.0.0").Build()
+		if err != nil {
+			t.Fatalf("Failed to create destination image: %v", err)
+		}
+
+		if _, err := plan.Sync(tt.name).
+			Source(source).
+			Destination(destination).
+			Retry(3, time.Millisecond).
+			Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		if err := plan.Execute(t.Context()); (err != nil) != tt.wantErr {
+			t.Fatalf("%s: Execute() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+
+		attempts := ""
+
+		for _, detail := range plan.Report().Operations[0].Details {
+			if strings.HasPrefix(detail, "Attempts:") {
+				attempts = detail
+			}
+		}
+
+		if attempts != tt.wantAttempts {
+			t.Errorf("%s: Report() attempts = %q, want %q", tt.name, attempts, tt.wantAttempts)
+		}
+	}
+}
+
+// INTENTION: A retry policy needs at least one attempt and a non-negative backoff.
+func TestSyncBuilder_InvalidRetry(t *testing.T) {
+	t.Parallel()
+
+	for _, policy := range []struct {
+		attempts int
+		backoff  time.Duration
+	}{{0, 0}, {2, -time.Second}} {
+		_, err := newTestSync(t, sdk.NewPlan(testPlanName), "mirror").Retry(policy.attempts, policy.backoff).Build()
+		if !errors.Is(err, sdk.ErrInvalidRetryPolicy) {
+			t.Errorf("Retry(%d, %s) Build() error = %v, want %v", policy.attempts, policy.backoff, err, sdk.ErrInvalidRetryPolicy)
+		}
+	}
+}
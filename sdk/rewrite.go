@@ -0,0 +1,58 @@
+package sdk
+
+import (
+	"fmt"
+	"strings"
+)
+
+// rewriteRule maps image names matching a pattern to another name.
+type rewriteRule struct {
+	pattern     string
+	replacement string
+}
+
+// RewriteRule adds a rule deriving sync destinations from their source: a sync built without Destination()
+// pushes to the source image name rewritten by the first matching rule, with the source version
+// (e.g., RewriteRule("docker.io/library/*", "registry.internal/mirror/docker.io/library/*") mirrors
+// alpine:3.20 as registry.internal/mirror/docker.io/library/alpine:3.20).
+// Patterns match the normalized image name (domain and path, e.g., "docker.io/library/alpine"). A pattern may
+// contain a single "*", matching any sequence of characters, which the "*" of the replacement receives.
+// Rules apply to syncs built after they are added.
+func (plan *Plan) RewriteRule(pattern, replacement string) error {
+	wildcards := strings.Count(pattern, "*")
+
+	if pattern == "" || replacement == "" || wildcards > 1 || strings.Count(replacement, "*") != wildcards {
+		return fmt.Errorf("%w: %q -> %q", ErrInvalidRewriteRule, pattern, replacement)
+	}
+
+	plan.rewriteRules = append(plan.rewriteRules, rewriteRule{pattern: pattern, replacement: replacement})
+
+	return nil
+}
+
+// rewrite returns the name of image rewritten by the first matching rule, and whether a rule matched.
+func (plan *Plan) rewrite(image *Image) (string, bool) {
+	name := image.Domain() + "/" + image.Path()
+
+	for _, rule := range plan.rewriteRules {
+		if rewritten, ok := rule.apply(name); ok {
+			return rewritten, true
+		}
+	}
+
+	return "", false
+}
+
+// apply returns name rewritten by the rule, and whether the rule matches name.
+func (rule rewriteRule) apply(name string) (string, bool) {
+	prefix, suffix, wildcard := strings.Cut(rule.pattern, "*")
+	if !wildcard {
+		return rule.replacement, name == rule.pattern
+	}
+
+	if len(name) < len(prefix)+len(suffix) || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+		return "", false
+	}
+
+	return strings.Replace(rule.replacement, "*", name[len(prefix):len(name)-len(suffix)], 1), true
+}
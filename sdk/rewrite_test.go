@@ -0,0 +1,72 @@
+package sdk_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: Syncs without destination are pushed to their source rewritten by the first matching plan rule,
+// with the source version; sources no rule matches still require a destination.
+func TestPlan_RewriteRule(t *testing.T) {
+	t.Parallel()
+
+	plan := sdk.NewPlan(testPlanName)
+
+	for _, rule := range [][2]string{
+		{"docker.io/library/*", "registry.internal/mirror/docker.io/library/*"},
+		{"ghcr.io/*/tools", "registry.internal/*/tools"},
+		{"docker.io/*", "registry.internal/hub/*"},
+	} {
+		if err := plan.RewriteRule(rule[0], rule[1]); err != nil {
+			t.Fatalf("RewriteRule(%q, %q) error = %v", rule[0], rule[1], err)
+		}
+	}
+
+	tests := []struct {
+		source  string
+		want    string
+		wantErr error
+	}{
+		{source: "alpine", want: "registry.internal/mirror/docker.io/library/alpine:3.20"},
+		{source: "timberio/vector", want: "registry.internal/hub/timberio/vector:3.20"},
+		{source: "ghcr.io/acme/tools", want: "registry.internal/acme/tools:3.20"},
+		{source: "quay.io/acme/app", wantErr: sdk.ErrSyncDestinationRequired},
+	}
+
+	for _, tt := range tests {
+		source, err := sdk.NewImage(tt.source).Version("3.20").Digest(testDigest).Build()
+		if err != nil {
+			t.Fatalf("Failed to create source image: %v", err)
+		}
+
+		sync, err := plan.Sync("mirror-" + tt.source).Source(source).Build()
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("Build(%q) error = %v, want %v", tt.source, err, tt.wantErr)
+
+			continue
+		}
+
+		if err == nil && sync.Destination().String() != tt.want {
+			t.Errorf("Build(%q) destination = %q, want %q", tt.source, sync.Destination(), tt.want)
+		}
+	}
+}
+
+// INTENTION: Rewrite rules have at most one wildcard, present in both the pattern and the replacement.
+func TestPlan_RewriteRule_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, rule := range [][2]string{
+		{"", "registry.internal/*"},
+		{"docker.io/*", ""},
+		{"docker.io/*/*", "registry.internal/*/*"},
+		{"docker.io/*", "registry.internal/mirror"},
+		{"docker.io/library/alpine", "registry.internal/*"},
+	} {
+		if err := sdk.NewPlan(testPlanName).RewriteRule(rule[0], rule[1]); !errors.Is(err, sdk.ErrInvalidRewriteRule) {
+			t.Errorf("RewriteRule(%q, %q) error = %v, want %v", rule[0], rule[1], err, sdk.ErrInvalidRewriteRule)
+		}
+	}
+}
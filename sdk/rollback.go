@@ -0,0 +1,217 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// Rollback represents re-pointing a destination tag at a prior digest.
+type Rollback struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	image    *Image
+	registry *Registry
+	digest   string
+}
+
+// RollbackBuilder builds a Rollback.
+type RollbackBuilder struct {
+	builderState
+
+	plan     *Plan
+	rollback *Rollback
+}
+
+// Image sets the destination image whose tag will be re-pointed.
+// The image must have a version (the tag to roll back).
+// Registry credentials are looked up from the plan's registry collection using the image domain.
+func (builder *RollbackBuilder) Image(image *Image) *RollbackBuilder {
+	builder.rollback.image = image
+	builder.rollback.registry = builder.plan.getRegistry(image.Domain())
+
+	return builder
+}
+
+// ToDigest sets the digest the tag should point to after the rollback.
+// Typically the value recorded by Sync.RecordPreviousDigest (Sync.PreviousDigest()).
+func (builder *RollbackBuilder) ToDigest(previousDigest string) *RollbackBuilder {
+	builder.rollback.digest = previousDigest
+
+	return builder
+}
+
+// RunOnlyOn restricts the rollback to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *RollbackBuilder) RunOnlyOn(envs ...Environment) *RollbackBuilder {
+	builder.rollback.runOnlyOn = append(builder.rollback.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the rollback mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *RollbackBuilder) Resource(resource Resource) *RollbackBuilder {
+	builder.rollback.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the rollback start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *RollbackBuilder) DependsOn(ops ...Dependency) *RollbackBuilder {
+	builder.rollback.add(ops)
+
+	return builder
+}
+
+// When makes the rollback run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the rollback.
+func (builder *RollbackBuilder) When(conditions ...Condition) *RollbackBuilder {
+	builder.rollback.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a rollback named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *RollbackBuilder) Clone(name string) *RollbackBuilder {
+	clone := builder.plan.Rollback(name)
+	clone.rollback.envGuard = builder.rollback.envGuard.clone()
+	clone.rollback.resourceHint = builder.rollback.resourceHint
+	clone.rollback.dependencyList = builder.rollback.dependencyList.clone()
+	clone.rollback.conditionList = builder.rollback.conditionList.clone()
+	clone.rollback.image = builder.rollback.image
+	clone.rollback.registry = builder.rollback.registry
+	clone.rollback.digest = builder.rollback.digest
+
+	return clone
+}
+
+// Reset makes the builder usable again for a rollback named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *RollbackBuilder) Reset(name string) *RollbackBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build validates and adds the rollback to the plan.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *RollbackBuilder) Build() (*Rollback, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.rollback.opName); err != nil {
+		return nil, err
+	}
+
+	if builder.rollback.image == nil {
+		return nil, ErrRollbackImageRequired
+	}
+
+	if builder.rollback.image.Version() == "" {
+		return nil, fmt.Errorf("%w for image %q", ErrRollbackVersionRequired, builder.rollback.image.Name())
+	}
+
+	if builder.rollback.digest == "" {
+		return nil, ErrRollbackDigestRequired
+	}
+
+	if _, err := digest.Parse(builder.rollback.digest); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidImageDigest, err)
+	}
+
+	builder.plan.rollbacks = append(builder.plan.rollbacks, builder.rollback)
+	builder.plan.addOperation(builder.rollback)
+
+	return builder.rollback, nil
+}
+
+func (rollback *Rollback) execute(ctx context.Context) error {
+	tagRef, err := rollback.image.tagRef()
+	if err != nil {
+		return fmt.Errorf("failed to build tag reference: %w", err)
+	}
+
+	digestRef := rollback.image.ref.Name() + "@" + rollback.digest
+
+	rollback.log.Info().
+		Str("tag", tagRef).
+		Str("digest", rollback.digest).
+		Msg("rolling back tag")
+
+	client := newRegistryClient(rollback.registry, rollback.log)
+
+	// Verify the target digest still exists (registries may have garbage-collected it)
+	exists, err := client.CheckExists(ctx, digestRef)
+	if err != nil {
+		return fmt.Errorf("failed to verify rollback digest: %w", err)
+	}
+
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrRollbackDigestNotFound, digestRef)
+	}
+
+	if err := client.Tag(ctx, digestRef, tagRef); err != nil {
+		return fmt.Errorf("failed to re-point tag: %w", err)
+	}
+
+	// Subsequent operations (e.g., scanning) see the rolled back digest
+	rollback.image.ref.Digest = digest.Digest(rollback.digest)
+
+	rollback.log.Info().
+		Str("tag", tagRef).
+		Str("digest", rollback.digest).
+		Msg("rollback complete")
+
+	return nil
+}
+
+// plannedChanges implements dryRunOperation: the target digest must still exist.
+func (rollback *Rollback) plannedChanges(ctx context.Context) ([]string, error) {
+	tagRef, err := rollback.image.tagRef()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build tag reference: %w", err)
+	}
+
+	digestRef := rollback.image.ref.Name() + "@" + rollback.digest
+
+	exists, err := newRegistryClient(rollback.registry, rollback.log).CheckExists(ctx, digestRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify rollback digest: %w", err)
+	}
+
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrRollbackDigestNotFound, digestRef)
+	}
+
+	return []string{fmt.Sprintf("Would re-point %s to %s", tagRef, rollback.digest)}, nil
+}
+
+// destructiveChange implements destructiveOperation: a rollback re-points an existing tag.
+func (rollback *Rollback) destructiveChange(ctx context.Context) (string, error) {
+	tagRef, err := rollback.image.tagRef()
+	if err != nil {
+		return "", fmt.Errorf("failed to build tag reference: %w", err)
+	}
+
+	return tagOverwrite(ctx, newRegistryClient(rollback.registry, rollback.log), tagRef, rollback.digest)
+}
+
+// producedDigest implements producingOperation.
+func (rollback *Rollback) producedDigest() string {
+	return rollback.digest
+}
+
+// resolveRegistries implements registryOperation.
+func (rollback *Rollback) resolveRegistries(lookup func(image *Image) *Registry) {
+	rollback.registry = lookup(rollback.image)
+}
@@ -0,0 +1,95 @@
+package sdk_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: Rollback requires a tagged image and a valid target digest.
+func TestRollbackBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	tagged, err := sdk.NewImage("my-org/alpine").
+		Domain("ghcr.io").
+		Version("3.20").
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	untagged, err := sdk.NewImage("my-org/alpine").
+		Domain("ghcr.io").
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		build   func(*sdk.Plan) (*sdk.Rollback, error)
+		wantErr error
+	}{
+		{
+			name: "valid rollback",
+			build: func(plan *sdk.Plan) (*sdk.Rollback, error) {
+				return plan.Rollback("rollback").Image(tagged).ToDigest(testDigest).Build()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "missing image",
+			build: func(plan *sdk.Plan) (*sdk.Rollback, error) {
+				return plan.Rollback("rollback").ToDigest(testDigest).Build()
+			},
+			wantErr: sdk.ErrRollbackImageRequired,
+		},
+		{
+			name: "image without version",
+			build: func(plan *sdk.Plan) (*sdk.Rollback, error) {
+				return plan.Rollback("rollback").Image(untagged).ToDigest(testDigest).Build()
+			},
+			wantErr: sdk.ErrRollbackVersionRequired,
+		},
+		{
+			name: "missing digest",
+			build: func(plan *sdk.Plan) (*sdk.Rollback, error) {
+				return plan.Rollback("rollback").Image(tagged).Build()
+			},
+			wantErr: sdk.ErrRollbackDigestRequired,
+		},
+		{
+			name: "invalid digest",
+			build: func(plan *sdk.Plan) (*sdk.Rollback, error) {
+				return plan.Rollback("rollback").Image(tagged).ToDigest("sha256:nope").Build()
+			},
+			wantErr: sdk.ErrInvalidImageDigest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			plan := sdk.NewPlan(testPlanName)
+			rollback, err := tt.build(plan)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Build() unexpected error = %v", err)
+			}
+
+			if rollback == nil {
+				t.Error("Build() returned nil rollback with nil error")
+			}
+		})
+	}
+}
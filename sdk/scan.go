@@ -3,12 +3,16 @@ package sdk
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
-	"github.com/rs/zerolog"
-
+	"github.com/farcloser/quark/internal/exceptions"
+	"github.com/farcloser/quark/internal/history"
 	"github.com/farcloser/quark/internal/trivy"
 )
 
@@ -183,30 +187,69 @@ type ScanSeverityCheck struct {
 	action    ScanAction
 }
 
+// ScanPlatformSummary summarizes the findings for a single platform.
+type ScanPlatformSummary struct {
+	// Platform is the scanned platform (e.g., "linux/arm64").
+	Platform string
+	// Counts is the number of vulnerabilities per severity (e.g., "HIGH": 3).
+	Counts map[string]int
+	// Unique lists vulnerability IDs found only on this platform.
+	Unique []string
+}
+
 // Scan represents a vulnerability scan operation.
 type Scan struct {
-	opName         string
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+	retryPolicy
+
 	image          *Image
 	registry       *Registry
 	severityChecks []ScanSeverityCheck
+	evaluateAll    bool
 	format         ScanFormat
+	platforms      []Platform
 	timeout        time.Duration
-	log            zerolog.Logger
+
+	// serverURL and serverToken are set by executor before execution (Plan.ScannerServer)
+	serverURL   string
+	serverToken string
+
+	// history is set by executor before execution (nil when Plan.History is unset)
+	history *historyRecorder
+
+	// Fail when findings are known to be exploited; exploits is set by executor before execution
+	// (nil when findings are not enriched)
+	failOnKnownExploited bool
+	exploits             *exploitCatalog
+
+	// exceptions is set by executor before execution (nil when Plan.Exceptions is unset)
+	exceptions *exceptions.Set
+
+	// Results populated after execution
+	platformSummaries []ScanPlatformSummary
+	waived            []string
 }
 
 // ScanBuilder builds a Scan.
 type ScanBuilder struct {
-	plan  *Plan
-	scan  *Scan
-	built bool
+	builderState
+
+	plan *Plan
+	scan *Scan
 }
 
 // Source sets the image to scan.
-// The image must have a digest specified for secure scanning.
+// The image must have a digest specified for secure scanning, or be the output of another operation (see
+// Sync.OutputImage): the scan then depends on it, and scans the produced digest.
 // Registry credentials are looked up from the plan's registry collection using the image domain.
 // If no registry is found, anonymous access will be used.
 func (builder *ScanBuilder) Source(image *Image) *ScanBuilder {
 	builder.scan.image = image
+	builder.scan.consume(image)
 	builder.scan.registry = builder.plan.getRegistry(image.Domain())
 
 	return builder
@@ -214,7 +257,7 @@ func (builder *ScanBuilder) Source(image *Image) *ScanBuilder {
 
 // Severity adds a severity threshold check.
 // If action is not provided, defaults to ActionError (fail on match).
-// Multiple calls are processed sequentially - first Error stops execution.
+// Multiple calls are processed sequentially - first Error stops execution, unless EvaluateAll is enabled.
 //
 // Examples:
 //
@@ -235,6 +278,24 @@ func (builder *ScanBuilder) Severity(threshold ScanSeverity, action ...ScanActio
 	return builder
 }
 
+// EvaluateAll enables evaluating every severity check before failing: instead of stopping at the first
+// ActionError check with findings, all checks report their findings (warnings and info included), and the scan
+// then fails with ErrVulnerabilitiesFound naming every failed threshold.
+func (builder *ScanBuilder) EvaluateAll(enabled bool) *ScanBuilder {
+	builder.scan.evaluateAll = enabled
+
+	return builder
+}
+
+// FailOnKnownExploited enables failing the scan with ErrKnownExploitedFound when a finding, of any severity,
+// is in the catalog of vulnerabilities known to be exploited in the wild (CISA KEV, see Plan.KnownExploitedFeed).
+// The check runs before severity checks: actually exploited vulnerabilities come first.
+func (builder *ScanBuilder) FailOnKnownExploited(enabled bool) *ScanBuilder {
+	builder.scan.failOnKnownExploited = enabled
+
+	return builder
+}
+
 // Format sets the output format.
 func (builder *ScanBuilder) Format(format ScanFormat) *ScanBuilder {
 	builder.scan.format = format
@@ -242,6 +303,16 @@ func (builder *ScanBuilder) Format(format ScanFormat) *ScanBuilder {
 	return builder
 }
 
+// Platforms sets the platforms to scan.
+// Each platform image of a multi-platform index is scanned separately; findings are
+// aggregated for severity checks and compared per architecture.
+// Defaults to the plan default platforms (see Plan.DefaultPlatforms).
+func (builder *ScanBuilder) Platforms(platforms ...Platform) *ScanBuilder {
+	builder.scan.platforms = platforms
+
+	return builder
+}
+
 // Timeout sets the operation timeout.
 // If not set, the operation will use the context timeout from Plan.Execute().
 func (builder *ScanBuilder) Timeout(duration time.Duration) *ScanBuilder {
@@ -250,15 +321,92 @@ func (builder *ScanBuilder) Timeout(duration time.Duration) *ScanBuilder {
 	return builder
 }
 
+// RunOnlyOn restricts the scan to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *ScanBuilder) RunOnlyOn(envs ...Environment) *ScanBuilder {
+	builder.scan.runOnlyOn = append(builder.scan.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the scan mostly uses (default: sdk.ResourceCPU),
+// for executors bounding how many operations of each class run at once.
+func (builder *ScanBuilder) Resource(resource Resource) *ScanBuilder {
+	builder.scan.resource = resource
+
+	return builder
+}
+
+// Retry attempts the scan up to attempts times (including the first) when it fails with a transient error
+// (e.g., a registry or SSH connection failure), waiting backoff before the second attempt and twice as long
+// before each next one. Failures retrying cannot fix (e.g., authentication, missing images, digest mismatches)
+// are not retried. The timeout, if any, applies to each attempt.
+func (builder *ScanBuilder) Retry(attempts int, backoff time.Duration) *ScanBuilder {
+	builder.scan.set(attempts, backoff)
+
+	return builder
+}
+
+// DependsOn makes the scan start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *ScanBuilder) DependsOn(ops ...Dependency) *ScanBuilder {
+	builder.scan.add(ops)
+
+	return builder
+}
+
+// When makes the scan run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the scan.
+func (builder *ScanBuilder) When(conditions ...Condition) *ScanBuilder {
+	builder.scan.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a scan named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *ScanBuilder) Clone(name string) *ScanBuilder {
+	clone := builder.plan.Scan(name)
+	clone.scan.envGuard = builder.scan.envGuard.clone()
+	clone.scan.resourceHint = builder.scan.resourceHint
+	clone.scan.dependencyList = builder.scan.dependencyList.clone()
+	clone.scan.conditionList = builder.scan.conditionList.clone()
+	clone.scan.retryPolicy = builder.scan.retryPolicy.clone()
+	clone.scan.image = builder.scan.image
+	clone.scan.registry = builder.scan.registry
+	clone.scan.severityChecks = slices.Clone(builder.scan.severityChecks)
+	clone.scan.evaluateAll = builder.scan.evaluateAll
+	clone.scan.failOnKnownExploited = builder.scan.failOnKnownExploited
+	clone.scan.format = builder.scan.format
+	clone.scan.platforms = slices.Clone(builder.scan.platforms)
+	clone.scan.timeout = builder.scan.timeout
+
+	return clone
+}
+
+// Reset makes the builder usable again for a scan named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *ScanBuilder) Reset(name string) *ScanBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
 // Build validates and adds the scan to the plan.
 // The builder becomes unusable after Build() is called.
-// Create a new builder for each operation.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
 func (builder *ScanBuilder) Build() (*Scan, error) {
-	if builder.built {
-		return nil, ErrBuilderAlreadyUsed
+	if err := builder.use(); err != nil {
+		return nil, err
 	}
 
-	builder.built = true
+	if err := builder.plan.checkOperationName(builder.scan.opName); err != nil {
+		return nil, err
+	}
+
+	if err := builder.scan.validate(); err != nil {
+		return nil, err
+	}
 
 	if builder.scan.image == nil {
 		return nil, ErrScanImageRequired
@@ -276,8 +424,10 @@ func (builder *ScanBuilder) Build() (*Scan, error) {
 		builder.scan.format = FormatTable
 	}
 
+	builder.scan.platforms = builder.plan.platformsOrDefault(builder.scan.platforms)
+
 	builder.plan.scans = append(builder.plan.scans, builder.scan)
-	builder.plan.operations = append(builder.plan.operations, builder.scan)
+	builder.plan.addOperation(builder.scan)
 
 	return builder.scan, nil
 }
@@ -316,13 +466,22 @@ func (scan *Scan) execute(ctx context.Context) error {
 		imageRef = scan.image.Name()
 	}
 
+	// Artifacts (WASM modules, signatures, charts, ...) cannot be scanned as container images
+	if err := newRegistryClient(scan.registry, scan.log).CheckRunnable(ctx, imageRef); err != nil {
+		return fmt.Errorf("cannot scan %s: %w", imageRef, err)
+	}
+
 	scan.log.Info().
 		Str("image", imageRef).
 		Str("format", scan.format.String()).
 		Msg("scanning image")
 
-	// Create Trivy scanner
-	scanner := trivy.NewScanner(scan.log)
+	// Create Trivy scanner (client mode if a server is configured)
+	scanner := trivy.NewScanner(scan.log).WithServer(scan.serverURL, scan.serverToken)
+
+	if scan.serverURL != "" {
+		scan.log.Debug().Str("server", scan.serverURL).Msg("using trivy server")
+	}
 
 	// Extract registry credentials if provided
 	var registryHost, username, password string
@@ -341,9 +500,15 @@ func (scan *Scan) execute(ctx context.Context) error {
 		trivy.SeverityCritical,
 	}
 
-	result, err := scanner.ScanImage(
+	platforms := make([]string, 0, len(scan.platforms))
+	for _, platform := range scan.platforms {
+		platforms = append(platforms, platform.String())
+	}
+
+	platformResults, err := scanner.ScanPlatforms(
 		ctx,
 		imageRef,
+		platforms,
 		allSeverities,
 		scan.format.String(),
 		registryHost,
@@ -354,7 +519,49 @@ func (scan *Scan) execute(ctx context.Context) error {
 		return fmt.Errorf("failed to scan image: %w", err)
 	}
 
-	// Process severity checks sequentially (fail-fast on first Error)
+	if err := scan.enrich(ctx, platformResults); err != nil {
+		return err
+	}
+
+	emitScanFindings(ctx, scan.opName, platformResults)
+
+	// Compare findings per architecture
+	scan.platformSummaries = summarizePlatforms(platformResults)
+
+	for _, summary := range scan.platformSummaries {
+		event := scan.log.Info().Str("platform", summary.Platform)
+		for severity, count := range summary.Counts {
+			event = event.Int(strings.ToLower(severity), count)
+		}
+
+		event.Strs("unique", summary.Unique).Msg("platform findings")
+	}
+
+	// Record findings before severity checks, which may fail the scan
+	scan.history.record(scan.opName, scan.image, history.Record{
+		Kind:            history.KindScan,
+		Vulnerabilities: historyVulnerabilities(platformResults),
+	})
+
+	result := scan.waive(trivy.Aggregate(platformResults), imageRef)
+
+	exploited, err := scan.reportKnownExploited(scanner, result)
+	if err != nil {
+		return err
+	}
+
+	var exploitedErr error
+
+	if len(exploited) > 0 {
+		exploitedErr = fmt.Errorf("%w: %s", ErrKnownExploitedFound, strings.Join(exploited, ", "))
+		if !scan.evaluateAll {
+			return exploitedErr
+		}
+	}
+
+	// Process severity checks sequentially (fail-fast on first Error, unless evaluating them all)
+	var failed []string
+
 	for _, check := range scan.severityChecks {
 		// Get vulnerabilities at or above this threshold
 		matchingVulns := getVulnerabilitiesAtOrAbove(result, check.threshold)
@@ -387,7 +594,11 @@ func (scan *Scan) execute(ctx context.Context) error {
 				Msg(msgVulnerabilitiesFound)
 			scan.log.Error().Msg(output)
 
-			return fmt.Errorf("%w: %s", ErrVulnerabilitiesFound, check.threshold)
+			if !scan.evaluateAll {
+				return fmt.Errorf("%w: %s", ErrVulnerabilitiesFound, check.threshold)
+			}
+
+			failed = append(failed, check.threshold.String())
 
 		case ActionWarn:
 			scan.log.Warn().
@@ -405,11 +616,176 @@ func (scan *Scan) execute(ctx context.Context) error {
 		}
 	}
 
+	if len(failed) > 0 {
+		return errors.Join(exploitedErr, fmt.Errorf("%w: %s", ErrVulnerabilitiesFound, strings.Join(failed, ", ")))
+	}
+
+	if exploitedErr != nil {
+		return exploitedErr
+	}
+
 	scan.log.Info().Msg("scan complete")
 
 	return nil
 }
 
+// enrich flags the findings known to be exploited, when the scan uses the catalog.
+// A catalog that cannot be loaded fails scans gating on it, and only degrades the others.
+func (scan *Scan) enrich(ctx context.Context, results []trivy.PlatformResult) error {
+	if scan.exploits == nil {
+		return nil
+	}
+
+	catalog, err := scan.exploits.load(ctx)
+	if err != nil {
+		if scan.failOnKnownExploited {
+			return err
+		}
+
+		scan.log.Warn().Err(err).Msg("findings not enriched with known exploited vulnerabilities")
+
+		return nil
+	}
+
+	scan.log.Debug().
+		Int("catalog", catalog.Len()).
+		Int("flagged", catalog.Enrich(results)).
+		Msg("findings enriched with known exploited vulnerabilities")
+
+	return nil
+}
+
+// reportKnownExploited logs the findings known to be exploited when the scan gates on them,
+// and returns their sorted IDs (none when the scan does not gate on them).
+func (scan *Scan) reportKnownExploited(scanner *trivy.Scanner, result *trivy.ScanResult) ([]string, error) {
+	if !scan.failOnKnownExploited {
+		return nil, nil
+	}
+
+	exploited := trivy.GetKnownExploited(result)
+	if len(exploited) == 0 {
+		return nil, nil
+	}
+
+	output, err := scanner.FormatOutput(&trivy.ScanResult{
+		Results: []trivy.Result{{Target: result.Results[0].Target, Vulnerabilities: exploited}},
+	}, scan.format.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format output: %w", err)
+	}
+
+	ids := make([]string, 0, len(exploited))
+	for _, vuln := range exploited {
+		ids = append(ids, vuln.VulnerabilityID)
+	}
+
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	scan.log.Error().Strs("vulnerabilities", ids).Msg("known exploited vulnerabilities found")
+	scan.log.Error().Msg(output)
+
+	return ids, nil
+}
+
+// waive returns result without the findings waived by the plan exceptions (see Plan.Exceptions),
+// and records the exceptions applied. Platform summaries and history keep waived findings.
+func (scan *Scan) waive(result *trivy.ScanResult, imageRef string) *trivy.ScanResult {
+	scan.waived = nil
+
+	applicable := waivers(scan.exceptions, imageRef, time.Now().UTC())
+	if len(applicable) == 0 {
+		return result
+	}
+
+	applied := make(map[string]bool)
+	kept := &trivy.ScanResult{Results: make([]trivy.Result, 0, len(result.Results))}
+
+	for _, target := range result.Results {
+		filtered := trivy.Result{Target: target.Target}
+
+		for _, vuln := range target.Vulnerabilities {
+			exception, ok := waivedBy(applicable, vuln.VulnerabilityID)
+			if !ok {
+				filtered.Vulnerabilities = append(filtered.Vulnerabilities, vuln)
+
+				continue
+			}
+
+			if !applied[exception.ID] {
+				applied[exception.ID] = true
+				scan.waived = append(scan.waived, describeWaiver(exception))
+			}
+		}
+
+		kept.Results = append(kept.Results, filtered)
+	}
+
+	if len(scan.waived) > 0 {
+		scan.log.Info().Strs("exceptions", slices.Sorted(maps.Keys(applied))).Msg("findings waived")
+	}
+
+	return kept
+}
+
+// Waived describes the exceptions that waived findings of the scan (see Plan.Exceptions).
+// Only valid after plan execution.
+func (scan *Scan) Waived() []string {
+	return scan.waived
+}
+
+// PlatformSummaries returns per-platform finding summaries.
+// Only valid after plan execution.
+func (scan *Scan) PlatformSummaries() []ScanPlatformSummary {
+	return scan.platformSummaries
+}
+
+// summarizePlatforms counts findings per platform and identifies vulnerabilities unique to one platform.
+func summarizePlatforms(results []trivy.PlatformResult) []ScanPlatformSummary {
+	// Vulnerability ID -> platforms it was found on
+	seenOn := make(map[string]map[string]bool)
+
+	summaries := make([]ScanPlatformSummary, 0, len(results))
+
+	for _, platformResult := range results {
+		summary := ScanPlatformSummary{
+			Platform: platformResult.Platform,
+			Counts:   make(map[string]int),
+		}
+
+		for _, target := range platformResult.Result.Results {
+			for _, vuln := range target.Vulnerabilities {
+				summary.Counts[vuln.Severity]++
+
+				if seenOn[vuln.VulnerabilityID] == nil {
+					seenOn[vuln.VulnerabilityID] = make(map[string]bool)
+				}
+
+				seenOn[vuln.VulnerabilityID][platformResult.Platform] = true
+			}
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	// A single platform has nothing to compare against
+	if len(results) < 2 {
+		return summaries
+	}
+
+	for idx := range summaries {
+		for vulnID, platforms := range seenOn {
+			if len(platforms) == 1 && platforms[summaries[idx].Platform] {
+				summaries[idx].Unique = append(summaries[idx].Unique, vulnID)
+			}
+		}
+
+		sort.Strings(summaries[idx].Unique)
+	}
+
+	return summaries
+}
+
 // getVulnerabilitiesAtOrAbove returns vulnerabilities at or above the given severity threshold.
 func getVulnerabilitiesAtOrAbove(result *trivy.ScanResult, threshold ScanSeverity) []trivy.Vulnerability {
 	// Build severity order map using existing constants to avoid string duplication
@@ -437,7 +813,31 @@ func getVulnerabilitiesAtOrAbove(result *trivy.ScanResult, threshold ScanSeverit
 	return matching
 }
 
-// operationName returns the scan operation name (implements operation interface).
-func (scan *Scan) operationName() string {
-	return scan.opName
+// plannedChanges implements dryRunOperation: the image must exist when its digest is known.
+func (scan *Scan) plannedChanges(ctx context.Context) ([]string, error) {
+	imageRef, err := checkImage(ctx, newRegistryClient(scan.registry, scan.log), scan.image)
+	if err != nil {
+		return nil, err
+	}
+
+	platforms := make([]string, 0, len(scan.platforms))
+	for _, platform := range scan.platforms {
+		platforms = append(platforms, platform.String())
+	}
+
+	if len(platforms) == 0 {
+		return []string{"Would scan " + imageRef}, nil
+	}
+
+	return []string{fmt.Sprintf("Would scan %s (%s)", imageRef, strings.Join(platforms, ", "))}, nil
+}
+
+// cacheSources implements cacheableOperation: a scan reads the scanned image.
+func (scan *Scan) cacheSources() []*Image {
+	return []*Image{scan.image}
+}
+
+// resolveRegistries implements registryOperation.
+func (scan *Scan) resolveRegistries(lookup func(image *Image) *Registry) {
+	scan.registry = lookup(scan.image)
 }
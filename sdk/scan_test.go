@@ -55,6 +55,16 @@ func TestScanBuilder_Build(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+		{
+			name: "valid scan with explicit platforms",
+			build: func(plan *sdk.Plan) (*sdk.Scan, error) {
+				return plan.Scan("test-scan-platforms").
+					Source(sourceImage).
+					Platforms(sdk.PlatformARM64).
+					Build()
+			},
+			wantErr: nil,
+		},
 		{
 			name: "valid scan with format",
 			build: func(plan *sdk.Plan) (*sdk.Scan, error) {
@@ -330,3 +340,41 @@ func TestScanFormat_UnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+// INTENTION: Scanner server must be an http(s) URL with a host; anything else is rejected up front.
+func TestPlan_ScannerServer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{name: "https server", url: "https://trivy.internal:4954", wantErr: nil},
+		{name: "http server", url: "http://localhost:4954", wantErr: nil},
+		{name: "empty url", url: "", wantErr: sdk.ErrInvalidScannerServer},
+		{name: "missing scheme", url: "trivy.internal:4954", wantErr: sdk.ErrInvalidScannerServer},
+		{name: "unsupported scheme", url: "ftp://trivy.internal", wantErr: sdk.ErrInvalidScannerServer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			plan := sdk.NewPlan(testPlanName)
+			err := plan.ScannerServer(tt.url, "token")
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ScannerServer() error = %v, wantErr %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Errorf("ScannerServer() unexpected error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/farcloser/quark/internal/cron"
+)
+
+// RunTrigger is what started an execution of a scheduled plan.
+type RunTrigger string
+
+const (
+	// TriggerSchedule indicates an execution started at a time of the schedule.
+	TriggerSchedule RunTrigger = "schedule"
+	// TriggerDemand indicates an execution started on demand (see ScheduledPlan.Trigger).
+	TriggerDemand RunTrigger = "demand"
+)
+
+// ScheduledPlan executes a plan on a cron schedule, and on demand, one execution at a time (see Schedule).
+type ScheduledPlan struct {
+	plan       *Plan
+	expression string
+	schedule   *cron.Schedule
+	log        zerolog.Logger
+
+	// Executions in progress, waited for by Run before returning
+	group sync.WaitGroup
+
+	mutex   sync.Mutex
+	running bool
+	next    time.Time
+	skipped int
+	lastRun *RunStatus
+}
+
+// RunStatus is the outcome of an execution of a scheduled plan.
+type RunStatus struct {
+	Trigger  RunTrigger    `json:"trigger"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+	// Error is the failure message (empty unless the execution failed).
+	Error string `json:"error,omitempty"`
+	// Operations counts the operations of the execution by status (e.g., succeeded: 3).
+	Operations map[OperationStatus]int `json:"operations,omitempty"`
+	// Report is the execution report (nil if the plan failed before executing operations).
+	Report *Report `json:"-"`
+}
+
+// ScheduleStatus describes a scheduled plan: whether it runs, when it runs next, and how its last execution went.
+type ScheduleStatus struct {
+	Plan string `json:"plan"`
+	// Cron is the schedule (empty for plans only executed on demand).
+	Cron    string `json:"cron,omitempty"`
+	Running bool   `json:"running"`
+	// Next is the next scheduled execution (zero when none is scheduled, or before Run).
+	Next time.Time `json:"next,omitzero"`
+	// Skipped counts the scheduled executions skipped because the plan was still running.
+	Skipped int `json:"skipped"`
+	// LastRun is the outcome of the last completed execution (nil until one completed).
+	LastRun *RunStatus `json:"lastRun,omitempty"`
+}
+
+// Schedule returns a schedule executing plan at the times matching expression, a cron expression of five fields
+// (minute, hour, day of month, month, day of week, e.g., "0 3 * * *" every night at 03:00 local time) or a macro
+// (@hourly, @daily, @weekly, @monthly). An empty expression uses the schedule of the plan document (schedule, see
+// LoadPlan); plans without one are only executed on demand (see ScheduledPlan.Trigger).
+// The same plan is executed each time: start the schedule with Run.
+func Schedule(plan *Plan, expression string) (*ScheduledPlan, error) {
+	if expression == "" {
+		expression = plan.cron
+	}
+
+	scheduled := &ScheduledPlan{
+		plan:       plan,
+		expression: expression,
+		log:        plan.log.With().Str("schedule", expression).Logger(),
+	}
+
+	if expression != "" {
+		schedule, err := cron.Parse(expression)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSchedule, err)
+		}
+
+		scheduled.schedule = schedule
+	}
+
+	return scheduled, nil
+}
+
+// Run executes the plan at each time of the schedule until ctx is canceled, then waits for the running execution,
+// canceled with ctx, and returns. A time reached while the plan still runs (a long execution, or one started on
+// demand) is skipped, not queued. Failed executions are logged and recorded in Status: the schedule continues.
+// Plans without schedule wait for ctx, to be executed on demand.
+func (scheduled *ScheduledPlan) Run(ctx context.Context) {
+	defer scheduled.group.Wait()
+
+	if scheduled.schedule == nil {
+		<-ctx.Done()
+
+		return
+	}
+
+	for {
+		next := scheduled.schedule.Next(time.Now())
+
+		scheduled.mutex.Lock()
+		scheduled.next = next
+		scheduled.mutex.Unlock()
+
+		if next.IsZero() {
+			scheduled.log.Warn().Msg("schedule matches no time, plan only executed on demand")
+			<-ctx.Done()
+
+			return
+		}
+
+		scheduled.log.Debug().Time("next", next).Msg("waiting for next scheduled execution")
+
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		case <-timer.C:
+		}
+
+		if !scheduled.start(ctx, TriggerSchedule) {
+			scheduled.mutex.Lock()
+			scheduled.skipped++
+			scheduled.mutex.Unlock()
+
+			scheduled.log.Warn().Time("scheduled", next).Msg("plan still running, scheduled execution skipped")
+		}
+	}
+}
+
+// Trigger starts an execution of the plan now, outside of the schedule (e.g., syncs on demand), and returns
+// without waiting for it: its outcome is recorded in Status. The execution is canceled with ctx.
+// Fails with ErrPlanRunning, starting nothing, while the plan runs.
+func (scheduled *ScheduledPlan) Trigger(ctx context.Context) error {
+	if !scheduled.start(ctx, TriggerDemand) {
+		return fmt.Errorf("%w: %q", ErrPlanRunning, scheduled.plan.name)
+	}
+
+	return nil
+}
+
+// Status returns the status of the scheduled plan.
+func (scheduled *ScheduledPlan) Status() ScheduleStatus {
+	scheduled.mutex.Lock()
+	defer scheduled.mutex.Unlock()
+
+	return ScheduleStatus{
+		Plan:    scheduled.plan.name,
+		Cron:    scheduled.expression,
+		Running: scheduled.running,
+		Next:    scheduled.next,
+		Skipped: scheduled.skipped,
+		LastRun: scheduled.lastRun,
+	}
+}
+
+// start executes the plan in the background, unless it is running (false).
+func (scheduled *ScheduledPlan) start(ctx context.Context, trigger RunTrigger) bool {
+	scheduled.mutex.Lock()
+	defer scheduled.mutex.Unlock()
+
+	if scheduled.running {
+		return false
+	}
+
+	scheduled.running = true
+	scheduled.group.Add(1)
+
+	go func() {
+		defer scheduled.group.Done()
+
+		status := scheduled.execute(ctx, trigger)
+
+		scheduled.mutex.Lock()
+		scheduled.running = false
+		scheduled.lastRun = status
+		scheduled.mutex.Unlock()
+	}()
+
+	return true
+}
+
+// execute executes the plan and returns the outcome.
+func (scheduled *ScheduledPlan) execute(ctx context.Context, trigger RunTrigger) *RunStatus {
+	status := &RunStatus{Trigger: trigger, Started: time.Now()}
+
+	scheduled.log.Info().Str("trigger", string(trigger)).Msg("starting scheduled plan execution")
+
+	report, err := scheduled.plan.ExecuteWithResult(ctx)
+
+	status.Duration = time.Since(status.Started)
+	status.Report = report
+
+	if report != nil {
+		status.Operations = map[OperationStatus]int{}
+
+		for _, op := range report.Operations {
+			status.Operations[op.Status]++
+		}
+	}
+
+	if err != nil {
+		status.Error = err.Error()
+
+		scheduled.log.Error().Err(err).Dur("duration", status.Duration).Msg("scheduled plan execution failed")
+
+		return status
+	}
+
+	scheduled.log.Info().Dur("duration", status.Duration).Msg("scheduled plan execution complete")
+
+	return status
+}
@@ -0,0 +1,105 @@
+package sdk_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: Plan schedules are cron expressions; an empty expression uses the schedule of the plan document,
+// and plans without one are executed on demand only.
+func TestSchedule(t *testing.T) {
+	t.Parallel()
+
+	if _, err := sdk.Schedule(sdk.NewPlan(testPlanName), "every night"); !errors.Is(err, sdk.ErrInvalidSchedule) {
+		t.Errorf("Schedule() error = %v, want %v", err, sdk.ErrInvalidSchedule)
+	}
+
+	scheduled, err := sdk.Schedule(sdk.NewPlan(testPlanName), "")
+	if err != nil {
+		t.Fatalf("Schedule() error = %v", err)
+	}
+
+	if status := scheduled.Status(); status.Cron != "" || !status.Next.IsZero() {
+		t.Errorf("Status() = %+v, want a plan executed on demand only", status)
+	}
+
+	plan, err := sdk.LoadPlan(writePlanDocument(t, "nightly.yaml", "schedule: '0 3 * * *'\n"))
+	if err != nil {
+		t.Fatalf("LoadPlan() error = %v", err)
+	}
+
+	scheduled, err = sdk.Schedule(plan, "")
+	if err != nil {
+		t.Fatalf("Schedule() error = %v", err)
+	}
+
+	if status := scheduled.Status(); status.Plan != "nightly" || status.Cron != "0 3 * * *" {
+		t.Errorf("Status() = %+v, want the schedule of the document", status)
+	}
+}
+
+// INTENTION: A plan triggered on demand runs in the background, one execution at a time: triggering it again while
+// it runs fails, and the outcome of the execution is recorded in the status.
+func TestScheduledPlan_Trigger(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		<-release
+		writer.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	image, err := sdk.NewImage("my-org/app").Domain(host).Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	plan := sdk.NewPlan(testPlanName)
+
+	if _, err := plan.Rollback("rollback-app").Image(image).ToDigest(testDigest).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	scheduled, err := sdk.Schedule(plan, "@daily")
+	if err != nil {
+		t.Fatalf("Schedule() error = %v", err)
+	}
+
+	if err := scheduled.Trigger(t.Context()); err != nil {
+		t.Fatalf("Trigger() error = %v", err)
+	}
+
+	if err := scheduled.Trigger(t.Context()); !errors.Is(err, sdk.ErrPlanRunning) {
+		t.Errorf("Trigger() while running error = %v, want %v", err, sdk.ErrPlanRunning)
+	}
+
+	if !scheduled.Status().Running {
+		t.Errorf("Status().Running = false while the plan runs")
+	}
+
+	close(release)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for scheduled.Status().Running && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	last := scheduled.Status().LastRun
+	if last == nil {
+		t.Fatal("Status().LastRun = nil, want the outcome of the execution")
+	}
+
+	if last.Trigger != sdk.TriggerDemand || last.Error == "" || last.Operations[sdk.StatusFailed] != 1 {
+		t.Errorf("LastRun = %+v, want a failed execution on demand", last)
+	}
+}
@@ -0,0 +1,270 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits maps size suffixes to their multiplier in bytes.
+// Binary (KiB, MiB, GiB) and decimal (KB, MB, GB) units are both supported.
+//
+//nolint:gochecknoglobals // Lookup table
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	// Longest suffixes first so "MiB" is not matched as "B"
+	{"KiB", 1 << 10},
+	{"MiB", 1 << 20},
+	{"GiB", 1 << 30},
+	{"TiB", 1 << 40},
+	{"KB", 1000},
+	{"MB", 1000 * 1000},
+	{"GB", 1000 * 1000 * 1000},
+	{"TB", 1000 * 1000 * 1000 * 1000},
+	{"B", 1},
+}
+
+// SizeCheck represents an image size and layer budget gate.
+type SizeCheck struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	image     *Image
+	registry  *Registry
+	maxSize   int64
+	maxLayers int
+
+	// sizeErr records an invalid MaxSize value, reported at Build() time
+	sizeErr error
+}
+
+// SizeCheckBuilder builds a SizeCheck.
+type SizeCheckBuilder struct {
+	builderState
+
+	plan  *Plan
+	check *SizeCheck
+}
+
+// Source sets the image to check.
+// Registry credentials are looked up from the plan's registry collection using the image domain.
+// If no registry is found, anonymous access will be used.
+func (builder *SizeCheckBuilder) Source(image *Image) *SizeCheckBuilder {
+	builder.check.image = image
+	builder.check.consume(image)
+	builder.check.registry = builder.plan.getRegistry(image.Domain())
+
+	return builder
+}
+
+// MaxSize sets the maximum compressed image size (e.g., "200MiB", "1.5GB", "500000000").
+// For multi-platform images, every platform image must fit within the budget.
+func (builder *SizeCheckBuilder) MaxSize(size string) *SizeCheckBuilder {
+	parsed, err := parseSize(size)
+	builder.check.maxSize = parsed
+	builder.check.sizeErr = err
+
+	return builder
+}
+
+// MaxLayers sets the maximum number of layers.
+// For multi-platform images, every platform image must fit within the budget.
+func (builder *SizeCheckBuilder) MaxLayers(layers int) *SizeCheckBuilder {
+	builder.check.maxLayers = layers
+
+	return builder
+}
+
+// RunOnlyOn restricts the size check to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *SizeCheckBuilder) RunOnlyOn(envs ...Environment) *SizeCheckBuilder {
+	builder.check.runOnlyOn = append(builder.check.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the size check mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *SizeCheckBuilder) Resource(resource Resource) *SizeCheckBuilder {
+	builder.check.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the size check start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *SizeCheckBuilder) DependsOn(ops ...Dependency) *SizeCheckBuilder {
+	builder.check.add(ops)
+
+	return builder
+}
+
+// When makes the size check run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the size check.
+func (builder *SizeCheckBuilder) When(conditions ...Condition) *SizeCheckBuilder {
+	builder.check.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a size check named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *SizeCheckBuilder) Clone(name string) *SizeCheckBuilder {
+	clone := builder.plan.SizeCheck(name)
+	clone.check.envGuard = builder.check.envGuard.clone()
+	clone.check.resourceHint = builder.check.resourceHint
+	clone.check.dependencyList = builder.check.dependencyList.clone()
+	clone.check.conditionList = builder.check.conditionList.clone()
+	clone.check.image = builder.check.image
+	clone.check.registry = builder.check.registry
+	clone.check.maxSize = builder.check.maxSize
+	clone.check.maxLayers = builder.check.maxLayers
+	clone.check.sizeErr = builder.check.sizeErr
+
+	return clone
+}
+
+// Reset makes the builder usable again for a size check named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *SizeCheckBuilder) Reset(name string) *SizeCheckBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build validates and adds the size check to the plan.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *SizeCheckBuilder) Build() (*SizeCheck, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.check.opName); err != nil {
+		return nil, err
+	}
+
+	if builder.check.image == nil {
+		return nil, ErrSizeCheckImageRequired
+	}
+
+	if builder.check.sizeErr != nil {
+		return nil, builder.check.sizeErr
+	}
+
+	if builder.check.maxSize <= 0 && builder.check.maxLayers <= 0 {
+		return nil, ErrSizeCheckLimitRequired
+	}
+
+	builder.plan.sizeChecks = append(builder.plan.sizeChecks, builder.check)
+	builder.plan.addOperation(builder.check)
+
+	return builder.check, nil
+}
+
+func (check *SizeCheck) execute(ctx context.Context) error {
+	// Prefer digest for immutability, fall back to tag
+	imageRef, err := check.image.digestRef()
+	if err != nil {
+		imageRef, err = check.image.tagRef()
+		if err != nil {
+			return fmt.Errorf("failed to build image reference: %w", err)
+		}
+	}
+
+	check.log.Info().
+		Str("image", imageRef).
+		Int64("max_size", check.maxSize).
+		Int("max_layers", check.maxLayers).
+		Msg("checking image size")
+
+	client := newRegistryClient(check.registry, check.log)
+
+	sizes, err := client.GetImageSizes(ctx, imageRef)
+	if err != nil {
+		return fmt.Errorf("failed to get image size: %w", err)
+	}
+
+	var violations []error
+
+	for _, size := range sizes {
+		check.log.Info().
+			Str("platform", size.Platform).
+			Int64("size", size.Size).
+			Int("layers", size.Layers).
+			Msg("image size")
+
+		if check.maxSize > 0 && size.Size > check.maxSize {
+			violations = append(violations, fmt.Errorf(
+				"%w: %s %d bytes > %d bytes", ErrImageSizeExceeded, platformLabel(size.Platform), size.Size, check.maxSize,
+			))
+		}
+
+		if check.maxLayers > 0 && size.Layers > check.maxLayers {
+			violations = append(violations, fmt.Errorf(
+				"%w: %s %d layers > %d layers", ErrImageLayersExceeded, platformLabel(size.Platform), size.Layers,
+				check.maxLayers,
+			))
+		}
+	}
+
+	if len(violations) > 0 {
+		for _, violation := range violations {
+			check.log.Error().Err(violation).Msg("image budget exceeded")
+		}
+
+		return errors.Join(violations...)
+	}
+
+	check.log.Info().Msg("size check passed")
+
+	return nil
+}
+
+// platformLabel returns a printable platform label.
+func platformLabel(platform string) string {
+	if platform == "" {
+		return "image"
+	}
+
+	return platform
+}
+
+// parseSize parses a human-readable size (e.g., "200MiB", "1.5GB", "1024") into bytes.
+func parseSize(size string) (int64, error) {
+	trimmed := strings.TrimSpace(size)
+	multiplier := int64(1)
+
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(trimmed, unit.suffix) {
+			trimmed = strings.TrimSpace(strings.TrimSuffix(trimmed, unit.suffix))
+			multiplier = unit.multiplier
+
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSize, size)
+	}
+
+	return int64(value * float64(multiplier)), nil
+}
+
+// cacheSources implements cacheableOperation: a size check reads the checked image.
+func (check *SizeCheck) cacheSources() []*Image {
+	return []*Image{check.image}
+}
+
+// resolveRegistries implements registryOperation.
+func (check *SizeCheck) resolveRegistries(lookup func(image *Image) *Registry) {
+	check.registry = lookup(check.image)
+}
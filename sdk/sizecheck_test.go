@@ -0,0 +1,102 @@
+package sdk_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: SizeCheck requires an image and at least one valid budget.
+func TestSizeCheckBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	image, err := sdk.NewImage("alpine").Version("3.20").Digest(testDigest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		build   func(*sdk.Plan) (*sdk.SizeCheck, error)
+		wantErr error
+	}{
+		{
+			name: "binary size unit",
+			build: func(plan *sdk.Plan) (*sdk.SizeCheck, error) {
+				return plan.SizeCheck("size").Source(image).MaxSize("200MiB").Build()
+			},
+		},
+		{
+			name: "decimal size unit with fraction",
+			build: func(plan *sdk.Plan) (*sdk.SizeCheck, error) {
+				return plan.SizeCheck("size").Source(image).MaxSize("1.5GB").Build()
+			},
+		},
+		{
+			name: "plain bytes",
+			build: func(plan *sdk.Plan) (*sdk.SizeCheck, error) {
+				return plan.SizeCheck("size").Source(image).MaxSize("1048576").Build()
+			},
+		},
+		{
+			name: "layers only",
+			build: func(plan *sdk.Plan) (*sdk.SizeCheck, error) {
+				return plan.SizeCheck("size").Source(image).MaxLayers(20).Build()
+			},
+		},
+		{
+			name: "missing image",
+			build: func(plan *sdk.Plan) (*sdk.SizeCheck, error) {
+				return plan.SizeCheck("size").MaxLayers(20).Build()
+			},
+			wantErr: sdk.ErrSizeCheckImageRequired,
+		},
+		{
+			name: "missing limits",
+			build: func(plan *sdk.Plan) (*sdk.SizeCheck, error) {
+				return plan.SizeCheck("size").Source(image).Build()
+			},
+			wantErr: sdk.ErrSizeCheckLimitRequired,
+		},
+		{
+			name: "unknown unit",
+			build: func(plan *sdk.Plan) (*sdk.SizeCheck, error) {
+				return plan.SizeCheck("size").Source(image).MaxSize("200XB").Build()
+			},
+			wantErr: sdk.ErrInvalidSize,
+		},
+		{
+			name: "negative size",
+			build: func(plan *sdk.Plan) (*sdk.SizeCheck, error) {
+				return plan.SizeCheck("size").Source(image).MaxSize("-5MiB").Build()
+			},
+			wantErr: sdk.ErrInvalidSize,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			plan := sdk.NewPlan(testPlanName)
+			check, err := tt.build(plan)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Build() unexpected error = %v", err)
+			}
+
+			if check == nil {
+				t.Error("Build() returned nil size check with nil error")
+			}
+		})
+	}
+}
@@ -0,0 +1,444 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/internal/harbor"
+)
+
+// PlanState describes the operations of a plan and their settings (images, platforms, severity checks...),
+// to compare the plan with a later version of it (see DiffStates), like `terraform plan` does.
+type PlanState struct {
+	Plan string `json:"plan"`
+	// Fingerprint is the plan fingerprint (see Plan.Fingerprint), empty in states written by earlier versions.
+	Fingerprint string           `json:"fingerprint,omitempty"`
+	Operations  []OperationState `json:"operations"`
+}
+
+// OperationState describes an operation.
+type OperationState struct {
+	Name string `json:"name"`
+	// Kind is the operation type, as shown in reports (e.g., "sync", "version-check").
+	Kind string `json:"kind"`
+	// Settings are the operation settings, by name (e.g., "source": "docker.io/library/alpine:3.20@sha256:...").
+	Settings map[string]string `json:"settings,omitempty"`
+	// CacheKey is the hash of the inputs of the operation, when the execution writing the state completed it
+	// (see Plan.SkipUnchanged).
+	CacheKey string `json:"cacheKey,omitempty"`
+	// Digest is the digest the operation pushed, when the execution writing the state completed it: operations
+	// skipped as unchanged restore it for the operations reading their output image.
+	Digest string `json:"digest,omitempty"`
+}
+
+// StateChangeKind is how an operation differs between two plan states.
+type StateChangeKind string
+
+const (
+	// StateAdded indicates an operation only in the new state.
+	StateAdded StateChangeKind = "added"
+	// StateRemoved indicates an operation only in the previous state.
+	StateRemoved StateChangeKind = "removed"
+	// StateChanged indicates an operation whose kind or settings changed.
+	StateChanged StateChangeKind = "changed"
+)
+
+// StateChange is an operation that differs between two plan states.
+type StateChange struct {
+	Operation string
+	Kind      string
+	Change    StateChangeKind
+	// Settings lists the changed settings, sorted by name (StateChanged only).
+	Settings []SettingChange
+}
+
+// SettingChange is a changed operation setting. Previous (or Current) is empty when the setting was added
+// (or removed).
+type SettingChange struct {
+	Setting  string
+	Previous string
+	Current  string
+}
+
+// State returns the state of the plan, from its operations as built (nothing is executed).
+func (plan *Plan) State() *PlanState {
+	state := &PlanState{Plan: plan.name, Operations: make([]OperationState, 0, len(plan.operations))}
+
+	for _, op := range plan.operations {
+		state.Operations = append(state.Operations, OperationState{
+			Name:     op.operationName(),
+			Kind:     op.kind(),
+			Settings: operationSettings(op),
+		})
+	}
+
+	state.Fingerprint = plan.fingerprint(state.Operations)
+
+	return state
+}
+
+// StateTo writes the plan state to path after every successful Execute (not after dry runs), as the reference
+// of the next `quark plan-diff`. QUARK_STATE (set by the CLI --state flag) takes precedence, except for plans run
+// by an Orchestrator.
+func (plan *Plan) StateTo(path string) {
+	plan.statePath = path
+}
+
+// writeState writes the plan state to its path and its database, if any, with the cache keys and digests of the
+// operations, by name.
+func (plan *Plan) writeState(ctx context.Context, cacheKeys, digests map[string]string) {
+	path := plan.processEnv("QUARK_STATE", plan.statePath)
+	if path == "" && plan.database == nil {
+		return
+	}
+
+	state := plan.State()
+	for idx := range state.Operations {
+		state.Operations[idx].CacheKey = cacheKeys[state.Operations[idx].Name]
+		state.Operations[idx].Digest = digests[state.Operations[idx].Name]
+	}
+
+	plan.writeDatabaseState(ctx, state)
+
+	if path == "" {
+		return
+	}
+
+	var content strings.Builder
+	if err := state.Write(&content); err != nil {
+		plan.log.Warn().Err(err).Msg("failed to write plan state")
+
+		return
+	}
+
+	if err := os.WriteFile(path, []byte(content.String()), filesystem.FilePermissionsDefault); err != nil {
+		plan.log.Warn().Err(err).Str("path", path).Msg("failed to write plan state")
+
+		return
+	}
+
+	plan.log.Info().Str("path", path).Msg("plan state written")
+}
+
+// Write writes the state as indented JSON.
+func (state *PlanState) Write(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(state); err != nil {
+		return fmt.Errorf("failed to write plan state: %w", err)
+	}
+
+	return nil
+}
+
+// ReadState reads a plan state written by Write (or StateTo).
+func ReadState(path string) (*PlanState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPlanState, err)
+	}
+
+	return parseState(path, data)
+}
+
+// parseState parses a plan state read from source (e.g., its path).
+func parseState(source string, data []byte) (*PlanState, error) {
+	var state PlanState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidPlanState, source, err)
+	}
+
+	return &state, nil
+}
+
+// DiffStates returns the operations that differ between two states of a plan: operations added or changed,
+// in the order of current, then operations removed, in the order of previous. Operations are matched by name.
+func DiffStates(previous, current *PlanState) []StateChange {
+	before := make(map[string]OperationState, len(previous.Operations))
+	for _, op := range previous.Operations {
+		before[op.Name] = op
+	}
+
+	var changes []StateChange
+
+	for _, op := range current.Operations {
+		old, existed := before[op.Name]
+		delete(before, op.Name)
+
+		if !existed {
+			changes = append(changes, StateChange{Operation: op.Name, Kind: op.Kind, Change: StateAdded})
+
+			continue
+		}
+
+		settings := diffSettings(old.Settings, op.Settings)
+		if old.Kind != op.Kind {
+			settings = append([]SettingChange{{Setting: "kind", Previous: old.Kind, Current: op.Kind}}, settings...)
+		}
+
+		if len(settings) > 0 {
+			changes = append(changes, StateChange{
+				Operation: op.Name,
+				Kind:      op.Kind,
+				Change:    StateChanged,
+				Settings:  settings,
+			})
+		}
+	}
+
+	for _, op := range previous.Operations {
+		if _, removed := before[op.Name]; removed {
+			changes = append(changes, StateChange{Operation: op.Name, Kind: op.Kind, Change: StateRemoved})
+		}
+	}
+
+	return changes
+}
+
+// diffSettings returns the settings that differ, sorted by name.
+func diffSettings(previous, current map[string]string) []SettingChange {
+	names := make([]string, 0, len(previous)+len(current))
+
+	for name := range previous {
+		names = append(names, name)
+	}
+
+	for name := range current {
+		if _, ok := previous[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	var changes []SettingChange
+
+	for _, name := range names {
+		if previous[name] != current[name] {
+			changes = append(changes, SettingChange{Setting: name, Previous: previous[name], Current: current[name]})
+		}
+	}
+
+	return changes
+}
+
+// operationSettings returns the settings of op that define what it does.
+func operationSettings(op operation) map[string]string {
+	settings := map[string]string{}
+
+	set := func(name, value string) {
+		if value != "" {
+			settings[name] = value
+		}
+	}
+
+	image := func(name string, img *Image) {
+		if img != nil {
+			set(name, img.String())
+		}
+	}
+
+	deps := make([]string, 0, len(op.dependencies()))
+	for _, dep := range op.dependencies() {
+		deps = append(deps, dep.operationName())
+	}
+
+	set("depends on", strings.Join(deps, ","))
+	set("runs only on", joinEnvironments(op.environments()))
+
+	switch typed := op.(type) {
+	case *Sync:
+		image("source", typed.sourceImage)
+		image("destination", typed.destImage)
+		set("platforms", joinPlatforms(typed.platforms))
+
+		flags := map[string]bool{
+			"copy signatures":        typed.copySigs,
+			"record previous digest": typed.recordPrevious,
+			"digest tag fallback":    typed.digestFallback,
+			"verify pushed digest":   typed.verifyPushed,
+			"verify blobs":           typed.verifyBlobs,
+		}
+
+		for flag, enabled := range flags {
+			if enabled {
+				set(flag, "true")
+			}
+		}
+	case *Build:
+		set("context", typed.context)
+		set("dockerfile", typed.dockerfile)
+		set("tag", typed.tag)
+		set("nodes", joinNodes(typed.nodes))
+		set("node selector", joinLabels(typed.selector))
+
+		if typed.timestamp != nil {
+			set("timestamp", typed.timestamp.Format(time.RFC3339))
+		}
+	case *Scan:
+		image("image", typed.image)
+		set("platforms", joinPlatforms(typed.platforms))
+		set("severity checks", joinSeverityChecks(typed.severityChecks))
+
+		if typed.evaluateAll {
+			set("evaluate all", "true")
+		}
+
+		if typed.failOnKnownExploited {
+			set("fail on known exploited", "true")
+		}
+	case *Audit:
+		image("image", typed.image)
+		set("dockerfile", typed.dockerfile)
+		set("rule set", typed.ruleSet.String())
+		set("ignore checks", strings.Join(typed.ignoreChecks, ","))
+	case *VersionCheck:
+		image("image", typed.image)
+	case *Rollback:
+		image("image", typed.image)
+		set("digest", typed.digest)
+	case *Rebase:
+		image("image", typed.image)
+		image("old base", typed.oldBase)
+		image("new base", typed.newBase)
+		image("destination", typed.destImage)
+	case *Flatten:
+		image("image", typed.image)
+		image("destination", typed.destImage)
+	case *Mutate:
+		image("image", typed.image)
+		image("destination", typed.destImage)
+		set("changes", strings.Join(typed.changes(), ","))
+	case *Index:
+		set("images", strings.Join(typed.sources, ","))
+		set("tag", typed.tag)
+	case *Prune:
+		image("repository", typed.image)
+
+		if typed.policy.KeepLastPerMinor > 0 {
+			set("keep last per minor", strconv.Itoa(typed.policy.KeepLastPerMinor))
+		}
+
+		set("keep tags", strings.Join(typed.policy.KeepTags, ","))
+		set("drop tags", strings.Join(typed.policy.DropTags, ","))
+
+		if typed.policy.DropUntaggedAfter > 0 {
+			set("drop untagged after", typed.policy.DropUntaggedAfter.String())
+		}
+	case *SizeCheck:
+		image("image", typed.image)
+
+		if typed.maxSize > 0 {
+			set("max size", strconv.FormatInt(typed.maxSize, 10))
+		}
+
+		if typed.maxLayers > 0 {
+			set("max layers", strconv.Itoa(typed.maxLayers))
+		}
+	case *Verify:
+		image("image", typed.image)
+		set("cosign key", typed.key)
+		set("cosign identity", typed.identity)
+		set("cosign issuer", typed.issuer)
+		set("rekor url", typed.rekorURL)
+		set("notation trust policy", typed.policy)
+	case *ContainerdImport:
+		image("image", typed.image)
+		set("nodes", joinNodes(typed.nodes))
+		set("namespace", typed.namespace)
+		set("tool", typed.tool.String())
+	case *Artifact:
+		image("image", typed.image)
+		set("artifact type", typed.artifactType)
+		set("annotations", joinLabels(typed.annotations))
+	case *HarborProject:
+		set("harbor url", typed.url)
+		set("project", typed.project)
+		set("public", strconv.FormatBool(typed.public))
+		set("retention", joinRetentionRules(typed.retention))
+		set("retention schedule", typed.schedule)
+		set("immutable tags", strings.Join(typed.immutable, ","))
+		set("robot", typed.robotName)
+	case *GHCRPackage:
+		image("image", typed.image)
+		set("repository", typed.repository)
+		set("description", typed.description)
+		set("labels", joinLabels(typed.labels))
+		set("visibility", typed.visibility.value)
+	case *RepositoryDocs:
+		image("image", typed.image)
+		set("description", typed.description)
+		set("readme", typed.readme)
+	}
+
+	return settings
+}
+
+func joinPlatforms(platforms []Platform) string {
+	names := make([]string, 0, len(platforms))
+	for _, platform := range platforms {
+		names = append(names, platform.String())
+	}
+
+	return strings.Join(names, ",")
+}
+
+func joinEnvironments(envs []Environment) string {
+	names := make([]string, 0, len(envs))
+	for _, env := range envs {
+		names = append(names, string(env))
+	}
+
+	return strings.Join(names, ",")
+}
+
+// joinNodes joins the names of nodes with their platforms, which decide what builds on them produce.
+func joinNodes(nodes []*BuildNode) string {
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.name+"="+node.platform.String())
+	}
+
+	return strings.Join(names, ",")
+}
+
+func joinRetentionRules(rules []harbor.RetentionRule) string {
+	names := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		if rule.KeepDays > 0 {
+			names = append(names, fmt.Sprintf("%s:%dd", rule.Tags, rule.KeepDays))
+		} else {
+			names = append(names, fmt.Sprintf("%s:%d", rule.Tags, rule.KeepLatest))
+		}
+	}
+
+	return strings.Join(names, ",")
+}
+
+func joinLabels(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		names = append(names, key+"="+labels[key])
+	}
+
+	return strings.Join(names, ",")
+}
+
+func joinSeverityChecks(checks []ScanSeverityCheck) string {
+	names := make([]string, 0, len(checks))
+	for _, check := range checks {
+		names = append(names, check.threshold.String()+":"+check.action.String())
+	}
+
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,99 @@
+package sdk_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: Comparing the state of a plan with a later version of it shows operations added, removed and
+// changed, with the changed settings (e.g., a bumped source image), like terraform plan.
+func TestDiffStates(t *testing.T) {
+	t.Parallel()
+
+	newPlan := func(version string, scan bool) *sdk.PlanState {
+		plan := sdk.NewPlan(testPlanName)
+
+		source, err := sdk.NewImage("alpine").Version(version).Digest(testDigest).Build()
+		if err != nil {
+			t.Fatalf("Failed to create source image: %v", err)
+		}
+
+		destination, err := sdk.NewImage("org/alpine").Domain("ghcr.io").Version(version).Build()
+		if err != nil {
+			t.Fatalf("Failed to create destination image: %v", err)
+		}
+
+		if _, err := plan.Sync("mirror").Source(source).Destination(destination).Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		if scan {
+			if _, err := plan.Scan("scan-mirror").Source(destination).Build(); err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+		} else {
+			if _, err := plan.VersionCheck("check-alpine").Source(source).Build(); err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+		}
+
+		return plan.State()
+	}
+
+	previous := newPlan("3.20", false)
+
+	// States survive a round trip through a file
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	var content bytes.Buffer
+	if err := previous.Write(&content); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if err := os.WriteFile(path, content.Bytes(), filesystem.FilePermissionsPrivate); err != nil {
+		t.Fatalf("Failed to write state: %v", err)
+	}
+
+	previous, err := sdk.ReadState(path)
+	if err != nil {
+		t.Fatalf("ReadState() error = %v", err)
+	}
+
+	changes := sdk.DiffStates(previous, newPlan("3.21", true))
+
+	want := []sdk.StateChange{
+		{
+			Operation: "mirror",
+			Kind:      "sync",
+			Change:    sdk.StateChanged,
+			Settings: []sdk.SettingChange{
+				{
+					Setting:  "destination",
+					Previous: "ghcr.io/org/alpine:3.20",
+					Current:  "ghcr.io/org/alpine:3.21",
+				},
+				{
+					Setting:  "source",
+					Previous: "docker.io/library/alpine:3.20@" + testDigest,
+					Current:  "docker.io/library/alpine:3.21@" + testDigest,
+				},
+			},
+		},
+		{Operation: "scan-mirror", Kind: "scan", Change: sdk.StateAdded},
+		{Operation: "check-alpine", Kind: "version-check", Change: sdk.StateRemoved},
+	}
+
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("DiffStates() = %+v, want %+v", changes, want)
+	}
+
+	if changes := sdk.DiffStates(previous, previous); len(changes) != 0 {
+		t.Errorf("DiffStates(same) = %+v, want no changes", changes)
+	}
+}
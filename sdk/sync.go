@@ -2,40 +2,67 @@ package sdk
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/opencontainers/go-digest"
-	"github.com/rs/zerolog"
 
 	"github.com/farcloser/quark/internal/registry"
 	syncsvc "github.com/farcloser/quark/internal/sync"
+	"github.com/farcloser/quark/internal/webhook"
 )
 
+// digestTagLength is the number of digest hex digits in digest-derived tags.
+const digestTagLength = 12
+
 // Sync represents an image sync operation from source to destination registry.
 type Sync struct {
-	opName         string
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+	retryPolicy
+
 	sourceRegistry *Registry
 	sourceImage    *Image
 	destRegistry   *Registry
 	destImage      *Image
 	platforms      []Platform
+	recordPrevious bool
+	verifyBlobs    bool
+	verifyPushed   bool
+	digestFallback bool
+	copySigs       bool
 	destDigest     string // Destination image digest (computed locally, not from registry)
-	log            zerolog.Logger
+	digestTag      string // Digest-derived tag pushed instead of the destination tag (DigestTagFallback)
+	previousDigest string // Digest the destination tag pointed to before this sync
+	verified       []string
+	signatures     []string        // Digests of the notation signatures copied
+	planName       string          // Name of the executing plan, for notifications
+	webhook        *webhook.Sender // Endpoint notified after the sync (Plan.SyncWebhook)
+	notifyError    error
 }
 
 // SyncBuilder builds a Sync.
 type SyncBuilder struct {
-	plan  *Plan
-	sync  *Sync
-	built bool
+	builderState
+
+	plan *Plan
+	sync *Sync
 }
 
 // Source sets the source image.
-// The image MUST have a digest specified - syncing by tag alone is not allowed for security.
+// The image MUST have a digest specified - syncing by tag alone is not allowed for security - unless it is the
+// output of another operation (see Build.OutputImage): the sync then depends on it, and syncs the produced digest.
 // Registry credentials are looked up from the plan's registry collection using the image domain.
 // If no registry is found for the domain, unauthenticated access will be used.
 func (builder *SyncBuilder) Source(image *Image) *SyncBuilder {
 	builder.sync.sourceImage = image
+	builder.sync.consume(image)
 	builder.sync.sourceRegistry = builder.plan.getRegistry(image.Domain())
 
 	return builder
@@ -43,6 +70,7 @@ func (builder *SyncBuilder) Source(image *Image) *SyncBuilder {
 
 // Destination sets the destination image.
 // The image should have name, domain, and version. Digest will be computed after sync.
+// Without destination, Build() derives it from the source with the plan rewrite rules (see Plan.RewriteRule).
 // Registry credentials are looked up from the plan's registry collection using the image domain.
 // If no registry is found for the domain, unauthenticated access will be used.
 func (builder *SyncBuilder) Destination(image *Image) *SyncBuilder {
@@ -52,46 +80,210 @@ func (builder *SyncBuilder) Destination(image *Image) *SyncBuilder {
 	return builder
 }
 
-// Platforms sets the platforms to sync.
+// Platforms sets the platforms of multi-platform images to sync; other platforms of the source index are left out
+// of the destination manifest list. Defaults to the plan default platforms (see Plan.DefaultPlatforms).
 func (builder *SyncBuilder) Platforms(platforms ...Platform) *SyncBuilder {
 	builder.sync.platforms = platforms
 
 	return builder
 }
 
+// RecordPreviousDigest enables writing the digest the destination tag pointed to before the sync
+// into the pushed manifest as the "quark.dev/previous-digest" annotation.
+// This gives a lightweight rollback pointer without a full tag history system.
+// Note that the annotation changes the destination manifest, so the destination digest
+// differs from the source digest when enabled.
+func (builder *SyncBuilder) RecordPreviousDigest(enabled bool) *SyncBuilder {
+	builder.sync.recordPrevious = enabled
+
+	return builder
+}
+
+// VerifyBlobs enables integrity mode: every layer downloaded from the source is hashed while it streams
+// to the destination, and the sync fails as soon as a blob does not match the digest or size declared
+// by the source manifest. Verified digests are available from VerifiedDigests() for attestation.
+// Same-registry syncs mount blobs without downloading them and are not verified.
+func (builder *SyncBuilder) VerifyBlobs(enabled bool) *SyncBuilder {
+	builder.sync.verifyBlobs = enabled
+
+	return builder
+}
+
+// VerifyPushedDigest enables checking, once a multi-platform manifest list is pushed, that the destination tag
+// points to the digest computed locally: the sync fails with ErrRegistryManifestRewritten when a registry or
+// proxy rewrote the manifest (the digest recorded for later operations would not exist at the destination).
+func (builder *SyncBuilder) VerifyPushedDigest(enabled bool) *SyncBuilder {
+	builder.sync.verifyPushed = enabled
+
+	return builder
+}
+
+// DigestTagFallback enables pushing under a tag derived from the source digest ("sha256-<12 hex digits>")
+// instead of the destination tag, when the destination tag already points at another image (the sync would
+// mutate it) or the registry refuses to overwrite it (immutable tags). The digest tag used is available
+// from DigestTag() and recorded in the execution report.
+func (builder *SyncBuilder) DigestTagFallback(enabled bool) *SyncBuilder {
+	builder.sync.digestFallback = enabled
+
+	return builder
+}
+
+// CopySignatures enables copying the notation (Notary Project) signatures of the source to the destination, so
+// registries verifying signatures on pull (e.g., Harbor, ACR) accept the synced image.
+// Signatures are bound to the digest they sign: multi-platform images are copied with all their platforms
+// (Platforms is ignored), and RecordPreviousDigest cannot be enabled.
+func (builder *SyncBuilder) CopySignatures(enabled bool) *SyncBuilder {
+	builder.sync.copySigs = enabled
+
+	return builder
+}
+
+// RunOnlyOn restricts the sync to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *SyncBuilder) RunOnlyOn(envs ...Environment) *SyncBuilder {
+	builder.sync.runOnlyOn = append(builder.sync.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the sync mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *SyncBuilder) Resource(resource Resource) *SyncBuilder {
+	builder.sync.resource = resource
+
+	return builder
+}
+
+// Retry attempts the sync up to attempts times (including the first) when it fails with a transient error
+// (e.g., a registry or SSH connection failure), waiting backoff before the second attempt and twice as long
+// before each next one. Failures retrying cannot fix (e.g., authentication, missing images, digest mismatches)
+// are not retried. The timeout, if any, applies to each attempt.
+func (builder *SyncBuilder) Retry(attempts int, backoff time.Duration) *SyncBuilder {
+	builder.sync.set(attempts, backoff)
+
+	return builder
+}
+
+// DependsOn makes the sync start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *SyncBuilder) DependsOn(ops ...Dependency) *SyncBuilder {
+	builder.sync.add(ops)
+
+	return builder
+}
+
+// When makes the sync run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the sync.
+func (builder *SyncBuilder) When(conditions ...Condition) *SyncBuilder {
+	builder.sync.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a sync named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *SyncBuilder) Clone(name string) *SyncBuilder {
+	clone := builder.plan.Sync(name)
+	clone.sync.envGuard = builder.sync.envGuard.clone()
+	clone.sync.resourceHint = builder.sync.resourceHint
+	clone.sync.dependencyList = builder.sync.dependencyList.clone()
+	clone.sync.conditionList = builder.sync.conditionList.clone()
+	clone.sync.retryPolicy = builder.sync.retryPolicy.clone()
+	clone.sync.sourceRegistry = builder.sync.sourceRegistry
+	clone.sync.sourceImage = builder.sync.sourceImage
+	clone.sync.destRegistry = builder.sync.destRegistry
+	clone.sync.destImage = builder.sync.destImage
+	clone.sync.platforms = slices.Clone(builder.sync.platforms)
+	clone.sync.recordPrevious = builder.sync.recordPrevious
+	clone.sync.verifyBlobs = builder.sync.verifyBlobs
+	clone.sync.verifyPushed = builder.sync.verifyPushed
+	clone.sync.digestFallback = builder.sync.digestFallback
+	clone.sync.copySigs = builder.sync.copySigs
+
+	return clone
+}
+
+// Reset makes the builder usable again for a sync named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *SyncBuilder) Reset(name string) *SyncBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
 // Build validates and adds the sync to the plan.
 // The builder becomes unusable after Build() is called.
-// Create a new builder for each operation.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
 func (builder *SyncBuilder) Build() (*Sync, error) {
-	if builder.built {
-		return nil, ErrBuilderAlreadyUsed
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.sync.opName); err != nil {
+		return nil, err
 	}
 
-	builder.built = true
+	if err := builder.sync.validate(); err != nil {
+		return nil, err
+	}
 
 	if builder.sync.sourceImage == nil {
 		return nil, ErrSyncSourceRequired
 	}
 
-	if builder.sync.sourceImage.Digest() == "" {
+	// Images produced by another operation get their digest when it executes
+	if builder.sync.sourceImage.Digest() == "" && builder.sync.sourceImage.producer == nil {
 		return nil, fmt.Errorf("%w for image %q", ErrSyncSourceDigestRequired, builder.sync.sourceImage.Name())
 	}
 
-	if builder.sync.destImage == nil {
-		return nil, ErrSyncDestinationRequired
+	if builder.sync.copySigs && builder.sync.recordPrevious {
+		return nil, ErrSyncSignaturesConflict
 	}
 
-	if len(builder.sync.platforms) == 0 {
-		// Default to both platforms
-		builder.sync.platforms = []Platform{PlatformAMD64, PlatformARM64}
+	if builder.sync.destImage == nil {
+		if err := builder.rewriteDestination(); err != nil {
+			return nil, err
+		}
 	}
 
+	builder.sync.platforms = builder.plan.platformsOrDefault(builder.sync.platforms)
+
 	builder.plan.syncs = append(builder.plan.syncs, builder.sync)
-	builder.plan.operations = append(builder.plan.operations, builder.sync)
+	builder.plan.addOperation(builder.sync)
 
 	return builder.sync, nil
 }
 
+// rewriteDestination sets the destination to the source rewritten by the plan rewrite rules, with the same
+// version.
+func (builder *SyncBuilder) rewriteDestination() error {
+	source := builder.sync.sourceImage
+
+	if source.Protocol() != ProtocolRegistry {
+		return ErrSyncDestinationRequired
+	}
+
+	rewritten, ok := builder.plan.rewrite(source)
+	if !ok {
+		return ErrSyncDestinationRequired
+	}
+
+	if source.Version() == "" {
+		return fmt.Errorf("%w: rewritten destination %q takes the source version", ErrImageVersionRequired, rewritten)
+	}
+
+	image, err := NewImage(rewritten).Version(source.Version()).Build()
+	if err != nil {
+		return fmt.Errorf("invalid rewritten destination %q: %w", rewritten, err)
+	}
+
+	builder.sync.log.Debug().Str("source", source.String()).Str("destination", image.String()).Msg("rewrote destination")
+
+	builder.Destination(image)
+
+	return nil
+}
+
 func (sync *Sync) execute(ctx context.Context) error {
 	// Use digestRef for source (immutable, secure)
 	sourceRef, err := sync.sourceImage.digestRef()
@@ -110,56 +302,57 @@ func (sync *Sync) execute(ctx context.Context) error {
 		Str("destination", destRef).
 		Msg("syncing image")
 
-	// Create source registry client
-	// If no registry provided, use empty credentials (for public images)
-	// Registry host will be inferred from image name by go-containerregistry
-	var srcClient *registry.Client
-	if sync.sourceRegistry != nil {
-		srcClient = registry.NewClient(
-			sync.sourceRegistry.host,
-			sync.sourceRegistry.username,
-			sync.sourceRegistry.password,
-			sync.log.With().Str("registry", "source").Logger(),
-		)
-	} else {
-		// No auth - for public images
-		srcClient = registry.NewClient(
-			"", // Host inferred from image name
-			"", // No username
-			"", // No password
-			sync.log.With().Str("registry", "source").Logger(),
-		)
-	}
-
-	var dstClient *registry.Client
-	if sync.destRegistry != nil {
-		dstClient = registry.NewClient(
-			sync.destRegistry.host,
-			sync.destRegistry.username,
-			sync.destRegistry.password,
-			sync.log.With().Str("registry", "destination").Logger(),
-		)
-	} else {
-		// No auth - attempting to push without credentials will fail
-		dstClient = registry.NewClient(
-			"", // Host inferred from image name
-			"", // No username
-			"", // No password
-			sync.log.With().Str("registry", "destination").Logger(),
-		)
-	}
-
-	// Create syncer
-	syncer := syncsvc.NewSyncer(srcClient, dstClient, sync.log)
+	// Create the syncer and its registry clients
+	// If no registry provided, use empty credentials (for public images): the registry host is
+	// inferred from the image name by go-containerregistry, and pushing without credentials will fail
+	syncer, dstClient := sync.syncer()
+
+	if err := sync.destRegistry.ensureRepository(ctx, sync.destImage.Path(), sync.log); err != nil {
+		return err
+	}
+
+	// Push under the digest tag rather than mutating an existing destination tag
+	fallbackRef := sync.destImage.ref.Name() + ":" + digestTag(sync.sourceImage.Digest())
+
+	if sync.digestFallback {
+		change, err := sync.tagChange(ctx, syncer, dstClient, sourceRef, destRef)
+		if err != nil {
+			return err
+		}
+
+		if change != "" {
+			sync.log.Warn().Str("destination", fallbackRef).Msg("destination tag exists: pushing under digest tag")
+
+			destRef = fallbackRef
+		}
+	}
 
 	// Sync the image by digest and capture destination digest
-	destDigest, err := syncer.SyncImage(ctx, sourceRef, destRef)
+	opts := sync.options()
+
+	result, err := syncer.SyncImageWithOptions(ctx, sourceRef, destRef, opts)
+	if err != nil && sync.digestFallback && destRef != fallbackRef && errors.Is(err, registry.ErrTagImmutable) {
+		sync.log.Warn().Err(err).Str("destination", fallbackRef).Msg("destination tag refused: pushing under digest tag")
+
+		destRef = fallbackRef
+		result, err = syncer.SyncImageWithOptions(ctx, sourceRef, destRef, opts)
+	}
+
 	if err != nil {
 		return fmt.Errorf("failed to sync image: %w", err)
 	}
 
+	if destRef == fallbackRef {
+		sync.digestTag = fallbackRef
+	}
+
+	destDigest := result.Digest
+
 	// Store the destination digest (computed locally for security)
 	sync.destDigest = destDigest
+	sync.previousDigest = result.PreviousDigest
+	sync.verified = result.VerifiedBlobs
+	sync.signatures = result.Signatures
 
 	// Auto-populate destination image digest for subsequent operations (e.g., scanning)
 	// Update the internal reference digest
@@ -171,12 +364,32 @@ func (sync *Sync) execute(ctx context.Context) error {
 	sync.destImage.ref.Digest = parsedDigest
 
 	sync.log.Info().
+		Str("destination", destRef).
 		Str("dest_digest", destDigest).
+		Str("previous_digest", sync.previousDigest).
+		Bool("chart", result.Chart).
+		Int("verified_blobs", len(sync.verified)).
+		Int("signatures", len(sync.signatures)).
 		Msg("image sync complete")
 
+	sync.notify(ctx, sourceRef, destRef)
+
 	return nil
 }
 
+// Destination returns the destination image (rewritten from the source when not set, see Plan.RewriteRule).
+func (sync *Sync) Destination() *Image {
+	return sync.destImage
+}
+
+// OutputImage returns the destination image, for the operations reading it (e.g., ScanBuilder.Source): they
+// depend on the sync without DependsOn, and use the digest pushed by the sync, resolved when it executes.
+func (sync *Sync) OutputImage() *Image {
+	sync.destImage.producer = sync
+
+	return sync.destImage
+}
+
 // DestDigest returns the destination image digest after sync execution.
 // The digest is computed locally from the pushed image/manifest, not retrieved
 // from the registry, providing defense in depth against compromised registries.
@@ -185,7 +398,188 @@ func (sync *Sync) DestDigest() string {
 	return sync.destDigest
 }
 
-// operationName returns the sync operation name (implements operation interface).
-func (sync *Sync) operationName() string {
-	return sync.opName
+// PreviousDigest returns the digest the destination tag pointed to before the sync,
+// as recorded in the "quark.dev/previous-digest" annotation.
+// Returns empty string if RecordPreviousDigest was not enabled, the tag did not exist,
+// or the sync has not been executed yet.
+func (sync *Sync) PreviousDigest() string {
+	return sync.previousDigest
+}
+
+// VerifiedDigests returns the layer digests verified while copying, sorted.
+// Returns nil if VerifyBlobs was not enabled, blobs were mounted within the registry,
+// or the sync has not been executed yet.
+func (sync *Sync) VerifiedDigests() []string {
+	return sync.verified
+}
+
+// Signatures returns the digests of the notation signatures copied to the destination.
+// Returns nil if CopySignatures was not enabled, the source is not signed, or the sync has not been executed yet.
+func (sync *Sync) Signatures() []string {
+	return sync.signatures
+}
+
+// DigestTag returns the digest-derived tag reference the image was pushed under instead of the destination tag
+// (see DigestTagFallback). Returns empty string if the destination tag was pushed, or the sync has not been
+// executed yet.
+func (sync *Sync) DigestTag() string {
+	return sync.digestTag
+}
+
+// Env returns the destination version and digest as <prefix>_VERSION and <prefix>_DIGEST variables
+// (e.g., VECTOR_VERSION, VECTOR_DIGEST), to be written with WriteEnv.
+// Only valid after plan execution: returns an empty map if the sync was not executed.
+func (sync *Sync) Env(prefix string) map[string]string {
+	if sync.destDigest == "" {
+		return map[string]string{}
+	}
+
+	return imageEnv(prefix, sync.destImage.Version(), sync.destDigest)
+}
+
+// options returns the options of the syncer.
+func (sync *Sync) options() syncsvc.Options {
+	platforms := make([]string, 0, len(sync.platforms))
+	for _, platform := range sync.platforms {
+		platforms = append(platforms, platform.String())
+	}
+
+	return syncsvc.Options{
+		Platforms:            platforms,
+		RecordPreviousDigest: sync.recordPrevious,
+		VerifyBlobs:          sync.verifyBlobs,
+		VerifyPushedDigest:   sync.verifyPushed,
+		CopySignatures:       sync.copySigs,
+	}
+}
+
+// syncer returns the syncer of the operation, with its source and destination clients.
+func (sync *Sync) syncer() (*syncsvc.Syncer, *registry.Client) {
+	srcClient := newRegistryClient(sync.sourceRegistry, sync.log.With().Str("registry", "source").Logger())
+	dstClient := newRegistryClient(sync.destRegistry, sync.log.With().Str("registry", "destination").Logger())
+
+	return syncsvc.NewSyncer(srcClient, dstClient, sync.log), dstClient
+}
+
+// tagChange describes overwriting destRef, or returns an empty string if the tag does not exist or already points
+// at the content the sync pushes: for multi-platform images, the manifest list of the synced platforms, whose
+// digest is not the source digest. Sources produced by an operation that did not execute yet are not compared.
+func (sync *Sync) tagChange(
+	ctx context.Context,
+	syncer *syncsvc.Syncer,
+	dstClient *registry.Client,
+	sourceRef, destRef string,
+) (string, error) {
+	if sync.sourceImage.Digest() != "" {
+		upToDate, err := syncer.UpToDate(ctx, sourceRef, destRef, sync.options())
+		if err != nil {
+			return "", fmt.Errorf("failed to compare destination tag: %w", err)
+		}
+
+		if upToDate {
+			return "", nil
+		}
+	}
+
+	return tagOverwrite(ctx, dstClient, destRef, "")
+}
+
+// destructiveChange implements destructiveOperation: a sync overwrites an existing destination tag,
+// unless it falls back to the digest tag.
+func (sync *Sync) destructiveChange(ctx context.Context) (string, error) {
+	if sync.digestFallback {
+		return "", nil
+	}
+
+	sourceRef, destRef, err := sync.refs()
+	if err != nil {
+		return "", err
+	}
+
+	syncer, dstClient := sync.syncer()
+
+	return sync.tagChange(ctx, syncer, dstClient, sourceRef, destRef)
+}
+
+// refs returns the source reference (by digest, when known) and the destination tag reference.
+func (sync *Sync) refs() (sourceRef, destRef string, err error) {
+	sourceRef = sync.sourceImage.String()
+
+	if sync.sourceImage.Digest() != "" {
+		if sourceRef, err = sync.sourceImage.digestRef(); err != nil {
+			return "", "", fmt.Errorf("failed to build source reference: %w", err)
+		}
+	}
+
+	if destRef, err = sync.destImage.tagRef(); err != nil {
+		return "", "", fmt.Errorf("failed to build destination reference: %w", err)
+	}
+
+	return sourceRef, destRef, nil
+}
+
+// plannedChanges implements dryRunOperation: the source must exist, and the destination registry must accept
+// the credentials.
+func (sync *Sync) plannedChanges(ctx context.Context) ([]string, error) {
+	sourceRef, err := checkImage(ctx, newRegistryClient(sync.sourceRegistry, sync.log), sync.sourceImage)
+	if err != nil {
+		return nil, err
+	}
+
+	destRef, err := sync.destImage.tagRef()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build destination reference: %w", err)
+	}
+
+	syncer, dstClient := sync.syncer()
+
+	change, err := sync.tagChange(ctx, syncer, dstClient, sourceRef, destRef)
+	if err != nil {
+		return nil, err
+	}
+
+	if change != "" && sync.digestFallback {
+		destRef = sync.destImage.ref.Name() + ":" + digestTag(sync.sourceImage.Digest())
+		change = ""
+	}
+
+	changes := []string{fmt.Sprintf("Would copy %s to %s", sourceRef, destRef)}
+	if change != "" {
+		changes = append(changes, "Would "+change)
+	}
+
+	return changes, nil
+}
+
+// digestTag returns the tag derived from digest: "<algorithm>-<first 12 hex digits>" (e.g., "sha256-1234567890ab").
+func digestTag(dgst string) string {
+	algorithm, hex, _ := strings.Cut(dgst, ":")
+
+	return algorithm + "-" + hex[:min(len(hex), digestTagLength)]
+}
+
+// cacheSources implements cacheableOperation: a sync reads its source image.
+func (sync *Sync) cacheSources() []*Image {
+	return []*Image{sync.sourceImage}
+}
+
+// outputDigest implements outputOperation.
+func (sync *Sync) outputDigest() (*Image, *string) {
+	return sync.destImage, &sync.destDigest
+}
+
+// producedDigest implements producingOperation.
+func (sync *Sync) producedDigest() string {
+	return sync.DestDigest()
+}
+
+// destination implements destinationOperation: the destination image.
+func (sync *Sync) destination() *Image {
+	return sync.destImage
+}
+
+// resolveRegistries implements registryOperation.
+func (sync *Sync) resolveRegistries(lookup func(image *Image) *Registry) {
+	sync.sourceRegistry = lookup(sync.sourceImage)
+	sync.destRegistry = lookup(sync.destImage)
 }
@@ -2,8 +2,21 @@ package sdk_test
 
 import (
 	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
 	"github.com/farcloser/quark/sdk"
 )
 
@@ -89,6 +102,18 @@ func TestSyncBuilder_Build(t *testing.T) {
 			},
 			wantErr: sdk.ErrSyncSourceDigestRequired,
 		},
+		{
+			name: "signatures copied with the previous digest annotation",
+			build: func(plan *sdk.Plan) (*sdk.Sync, error) {
+				return plan.Sync("test-sync-signatures").
+					Source(sourceWithDigest).
+					Destination(destImage).
+					CopySignatures(true).
+					RecordPreviousDigest(true).
+					Build()
+			},
+			wantErr: sdk.ErrSyncSignaturesConflict,
+		},
 	}
 
 	for _, tt := range tests {
@@ -205,3 +230,184 @@ func TestSyncBuilder_RegistryLookup(t *testing.T) {
 		t.Error("Build() returned nil sync")
 	}
 }
+
+// INTENTION: With DigestTagFallback, a sync that would mutate an existing destination tag, or that the registry
+// refuses to tag (immutable tags), pushes under the digest-derived tag and leaves the destination tag untouched.
+func TestSync_DigestTagFallback(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		existing  bool
+		immutable bool
+	}{
+		{name: "existing tag", existing: true},
+		{name: "immutable tag refused", immutable: true},
+		{name: "new tag"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+			server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+				if tt.immutable && req.Method == http.MethodPut && req.URL.Path == "/v2/mirror/app/manifests/1.0.0" {
+					writer.Header().Set("Content-Type", "application/json")
+					writer.WriteHeader(http.StatusBadRequest)
+					_, _ = writer.Write([]byte(`{"errors":[{"code":"TAG_INVALID","message":"tag is immutable"}]}`))
+
+					return
+				}
+
+				handler.ServeHTTP(writer, req)
+			}))
+			t.Cleanup(server.Close)
+
+			host := strings.TrimPrefix(server.URL, "http://")
+			digest := pushRandomImage(t, host+"/source/app:1.0.0")
+
+			var current string
+			if tt.existing {
+				current = pushRandomImage(t, host+"/mirror/app:1.0.0")
+			}
+
+			source, err := sdk.NewImage("source/app").Domain(host).Version("1.0.0").Digest(digest).Build()
+			if err != nil {
+				t.Fatalf("Failed to create source image: %v", err)
+			}
+
+			destination, err := sdk.NewImage("mirror/app").Domain(host).Version("1.0.0").Build()
+			if err != nil {
+				t.Fatalf("Failed to create destination image: %v", err)
+			}
+
+			plan := sdk.NewPlan(testPlanName)
+
+			sync, err := plan.Sync("mirror").Source(source).Destination(destination).DigestTagFallback(true).Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			if err := plan.Execute(t.Context()); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			want := ""
+			if tt.existing || tt.immutable {
+				want = host + "/mirror/app:sha256-" + strings.TrimPrefix(digest, "sha256:")[:12]
+			}
+
+			if sync.DigestTag() != want {
+				t.Errorf("DigestTag() = %q, want %q", sync.DigestTag(), want)
+			}
+
+			if sync.DestDigest() != digest {
+				t.Errorf("DestDigest() = %q, want %q", sync.DestDigest(), digest)
+			}
+
+			if tt.existing {
+				tag, err := name.ParseReference(host + "/mirror/app:1.0.0")
+				if err != nil {
+					t.Fatalf("Failed to parse reference: %v", err)
+				}
+
+				desc, err := remote.Head(tag)
+				if err != nil || desc.Digest.String() != current {
+					t.Errorf("destination tag = %v (%v), want %s", desc, err, current)
+				}
+			}
+
+			details := strings.Join(plan.Report().Operations[0].Details, "\n")
+			if want != "" && !strings.Contains(details, want) {
+				t.Errorf("Report() details = %q, want digest tag %q", details, want)
+			}
+		})
+	}
+}
+
+// pushMultiPlatformIndex pushes an index of random images for linux/amd64, linux/arm64 and linux/s390x to ref, and
+// returns its digest.
+func pushMultiPlatformIndex(t *testing.T, ref string) string {
+	t.Helper()
+
+	var idx v1.ImageIndex = empty.Index
+
+	for _, arch := range []string{"amd64", "arm64", "s390x"} {
+		img, err := random.Image(256, 1)
+		if err != nil {
+			t.Fatalf("Failed to create random image: %v", err)
+		}
+
+		idx = mutate.AppendManifests(idx, mutate.IndexAddendum{
+			Add:        img,
+			Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: arch}},
+		})
+	}
+
+	parsed, err := name.ParseReference(ref)
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+
+	if err := remote.WriteIndex(parsed, idx); err != nil {
+		t.Fatalf("Failed to push index: %v", err)
+	}
+
+	digest, err := idx.Digest()
+	if err != nil {
+		t.Fatalf("Failed to get index digest: %v", err)
+	}
+
+	return digest.String()
+}
+
+// INTENTION: Re-syncing a multi-platform image the destination tag already holds is not a tag change, although
+// the pushed manifest list (synced platforms only) has another digest than the source index: DigestTagFallback
+// pushes to the destination tag again, not to the digest tag.
+func TestSync_DigestTagFallback_MultiPlatform(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	digest := pushMultiPlatformIndex(t, host+"/source/app:1.0.0")
+
+	var pushed string
+
+	for run := range 2 {
+		source, err := sdk.NewImage("source/app").Domain(host).Version("1.0.0").Digest(digest).Build()
+		if err != nil {
+			t.Fatalf("Failed to create source image: %v", err)
+		}
+
+		destination, err := sdk.NewImage("mirror/app").Domain(host).Version("1.0.0").Build()
+		if err != nil {
+			t.Fatalf("Failed to create destination image: %v", err)
+		}
+
+		plan := sdk.NewPlan(testPlanName)
+
+		sync, err := plan.Sync("mirror").Source(source).Destination(destination).DigestTagFallback(true).Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		if err := plan.Execute(t.Context()); err != nil {
+			t.Fatalf("run %d: Execute() error = %v", run, err)
+		}
+
+		if sync.DigestTag() != "" {
+			t.Errorf("run %d: DigestTag() = %q, want the destination tag pushed", run, sync.DigestTag())
+		}
+
+		if run == 0 {
+			pushed = sync.DestDigest()
+		}
+
+		if sync.DestDigest() == digest || sync.DestDigest() != pushed {
+			t.Errorf("run %d: DestDigest() = %q, want the filtered manifest list %q", run, sync.DestDigest(), pushed)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package sdk
+
+// SyncTemplate stamps syncs sharing the configuration of a SyncBuilder (e.g., the same platforms
+// and environment guards for every mirrored image).
+type SyncTemplate struct {
+	builder *SyncBuilder
+}
+
+// Template returns a template with the current configuration of the builder.
+// Later changes to the builder do not affect the template, and the builder remains usable.
+func (builder *SyncBuilder) Template() *SyncTemplate {
+	return &SyncTemplate{builder: builder.Clone(builder.sync.opName)}
+}
+
+// New returns a builder for a sync named name, configured as the template.
+func (template *SyncTemplate) New(name string) *SyncBuilder {
+	return template.builder.Clone(name)
+}
+
+// ScanTemplate stamps scans sharing the configuration of a ScanBuilder (e.g., the same severity checks,
+// format and timeout for every scanned image).
+type ScanTemplate struct {
+	builder *ScanBuilder
+}
+
+// Template returns a template with the current configuration of the builder.
+// Later changes to the builder do not affect the template, and the builder remains usable.
+func (builder *ScanBuilder) Template() *ScanTemplate {
+	return &ScanTemplate{builder: builder.Clone(builder.scan.opName)}
+}
+
+// New returns a builder for a scan named name, configured as the template.
+func (template *ScanTemplate) New(name string) *ScanBuilder {
+	return template.builder.Clone(name)
+}
+
+// BuildTemplate stamps builds sharing the configuration of a BuildBuilder (e.g., the same nodes
+// and timeout for every image of a repository).
+type BuildTemplate struct {
+	builder *BuildBuilder
+}
+
+// Template returns a template with the current configuration of the builder.
+// Later changes to the builder do not affect the template, and the builder remains usable.
+func (builder *BuildBuilder) Template() *BuildTemplate {
+	return &BuildTemplate{builder: builder.Clone(builder.build.opName)}
+}
+
+// New returns a builder for a build named name, configured as the template.
+func (template *BuildTemplate) New(name string) *BuildBuilder {
+	return template.builder.Clone(name)
+}
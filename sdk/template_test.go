@@ -0,0 +1,51 @@
+package sdk_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: A template stamps any number of independent operations sharing its settings, and is a snapshot:
+// changes made to the original builder afterwards are not part of the template.
+func TestScanTemplate(t *testing.T) {
+	t.Parallel()
+
+	plan := sdk.NewPlan(testPlanName)
+
+	builder := plan.Scan("scan").
+		Severity(sdk.SeverityHigh, sdk.ActionError).
+		Timeout(time.Minute).
+		RunOnlyOn(sdk.EnvCI)
+
+	template := builder.Template()
+
+	// Setting a source on the builder after Template() does not change the template
+	source, err := sdk.NewImage("test/source").Domain(testDomain).Version(testVersion).Digest(testDigest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create image: %v", err)
+	}
+
+	builder.Source(source)
+
+	if _, err := template.New("scan-without-source").Build(); !errors.Is(err, sdk.ErrScanImageRequired) {
+		t.Errorf("template Build() without source error = %v, want %v", err, sdk.ErrScanImageRequired)
+	}
+
+	for _, name := range []string{"app", "worker", "cron"} {
+		image, err := sdk.NewImage("test/" + name).Domain(testDomain).Version(testVersion).Digest(testDigest).Build()
+		if err != nil {
+			t.Fatalf("Failed to create image: %v", err)
+		}
+
+		if _, err := template.New("scan-" + name).Source(image).Build(); err != nil {
+			t.Errorf("template Build() for %s error = %v", name, err)
+		}
+	}
+
+	if _, err := builder.Build(); err != nil {
+		t.Errorf("original builder Build() error = %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/farcloser/quark/filesystem"
+)
+
+// traceEvent is an event of the Chrome trace event format, read by Perfetto (ui.perfetto.dev) and
+// chrome://tracing. Complete events ("X") span an operation; metadata events ("M") name the lanes.
+type traceEvent struct {
+	Name     string         `json:"name"`
+	Category string         `json:"cat,omitempty"`
+	Phase    string         `json:"ph"`
+	Start    int64          `json:"ts"`
+	Duration int64          `json:"dur,omitempty"`
+	Process  int            `json:"pid"`
+	Lane     int            `json:"tid"`
+	Args     map[string]any `json:"args,omitempty"`
+}
+
+// TraceTo writes the execution timeline to path after every Execute (Chrome trace event format): open it in
+// Perfetto (ui.perfetto.dev) to see when each operation ran and where time is spent.
+// QUARK_TRACE (set by the CLI --trace flag) takes precedence, except for plans run by an Orchestrator.
+func (plan *Plan) TraceTo(path string) {
+	plan.tracePath = path
+}
+
+// WriteTrace renders the execution timeline in the Chrome trace event format. The plan spans the first lane;
+// operations that ran are spread over the following lanes, an operation per lane at a time, so operations
+// running concurrently appear side by side. Events are categorized by operation kind (e.g., "sync", "build").
+func (report *Report) WriteTrace(out io.Writer) error {
+	events := []traceEvent{
+		{Name: "process_name", Phase: "M", Process: 1, Args: map[string]any{"name": "quark " + report.Plan}},
+		{Name: "thread_name", Phase: "M", Process: 1, Lane: 0, Args: map[string]any{"name": "plan"}},
+		{
+			Name:     report.Plan,
+			Category: "plan",
+			Phase:    "X",
+			Duration: report.Duration.Microseconds(),
+			Process:  1,
+		},
+	}
+
+	operations := make([]OperationReport, 0, len(report.Operations))
+
+	for _, op := range report.Operations {
+		if !op.Started.IsZero() {
+			operations = append(operations, op)
+		}
+	}
+
+	sort.SliceStable(operations, func(i, j int) bool {
+		return operations[i].Started.Before(operations[j].Started)
+	})
+
+	// End of the last operation of each lane
+	var lanes []time.Time
+
+	for _, op := range operations {
+		lane := 0
+		for lane < len(lanes) && lanes[lane].After(op.Started) {
+			lane++
+		}
+
+		if lane == len(lanes) {
+			lanes = append(lanes, time.Time{})
+			events = append(events, traceEvent{
+				Name:    "thread_name",
+				Phase:   "M",
+				Process: 1,
+				Lane:    lane + 1,
+				Args:    map[string]any{"name": fmt.Sprintf("operations %d", lane+1)},
+			})
+		}
+
+		lanes[lane] = op.Started.Add(op.Duration)
+
+		args := map[string]any{"status": string(op.Status)}
+		if op.Error != "" {
+			args["error"] = op.Error
+		}
+
+		if len(op.Details) > 0 {
+			args["details"] = strings.Join(op.Details, "\n")
+		}
+
+		events = append(events, traceEvent{
+			Name:     op.Name,
+			Category: op.Kind,
+			Phase:    "X",
+			Start:    op.Started.Sub(report.Started).Microseconds(),
+			Duration: op.Duration.Microseconds(),
+			Process:  1,
+			Lane:     lane + 1,
+			Args:     args,
+		})
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+
+	//nolint:wrapcheck // Standard library JSON encoding
+	return encoder.Encode(map[string]any{"traceEvents": events, "displayTimeUnit": "ms"})
+}
+
+// writeTrace writes the timeline of the last execution where configured (QUARK_TRACE or TraceTo).
+func (plan *Plan) writeTrace() {
+	path := plan.processEnv("QUARK_TRACE", plan.tracePath)
+	if path == "" {
+		return
+	}
+
+	var content strings.Builder
+	if err := plan.report.WriteTrace(&content); err != nil {
+		plan.log.Warn().Err(err).Msg("failed to write execution trace")
+
+		return
+	}
+
+	if err := os.WriteFile(path, []byte(content.String()), filesystem.FilePermissionsDefault); err != nil {
+		plan.log.Warn().Err(err).Str("path", path).Msg("failed to write execution trace")
+
+		return
+	}
+
+	plan.log.Info().Str("path", path).Msg("execution trace written")
+}
@@ -0,0 +1,158 @@
+package sdk_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+type testTrace struct {
+	TraceEvents []struct {
+		Name     string         `json:"name"`
+		Category string         `json:"cat"`
+		Phase    string         `json:"ph"`
+		Start    int64          `json:"ts"`
+		Duration int64          `json:"dur"`
+		Lane     int            `json:"tid"`
+		Args     map[string]any `json:"args"`
+	} `json:"traceEvents"`
+}
+
+// INTENTION: Operations that ran become complete events relative to the plan start, on the first lane free
+// when they start, so concurrent operations do not overlap; operations that did not run are left out.
+func TestReport_WriteTrace(t *testing.T) {
+	t.Parallel()
+
+	started := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	report := &sdk.Report{
+		Plan:     testPlanName,
+		Started:  started,
+		Duration: 5 * time.Second,
+		Operations: []sdk.OperationReport{
+			{Name: "sync", Kind: "sync", Status: sdk.StatusSucceeded, Started: started, Duration: 2 * time.Second},
+			{
+				Name:     "build",
+				Kind:     "build",
+				Status:   sdk.StatusFailed,
+				Started:  started.Add(time.Second),
+				Duration: 3 * time.Second,
+				Error:    "boom",
+			},
+			{
+				Name:     "scan",
+				Kind:     "scan",
+				Status:   sdk.StatusSucceeded,
+				Started:  started.Add(2 * time.Second),
+				Duration: time.Second,
+			},
+			{Name: "after", Kind: "scan", Status: sdk.StatusNotRun},
+		},
+	}
+
+	var out strings.Builder
+	if err := report.WriteTrace(&out); err != nil {
+		t.Fatalf("WriteTrace() error = %v", err)
+	}
+
+	var trace testTrace
+	if err := json.Unmarshal([]byte(out.String()), &trace); err != nil {
+		t.Fatalf("WriteTrace() is not JSON: %v", err)
+	}
+
+	want := map[string]struct {
+		start int64
+		lane  int
+	}{
+		testPlanName: {start: 0, lane: 0},
+		"sync":       {start: 0, lane: 1},
+		"build":      {start: 1_000_000, lane: 2},
+		"scan":       {start: 2_000_000, lane: 1},
+	}
+
+	spans := 0
+
+	for _, event := range trace.TraceEvents {
+		if event.Phase != "X" {
+			continue
+		}
+
+		spans++
+
+		expected, ok := want[event.Name]
+		if !ok {
+			t.Errorf("unexpected event %q", event.Name)
+
+			continue
+		}
+
+		if event.Start != expected.start || event.Lane != expected.lane {
+			t.Errorf("event %q at %d on lane %d, want %d on lane %d",
+				event.Name, event.Start, event.Lane, expected.start, expected.lane)
+		}
+
+		if event.Name == "build" && (event.Category != "build" || event.Args["error"] != "boom") {
+			t.Errorf("event build = %s %v, want category build and error", event.Category, event.Args)
+		}
+	}
+
+	if spans != len(want) {
+		t.Errorf("WriteTrace() = %d complete events, want %d", spans, len(want))
+	}
+}
+
+// INTENTION: TraceTo writes the timeline after every execution, failed ones included, and the report records
+// when operations started.
+func TestPlan_TraceTo(t *testing.T) {
+	t.Parallel()
+
+	dest, err := sdk.NewImage("my-org/app-sbom").Domain("ghcr.io").Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	plan := sdk.NewPlan(testPlanName)
+
+	tracePath := filepath.Join(t.TempDir(), "trace.json")
+	plan.TraceTo(tracePath)
+
+	// Executing an artifact fails before any network access: the file does not exist
+	if _, err := plan.Artifact("publish").
+		Destination(dest).
+		ArtifactType("application/vnd.example.sbom").
+		File(filepath.Join(t.TempDir(), "missing.json"), "application/json").
+		Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if err := plan.Execute(t.Context()); err == nil {
+		t.Fatal("Execute() should fail")
+	}
+
+	if started := plan.Report().Operations[0].Started; started.IsZero() {
+		t.Error("Report().Operations[0].Started is zero")
+	}
+
+	content, err := os.ReadFile(tracePath)
+	if err != nil {
+		t.Fatalf("trace not written: %v", err)
+	}
+
+	var trace testTrace
+	if err := json.Unmarshal(content, &trace); err != nil {
+		t.Fatalf("trace is not JSON: %v", err)
+	}
+
+	for _, event := range trace.TraceEvents {
+		if event.Name == "publish" && event.Phase == "X" && event.Category == "artifact" {
+			return
+		}
+	}
+
+	t.Errorf("trace has no event for the operation: %s", content)
+}
@@ -0,0 +1,200 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/farcloser/quark/internal/tools"
+	"github.com/farcloser/quark/ssh"
+)
+
+// Validate checks, without executing anything, what the operations of the plan need to run: the external tools
+// are installed (or installable), the declared registries accept their credentials, the build nodes are
+// reachable over SSH, and the Dockerfiles and build contexts exist. All problems are reported at once, joined
+// in an error wrapping ErrPlanValidation. Operations that do not run in the execution environment are not checked.
+// Execute validates the plan before running any operation, so it fails before the first change instead of
+// mid-run. QUARK_VALIDATE=true (set by `quark validate`) makes Execute stop after validation.
+func (plan *Plan) Validate(ctx context.Context) error {
+	pool := ssh.NewPool(plan.log)
+	defer func() {
+		if err := pool.CloseAll(); err != nil {
+			plan.log.Warn().Err(err).Msg("failed to close SSH connections")
+		}
+	}()
+
+	env := plan.environment
+	if env == "" {
+		env = DetectEnvironment()
+	}
+
+	return plan.validate(ctx, env, pool)
+}
+
+// SkipValidation disables the validation Execute runs before the operations (see Validate), for plans whose
+// operations provide what validation checks (e.g., an earlier operation starts the build node).
+func (plan *Plan) SkipValidation(skip bool) {
+	plan.skipValidation = skip
+}
+
+// validate checks the plan, connecting to build nodes with pool (so execution reuses the connections).
+func (plan *Plan) validate(ctx context.Context, env Environment, pool *ssh.Pool) error {
+	plan.log.Info().Msg("validating plan")
+
+	var (
+		problems []error
+		tooling  []tools.Tool
+		nodes    []nodeConnection
+	)
+
+	for _, op := range plan.operations {
+		if !op.runsOn(env) {
+			continue
+		}
+
+		switch typed := op.(type) {
+		case *Scan:
+			tooling = appendUnique(tooling, tools.Trivy)
+		case *Verify:
+			if typed.policy != "" {
+				tooling = appendUnique(tooling, tools.Notation)
+				problems = append(problems, checkPath(op, "notation trust policy", typed.policy, true))
+			} else {
+				tooling = appendUnique(tooling, tools.Cosign)
+			}
+		case *Audit:
+			if typed.image != nil {
+				tooling = appendUnique(tooling, tools.Dockle)
+			}
+
+			if typed.dockerfile != "" {
+				problems = append(problems, checkPath(op, "Dockerfile", typed.dockerfile, false))
+			}
+		case *Build:
+			problems = append(problems,
+				checkPath(op, "build context", typed.context, true),
+				checkPath(op, "Dockerfile", filepath.Join(typed.context, typed.dockerfile), false))
+
+			if len(typed.nodes) > 0 {
+				first := typed.nodes[0]
+				nodes = appendUnique(nodes, nodeConnection{node: first, forwardAgent: first.forwardAgent})
+			}
+		case *NodeMaintenance:
+			nodes = appendUnique(nodes, nodeConnection{node: typed.node})
+		case *ProvisionNode:
+			nodes = appendUnique(nodes, nodeConnection{node: typed.node})
+		case *RemoteRun:
+			nodes = appendUnique(nodes, nodeConnection{node: typed.node})
+		case *ContainerdImport:
+			for _, node := range typed.nodes {
+				nodes = appendUnique(nodes, nodeConnection{node: node})
+			}
+		case *RepositoryDocs:
+			if typed.readme != "" {
+				problems = append(problems, checkPath(op, "README", typed.readme, false))
+			}
+		case *HarborProject:
+			if err := typed.client().CheckAuth(ctx); err != nil {
+				problems = append(problems, fmt.Errorf("%w: operation %q: harbor %s: %w", ErrPlanValidation,
+					op.operationName(), typed.url, err))
+			}
+		}
+	}
+
+	for _, tool := range tooling {
+		if err := tools.Available(tool); err != nil {
+			problems = append(problems, fmt.Errorf("%w: %w", ErrPlanValidation, err))
+		}
+	}
+
+	problems = append(problems, plan.validateRegistries(ctx)...)
+
+	for _, conn := range nodes {
+		if err := conn.check(pool); err != nil {
+			problems = append(problems, fmt.Errorf("%w: build node %q unreachable: %w", ErrPlanValidation,
+				conn.node.name, err))
+		}
+	}
+
+	if err := errors.Join(problems...); err != nil {
+		return err
+	}
+
+	plan.log.Info().Msg("plan is valid")
+
+	return nil
+}
+
+// validateRegistries checks that the declared registries with credentials accept them, in host order.
+func (plan *Plan) validateRegistries(ctx context.Context) []error {
+	hosts := make([]string, 0, len(plan.registries))
+	for host := range plan.registries {
+		hosts = append(hosts, host)
+	}
+
+	slices.Sort(hosts)
+
+	var problems []error
+
+	for _, host := range hosts {
+		reg := plan.registries[host]
+		if reg.username == "" && len(reg.auth) == 0 {
+			continue
+		}
+
+		if err := newRegistryClient(reg, plan.log).CheckAuth(ctx); err != nil {
+			problems = append(problems, fmt.Errorf("%w: registry %s: %w", ErrPlanValidation, reg.host, err))
+		}
+	}
+
+	return problems
+}
+
+// nodeConnection is a build node connection operations open.
+type nodeConnection struct {
+	node         *BuildNode
+	forwardAgent bool
+}
+
+// check opens the connection.
+func (conn nodeConnection) check(pool *ssh.Pool) error {
+	var err error
+
+	if conn.forwardAgent {
+		_, err = pool.GetClientWithAgentForwarding(conn.node.endpoint)
+	} else {
+		_, err = pool.GetClient(conn.node.endpoint)
+	}
+
+	//nolint:wrapcheck // Wrapped by the caller
+	return err
+}
+
+// checkPath returns a problem unless path exists, and is a directory when dir is true (a file otherwise).
+func checkPath(op operation, what, path string, dir bool) error {
+	info, err := os.Stat(path)
+
+	switch {
+	case err != nil:
+		return fmt.Errorf("%w: operation %q: %s %s not found", ErrPlanValidation, op.operationName(), what, path)
+	case dir && !info.IsDir():
+		return fmt.Errorf("%w: operation %q: %s %s is not a directory", ErrPlanValidation, op.operationName(), what,
+			path)
+	case !dir && info.IsDir():
+		return fmt.Errorf("%w: operation %q: %s %s is a directory", ErrPlanValidation, op.operationName(), what, path)
+	default:
+		return nil
+	}
+}
+
+// appendUnique appends value to values, unless already there.
+func appendUnique[T comparable](values []T, value T) []T {
+	if slices.Contains(values, value) {
+		return values
+	}
+
+	return append(values, value)
+}
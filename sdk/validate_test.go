@@ -0,0 +1,82 @@
+package sdk_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/sdk"
+)
+
+// newAuthRegistry starts a registry API endpoint accepting only user:secret, and returns its host.
+func newAuthRegistry(t *testing.T) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if username, password, ok := req.BasicAuth(); !ok || username != "user" || password != "secret" {
+			writer.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			writer.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		writer.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	return serverURL.Host
+}
+
+// INTENTION: Validate reports every problem of the plan at once (rejected credentials, missing build context
+// and Dockerfile) without executing anything, and passes once they are fixed.
+func TestPlan_Validate(t *testing.T) {
+	t.Parallel()
+
+	host := newAuthRegistry(t)
+	contextDir := t.TempDir()
+	dockerfile := filepath.Join(contextDir, "Dockerfile")
+
+	newPlan := func(password string) *sdk.Plan {
+		plan := sdk.NewPlan(testPlanName)
+
+		if _, err := plan.Registry(host).Username("user").Password(password).Build(); err != nil {
+			t.Fatalf("Failed to create registry: %v", err)
+		}
+
+		if _, err := plan.Audit("lint").Dockerfile(dockerfile).Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		return plan
+	}
+
+	err := newPlan("wrong").Validate(t.Context())
+	if !errors.Is(err, sdk.ErrPlanValidation) {
+		t.Fatalf("Validate() error = %v, want ErrPlanValidation", err)
+	}
+
+	for _, want := range []string{"registry " + host, "Dockerfile " + dockerfile + " not found"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want a problem mentioning %q", err, want)
+		}
+	}
+
+	if err := os.WriteFile(dockerfile, []byte("FROM scratch\n"), filesystem.FilePermissionsPrivate); err != nil {
+		t.Fatalf("Failed to write Dockerfile: %v", err)
+	}
+
+	if err := newPlan("secret").Validate(t.Context()); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+}
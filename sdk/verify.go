@@ -0,0 +1,333 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/farcloser/quark/internal/cosign"
+	"github.com/farcloser/quark/internal/notation"
+)
+
+// Verify represents a signature verification gate: it fails unless the image is signed with the expected
+// cosign key or keyless identity, or with a notation signature trusted by the trust policy, so the operations
+// depending on it only use trusted images.
+type Verify struct {
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+
+	image    *Image
+	registry *Registry
+	key      string
+	identity string
+	issuer   string
+	rekorURL string
+	policy   string // notation trust policy directory
+	timeout  time.Duration
+
+	// Results populated after execution
+	signatures []cosign.Signature
+}
+
+// VerifyBuilder builds a Verify.
+type VerifyBuilder struct {
+	builderState
+
+	plan   *Plan
+	verify *Verify
+}
+
+// Image sets the image to verify.
+// The image must have a digest specified, or be the output of another operation (see Sync.OutputImage):
+// signatures are bound to digests, a tag could be moved to unsigned content once verified.
+// Registry credentials are looked up from the plan's registry collection using the image domain.
+// If no registry is found, anonymous access will be used.
+func (builder *VerifyBuilder) Image(image *Image) *VerifyBuilder {
+	builder.verify.image = image
+	builder.verify.consume(image)
+	builder.verify.registry = builder.plan.getRegistry(image.Domain())
+
+	return builder
+}
+
+// CosignKey verifies signatures with a public key: a file path, URL or KMS URI (e.g., "cosign.pub",
+// "awskms:///alias/signing"). Mutually exclusive with CosignIdentity.
+func (builder *VerifyBuilder) CosignKey(key string) *VerifyBuilder {
+	builder.verify.key = key
+
+	return builder
+}
+
+// CosignIdentity verifies keyless signatures: the signing certificate must be issued to identity by the OIDC
+// issuer (e.g., a release workflow URL and "https://token.actions.githubusercontent.com").
+// Mutually exclusive with CosignKey.
+func (builder *VerifyBuilder) CosignIdentity(identity, issuer string) *VerifyBuilder {
+	builder.verify.identity = identity
+	builder.verify.issuer = issuer
+
+	return builder
+}
+
+// NotationTrustPolicy verifies notation (Notary Project) signatures against the trust policy in dir, laid out like
+// the notation configuration directory: trustpolicy.json (or trustpolicy.oci.json) and truststore/ with the
+// trusted certificates. The user notation configuration is not read.
+// Mutually exclusive with CosignKey and CosignIdentity.
+func (builder *VerifyBuilder) NotationTrustPolicy(dir string) *VerifyBuilder {
+	builder.verify.policy = dir
+
+	return builder
+}
+
+// RekorURL sets the transparency log signatures are checked against (e.g., a private Rekor instance).
+// If not set, the public Sigstore instance is used.
+func (builder *VerifyBuilder) RekorURL(rekorURL string) *VerifyBuilder {
+	builder.verify.rekorURL = rekorURL
+
+	return builder
+}
+
+// Timeout sets the operation timeout.
+// If not set, the operation will use the context timeout from Plan.Execute().
+func (builder *VerifyBuilder) Timeout(duration time.Duration) *VerifyBuilder {
+	builder.verify.timeout = duration
+
+	return builder
+}
+
+// RunOnlyOn restricts the verification to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *VerifyBuilder) RunOnlyOn(envs ...Environment) *VerifyBuilder {
+	builder.verify.runOnlyOn = append(builder.verify.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the verification mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *VerifyBuilder) Resource(resource Resource) *VerifyBuilder {
+	builder.verify.resource = resource
+
+	return builder
+}
+
+// DependsOn makes the verification start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *VerifyBuilder) DependsOn(ops ...Dependency) *VerifyBuilder {
+	builder.verify.add(ops)
+
+	return builder
+}
+
+// When makes the verification run only if the given conditions all hold once the operations it depends on
+// completed; otherwise it is skipped. A failing condition fails the verification.
+func (builder *VerifyBuilder) When(conditions ...Condition) *VerifyBuilder {
+	builder.verify.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a verification named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *VerifyBuilder) Clone(name string) *VerifyBuilder {
+	clone := builder.plan.Verify(name)
+	clone.verify.envGuard = builder.verify.envGuard.clone()
+	clone.verify.resourceHint = builder.verify.resourceHint
+	clone.verify.dependencyList = builder.verify.dependencyList.clone()
+	clone.verify.conditionList = builder.verify.conditionList.clone()
+	clone.verify.image = builder.verify.image
+	clone.verify.registry = builder.verify.registry
+	clone.verify.key = builder.verify.key
+	clone.verify.identity = builder.verify.identity
+	clone.verify.issuer = builder.verify.issuer
+	clone.verify.rekorURL = builder.verify.rekorURL
+	clone.verify.policy = builder.verify.policy
+	clone.verify.timeout = builder.verify.timeout
+
+	return clone
+}
+
+// Reset makes the builder usable again for a verification named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *VerifyBuilder) Reset(name string) *VerifyBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
+// Build validates and adds the verification to the plan.
+// The builder becomes unusable after Build() is called.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
+func (builder *VerifyBuilder) Build() (*Verify, error) {
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.verify.opName); err != nil {
+		return nil, err
+	}
+
+	verify := builder.verify
+
+	if verify.image == nil {
+		return nil, ErrVerifyImageRequired
+	}
+
+	// Images produced by another operation get their digest when it executes
+	if verify.image.Digest() == "" && verify.image.producer == nil {
+		return nil, fmt.Errorf("%w for image %q", ErrVerifyDigestRequired, verify.image.Name())
+	}
+
+	keyless := verify.identity != "" || verify.issuer != ""
+
+	methods := 0
+
+	for _, set := range []bool{verify.key != "", keyless, verify.policy != ""} {
+		if set {
+			methods++
+		}
+	}
+
+	switch {
+	case methods == 0:
+		return nil, ErrVerifyKeyRequired
+	case methods > 1:
+		return nil, ErrVerifyConflictingKeys
+	case keyless && (verify.identity == "" || verify.issuer == ""):
+		return nil, ErrVerifyIdentityIncomplete
+	case verify.policy != "" && verify.rekorURL != "":
+		return nil, fmt.Errorf("%w: RekorURL applies to cosign signatures", ErrVerifyConflictingKeys)
+	}
+
+	if verify.rekorURL != "" {
+		parsed, err := url.Parse(verify.rekorURL)
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRekorURL, verify.rekorURL)
+		}
+	}
+
+	builder.plan.verifications = append(builder.plan.verifications, verify)
+	builder.plan.addOperation(verify)
+
+	return verify, nil
+}
+
+func (verify *Verify) execute(ctx context.Context) error {
+	// Apply timeout if configured
+	if verify.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, verify.timeout)
+		defer cancel()
+	}
+
+	// Validate digest is present (may have been populated during plan execution)
+	if verify.image.Digest() == "" {
+		return fmt.Errorf("%w for image %q", ErrVerifyDigestRequired, verify.image.Name())
+	}
+
+	imageRef, err := verify.image.digestRef()
+	if err != nil {
+		return fmt.Errorf("failed to build digest reference: %w", err)
+	}
+
+	var signatures []cosign.Signature
+
+	if verify.policy != "" {
+		signatures, err = verify.verifyNotation(ctx, imageRef)
+	} else {
+		signatures, err = verify.verifyCosign(ctx, imageRef)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSignatureVerificationFailed, err)
+	}
+
+	verify.signatures = signatures
+
+	verify.log.Info().
+		Str("image", imageRef).
+		Int("signatures", len(signatures)).
+		Msg("signatures verified")
+
+	return nil
+}
+
+func (verify *Verify) verifyCosign(ctx context.Context, imageRef string) ([]cosign.Signature, error) {
+	opts := cosign.VerifyOptions{
+		Key:      verify.key,
+		Identity: verify.identity,
+		Issuer:   verify.issuer,
+		RekorURL: verify.rekorURL,
+	}
+
+	if verify.registry != nil {
+		opts.RegistryHost = verify.registry.host
+		opts.Username = verify.registry.username
+		opts.Password = verify.registry.password
+	}
+
+	//nolint:wrapcheck // Wrapped by execute
+	return cosign.NewVerifier(verify.log).Verify(ctx, imageRef, opts)
+}
+
+// verifyNotation verifies the notation signatures of imageRef: notation does not list the signatures it
+// verified, the image counts one.
+func (verify *Verify) verifyNotation(ctx context.Context, imageRef string) ([]cosign.Signature, error) {
+	opts := notation.VerifyOptions{TrustPolicy: verify.policy}
+
+	if verify.registry != nil {
+		opts.Username = verify.registry.username
+		opts.Password = verify.registry.password
+	}
+
+	verified, err := notation.NewVerifier(verify.log).Verify(ctx, imageRef, opts)
+	if err != nil {
+		//nolint:wrapcheck // Wrapped by execute
+		return nil, err
+	}
+
+	return []cosign.Signature{{Digest: verified}}, nil
+}
+
+// Signatures returns the number of signatures verified. Only valid after plan execution.
+func (verify *Verify) Signatures() int {
+	return len(verify.signatures)
+}
+
+// Signers returns the certificate identities of the verified keyless signatures, with their issuer
+// (e.g., "https://github.com/org/app/.github/workflows/release.yml@refs/heads/main (https://token.actions...)").
+// Empty for key verifications. Only valid after plan execution.
+func (verify *Verify) Signers() []string {
+	var signers []string
+
+	for _, signature := range verify.signatures {
+		if signature.Identity != "" {
+			signers = append(signers, fmt.Sprintf("%s (%s)", signature.Identity, signature.Issuer))
+		}
+	}
+
+	return signers
+}
+
+// plannedChanges implements dryRunOperation: the image must exist when its digest is known.
+func (verify *Verify) plannedChanges(ctx context.Context) ([]string, error) {
+	imageRef, err := checkImage(ctx, newRegistryClient(verify.registry, verify.log), verify.image)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{"Would verify the signatures of " + imageRef}, nil
+}
+
+// cacheSources implements cacheableOperation: a verification reads the verified image.
+func (verify *Verify) cacheSources() []*Image {
+	return []*Image{verify.image}
+}
+
+// resolveRegistries implements registryOperation.
+func (verify *Verify) resolveRegistries(lookup func(image *Image) *Registry) {
+	verify.registry = lookup(verify.image)
+}
@@ -0,0 +1,195 @@
+package sdk_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: Verify requires a digest-pinned image, and exactly one of a key or a complete keyless identity.
+func TestVerifyBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	image, err := sdk.NewImage("ghcr.io/org/app").Version("1.0.0").Digest(testDigest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	unpinned, err := sdk.NewImage("ghcr.io/org/app").Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	const (
+		identity = "https://github.com/org/app/.github/workflows/release.yml@refs/heads/main"
+		issuer   = "https://token.actions.githubusercontent.com"
+	)
+
+	tests := []struct {
+		name    string
+		build   func(*sdk.Plan) (*sdk.Verify, error)
+		wantErr error
+	}{
+		{
+			name: "key",
+			build: func(plan *sdk.Plan) (*sdk.Verify, error) {
+				return plan.Verify("verify").Image(image).CosignKey("cosign.pub").Build()
+			},
+		},
+		{
+			name: "keyless with private transparency log",
+			build: func(plan *sdk.Plan) (*sdk.Verify, error) {
+				return plan.Verify("verify").Image(image).CosignIdentity(identity, issuer).
+					RekorURL("https://rekor.example.com").Build()
+			},
+		},
+		{
+			name: "notation trust policy",
+			build: func(plan *sdk.Plan) (*sdk.Verify, error) {
+				return plan.Verify("verify").Image(image).NotationTrustPolicy("notation").Build()
+			},
+		},
+		{
+			name: "missing image",
+			build: func(plan *sdk.Plan) (*sdk.Verify, error) {
+				return plan.Verify("verify").CosignKey("cosign.pub").Build()
+			},
+			wantErr: sdk.ErrVerifyImageRequired,
+		},
+		{
+			name: "missing digest",
+			build: func(plan *sdk.Plan) (*sdk.Verify, error) {
+				return plan.Verify("verify").Image(unpinned).CosignKey("cosign.pub").Build()
+			},
+			wantErr: sdk.ErrVerifyDigestRequired,
+		},
+		{
+			name: "missing key",
+			build: func(plan *sdk.Plan) (*sdk.Verify, error) {
+				return plan.Verify("verify").Image(image).Build()
+			},
+			wantErr: sdk.ErrVerifyKeyRequired,
+		},
+		{
+			name: "key and identity",
+			build: func(plan *sdk.Plan) (*sdk.Verify, error) {
+				return plan.Verify("verify").Image(image).CosignKey("cosign.pub").
+					CosignIdentity(identity, issuer).Build()
+			},
+			wantErr: sdk.ErrVerifyConflictingKeys,
+		},
+		{
+			name: "key and trust policy",
+			build: func(plan *sdk.Plan) (*sdk.Verify, error) {
+				return plan.Verify("verify").Image(image).CosignKey("cosign.pub").NotationTrustPolicy("notation").Build()
+			},
+			wantErr: sdk.ErrVerifyConflictingKeys,
+		},
+		{
+			name: "trust policy with Rekor URL",
+			build: func(plan *sdk.Plan) (*sdk.Verify, error) {
+				return plan.Verify("verify").Image(image).NotationTrustPolicy("notation").
+					RekorURL("https://rekor.example.com").Build()
+			},
+			wantErr: sdk.ErrVerifyConflictingKeys,
+		},
+		{
+			name: "identity without issuer",
+			build: func(plan *sdk.Plan) (*sdk.Verify, error) {
+				return plan.Verify("verify").Image(image).CosignIdentity(identity, "").Build()
+			},
+			wantErr: sdk.ErrVerifyIdentityIncomplete,
+		},
+		{
+			name: "invalid Rekor URL",
+			build: func(plan *sdk.Plan) (*sdk.Verify, error) {
+				return plan.Verify("verify").Image(image).CosignKey("cosign.pub").RekorURL("rekor.example.com").Build()
+			},
+			wantErr: sdk.ErrInvalidRekorURL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			plan := sdk.NewPlan(testPlanName)
+			verify, err := tt.build(plan)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Build() unexpected error = %v", err)
+			}
+
+			if verify == nil {
+				t.Error("Build() returned nil verification with nil error")
+			}
+		})
+	}
+}
+
+// INTENTION: An image without the expected signature fails the plan, and verified keyless signers are reported.
+func TestVerify_Execute(t *testing.T) {
+	// cosign is faked in PATH: it accepts the image signed by the release workflow, rejects the others
+	dir := t.TempDir()
+	script := `#!/bin/sh
+for arg; do image="$arg"; done
+case "$image" in
+  */signed@*) echo '[{"critical":{"image":{"docker-manifest-digest":"` + testDigest + `"}},` +
+		`"optional":{"Subject":"release.yml","Issuer":"https://token.actions.githubusercontent.com"}}]' ;;
+  *) echo 'no matching signatures' >&2; exit 1 ;;
+esac
+`
+
+	//nolint:gosec // Test binary must be executable
+	if err := os.WriteFile(filepath.Join(dir, "cosign"), []byte(script), 0o700); err != nil {
+		t.Fatalf("Failed to write fake cosign: %v", err)
+	}
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	signed, err := sdk.NewImage("ghcr.io/org/signed").Version("1.0.0").Digest(testDigest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	unsigned, err := sdk.NewImage("ghcr.io/org/unsigned").Version("1.0.0").Digest(testDigest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+
+	plan := sdk.NewPlan(testPlanName)
+	plan.SkipValidation(true)
+
+	verified, err := plan.Verify("verify-signed").Image(signed).
+		CosignIdentity("release.yml", "https://token.actions.githubusercontent.com").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if _, err := plan.Verify("verify-unsigned").Image(unsigned).CosignKey("cosign.pub").Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	err = plan.Execute(t.Context())
+	if !errors.Is(err, sdk.ErrSignatureVerificationFailed) {
+		t.Fatalf("Execute() error = %v, want error wrapping %v", err, sdk.ErrSignatureVerificationFailed)
+	}
+
+	if verified.Signatures() != 1 ||
+		!slices.Equal(verified.Signers(), []string{"release.yml (https://token.actions.githubusercontent.com)"}) {
+		t.Errorf("Signatures() = %d, Signers() = %v, want the release workflow", verified.Signatures(),
+			verified.Signers())
+	}
+}
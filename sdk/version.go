@@ -0,0 +1,7 @@
+package sdk
+
+// Version is the quark version, sent in the registry User-Agent ("quark/<version>").
+// Release builds override it with -ldflags "-X github.com/farcloser/quark/sdk.Version=<version>".
+//
+//nolint:gochecknoglobals // Set at link time
+var Version = "0.1.0"
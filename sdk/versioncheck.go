@@ -3,18 +3,27 @@ package sdk
 import (
 	"context"
 	"fmt"
+	"time"
 
-	"github.com/rs/zerolog"
-
+	"github.com/farcloser/quark/internal/history"
 	"github.com/farcloser/quark/internal/version"
 )
 
 // VersionCheck represents a version check operation.
 type VersionCheck struct {
-	opName   string
-	image    *Image
-	registry *Registry
-	log      zerolog.Logger
+	operationCore
+	envGuard
+	resourceHint
+	dependencyList
+	conditionList
+	retryPolicy
+
+	image         *Image
+	registry      *Registry
+	variantParser func(tag string) (version, variant string)
+
+	// history is set by executor before execution (nil when Plan.History is unset)
+	history *historyRecorder
 
 	// Results populated after execution
 	currentVersion  string
@@ -26,9 +35,10 @@ type VersionCheck struct {
 
 // VersionCheckBuilder builds a VersionCheck.
 type VersionCheckBuilder struct {
+	builderState
+
 	plan  *Plan
 	check *VersionCheck
-	built bool
 }
 
 // Source sets the source image.
@@ -44,15 +54,99 @@ func (builder *VersionCheckBuilder) Source(image *Image) *VersionCheckBuilder {
 	return builder
 }
 
+// VariantParser replaces the default tag parsing rules (see ParseVersionTag) for images with unconventional
+// tag schemes: parser splits a tag into its version and variant (e.g., "jdk17-alpine-2024" into "2024" and
+// "jdk17-alpine"). The image version and the registry tags are split with it, and only tags of the same
+// variant whose version is made of numbers separated by dots are compared.
+func (builder *VersionCheckBuilder) VariantParser(
+	parser func(tag string) (version, variant string),
+) *VersionCheckBuilder {
+	builder.check.variantParser = parser
+
+	return builder
+}
+
+// RunOnlyOn restricts the version check to the given environments (e.g., sdk.EnvCI):
+// it is skipped when the plan is executed elsewhere.
+func (builder *VersionCheckBuilder) RunOnlyOn(envs ...Environment) *VersionCheckBuilder {
+	builder.check.runOnlyOn = append(builder.check.runOnlyOn, envs...)
+
+	return builder
+}
+
+// Resource declares the resource class the version check mostly uses (default: sdk.ResourceNetwork),
+// for executors bounding how many operations of each class run at once.
+func (builder *VersionCheckBuilder) Resource(resource Resource) *VersionCheckBuilder {
+	builder.check.resource = resource
+
+	return builder
+}
+
+// Retry attempts the version check up to attempts times (including the first) when it fails with a transient error
+// (e.g., a registry or SSH connection failure), waiting backoff before the second attempt and twice as long
+// before each next one. Failures retrying cannot fix (e.g., authentication, missing images, digest mismatches)
+// are not retried. The timeout, if any, applies to each attempt.
+func (builder *VersionCheckBuilder) Retry(attempts int, backoff time.Duration) *VersionCheckBuilder {
+	builder.check.set(attempts, backoff)
+
+	return builder
+}
+
+// DependsOn makes the version check start only once the given operations, built before it in the plan, completed
+// (see Plan.MaxParallelism). It does not run if one of them failed.
+func (builder *VersionCheckBuilder) DependsOn(ops ...Dependency) *VersionCheckBuilder {
+	builder.check.add(ops)
+
+	return builder
+}
+
+// When makes the version check run only if the given conditions all hold once the operations it depends on completed;
+// otherwise it is skipped. A failing condition fails the version check.
+func (builder *VersionCheckBuilder) When(conditions ...Condition) *VersionCheckBuilder {
+	builder.check.require(conditions)
+
+	return builder
+}
+
+// Clone returns a new builder for a version check named name, with the same configuration.
+// It can be called before or after Build(), to define similar operations from one template.
+func (builder *VersionCheckBuilder) Clone(name string) *VersionCheckBuilder {
+	clone := builder.plan.VersionCheck(name)
+	clone.check.envGuard = builder.check.envGuard.clone()
+	clone.check.resourceHint = builder.check.resourceHint
+	clone.check.dependencyList = builder.check.dependencyList.clone()
+	clone.check.conditionList = builder.check.conditionList.clone()
+	clone.check.retryPolicy = builder.check.retryPolicy.clone()
+	clone.check.image = builder.check.image
+	clone.check.registry = builder.check.registry
+	clone.check.variantParser = builder.check.variantParser
+
+	return clone
+}
+
+// Reset makes the builder usable again for a version check named name, keeping its configuration.
+// The result of a previous Build() is not affected by later changes.
+func (builder *VersionCheckBuilder) Reset(name string) *VersionCheckBuilder {
+	*builder = *builder.Clone(name)
+
+	return builder
+}
+
 // Build validates and adds the version check to the plan.
 // The builder becomes unusable after Build() is called.
-// Create a new builder for each operation.
+// Create a new builder for each operation, or derive one with Clone() or Reset().
 func (builder *VersionCheckBuilder) Build() (*VersionCheck, error) {
-	if builder.built {
-		return nil, ErrBuilderAlreadyUsed
+	if err := builder.use(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.plan.checkOperationName(builder.check.opName); err != nil {
+		return nil, err
 	}
 
-	builder.built = true
+	if err := builder.check.validate(); err != nil {
+		return nil, err
+	}
 
 	if builder.check.image == nil {
 		return nil, ErrVersionCheckImageRequired
@@ -63,12 +157,12 @@ func (builder *VersionCheckBuilder) Build() (*VersionCheck, error) {
 	}
 
 	builder.plan.versionChecks = append(builder.plan.versionChecks, builder.check)
-	builder.plan.operations = append(builder.plan.operations, builder.check)
+	builder.plan.addOperation(builder.check)
 
 	return builder.check, nil
 }
 
-func (check *VersionCheck) execute(_ context.Context) error {
+func (check *VersionCheck) execute(ctx context.Context) error {
 	img := check.image
 
 	check.log.Info().
@@ -83,7 +177,14 @@ func (check *VersionCheck) execute(_ context.Context) error {
 		password = check.registry.password
 	}
 
-	checker := version.NewChecker(username, password, check.log)
+	// Current tag digest lookups go through the registry client to share the execution manifest cache
+	// (prefetched for all version checks, see prefetchVersionCheckDigests)
+	client := newRegistryClient(check.registry, check.log)
+
+	checker := version.NewChecker(username, password, check.log).WithRemoteOptions(client.TransportOptions(ctx)...)
+	if check.variantParser != nil {
+		checker.WithVariantParser(check.variantParser)
+	}
 
 	// Use tagRef to query what the tag points to
 	tagReference, err := img.tagRef()
@@ -97,7 +198,7 @@ func (check *VersionCheck) execute(_ context.Context) error {
 			Str("expected_digest", img.Digest()).
 			Msg("verifying current version digest")
 
-		actualDigest, err := checker.GetTagDigest(tagReference)
+		actualDigest, err := client.HeadDigest(ctx, tagReference)
 		if err != nil {
 			return fmt.Errorf("failed to get current version digest: %w", err)
 		}
@@ -123,7 +224,7 @@ func (check *VersionCheck) execute(_ context.Context) error {
 			Msg("current version digest verification passed")
 	} else {
 		// Warn if no digest provided - show actual digest
-		actualDigest, err := checker.GetTagDigest(tagReference)
+		actualDigest, err := client.HeadDigest(ctx, tagReference)
 		if err != nil {
 			check.log.Warn().
 				Err(err).
@@ -163,6 +264,13 @@ func (check *VersionCheck) execute(_ context.Context) error {
 	check.updateAvailable = info.UpdateAvailable
 	check.executed = true
 
+	check.history.record(check.opName, img, history.Record{
+		Kind:            history.KindVersionCheck,
+		LatestVersion:   info.LatestVersion,
+		LatestDigest:    info.LatestDigest,
+		UpdateAvailable: info.UpdateAvailable,
+	})
+
 	if info.UpdateAvailable {
 		check.log.Warn().
 			Str("image", img.Name()).
@@ -203,12 +311,79 @@ func (check *VersionCheck) UpdateAvailable() bool {
 	return check.updateAvailable
 }
 
+// Env returns the latest version and its digest as <prefix>_VERSION and <prefix>_DIGEST variables
+// (e.g., VECTOR_VERSION, VECTOR_DIGEST), to be written with WriteEnv.
+// Only valid after plan execution: returns an empty map if the check was not executed.
+func (check *VersionCheck) Env(prefix string) map[string]string {
+	if !check.executed {
+		return map[string]string{}
+	}
+
+	return imageEnv(prefix, check.latestVersion, check.latestDigest)
+}
+
 // Executed returns whether the version check has been executed.
 func (check *VersionCheck) Executed() bool {
 	return check.executed
 }
 
-// operationName returns the version check operation name (implements operation interface).
-func (check *VersionCheck) operationName() string {
-	return check.opName
+// ParseVersionTag splits a tag into its version and variant with the default rules of version checks:
+// the variant is everything after the first hyphen, and a leading "v" is dropped from the version
+// (e.g., "v2.10.2-distroless-static" gives "2.10.2" and "distroless-static"; "1.27" gives "1.27" and "").
+// Version checks compare the tags of the variant of their image; see VersionCheckBuilder.VariantParser
+// for other tag schemes.
+func ParseVersionTag(tag string) (string, string) {
+	return version.ExtractVariant(tag)
+}
+
+// prefetchVersionCheckDigests resolves the current tag digests of the version checks running in env
+// with concurrent HEAD requests, into the execution cache, so that plans checking many images do not wait
+// for each lookup in turn. Failures are left to the version checks, which resolve their digest again.
+func (plan *Plan) prefetchVersionCheckDigests(ctx context.Context, env Environment) {
+	refs := make(map[*Registry][]string)
+
+	var (
+		registries []*Registry
+		total      int
+	)
+
+	for _, check := range plan.versionChecks {
+		if !check.runsOn(env) {
+			continue
+		}
+
+		tagReference, err := check.image.tagRef()
+		if err != nil {
+			continue
+		}
+
+		if _, ok := refs[check.registry]; !ok {
+			registries = append(registries, check.registry)
+		}
+
+		refs[check.registry] = append(refs[check.registry], tagReference)
+		total++
+	}
+
+	// A single lookup gains nothing from batching
+	if total < 2 {
+		return
+	}
+
+	for _, reg := range registries {
+		results := newRegistryClient(reg, plan.log).GetDigests(ctx, refs[reg], plan.digestConcurrency)
+
+		for _, result := range results {
+			if result.Err != nil {
+				plan.log.Debug().Err(result.Err).Str("image", result.Reference).Msg("digest prefetch failed")
+			}
+		}
+	}
+
+	plan.log.Debug().Int("digests", total).Msg("version check digests prefetched")
+}
+
+// resolveRegistries implements registryOperation.
+func (check *VersionCheck) resolveRegistries(lookup func(image *Image) *Registry) {
+	check.registry = lookup(check.image)
 }
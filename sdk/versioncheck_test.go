@@ -1,9 +1,17 @@
 package sdk_test
 
 import (
+	"context"
 	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+
 	"github.com/farcloser/quark/sdk"
 )
 
@@ -186,3 +194,55 @@ func TestVersionCheck_RegistryLookup(t *testing.T) {
 		t.Error("Build() returned nil check")
 	}
 }
+
+// INTENTION: Version checks of a plan resolve their current digests through the batched prefetch:
+// digest verification still catches mismatches, and matching checks succeed.
+func TestVersionCheck_PrefetchedDigests(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+
+	tests := []struct {
+		name    string
+		digest  func(pushed string) string
+		wantErr error
+	}{
+		{name: "matching digests", digest: func(pushed string) string { return pushed }},
+		{name: "mismatch", digest: func(string) string { return testDigest }, wantErr: sdk.ErrDigestMismatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			plan := sdk.NewPlan(testPlanName)
+			plan.DigestConcurrency(1)
+
+			for _, app := range []string{"api", "web"} {
+				repo := strings.ReplaceAll(test.name, " ", "-") + "/" + app
+				pushed := pushRandomImage(t, host+"/"+repo+":1.0")
+
+				image, err := sdk.NewImage(repo).Domain(host).Version("1.0").Digest(test.digest(pushed)).Build()
+				if err != nil {
+					t.Fatalf("Failed to create image: %v", err)
+				}
+
+				if _, err := plan.VersionCheck("check-" + app).Source(image).Build(); err != nil {
+					t.Fatalf("Build() error = %v", err)
+				}
+			}
+
+			if err := plan.Execute(context.Background()); !errors.Is(err, test.wantErr) {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/farcloser/quark/internal/webhook"
+)
+
+// SyncEvent is the webhook event type of SyncNotification.
+const SyncEvent = "sync"
+
+// SyncNotification is the JSON payload POSTed to the sync webhook after each successful sync (see Plan.SyncWebhook).
+type SyncNotification struct {
+	Event     string `json:"event"` // Always SyncEvent
+	Plan      string `json:"plan"`
+	Operation string `json:"operation"`
+	// Source is the source image reference, by digest.
+	Source string `json:"source"`
+	// Destination is the destination image reference, by tag (the digest tag when the sync fell back to it).
+	Destination string `json:"destination"`
+	// Digest is the destination digest.
+	Digest string `json:"digest"`
+	// Platforms are the synced platforms of multi-platform images (empty when all were synced).
+	Platforms []string  `json:"platforms,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// SyncWebhook configures every sync of the plan to POST a SyncNotification to endpoint once it succeeded,
+// so downstream systems (deploy bots, CMDBs) learn about newly mirrored digests without polling.
+// When secret is not empty, requests carry its HMAC-SHA256 of the X-Quark-Timestamp header and the body in the
+// X-Quark-Signature header ("sha256=<hex>"). Failed deliveries are retried, then logged and reported: they do not
+// fail the sync.
+func (plan *Plan) SyncWebhook(endpoint, secret string) error {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidWebhook, endpoint)
+	}
+
+	plan.syncWebhook = &webhook.Sender{URL: endpoint, Secret: secret}
+
+	return nil
+}
+
+// notify delivers the notification of a successful sync to the plan webhook, if any.
+// Failures are recorded for the report, not returned: the image was synced.
+func (sync *Sync) notify(ctx context.Context, sourceRef, destRef string) {
+	sync.notifyError = nil
+
+	if sync.webhook == nil {
+		return
+	}
+
+	platforms := make([]string, 0, len(sync.platforms))
+	for _, platform := range sync.platforms {
+		platforms = append(platforms, platform.String())
+	}
+
+	notification := SyncNotification{
+		Event:       SyncEvent,
+		Plan:        sync.planName,
+		Operation:   sync.opName,
+		Source:      sourceRef,
+		Destination: destRef,
+		Digest:      sync.destDigest,
+		Platforms:   platforms,
+		Time:        time.Now().UTC(),
+	}
+
+	if err := sync.webhook.Send(ctx, SyncEvent, notification); err != nil {
+		sync.notifyError = err
+		sync.log.Warn().Err(err).Str("destination", destRef).Msg("failed to notify sync webhook")
+
+		return
+	}
+
+	sync.log.Debug().Str("destination", destRef).Msg("sync webhook notified")
+}
+
+// NotifyError returns the failure to deliver the sync notification to the plan webhook (see Plan.SyncWebhook).
+// Returns nil if the notification was delivered, no webhook is configured, or the sync has not been executed yet.
+func (sync *Sync) NotifyError() error {
+	return sync.notifyError
+}
@@ -0,0 +1,105 @@
+package sdk_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/farcloser/quark/internal/webhook"
+	"github.com/farcloser/quark/sdk"
+)
+
+// INTENTION: After a successful sync, the webhook receives the signed source, destination and digest,
+// so downstream systems learn about the mirrored digest without polling.
+func TestPlan_SyncWebhook(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	host := serverURL.Host
+	digest := pushRandomImage(t, host+"/source/app:1.0.0")
+
+	var notifications []sdk.SyncNotification
+
+	endpoint := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		signature := webhook.Sign("s3cret", req.Header.Get(webhook.TimestampHeader), body)
+		if req.Header.Get(webhook.SignatureHeader) != signature {
+			writer.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		var notification sdk.SyncNotification
+		if err := json.Unmarshal(body, &notification); err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		notifications = append(notifications, notification)
+	}))
+	t.Cleanup(endpoint.Close)
+
+	plan := sdk.NewPlan(testPlanName)
+	if err := plan.SyncWebhook(endpoint.URL+"/hooks/quark", "s3cret"); err != nil {
+		t.Fatalf("SyncWebhook() error = %v", err)
+	}
+
+	source, err := sdk.NewImage("source/app").Domain(host).Version("1.0.0").Digest(digest).Build()
+	if err != nil {
+		t.Fatalf("Failed to create source image: %v", err)
+	}
+
+	destination, err := sdk.NewImage("mirror/app").Domain(host).Version("1.0.0").Build()
+	if err != nil {
+		t.Fatalf("Failed to create destination image: %v", err)
+	}
+
+	sync, err := plan.Sync("mirror").Source(source).Destination(destination).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if err := plan.Execute(t.Context()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if sync.NotifyError() != nil {
+		t.Fatalf("NotifyError() = %v", sync.NotifyError())
+	}
+
+	if len(notifications) != 1 {
+		t.Fatalf("notifications = %+v, want one", notifications)
+	}
+
+	got := notifications[0]
+	if got.Event != sdk.SyncEvent || got.Plan != testPlanName || got.Operation != "mirror" ||
+		got.Destination != host+"/mirror/app:1.0.0" || got.Digest != sync.DestDigest() {
+		t.Errorf("notification = %+v, want the sync of mirror to %s/mirror/app:1.0.0", got, host)
+	}
+}
+
+// INTENTION: Only http(s) endpoints are accepted, so a typo fails when the plan is declared.
+func TestPlan_SyncWebhook_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, endpoint := range []string{"", "hooks.example.com/quark", "ftp://hooks.example.com"} {
+		if err := sdk.NewPlan(testPlanName).SyncWebhook(endpoint, ""); !errors.Is(err, sdk.ErrInvalidWebhook) {
+			t.Errorf("SyncWebhook(%q) error = %v, want ErrInvalidWebhook", endpoint, err)
+		}
+	}
+}
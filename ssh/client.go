@@ -2,6 +2,7 @@
 package ssh
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -19,7 +20,10 @@ import (
 	"golang.org/x/crypto/ssh/agent"
 	"golang.org/x/crypto/ssh/knownhosts"
 
+	"github.com/rs/zerolog"
+
 	"github.com/farcloser/quark/filesystem"
+	"github.com/farcloser/quark/internal/subprocess"
 )
 
 var (
@@ -43,6 +47,7 @@ type Connection interface {
 	ExecuteStreaming(command string, stdout, stderr io.Writer) error
 	UploadFile(localPath, remotePath string) error
 	UploadData(data []byte, remotePath string) error
+	UploadStream(reader io.Reader, remotePath string) error
 }
 
 // client represents an SSH client with connection pooling.
@@ -57,7 +62,9 @@ type client struct {
 	agentConn          net.Conn
 	sshFingerprint     string
 	sshKeyContent      string
-	identityFilePubKey ssh.PublicKey // Public key from IdentityFile (for agent filtering)
+	forwardAgent       bool
+	identityFilePubKey ssh.PublicKey   // Public key from IdentityFile (for agent filtering)
+	echo               *zerolog.Logger // Logs commands when set (Pool.EchoCommands)
 	mu                 sync.Mutex
 }
 
@@ -66,11 +73,13 @@ type client struct {
 // Connection parameters (User, Port, Hostname) are resolved from ~/.ssh/config.
 // If fingerprint is provided, it will be used for host key verification instead of ~/.ssh/known_hosts.
 // If keyContent is provided, it will be used for authentication instead of SSH agent.
-func newClient(endpoint, fingerprint, keyContent string) *client {
+// If forwardAgent is set, the local SSH agent is forwarded to every remote command session.
+func newClient(endpoint, fingerprint, keyContent string, forwardAgent bool) *client {
 	return &client{
 		endpoint:       endpoint,
 		sshFingerprint: fingerprint,
 		sshKeyContent:  keyContent,
+		forwardAgent:   forwardAgent,
 	}
 }
 
@@ -126,6 +135,15 @@ func (c *client) connect() error {
 
 	c.sshClient = client
 
+	if c.forwardAgent {
+		if err := c.setupAgentForwarding(); err != nil {
+			_ = client.Close()
+			c.sshClient = nil
+
+			return err
+		}
+	}
+
 	// Initialize SFTP client for file operations
 	sftpClient, err := sftp.NewClient(client)
 	if err != nil {
@@ -140,6 +158,39 @@ func (c *client) connect() error {
 	return nil
 }
 
+// setupAgentForwarding serves agent channels opened by the remote host from the local SSH agent.
+// Sessions still have to request forwarding (see newSession).
+func (c *client) setupAgentForwarding() error {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return fmt.Errorf("%w: agent forwarding requested", errNoSSHAgent)
+	}
+
+	if err := agent.ForwardToRemote(c.sshClient, socket); err != nil {
+		return fmt.Errorf("failed to set up agent forwarding: %w", err)
+	}
+
+	return nil
+}
+
+// newSession opens a session, requesting agent forwarding if enabled.
+func (c *client) newSession() (*ssh.Session, error) {
+	session, err := c.sshClient.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	if c.forwardAgent {
+		if err := agent.RequestAgentForwarding(session); err != nil {
+			_ = session.Close()
+
+			return nil, fmt.Errorf("failed to request agent forwarding (is AllowAgentForwarding enabled?): %w", err)
+		}
+	}
+
+	return session, nil
+}
+
 // resolveConfig resolves SSH connection parameters from ~/.ssh/config.
 func (c *client) resolveConfig() error {
 	endpointUser, endpointHost := c.parseEndpoint()
@@ -325,14 +376,18 @@ func (c *client) close() error {
 
 // Execute runs a command on the remote host and returns stdout, stderr, and error.
 func (c *client) Execute(command string) (stdout, stderr string, err error) {
+	if c.echo != nil {
+		c.echo.Debug().Str("command", subprocess.Redact(command)).Msg("remote command")
+	}
+
 	if c.sshClient == nil {
 		return "", "", errNotConnected
 	}
 
 	// Create a new session for this command
-	session, err := c.sshClient.NewSession()
+	session, err := c.newSession()
 	if err != nil {
-		return "", "", fmt.Errorf("failed to create session: %w", err)
+		return "", "", err
 	}
 
 	defer func() { _ = session.Close() }()
@@ -371,14 +426,18 @@ func (c *client) Execute(command string) (stdout, stderr string, err error) {
 
 // ExecuteStreaming runs a command on the remote host and streams stdout/stderr to the provided writers.
 func (c *client) ExecuteStreaming(command string, stdout, stderr io.Writer) error {
+	if c.echo != nil {
+		c.echo.Debug().Str("command", subprocess.Redact(command)).Msg("remote command")
+	}
+
 	if c.sshClient == nil {
 		return errNotConnected
 	}
 
 	// Create a new session for this command
-	session, err := c.sshClient.NewSession()
+	session, err := c.newSession()
 	if err != nil {
-		return fmt.Errorf("failed to create session: %w", err)
+		return err
 	}
 
 	defer func() { _ = session.Close() }()
@@ -639,6 +698,12 @@ func (c *client) UploadFile(localPath, remotePath string) error {
 // UploadData uploads raw data as a file to the remote host.
 // Data is uploaded directly without creating a local temporary file.
 func (c *client) UploadData(data []byte, remotePath string) error {
+	return c.UploadStream(bytes.NewReader(data), remotePath)
+}
+
+// UploadStream uploads the content of reader as a file to the remote host.
+// Content is copied in chunks, so large payloads are uploaded with constant memory.
+func (c *client) UploadStream(reader io.Reader, remotePath string) error {
 	if c.sshClient == nil {
 		return errNotConnected
 	}
@@ -649,8 +714,8 @@ func (c *client) UploadData(data []byte, remotePath string) error {
 		return fmt.Errorf("failed to create remote file: %w", err)
 	}
 
-	// Write data
-	if _, err := remoteFile.Write(data); err != nil {
+	// Copy content
+	if _, err := io.Copy(remoteFile, reader); err != nil {
 		_ = remoteFile.Close()
 
 		return fmt.Errorf("failed to write file content: %w", err)
@@ -11,12 +11,16 @@ import (
 
 var errClosingConnections = errors.New("errors closing connections")
 
+// agentForwardingKeySuffix distinguishes agent forwarding connections in the pool.
+const agentForwardingKeySuffix = " (agent forwarding)"
+
 // Pool manages SSH connections to multiple hosts.
 // It ensures one connection per unique host and reuses connections.
 type Pool struct {
 	clients map[string]*client
 	mu      sync.RWMutex
 	logger  zerolog.Logger
+	echo    bool
 }
 
 // NewPool creates a new SSH connection pool.
@@ -27,6 +31,15 @@ func NewPool(logger zerolog.Logger) *Pool {
 	}
 }
 
+// EchoCommands logs every command run on connections created afterwards at debug level,
+// with secrets redacted, so failures can be reproduced manually.
+func (p *Pool) EchoCommands(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.echo = enabled
+}
+
 // GetClient returns a Connection for the given endpoint, creating and connecting if needed.
 // The endpoint can be an IP address, hostname, or SSH config alias.
 // Connection parameters are resolved from ~/.ssh/config.
@@ -45,8 +58,24 @@ func (p *Pool) GetClientWithFingerprint(endpoint, fingerprint string) (Connectio
 // If keyContent is provided, it will be used for authentication instead of SSH agent.
 // If fingerprint is provided, it will be used for host key verification instead of ~/.ssh/known_hosts.
 func (p *Pool) GetClientWithKey(endpoint, fingerprint, keyContent string) (Connection, error) {
+	return p.getClient(endpoint, fingerprint, keyContent, false)
+}
+
+// GetClientWithAgentForwarding returns a Connection for the given endpoint that forwards the local SSH agent
+// (SSH_AUTH_SOCK) to every remote command, like `ssh -A`.
+// SECURITY: anyone with root access on the remote host can use the forwarded agent to authenticate as you
+// for as long as the connection is open. Only forward the agent to trusted hosts.
+// Forwarding connections are pooled separately from regular connections to the same endpoint.
+func (p *Pool) GetClientWithAgentForwarding(endpoint string) (Connection, error) {
+	return p.getClient(endpoint, "", "", true)
+}
+
+func (p *Pool) getClient(endpoint, fingerprint, keyContent string, forwardAgent bool) (Connection, error) {
 	// Use endpoint as key since SSH config will resolve the actual connection params
 	key := endpoint
+	if forwardAgent {
+		key += agentForwardingKeySuffix
+	}
 
 	// Check if client already exists
 	p.mu.RLock()
@@ -68,7 +97,12 @@ func (p *Pool) GetClientWithKey(endpoint, fingerprint, keyContent string) (Conne
 
 	p.logger.Debug().Str("endpoint", key).Msg("Creating new SSH connection")
 
-	client := newClient(endpoint, fingerprint, keyContent)
+	client := newClient(endpoint, fingerprint, keyContent, forwardAgent)
+	if p.echo {
+		echo := p.logger.With().Str("endpoint", key).Logger()
+		client.echo = &echo
+	}
+
 	if err := client.connect(); err != nil {
 		return nil, fmt.Errorf("failed to connect to %s: %w", key, err)
 	}
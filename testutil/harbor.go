@@ -0,0 +1,219 @@
+package testutil
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// FakeHarbor is an in-memory Harbor API (projects, retention policies, immutability rules and robot accounts),
+// accepting one administrator.
+type FakeHarbor struct {
+	// URL is the root of the fake instance (e.g., "http://127.0.0.1:12345").
+	URL string
+
+	mu       sync.Mutex
+	username string
+	password string
+	nextID   int64
+
+	// Projects by name, with their metadata (e.g., "public", "retention_id")
+	Projects map[string]map[string]string
+	// Retention policies by ID
+	Retentions map[string]map[string]any
+	// Immutable tag patterns by project
+	ImmutableTags map[string][]string
+	// Robots by full name (e.g., "robot$mirror+sync"), with their secret
+	Robots map[string]string
+
+	projectIDs map[string]int64
+	robotIDs   map[int64]string
+}
+
+// NewFakeHarbor starts a fake Harbor instance accepting username and password, stopped at the end of the test.
+func NewFakeHarbor(t *testing.T, username, password string) *FakeHarbor {
+	t.Helper()
+
+	harbor := &FakeHarbor{
+		username:      username,
+		password:      password,
+		nextID:        1,
+		Projects:      map[string]map[string]string{},
+		Retentions:    map[string]map[string]any{},
+		ImmutableTags: map[string][]string{},
+		Robots:        map[string]string{},
+		projectIDs:    map[string]int64{},
+		robotIDs:      map[int64]string{},
+	}
+
+	server := httptest.NewServer(harbor)
+	t.Cleanup(server.Close)
+
+	harbor.URL = server.URL
+
+	return harbor
+}
+
+// Snapshot calls inspect with the state of the instance locked.
+func (harbor *FakeHarbor) Snapshot(inspect func(harbor *FakeHarbor)) {
+	harbor.mu.Lock()
+	defer harbor.mu.Unlock()
+
+	inspect(harbor)
+}
+
+// ServeHTTP implements http.Handler.
+//
+//nolint:cyclop,gocognit,funlen // A routing table of the fake endpoints
+func (harbor *FakeHarbor) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	harbor.mu.Lock()
+	defer harbor.mu.Unlock()
+
+	if username, password, ok := req.BasicAuth(); !ok || username != harbor.username || password != harbor.password {
+		writer.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	var payload map[string]any
+	if req.Body != nil {
+		_ = json.NewDecoder(req.Body).Decode(&payload)
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, "/api/v2.0")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	switch {
+	case path == "/users/current" && req.Method == http.MethodGet:
+		harbor.reply(writer, map[string]string{"username": harbor.username})
+	case path == "/projects" && req.Method == http.MethodPost:
+		name, _ := payload["project_name"].(string)
+		harbor.projectIDs[name] = harbor.id()
+		harbor.Projects[name] = metadata(payload)
+		writer.WriteHeader(http.StatusCreated)
+	case len(segments) == 2 && segments[0] == "projects":
+		meta, ok := harbor.Projects[segments[1]]
+		if !ok {
+			writer.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		if req.Method == http.MethodPut {
+			for key, value := range metadata(payload) {
+				meta[key] = value
+			}
+
+			return
+		}
+
+		harbor.reply(writer, map[string]any{
+			"project_id": harbor.projectIDs[segments[1]],
+			"name":       segments[1],
+			"metadata":   meta,
+		})
+	case len(segments) == 3 && segments[0] == "projects" && segments[2] == "immutabletagrules":
+		if req.Method == http.MethodPost {
+			selectors, _ := payload["tag_selectors"].([]any)
+			for _, entry := range selectors {
+				selector, _ := entry.(map[string]any)
+				pattern, _ := selector["pattern"].(string)
+				harbor.ImmutableTags[segments[1]] = append(harbor.ImmutableTags[segments[1]], pattern)
+			}
+
+			writer.WriteHeader(http.StatusCreated)
+
+			return
+		}
+
+		rules := []map[string]any{}
+		for _, pattern := range harbor.ImmutableTags[segments[1]] {
+			rules = append(rules, map[string]any{"tag_selectors": []map[string]string{{"pattern": pattern}}})
+		}
+
+		harbor.reply(writer, rules)
+	case path == "/retentions" && req.Method == http.MethodPost:
+		id := strconv.FormatInt(harbor.id(), 10)
+		harbor.Retentions[id] = payload
+
+		scope, _ := payload["scope"].(map[string]any)
+		ref, _ := scope["ref"].(float64)
+
+		for name, projectID := range harbor.projectIDs {
+			if projectID == int64(ref) {
+				harbor.Projects[name]["retention_id"] = id
+			}
+		}
+
+		writer.WriteHeader(http.StatusCreated)
+	case len(segments) == 2 && segments[0] == "retentions" && req.Method == http.MethodPut:
+		if _, ok := harbor.Retentions[segments[1]]; !ok {
+			writer.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		harbor.Retentions[segments[1]] = payload
+	case path == "/robots" && req.Method == http.MethodGet:
+		robots := []map[string]any{}
+		for id, name := range harbor.robotIDs {
+			robots = append(robots, map[string]any{"id": id, "name": name})
+		}
+
+		harbor.reply(writer, robots)
+	case path == "/robots" && req.Method == http.MethodPost:
+		permissions, _ := payload["permissions"].([]any)
+		permission, _ := permissions[0].(map[string]any)
+		namespace, _ := permission["namespace"].(string)
+		name, _ := payload["name"].(string)
+
+		id := harbor.id()
+		fullName := "robot$" + namespace + "+" + name
+		harbor.robotIDs[id] = fullName
+		harbor.Robots[fullName] = "generated-" + strconv.FormatInt(id, 10)
+
+		writer.WriteHeader(http.StatusCreated)
+		harbor.reply(writer, map[string]any{"id": id, "name": fullName, "secret": harbor.Robots[fullName]})
+	case len(segments) == 2 && segments[0] == "robots" && req.Method == http.MethodPatch:
+		id, _ := strconv.ParseInt(segments[1], 10, 64)
+
+		name, ok := harbor.robotIDs[id]
+		if !ok {
+			writer.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		harbor.Robots[name], _ = payload["secret"].(string)
+	default:
+		writer.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (harbor *FakeHarbor) id() int64 {
+	id := harbor.nextID
+	harbor.nextID++
+
+	return id
+}
+
+func (harbor *FakeHarbor) reply(writer http.ResponseWriter, body any) {
+	writer.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(writer).Encode(body)
+}
+
+// metadata returns the project metadata of a project creation or update payload.
+func metadata(payload map[string]any) map[string]string {
+	meta := map[string]string{}
+
+	values, _ := payload["metadata"].(map[string]any)
+	for key, value := range values {
+		meta[key], _ = value.(string)
+	}
+
+	return meta
+}